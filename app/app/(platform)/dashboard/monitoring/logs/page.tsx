@@ -41,7 +41,7 @@ import {
 import { Separator } from "@/components/ui/separator";
 import { cn } from "@/lib/utils";
 import { logsApi, type LogsParams } from "@/lib/api/client";
-import type { LogEntry, LogLevel, LogEvent, LogStats } from "@/lib/api/types";
+import type { LogEntry, LogLevel, LogEvent, LogRecord, LogStats } from "@/lib/api/types";
 
 const levelConfig = {
   info: { label: "Info", color: "bg-blue-500", text: "text-blue-600", bg: "bg-blue-50" },
@@ -64,6 +64,27 @@ const eventConfig = {
   api_call: { icon: CheckCircle2, label: "API Call" },
 };
 
+// toLogEntry adapts a server log to the row displayed in the table
+function toLogEntry(record: LogRecord): LogEntry {
+  const level: LogLevel =
+    record.level === "warn" ? "warning" : record.level === "debug" ? "info" : record.level;
+  return {
+    id: record.id,
+    timestamp: record.createdAt,
+    level,
+    event: record.event as LogEvent,
+    user: record.userId || "",
+    email: record.userEmail || "",
+    ip: record.ipAddress || "",
+    userAgent: record.userAgent,
+    connection: record.connection || "",
+    details: record.details || record.message,
+  };
+}
+
+// The server stores warnings as "warn" and has no "success" level
+const serverLevel = (level: string) => (level === "warning" ? "warn" : level);
+
 function formatTimestamp(dateStr: string): string {
   const date = new Date(dateStr);
   const now = new Date();
@@ -88,11 +109,13 @@ export default function LogsPage() {
   const [eventFilter, setEventFilter] = useState<string>("all");
   const [expandedLog, setExpandedLog] = useState<string | null>(null);
   const [currentPage, setCurrentPage] = useState(1);
+  // cursors[i] is the cursor of page i + 1; the first page has none
+  const [cursors, setCursors] = useState<string[]>([""]);
+  const [nextCursor, setNextCursor] = useState<string | null>(null);
   const [logs, setLogs] = useState<LogEntry[]>([]);
   const [stats, setStats] = useState<LogStats | null>(null);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState<string | null>(null);
-  const [total, setTotal] = useState(0);
 
   const logsPerPage = 15;
 
@@ -101,19 +124,15 @@ export default function LogsPage() {
     setError(null);
     try {
       const params: LogsParams = {
-        page: currentPage,
-        pageSize: logsPerPage,
+        limit: logsPerPage,
+        cursor: cursors[currentPage - 1] || undefined,
         search: search || undefined,
-        level: levelFilter !== "all" ? levelFilter : undefined,
+        level: levelFilter !== "all" ? serverLevel(levelFilter) : undefined,
         event: eventFilter !== "all" ? eventFilter : undefined,
       };
       const response = await logsApi.list(params);
-      if (response.success && response.data) {
-        setLogs(response.data);
-        setTotal(response.total || 0);
-      } else {
-        setError(response.error || "Failed to fetch logs");
-      }
+      setLogs(response.logs.map(toLogEntry));
+      setNextCursor(response.nextCursor);
     } catch (err) {
       setError(err instanceof Error ? err.message : "An error occurred");
     } finally {
@@ -134,34 +153,41 @@ export default function LogsPage() {
 
   useEffect(() => {
     fetchLogs();
-  }, [currentPage, levelFilter, eventFilter]);
+  }, [currentPage, cursors, levelFilter, eventFilter]);
+
+  const resetPagination = () => {
+    setCursors([""]);
+    setCurrentPage(1);
+  };
+
+  const goToNextPage = () => {
+    if (!nextCursor) return;
+    setCursors((c) => [...c.slice(0, currentPage), nextCursor]);
+    setCurrentPage((p) => p + 1);
+  };
 
   useEffect(() => {
     fetchStats();
   }, []);
 
+  // A new cursor list triggers the fetch, from the first page
   const handleSearch = () => {
-    setCurrentPage(1);
-    fetchLogs();
+    resetPagination();
   };
 
   const handleExport = async () => {
     try {
-      const response = await logsApi.export({
-        level: levelFilter !== "all" ? levelFilter : undefined,
+      const blob = await logsApi.export({
+        search: search || undefined,
+        level: levelFilter !== "all" ? serverLevel(levelFilter) : undefined,
         event: eventFilter !== "all" ? eventFilter : undefined,
       });
-      if (response.success && response.data) {
-        const blob = new Blob([JSON.stringify(response.data, null, 2)], {
-          type: "application/json",
-        });
-        const url = URL.createObjectURL(blob);
-        const a = document.createElement("a");
-        a.href = url;
-        a.download = `logs-${new Date().toISOString()}.json`;
-        a.click();
-        URL.revokeObjectURL(url);
-      }
+      const url = URL.createObjectURL(blob);
+      const a = document.createElement("a");
+      a.href = url;
+      a.download = `logs-${new Date().toISOString()}.ndjson`;
+      a.click();
+      URL.revokeObjectURL(url);
     } catch (err) {
       console.error("Export failed:", err);
     }
@@ -297,19 +323,30 @@ export default function LogsPage() {
                   />
                 </div>
                 <div className="flex items-center gap-2">
-                  <Select value={levelFilter} onValueChange={setLevelFilter}>
+                  <Select
+                    value={levelFilter}
+                    onValueChange={(value) => {
+                      resetPagination();
+                      setLevelFilter(value);
+                    }}
+                  >
                     <SelectTrigger className="w-32">
                       <SelectValue placeholder="Level" />
                     </SelectTrigger>
                     <SelectContent>
                       <SelectItem value="all">All Levels</SelectItem>
                       <SelectItem value="info">Info</SelectItem>
-                      <SelectItem value="success">Success</SelectItem>
                       <SelectItem value="warning">Warning</SelectItem>
                       <SelectItem value="error">Error</SelectItem>
                     </SelectContent>
                   </Select>
-                  <Select value={eventFilter} onValueChange={setEventFilter}>
+                  <Select
+                    value={eventFilter}
+                    onValueChange={(value) => {
+                      resetPagination();
+                      setEventFilter(value);
+                    }}
+                  >
                     <SelectTrigger className="w-36">
                       <SelectValue placeholder="Event Type" />
                     </SelectTrigger>
@@ -485,8 +522,7 @@ export default function LogsPage() {
 
             <div className="flex items-center justify-between mt-4">
               <p className="text-sm text-muted-foreground">
-                Showing {(currentPage - 1) * logsPerPage + 1} to{" "}
-                {Math.min(currentPage * logsPerPage, total)} of {total} entries
+                Page {currentPage} · {logs.length} entries
               </p>
               <div className="flex items-center gap-2">
                 <Button
@@ -497,27 +533,11 @@ export default function LogsPage() {
                 >
                   Previous
                 </Button>
-                {Array.from({ length: Math.min(5, Math.ceil(total / logsPerPage)) }, (_, i) => {
-                  const page = i + 1;
-                  return (
-                    <Button
-                      key={page}
-                      variant={currentPage === page ? "default" : "outline"}
-                      size="sm"
-                      onClick={() => setCurrentPage(page)}
-                      disabled={loading}
-                    >
-                      {page}
-                    </Button>
-                  );
-                })}
                 <Button
                   variant="outline"
                   size="sm"
-                  onClick={() =>
-                    setCurrentPage((p) => Math.min(Math.ceil(total / logsPerPage), p + 1))
-                  }
-                  disabled={currentPage >= Math.ceil(total / logsPerPage) || loading}
+                  onClick={goToNextPage}
+                  disabled={!nextCursor || loading}
                 >
                   Next
                 </Button>
@@ -536,7 +556,7 @@ export default function LogsPage() {
               <div className="space-y-4">
                 {Object.entries(eventConfig).map(([key, config]) => {
                   const count = logs.filter((l) => l.event === key).length;
-                  const percentage = total > 0 ? (count / total) * 100 : 0;
+                  const percentage = logs.length > 0 ? (count / logs.length) * 100 : 0;
                   return (
                     <div key={key} className="flex items-center justify-between">
                       <div className="flex items-center gap-3">
@@ -570,7 +590,7 @@ export default function LogsPage() {
               <div className="space-y-4">
                 {Object.entries(levelConfig).map(([key, config]) => {
                   const count = logs.filter((l) => l.level === key).length;
-                  const percentage = total > 0 ? (count / total) * 100 : 0;
+                  const percentage = logs.length > 0 ? (count / logs.length) * 100 : 0;
                   return (
                     <div key={key} className="flex items-center justify-between">
                       <div className="flex items-center gap-3">
@@ -77,6 +77,33 @@ class ApiClient {
     });
   }
 
+  // getBlob downloads a non-JSON response (e.g. a streamed export) with the same authentication
+  async getBlob(endpoint: string, options: RequestOptions = {}): Promise<Blob> {
+    const { params, ...fetchOptions } = options;
+
+    let url = `${this.baseURL}${endpoint}`;
+    if (params) {
+      url += `?${new URLSearchParams(params).toString()}`;
+    }
+
+    const token = typeof window !== "undefined" ? localStorage.getItem("accessToken") : null;
+    const response = await fetch(url, {
+      ...fetchOptions,
+      method: "GET",
+      headers: {
+        ...(token && { Authorization: `Bearer ${token}` }),
+        ...fetchOptions.headers,
+      },
+    });
+
+    if (!response.ok) {
+      const data = await response.json().catch(() => ({}));
+      throw new Error(data.error || `Request failed with status ${response.status}`);
+    }
+
+    return response.blob();
+  }
+
   async delete<T>(endpoint: string, options?: RequestOptions): Promise<T> {
     return this.request<T>(endpoint, { ...options, method: "DELETE" });
   }
@@ -1028,6 +1055,7 @@ export const connectionsApi = {
 };
 
 import type {
+  LogRecord,
   LogsResponse,
   LogsStatsResponse,
   ActionLogsResponse,
@@ -1046,11 +1074,11 @@ import type {
 // ==================== LOGS API ====================
 
 export type LogsParams = {
-  page?: number;
-  pageSize?: number;
+  limit?: number;
+  cursor?: string;
   level?: string;
   event?: string;
-  user?: string;
+  userId?: string;
   connection?: string;
   ip?: string;
   search?: string;
@@ -1058,37 +1086,35 @@ export type LogsParams = {
   to?: string;
 };
 
+export type LogsExportFormat = "ndjson" | "csv" | "cef";
+
+const logsQueryParams = (params?: LogsParams): Record<string, string> => {
+  const queryParams: Record<string, string> = {};
+  if (params?.limit) queryParams.limit = String(params.limit);
+  if (params?.cursor) queryParams.cursor = params.cursor;
+  if (params?.level) queryParams.level = params.level;
+  if (params?.event) queryParams.event = params.event;
+  if (params?.userId) queryParams.user_id = params.userId;
+  if (params?.connection) queryParams.connection = params.connection;
+  if (params?.ip) queryParams.ip = params.ip;
+  if (params?.search) queryParams.q = params.search;
+  if (params?.from) queryParams.from = params.from;
+  if (params?.to) queryParams.to = params.to;
+  return queryParams;
+};
+
 export const logsApi = {
-  list: (params?: LogsParams) => {
-    const queryParams: Record<string, string> = {};
-    if (params?.page) queryParams.page = String(params.page);
-    if (params?.pageSize) queryParams.pageSize = String(params.pageSize);
-    if (params?.level) queryParams.level = params.level;
-    if (params?.event) queryParams.event = params.event;
-    if (params?.user) queryParams.user = params.user;
-    if (params?.connection) queryParams.connection = params.connection;
-    if (params?.ip) queryParams.ip = params.ip;
-    if (params?.search) queryParams.search = params.search;
-    if (params?.from) queryParams.from = params.from;
-    if (params?.to) queryParams.to = params.to;
-    return apiClient.get<LogsResponse>("/api/v1/logs", { params: queryParams });
-  },
+  list: (params?: LogsParams) =>
+    apiClient.get<LogsResponse>("/api/v1/logs", { params: logsQueryParams(params) }),
 
-  get: (id: string) => apiClient.get<LogsResponse>(`/api/v1/logs/${id}`),
+  get: (id: string) => apiClient.get<LogRecord>(`/api/v1/logs/${id}`),
 
   getStats: () => apiClient.get<LogsStatsResponse>("/api/v1/logs/stats"),
 
-  export: (params?: LogsParams) => {
-    const queryParams: Record<string, string> = {};
-    if (params?.level) queryParams.level = params.level;
-    if (params?.event) queryParams.event = params.event;
-    if (params?.user) queryParams.user = params.user;
-    if (params?.connection) queryParams.connection = params.connection;
-    if (params?.ip) queryParams.ip = params.ip;
-    if (params?.from) queryParams.from = params.from;
-    if (params?.to) queryParams.to = params.to;
-    return apiClient.get<LogsResponse>("/api/v1/logs/export", { params: queryParams });
-  },
+  export: (params?: Omit<LogsParams, "limit" | "cursor">, format: LogsExportFormat = "ndjson") =>
+    apiClient.getBlob("/api/v1/logs/export", {
+      params: { ...logsQueryParams(params), format },
+    }),
 
   stream: (params?: LogsParams) => {
     const queryParams: Record<string, string> = {};
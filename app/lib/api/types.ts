@@ -1156,13 +1156,26 @@ export interface LogEntry {
   metadata?: Record<string, string>;
 }
 
+// Log as returned by GET /api/v1/logs and /api/v1/logs/:id
+export interface LogRecord {
+  id: string;
+  level: "debug" | "info" | "warn" | "error";
+  event: string;
+  message: string;
+  details?: string;
+  userEmail?: string;
+  userId?: string;
+  ipAddress?: string;
+  connection?: string;
+  location?: string;
+  userAgent?: string;
+  createdAt: string;
+}
+
+// Cursor-paginated page of logs, newest first; nextCursor is null on the last page
 export interface LogsResponse {
-  success: boolean;
-  data?: LogEntry[];
-  total?: number;
-  page?: number;
-  pageSize?: number;
-  totalPages?: number;
+  logs: LogRecord[];
+  nextCursor: string | null;
   error?: string;
 }
 
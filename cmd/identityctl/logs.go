@@ -0,0 +1,194 @@
+package vaultctl
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/cmd/internal/context"
+	"github.com/spf13/cobra"
+)
+
+// logStreamMessage est un message du flux /api/v1/logs/stream
+type logStreamMessage struct {
+	Kind     string          `json:"kind"`
+	Time     time.Time       `json:"timestamp"`
+	Level    string          `json:"level"`
+	Event    string          `json:"event"`
+	User     string          `json:"user"`
+	Email    string          `json:"email"`
+	IP       string          `json:"ip"`
+	TenantID string          `json:"tenantId"`
+	Details  string          `json:"details"`
+	Data     json.RawMessage `json:"data"`
+}
+
+func newLogsCommand(ctx *context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Consulter les logs et événements",
+	}
+	cmd.AddCommand(newLogsTailCommand(ctx))
+	return cmd
+}
+
+func newLogsTailCommand(ctx *context.Context) *cobra.Command {
+	var (
+		server, token, tenant, user string
+		kinds, events, levels       []string
+		tail                        int
+		raw                         bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tail",
+		Short: "Suivre en direct les événements d'authentification",
+		Long: `Suit en direct le flux des logs, événements, activités de sécurité et entrées d'audit.
+Par défaut, seuls les événements d'authentification (auth.*) sont affichés.
+La connexion est rétablie automatiquement et reprend après le dernier événement reçu.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if server == "" {
+				server = defaultServerURL(ctx)
+			}
+			if token == "" {
+				token = os.Getenv("VAULTCTL_TOKEN")
+			}
+
+			query := url.Values{}
+			for param, values := range map[string][]string{"kind": kinds, "event": events, "level": levels} {
+				for _, value := range values {
+					query.Add(param, value)
+				}
+			}
+			if tenant != "" {
+				query.Set("tenant_id", tenant)
+			}
+			if user != "" {
+				query.Set("user_id", user)
+			}
+			if tail > 0 {
+				query.Set("tail", strconv.Itoa(tail))
+			}
+			endpoint := strings.TrimRight(server, "/") + "/api/v1/logs/stream?" + query.Encode()
+
+			lastEventID := ""
+			for {
+				err := followLogStream(cmd, endpoint, token, &lastEventID, raw)
+				if err == errLogStreamUnauthorized {
+					return fmt.Errorf("accès refusé au flux : vérifiez --token ou VAULTCTL_TOKEN")
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Connexion au flux interrompue (%v), nouvelle tentative dans 3s...\n", err)
+				time.Sleep(3 * time.Second)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&server, "server", "", "URL du serveur Aether Identity (par défaut celle de la configuration)")
+	cmd.Flags().StringVar(&token, "token", "", "Jeton d'accès (par défaut VAULTCTL_TOKEN)")
+	cmd.Flags().StringVar(&tenant, "tenant", "", "Limiter au tenant donné")
+	cmd.Flags().StringVar(&user, "user", "", "Limiter à l'utilisateur donné")
+	cmd.Flags().StringSliceVar(&kinds, "kind", nil, "Types de messages : log, event, security, audit")
+	cmd.Flags().StringSliceVar(&events, "event", []string{"auth."}, "Événements suivis (un préfixe se termine par un point)")
+	cmd.Flags().StringSliceVar(&levels, "level", nil, "Niveaux : debug, info, warn, error")
+	cmd.Flags().IntVarP(&tail, "lines", "n", 20, "Nombre de messages récents affichés au démarrage")
+	cmd.Flags().BoolVar(&raw, "json", false, "Afficher les messages bruts en JSON")
+	return cmd
+}
+
+var errLogStreamUnauthorized = errors.New("unauthorized")
+
+// followLogStream lit le flux Server-Sent Events jusqu'à sa fermeture en mémorisant le dernier identifiant reçu
+func followLogStream(cmd *cobra.Command, endpoint, token string, lastEventID *string, raw bool) error {
+	req, err := http.NewRequestWithContext(cmd.Context(), http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if *lastEventID != "" {
+		req.Header.Set("Last-Event-ID", *lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return errLogStreamUnauthorized
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("statut HTTP %d", resp.StatusCode)
+	}
+
+	out := cmd.OutOrStdout()
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	event, data := "", ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data != "" {
+				printLogStreamEvent(out, event, data, raw)
+			}
+			event, data = "", ""
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		case strings.HasPrefix(line, "id:"):
+			*lastEventID = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("flux fermé par le serveur")
+}
+
+// printLogStreamEvent affiche un message du flux sur une ligne
+func printLogStreamEvent(out io.Writer, event, data string, raw bool) {
+	switch event {
+	case "reset":
+		fmt.Fprintln(out, "-- reprise impossible, des événements ont pu être perdus --")
+		return
+	case "lagged":
+		fmt.Fprintf(out, "-- événements ignorés, client trop lent : %s --\n", data)
+		return
+	}
+	if raw {
+		fmt.Fprintln(out, data)
+		return
+	}
+
+	var msg logStreamMessage
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		fmt.Fprintln(out, data)
+		return
+	}
+	who := msg.Email
+	if who == "" {
+		who = msg.User
+	}
+	fmt.Fprintf(out, "%s %-5s %-8s %-28s %-24s %-15s %s\n",
+		msg.Time.Local().Format("2006-01-02 15:04:05"), strings.ToUpper(msg.Level), msg.Kind, msg.Event, who, msg.IP, msg.Details)
+}
+
+// defaultServerURL construit l'URL du serveur à partir de la configuration de vaultctl
+func defaultServerURL(ctx *context.Context) string {
+	if ctx != nil && ctx.Config != nil && ctx.Config.Server.Host != "" {
+		return fmt.Sprintf("http://%s:%d", ctx.Config.Server.Host, ctx.Config.Server.Port)
+	}
+	return "http://localhost:8080"
+}
@@ -29,6 +29,7 @@ func NewRootCommand(ctx *context.Context) *cobra.Command {
 	cmd.AddCommand(newNetworkCommand(ctx))
 	cmd.AddCommand(newSecurityCommand(ctx))
 	cmd.AddCommand(newMaintenanceCommand(ctx))
+	cmd.AddCommand(newLogsCommand(ctx))
 
 	return cmd
 }
@@ -3,8 +3,15 @@ package docker
 import (
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
+	"path"
+)
+
+const (
+	// AgentConfigPath is where the agent configuration file is mounted in the agent
+	AgentConfigPath = "/etc/aether/" + AgentConfigFileName
+
+	// AgentTokenDir holds one token volume per identity service in the agent
+	AgentTokenDir = "/var/lib/aether/tokens"
 )
 
 // AgentConfig holds configuration for the identity agent
@@ -27,7 +34,12 @@ type AgentConfiguration struct {
 	Stack       string                     `json:"stack"`
 	Environment string                     `json:"environment"`
 	SocketPath  string                     `json:"socket_path"`
+	TokenDir    string                     `json:"token_dir"`
 	Services    map[string]ServiceIdentity `json:"services"`
+
+	// LogLevel and TokenRefreshInterval come from the stack AgentConfig
+	LogLevel             string `json:"log_level,omitempty"`
+	TokenRefreshInterval string `json:"token_refresh_interval,omitempty"`
 }
 
 // ServiceIdentity defines identity requirements for a service in agent config
@@ -36,13 +48,39 @@ type ServiceIdentity struct {
 	Roles       []string `json:"roles,omitempty"`
 	ServiceName string   `json:"service_name"`
 	Audience    string   `json:"audience,omitempty"`
+
+	// Client credentials used by the agent; the secret is read from a mounted file
+	ClientID         string `json:"client_id,omitempty"`
+	ClientSecretFile string `json:"client_secret_file,omitempty"`
+	TokenEndpoint    string `json:"token_endpoint,omitempty"`
+}
+
+// TokenFilePath returns the path of the token file the agent writes for a service.
+// The directory is a volume shared read-only with that service only.
+func TokenFilePath(service string) string {
+	return path.Join(AgentTokenDir, service, "token")
+}
+
+// TokenSocketPath returns the path of the Unix socket on which the agent serves the token
+// of a service. It lives in the service's token volume, so no other service can reach it.
+func TokenSocketPath(service string) string {
+	return path.Join(AgentTokenDir, service, "agent.sock")
 }
 
 // generateAgentConfig generates the identity agent configuration file
 func (c *Client) generateAgentConfig(stack *Stack, path string) error {
-	if err := stack.Validate(); err != nil {
+	data, err := c.renderAgentConfig(stack)
+	if err != nil {
 		return err
 	}
+	return writeArtifact(path, data, "agent config")
+}
+
+// renderAgentConfig renders the identity agent configuration of the stack
+func (c *Client) renderAgentConfig(stack *Stack) ([]byte, error) {
+	if err := stack.Validate(); err != nil {
+		return nil, err
+	}
 
 	config := AgentConfiguration{
 		Version:     "1.0",
@@ -51,7 +89,11 @@ func (c *Client) generateAgentConfig(stack *Stack, path string) error {
 		Stack:       stack.name,
 		Environment: c.config.Environment,
 		SocketPath:  c.config.AgentSocketPath,
+		TokenDir:    AgentTokenDir,
 		Services:    make(map[string]ServiceIdentity),
+
+		LogLevel:             stack.agentConfig.LogLevel,
+		TokenRefreshInterval: stack.agentConfig.TokenRefreshInterval,
 	}
 
 	// Add service identities
@@ -62,6 +104,12 @@ func (c *Client) generateAgentConfig(stack *Stack, path string) error {
 				Roles:       svc.Identity.GetRoles(),
 				ServiceName: svc.Identity.ServiceName,
 				Audience:    svc.Identity.Audience,
+
+				ClientID:      svc.Identity.ClientID,
+				TokenEndpoint: svc.Identity.TokenEndpoint,
+			}
+			if svc.Identity.ClientSecret != nil {
+				si.ClientSecretFile = svc.Identity.ClientSecret.MountPath()
 			}
 
 			if si.ServiceName == "" {
@@ -75,27 +123,19 @@ func (c *Client) generateAgentConfig(stack *Stack, path string) error {
 	// Marshal to JSON
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
-		return WrapError(err, "failed to marshal agent config")
-	}
-
-	// Ensure directory exists
-	dir := filepath.Dir(path)
-	if dir != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return WrapError(err, "failed to create directory %q", dir)
-		}
-	}
-
-	// Write file
-	if err := os.WriteFile(path, data, 0644); err != nil {
-		return WrapError(err, "failed to write agent config to %q", path)
+		return nil, WrapError(err, "failed to marshal agent config")
 	}
 
-	return nil
+	return append(data, '\n'), nil
 }
 
 // generateEnv generates an .env file with non-sensitive configuration
 func (c *Client) generateEnv(stack *Stack, path string) error {
+	return writeArtifact(path, c.renderEnv(stack), "env file")
+}
+
+// renderEnv renders the .env content of the stack
+func (c *Client) renderEnv(stack *Stack) []byte {
 	var content string
 
 	// Add header
@@ -113,18 +153,5 @@ func (c *Client) generateEnv(stack *Stack, path string) error {
 	content += fmt.Sprintf("AETHER_AGENT_SOCKET=%s\n", c.config.AgentSocketPath)
 	content += fmt.Sprintf("AETHER_AGENT_IMAGE=%s\n", c.config.AgentImage)
 
-	// Ensure directory exists
-	dir := filepath.Dir(path)
-	if dir != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return WrapError(err, "failed to create directory %q", dir)
-		}
-	}
-
-	// Write file
-	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
-		return WrapError(err, "failed to write env file to %q", path)
-	}
-
-	return nil
+	return []byte(content)
 }
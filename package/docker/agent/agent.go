@@ -0,0 +1,293 @@
+// Package agent is the runtime of the Aether Identity agent sidecar generated by package docker.
+// It reads the agent configuration, obtains and refreshes a client-credentials token for every
+// service identity and writes each token to the service's token volume, as a file and on a
+// per-service Unix socket. The shared agent socket serves health and metrics only.
+package agent
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/package/docker"
+)
+
+const (
+	// minRefreshDelay bounds how often a token is renewed
+	minRefreshDelay = 5 * time.Second
+
+	// maxRetryDelay bounds the backoff between failed token requests
+	maxRetryDelay = time.Minute
+)
+
+// Agent obtains and refreshes the tokens of the service identities of a stack
+type Agent struct {
+	config   *docker.AgentConfiguration
+	options  Options
+	interval time.Duration
+	logger   *slog.Logger
+	metrics  *metrics
+
+	mu       sync.RWMutex
+	services map[string]*serviceState
+}
+
+// serviceState is the token state of one service identity
+type serviceState struct {
+	name       string
+	identity   docker.ServiceIdentity
+	tokenFile  string
+	socketFile string // Serves this service's token only
+	token      *Token
+	lastError  error
+}
+
+// New creates an agent for the given configuration
+func New(config *docker.AgentConfiguration, options Options) (*Agent, error) {
+	if err := ValidateConfig(config); err != nil {
+		return nil, err
+	}
+	interval, _ := refreshInterval(config)
+
+	// Apply defaults
+	if options.RefreshBefore == 0 {
+		options.RefreshBefore = DefaultRefreshBefore
+	}
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if options.Logger == nil {
+		options.Logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel(config.LogLevel)}))
+	}
+
+	tokenDir := config.TokenDir
+	if tokenDir == "" {
+		tokenDir = docker.AgentTokenDir
+	}
+
+	a := &Agent{
+		config:   config,
+		options:  options,
+		interval: interval,
+		logger:   options.Logger.With("stack", config.Stack),
+		metrics:  newMetrics(),
+		services: make(map[string]*serviceState),
+	}
+	for name, identity := range config.Services {
+		a.services[name] = &serviceState{
+			name:       name,
+			identity:   identity,
+			tokenFile:  filepath.Join(tokenDir, name, "token"),
+			socketFile: filepath.Join(tokenDir, name, "agent.sock"),
+		}
+	}
+	return a, nil
+}
+
+// Run refreshes the tokens and serves the sockets (and HTTPAddr, if set) until ctx is canceled
+func (a *Agent) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	servers, err := a.listen()
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, state := range a.services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.refreshLoop(ctx, state)
+		}()
+	}
+
+	errs := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func() {
+			if err := srv.serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errs <- err
+			}
+		}()
+	}
+
+	a.logger.Info("identity agent started", "services", len(a.services), "socket", a.config.SocketPath)
+
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+		cancel()
+	}
+
+	shutdownCtx, stop := context.WithTimeout(context.Background(), 5*time.Second)
+	defer stop()
+	for _, srv := range servers {
+		srv.Shutdown(shutdownCtx)
+	}
+	wg.Wait()
+	return err
+}
+
+// Token returns the current token of a service, looked up by stack service name or
+// by identity service name (AETHER_IDENTITY_SERVICE in the service container)
+func (a *Agent) Token(service string) (*Token, bool) {
+	_, token, ok := a.token(service)
+	return token, ok
+}
+
+// token returns the stack service name and current token of a service; the name is
+// empty for unknown services
+func (a *Agent) token(service string) (string, *Token, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	state := a.lookup(service)
+	if state == nil {
+		return "", nil, false
+	}
+	if !state.token.Valid(0) {
+		return state.name, nil, false
+	}
+	return state.name, state.token, true
+}
+
+// Ready returns true once every service has a valid token
+func (a *Agent) Ready() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, state := range a.services {
+		if !state.token.Valid(0) {
+			return false
+		}
+	}
+	return true
+}
+
+// ServiceStatus is the token status of a service identity
+type ServiceStatus struct {
+	Service   string    `json:"service"`
+	Ready     bool      `json:"ready"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Status returns the token status of every service, sorted by name
+func (a *Agent) Status() []ServiceStatus {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	statuses := make([]ServiceStatus, 0, len(a.services))
+	for name, state := range a.services {
+		status := ServiceStatus{Service: name, Ready: state.token.Valid(0)}
+		if state.token != nil {
+			status.ExpiresAt = state.token.ExpiresAt
+		}
+		if state.lastError != nil {
+			status.Error = state.lastError.Error()
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Service < statuses[j].Service })
+	return statuses
+}
+
+// resolve returns the stack service name of a service given by stack or identity name,
+// or "" if unknown
+func (a *Agent) resolve(service string) string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if state := a.lookup(service); state != nil {
+		return state.name
+	}
+	return ""
+}
+
+// lookup finds a service by stack or identity name; callers hold a.mu
+func (a *Agent) lookup(service string) *serviceState {
+	if state, ok := a.services[service]; ok {
+		return state
+	}
+	for _, state := range a.services {
+		if state.identity.ServiceName == service {
+			return state
+		}
+	}
+	return nil
+}
+
+// refreshLoop renews the token of a service before it expires, retrying failures with backoff
+func (a *Agent) refreshLoop(ctx context.Context, state *serviceState) {
+	logger := a.logger.With("service", state.name)
+	retryDelay := time.Second
+
+	for {
+		var wait time.Duration
+		token, err := a.refresh(ctx, state)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Error("token refresh failed", "error", err, "retry_in", retryDelay)
+			wait = retryDelay
+			retryDelay = min(retryDelay*2, maxRetryDelay)
+		} else {
+			retryDelay = time.Second
+			wait = a.nextRefresh(token)
+			logger.Debug("token refreshed", "expires_at", token.ExpiresAt, "next_refresh_in", wait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// refresh requests a new token, stores it and writes the service token file.
+// On failure the previous token keeps being served until it expires.
+func (a *Agent) refresh(ctx context.Context, state *serviceState) (*Token, error) {
+	token, err := a.requestToken(ctx, state.identity)
+	if err == nil {
+		err = writeTokenFile(state.tokenFile, token)
+	}
+
+	a.mu.Lock()
+	state.lastError = err
+	if err == nil {
+		state.token = token
+	}
+	a.mu.Unlock()
+
+	a.metrics.observeRefresh(state.name, token, err)
+	return token, err
+}
+
+// nextRefresh returns the delay before renewing a token: at the latest RefreshBefore
+// (or a fifth of its lifetime, if longer) before expiry, and at least every refresh interval
+func (a *Agent) nextRefresh(token *Token) time.Duration {
+	if token.ExpiresAt.IsZero() {
+		if a.interval > 0 {
+			return a.interval
+		}
+		return DefaultRefreshInterval
+	}
+
+	lifetime := token.ExpiresAt.Sub(token.IssuedAt)
+	wait := time.Until(token.ExpiresAt) - max(a.options.RefreshBefore, lifetime/5)
+	if a.interval > 0 {
+		wait = min(wait, a.interval)
+	}
+	return max(wait, minRefreshDelay)
+}
@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/package/docker"
+)
+
+const (
+	// DefaultRefreshInterval is the refresh period of tokens without an expiry
+	DefaultRefreshInterval = 5 * time.Minute
+
+	// DefaultRefreshBefore is how long before expiry a token is renewed at least
+	DefaultRefreshBefore = time.Minute
+)
+
+// Options configures the agent runtime
+type Options struct {
+	// HTTPAddr serves health and metrics over TCP (for example ":9090")
+	// Tokens are only ever served on the Unix socket. Disabled if empty.
+	HTTPAddr string
+
+	// RefreshBefore is how long before expiry a token is renewed at least
+	// Defaults to DefaultRefreshBefore if zero
+	RefreshBefore time.Duration
+
+	// HTTPClient sends the token requests; defaults to a client with a 30s timeout
+	HTTPClient *http.Client
+
+	// Logger receives the agent logs; defaults to a text logger at the configured level
+	Logger *slog.Logger
+}
+
+// LoadConfig reads the agent configuration generated by docker.Stack.GenerateAgentConfig.
+// AETHER_IDENTITY_ENDPOINT, AETHER_AGENT_SOCKET and AETHER_LOG_LEVEL override the file,
+// so one generated file can be reused across environments.
+func LoadConfig(path string) (*docker.AgentConfiguration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, docker.WrapError(err, "failed to read agent config %q", path)
+	}
+
+	var config docker.AgentConfiguration
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, docker.WrapError(err, "failed to parse agent config %q", path)
+	}
+
+	if endpoint := os.Getenv("AETHER_IDENTITY_ENDPOINT"); endpoint != "" {
+		config.Endpoint = endpoint
+	}
+	if socket := os.Getenv("AETHER_AGENT_SOCKET"); socket != "" {
+		config.SocketPath = socket
+	}
+	if level := os.Getenv("AETHER_LOG_LEVEL"); level != "" {
+		config.LogLevel = level
+	}
+	if config.TokenDir == "" {
+		config.TokenDir = docker.AgentTokenDir
+	}
+
+	if err := ValidateConfig(&config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// ValidateConfig checks that every service identity can obtain tokens
+func ValidateConfig(config *docker.AgentConfiguration) error {
+	if config.Endpoint == "" {
+		return docker.NewValidationError("agent config endpoint is required")
+	}
+	if config.SocketPath == "" {
+		return docker.NewValidationError("agent config socket_path is required")
+	}
+	if _, err := refreshInterval(config); err != nil {
+		return err
+	}
+
+	for name, identity := range config.Services {
+		if identity.ClientID == "" || identity.ClientSecretFile == "" {
+			return docker.NewValidationError(fmt.Sprintf("service %q has no client credentials", name))
+		}
+	}
+	return nil
+}
+
+// refreshInterval parses the configured refresh interval; zero means refresh on expiry only
+func refreshInterval(config *docker.AgentConfiguration) (time.Duration, error) {
+	if config.TokenRefreshInterval == "" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(config.TokenRefreshInterval)
+	if err != nil || interval <= 0 {
+		return 0, docker.NewValidationError(fmt.Sprintf("invalid token_refresh_interval %q", config.TokenRefreshInterval))
+	}
+	return interval, nil
+}
+
+// logLevel maps the configured log level to a slog level
+func logLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
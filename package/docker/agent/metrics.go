@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// metrics holds the agent counters and gauges, exposed in the Prometheus text format
+type metrics struct {
+	mu         sync.Mutex
+	refreshes  map[[2]string]uint64 // {service, result}
+	requests   map[[2]string]uint64 // {service, result}
+	expiry     map[string]time.Time
+	lastUpdate map[string]time.Time
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		refreshes:  make(map[[2]string]uint64),
+		requests:   make(map[[2]string]uint64),
+		expiry:     make(map[string]time.Time),
+		lastUpdate: make(map[string]time.Time),
+	}
+}
+
+// observeRefresh records a token refresh of a service
+func (m *metrics) observeRefresh(service string, token *Token, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil {
+		m.refreshes[[2]string{service, "failure"}]++
+		return
+	}
+	m.refreshes[[2]string{service, "success"}]++
+	m.lastUpdate[service] = token.IssuedAt
+	if !token.ExpiresAt.IsZero() {
+		m.expiry[service] = token.ExpiresAt
+	}
+}
+
+// observeRequest records a token request on the socket; unknown services share one label
+func (m *metrics) observeRequest(service string, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := "success"
+	switch {
+	case service == "":
+		service, result = "unknown", "not_found"
+	case !ok:
+		result = "unavailable"
+	}
+	m.requests[[2]string{service, result}]++
+}
+
+// write writes the metrics in the Prometheus text exposition format
+func (m *metrics) write(w io.Writer, ready bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	readyValue := 0
+	if ready {
+		readyValue = 1
+	}
+	fmt.Fprintln(w, "# HELP aether_agent_ready Whether every service identity has a valid token.")
+	fmt.Fprintln(w, "# TYPE aether_agent_ready gauge")
+	fmt.Fprintf(w, "aether_agent_ready %d\n", readyValue)
+
+	writeCounter(w, "aether_agent_token_refreshes_total", "Token refreshes by service and result.", m.refreshes)
+	writeCounter(w, "aether_agent_token_requests_total", "Token requests on the agent socket by service and result.", m.requests)
+	writeTimestamps(w, "aether_agent_token_expiry_timestamp_seconds", "Expiry of the current token by service.", m.expiry)
+	writeTimestamps(w, "aether_agent_token_last_refresh_timestamp_seconds", "Last successful token refresh by service.", m.lastUpdate)
+}
+
+func writeCounter(w io.Writer, name, help string, values map[[2]string]uint64) {
+	keys := make([][2]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s counter\n", name)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s{service=%q,result=%q} %d\n", name, key[0], key[1], values[key])
+	}
+}
+
+func writeTimestamps(w io.Writer, name, help string, values map[string]time.Time) {
+	services := make([]string, 0, len(values))
+	for service := range values {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+	for _, service := range services {
+		fmt.Fprintf(w, "%s{service=%q} %d\n", name, service, values[service].Unix())
+	}
+}
@@ -0,0 +1,180 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/package/docker"
+)
+
+// server is an HTTP server bound to a listener
+type server struct {
+	*http.Server
+	listener net.Listener
+}
+
+func (s server) serve() error {
+	return s.Serve(s.listener)
+}
+
+// listen opens the agent socket, one token socket per service and, if configured, the TCP
+// health and metrics address
+func (a *Agent) listen() ([]server, error) {
+	listener, err := listenUnix(a.config.SocketPath)
+	if err != nil {
+		return nil, err
+	}
+	servers := []server{{
+		Server:   &http.Server{Handler: a.HealthHandler(), ReadHeaderTimeout: 5 * time.Second},
+		listener: listener,
+	}}
+	closeAll := func() {
+		for _, srv := range servers {
+			srv.listener.Close()
+		}
+	}
+
+	for _, state := range a.services {
+		listener, err := listenUnix(state.socketFile)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		servers = append(servers, server{
+			Server:   &http.Server{Handler: a.ServiceHandler(state.name), ReadHeaderTimeout: 5 * time.Second},
+			listener: listener,
+		})
+	}
+
+	if a.options.HTTPAddr != "" {
+		tcp, err := net.Listen("tcp", a.options.HTTPAddr)
+		if err != nil {
+			closeAll()
+			return nil, docker.WrapError(err, "failed to listen on %q", a.options.HTTPAddr)
+		}
+		servers = append(servers, server{
+			Server:   &http.Server{Handler: a.HealthHandler(), ReadHeaderTimeout: 5 * time.Second},
+			listener: tcp,
+		})
+	}
+
+	return servers, nil
+}
+
+// listenUnix opens a Unix socket, replacing one left by a previous run
+func listenUnix(socket string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(socket), 0o755); err != nil {
+		return nil, docker.WrapError(err, "failed to create socket directory for %q", socket)
+	}
+	// A socket left by a previous run would make Listen fail
+	if err := os.Remove(socket); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, docker.WrapError(err, "failed to remove stale socket %q", socket)
+	}
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, docker.WrapError(err, "failed to listen on %q", socket)
+	}
+	// Service containers may run as any user; access is scoped by the volume holding the socket
+	if err := os.Chmod(socket, 0o666); err != nil {
+		listener.Close()
+		return nil, docker.WrapError(err, "failed to set socket mode %q", socket)
+	}
+	return listener, nil
+}
+
+// ServiceHandler serves the token of one service on its own socket:
+//
+//	GET /v1/token            current token of the service
+//	GET /v1/token/{service}  same, if service names this service; 403 otherwise
+//
+// The socket sits in the service's token volume, so the caller is that service (or the agent).
+func (a *Agent) ServiceHandler(service string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/token", func(w http.ResponseWriter, r *http.Request) {
+		a.handleToken(w, service)
+	})
+	mux.HandleFunc("GET /v1/token/{service}", func(w http.ResponseWriter, r *http.Request) {
+		requested := r.PathValue("service")
+		if a.resolve(requested) != service {
+			a.metrics.observeRequest(service, false)
+			writeJSON(w, http.StatusForbidden, map[string]string{
+				"error":             "access_denied",
+				"error_description": "this socket only serves the token of service " + service,
+			})
+			return
+		}
+		a.handleToken(w, service)
+	})
+	return mux
+}
+
+// HealthHandler serves health and metrics; tokens are only served on the service sockets:
+//
+//	GET /healthz  liveness
+//	GET /readyz   ready once every service has a token
+//	GET /metrics  Prometheus metrics
+func (a *Agent) HealthHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", a.handleHealth)
+	mux.HandleFunc("GET /readyz", a.handleReady)
+	mux.HandleFunc("GET /metrics", a.handleMetrics)
+	return mux
+}
+
+func (a *Agent) handleToken(w http.ResponseWriter, service string) {
+	name, token, ok := a.token(service)
+	a.metrics.observeRequest(name, ok)
+	if !ok {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{
+			"error":             "token_unavailable",
+			"error_description": "no valid token for service " + service,
+		})
+		return
+	}
+
+	response := map[string]interface{}{
+		"access_token": token.AccessToken,
+		"token_type":   token.TokenType,
+	}
+	if token.Scope != "" {
+		response["scope"] = token.Scope
+	}
+	if !token.ExpiresAt.IsZero() {
+		response["expires_in"] = int64(time.Until(token.ExpiresAt).Seconds())
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (a *Agent) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (a *Agent) handleReady(w http.ResponseWriter, r *http.Request) {
+	status := http.StatusOK
+	if !a.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, map[string]interface{}{
+		"ready":    status == http.StatusOK,
+		"services": a.Status(),
+	})
+}
+
+func (a *Agent) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	a.metrics.write(w, a.Ready())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
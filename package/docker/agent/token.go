@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/package/docker"
+)
+
+// Token is an Aether access token obtained for a service identity
+type Token struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	Scope       string    `json:"scope,omitempty"`
+	ExpiresAt   time.Time `json:"expires_at,omitzero"`
+	IssuedAt    time.Time `json:"issued_at"`
+}
+
+// Valid returns true if the token is set and does not expire within margin
+func (t *Token) Valid(margin time.Duration) bool {
+	return t != nil && t.AccessToken != "" && (t.ExpiresAt.IsZero() || time.Now().Add(margin).Before(t.ExpiresAt))
+}
+
+// TokenError is an error response of the token endpoint
+type TokenError struct {
+	StatusCode  int
+	Code        string
+	Description string
+}
+
+func (e TokenError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("token request failed (%d %s): %s", e.StatusCode, e.Code, e.Description)
+	}
+	return fmt.Sprintf("token request failed (%d %s)", e.StatusCode, e.Code)
+}
+
+// requestToken obtains a token with the client credentials grant.
+// The secret file is read on every request so rotated secrets are picked up.
+func (a *Agent) requestToken(ctx context.Context, identity docker.ServiceIdentity) (*Token, error) {
+	secret, err := os.ReadFile(identity.ClientSecretFile)
+	if err != nil {
+		return nil, docker.WrapError(err, "failed to read client secret %q", identity.ClientSecretFile)
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {identity.ClientID},
+		"client_secret": {strings.TrimSpace(string(secret))},
+	}
+	if len(identity.Scopes) > 0 {
+		form.Set("scope", strings.Join(identity.Scopes, " "))
+	}
+	if identity.Audience != "" {
+		form.Set("audience", identity.Audience)
+	}
+
+	endpoint := identity.TokenEndpoint
+	if endpoint == "" {
+		endpoint = strings.TrimSuffix(a.config.Endpoint, "/") + "/oauth/token"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, docker.WrapError(err, "failed to create token request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := a.options.HTTPClient.Do(req)
+	if err != nil {
+		return nil, docker.WrapError(err, "token request to %q failed", endpoint)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Scope            string `json:"scope"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, docker.WrapError(err, "failed to decode token response")
+	}
+
+	if resp.StatusCode != http.StatusOK || body.AccessToken == "" {
+		return nil, TokenError{StatusCode: resp.StatusCode, Code: body.Error, Description: body.ErrorDescription}
+	}
+
+	token := &Token{
+		AccessToken: body.AccessToken,
+		TokenType:   body.TokenType,
+		Scope:       body.Scope,
+		IssuedAt:    time.Now(),
+	}
+	if body.ExpiresIn > 0 {
+		token.ExpiresAt = token.IssuedAt.Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// writeTokenFile atomically replaces the token file of a service, so readers never see
+// a partial token. The directory is the service's own volume.
+func writeTokenFile(path string, token *Token) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return docker.WrapError(err, "failed to create token directory %q", dir)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".token-*")
+	if err != nil {
+		return docker.WrapError(err, "failed to create token file in %q", dir)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(token.AccessToken); err != nil {
+		tmp.Close()
+		return docker.WrapError(err, "failed to write token file %q", path)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return docker.WrapError(err, "failed to set token file mode %q", path)
+	}
+	if err := tmp.Close(); err != nil {
+		return docker.WrapError(err, "failed to write token file %q", path)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return docker.WrapError(err, "failed to replace token file %q", path)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package docker
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Default file names of the artifacts generated for an environment
+const (
+	ComposeFileName     = "docker-compose.yml"
+	EnvFileName         = ".env"
+	AgentConfigFileName = "agent-config.json"
+)
+
+// writeArtifact writes a generated artifact, creating its directory if needed
+func writeArtifact(path string, data []byte, kind string) error {
+	// Ensure directory exists
+	dir := filepath.Dir(path)
+	if dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return WrapError(err, "failed to create directory %q", dir)
+		}
+	}
+
+	// Write file
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return WrapError(err, "failed to write %s to %q", kind, path)
+	}
+
+	return nil
+}
+
+// sortedKeys returns the keys of a map in order, so generated artifacts are stable
+func sortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -48,6 +48,7 @@ func (c *Client) NewStack(name string) *Stack {
 		name:     name,
 		client:   c,
 		services: make(map[string]Service),
+		overlays: make(map[string]Overlay),
 	}
 }
 
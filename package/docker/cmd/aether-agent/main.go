@@ -0,0 +1,150 @@
+// Command aether-agent runs the Aether Identity agent sidecar of a generated Docker stack.
+//
+// Usage:
+//
+//	aether-agent [run] [-config path] [-http-addr addr]   refresh tokens and serve the socket
+//	aether-agent health [-socket path]                     exit 0 once every token is ready
+//	aether-agent token [-socket path] [service]            print the current token of a service
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/package/docker"
+	"github.com/skygenesisenterprise/aether-identity/package/docker/agent"
+)
+
+func main() {
+	args := os.Args[1:]
+	command := "run"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		command, args = args[0], args[1:]
+	}
+
+	var err error
+	switch command {
+	case "run":
+		err = run(args)
+	case "health":
+		err = health(args)
+	case "token":
+		err = token(args)
+	default:
+		err = fmt.Errorf("unknown command %q (expected run, health or token)", command)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "aether-agent:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	configPath := flags.String("config", envOr("AETHER_AGENT_CONFIG", docker.AgentConfigPath), "agent configuration file")
+	httpAddr := flags.String("http-addr", os.Getenv("AETHER_AGENT_HTTP_ADDR"), "TCP address serving health and metrics (disabled if empty)")
+	flags.Parse(args)
+
+	config, err := agent.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	a, err := agent.New(config, agent.Options{HTTPAddr: *httpAddr})
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return a.Run(ctx)
+}
+
+func health(args []string) error {
+	flags := flag.NewFlagSet("health", flag.ExitOnError)
+	socket := flags.String("socket", envOr("AETHER_AGENT_SOCKET", "/var/run/aether/identity.sock"), "agent socket")
+	flags.Parse(args)
+
+	resp, err := socketGet(*socket, "/readyz")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("agent not ready: %s", body)
+	}
+	return nil
+}
+
+func token(args []string) error {
+	flags := flag.NewFlagSet("token", flag.ExitOnError)
+	socket := flags.String("socket", os.Getenv("AETHER_IDENTITY_SOCKET"), "token socket of the service (default: the service's socket in the agent token directory)")
+	flags.Parse(args)
+
+	service := flags.Arg(0)
+	if service == "" {
+		service = os.Getenv("AETHER_IDENTITY_SERVICE")
+	}
+	// Each service has its own token socket; in the agent container, it is found by service name
+	if *socket == "" {
+		if service == "" {
+			return fmt.Errorf("service name or -socket is required")
+		}
+		*socket = docker.TokenSocketPath(service)
+	}
+	path := "/v1/token"
+	if service != "" {
+		path += "/" + url.PathEscape(service)
+	}
+
+	resp, err := socketGet(*socket, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode agent response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s", body.ErrorDescription)
+	}
+	fmt.Println(body.AccessToken)
+	return nil
+}
+
+// socketGet sends a GET request to the agent over its Unix socket
+func socketGet(socket, path string) (*http.Response, error) {
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	return client.Get("http://aether-agent" + path)
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
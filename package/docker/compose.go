@@ -2,22 +2,32 @@ package docker
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
 // generateCompose generates a docker-compose.yml file for the stack
 func (c *Client) generateCompose(stack *Stack, path string) error {
-	if err := stack.Validate(); err != nil {
+	data, err := c.renderCompose(stack)
+	if err != nil {
 		return err
 	}
+	return writeArtifact(path, data, "compose file")
+}
+
+// renderCompose renders the docker-compose.yml content of the stack
+func (c *Client) renderCompose(stack *Stack) ([]byte, error) {
+	if err := stack.Validate(); err != nil {
+		return nil, err
+	}
 
 	compose := &ComposeFile{
 		Version:  "3.8",
 		Services: make(map[string]ComposeService),
 		Networks: make(map[string]ComposeNetwork),
 		Volumes:  make(map[string]ComposeVolume),
+		Secrets:  make(map[string]ComposeSecret),
 	}
 
 	// Add identity network
@@ -34,32 +44,33 @@ func (c *Client) generateCompose(stack *Stack, path string) error {
 	agentService := c.buildAgentService(stack)
 	compose.Services["identity-agent"] = agentService
 
-	// Add user services
+	// Add user services and the secrets they reference
 	for name, svc := range stack.services {
 		composeService := c.buildComposeService(name, svc, stack)
 		compose.Services[name] = composeService
-	}
 
-	// Marshal to YAML
-	yaml, err := compose.ToYAML()
-	if err != nil {
-		return WrapError(err, "failed to marshal compose file")
-	}
+		for _, ref := range svc.Secrets {
+			compose.Secrets[ref.Name] = ref.composeSecret()
+		}
 
-	// Ensure directory exists
-	dir := filepath.Dir(path)
-	if dir != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return WrapError(err, "failed to create directory %q", dir)
+		// Each identity service gets its own token volume, shared with the agent only
+		if svc.HasIdentity() {
+			compose.Volumes[tokenVolumeName(name)] = ComposeVolume{
+				Driver: "local",
+			}
+			if ref := svc.Identity.ClientSecret; ref != nil {
+				compose.Secrets[ref.Name] = ref.composeSecret()
+			}
 		}
 	}
 
-	// Write file
-	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
-		return WrapError(err, "failed to write compose file to %q", path)
+	// Marshal to YAML
+	yaml, err := compose.ToYAML()
+	if err != nil {
+		return nil, WrapError(err, "failed to marshal compose file")
 	}
 
-	return nil
+	return []byte(yaml), nil
 }
 
 // buildAgentService builds the identity agent sidecar service
@@ -72,6 +83,7 @@ func (c *Client) buildAgentService(stack *Stack) ComposeService {
 		"AETHER_STACK":             stack.name,
 		"AETHER_AGENT_SOCKET":      config.AgentSocketPath,
 		"AETHER_ENVIRONMENT":       config.Environment,
+		"AETHER_AGENT_CONFIG":      AgentConfigPath,
 	}
 
 	// Add agent-specific config
@@ -81,16 +93,33 @@ func (c *Client) buildAgentService(stack *Stack) ComposeService {
 		}
 	}
 
+	// The agent reads the generated config, the client secrets of every
+	// identity service, and writes each service token to its own volume
+	volumes := []string{
+		"aether-identity-socket:" + filepath.Dir(config.AgentSocketPath),
+		"./" + AgentConfigFileName + ":" + AgentConfigPath + ":ro",
+	}
+	var secrets []string
+	for _, name := range sortedKeys(stack.services) {
+		svc := stack.services[name]
+		if !svc.HasIdentity() {
+			continue
+		}
+		volumes = append(volumes, tokenVolumeName(name)+":"+filepath.Dir(TokenFilePath(name)))
+		if ref := svc.Identity.ClientSecret; ref != nil && !slices.Contains(secrets, ref.Name) {
+			secrets = append(secrets, ref.Name)
+		}
+	}
+
 	return ComposeService{
 		Image:       config.AgentImage,
 		Restart:     "unless-stopped",
 		Environment: env,
-		Volumes: []string{
-			"aether-identity-socket:" + filepath.Dir(config.AgentSocketPath),
-		},
-		Networks: []string{"aether-identity"},
+		Volumes:     volumes,
+		Secrets:     secrets,
+		Networks:    []string{"aether-identity"},
 		HealthCheck: &ComposeHealthCheck{
-			Test:     []string{"CMD", "test", "-S", config.AgentSocketPath},
+			Test:     []string{"CMD", "aether-agent", "health"},
 			Interval: "10s",
 			Timeout:  "5s",
 			Retries:  3,
@@ -103,6 +132,11 @@ func (c *Client) buildAgentService(stack *Stack) ComposeService {
 	}
 }
 
+// tokenVolumeName returns the name of the token volume of an identity service
+func tokenVolumeName(service string) string {
+	return "aether-token-" + service
+}
+
 // buildComposeService builds a compose service from a Service definition
 func (c *Client) buildComposeService(name string, svc Service, stack *Stack) ComposeService {
 	cs := ComposeService{
@@ -122,17 +156,27 @@ func (c *Client) buildComposeService(name string, svc Service, stack *Stack) Com
 		cs.Environment[k] = v
 	}
 
+	// Mount secrets and point <NAME>_FILE to them
+	for _, envName := range sortedKeys(svc.Secrets) {
+		ref := svc.Secrets[envName]
+		cs.Environment[envName+"_FILE"] = ref.MountPath()
+		if !slices.Contains(cs.Secrets, ref.Name) {
+			cs.Secrets = append(cs.Secrets, ref.Name)
+		}
+	}
+
 	// Add identity environment variables
 	if svc.HasIdentity() {
-		cs.Environment["AETHER_IDENTITY_SOCKET"] = c.config.AgentSocketPath
+		cs.Environment["AETHER_IDENTITY_SOCKET"] = TokenSocketPath(name)
 		cs.Environment["AETHER_IDENTITY_SERVICE"] = svc.Identity.ServiceName
 		if cs.Environment["AETHER_IDENTITY_SERVICE"] == "" {
 			cs.Environment["AETHER_IDENTITY_SERVICE"] = name
 		}
 
-		// Mount identity socket
-		cs.Volumes = append(cs.Volumes,
-			"aether-identity-socket:"+filepath.Dir(c.config.AgentSocketPath)+":ro")
+		// Mount the service token volume only: it holds the token file and the token
+		// socket of this service. The shared agent socket serves no tokens.
+		cs.Volumes = append(cs.Volumes, tokenVolumeName(name)+":"+filepath.Dir(TokenFilePath(name))+":ro")
+		cs.Environment["AETHER_IDENTITY_TOKEN_FILE"] = TokenFilePath(name)
 	}
 
 	// Copy user volumes
@@ -186,6 +230,7 @@ type ComposeFile struct {
 	Services map[string]ComposeService `yaml:"services"`
 	Networks map[string]ComposeNetwork `yaml:"networks,omitempty"`
 	Volumes  map[string]ComposeVolume  `yaml:"volumes,omitempty"`
+	Secrets  map[string]ComposeSecret  `yaml:"secrets,omitempty"`
 }
 
 // ComposeService represents a service in docker-compose
@@ -200,6 +245,7 @@ type ComposeService struct {
 	Networks    []string            `yaml:"networks,omitempty"`
 	DependsOn   []string            `yaml:"depends_on,omitempty"`
 	Labels      map[string]string   `yaml:"labels,omitempty"`
+	Secrets     []string            `yaml:"secrets,omitempty"`
 	HealthCheck *ComposeHealthCheck `yaml:"healthcheck,omitempty"`
 }
 
@@ -221,6 +267,13 @@ type ComposeVolume struct {
 	Driver string `yaml:"driver,omitempty"`
 }
 
+// ComposeSecret represents a top-level secret in docker-compose
+type ComposeSecret struct {
+	File        string `yaml:"file,omitempty"`
+	External    bool   `yaml:"external,omitempty"`
+	Environment string `yaml:"environment,omitempty"`
+}
+
 // ToYAML converts the compose file to YAML format
 func (cf *ComposeFile) ToYAML() (string, error) {
 	var sb strings.Builder
@@ -228,7 +281,8 @@ func (cf *ComposeFile) ToYAML() (string, error) {
 	sb.WriteString("version: \"" + cf.Version + "\"\n")
 	sb.WriteString("\nservices:\n")
 
-	for name, svc := range cf.Services {
+	for _, name := range sortedKeys(cf.Services) {
+		svc := cf.Services[name]
 		sb.WriteString(fmt.Sprintf("  %s:\n", name))
 		sb.WriteString(fmt.Sprintf("    image: %s\n", svc.Image))
 
@@ -253,8 +307,8 @@ func (cf *ComposeFile) ToYAML() (string, error) {
 
 		if len(svc.Environment) > 0 {
 			sb.WriteString("    environment:\n")
-			for k, v := range svc.Environment {
-				sb.WriteString(fmt.Sprintf("      %s: %s\n", k, formatEnvValue(v)))
+			for _, k := range sortedKeys(svc.Environment) {
+				sb.WriteString(fmt.Sprintf("      %s: %s\n", k, formatEnvValue(svc.Environment[k])))
 			}
 		}
 
@@ -281,8 +335,15 @@ func (cf *ComposeFile) ToYAML() (string, error) {
 
 		if len(svc.Labels) > 0 {
 			sb.WriteString("    labels:\n")
-			for k, v := range svc.Labels {
-				sb.WriteString(fmt.Sprintf("      - \"%s=%s\"\n", k, v))
+			for _, k := range sortedKeys(svc.Labels) {
+				sb.WriteString(fmt.Sprintf("      - \"%s=%s\"\n", k, svc.Labels[k]))
+			}
+		}
+
+		if len(svc.Secrets) > 0 {
+			sb.WriteString("    secrets:\n")
+			for _, secret := range svc.Secrets {
+				sb.WriteString(fmt.Sprintf("      - %s\n", secret))
 			}
 		}
 
@@ -303,7 +364,8 @@ func (cf *ComposeFile) ToYAML() (string, error) {
 
 	if len(cf.Networks) > 0 {
 		sb.WriteString("\nnetworks:\n")
-		for name, net := range cf.Networks {
+		for _, name := range sortedKeys(cf.Networks) {
+			net := cf.Networks[name]
 			sb.WriteString(fmt.Sprintf("  %s:\n", name))
 			if net.Driver != "" {
 				sb.WriteString(fmt.Sprintf("    driver: %s\n", net.Driver))
@@ -313,7 +375,8 @@ func (cf *ComposeFile) ToYAML() (string, error) {
 
 	if len(cf.Volumes) > 0 {
 		sb.WriteString("\nvolumes:\n")
-		for name, vol := range cf.Volumes {
+		for _, name := range sortedKeys(cf.Volumes) {
+			vol := cf.Volumes[name]
 			sb.WriteString(fmt.Sprintf("  %s:\n", name))
 			if vol.Driver != "" {
 				sb.WriteString(fmt.Sprintf("    driver: %s\n", vol.Driver))
@@ -321,6 +384,23 @@ func (cf *ComposeFile) ToYAML() (string, error) {
 		}
 	}
 
+	if len(cf.Secrets) > 0 {
+		sb.WriteString("\nsecrets:\n")
+		for _, name := range sortedKeys(cf.Secrets) {
+			secret := cf.Secrets[name]
+			sb.WriteString(fmt.Sprintf("  %s:\n", name))
+			if secret.File != "" {
+				sb.WriteString(fmt.Sprintf("    file: %s\n", secret.File))
+			}
+			if secret.External {
+				sb.WriteString("    external: true\n")
+			}
+			if secret.Environment != "" {
+				sb.WriteString(fmt.Sprintf("    environment: %s\n", secret.Environment))
+			}
+		}
+	}
+
 	return sb.String(), nil
 }
 
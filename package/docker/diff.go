@@ -0,0 +1,235 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// FileDiff describes what generating an artifact would change in the file on disk
+type FileDiff struct {
+	// Path is the path of the file on disk
+	Path string
+
+	// Exists is false when the file does not exist yet
+	Exists bool
+
+	// Changed is true when the generated content differs from the file
+	Changed bool
+
+	// Unified is the diff in unified format, empty when unchanged
+	Unified string
+}
+
+// String returns the unified diff, or a note when the file is up to date
+func (d FileDiff) String() string {
+	if !d.Changed {
+		return fmt.Sprintf("%s: up to date\n", d.Path)
+	}
+	return d.Unified
+}
+
+// HasChanges returns true if any of the diffs has changes
+func HasChanges(diffs []FileDiff) bool {
+	for _, d := range diffs {
+		if d.Changed {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffCompose compares the compose file that GenerateCompose would write with the file at path
+func (s *Stack) DiffCompose(path string) (FileDiff, error) {
+	data, err := s.client.renderCompose(s)
+	if err != nil {
+		return FileDiff{}, err
+	}
+	return diffFile(path, data)
+}
+
+// DiffEnv compares the .env file that GenerateEnv would write with the file at path
+func (s *Stack) DiffEnv(path string) (FileDiff, error) {
+	return diffFile(path, s.client.renderEnv(s))
+}
+
+// DiffAgentConfig compares the agent configuration that GenerateAgentConfig would write with the file at path
+func (s *Stack) DiffAgentConfig(path string) (FileDiff, error) {
+	data, err := s.client.renderAgentConfig(s)
+	if err != nil {
+		return FileDiff{}, err
+	}
+	return diffFile(path, data)
+}
+
+// DiffEnvironment compares the artifacts that GenerateEnvironment would write with the files in dir
+func (s *Stack) DiffEnvironment(environment, dir string) ([]FileDiff, error) {
+	resolved, err := s.ForEnvironment(environment)
+	if err != nil {
+		return nil, err
+	}
+
+	compose, err := resolved.DiffCompose(filepath.Join(dir, ComposeFileName))
+	if err != nil {
+		return nil, err
+	}
+	env, err := resolved.DiffEnv(filepath.Join(dir, EnvFileName))
+	if err != nil {
+		return nil, err
+	}
+	agent, err := resolved.DiffAgentConfig(filepath.Join(dir, AgentConfigFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	return []FileDiff{compose, env, agent}, nil
+}
+
+// diffFile compares generated content with the file at path
+func diffFile(path string, generated []byte) (FileDiff, error) {
+	diff := FileDiff{Path: path, Exists: true}
+
+	current, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		diff.Exists = false
+	} else if err != nil {
+		return FileDiff{}, WrapError(err, "failed to read %q", path)
+	}
+
+	if diff.Exists && string(current) == string(generated) {
+		return diff, nil
+	}
+
+	oldName := path
+	if !diff.Exists {
+		oldName = "/dev/null"
+	}
+
+	diff.Changed = true
+	diff.Unified = unifiedDiff(oldName, path+" (generated)", splitLines(string(current)), splitLines(string(generated)))
+	return diff, nil
+}
+
+// diffOp is an operation of a line edit script: ' ' keeps, '-' removes and '+' adds a line
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines returns the shortest edit script turning a into b (longest common subsequence)
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// unifiedDiff formats the differences between a and b in unified format
+func unifiedDiff(oldName, newName string, a, b []string) string {
+	ops := diffLines(a, b)
+
+	// Group changes into hunks with surrounding context
+	type hunk struct{ start, end int }
+	var hunks []hunk
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		start, end := max(i-diffContext, 0), min(i+diffContext+1, len(ops))
+		if n := len(hunks); n > 0 && start <= hunks[n-1].end {
+			hunks[n-1].end = end
+		} else {
+			hunks = append(hunks, hunk{start, end})
+		}
+	}
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	// oldPos[i] and newPos[i] count the lines of a and b before ops[i]
+	oldPos := make([]int, len(ops)+1)
+	newPos := make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if op.kind != '+' {
+			oldPos[i+1]++
+		}
+		if op.kind != '-' {
+			newPos[i+1]++
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldName, newName))
+	for _, h := range hunks {
+		sb.WriteString(fmt.Sprintf("@@ -%s +%s @@\n",
+			hunkRange(oldPos[h.start], oldPos[h.end]-oldPos[h.start]),
+			hunkRange(newPos[h.start], newPos[h.end]-newPos[h.start])))
+		for _, op := range ops[h.start:h.end] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+// hunkRange formats the line range of a hunk; start is the number of lines before it
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
+
+// splitLines splits content into lines without their terminators
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
@@ -22,6 +22,12 @@ type IdentityBinding struct {
 
 	// Audience specifies the intended audience for tokens
 	Audience string
+
+	// ClientID is the OAuth client the agent authenticates as for this service
+	ClientID string
+
+	// ClientSecret references the client secret; it is mounted in the agent only
+	ClientSecret *SecretRef
 }
 
 // Validate validates the identity binding
@@ -36,6 +42,18 @@ func (ib IdentityBinding) Validate() error {
 		return NewValidationError("identity binding must specify at least one scope or role")
 	}
 
+	// The agent needs both halves of the client credentials
+	if ib.ClientSecret != nil {
+		if ib.ClientID == "" {
+			return NewValidationError("identity binding with a client secret must specify a client ID")
+		}
+		if err := ib.ClientSecret.Validate(); err != nil {
+			return err
+		}
+	} else if ib.ClientID != "" {
+		return NewValidationError("identity binding with a client ID must reference a client secret")
+	}
+
 	return nil
 }
 
@@ -0,0 +1,79 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultJenkinsCredentialID is the default OpenID Connect id token credential of Jenkins pipelines
+const DefaultJenkinsCredentialID = "aether-oidc-token"
+
+// GenerateJenkinsfile generates a declarative Jenkinsfile.
+// The ID token comes from an "OpenID Connect id token" credential of the oidc-provider
+// plugin, whose audience must be accepted by the federated issuer and whose claim
+// templates should emit repository, branch and environment claims for trust policies.
+func (p *Pipeline) GenerateJenkinsfile() (string, error) {
+	if err := p.stack.Validate(); err != nil {
+		return "", err
+	}
+
+	credentialID := p.identity.CredentialID
+	if credentialID == "" {
+		credentialID = DefaultJenkinsCredentialID
+	}
+
+	var b strings.Builder
+	b.WriteString("pipeline {\n")
+	b.WriteString("    agent any\n\n")
+
+	b.WriteString("    environment {\n")
+	variables := p.variables()
+	for _, name := range sortedKeys(variables) {
+		fmt.Fprintf(&b, "        %s = %s\n", name, groovyString(variables[name]))
+	}
+	b.WriteString("    }\n\n")
+
+	b.WriteString("    stages {\n")
+	for _, name := range sortedKeys(p.stack.services) {
+		svc := p.stack.services[name]
+		writeJenkinsStage(&b, fmt.Sprintf("Build %s", name), "", credentialID, []string{
+			p.exchangeCommand(),
+			fmt.Sprintf("export %s", TokenVariable),
+			fmt.Sprintf("docker build -t %s .", svc.Image),
+			fmt.Sprintf("docker push %s", svc.Image),
+		})
+	}
+	writeJenkinsStage(&b, "Deploy", "main", credentialID, []string{
+		p.exchangeCommand(),
+		fmt.Sprintf("export %s", TokenVariable),
+		"docker-compose -f docker-compose.yml up -d",
+	})
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+
+	return b.String(), nil
+}
+
+// writeJenkinsStage writes a stage running commands with the ID token bound to IDTokenVariable.
+// Commands run in a single-quoted sh block so Groovy leaves shell variables to the shell.
+func writeJenkinsStage(b *strings.Builder, name, branch, credentialID string, commands []string) {
+	fmt.Fprintf(b, "        stage(%s) {\n", groovyString(name))
+	if branch != "" {
+		fmt.Fprintf(b, "            when { branch %s }\n", groovyString(branch))
+	}
+	b.WriteString("            steps {\n")
+	fmt.Fprintf(b, "                withCredentials([string(credentialsId: %s, variable: '%s')]) {\n", groovyString(credentialID), IDTokenVariable)
+	b.WriteString("                    sh '''\n")
+	for _, command := range commands {
+		fmt.Fprintf(b, "                        %s\n", strings.ReplaceAll(command, `\`, `\\`))
+	}
+	b.WriteString("                    '''\n")
+	b.WriteString("                }\n")
+	b.WriteString("            }\n")
+	b.WriteString("        }\n")
+}
+
+// groovyString quotes a value as a single-quoted Groovy string
+func groovyString(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
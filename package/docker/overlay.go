@@ -0,0 +1,193 @@
+package docker
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+)
+
+// Overlay holds the overrides of one environment (dev, staging, prod),
+// applied on top of the base stack definition
+type Overlay struct {
+	// IdentityEndpoint overrides the client identity endpoint
+	IdentityEndpoint string
+
+	// AgentImage overrides the identity agent image
+	AgentImage string
+
+	// AgentConfig replaces the stack agent configuration
+	AgentConfig *AgentConfig
+
+	// Environment is merged into the environment of every service
+	Environment map[string]string
+
+	// Services overrides services of the base stack by name
+	Services map[string]ServiceOverride
+
+	// Exclude removes services from this environment
+	Exclude []string
+}
+
+// ServiceOverride overrides fields of a base service
+// Zero values keep the base value; maps are merged and slices replace the base value
+type ServiceOverride struct {
+	// Image replaces the service image
+	Image string
+
+	// Ports replace the exposed ports when non-nil
+	Ports []string
+
+	// Environment is merged into the service environment
+	Environment map[string]string
+
+	// Secrets are merged into the service secrets
+	Secrets map[string]SecretRef
+
+	// Volumes replace the mounted volumes when non-nil
+	Volumes []string
+
+	// Labels are merged into the service labels
+	Labels map[string]string
+
+	// Command replaces the command when non-nil
+	Command []string
+
+	// Identity replaces the identity binding
+	Identity *IdentityBinding
+
+	// HealthCheck replaces the health check
+	HealthCheck *HealthCheck
+}
+
+// Overlay adds or replaces the overlay of an environment
+func (s *Stack) Overlay(environment string, overlay Overlay) *Stack {
+	s.overlays[environment] = overlay
+	return s
+}
+
+// Environments returns the environments with an overlay, in order
+func (s *Stack) Environments() []string {
+	return sortedKeys(s.overlays)
+}
+
+// ForEnvironment returns the stack resolved for an environment: the overlay is applied to
+// the services, and the client configuration uses the environment name and overrides
+func (s *Stack) ForEnvironment(environment string) (*Stack, error) {
+	overlay, ok := s.overlays[environment]
+	if !ok {
+		return nil, NewValidationError(fmt.Sprintf("stack %q has no overlay for environment %q", s.name, environment))
+	}
+
+	config := s.client.config
+	config.Environment = environment
+	if overlay.IdentityEndpoint != "" {
+		config.IdentityEndpoint = overlay.IdentityEndpoint
+	}
+	if overlay.AgentImage != "" {
+		config.AgentImage = overlay.AgentImage
+	}
+
+	resolved := NewClient(config).NewStack(s.name)
+	resolved.agentConfig = s.agentConfig
+	if overlay.AgentConfig != nil {
+		resolved.agentConfig = *overlay.AgentConfig
+	}
+
+	for _, name := range overlay.Exclude {
+		if _, ok := s.services[name]; !ok {
+			return nil, NewValidationError(fmt.Sprintf("overlay %q excludes unknown service %q", environment, name))
+		}
+	}
+	for name := range overlay.Services {
+		if _, ok := s.services[name]; !ok {
+			return nil, NewValidationError(fmt.Sprintf("overlay %q overrides unknown service %q", environment, name))
+		}
+		if slices.Contains(overlay.Exclude, name) {
+			return nil, NewValidationError(fmt.Sprintf("overlay %q both overrides and excludes service %q", environment, name))
+		}
+	}
+
+	for name, svc := range s.services {
+		if slices.Contains(overlay.Exclude, name) {
+			continue
+		}
+		resolved.services[name] = overlay.apply(name, svc)
+	}
+
+	return resolved, nil
+}
+
+// GenerateEnvironment generates the compose file, .env file and agent configuration
+// of an environment into dir
+func (s *Stack) GenerateEnvironment(environment, dir string) error {
+	resolved, err := s.ForEnvironment(environment)
+	if err != nil {
+		return err
+	}
+
+	if err := resolved.GenerateCompose(filepath.Join(dir, ComposeFileName)); err != nil {
+		return err
+	}
+	if err := resolved.GenerateEnv(filepath.Join(dir, EnvFileName)); err != nil {
+		return err
+	}
+	return resolved.GenerateAgentConfig(filepath.Join(dir, AgentConfigFileName))
+}
+
+// GenerateEnvironments generates the artifacts of every environment into dir/<environment>
+func (s *Stack) GenerateEnvironments(dir string) error {
+	if len(s.overlays) == 0 {
+		return NewValidationError(fmt.Sprintf("stack %q has no environment overlays", s.name))
+	}
+
+	for _, environment := range s.Environments() {
+		if err := s.GenerateEnvironment(environment, filepath.Join(dir, environment)); err != nil {
+			return WrapError(err, "environment %q", environment)
+		}
+	}
+	return nil
+}
+
+// apply returns the service with the overlay and its service override applied
+func (o Overlay) apply(name string, svc Service) Service {
+	override := o.Services[name]
+
+	if override.Image != "" {
+		svc.Image = override.Image
+	}
+	if override.Ports != nil {
+		svc.Ports = override.Ports
+	}
+	if override.Volumes != nil {
+		svc.Volumes = override.Volumes
+	}
+	if override.Command != nil {
+		svc.Command = override.Command
+	}
+	if override.Identity != nil {
+		svc.Identity = *override.Identity
+	}
+	if override.HealthCheck != nil {
+		svc.HealthCheck = override.HealthCheck
+	}
+
+	svc.Environment = mergeMaps(svc.Environment, o.Environment, override.Environment)
+	svc.Secrets = mergeMaps(svc.Secrets, override.Secrets)
+	svc.Labels = mergeMaps(svc.Labels, override.Labels)
+
+	return svc
+}
+
+// mergeMaps returns a new map with the entries of all maps, later maps winning
+func mergeMaps[V any](maps ...map[string]V) map[string]V {
+	var result map[string]V
+	for _, m := range maps {
+		for k, v := range m {
+			if result == nil {
+				result = make(map[string]V)
+			}
+			result[k] = v
+		}
+	}
+	return result
+}
@@ -2,12 +2,18 @@ package docker
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
 )
 
-// Pipeline provides CI/CD helpers for Docker-based identity workflows
+// Pipeline provides CI/CD helpers for Docker-based identity workflows.
+// Generated jobs hold no static credentials: each job requests its CI platform's
+// OIDC ID token and exchanges it for an Aether token at the identity endpoint,
+// where a trust policy matches the token's repository, branch and environment.
 type Pipeline struct {
-	client *Client
-	stack  *Stack
+	client   *Client
+	stack    *Stack
+	identity PipelineIdentity
 }
 
 // NewPipeline creates a new pipeline helper for the given stack
@@ -18,6 +24,12 @@ func (s *Stack) NewPipeline() *Pipeline {
 	}
 }
 
+// WithIdentity sets the identity requested by the generated jobs
+func (p *Pipeline) WithIdentity(identity PipelineIdentity) *Pipeline {
+	p.identity = identity
+	return p
+}
+
 // PipelineStage represents a CI/CD pipeline stage
 type PipelineStage struct {
 	Name        string
@@ -32,41 +44,112 @@ type PipelineIdentity struct {
 	Scopes      []string
 	Roles       []string
 	ServiceName string
+
+	// Audience is the audience of the CI OIDC ID token.
+	// Defaults to the identity endpoint; it must be one of the federated issuer's audiences.
+	Audience string
+
+	// Environment is the CI environment the deploy job runs in, matched by trust policies.
+	// Defaults to the client environment.
+	Environment string
+
+	// ServiceAccount is the Kubernetes service account of Woodpecker steps
+	// (Kubernetes backend), whose token is sent as a client assertion
+	ServiceAccount string
+
+	// CredentialID is the Jenkins credential of the OpenID Connect provider plugin
+	// issuing the ID token. Defaults to "aether-oidc-token".
+	CredentialID string
+}
+
+const (
+	// TokenExchangeGrantType is the RFC 8693 grant used to exchange CI ID tokens
+	TokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+	// JWTTokenType is the subject token type of CI ID tokens
+	JWTTokenType = "urn:ietf:params:oauth:token-type:jwt"
+
+	// JWTBearerAssertionType is the RFC 7523 client assertion type of service account tokens
+	JWTBearerAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	// IDTokenVariable holds the CI ID token in generated jobs
+	IDTokenVariable = "AETHER_ID_TOKEN"
+
+	// TokenVariable holds the exchanged Aether token in generated jobs
+	TokenVariable = "AETHER_TOKEN"
+)
+
+// audience returns the audience requested for CI ID tokens
+func (p *Pipeline) audience() string {
+	if p.identity.Audience != "" {
+		return p.identity.Audience
+	}
+	return p.client.config.IdentityEndpoint
+}
+
+// environment returns the deploy environment matched by trust policies
+func (p *Pipeline) environment() string {
+	if p.identity.Environment != "" {
+		return p.identity.Environment
+	}
+	return p.client.config.Environment
+}
+
+// variables returns the variables shared by all generated jobs
+func (p *Pipeline) variables() map[string]string {
+	return map[string]string{
+		"AETHER_IDENTITY_ENDPOINT": p.client.config.IdentityEndpoint,
+		"AETHER_PROJECT":           p.client.config.Project,
+		"AETHER_STACK":             p.stack.name,
+	}
+}
+
+// exchangeCommand returns the shell command exchanging the ID token held in
+// IDTokenVariable for an Aether token stored in TokenVariable
+func (p *Pipeline) exchangeCommand() string {
+	return p.tokenCommand(
+		"-d grant_type="+TokenExchangeGrantType,
+		"-d subject_token_type="+JWTTokenType,
+		fmt.Sprintf("--data-urlencode \"subject_token=$%s\"", IDTokenVariable),
+	)
 }
 
-// GenerateGitLabCI generates a GitLab CI configuration
+// tokenCommand returns a curl request to the token endpoint with the given form parameters
+func (p *Pipeline) tokenCommand(params ...string) string {
+	if len(p.identity.Scopes) > 0 {
+		params = append(params, fmt.Sprintf("-d \"scope=%s\"", strings.Join(p.identity.Scopes, " ")))
+	}
+	return fmt.Sprintf(
+		"%s=$(curl -sSf -X POST \"$AETHER_IDENTITY_ENDPOINT/oauth/token\" %s | jq -r .access_token)",
+		TokenVariable, strings.Join(params, " "),
+	)
+}
+
+// GenerateGitLabCI generates a GitLab CI configuration.
+// Each job declares an id_tokens entry and exchanges it in before_script.
 func (p *Pipeline) GenerateGitLabCI() (*GitLabCIConfig, error) {
 	if err := p.stack.Validate(); err != nil {
 		return nil, err
 	}
 
 	config := &GitLabCIConfig{
-		Stages: []string{"authenticate", "build", "deploy"},
-		Variables: map[string]string{
-			"AETHER_IDENTITY_ENDPOINT": p.client.config.IdentityEndpoint,
-			"AETHER_PROJECT":           p.client.config.Project,
-			"AETHER_STACK":             p.stack.name,
-		},
-		Jobs: make(map[string]GitLabCIJob),
+		Stages:    []string{"build", "deploy"},
+		Variables: p.variables(),
+		Jobs:      make(map[string]GitLabCIJob),
 	}
 
-	// Authenticate job
-	config.Jobs["authenticate"] = GitLabCIJob{
-		Stage: "authenticate",
-		Image: p.client.config.AgentImage,
-		Script: []string{
-			"echo \"Authenticating with Aether Identity...\"",
-			"aether-agent authenticate || exit 1",
-			"echo \"Authentication successful\"",
-		},
-		Artifacts: &GitLabCIArtifacts{
-			Paths:    []string{".aether/token"},
-			ExpireIn: "1 hour",
-		},
+	idTokens := map[string]GitLabCIIDToken{
+		IDTokenVariable: {Aud: p.audience()},
+	}
+	beforeScript := []string{
+		"apk add --no-cache curl jq",
+		p.exchangeCommand(),
+		fmt.Sprintf("export %s", TokenVariable),
 	}
 
 	// Build job for each service
-	for name, svc := range p.stack.services {
+	for _, name := range sortedKeys(p.stack.services) {
+		svc := p.stack.services[name]
 		jobName := fmt.Sprintf("build:%s", name)
 		config.Jobs[jobName] = GitLabCIJob{
 			Stage:    "build",
@@ -75,32 +158,46 @@ func (p *Pipeline) GenerateGitLabCI() (*GitLabCIConfig, error) {
 			Variables: map[string]string{
 				"DOCKER_DRIVER": "overlay2",
 			},
+			IDTokens:     idTokens,
+			BeforeScript: beforeScript,
 			Script: []string{
 				fmt.Sprintf("docker build -t %s .", svc.Image),
 				fmt.Sprintf("docker push %s", svc.Image),
 			},
-			Dependencies: []string{"authenticate"},
 		}
 	}
 
 	// Deploy job
 	config.Jobs["deploy"] = GitLabCIJob{
-		Stage: "deploy",
-		Image: "docker/compose:latest",
+		Stage:        "deploy",
+		Image:        "docker/compose:latest",
+		IDTokens:     idTokens,
+		BeforeScript: beforeScript,
 		Script: []string{
 			"docker-compose -f docker-compose.yml up -d",
 		},
-		Dependencies: []string{"authenticate"},
 		Environment: &GitLabCIEnvironment{
-			Name: p.stack.name,
+			Name: p.environment(),
 		},
 	}
 
 	return config, nil
 }
 
-// GenerateGitHubActions generates GitHub Actions workflow configuration
+// GenerateGitHubActions generates GitHub Actions workflow configuration.
+// The workflow is granted id-token: write and exchanges the Actions ID token before building.
 func (p *Pipeline) GenerateGitHubActions() (*GitHubActionsConfig, error) {
+	return p.generateActions()
+}
+
+// GenerateGiteaActions generates a Gitea Actions workflow configuration.
+// Gitea Actions reads the GitHub Actions syntax and exposes the same ID token request variables.
+func (p *Pipeline) GenerateGiteaActions() (*GitHubActionsConfig, error) {
+	return p.generateActions()
+}
+
+// generateActions generates a workflow for GitHub-compatible Actions runners
+func (p *Pipeline) generateActions() (*GitHubActionsConfig, error) {
 	if err := p.stack.Validate(); err != nil {
 		return nil, err
 	}
@@ -112,54 +209,48 @@ func (p *Pipeline) GenerateGitHubActions() (*GitHubActionsConfig, error) {
 				Branches: []string{"main"},
 			},
 		},
-		Jobs: make(map[string]GitHubActionsJob),
-	}
-
-	// Authenticate job
-	config.Jobs["authenticate"] = GitHubActionsJob{
-		RunsOn: "ubuntu-latest",
-		Steps: []GitHubActionsStep{
-			{
-				Name: "Checkout",
-				Uses: "actions/checkout@v4",
-			},
-			{
-				Name: "Authenticate with Aether Identity",
-				Uses: "aether/identity-action@v1",
-				With: map[string]string{
-					"endpoint": p.client.config.IdentityEndpoint,
-					"project":  p.client.config.Project,
-				},
-			},
-		},
-		Outputs: map[string]string{
-			"token": "${{ steps.auth.outputs.token }}",
+		Permissions: map[string]string{
+			"contents": "read",
+			"id-token": "write",
 		},
+		Env:  p.variables(),
+		Jobs: make(map[string]GitHubActionsJob),
 	}
 
-	// Build job
-	buildSteps := []GitHubActionsStep{
+	steps := []GitHubActionsStep{
 		{
 			Name: "Checkout",
 			Uses: "actions/checkout@v4",
 		},
+		{
+			Name: "Authenticate with Aether Identity",
+			Run: strings.Join([]string{
+				fmt.Sprintf(
+					"%s=$(curl -sSf -H \"Authorization: bearer $ACTIONS_ID_TOKEN_REQUEST_TOKEN\" \"$ACTIONS_ID_TOKEN_REQUEST_URL&audience=%s\" | jq -r .value)",
+					IDTokenVariable, url.QueryEscape(p.audience()),
+				),
+				p.exchangeCommand(),
+				fmt.Sprintf("echo \"::add-mask::$%s\"", TokenVariable),
+				fmt.Sprintf("echo \"%s=$%s\" >> \"$GITHUB_ENV\"", TokenVariable, TokenVariable),
+			}, "\n"),
+		},
 		{
 			Name: "Set up Docker Buildx",
 			Uses: "docker/setup-buildx-action@v3",
 		},
 	}
 
-	for name, svc := range p.stack.services {
-		buildSteps = append(buildSteps, GitHubActionsStep{
+	for _, name := range sortedKeys(p.stack.services) {
+		steps = append(steps, GitHubActionsStep{
 			Name: fmt.Sprintf("Build %s", name),
-			Run:  fmt.Sprintf("docker build -t %s .", svc.Image),
+			Run:  fmt.Sprintf("docker build -t %s .", p.stack.services[name].Image),
 		})
 	}
 
 	config.Jobs["build"] = GitHubActionsJob{
-		RunsOn: "ubuntu-latest",
-		Needs:  []string{"authenticate"},
-		Steps:  buildSteps,
+		RunsOn:      "ubuntu-latest",
+		Environment: p.environment(),
+		Steps:       steps,
 	}
 
 	return config, nil
@@ -174,14 +265,21 @@ type GitLabCIConfig struct {
 
 // GitLabCIJob represents a GitLab CI job
 type GitLabCIJob struct {
-	Stage        string               `yaml:"stage"`
-	Image        string               `yaml:"image,omitempty"`
-	Services     []string             `yaml:"services,omitempty"`
-	Variables    map[string]string    `yaml:"variables,omitempty"`
-	Script       []string             `yaml:"script"`
-	Dependencies []string             `yaml:"dependencies,omitempty"`
-	Artifacts    *GitLabCIArtifacts   `yaml:"artifacts,omitempty"`
-	Environment  *GitLabCIEnvironment `yaml:"environment,omitempty"`
+	Stage        string                     `yaml:"stage"`
+	Image        string                     `yaml:"image,omitempty"`
+	Services     []string                   `yaml:"services,omitempty"`
+	Variables    map[string]string          `yaml:"variables,omitempty"`
+	IDTokens     map[string]GitLabCIIDToken `yaml:"id_tokens,omitempty"`
+	BeforeScript []string                   `yaml:"before_script,omitempty"`
+	Script       []string                   `yaml:"script"`
+	Dependencies []string                   `yaml:"dependencies,omitempty"`
+	Artifacts    *GitLabCIArtifacts         `yaml:"artifacts,omitempty"`
+	Environment  *GitLabCIEnvironment       `yaml:"environment,omitempty"`
+}
+
+// GitLabCIIDToken represents a GitLab CI OIDC ID token request
+type GitLabCIIDToken struct {
+	Aud string `yaml:"aud"`
 }
 
 // GitLabCIArtifacts represents GitLab CI artifacts
@@ -197,9 +295,11 @@ type GitLabCIEnvironment struct {
 
 // GitHubActionsConfig represents a GitHub Actions workflow
 type GitHubActionsConfig struct {
-	Name string                      `yaml:"name"`
-	On   GitHubActionsOn             `yaml:"on"`
-	Jobs map[string]GitHubActionsJob `yaml:"jobs"`
+	Name        string                      `yaml:"name"`
+	On          GitHubActionsOn             `yaml:"on"`
+	Permissions map[string]string           `yaml:"permissions,omitempty"`
+	Env         map[string]string           `yaml:"env,omitempty"`
+	Jobs        map[string]GitHubActionsJob `yaml:"jobs"`
 }
 
 // GitHubActionsOn represents GitHub Actions triggers
@@ -220,10 +320,11 @@ type GitHubActionsPR struct {
 
 // GitHubActionsJob represents a GitHub Actions job
 type GitHubActionsJob struct {
-	RunsOn  string              `yaml:"runs-on"`
-	Needs   []string            `yaml:"needs,omitempty"`
-	Steps   []GitHubActionsStep `yaml:"steps"`
-	Outputs map[string]string   `yaml:"outputs,omitempty"`
+	RunsOn      string              `yaml:"runs-on"`
+	Needs       []string            `yaml:"needs,omitempty"`
+	Environment string              `yaml:"environment,omitempty"`
+	Steps       []GitHubActionsStep `yaml:"steps"`
+	Outputs     map[string]string   `yaml:"outputs,omitempty"`
 }
 
 // GitHubActionsStep represents a GitHub Actions step
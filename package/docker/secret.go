@@ -0,0 +1,82 @@
+package docker
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// secretNamePattern matches valid compose secret names
+var secretNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// sensitiveEnvSuffixes flag environment variable names that must be passed as secrets
+var sensitiveEnvSuffixes = []string{"PASSWORD", "PASSWD", "SECRET", "TOKEN", "PRIVATE_KEY", "API_KEY", "CREDENTIALS"}
+
+// SecretRef references a secret managed outside the stack
+// Exactly one of File, External or EnvironmentVariable must be set
+type SecretRef struct {
+	// Name is the compose secret name
+	// The secret is mounted at /run/secrets/<Name>
+	Name string
+
+	// File reads the secret from a local file (development)
+	File string
+
+	// External uses a secret created outside compose (docker secret create, Swarm)
+	External bool
+
+	// EnvironmentVariable reads the secret from the environment of the host running compose
+	EnvironmentVariable string
+}
+
+// Validate validates the secret reference
+func (r SecretRef) Validate() error {
+	if !secretNamePattern.MatchString(r.Name) {
+		return NewValidationError(fmt.Sprintf("invalid secret name %q", r.Name))
+	}
+
+	sources := 0
+	if r.File != "" {
+		sources++
+	}
+	if r.External {
+		sources++
+	}
+	if r.EnvironmentVariable != "" {
+		sources++
+	}
+	if sources != 1 {
+		return NewValidationError(fmt.Sprintf("secret %q must have exactly one of file, external or environment variable", r.Name))
+	}
+
+	return nil
+}
+
+// MountPath returns the path where the secret is mounted in the container
+func (r SecretRef) MountPath() string {
+	return "/run/secrets/" + r.Name
+}
+
+// composeSecret returns the top-level compose definition of the secret
+func (r SecretRef) composeSecret() ComposeSecret {
+	return ComposeSecret{
+		File:        r.File,
+		External:    r.External,
+		Environment: r.EnvironmentVariable,
+	}
+}
+
+// isInlineSecret returns true if an environment variable looks like a credential with an inline value.
+// Interpolated values (${VAR}) are resolved by compose from the host and are not inline.
+func isInlineSecret(name, value string) bool {
+	if value == "" || (strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}")) {
+		return false
+	}
+	upper := strings.ToUpper(name)
+	for _, suffix := range sensitiveEnvSuffixes {
+		if strings.HasSuffix(upper, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -13,6 +13,10 @@ type Service struct {
 	// Environment variables (non-sensitive only)
 	Environment map[string]string
 
+	// Secrets maps environment variable names to secret references
+	// The secret is mounted in the container and <NAME>_FILE points to it
+	Secrets map[string]SecretRef
+
 	// Volumes to mount (format: "source:target" or "target")
 	Volumes []string
 
@@ -52,6 +56,22 @@ func (s Service) Validate() error {
 		return NewValidationError("service image is required")
 	}
 
+	// Credentials must be referenced as secrets, never inlined
+	for name, value := range s.Environment {
+		if isInlineSecret(name, value) {
+			return NewValidationError(fmt.Sprintf("environment variable %q looks sensitive; use Secrets instead of an inline value", name))
+		}
+	}
+
+	for name, ref := range s.Secrets {
+		if _, ok := s.Environment[name]; ok {
+			return NewValidationError(fmt.Sprintf("%q is defined both as environment variable and secret", name))
+		}
+		if err := ref.Validate(); err != nil {
+			return err
+		}
+	}
+
 	// Validate identity binding
 	if err := s.Identity.Validate(); err != nil {
 		return err
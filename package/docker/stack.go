@@ -1,10 +1,17 @@
 package docker
 
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
 // Stack represents a Docker Compose stack with identity-aware services
 type Stack struct {
 	name        string
 	client      *Client
 	services    map[string]Service
+	overlays    map[string]Overlay
 	agentConfig AgentConfig
 }
 
@@ -65,10 +72,24 @@ func (s *Stack) Validate() error {
 		return NewValidationError("stack must have at least one service")
 	}
 
-	for name, svc := range s.services {
+	secrets := make(map[string]SecretRef)
+	for _, name := range sortedKeys(s.services) {
+		svc := s.services[name]
 		if err := svc.Validate(); err != nil {
 			return WrapError(err, "service %q validation failed", name)
 		}
+
+		// Services sharing a secret must agree on its source
+		refs := slices.Collect(maps.Values(svc.Secrets))
+		if svc.HasIdentity() && svc.Identity.ClientSecret != nil {
+			refs = append(refs, *svc.Identity.ClientSecret)
+		}
+		for _, ref := range refs {
+			if existing, ok := secrets[ref.Name]; ok && existing != ref {
+				return NewValidationError(fmt.Sprintf("secret %q is defined with different sources", ref.Name))
+			}
+			secrets[ref.Name] = ref
+		}
 	}
 
 	return nil
@@ -0,0 +1,100 @@
+package docker
+
+import (
+	"fmt"
+)
+
+// ServiceAccountTokenPath is where Kubernetes mounts the service account token of a pod
+const ServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// GenerateWoodpecker generates a Woodpecker CI pipeline configuration.
+// Woodpecker does not issue OIDC ID tokens: steps run on the Kubernetes backend under
+// PipelineIdentity.ServiceAccount and send its token as an RFC 7523 client assertion,
+// matched by a federated identity binding of the cluster issuer.
+func (p *Pipeline) GenerateWoodpecker() (*WoodpeckerConfig, error) {
+	if err := p.stack.Validate(); err != nil {
+		return nil, err
+	}
+	if p.identity.ServiceAccount == "" {
+		return nil, NewValidationError("woodpecker pipelines require a service account identity")
+	}
+
+	config := &WoodpeckerConfig{
+		When: []WoodpeckerWhen{
+			{Event: "push", Branch: "main"},
+		},
+	}
+
+	backendOptions := &WoodpeckerBackendOptions{
+		Kubernetes: &WoodpeckerKubernetesOptions{
+			ServiceAccountName: p.identity.ServiceAccount,
+		},
+	}
+	authenticate := []string{
+		p.tokenCommand(
+			"-d grant_type=client_credentials",
+			"-d client_assertion_type="+JWTBearerAssertionType,
+			fmt.Sprintf("--data-urlencode \"client_assertion=$(cat %s)\"", ServiceAccountTokenPath),
+		),
+		fmt.Sprintf("export %s", TokenVariable),
+	}
+
+	// Build step for each service
+	for _, name := range sortedKeys(p.stack.services) {
+		svc := p.stack.services[name]
+		config.Steps = append(config.Steps, WoodpeckerStep{
+			Name:        fmt.Sprintf("build-%s", name),
+			Image:       "docker:latest",
+			Environment: p.variables(),
+			Commands: append(append([]string{"apk add --no-cache curl jq"}, authenticate...),
+				fmt.Sprintf("docker build -t %s .", svc.Image),
+				fmt.Sprintf("docker push %s", svc.Image),
+			),
+			BackendOptions: backendOptions,
+		})
+	}
+
+	// Deploy step
+	config.Steps = append(config.Steps, WoodpeckerStep{
+		Name:        "deploy",
+		Image:       "docker/compose:latest",
+		Environment: p.variables(),
+		Commands: append(append([]string{"apk add --no-cache curl jq"}, authenticate...),
+			"docker-compose -f docker-compose.yml up -d",
+		),
+		BackendOptions: backendOptions,
+	})
+
+	return config, nil
+}
+
+// WoodpeckerConfig represents a Woodpecker CI pipeline
+type WoodpeckerConfig struct {
+	When  []WoodpeckerWhen `yaml:"when,omitempty"`
+	Steps []WoodpeckerStep `yaml:"steps"`
+}
+
+// WoodpeckerWhen represents a Woodpecker pipeline filter
+type WoodpeckerWhen struct {
+	Event  string `yaml:"event,omitempty"`
+	Branch string `yaml:"branch,omitempty"`
+}
+
+// WoodpeckerStep represents a Woodpecker pipeline step
+type WoodpeckerStep struct {
+	Name           string                    `yaml:"name"`
+	Image          string                    `yaml:"image"`
+	Environment    map[string]string         `yaml:"environment,omitempty"`
+	Commands       []string                  `yaml:"commands"`
+	BackendOptions *WoodpeckerBackendOptions `yaml:"backend_options,omitempty"`
+}
+
+// WoodpeckerBackendOptions represents backend-specific step options
+type WoodpeckerBackendOptions struct {
+	Kubernetes *WoodpeckerKubernetesOptions `yaml:"kubernetes,omitempty"`
+}
+
+// WoodpeckerKubernetesOptions represents Kubernetes backend step options
+type WoodpeckerKubernetesOptions struct {
+	ServiceAccountName string `yaml:"serviceAccountName,omitempty"`
+}
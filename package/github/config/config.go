@@ -15,12 +15,24 @@ type Config struct {
 	// GitHub App configuration
 	GitHub GitHubConfig
 
+	// Self-hosted GitLab configuration; disabled when no URL is set
+	GitLab GitLabConfig
+
+	// Gitea configuration; disabled when no URL is set
+	Gitea GiteaConfig
+
 	// Identity API configuration
 	Identity IdentityConfig
 
 	// Sync configuration
 	Sync SyncConfig
 
+	// Webhook queue configuration
+	Queue QueueConfig
+
+	// Branch policy enforcement configuration
+	Enforcement EnforcementConfig
+
 	// Logging configuration
 	Log LogConfig
 }
@@ -44,6 +56,30 @@ type GitHubConfig struct {
 	EnterpriseURL string // For GitHub Enterprise Server
 }
 
+// GitLabConfig holds the GitLab API and system hook settings
+type GitLabConfig struct {
+	BaseURL       string // e.g. https://gitlab.example.com
+	Token         string // Personal or service account token with admin read access
+	WebhookSecret string // Secret token of the system hook, sent in X-Gitlab-Token
+}
+
+// Enabled reports whether the GitLab adapter is configured
+func (c GitLabConfig) Enabled() bool {
+	return c.BaseURL != ""
+}
+
+// GiteaConfig holds the Gitea API and webhook settings
+type GiteaConfig struct {
+	BaseURL       string // e.g. https://gitea.example.com
+	Token         string // Access token of an admin account
+	WebhookSecret string // HMAC secret of the webhooks, checked against X-Gitea-Signature
+}
+
+// Enabled reports whether the Gitea adapter is configured
+func (c GiteaConfig) Enabled() bool {
+	return c.BaseURL != ""
+}
+
 // IdentityConfig holds Aether Identity API configuration
 type IdentityConfig struct {
 	BaseURL       string
@@ -59,6 +95,27 @@ type SyncConfig struct {
 	Interval       time.Duration
 	BatchSize      int
 	MaxConcurrency int
+	Prune          bool // Scheduled reconciliations delete Identity entities that no longer exist on GitHub
+}
+
+// QueueConfig holds webhook delivery queue settings
+type QueueConfig struct {
+	Backend      string // file or memory
+	Path         string // Directory of the file backend
+	Workers      int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	PollInterval time.Duration
+	Retention    time.Duration // How long succeeded deliveries are kept for replay
+	AdminToken   string        // Bearer token for the delivery admin endpoints; disabled when empty
+}
+
+// EnforcementConfig holds branch policy enforcement settings
+type EnforcementConfig struct {
+	PolicyFile    string // JSON file with the branch policies; only GitHub branch protection applies when empty
+	ReportMode    string // check_run or status
+	StatusContext string // Name of the check run or commit status context
 }
 
 // LogConfig holds logging configuration
@@ -85,6 +142,16 @@ func Load() (*Config, error) {
 			ClientSecret:  getEnv("GITHUB_CLIENT_SECRET", ""),
 			EnterpriseURL: getEnv("GITHUB_ENTERPRISE_URL", ""),
 		},
+		GitLab: GitLabConfig{
+			BaseURL:       getEnv("GITLAB_URL", ""),
+			Token:         getEnv("GITLAB_TOKEN", ""),
+			WebhookSecret: getEnv("GITLAB_WEBHOOK_SECRET", ""),
+		},
+		Gitea: GiteaConfig{
+			BaseURL:       getEnv("GITEA_URL", ""),
+			Token:         getEnv("GITEA_TOKEN", ""),
+			WebhookSecret: getEnv("GITEA_WEBHOOK_SECRET", ""),
+		},
 		Identity: IdentityConfig{
 			BaseURL:       getEnv("IDENTITY_API_URL", ""),
 			APIKey:        getEnv("IDENTITY_API_KEY", ""),
@@ -97,6 +164,23 @@ func Load() (*Config, error) {
 			Interval:       getDuration("SYNC_INTERVAL", 5*time.Minute),
 			BatchSize:      getInt("SYNC_BATCH_SIZE", 100),
 			MaxConcurrency: getInt("SYNC_MAX_CONCURRENCY", 10),
+			Prune:          getBool("SYNC_PRUNE", false),
+		},
+		Queue: QueueConfig{
+			Backend:      getEnv("WEBHOOK_QUEUE_BACKEND", "file"),
+			Path:         getEnv("WEBHOOK_QUEUE_PATH", "./data/webhooks"),
+			Workers:      getInt("WEBHOOK_QUEUE_WORKERS", 4),
+			MaxAttempts:  getInt("WEBHOOK_QUEUE_MAX_ATTEMPTS", 8),
+			BaseBackoff:  getDuration("WEBHOOK_QUEUE_BASE_BACKOFF", 5*time.Second),
+			MaxBackoff:   getDuration("WEBHOOK_QUEUE_MAX_BACKOFF", 10*time.Minute),
+			PollInterval: getDuration("WEBHOOK_QUEUE_POLL_INTERVAL", 1*time.Second),
+			Retention:    getDuration("WEBHOOK_QUEUE_RETENTION", 7*24*time.Hour),
+			AdminToken:   getEnv("GITHUB_APP_ADMIN_TOKEN", ""),
+		},
+		Enforcement: EnforcementConfig{
+			PolicyFile:    getEnv("BRANCH_POLICY_FILE", ""),
+			ReportMode:    getEnv("BRANCH_POLICY_REPORT_MODE", "check_run"),
+			StatusContext: getEnv("BRANCH_POLICY_CONTEXT", "identity/branch-policy"),
 		},
 		Log: LogConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
@@ -122,12 +206,21 @@ func (c *Config) Validate() error {
 	if c.GitHub.WebhookSecret == "" {
 		return fmt.Errorf("GITHUB_APP_WEBHOOK_SECRET is required")
 	}
+	if c.GitLab.Enabled() && (c.GitLab.Token == "" || c.GitLab.WebhookSecret == "") {
+		return fmt.Errorf("GITLAB_TOKEN and GITLAB_WEBHOOK_SECRET are required when GITLAB_URL is set")
+	}
+	if c.Gitea.Enabled() && (c.Gitea.Token == "" || c.Gitea.WebhookSecret == "") {
+		return fmt.Errorf("GITEA_TOKEN and GITEA_WEBHOOK_SECRET are required when GITEA_URL is set")
+	}
 	if c.Identity.BaseURL == "" {
 		return fmt.Errorf("IDENTITY_API_URL is required")
 	}
 	if c.Identity.APIKey == "" {
 		return fmt.Errorf("IDENTITY_API_KEY is required")
 	}
+	if c.Enforcement.ReportMode != "check_run" && c.Enforcement.ReportMode != "status" {
+		return fmt.Errorf("BRANCH_POLICY_REPORT_MODE must be check_run or status")
+	}
 	return nil
 }
 
@@ -29,6 +29,9 @@ var (
 	ErrInvalidPayload   = errors.New("invalid webhook payload")
 	ErrUnsupportedEvent = errors.New("unsupported webhook event")
 
+	// Enforcement errors
+	ErrBranchPolicyUnsupported = errors.New("branch policies are not supported for this provider")
+
 	// Sync errors
 	ErrSyncFailed  = errors.New("synchronization failed")
 	ErrPartialSync = errors.New("partial synchronization failure")
@@ -99,6 +102,15 @@ func IsGitHubError(err error) bool {
 	return errors.Is(err, ErrGitHubAPIError)
 }
 
+// IsForgeError checks if error is a GitHub, GitLab or Gitea API error
+func IsForgeError(err error) bool {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr.Type == "GITHUB" || appErr.Type == "GITLAB" || appErr.Type == "GITEA"
+	}
+	return errors.Is(err, ErrGitHubAPIError)
+}
+
 // IsIdentityError checks if error is an Identity API error
 func IsIdentityError(err error) bool {
 	var appErr *AppError
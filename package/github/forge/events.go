@@ -0,0 +1,155 @@
+package forge
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Event represents a normalized forge webhook event
+type Event struct {
+	Provider     Provider               `json:"provider"`
+	Type         EventType              `json:"type"`
+	Action       string                 `json:"action,omitempty"`
+	DeliveryID   string                 `json:"delivery_id"`
+	Installation Installation           `json:"installation,omitempty"`
+	Repository   Repository             `json:"repository,omitempty"`
+	Organization Organization           `json:"organization,omitempty"`
+	Sender       User                   `json:"sender"`
+	Payload      map[string]interface{} `json:"payload"`
+	RawPayload   []byte                 `json:"-"`
+	ReceivedAt   time.Time              `json:"received_at"`
+}
+
+// EventType represents the normalized type of an event; the values follow GitHub's event names
+type EventType string
+
+const (
+	EventTypePush              EventType = "push"
+	EventTypePullRequest       EventType = "pull_request"
+	EventTypePullRequestReview EventType = "pull_request_review"
+	EventTypeMembership        EventType = "membership"
+	EventTypeRepository        EventType = "repository"
+	EventTypeInstallation      EventType = "installation"
+	EventTypeTeam              EventType = "team"
+	EventTypeWorkflowJob       EventType = "workflow_job"
+	EventTypeWorkflowRun       EventType = "workflow_run"
+	EventTypeRelease           EventType = "release"
+	EventTypeCreate            EventType = "create"
+	EventTypeDelete            EventType = "delete"
+	EventTypePing              EventType = "ping"
+)
+
+// Installation represents the namespace an event belongs to: a GitHub App installation,
+// a GitLab top-level group or a Gitea organization
+type Installation struct {
+	ID      int64  `json:"id"`
+	Account User   `json:"account"`
+	AppID   int64  `json:"app_id"`
+	AppSlug string `json:"app_slug,omitempty"`
+}
+
+// Repository represents a repository; FullName is "owner/name", the owner may contain slashes on GitLab
+type Repository struct {
+	ID            int64  `json:"id"`
+	NodeID        string `json:"node_id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Owner         User   `json:"owner"`
+	Private       bool   `json:"private"`
+	Description   string `json:"description,omitempty"`
+	HTMLURL       string `json:"html_url"`
+	CloneURL      string `json:"clone_url,omitempty"`
+	DefaultBranch string `json:"default_branch,omitempty"`
+}
+
+// Organization represents an organization, or a top-level group on GitLab
+type Organization struct {
+	ID          int64  `json:"id"`
+	Login       string `json:"login"`
+	NodeID      string `json:"node_id,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// User represents a forge user
+type User struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	NodeID    string `json:"node_id,omitempty"`
+	Email     string `json:"email,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Type      string `json:"type,omitempty"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+}
+
+// Team represents a team, or a group on GitLab. Permission is the normalized repository
+// permission (pull, triage, push, maintain or admin) when the team is listed for a repository.
+type Team struct {
+	ID          int64  `json:"id"`
+	NodeID      string `json:"node_id"`
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	Description string `json:"description,omitempty"`
+	Privacy     string `json:"privacy,omitempty"`
+	Permission  string `json:"permission,omitempty"`
+}
+
+// PullRequest represents a pull request or a GitLab merge request
+type PullRequest struct {
+	ID        int64  `json:"id"`
+	Number    int    `json:"number"`
+	Title     string `json:"title"`
+	State     string `json:"state"`
+	User      User   `json:"user"`
+	Head      Branch `json:"head"`
+	Base      Branch `json:"base"`
+	Merged    bool   `json:"merged,omitempty"`
+	Mergeable bool   `json:"mergeable,omitempty"`
+}
+
+// Branch represents a branch reference
+type Branch struct {
+	Ref  string     `json:"ref"`
+	SHA  string     `json:"sha"`
+	Repo Repository `json:"repo,omitempty"`
+}
+
+// IsSensitiveAction returns true if the event represents a sensitive action that requires authorization
+func (e *Event) IsSensitiveAction() bool {
+	switch e.Type {
+	case EventTypePush:
+		return true
+	case EventTypePullRequest:
+		action := e.Action
+		return action == "merged" || action == "closed" || action == "reopened"
+	default:
+		return false
+	}
+}
+
+// GetRepositoryInfo extracts repository information from the event.
+// The repository name is the last path segment, everything before it is the owner.
+func (e *Event) GetRepositoryInfo() (owner, repo string, err error) {
+	if e.Repository.FullName == "" {
+		return "", "", fmt.Errorf("no repository information in event")
+	}
+
+	fullName := strings.Trim(e.Repository.FullName, "/")
+	i := strings.LastIndex(fullName, "/")
+	if i <= 0 || i == len(fullName)-1 {
+		return "", "", fmt.Errorf("invalid repository full name: %s", e.Repository.FullName)
+	}
+
+	return fullName[:i], fullName[i+1:], nil
+}
+
+// GetActor returns the actor who triggered the event
+func (e *Event) GetActor() User {
+	return e.Sender
+}
+
+// ToJSON serializes the event to JSON
+func (e *Event) ToJSON() ([]byte, error) {
+	return json.Marshal(e)
+}
@@ -0,0 +1,120 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Provider identifies a forge
+type Provider string
+
+const (
+	ProviderGitHub Provider = "github"
+	ProviderGitLab Provider = "gitlab"
+	ProviderGitea  Provider = "gitea"
+)
+
+// Namespace is the unit a forge is synchronized by: a GitHub App installation,
+// a GitLab top-level group or a Gitea organization
+type Namespace struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	Type      string `json:"type"`       // Organization or User
+	AccountID int64  `json:"account_id"` // ID of the owning account; the namespace ID itself outside GitHub
+}
+
+// HasTeams reports whether the namespace can have teams
+func (n Namespace) HasTeams() bool {
+	return n.Type == "Organization"
+}
+
+// Directory reads the repositories, teams and team members of a forge
+type Directory interface {
+	Provider() Provider
+	// ListNamespaces returns the namespaces the bridge has access to
+	ListNamespaces(ctx context.Context) ([]Namespace, error)
+	// ListRepositories returns the repositories of a namespace, including nested groups
+	ListRepositories(ctx context.Context, ns Namespace, pageSize int) ([]Repository, error)
+	// ListTeams returns the teams of a namespace; Team.Slug is relative to the namespace
+	ListTeams(ctx context.Context, ns Namespace, pageSize int) ([]Team, error)
+	// ListTeamMembers returns the members of a team
+	ListTeamMembers(ctx context.Context, ns Namespace, team Team, pageSize int) ([]User, error)
+	// ListRepositoryTeams returns the teams with access to a repository, with Team.Permission set
+	ListRepositoryTeams(ctx context.Context, ns Namespace, repo Repository) ([]Team, error)
+}
+
+// Collaborator is a user with direct access to a repository
+type Collaborator struct {
+	User       User   `json:"user"`
+	Permission string `json:"permission"` // pull, triage, push, maintain or admin
+}
+
+// CollaboratorLister is implemented by directories that grant repository access per user,
+// like GitLab where each group member has their own access level
+type CollaboratorLister interface {
+	ListRepositoryCollaborators(ctx context.Context, ns Namespace, repo Repository, pageSize int) ([]Collaborator, error)
+}
+
+// WebhookSource authenticates and parses the webhooks of a forge
+type WebhookSource interface {
+	Provider() Provider
+	// Delivery returns the event type and delivery ID headers of a webhook request
+	Delivery(r *http.Request) (eventType, deliveryID string)
+	// ValidateWebhook checks the signature or token of a webhook request
+	ValidateWebhook(r *http.Request, payload []byte) error
+	// ParseEvent parses a webhook payload into a normalized Event
+	ParseEvent(eventType string, payload []byte) (*Event, error)
+}
+
+// Forge is a provider adapter: a directory and a webhook source
+type Forge interface {
+	Directory
+	WebhookSource
+}
+
+// Identity objects of GitHub entities keep their historical unprefixed form ("repo:owner/name");
+// entities of other forges are prefixed with the provider ("repo:gitlab:group/project").
+
+// RepositoryID returns the Identity ID of a repository
+func RepositoryID(provider Provider, fullName string) string {
+	return qualify(provider, fullName)
+}
+
+// OrganizationID returns the Identity ID of an organization or top-level group
+func OrganizationID(provider Provider, login string) string {
+	return qualify(provider, login)
+}
+
+// TeamName returns the name of a team within its organization; a GitLab top-level group has an empty slug
+func TeamName(org, slug string) string {
+	if slug == "" {
+		return org
+	}
+	return org + "/" + slug
+}
+
+// SplitTeamName splits a name built by TeamName into the organization and team slug
+func SplitTeamName(name string) (org, slug string) {
+	org, slug, _ = strings.Cut(name, "/")
+	return org, slug
+}
+
+// TeamID returns the Identity ID of a team
+func TeamID(provider Provider, org, slug string) string {
+	return qualify(provider, TeamName(org, slug))
+}
+
+// UserSubject returns the Identity subject of a forge user, e.g. "gitlab_user:42"
+func UserSubject(provider Provider, userID int64) string {
+	return fmt.Sprintf("%s_user:%d", provider, userID)
+}
+
+// qualify prefixes an ID with the provider, except for GitHub
+func qualify(provider Provider, id string) string {
+	if provider == "" || provider == ProviderGitHub {
+		return id
+	}
+	return string(provider) + ":" + id
+}
@@ -0,0 +1,290 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/package/github/config"
+	"github.com/skygenesisenterprise/aether-identity/package/github/errors"
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
+)
+
+// Client is the Gitea forge adapter; organizations are namespaces and org teams are teams
+type Client struct {
+	baseURL       string
+	token         string
+	webhookSecret string
+	httpClient    *http.Client
+	parser        *EventParser
+}
+
+// NewClient creates a new Gitea client
+func NewClient(cfg config.GiteaConfig) *Client {
+	return NewClientWithHTTPClient(cfg, &http.Client{Timeout: 30 * time.Second})
+}
+
+// NewClientWithHTTPClient creates a Gitea client that sends its requests through httpClient
+func NewClientWithHTTPClient(cfg config.GiteaConfig, httpClient *http.Client) *Client {
+	return &Client{
+		baseURL:       strings.TrimSuffix(cfg.BaseURL, "/"),
+		token:         cfg.Token,
+		webhookSecret: cfg.WebhookSecret,
+		httpClient:    httpClient,
+		parser:        NewEventParser(),
+	}
+}
+
+// organization is a Gitea organization
+type organization struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Username    string `json:"username"`
+	Description string `json:"description"`
+}
+
+// team is a Gitea organization team
+type team struct {
+	ID          int64             `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Permission  string            `json:"permission"`
+	UnitsMap    map[string]string `json:"units_map"`
+}
+
+// user is a Gitea user
+type user struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	FullName  string `json:"full_name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// repository is a Gitea repository
+type repository struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Owner         user   `json:"owner"`
+	Private       bool   `json:"private"`
+	Description   string `json:"description"`
+	HTMLURL       string `json:"html_url"`
+	CloneURL      string `json:"clone_url"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+// Provider returns the Gitea provider
+func (c *Client) Provider() forge.Provider {
+	return forge.ProviderGitea
+}
+
+// ListNamespaces returns every organization of the instance; the token must belong to an admin
+func (c *Client) ListNamespaces(ctx context.Context) ([]forge.Namespace, error) {
+	orgs, err := listAll[organization](ctx, c, "/admin/orgs", 50)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make([]forge.Namespace, 0, len(orgs))
+	for _, org := range orgs {
+		login := org.Name
+		if login == "" {
+			login = org.Username
+		}
+		namespaces = append(namespaces, forge.Namespace{
+			ID:        org.ID,
+			Login:     login,
+			Type:      "Organization",
+			AccountID: org.ID,
+		})
+	}
+
+	return namespaces, nil
+}
+
+// ListRepositories returns the repositories of an organization
+func (c *Client) ListRepositories(ctx context.Context, ns forge.Namespace, pageSize int) ([]forge.Repository, error) {
+	repos, err := listAll[repository](ctx, c, fmt.Sprintf("/orgs/%s/repos", url.PathEscape(ns.Login)), pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]forge.Repository, 0, len(repos))
+	for _, repo := range repos {
+		r := convertRepository(repo)
+		r.Owner.Type = "Organization"
+		result = append(result, r)
+	}
+
+	return result, nil
+}
+
+// ListTeams returns the teams of an organization
+func (c *Client) ListTeams(ctx context.Context, ns forge.Namespace, pageSize int) ([]forge.Team, error) {
+	teams, err := listAll[team](ctx, c, fmt.Sprintf("/orgs/%s/teams", url.PathEscape(ns.Login)), pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]forge.Team, 0, len(teams))
+	for _, t := range teams {
+		result = append(result, convertTeam(t))
+	}
+
+	return result, nil
+}
+
+// ListTeamMembers returns the members of a team
+func (c *Client) ListTeamMembers(ctx context.Context, ns forge.Namespace, t forge.Team, pageSize int) ([]forge.User, error) {
+	members, err := listAll[user](ctx, c, fmt.Sprintf("/teams/%d/members", t.ID), pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]forge.User, 0, len(members))
+	for _, member := range members {
+		result = append(result, convertUser(member))
+	}
+
+	return result, nil
+}
+
+// ListRepositoryTeams returns the teams with access to a repository and their permission
+func (c *Client) ListRepositoryTeams(ctx context.Context, ns forge.Namespace, repo forge.Repository) ([]forge.Team, error) {
+	owner, name, ok := strings.Cut(repo.FullName, "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid repository full name: %s", repo.FullName)
+	}
+
+	var teams []team
+	path := fmt.Sprintf("/repos/%s/%s/teams", url.PathEscape(owner), url.PathEscape(name))
+	if err := c.get(ctx, path, nil, &teams); err != nil {
+		return nil, err
+	}
+
+	result := make([]forge.Team, 0, len(teams))
+	for _, t := range teams {
+		converted := convertTeam(t)
+		if converted.Permission == "" {
+			continue
+		}
+		result = append(result, converted)
+	}
+
+	return result, nil
+}
+
+// TeamPermission maps a Gitea team permission to a normalized repository permission.
+// Gitea 1.20+ reports "none" and puts the access level in the per-unit map instead.
+func TeamPermission(permission string, unitsMap map[string]string) string {
+	if permission == "" || permission == "none" {
+		permission = unitsMap["repo.code"]
+	}
+
+	switch permission {
+	case "owner", "admin":
+		return "admin"
+	case "write":
+		return "push"
+	case "read":
+		return "pull"
+	default:
+		return ""
+	}
+}
+
+// get sends an authenticated GET request to the Gitea API and decodes the JSON response
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	endpoint := c.baseURL + "/api/v1" + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return errors.Wrap(err, "GITEA", "REQUEST_ERROR", "failed to create request")
+	}
+	req.Header.Set("Authorization", "token "+c.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "GITEA", "API_ERROR", fmt.Sprintf("GET %s failed", path))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("GITEA", "API_ERROR", fmt.Sprintf("GET %s returned status %d", path, resp.StatusCode))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.Wrap(err, "GITEA", "DESERIALIZATION_ERROR", fmt.Sprintf("failed to decode %s", path))
+	}
+
+	return nil
+}
+
+// listAll reads pages until one comes back shorter than the page size
+func listAll[T any](ctx context.Context, c *Client, path string, pageSize int) ([]T, error) {
+	limit := max(pageSize, 1)
+
+	var all []T
+	for page := 1; ; page++ {
+		var items []T
+		query := url.Values{"page": {strconv.Itoa(page)}, "limit": {strconv.Itoa(limit)}}
+		if err := c.get(ctx, path, query, &items); err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+
+		if len(items) < limit {
+			break
+		}
+	}
+
+	return all, nil
+}
+
+// convertRepository converts a Gitea repository to a forge repository
+func convertRepository(repo repository) forge.Repository {
+	return forge.Repository{
+		ID:            repo.ID,
+		Name:          repo.Name,
+		FullName:      repo.FullName,
+		Owner:         convertUser(repo.Owner),
+		Private:       repo.Private,
+		Description:   repo.Description,
+		HTMLURL:       repo.HTMLURL,
+		CloneURL:      repo.CloneURL,
+		DefaultBranch: repo.DefaultBranch,
+	}
+}
+
+// convertTeam converts a Gitea team to a forge team; the team name is its slug
+func convertTeam(t team) forge.Team {
+	return forge.Team{
+		ID:          t.ID,
+		Name:        t.Name,
+		Slug:        t.Name,
+		Description: t.Description,
+		Permission:  TeamPermission(t.Permission, t.UnitsMap),
+	}
+}
+
+// convertUser converts a Gitea user to a forge user
+func convertUser(u user) forge.User {
+	return forge.User{
+		ID:        u.ID,
+		Login:     u.Login,
+		Name:      u.FullName,
+		Email:     u.Email,
+		Type:      "User",
+		AvatarURL: u.AvatarURL,
+	}
+}
@@ -0,0 +1,158 @@
+package gitea
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/skygenesisenterprise/aether-identity/package/github/errors"
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
+)
+
+// EventParser normalizes Gitea webhooks into forge events. Gitea payloads follow GitHub's
+// shape closely; reviews are sent as separate pull_request_approved and pull_request_rejected events.
+type EventParser struct{}
+
+// NewEventParser creates a new Gitea event parser
+func NewEventParser() *EventParser {
+	return &EventParser{}
+}
+
+// hookPayload holds the fields of the Gitea webhook payloads the parser reads
+type hookPayload struct {
+	Action       string        `json:"action"`
+	Number       int           `json:"number"`
+	Repository   *repository   `json:"repository"`
+	Organization *organization `json:"organization"`
+	Sender       *user         `json:"sender"`
+	Pusher       *user         `json:"pusher"`
+	PullRequest  *struct {
+		ID     int64  `json:"id"`
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		State  string `json:"state"`
+		Merged bool   `json:"merged"`
+		User   user   `json:"user"`
+		Head   struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"base"`
+	} `json:"pull_request"`
+}
+
+// ParseEvent parses a Gitea webhook payload; eventType is the X-Gitea-Event header
+func (p *EventParser) ParseEvent(eventType string, payload []byte) (*forge.Event, error) {
+	var hook hookPayload
+	if err := json.Unmarshal(payload, &hook); err != nil {
+		return nil, fmt.Errorf("failed to parse %s event: %w", eventType, err)
+	}
+
+	var rawPayload map[string]interface{}
+	if err := json.Unmarshal(payload, &rawPayload); err != nil {
+		return nil, fmt.Errorf("failed to parse %s event: %w", eventType, err)
+	}
+
+	event := &forge.Event{
+		Provider:   forge.ProviderGitea,
+		Type:       forge.EventType(eventType),
+		Action:     hook.Action,
+		RawPayload: payload,
+		Payload:    rawPayload,
+	}
+
+	if hook.Sender != nil {
+		event.Sender = convertUser(*hook.Sender)
+	}
+
+	if hook.Repository != nil {
+		event.Repository = convertRepository(*hook.Repository)
+		owner := hook.Repository.Owner
+		event.Installation = forge.Installation{ID: owner.ID, Account: convertUser(owner)}
+		event.Organization = forge.Organization{ID: owner.ID, Login: owner.Login}
+	}
+
+	if hook.Organization != nil {
+		login := hook.Organization.Name
+		if login == "" {
+			login = hook.Organization.Username
+		}
+		event.Organization = forge.Organization{ID: hook.Organization.ID, Login: login, Description: hook.Organization.Description}
+	}
+
+	switch eventType {
+	case "push":
+		event.Action = "push"
+		if hook.Pusher != nil && event.Sender.ID == 0 {
+			event.Sender = convertUser(*hook.Pusher)
+		}
+	case "pull_request", "pull_request_sync":
+		event.Type = forge.EventTypePullRequest
+		if eventType == "pull_request_sync" || event.Action == "synchronized" {
+			event.Action = "synchronize"
+		}
+	case "pull_request_approved", "pull_request_rejected", "pull_request_comment":
+		event.Type = forge.EventTypePullRequestReview
+		state := "commented"
+		switch eventType {
+		case "pull_request_approved":
+			state = "approved"
+		case "pull_request_rejected":
+			state = "changes_requested"
+		}
+		event.Action = "submitted"
+		event.Payload["review"] = map[string]interface{}{"state": state}
+	}
+
+	if hook.PullRequest != nil {
+		pr := hook.PullRequest
+		event.Payload["normalized_pull_request"] = forge.PullRequest{
+			ID:     pr.ID,
+			Number: pr.Number,
+			Title:  pr.Title,
+			State:  pr.State,
+			User:   convertUser(pr.User),
+			Head:   forge.Branch{Ref: pr.Head.Ref, SHA: pr.Head.SHA},
+			Base:   forge.Branch{Ref: pr.Base.Ref, SHA: pr.Base.SHA},
+			Merged: pr.Merged,
+		}
+	}
+
+	return event, nil
+}
+
+// Delivery returns the X-Gitea-Event and X-Gitea-Delivery headers
+func (c *Client) Delivery(r *http.Request) (eventType, deliveryID string) {
+	return r.Header.Get("X-Gitea-Event"), r.Header.Get("X-Gitea-Delivery")
+}
+
+// ValidateWebhook checks the X-Gitea-Signature header, a hex encoded HMAC-SHA256 of the payload
+func (c *Client) ValidateWebhook(r *http.Request, payload []byte) error {
+	if c.webhookSecret == "" {
+		return errors.ErrInvalidWebhookSecret
+	}
+
+	signature, err := hex.DecodeString(r.Header.Get("X-Gitea-Signature"))
+	if err != nil || len(signature) == 0 {
+		return errors.Wrap(errors.ErrInvalidSignature, "WEBHOOK", "VALIDATION_ERROR", "missing or malformed X-Gitea-Signature")
+	}
+
+	mac := hmac.New(sha256.New, []byte(c.webhookSecret))
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return errors.Wrap(errors.ErrInvalidSignature, "WEBHOOK", "VALIDATION_ERROR", "invalid signature")
+	}
+
+	return nil
+}
+
+// ParseEvent parses a Gitea webhook payload
+func (c *Client) ParseEvent(eventType string, payload []byte) (*forge.Event, error) {
+	return c.parser.ParseEvent(eventType, payload)
+}
@@ -26,6 +26,12 @@ type Client struct {
 
 // NewClient creates a new GitHub App client
 func NewClient(cfg config.GitHubConfig) (*Client, error) {
+	return NewClientWithHTTPClient(cfg, http.DefaultClient)
+}
+
+// NewClientWithHTTPClient creates a GitHub App client that sends its requests through httpClient.
+// Combined with EnterpriseURL it lets the client run against a recorded or fake GitHub API.
+func NewClientWithHTTPClient(cfg config.GitHubConfig, httpClient *http.Client) (*Client, error) {
 	privateKey, err := parsePrivateKey(cfg.PrivateKey)
 	if err != nil {
 		return nil, errors.Wrap(err, "GITHUB", "CONFIG_ERROR", "failed to parse private key")
@@ -33,12 +39,12 @@ func NewClient(cfg config.GitHubConfig) (*Client, error) {
 
 	var client *github.Client
 	if cfg.EnterpriseURL != "" {
-		client, err = github.NewEnterpriseClient(cfg.EnterpriseURL, cfg.EnterpriseURL, http.DefaultClient)
+		client, err = github.NewEnterpriseClient(cfg.EnterpriseURL, cfg.EnterpriseURL, httpClient)
 		if err != nil {
 			return nil, errors.Wrap(err, "GITHUB", "CONFIG_ERROR", "failed to create enterprise client")
 		}
 	} else {
-		client = github.NewClient(http.DefaultClient)
+		client = github.NewClient(httpClient)
 	}
 
 	return &Client{
@@ -258,6 +264,60 @@ func (c *Client) ListTeamMembers(ctx context.Context, installationID int64, orgI
 	return allMembers, nil
 }
 
+// ListInstallationRepositories lists the repositories an installation can access
+func (c *Client) ListInstallationRepositories(ctx context.Context, installationID int64, pageSize int) ([]*github.Repository, error) {
+	client, err := c.GetInstallationClient(ctx, installationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var allRepos []*github.Repository
+	opts := &github.ListOptions{PerPage: pageSize}
+
+	for {
+		repoList, resp, err := client.Apps.ListRepos(ctx, opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "GITHUB", "API_ERROR", "failed to list installation repositories")
+		}
+
+		allRepos = append(allRepos, repoList.Repositories...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return allRepos, nil
+}
+
+// ListOrganizationTeams lists the teams of an organization
+func (c *Client) ListOrganizationTeams(ctx context.Context, installationID int64, org string, pageSize int) ([]*github.Team, error) {
+	client, err := c.GetInstallationClient(ctx, installationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var allTeams []*github.Team
+	opts := &github.ListOptions{PerPage: pageSize}
+
+	for {
+		teams, resp, err := client.Teams.ListTeams(ctx, org, opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "GITHUB", "API_ERROR", "failed to list organization teams")
+		}
+
+		allTeams = append(allTeams, teams...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return allTeams, nil
+}
+
 // ListRepositoryTeams lists teams with access to a repository
 func (c *Client) ListRepositoryTeams(ctx context.Context, installationID int64, owner, repo string) ([]*github.Team, error) {
 	client, err := c.GetInstallationClient(ctx, installationID)
@@ -285,6 +345,166 @@ func (c *Client) ListRepositoryTeams(ctx context.Context, installationID int64,
 	return allTeams, nil
 }
 
+// GetBranchProtection retrieves the protection of a branch; it returns nil when the branch is not protected
+func (c *Client) GetBranchProtection(ctx context.Context, installationID int64, owner, repo, branch string) (*github.Protection, error) {
+	client, err := c.GetInstallationClient(ctx, installationID)
+	if err != nil {
+		return nil, err
+	}
+
+	protection, resp, err := client.Repositories.GetBranchProtection(ctx, owner, repo, branch)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "GITHUB", "API_ERROR", "failed to get branch protection")
+	}
+
+	return protection, nil
+}
+
+// GetFileContent retrieves the content of a file at a ref; it returns nil when the file does not exist
+func (c *Client) GetFileContent(ctx context.Context, installationID int64, owner, repo, path, ref string) ([]byte, error) {
+	client, err := c.GetInstallationClient(ctx, installationID)
+	if err != nil {
+		return nil, err
+	}
+
+	file, _, resp, err := client.Repositories.GetContents(ctx, owner, repo, path, &github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "GITHUB", "API_ERROR", "failed to get file content")
+	}
+	if file == nil {
+		return nil, nil
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, errors.Wrap(err, "GITHUB", "API_ERROR", "failed to decode file content")
+	}
+
+	return []byte(content), nil
+}
+
+// GetPullRequest retrieves a pull request by number
+func (c *Client) GetPullRequest(ctx context.Context, installationID int64, owner, repo string, number int) (*github.PullRequest, error) {
+	client, err := c.GetInstallationClient(ctx, installationID)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, _, err := client.PullRequests.Get(ctx, owner, repo, number)
+	if err != nil {
+		return nil, errors.Wrap(err, "GITHUB", "API_ERROR", "failed to get pull request")
+	}
+
+	return pr, nil
+}
+
+// ListPullRequestReviews lists the reviews of a pull request, oldest first
+func (c *Client) ListPullRequestReviews(ctx context.Context, installationID int64, owner, repo string, number int) ([]*github.PullRequestReview, error) {
+	client, err := c.GetInstallationClient(ctx, installationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var allReviews []*github.PullRequestReview
+	opts := &github.ListOptions{PerPage: 100}
+
+	for {
+		reviews, resp, err := client.PullRequests.ListReviews(ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "GITHUB", "API_ERROR", "failed to list pull request reviews")
+		}
+
+		allReviews = append(allReviews, reviews...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return allReviews, nil
+}
+
+// ListPullRequestFiles lists the files changed by a pull request
+func (c *Client) ListPullRequestFiles(ctx context.Context, installationID int64, owner, repo string, number int) ([]*github.CommitFile, error) {
+	client, err := c.GetInstallationClient(ctx, installationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var allFiles []*github.CommitFile
+	opts := &github.ListOptions{PerPage: 100}
+
+	for {
+		files, resp, err := client.PullRequests.ListFiles(ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "GITHUB", "API_ERROR", "failed to list pull request files")
+		}
+
+		allFiles = append(allFiles, files...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return allFiles, nil
+}
+
+// IsTeamMember reports whether a user is an active member of a team
+func (c *Client) IsTeamMember(ctx context.Context, installationID int64, org, teamSlug, login string) (bool, error) {
+	client, err := c.GetInstallationClient(ctx, installationID)
+	if err != nil {
+		return false, err
+	}
+
+	membership, resp, err := client.Teams.GetTeamMembershipBySlug(ctx, org, teamSlug, login)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "GITHUB", "API_ERROR", "failed to get team membership")
+	}
+
+	return membership.GetState() == "active", nil
+}
+
+// CreateCommitStatus sets a commit status on a SHA
+func (c *Client) CreateCommitStatus(ctx context.Context, installationID int64, owner, repo, sha string, status *github.RepoStatus) error {
+	client, err := c.GetInstallationClient(ctx, installationID)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := client.Repositories.CreateStatus(ctx, owner, repo, sha, status); err != nil {
+		return errors.Wrap(err, "GITHUB", "API_ERROR", "failed to create commit status")
+	}
+
+	return nil
+}
+
+// CreateCheckRun creates a check run on a SHA
+func (c *Client) CreateCheckRun(ctx context.Context, installationID int64, owner, repo string, opts github.CreateCheckRunOptions) (*github.CheckRun, error) {
+	client, err := c.GetInstallationClient(ctx, installationID)
+	if err != nil {
+		return nil, err
+	}
+
+	checkRun, _, err := client.Checks.CreateCheckRun(ctx, owner, repo, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "GITHUB", "API_ERROR", "failed to create check run")
+	}
+
+	return checkRun, nil
+}
+
 // GetRateLimit gets the current rate limit status
 func (c *Client) GetRateLimit(ctx context.Context) (*github.RateLimits, error) {
 	jwt, err := c.GenerateAppJWT()
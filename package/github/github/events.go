@@ -1,113 +1,51 @@
 package github
 
-import (
-	"encoding/json"
-	"fmt"
-	"time"
-)
+import "github.com/skygenesisenterprise/aether-identity/package/github/forge"
+
+// The normalized event model is shared by all forges; these aliases keep the github package self-contained
 
 // Event represents a normalized GitHub webhook event
-type Event struct {
-	Type         EventType              `json:"type"`
-	Action       string                 `json:"action,omitempty"`
-	DeliveryID   string                 `json:"delivery_id"`
-	Installation Installation           `json:"installation,omitempty"`
-	Repository   Repository             `json:"repository,omitempty"`
-	Organization Organization           `json:"organization,omitempty"`
-	Sender       User                   `json:"sender"`
-	Payload      map[string]interface{} `json:"payload"`
-	RawPayload   []byte                 `json:"-"`
-	ReceivedAt   time.Time              `json:"received_at"`
-}
+type Event = forge.Event
 
 // EventType represents the type of GitHub event
-type EventType string
+type EventType = forge.EventType
 
 const (
-	EventTypePush         EventType = "push"
-	EventTypePullRequest  EventType = "pull_request"
-	EventTypeMembership   EventType = "membership"
-	EventTypeRepository   EventType = "repository"
-	EventTypeInstallation EventType = "installation"
-	EventTypeTeam         EventType = "team"
-	EventTypeWorkflowJob  EventType = "workflow_job"
-	EventTypeWorkflowRun  EventType = "workflow_run"
-	EventTypeRelease      EventType = "release"
-	EventTypeCreate       EventType = "create"
-	EventTypeDelete       EventType = "delete"
-	EventTypePing         EventType = "ping"
+	EventTypePush              = forge.EventTypePush
+	EventTypePullRequest       = forge.EventTypePullRequest
+	EventTypePullRequestReview = forge.EventTypePullRequestReview
+	EventTypeMembership        = forge.EventTypeMembership
+	EventTypeRepository        = forge.EventTypeRepository
+	EventTypeInstallation      = forge.EventTypeInstallation
+	EventTypeTeam              = forge.EventTypeTeam
+	EventTypeWorkflowJob       = forge.EventTypeWorkflowJob
+	EventTypeWorkflowRun       = forge.EventTypeWorkflowRun
+	EventTypeRelease           = forge.EventTypeRelease
+	EventTypeCreate            = forge.EventTypeCreate
+	EventTypeDelete            = forge.EventTypeDelete
+	EventTypePing              = forge.EventTypePing
 )
 
 // Installation represents a GitHub App installation
-type Installation struct {
-	ID      int64  `json:"id"`
-	Account User   `json:"account"`
-	AppID   int64  `json:"app_id"`
-	AppSlug string `json:"app_slug,omitempty"`
-}
+type Installation = forge.Installation
 
 // Repository represents a GitHub repository
-type Repository struct {
-	ID            int64  `json:"id"`
-	NodeID        string `json:"node_id"`
-	Name          string `json:"name"`
-	FullName      string `json:"full_name"`
-	Owner         User   `json:"owner"`
-	Private       bool   `json:"private"`
-	Description   string `json:"description,omitempty"`
-	HTMLURL       string `json:"html_url"`
-	CloneURL      string `json:"clone_url,omitempty"`
-	DefaultBranch string `json:"default_branch,omitempty"`
-}
+type Repository = forge.Repository
 
 // Organization represents a GitHub organization
-type Organization struct {
-	ID          int64  `json:"id"`
-	Login       string `json:"login"`
-	NodeID      string `json:"node_id,omitempty"`
-	Description string `json:"description,omitempty"`
-}
+type Organization = forge.Organization
 
 // User represents a GitHub user
-type User struct {
-	ID        int64  `json:"id"`
-	Login     string `json:"login"`
-	NodeID    string `json:"node_id,omitempty"`
-	Email     string `json:"email,omitempty"`
-	Name      string `json:"name,omitempty"`
-	Type      string `json:"type,omitempty"`
-	AvatarURL string `json:"avatar_url,omitempty"`
-}
+type User = forge.User
 
 // Team represents a GitHub team
-type Team struct {
-	ID          int64  `json:"id"`
-	NodeID      string `json:"node_id"`
-	Name        string `json:"name"`
-	Slug        string `json:"slug"`
-	Description string `json:"description,omitempty"`
-	Privacy     string `json:"privacy,omitempty"`
-}
+type Team = forge.Team
 
 // PullRequest represents a pull request (extracted from pull_request event)
-type PullRequest struct {
-	ID        int64  `json:"id"`
-	Number    int    `json:"number"`
-	Title     string `json:"title"`
-	State     string `json:"state"`
-	User      User   `json:"user"`
-	Head      Branch `json:"head"`
-	Base      Branch `json:"base"`
-	Merged    bool   `json:"merged,omitempty"`
-	Mergeable bool   `json:"mergeable,omitempty"`
-}
+type PullRequest = forge.PullRequest
 
 // Branch represents a branch reference
-type Branch struct {
-	Ref  string     `json:"ref"`
-	SHA  string     `json:"sha"`
-	Repo Repository `json:"repo,omitempty"`
-}
+type Branch = forge.Branch
 
 // PushEventPayload represents the payload of a push event
 type PushEventPayload struct {
@@ -182,64 +120,3 @@ type Changes struct {
 type Change struct {
 	From string `json:"from"`
 }
-
-// IsSensitiveAction returns true if the event represents a sensitive action that requires authorization
-func (e *Event) IsSensitiveAction() bool {
-	switch e.Type {
-	case EventTypePush:
-		return true
-	case EventTypePullRequest:
-		action := e.Action
-		return action == "merged" || action == "closed" || action == "reopened"
-	default:
-		return false
-	}
-}
-
-// GetRepositoryInfo extracts repository information from the event
-func (e *Event) GetRepositoryInfo() (owner, repo string, err error) {
-	if e.Repository.FullName == "" {
-		return "", "", fmt.Errorf("no repository information in event")
-	}
-
-	// Parse owner/repo from full_name
-	parts := splitRepoFullName(e.Repository.FullName)
-	if len(parts) != 2 {
-		return "", "", fmt.Errorf("invalid repository full name: %s", e.Repository.FullName)
-	}
-
-	return parts[0], parts[1], nil
-}
-
-// GetActor returns the actor who triggered the event
-func (e *Event) GetActor() User {
-	return e.Sender
-}
-
-// ToJSON serializes the event to JSON
-func (e *Event) ToJSON() ([]byte, error) {
-	return json.Marshal(e)
-}
-
-// splitRepoFullName splits a repository full name into owner and repo
-func splitRepoFullName(fullName string) []string {
-	result := make([]string, 0)
-	current := ""
-
-	for _, char := range fullName {
-		if char == '/' {
-			if current != "" {
-				result = append(result, current)
-				current = ""
-			}
-		} else {
-			current += string(char)
-		}
-	}
-
-	if current != "" {
-		result = append(result, current)
-	}
-
-	return result
-}
@@ -0,0 +1,131 @@
+package github
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
+)
+
+// Forge adapts the GitHub App client to the forge interfaces; namespaces are App installations
+type Forge struct {
+	client *Client
+	parser *EventParser
+}
+
+// NewForge creates the GitHub forge adapter
+func NewForge(client *Client) *Forge {
+	return &Forge{
+		client: client,
+		parser: NewEventParser(),
+	}
+}
+
+// Provider returns the GitHub provider
+func (f *Forge) Provider() forge.Provider {
+	return forge.ProviderGitHub
+}
+
+// Client returns the underlying GitHub App client
+func (f *Forge) Client() *Client {
+	return f.client
+}
+
+// ListNamespaces returns the installations of the App
+func (f *Forge) ListNamespaces(ctx context.Context) ([]forge.Namespace, error) {
+	installations, err := f.client.ListInstallations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make([]forge.Namespace, 0, len(installations))
+	for _, inst := range installations {
+		namespaces = append(namespaces, forge.Namespace{
+			ID:        inst.GetID(),
+			Login:     inst.GetAccount().GetLogin(),
+			Type:      inst.GetAccount().GetType(),
+			AccountID: inst.GetAccount().GetID(),
+		})
+	}
+
+	return namespaces, nil
+}
+
+// ListRepositories returns the repositories the installation has access to
+func (f *Forge) ListRepositories(ctx context.Context, ns forge.Namespace, pageSize int) ([]forge.Repository, error) {
+	repos, err := f.client.ListInstallationRepositories(ctx, ns.ID, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]forge.Repository, 0, len(repos))
+	for _, repo := range repos {
+		result = append(result, f.parser.convertRepository(repo))
+	}
+
+	return result, nil
+}
+
+// ListTeams returns the teams of the installation's organization
+func (f *Forge) ListTeams(ctx context.Context, ns forge.Namespace, pageSize int) ([]forge.Team, error) {
+	teams, err := f.client.ListOrganizationTeams(ctx, ns.ID, ns.Login, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]forge.Team, 0, len(teams))
+	for _, team := range teams {
+		result = append(result, f.parser.convertTeam(team))
+	}
+
+	return result, nil
+}
+
+// ListTeamMembers returns the members of a team
+func (f *Forge) ListTeamMembers(ctx context.Context, ns forge.Namespace, team forge.Team, pageSize int) ([]forge.User, error) {
+	members, err := f.client.ListTeamMembers(ctx, ns.ID, ns.AccountID, team.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]forge.User, 0, len(members))
+	for _, member := range members {
+		result = append(result, f.parser.convertUser(member))
+	}
+
+	return result, nil
+}
+
+// ListRepositoryTeams returns the teams with access to a repository and their permission
+func (f *Forge) ListRepositoryTeams(ctx context.Context, ns forge.Namespace, repo forge.Repository) ([]forge.Team, error) {
+	teams, err := f.client.ListRepositoryTeams(ctx, ns.ID, repo.Owner.Login, repo.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]forge.Team, 0, len(teams))
+	for _, team := range teams {
+		result = append(result, f.parser.convertTeam(team))
+	}
+
+	return result, nil
+}
+
+// Delivery returns the X-GitHub-Event and X-GitHub-Delivery headers
+func (f *Forge) Delivery(r *http.Request) (eventType, deliveryID string) {
+	return r.Header.Get("X-GitHub-Event"), r.Header.Get("X-GitHub-Delivery")
+}
+
+// ValidateWebhook checks the X-Hub-Signature-256 header, falling back to the legacy X-Hub-Signature
+func (f *Forge) ValidateWebhook(r *http.Request, payload []byte) error {
+	signature := r.Header.Get("X-Hub-Signature-256")
+	if signature == "" {
+		signature = r.Header.Get("X-Hub-Signature")
+	}
+	return f.client.ValidateWebhookSignature(payload, signature)
+}
+
+// ParseEvent parses a GitHub webhook payload
+func (f *Forge) ParseEvent(eventType string, payload []byte) (*forge.Event, error) {
+	return f.parser.ParseEvent(eventType, payload)
+}
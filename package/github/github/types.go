@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/google/go-github/v57/github"
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
 )
 
 // EventParser handles parsing of GitHub webhook events
@@ -18,6 +19,7 @@ func NewEventParser() *EventParser {
 // ParseEvent parses a raw GitHub webhook payload into a normalized Event
 func (p *EventParser) ParseEvent(eventType string, payload []byte) (*Event, error) {
 	event := &Event{
+		Provider:   forge.ProviderGitHub,
 		Type:       EventType(eventType),
 		RawPayload: payload,
 	}
@@ -27,6 +29,8 @@ func (p *EventParser) ParseEvent(eventType string, payload []byte) (*Event, erro
 		return p.parsePushEvent(event, payload)
 	case "pull_request":
 		return p.parsePullRequestEvent(event, payload)
+	case "pull_request_review":
+		return p.parsePullRequestReviewEvent(event, payload)
 	case "membership":
 		return p.parseMembershipEvent(event, payload)
 	case "repository":
@@ -80,6 +84,10 @@ func (p *EventParser) parsePushEvent(event *Event, payload []byte) (*Event, erro
 		}
 	}
 
+	if ghEvent.Installation != nil {
+		event.Installation = p.convertInstallation(ghEvent.Installation)
+	}
+
 	// Store raw payload for detailed processing
 	var rawPayload map[string]interface{}
 	if err := json.Unmarshal(payload, &rawPayload); err == nil {
@@ -135,6 +143,38 @@ func (p *EventParser) parsePullRequestEvent(event *Event, payload []byte) (*Even
 	return event, nil
 }
 
+func (p *EventParser) parsePullRequestReviewEvent(event *Event, payload []byte) (*Event, error) {
+	var ghEvent github.PullRequestReviewEvent
+	if err := json.Unmarshal(payload, &ghEvent); err != nil {
+		return nil, fmt.Errorf("failed to parse pull request review event: %w", err)
+	}
+
+	event.Action = ghEvent.GetAction()
+
+	if ghEvent.Repo != nil {
+		event.Repository = p.convertRepository(ghEvent.Repo)
+	}
+
+	if ghEvent.Sender != nil {
+		event.Sender = p.convertUser(ghEvent.Sender)
+	}
+
+	if ghEvent.Organization != nil {
+		event.Organization = p.convertOrganization(ghEvent.Organization)
+	}
+
+	if ghEvent.Installation != nil {
+		event.Installation = p.convertInstallation(ghEvent.Installation)
+	}
+
+	var rawPayload map[string]interface{}
+	if err := json.Unmarshal(payload, &rawPayload); err == nil {
+		event.Payload = rawPayload
+	}
+
+	return event, nil
+}
+
 func (p *EventParser) parseMembershipEvent(event *Event, payload []byte) (*Event, error) {
 	var ghEvent github.MembershipEvent
 	if err := json.Unmarshal(payload, &ghEvent); err != nil {
@@ -392,5 +432,6 @@ func (p *EventParser) convertTeam(team *github.Team) Team {
 		Slug:        team.GetSlug(),
 		Description: team.GetDescription(),
 		Privacy:     team.GetPrivacy(),
+		Permission:  team.GetPermission(),
 	}
 }
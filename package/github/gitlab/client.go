@@ -0,0 +1,337 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/package/github/config"
+	"github.com/skygenesisenterprise/aether-identity/package/github/errors"
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
+)
+
+// Client is the GitLab forge adapter. Top-level groups are namespaces, their subgroups are teams
+// and system hooks feed the shared sync core.
+type Client struct {
+	baseURL       string
+	token         string
+	webhookSecret string
+	httpClient    *http.Client
+	parser        *EventParser
+}
+
+// NewClient creates a new GitLab client
+func NewClient(cfg config.GitLabConfig) *Client {
+	return NewClientWithHTTPClient(cfg, &http.Client{Timeout: 30 * time.Second})
+}
+
+// NewClientWithHTTPClient creates a GitLab client that sends its requests through httpClient
+func NewClientWithHTTPClient(cfg config.GitLabConfig, httpClient *http.Client) *Client {
+	return &Client{
+		baseURL:       strings.TrimSuffix(cfg.BaseURL, "/"),
+		token:         cfg.Token,
+		webhookSecret: cfg.WebhookSecret,
+		httpClient:    httpClient,
+		parser:        NewEventParser(),
+	}
+}
+
+// group is a GitLab group as returned by the groups API
+type group struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	FullPath    string `json:"full_path"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility"`
+}
+
+// project is a GitLab project as returned by the projects API
+type project struct {
+	ID                int64  `json:"id"`
+	Name              string `json:"name"`
+	Path              string `json:"path"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	Description       string `json:"description"`
+	Visibility        string `json:"visibility"`
+	WebURL            string `json:"web_url"`
+	HTTPURLToRepo     string `json:"http_url_to_repo"`
+	DefaultBranch     string `json:"default_branch"`
+	Namespace         struct {
+		ID       int64  `json:"id"`
+		Kind     string `json:"kind"`
+		FullPath string `json:"full_path"`
+	} `json:"namespace"`
+	SharedWithGroups []struct {
+		GroupID          int64  `json:"group_id"`
+		GroupName        string `json:"group_name"`
+		GroupFullPath    string `json:"group_full_path"`
+		GroupAccessLevel int    `json:"group_access_level"`
+	} `json:"shared_with_groups"`
+}
+
+// member is a group or project member with its access level
+type member struct {
+	ID          int64  `json:"id"`
+	Username    string `json:"username"`
+	Name        string `json:"name"`
+	State       string `json:"state"`
+	AvatarURL   string `json:"avatar_url"`
+	AccessLevel int    `json:"access_level"`
+}
+
+// Provider returns the GitLab provider
+func (c *Client) Provider() forge.Provider {
+	return forge.ProviderGitLab
+}
+
+// ListNamespaces returns the top-level groups visible to the token
+func (c *Client) ListNamespaces(ctx context.Context) ([]forge.Namespace, error) {
+	groups, err := listAll[group](ctx, c, "/groups", url.Values{"top_level_only": {"true"}, "all_available": {"true"}}, 100)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make([]forge.Namespace, 0, len(groups))
+	for _, g := range groups {
+		namespaces = append(namespaces, forge.Namespace{
+			ID:        g.ID,
+			Login:     g.FullPath,
+			Type:      "Organization",
+			AccountID: g.ID,
+		})
+	}
+
+	return namespaces, nil
+}
+
+// ListRepositories returns the projects of a top-level group and of all its subgroups
+func (c *Client) ListRepositories(ctx context.Context, ns forge.Namespace, pageSize int) ([]forge.Repository, error) {
+	projects, err := listAll[project](ctx, c, fmt.Sprintf("/groups/%d/projects", ns.ID),
+		url.Values{"include_subgroups": {"true"}, "with_shared": {"false"}}, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]forge.Repository, 0, len(projects))
+	for _, p := range projects {
+		repos = append(repos, convertProject(p))
+	}
+
+	return repos, nil
+}
+
+// ListTeams returns the top-level group itself, with an empty slug, and all its descendant groups
+func (c *Client) ListTeams(ctx context.Context, ns forge.Namespace, pageSize int) ([]forge.Team, error) {
+	var root group
+	if _, err := c.get(ctx, fmt.Sprintf("/groups/%d", ns.ID), url.Values{"with_projects": {"false"}}, &root); err != nil {
+		return nil, err
+	}
+
+	descendants, err := listAll[group](ctx, c, fmt.Sprintf("/groups/%d/descendant_groups", ns.ID), nil, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	teams := []forge.Team{convertGroup(root, ns.Login)}
+	for _, g := range descendants {
+		teams = append(teams, convertGroup(g, ns.Login))
+	}
+
+	return teams, nil
+}
+
+// ListTeamMembers returns the active members of a group, including those inherited from parent groups
+func (c *Client) ListTeamMembers(ctx context.Context, ns forge.Namespace, team forge.Team, pageSize int) ([]forge.User, error) {
+	members, err := listAll[member](ctx, c, fmt.Sprintf("/groups/%d/members/all", team.ID), nil, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]forge.User, 0, len(members))
+	for _, m := range members {
+		if m.State != "" && m.State != "active" {
+			continue
+		}
+		users = append(users, convertMember(m))
+	}
+
+	return users, nil
+}
+
+// ListRepositoryTeams returns the groups a project is shared with. Members of the project's own
+// group hierarchy are returned by ListRepositoryCollaborators with their individual access level.
+func (c *Client) ListRepositoryTeams(ctx context.Context, ns forge.Namespace, repo forge.Repository) ([]forge.Team, error) {
+	var p project
+	if _, err := c.get(ctx, fmt.Sprintf("/projects/%d", repo.ID), nil, &p); err != nil {
+		return nil, err
+	}
+
+	var teams []forge.Team
+	for _, shared := range p.SharedWithGroups {
+		permission := AccessLevelPermission(shared.GroupAccessLevel)
+		if permission == "" {
+			continue
+		}
+		// Members of groups from another namespace are still returned as collaborators
+		org, slug := forge.SplitTeamName(shared.GroupFullPath)
+		if org != ns.Login {
+			continue
+		}
+		teams = append(teams, forge.Team{
+			ID:         shared.GroupID,
+			Name:       shared.GroupName,
+			Slug:       slug,
+			Permission: permission,
+		})
+	}
+
+	return teams, nil
+}
+
+// ListRepositoryCollaborators returns the effective members of a project with their permission
+func (c *Client) ListRepositoryCollaborators(ctx context.Context, ns forge.Namespace, repo forge.Repository, pageSize int) ([]forge.Collaborator, error) {
+	members, err := listAll[member](ctx, c, fmt.Sprintf("/projects/%d/members/all", repo.ID), nil, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	collaborators := make([]forge.Collaborator, 0, len(members))
+	for _, m := range members {
+		permission := AccessLevelPermission(m.AccessLevel)
+		if permission == "" || (m.State != "" && m.State != "active") {
+			continue
+		}
+		collaborators = append(collaborators, forge.Collaborator{User: convertMember(m), Permission: permission})
+	}
+
+	return collaborators, nil
+}
+
+// AccessLevelPermission maps a GitLab access level to a normalized repository permission.
+// Guests cannot read the code of private projects and get no permission.
+func AccessLevelPermission(level int) string {
+	switch {
+	case level >= 50:
+		return "admin"
+	case level >= 40:
+		return "maintain"
+	case level >= 30:
+		return "push"
+	case level >= 20:
+		return "pull"
+	default:
+		return ""
+	}
+}
+
+// get sends an authenticated GET request to the GitLab API and decodes the JSON response
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) (*http.Response, error) {
+	endpoint := c.baseURL + "/api/v4" + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "GITLAB", "REQUEST_ERROR", "failed to create request")
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "GITLAB", "API_ERROR", fmt.Sprintf("GET %s failed", path))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("GITLAB", "API_ERROR", fmt.Sprintf("GET %s returned status %d", path, resp.StatusCode))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return nil, errors.Wrap(err, "GITLAB", "DESERIALIZATION_ERROR", fmt.Sprintf("failed to decode %s", path))
+	}
+
+	return resp, nil
+}
+
+// listAll follows the X-Next-Page header to read every page of a list endpoint
+func listAll[T any](ctx context.Context, c *Client, path string, query url.Values, pageSize int) ([]T, error) {
+	params := url.Values{}
+	for key, values := range query {
+		params[key] = values
+	}
+	params.Set("per_page", strconv.Itoa(max(pageSize, 1)))
+
+	var all []T
+	for page := "1"; page != ""; {
+		params.Set("page", page)
+
+		var items []T
+		resp, err := c.get(ctx, path, params, &items)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+
+		page = resp.Header.Get("X-Next-Page")
+	}
+
+	return all, nil
+}
+
+// convertProject converts a GitLab project to a forge repository
+func convertProject(p project) forge.Repository {
+	ownerType := "User"
+	if p.Namespace.Kind == "group" {
+		ownerType = "Organization"
+	}
+
+	return forge.Repository{
+		ID:       p.ID,
+		Name:     p.Path,
+		FullName: p.PathWithNamespace,
+		Owner: forge.User{
+			ID:    p.Namespace.ID,
+			Login: p.Namespace.FullPath,
+			Type:  ownerType,
+		},
+		Private:       p.Visibility != "public",
+		Description:   p.Description,
+		HTMLURL:       p.WebURL,
+		CloneURL:      p.HTTPURLToRepo,
+		DefaultBranch: p.DefaultBranch,
+	}
+}
+
+// convertGroup converts a GitLab group to a forge team whose slug is relative to the top-level group
+func convertGroup(g group, namespace string) forge.Team {
+	slug, _ := strings.CutPrefix(g.FullPath, namespace+"/")
+	if g.FullPath == namespace {
+		slug = ""
+	}
+	return forge.Team{
+		ID:          g.ID,
+		Name:        g.Name,
+		Slug:        slug,
+		Description: g.Description,
+		Privacy:     g.Visibility,
+	}
+}
+
+// convertMember converts a GitLab member to a forge user
+func convertMember(m member) forge.User {
+	return forge.User{
+		ID:        m.ID,
+		Login:     m.Username,
+		Name:      m.Name,
+		Type:      "User",
+		AvatarURL: m.AvatarURL,
+	}
+}
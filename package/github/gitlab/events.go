@@ -0,0 +1,325 @@
+package gitlab
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/skygenesisenterprise/aether-identity/package/github/errors"
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
+)
+
+// EventParser normalizes GitLab system hooks and project hooks into forge events.
+// System hooks describe their event in event_name, project hooks in object_kind.
+type EventParser struct{}
+
+// NewEventParser creates a new GitLab event parser
+func NewEventParser() *EventParser {
+	return &EventParser{}
+}
+
+// hookPayload holds the fields of the GitLab hook payloads the parser reads
+type hookPayload struct {
+	ObjectKind string `json:"object_kind"`
+	EventName  string `json:"event_name"`
+
+	// System hook and push hook fields
+	ProjectID         int64         `json:"project_id"`
+	Name              string        `json:"name"`
+	Path              string        `json:"path"`
+	PathWithNamespace string        `json:"path_with_namespace"`
+	ProjectVisibility string        `json:"project_visibility"`
+	GroupID           int64         `json:"group_id"`
+	GroupName         string        `json:"group_name"`
+	GroupPath         string        `json:"group_path"`
+	FullPath          string        `json:"full_path"`
+	UserID            int64         `json:"user_id"`
+	UserName          string        `json:"user_name"`
+	UserUsername      string        `json:"user_username"`
+	UserEmail         string        `json:"user_email"`
+	Username          string        `json:"username"`
+	Email             string        `json:"email"`
+	User              *hookUser     `json:"user"`
+	Project           *hookProject  `json:"project"`
+	ObjectAttributes  *mergeRequest `json:"object_attributes"`
+}
+
+// hookUser is the user of a merge request hook
+type hookUser struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// hookProject is the project of a push or merge request hook
+type hookProject struct {
+	ID                int64  `json:"id"`
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	WebURL            string `json:"web_url"`
+	GitHTTPURL        string `json:"git_http_url"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	DefaultBranch     string `json:"default_branch"`
+	VisibilityLevel   int    `json:"visibility_level"`
+}
+
+// mergeRequest holds the object attributes of a merge request hook
+type mergeRequest struct {
+	ID           int64  `json:"id"`
+	IID          int    `json:"iid"`
+	Title        string `json:"title"`
+	State        string `json:"state"`
+	Action       string `json:"action"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	OldRev       string `json:"oldrev"`
+	LastCommit   struct {
+		ID string `json:"id"`
+	} `json:"last_commit"`
+}
+
+// ParseEvent parses a GitLab hook payload; eventType is the X-Gitlab-Event header
+func (p *EventParser) ParseEvent(eventType string, payload []byte) (*forge.Event, error) {
+	var hook hookPayload
+	if err := json.Unmarshal(payload, &hook); err != nil {
+		return nil, fmt.Errorf("failed to parse %s payload: %w", eventType, err)
+	}
+
+	var rawPayload map[string]interface{}
+	if err := json.Unmarshal(payload, &rawPayload); err != nil {
+		return nil, fmt.Errorf("failed to parse %s payload: %w", eventType, err)
+	}
+
+	event := &forge.Event{
+		Provider:   forge.ProviderGitLab,
+		RawPayload: payload,
+		Payload:    rawPayload,
+	}
+
+	kind := hook.ObjectKind
+	if kind == "" {
+		kind = hook.EventName
+	}
+
+	switch kind {
+	case "push", "tag_push":
+		p.parsePush(event, &hook)
+	case "merge_request":
+		p.parseMergeRequest(event, &hook)
+	case "project_create", "project_destroy", "project_rename", "project_transfer", "project_update":
+		p.parseProject(event, &hook, kind)
+	case "user_add_to_group", "user_remove_from_group", "user_update_for_group":
+		p.parseGroupMembership(event, &hook, kind)
+	case "group_create", "group_destroy", "group_rename":
+		p.parseGroup(event, &hook, kind)
+	case "user_create", "user_destroy", "user_rename":
+		event.Type = forge.EventType("user")
+		event.Action = strings.TrimPrefix(kind, "user_")
+		event.Sender = forge.User{ID: hook.UserID, Login: hook.Username, Name: hook.Name, Email: hook.Email}
+	default:
+		event.Type = forge.EventType(kind)
+		event.Action = kind
+	}
+
+	return event, nil
+}
+
+// parsePush normalizes a push hook
+func (p *EventParser) parsePush(event *forge.Event, hook *hookPayload) {
+	event.Type = forge.EventTypePush
+	event.Action = "push"
+	if hook.ObjectKind == "tag_push" {
+		event.Type = forge.EventTypeCreate
+		event.Action = "tag"
+	}
+
+	event.Sender = forge.User{ID: hook.UserID, Login: hook.UserUsername, Name: hook.UserName, Email: hook.UserEmail}
+	if hook.Project != nil {
+		event.Repository = convertHookProject(hook.Project)
+		event.Organization = forge.Organization{Login: topLevelGroup(hook.Project.PathWithNamespace)}
+	}
+}
+
+// parseMergeRequest normalizes a merge request hook. Approvals become pull request reviews and
+// GitLab actions are renamed to their GitHub equivalent.
+func (p *EventParser) parseMergeRequest(event *forge.Event, hook *hookPayload) {
+	event.Type = forge.EventTypePullRequest
+	if hook.User != nil {
+		event.Sender = forge.User{ID: hook.User.ID, Login: hook.User.Username, Name: hook.User.Name, Email: hook.User.Email}
+	}
+	if hook.Project != nil {
+		event.Repository = convertHookProject(hook.Project)
+		event.Organization = forge.Organization{Login: topLevelGroup(hook.Project.PathWithNamespace)}
+	}
+
+	mr := hook.ObjectAttributes
+	if mr == nil {
+		event.Action = "unknown"
+		return
+	}
+
+	switch mr.Action {
+	case "open":
+		event.Action = "opened"
+	case "close":
+		event.Action = "closed"
+	case "reopen":
+		event.Action = "reopened"
+	case "merge":
+		event.Action = "merged"
+	case "update":
+		event.Action = "edited"
+		if mr.OldRev != "" {
+			event.Action = "synchronize"
+		}
+	case "approved", "approval":
+		event.Type = forge.EventTypePullRequestReview
+		event.Action = "submitted"
+		event.Payload["review"] = map[string]interface{}{"state": "approved"}
+	case "unapproved", "unapproval":
+		event.Type = forge.EventTypePullRequestReview
+		event.Action = "dismissed"
+	default:
+		event.Action = mr.Action
+	}
+
+	event.Payload["number"] = float64(mr.IID)
+	event.Payload["normalized_pull_request"] = forge.PullRequest{
+		ID:     mr.ID,
+		Number: mr.IID,
+		Title:  mr.Title,
+		State:  mr.State,
+		User:   event.Sender,
+		Head:   forge.Branch{Ref: mr.SourceBranch, SHA: mr.LastCommit.ID},
+		Base:   forge.Branch{Ref: mr.TargetBranch},
+		Merged: mr.State == "merged",
+	}
+}
+
+// parseProject normalizes the project system hooks into repository events
+func (p *EventParser) parseProject(event *forge.Event, hook *hookPayload, kind string) {
+	event.Type = forge.EventTypeRepository
+	switch kind {
+	case "project_create":
+		event.Action = "created"
+	case "project_destroy":
+		event.Action = "deleted"
+	case "project_transfer":
+		event.Action = "transferred"
+	default:
+		event.Action = "edited"
+	}
+
+	event.Repository = forge.Repository{
+		ID:       hook.ProjectID,
+		Name:     hook.Path,
+		FullName: hook.PathWithNamespace,
+		Private:  hook.ProjectVisibility != "public",
+	}
+	event.Organization = forge.Organization{Login: topLevelGroup(hook.PathWithNamespace)}
+}
+
+// parseGroupMembership normalizes the group member system hooks into membership events.
+// The payload gets GitHub-shaped "member" and "team" entries for the sync core.
+func (p *EventParser) parseGroupMembership(event *forge.Event, hook *hookPayload, kind string) {
+	event.Type = forge.EventTypeMembership
+	switch kind {
+	case "user_add_to_group":
+		event.Action = "added"
+	case "user_remove_from_group":
+		event.Action = "removed"
+	default:
+		event.Action = "edited"
+	}
+
+	fullPath := hook.FullPath
+	if fullPath == "" {
+		fullPath = hook.GroupPath
+	}
+	org, slug := forge.SplitTeamName(fullPath)
+
+	event.Sender = forge.User{ID: hook.UserID, Login: hook.UserUsername, Name: hook.UserName, Email: hook.UserEmail}
+	event.Organization = forge.Organization{Login: org}
+	event.Payload["member"] = map[string]interface{}{"id": float64(hook.UserID), "login": hook.UserUsername}
+	event.Payload["team"] = map[string]interface{}{"id": float64(hook.GroupID), "slug": slug, "name": hook.GroupName}
+}
+
+// parseGroup normalizes the group system hooks into team events
+func (p *EventParser) parseGroup(event *forge.Event, hook *hookPayload, kind string) {
+	event.Type = forge.EventTypeTeam
+	switch kind {
+	case "group_create":
+		event.Action = "created"
+	case "group_destroy":
+		event.Action = "deleted"
+	default:
+		event.Action = "edited"
+	}
+
+	fullPath := hook.FullPath
+	if fullPath == "" {
+		fullPath = hook.Path
+	}
+	org, slug := forge.SplitTeamName(fullPath)
+
+	event.Organization = forge.Organization{ID: hook.GroupID, Login: org}
+	event.Payload["team"] = map[string]interface{}{"id": float64(hook.GroupID), "slug": slug, "name": hook.Name}
+}
+
+// Delivery returns the X-Gitlab-Event header and the X-Gitlab-Event-UUID delivery ID
+func (c *Client) Delivery(r *http.Request) (eventType, deliveryID string) {
+	deliveryID = r.Header.Get("X-Gitlab-Event-UUID")
+	if deliveryID == "" {
+		deliveryID = r.Header.Get("X-Gitlab-Webhook-UUID")
+	}
+	return r.Header.Get("X-Gitlab-Event"), deliveryID
+}
+
+// ValidateWebhook compares the X-Gitlab-Token header with the configured secret token
+func (c *Client) ValidateWebhook(r *http.Request, payload []byte) error {
+	if c.webhookSecret == "" {
+		return errors.ErrInvalidWebhookSecret
+	}
+
+	token := r.Header.Get("X-Gitlab-Token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(c.webhookSecret)) != 1 {
+		return errors.Wrap(errors.ErrInvalidSignature, "WEBHOOK", "VALIDATION_ERROR", "invalid X-Gitlab-Token")
+	}
+	return nil
+}
+
+// ParseEvent parses a GitLab hook payload
+func (c *Client) ParseEvent(eventType string, payload []byte) (*forge.Event, error) {
+	return c.parser.ParseEvent(eventType, payload)
+}
+
+// convertHookProject converts the project of a push or merge request hook
+func convertHookProject(p *hookProject) forge.Repository {
+	fullName := p.PathWithNamespace
+	owner := ""
+	name := fullName
+	if i := strings.LastIndex(fullName, "/"); i >= 0 {
+		owner, name = fullName[:i], fullName[i+1:]
+	}
+
+	return forge.Repository{
+		ID:            p.ID,
+		Name:          name,
+		FullName:      fullName,
+		Owner:         forge.User{Login: owner},
+		Private:       p.VisibilityLevel != 20,
+		Description:   p.Description,
+		HTMLURL:       p.WebURL,
+		CloneURL:      p.GitHTTPURL,
+		DefaultBranch: p.DefaultBranch,
+	}
+}
+
+// topLevelGroup returns the first segment of a GitLab path
+func topLevelGroup(path string) string {
+	group, _, _ := strings.Cut(path, "/")
+	return group
+}
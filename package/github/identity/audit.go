@@ -22,6 +22,7 @@ type AuditEntry struct {
 	ID            string                 `json:"id"`
 	Timestamp     time.Time              `json:"timestamp"`
 	RequestID     string                 `json:"request_id"`
+	Provider      string                 `json:"provider,omitempty"` // Forge the entry relates to; github when empty
 	EventType     AuditEventType         `json:"event_type"`
 	Actor         Actor                  `json:"actor"`
 	Action        string                 `json:"action"`
@@ -74,7 +75,7 @@ func (a *AuditLogger) LogAuthorization(ctx context.Context, entry AuditEntry) er
 	// Send to Identity audit API
 	syncEvent := SyncEvent{
 		EventType:  "audit",
-		Provider:   "github",
+		Provider:   entry.provider(),
 		EntityType: "audit_entry",
 		EntityID:   entry.ID,
 		Data: map[string]interface{}{
@@ -102,7 +103,7 @@ func (a *AuditLogger) LogSync(ctx context.Context, entry AuditEntry) error {
 
 	syncEvent := SyncEvent{
 		EventType:  "audit",
-		Provider:   "github",
+		Provider:   entry.provider(),
 		EntityType: "audit_entry",
 		EntityID:   entry.ID,
 		Data: map[string]interface{}{
@@ -130,7 +131,7 @@ func (a *AuditLogger) LogEnforcement(ctx context.Context, entry AuditEntry) erro
 
 	syncEvent := SyncEvent{
 		EventType:  "audit",
-		Provider:   "github",
+		Provider:   entry.provider(),
 		EntityType: "audit_entry",
 		EntityID:   entry.ID,
 		Data: map[string]interface{}{
@@ -163,7 +164,7 @@ func (a *AuditLogger) LogError(ctx context.Context, entry AuditEntry, err error)
 
 	syncEvent := SyncEvent{
 		EventType:  "audit",
-		Provider:   "github",
+		Provider:   entry.provider(),
 		EntityType: "audit_entry",
 		EntityID:   entry.ID,
 		Data: map[string]interface{}{
@@ -180,6 +181,14 @@ func (a *AuditLogger) LogError(ctx context.Context, entry AuditEntry, err error)
 	return nil
 }
 
+// provider returns the forge of the entry, defaulting to GitHub
+func (e AuditEntry) provider() string {
+	if e.Provider == "" {
+		return "github"
+	}
+	return e.Provider
+}
+
 // generateID generates a unique ID for audit entries
 func generateID() string {
 	return time.Now().Format("20060102-150405-") + randomString(8)
@@ -56,6 +56,30 @@ type AuthorizationResponse struct {
 	Reason   string                 `json:"reason,omitempty"`
 	Decision string                 `json:"decision"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	// ConsistencyToken identifies the snapshot the decision was evaluated at
+	ConsistencyToken string `json:"consistency_token,omitempty"`
+}
+
+// RelationCheckRequest asks whether a subject ("user:<id>") has a relation on an object ("team:org/slug")
+type RelationCheckRequest struct {
+	Object    string `json:"object"`
+	Relation  string `json:"relation"`
+	Subject   string `json:"subject"`
+	RequestID string `json:"request_id"`
+}
+
+// RelationshipWriteRequest writes and deletes relation tuples ("repo:owner/name#writer@github_user:42")
+// in a single revision. Replace lists object relations ("team:org/slug#member") whose tuples
+// not present in Writes are deleted.
+type RelationshipWriteRequest struct {
+	Writes  []string `json:"writes,omitempty"`
+	Deletes []string `json:"deletes,omitempty"`
+	Replace []string `json:"replace,omitempty"`
+}
+
+// RelationshipWriteResponse represents the response from a relationship write
+type RelationshipWriteResponse struct {
+	ConsistencyToken string `json:"consistency_token"`
 }
 
 // User represents an Identity user
@@ -90,6 +114,26 @@ type SyncEvent struct {
 	RequestID  string                 `json:"request_id"`
 }
 
+// SyncedEntity is an entity Identity holds from a previous sync event
+type SyncedEntity struct {
+	ID             string                 `json:"id"`
+	Provider       string                 `json:"provider"`
+	EntityType     string                 `json:"entityType"`
+	ExternalID     string                 `json:"externalId"`
+	Name           string                 `json:"name"`
+	Data           map[string]interface{} `json:"data,omitempty"`
+	InstallationID *int64                 `json:"installationId,omitempty"`
+	SyncedAt       time.Time              `json:"syncedAt"`
+}
+
+// syncedEntityPage is a page of the synced entity listing
+type syncedEntityPage struct {
+	Entities []SyncedEntity `json:"entities"`
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	Limit    int            `json:"limit"`
+}
+
 // CheckAuthorization asks Identity if a user is allowed to perform an action
 func (c *Client) CheckAuthorization(ctx context.Context, req AuthorizationRequest) (*AuthorizationResponse, error) {
 	url := fmt.Sprintf("%s/api/v1/authz/check", c.baseURL)
@@ -126,9 +170,81 @@ func (c *Client) CheckAuthorization(ctx context.Context, req AuthorizationReques
 	return &authResp, nil
 }
 
-// ResolveUser resolves a GitHub user to an Identity user
-func (c *Client) ResolveUser(ctx context.Context, githubUserID string) (*User, error) {
-	url := fmt.Sprintf("%s/api/v1/users/resolve?provider=github&external_id=%s", c.baseURL, githubUserID)
+// CheckRelation asks Identity if a subject has a relation on an object
+func (c *Client) CheckRelation(ctx context.Context, req RelationCheckRequest) (*AuthorizationResponse, error) {
+	url := fmt.Sprintf("%s/api/v1/authz/check", c.baseURL)
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "IDENTITY", "SERIALIZATION_ERROR", "failed to marshal relation check")
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "IDENTITY", "REQUEST_ERROR", "failed to create relation check request")
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	httpReq.Header.Set("X-Request-ID", req.RequestID)
+
+	resp, err := c.doWithRetry(httpReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "IDENTITY", "API_ERROR", "relation check failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("IDENTITY", "API_ERROR", fmt.Sprintf("relation check returned status %d", resp.StatusCode))
+	}
+
+	var authResp AuthorizationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&authResp); err != nil {
+		return nil, errors.Wrap(err, "IDENTITY", "DESERIALIZATION_ERROR", "failed to decode relation check response")
+	}
+
+	return &authResp, nil
+}
+
+// WriteRelationships writes relation tuples to the Identity authorization store
+func (c *Client) WriteRelationships(ctx context.Context, req RelationshipWriteRequest, requestID string) (*RelationshipWriteResponse, error) {
+	url := fmt.Sprintf("%s/api/v1/authz/write", c.baseURL)
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "IDENTITY", "SERIALIZATION_ERROR", "failed to marshal relationship write")
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "IDENTITY", "REQUEST_ERROR", "failed to create relationship write request")
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	httpReq.Header.Set("X-Request-ID", requestID)
+
+	resp, err := c.doWithRetry(httpReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "IDENTITY", "API_ERROR", "relationship write failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("IDENTITY", "API_ERROR", fmt.Sprintf("relationship write returned status %d", resp.StatusCode))
+	}
+
+	var writeResp RelationshipWriteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&writeResp); err != nil {
+		return nil, errors.Wrap(err, "IDENTITY", "DESERIALIZATION_ERROR", "failed to decode relationship write response")
+	}
+
+	return &writeResp, nil
+}
+
+// ResolveUser resolves a forge user (github, gitlab or gitea) to an Identity user
+func (c *Client) ResolveUser(ctx context.Context, provider, externalUserID string) (*User, error) {
+	url := fmt.Sprintf("%s/api/v1/users/resolve?provider=%s&external_id=%s", c.baseURL, provider, externalUserID)
 
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -159,9 +275,9 @@ func (c *Client) ResolveUser(ctx context.Context, githubUserID string) (*User, e
 	return &user, nil
 }
 
-// SyncEntity sends a synchronization event to Identity
+// SyncEntity sends a synchronization event to Identity, under the event's provider
 func (c *Client) SyncEntity(ctx context.Context, event SyncEvent) error {
-	url := fmt.Sprintf("%s/api/v1/sync/github", c.baseURL)
+	url := fmt.Sprintf("%s/api/v1/sync/%s", c.baseURL, event.Provider)
 
 	body, err := json.Marshal(event)
 	if err != nil {
@@ -190,6 +306,73 @@ func (c *Client) SyncEntity(ctx context.Context, event SyncEvent) error {
 	return nil
 }
 
+// ListSyncedEntities lists the entities of a provider and type that Identity holds for a namespace
+func (c *Client) ListSyncedEntities(ctx context.Context, provider, entityType string, installationID int64, pageSize int) ([]SyncedEntity, error) {
+	var allEntities []SyncedEntity
+
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s/api/v1/sync/%s/entities?entity_type=%s&installation_id=%d&page=%d&limit=%d",
+			c.baseURL, provider, entityType, installationID, page, pageSize)
+
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "IDENTITY", "REQUEST_ERROR", "failed to create synced entities request")
+		}
+
+		httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+
+		resp, err := c.doWithRetry(httpReq)
+		if err != nil {
+			return nil, errors.Wrap(err, "IDENTITY", "API_ERROR", "failed to list synced entities")
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, errors.New("IDENTITY", "API_ERROR", fmt.Sprintf("list synced entities returned status %d", resp.StatusCode))
+		}
+
+		var result syncedEntityPage
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "IDENTITY", "DESERIALIZATION_ERROR", "failed to decode synced entities response")
+		}
+
+		allEntities = append(allEntities, result.Entities...)
+
+		if len(result.Entities) == 0 || int64(len(allEntities)) >= result.Total {
+			break
+		}
+	}
+
+	return allEntities, nil
+}
+
+// DeleteSyncedEntity removes an entity that no longer exists on its forge
+func (c *Client) DeleteSyncedEntity(ctx context.Context, provider, entityType, externalID, requestID string) error {
+	url := fmt.Sprintf("%s/api/v1/sync/%s/entities/%s/%s", c.baseURL, provider, entityType, externalID)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return errors.Wrap(err, "IDENTITY", "REQUEST_ERROR", "failed to create synced entity deletion request")
+	}
+
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	httpReq.Header.Set("X-Request-ID", requestID)
+
+	resp, err := c.doWithRetry(httpReq)
+	if err != nil {
+		return errors.Wrap(err, "IDENTITY", "API_ERROR", "failed to delete synced entity")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return errors.New("IDENTITY", "API_ERROR", fmt.Sprintf("delete synced entity returned status %d", resp.StatusCode))
+	}
+
+	return nil
+}
+
 // GetUserRoles retrieves roles for a user from Identity
 func (c *Client) GetUserRoles(ctx context.Context, userID string) ([]Role, error) {
 	url := fmt.Sprintf("%s/api/v1/users/%s/roles", c.baseURL, userID)
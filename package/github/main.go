@@ -14,9 +14,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/skygenesisenterprise/aether-identity/package/github/config"
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
+	"github.com/skygenesisenterprise/aether-identity/package/github/gitea"
 	"github.com/skygenesisenterprise/aether-identity/package/github/github"
+	"github.com/skygenesisenterprise/aether-identity/package/github/gitlab"
 	"github.com/skygenesisenterprise/aether-identity/package/github/identity"
 	"github.com/skygenesisenterprise/aether-identity/package/github/permissions"
+	"github.com/skygenesisenterprise/aether-identity/package/github/queue"
 	"github.com/skygenesisenterprise/aether-identity/package/github/server"
 	"github.com/skygenesisenterprise/aether-identity/package/github/sync"
 )
@@ -71,27 +75,63 @@ func main() {
 		log.Fatalf("Failed to create GitHub client: %v", err)
 	}
 
+	// GitHub is always enabled; GitLab and Gitea are enabled by their base URL
+	ghForge := github.NewForge(ghClient)
+	directories := []forge.Directory{ghForge}
+	sources := []forge.WebhookSource{ghForge}
+	if cfg.GitLab.Enabled() {
+		glClient := gitlab.NewClient(cfg.GitLab)
+		directories = append(directories, glClient)
+		sources = append(sources, glClient)
+	}
+	if cfg.Gitea.Enabled() {
+		gtClient := gitea.NewClient(cfg.Gitea)
+		directories = append(directories, gtClient)
+		sources = append(sources, gtClient)
+	}
+
 	idClient := identity.NewClient(cfg.Identity)
 	auditLogger := identity.NewAuditLogger(idClient)
-	syncMgr := sync.NewManager(cfg.Sync, idClient, auditLogger, ghClient)
+	syncMgr := sync.NewManager(cfg.Sync, idClient, auditLogger, directories...)
+	scheduler := sync.NewScheduler(syncMgr, cfg.Sync)
 	checker := permissions.NewChecker(idClient)
 	mapper := permissions.NewMapper()
-	enforcer := permissions.NewEnforcer(checker, auditLogger, mapper)
-	eventParser := github.NewEventParser()
+	policyEngine, err := permissions.NewPolicyEngine(cfg.Enforcement, ghClient, idClient)
+	if err != nil {
+		log.Fatalf("Failed to load branch policies: %v", err)
+	}
+	if cfg.Enforcement.PolicyFile != "" && (cfg.GitLab.Enabled() || cfg.Gitea.Enabled()) {
+		log.Printf("Warning: branch policies are only enforced on GitHub; GitLab and Gitea pushes and merge requests are not checked")
+	}
+	enforcer := permissions.NewEnforcer(checker, auditLogger, mapper, policyEngine)
+
+	deliveryStore, err := queue.NewStore(cfg.Queue)
+	if err != nil {
+		log.Fatalf("Failed to open webhook queue: %v", err)
+	}
+	deliveryQueue := queue.New(deliveryStore, cfg.Queue)
 
 	webhookHandler := server.NewWebhookHandler(
-		eventParser,
 		syncMgr,
 		enforcer,
 		auditLogger,
-		ghClient,
+		deliveryQueue,
+		sources...,
 	)
+	adminHandler := server.NewAdminHandler(deliveryQueue, scheduler, cfg.Queue.AdminToken)
 
 	handlers := &server.Handlers{
-		WebhookHandler:      webhookHandler.Handle,
-		HealthHandler:       healthHandler,
-		MetricsHandler:      metricsHandler,
-		InstallationHandler: installationHandler(syncMgr),
+		WebhookHandler:       webhookHandler.Handle,
+		GitLabWebhookHandler: webhookHandler.HandleProvider(forge.ProviderGitLab),
+		GiteaWebhookHandler:  webhookHandler.HandleProvider(forge.ProviderGitea),
+		HealthHandler:        healthHandler,
+		MetricsHandler:       metricsHandler,
+		InstallationHandler:  installationHandler(syncMgr),
+		DeliveriesHandler:    adminHandler.ListDeliveries,
+		ReplayHandler:        adminHandler.ReplayDeliveries,
+		DriftHandler:         adminHandler.SyncDrift,
+		ReconcileHandler:     adminHandler.Reconcile,
+		SyncStatusHandler:    adminHandler.SyncStatus,
 	}
 
 	gin.SetMode(gin.ReleaseMode)
@@ -101,9 +141,16 @@ func main() {
 	gin.DefaultWriter = io.Discard
 
 	router.POST("/webhook", gin.WrapF(handlers.WebhookHandler))
+	router.POST("/webhooks/gitlab", gin.WrapF(handlers.GitLabWebhookHandler))
+	router.POST("/webhooks/gitea", gin.WrapF(handlers.GiteaWebhookHandler))
 	router.GET("/health", gin.WrapF(handlers.HealthHandler))
 	router.GET("/metrics", gin.WrapF(handlers.MetricsHandler))
 	router.POST("/installations/sync", gin.WrapF(handlers.InstallationHandler))
+	router.GET("/admin/deliveries", gin.WrapF(handlers.DeliveriesHandler))
+	router.POST("/admin/deliveries/replay", gin.WrapF(handlers.ReplayHandler))
+	router.GET("/admin/sync/drift", gin.WrapF(handlers.DriftHandler))
+	router.POST("/admin/sync/reconcile", gin.WrapF(handlers.ReconcileHandler))
+	router.GET("/admin/sync/status", gin.WrapF(handlers.SyncStatusHandler))
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -130,6 +177,14 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	// Start the webhook queue workers
+	if err := deliveryQueue.Start(ctx, webhookHandler.ProcessDelivery); err != nil {
+		log.Fatalf("Failed to start webhook queue: %v", err)
+	}
+
+	// Start the periodic reconciliation with the configured forges
+	scheduler.Start(ctx)
+
 	// Start server in a goroutine
 	serverErr := make(chan error, 1)
 	go func() {
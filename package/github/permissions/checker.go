@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	apperrors "github.com/skygenesisenterprise/aether-identity/package/github/errors"
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
 	"github.com/skygenesisenterprise/aether-identity/package/github/identity"
 )
 
@@ -29,14 +31,16 @@ type CheckResult struct {
 	Decision  string
 	RequestID string
 	Timestamp time.Time
+	// ConsistencyToken can be sent back to Identity to evaluate later checks at least as fresh
+	ConsistencyToken string
 }
 
-// CheckAuthorization checks if a user is authorized to perform an action on a resource
-func (c *Checker) CheckAuthorization(ctx context.Context, githubUserID string, action string, resource identity.AuthorizationResource, contextData map[string]interface{}) (*CheckResult, error) {
+// CheckAuthorization checks if a forge user is authorized to perform an action on a resource
+func (c *Checker) CheckAuthorization(ctx context.Context, provider forge.Provider, externalUserID string, action string, resource identity.AuthorizationResource, contextData map[string]interface{}) (*CheckResult, error) {
 	requestID := generateRequestID()
 
-	// First, resolve the GitHub user to an Identity user
-	identityUser, err := c.identityClient.ResolveUser(ctx, githubUserID)
+	// First, resolve the forge user to an Identity user
+	identityUser, err := c.identityClient.ResolveUser(ctx, string(provider), externalUserID)
 	if err != nil {
 		if errors.Is(err, apperrors.ErrUserNotFound) {
 			return &CheckResult{
@@ -67,67 +71,68 @@ func (c *Checker) CheckAuthorization(ctx context.Context, githubUserID string, a
 	}
 
 	return &CheckResult{
-		Allowed:   authResp.Allowed,
-		Reason:    authResp.Reason,
-		Decision:  authResp.Decision,
-		RequestID: requestID,
-		Timestamp: time.Now().UTC(),
+		Allowed:          authResp.Allowed,
+		Reason:           authResp.Reason,
+		Decision:         authResp.Decision,
+		RequestID:        requestID,
+		Timestamp:        time.Now().UTC(),
+		ConsistencyToken: authResp.ConsistencyToken,
 	}, nil
 }
 
 // CheckPushAuthorization checks if a user is authorized to push to a repository
-func (c *Checker) CheckPushAuthorization(ctx context.Context, githubUserID string, repoOwner, repoName, branch string, installationID int64) (*CheckResult, error) {
-	resource := identity.AuthorizationResource{
-		Type:         "repository",
-		ID:           fmt.Sprintf("%s/%s", repoOwner, repoName),
-		Name:         fmt.Sprintf("%s/%s", repoOwner, repoName),
-		Organization: repoOwner,
-	}
+func (c *Checker) CheckPushAuthorization(ctx context.Context, provider forge.Provider, externalUserID string, repoOwner, repoName, branch string, installationID int64) (*CheckResult, error) {
+	resource := repositoryResource(provider, repoOwner, repoName)
 
 	contextData := map[string]interface{}{
 		"action_type":     "push",
 		"branch":          branch,
 		"installation_id": installationID,
+		"provider":        provider,
 	}
 
-	return c.CheckAuthorization(ctx, githubUserID, "push", resource, contextData)
+	return c.CheckAuthorization(ctx, provider, externalUserID, "push", resource, contextData)
 }
 
 // CheckPullRequestAuthorization checks if a user is authorized to perform a pull request action
-func (c *Checker) CheckPullRequestAuthorization(ctx context.Context, githubUserID string, repoOwner, repoName string, prNumber int, action string, installationID int64) (*CheckResult, error) {
-	resource := identity.AuthorizationResource{
-		Type:         "repository",
-		ID:           fmt.Sprintf("%s/%s", repoOwner, repoName),
-		Name:         fmt.Sprintf("%s/%s", repoOwner, repoName),
-		Organization: repoOwner,
-	}
+func (c *Checker) CheckPullRequestAuthorization(ctx context.Context, provider forge.Provider, externalUserID string, repoOwner, repoName string, prNumber int, action string, installationID int64) (*CheckResult, error) {
+	resource := repositoryResource(provider, repoOwner, repoName)
 
 	contextData := map[string]interface{}{
 		"action_type":     "pull_request",
 		"pr_number":       prNumber,
 		"pr_action":       action,
 		"installation_id": installationID,
+		"provider":        provider,
 	}
 
-	return c.CheckAuthorization(ctx, githubUserID, fmt.Sprintf("pull_request.%s", action), resource, contextData)
+	return c.CheckAuthorization(ctx, provider, externalUserID, fmt.Sprintf("pull_request.%s", action), resource, contextData)
 }
 
 // CheckAdminAuthorization checks if a user is authorized to perform an admin action
-func (c *Checker) CheckAdminAuthorization(ctx context.Context, githubUserID string, repoOwner, repoName, adminAction string, installationID int64) (*CheckResult, error) {
-	resource := identity.AuthorizationResource{
-		Type:         "repository",
-		ID:           fmt.Sprintf("%s/%s", repoOwner, repoName),
-		Name:         fmt.Sprintf("%s/%s", repoOwner, repoName),
-		Organization: repoOwner,
-	}
+func (c *Checker) CheckAdminAuthorization(ctx context.Context, provider forge.Provider, externalUserID string, repoOwner, repoName, adminAction string, installationID int64) (*CheckResult, error) {
+	resource := repositoryResource(provider, repoOwner, repoName)
 
 	contextData := map[string]interface{}{
 		"action_type":     "admin",
 		"admin_action":    adminAction,
 		"installation_id": installationID,
+		"provider":        provider,
 	}
 
-	return c.CheckAuthorization(ctx, githubUserID, fmt.Sprintf("admin.%s", adminAction), resource, contextData)
+	return c.CheckAuthorization(ctx, provider, externalUserID, fmt.Sprintf("admin.%s", adminAction), resource, contextData)
+}
+
+// repositoryResource returns the authorization resource of a repository; repositories outside
+// GitHub are prefixed with their provider, e.g. "gitlab:group/project"
+func repositoryResource(provider forge.Provider, repoOwner, repoName string) identity.AuthorizationResource {
+	fullName := fmt.Sprintf("%s/%s", repoOwner, repoName)
+	return identity.AuthorizationResource{
+		Type:         "repository",
+		ID:           forge.RepositoryID(provider, fullName),
+		Name:         fullName,
+		Organization: forge.OrganizationID(provider, strings.SplitN(repoOwner, "/", 2)[0]),
+	}
 }
 
 // generateRequestID generates a unique request ID
@@ -0,0 +1,119 @@
+package permissions
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// CodeOwnersPaths lists the locations GitHub reads a CODEOWNERS file from, in order of precedence
+var CodeOwnersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeOwnersRule associates a path pattern with its owners ("@user", "@org/team" or an email)
+type CodeOwnersRule struct {
+	Pattern string
+	Owners  []string
+	matcher *regexp.Regexp
+}
+
+// CodeOwners holds the rules of a CODEOWNERS file
+type CodeOwners struct {
+	Rules []CodeOwnersRule
+}
+
+// ParseCodeOwners parses a CODEOWNERS file. Like GitHub, lines with an invalid pattern are skipped.
+func ParseCodeOwners(content []byte) *CodeOwners {
+	codeOwners := &CodeOwners{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		matcher, err := compileCodeOwnersPattern(fields[0])
+		if err != nil {
+			continue
+		}
+
+		codeOwners.Rules = append(codeOwners.Rules, CodeOwnersRule{
+			Pattern: fields[0],
+			Owners:  fields[1:],
+			matcher: matcher,
+		})
+	}
+
+	return codeOwners
+}
+
+// Owners returns the owners of a path; the last matching rule wins and may have no owners
+func (c *CodeOwners) Owners(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	for i := len(c.Rules) - 1; i >= 0; i-- {
+		if c.Rules[i].matcher.MatchString(path) {
+			return c.Rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// compileCodeOwnersPattern converts a CODEOWNERS pattern (gitignore syntax) to a regular expression.
+// A pattern without a slash matches at any depth; a directory pattern matches everything beneath it.
+func compileCodeOwnersPattern(pattern string) (*regexp.Regexp, error) {
+	trimmed := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(trimmed, "/")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+
+	prefix := "^"
+	if !anchored {
+		prefix = "^(?:.*/)?"
+	}
+
+	// "docs/*" only matches direct children, "docs/" and "docs" match the whole tree
+	suffix := "(?:/.*)?$"
+	lastSegment := trimmed[strings.LastIndex(trimmed, "/")+1:]
+	if strings.HasSuffix(pattern, "/") {
+		suffix = "/.*$"
+	} else if strings.ContainsAny(lastSegment, "*?") {
+		suffix = "$"
+	}
+
+	return regexp.Compile(prefix + globToRegexp(trimmed) + suffix)
+}
+
+// matchGlob reports whether name matches a glob pattern where "*" stops at "/" and "**" does not
+func matchGlob(pattern, name string) bool {
+	matcher, err := regexp.Compile("^" + globToRegexp(pattern) + "$")
+	if err != nil {
+		return false
+	}
+	return matcher.MatchString(name)
+}
+
+// globToRegexp translates "*", "**" and "?" wildcards to a regular expression
+func globToRegexp(pattern string) string {
+	var expr strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expr.WriteString("[^/]*")
+		case pattern[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	return expr.String()
+}
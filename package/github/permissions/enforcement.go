@@ -2,9 +2,15 @@ package permissions
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	gh "github.com/google/go-github/v57/github"
+	apperrors "github.com/skygenesisenterprise/aether-identity/package/github/errors"
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
+	"github.com/skygenesisenterprise/aether-identity/package/github/github"
 	"github.com/skygenesisenterprise/aether-identity/package/github/identity"
 )
 
@@ -13,6 +19,7 @@ type Enforcer struct {
 	checker     *Checker
 	auditLogger *identity.AuditLogger
 	mapper      *Mapper
+	policies    *PolicyEngine
 }
 
 // EnforcementResult represents the result of an enforcement action
@@ -24,28 +31,29 @@ type EnforcementResult struct {
 	Timestamp time.Time
 }
 
-// NewEnforcer creates a new policy enforcer
-func NewEnforcer(checker *Checker, auditLogger *identity.AuditLogger, mapper *Mapper) *Enforcer {
+// NewEnforcer creates a new policy enforcer; branch policies are not enforced when policies is nil
+func NewEnforcer(checker *Checker, auditLogger *identity.AuditLogger, mapper *Mapper, policies *PolicyEngine) *Enforcer {
 	return &Enforcer{
 		checker:     checker,
 		auditLogger: auditLogger,
 		mapper:      mapper,
+		policies:    policies,
 	}
 }
 
 // EnforcePush enforces authorization for a push event
-func (e *Enforcer) EnforcePush(ctx context.Context, githubUserID string, repoOwner, repoName, branch string, installationID int64) (*EnforcementResult, error) {
+func (e *Enforcer) EnforcePush(ctx context.Context, provider forge.Provider, externalUserID string, repoOwner, repoName, branch string, installationID int64) (*EnforcementResult, error) {
 	requestID := generateEnforcementID()
 
 	// Check authorization
-	result, err := e.checker.CheckPushAuthorization(ctx, githubUserID, repoOwner, repoName, branch, installationID)
+	result, err := e.checker.CheckPushAuthorization(ctx, provider, externalUserID, repoOwner, repoName, branch, installationID)
 	if err != nil {
-		e.logEnforcementError(ctx, githubUserID, "push", repoOwner, repoName, err, requestID)
+		e.logEnforcementError(ctx, provider, externalUserID, "push", repoOwner, repoName, err, requestID)
 		return nil, err
 	}
 
 	// Log the enforcement decision
-	e.logEnforcementDecision(ctx, githubUserID, "push", repoOwner, repoName, result, requestID)
+	e.logEnforcementDecision(ctx, provider, externalUserID, "push", repoOwner, repoName, result, requestID)
 
 	return &EnforcementResult{
 		Action:    "push",
@@ -57,18 +65,18 @@ func (e *Enforcer) EnforcePush(ctx context.Context, githubUserID string, repoOwn
 }
 
 // EnforcePullRequest enforces authorization for a pull request action
-func (e *Enforcer) EnforcePullRequest(ctx context.Context, githubUserID string, repoOwner, repoName string, prNumber int, action string, installationID int64) (*EnforcementResult, error) {
+func (e *Enforcer) EnforcePullRequest(ctx context.Context, provider forge.Provider, externalUserID string, repoOwner, repoName string, prNumber int, action string, installationID int64) (*EnforcementResult, error) {
 	requestID := generateEnforcementID()
 
 	// Check authorization
-	result, err := e.checker.CheckPullRequestAuthorization(ctx, githubUserID, repoOwner, repoName, prNumber, action, installationID)
+	result, err := e.checker.CheckPullRequestAuthorization(ctx, provider, externalUserID, repoOwner, repoName, prNumber, action, installationID)
 	if err != nil {
-		e.logEnforcementError(ctx, githubUserID, fmt.Sprintf("pull_request.%s", action), repoOwner, repoName, err, requestID)
+		e.logEnforcementError(ctx, provider, externalUserID, fmt.Sprintf("pull_request.%s", action), repoOwner, repoName, err, requestID)
 		return nil, err
 	}
 
 	// Log the enforcement decision
-	e.logEnforcementDecision(ctx, githubUserID, fmt.Sprintf("pull_request.%s", action), repoOwner, repoName, result, requestID)
+	e.logEnforcementDecision(ctx, provider, externalUserID, fmt.Sprintf("pull_request.%s", action), repoOwner, repoName, result, requestID)
 
 	return &EnforcementResult{
 		Action:    fmt.Sprintf("pull_request.%s", action),
@@ -80,18 +88,18 @@ func (e *Enforcer) EnforcePullRequest(ctx context.Context, githubUserID string,
 }
 
 // EnforceAdminAction enforces authorization for an admin action
-func (e *Enforcer) EnforceAdminAction(ctx context.Context, githubUserID string, repoOwner, repoName, adminAction string, installationID int64) (*EnforcementResult, error) {
+func (e *Enforcer) EnforceAdminAction(ctx context.Context, provider forge.Provider, externalUserID string, repoOwner, repoName, adminAction string, installationID int64) (*EnforcementResult, error) {
 	requestID := generateEnforcementID()
 
 	// Check authorization
-	result, err := e.checker.CheckAdminAuthorization(ctx, githubUserID, repoOwner, repoName, adminAction, installationID)
+	result, err := e.checker.CheckAdminAuthorization(ctx, provider, externalUserID, repoOwner, repoName, adminAction, installationID)
 	if err != nil {
-		e.logEnforcementError(ctx, githubUserID, fmt.Sprintf("admin.%s", adminAction), repoOwner, repoName, err, requestID)
+		e.logEnforcementError(ctx, provider, externalUserID, fmt.Sprintf("admin.%s", adminAction), repoOwner, repoName, err, requestID)
 		return nil, err
 	}
 
 	// Log the enforcement decision
-	e.logEnforcementDecision(ctx, githubUserID, fmt.Sprintf("admin.%s", adminAction), repoOwner, repoName, result, requestID)
+	e.logEnforcementDecision(ctx, provider, externalUserID, fmt.Sprintf("admin.%s", adminAction), repoOwner, repoName, result, requestID)
 
 	return &EnforcementResult{
 		Action:    fmt.Sprintf("admin.%s", adminAction),
@@ -102,13 +110,110 @@ func (e *Enforcer) EnforceAdminAction(ctx context.Context, githubUserID string,
 	}, nil
 }
 
+// EnforceBranchPolicy evaluates the branch policy of a push, pull request or review event
+// and reports the outcome to GitHub. It returns nil for events without a policy to evaluate.
+// Branch policies are only evaluated for GitHub: push, pull request and review events from
+// GitLab or Gitea return ErrBranchPolicyUnsupported instead of passing unchecked.
+func (e *Enforcer) EnforceBranchPolicy(ctx context.Context, event *github.Event) (*EnforcementResult, error) {
+	if e.policies == nil {
+		return nil, nil
+	}
+	if event.Provider != "" && event.Provider != forge.ProviderGitHub {
+		switch event.Type {
+		case github.EventTypePush, github.EventTypePullRequest, github.EventTypePullRequestReview:
+			return nil, fmt.Errorf("%w: %s", apperrors.ErrBranchPolicyUnsupported, event.Provider)
+		}
+		return nil, nil
+	}
+
+	owner, repo, err := event.GetRepositoryInfo()
+	if err != nil {
+		return nil, nil
+	}
+
+	action, _, err := e.mapper.MapGitHubEventToAction(event)
+	if err != nil {
+		return nil, nil
+	}
+
+	var evaluation *PolicyEvaluation
+	var actor *gh.User
+
+	switch event.Type {
+	case github.EventTypePush:
+		var push gh.PushEvent
+		if err := json.Unmarshal(event.RawPayload, &push); err != nil {
+			return nil, fmt.Errorf("failed to decode push event: %w", err)
+		}
+		// Tags and branch deletions are not subject to branch policies
+		if !strings.HasPrefix(push.GetRef(), "refs/heads/") || push.GetDeleted() {
+			return nil, nil
+		}
+		actor = push.GetSender()
+		evaluation, err = e.policies.EvaluatePush(ctx, event.Installation.ID, owner, repo,
+			strings.TrimPrefix(push.GetRef(), "refs/heads/"), actor, push.GetForced(), push.GetAfter())
+
+	case github.EventTypePullRequest:
+		switch event.Action {
+		case "opened", "reopened", "synchronize", "ready_for_review", "edited":
+		default:
+			return nil, nil
+		}
+		var prEvent gh.PullRequestEvent
+		if err := json.Unmarshal(event.RawPayload, &prEvent); err != nil {
+			return nil, fmt.Errorf("failed to decode pull request event: %w", err)
+		}
+		actor = prEvent.GetSender()
+		evaluation, err = e.policies.EvaluatePullRequest(ctx, event.Installation.ID, owner, repo, prEvent.GetPullRequest())
+
+	case github.EventTypePullRequestReview:
+		var review gh.PullRequestReviewEvent
+		if err := json.Unmarshal(event.RawPayload, &review); err != nil {
+			return nil, fmt.Errorf("failed to decode pull request review event: %w", err)
+		}
+		actor = review.GetSender()
+		evaluation, err = e.policies.EvaluatePullRequest(ctx, event.Installation.ID, owner, repo, review.GetPullRequest())
+
+	default:
+		return nil, nil
+	}
+
+	provider := forge.ProviderGitHub
+	externalUserID := fmt.Sprintf("%d", actor.GetID())
+	requestID := generateEnforcementID()
+
+	if err != nil {
+		e.logEnforcementError(ctx, provider, externalUserID, action, owner, repo, err, requestID)
+		return nil, err
+	}
+	if evaluation.Policy.IsEmpty() || evaluation.SHA == "" {
+		return nil, nil
+	}
+
+	if err := e.policies.Report(ctx, event.Installation.ID, owner, repo, evaluation); err != nil {
+		e.logEnforcementError(ctx, provider, externalUserID, action, owner, repo, err, requestID)
+		return nil, err
+	}
+
+	result := &CheckResult{Allowed: evaluation.Satisfied, Reason: evaluation.Summary()}
+	e.logEnforcementDecision(ctx, provider, externalUserID, action, owner, repo, result, requestID)
+
+	return &EnforcementResult{
+		Action:    action,
+		Allowed:   evaluation.Satisfied,
+		Reason:    evaluation.Summary(),
+		RequestID: requestID,
+		Timestamp: time.Now().UTC(),
+	}, nil
+}
+
 // ShouldBlock returns true if the action should be blocked
 func (e *EnforcementResult) ShouldBlock() bool {
 	return !e.Allowed
 }
 
 // logEnforcementDecision logs an enforcement decision
-func (e *Enforcer) logEnforcementDecision(ctx context.Context, githubUserID, action, repoOwner, repoName string, result *CheckResult, requestID string) {
+func (e *Enforcer) logEnforcementDecision(ctx context.Context, provider forge.Provider, externalUserID, action, repoOwner, repoName string, result *CheckResult, requestID string) {
 	if e.auditLogger == nil {
 		return
 	}
@@ -120,14 +225,15 @@ func (e *Enforcer) logEnforcementDecision(ctx context.Context, githubUserID, act
 
 	auditEntry := identity.AuditEntry{
 		RequestID: requestID,
+		Provider:  string(provider),
 		Actor: identity.Actor{
 			Type:       "user",
-			ExternalID: githubUserID,
+			ExternalID: externalUserID,
 		},
 		Action: action,
 		Resource: identity.Resource{
 			Type: "repository",
-			ID:   fmt.Sprintf("%s/%s", repoOwner, repoName),
+			ID:   forge.RepositoryID(provider, repoOwner+"/"+repoName),
 			Name: fmt.Sprintf("%s/%s", repoOwner, repoName),
 		},
 		Decision: decision,
@@ -138,21 +244,22 @@ func (e *Enforcer) logEnforcementDecision(ctx context.Context, githubUserID, act
 }
 
 // logEnforcementError logs an enforcement error
-func (e *Enforcer) logEnforcementError(ctx context.Context, githubUserID, action, repoOwner, repoName string, err error, requestID string) {
+func (e *Enforcer) logEnforcementError(ctx context.Context, provider forge.Provider, externalUserID, action, repoOwner, repoName string, err error, requestID string) {
 	if e.auditLogger == nil {
 		return
 	}
 
 	auditEntry := identity.AuditEntry{
 		RequestID: requestID,
+		Provider:  string(provider),
 		Actor: identity.Actor{
 			Type:       "user",
-			ExternalID: githubUserID,
+			ExternalID: externalUserID,
 		},
 		Action: action,
 		Resource: identity.Resource{
 			Type: "repository",
-			ID:   fmt.Sprintf("%s/%s", repoOwner, repoName),
+			ID:   forge.RepositoryID(provider, repoOwner+"/"+repoName),
 			Name: fmt.Sprintf("%s/%s", repoOwner, repoName),
 		},
 		Decision: "error",
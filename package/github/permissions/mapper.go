@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
 	"github.com/skygenesisenterprise/aether-identity/package/github/github"
 )
 
@@ -21,7 +22,10 @@ func (m *Mapper) MapGitHubEventToAction(event *github.Event) (action string, res
 	case github.EventTypePush:
 		return "push", "repository", nil
 	case github.EventTypePullRequest:
-		action := m.mapPullRequestAction(event.Action)
+		action := m.mapPullRequestAction(event.Action, isMerged(event))
+		return action, "repository", nil
+	case github.EventTypePullRequestReview:
+		action := m.mapPullRequestReviewAction(event)
 		return action, "repository", nil
 	case github.EventTypeRepository:
 		action := m.mapRepositoryAction(event.Action)
@@ -55,7 +59,12 @@ func (m *Mapper) MapGitHubPermissionToIdentity(githubPermission string) []string
 
 // MapGitHubRoleToIdentity maps a GitHub team/role to Identity role name
 func (m *Mapper) MapGitHubRoleToIdentity(org, teamSlug string) string {
-	return fmt.Sprintf("github:%s/%s", org, teamSlug)
+	return m.MapRoleToIdentity(forge.ProviderGitHub, org, teamSlug)
+}
+
+// MapRoleToIdentity maps a forge team to Identity role name; GitLab top-level groups have no slug
+func (m *Mapper) MapRoleToIdentity(provider forge.Provider, org, teamSlug string) string {
+	return fmt.Sprintf("%s:%s", provider, forge.TeamName(org, teamSlug))
 }
 
 // MapRepositoryToResourceID maps a repository to an Identity resource ID
@@ -68,12 +77,16 @@ func (m *Mapper) MapUserToIdentityID(githubUserID int64) string {
 	return fmt.Sprintf("github:%d", githubUserID)
 }
 
-// mapPullRequestAction maps a pull request action to an Identity action
-func (m *Mapper) mapPullRequestAction(action string) string {
+// mapPullRequestAction maps a pull request action to an Identity action.
+// GitHub sends merges as "closed" with the merged flag set.
+func (m *Mapper) mapPullRequestAction(action string, merged bool) string {
 	switch action {
 	case "opened":
 		return "pull_request.open"
 	case "closed":
+		if merged {
+			return "pull_request.merge"
+		}
 		return "pull_request.close"
 	case "reopened":
 		return "pull_request.reopen"
@@ -83,11 +96,41 @@ func (m *Mapper) mapPullRequestAction(action string) string {
 		return "pull_request.update"
 	case "edited":
 		return "pull_request.edit"
+	case "ready_for_review":
+		return "pull_request.ready"
+	case "converted_to_draft":
+		return "pull_request.draft"
+	case "review_requested":
+		return "pull_request.request_review"
+	case "review_request_removed":
+		return "pull_request.remove_review_request"
+	case "auto_merge_enabled":
+		return "pull_request.enable_auto_merge"
+	case "auto_merge_disabled":
+		return "pull_request.disable_auto_merge"
 	default:
 		return fmt.Sprintf("pull_request.%s", action)
 	}
 }
 
+// mapPullRequestReviewAction maps a pull request review to an Identity action
+func (m *Mapper) mapPullRequestReviewAction(event *github.Event) string {
+	if event.Action == "dismissed" {
+		return "pull_request.dismiss_review"
+	}
+
+	review, _ := event.Payload["review"].(map[string]interface{})
+	state, _ := review["state"].(string)
+	switch strings.ToLower(state) {
+	case "approved":
+		return "pull_request.approve"
+	case "changes_requested":
+		return "pull_request.request_changes"
+	default:
+		return "pull_request.review"
+	}
+}
+
 // mapRepositoryAction maps a repository action to an Identity action
 func (m *Mapper) mapRepositoryAction(action string) string {
 	switch action {
@@ -130,3 +173,10 @@ func (m *Mapper) ParseRepositoryFullName(fullName string) (owner, repo string, e
 	}
 	return parts[0], parts[1], nil
 }
+
+// isMerged reports whether a pull request event concerns a merged pull request
+func isMerged(event *github.Event) bool {
+	pr, _ := event.Payload["pull_request"].(map[string]interface{})
+	merged, _ := pr["merged"].(bool)
+	return merged
+}
@@ -0,0 +1,484 @@
+package permissions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v57/github"
+	"github.com/skygenesisenterprise/aether-identity/package/github/config"
+	apperrors "github.com/skygenesisenterprise/aether-identity/package/github/errors"
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
+	"github.com/skygenesisenterprise/aether-identity/package/github/github"
+	"github.com/skygenesisenterprise/aether-identity/package/github/identity"
+)
+
+// BranchPolicy describes the rules protecting the branches matching its patterns
+type BranchPolicy struct {
+	Name         string   `json:"name"`
+	Repositories []string `json:"repositories,omitempty"` // "owner/name" patterns; all repositories when empty
+	Branches     []string `json:"branches"`               // "main", "release/*", "hotfix/**"
+	// RequireCodeOwnerReview requires an approval from an owner of every changed file
+	RequireCodeOwnerReview bool `json:"require_code_owner_review,omitempty"`
+	// RequiredApprovals is the minimum number of approving reviews, the author excluded
+	RequiredApprovals int `json:"required_approvals,omitempty"`
+	// ApprovalRules require approvals from subjects holding a relation in Identity
+	ApprovalRules []ApprovalRule `json:"approval_rules,omitempty"`
+	// PushRelation is the relation on "repo:<owner>/<name>" required to push directly to the branch
+	PushRelation   string `json:"push_relation,omitempty"`
+	BlockForcePush bool   `json:"block_force_push,omitempty"`
+}
+
+// ApprovalRule requires Count approvals from users having Relation on Object,
+// for instance two members of "team:acme/security" or two maintainers of "repo:{repository}"
+type ApprovalRule struct {
+	Name     string `json:"name"`
+	Object   string `json:"object"` // "{repository}" is replaced by the repository full name
+	Relation string `json:"relation"`
+	Count    int    `json:"count"`
+}
+
+// PolicyFile is the format of the branch policy file
+type PolicyFile struct {
+	Policies []BranchPolicy `json:"policies"`
+}
+
+// EffectivePolicy combines the policies and GitHub branch protection that apply to a branch
+type EffectivePolicy struct {
+	Sources                []string
+	RequireCodeOwnerReview bool
+	RequiredApprovals      int
+	ApprovalRules          []ApprovalRule
+	PushRelations          []string
+	BlockForcePush         bool
+}
+
+// IsEmpty returns true when no rule applies to the branch
+func (p *EffectivePolicy) IsEmpty() bool {
+	return !p.RequireCodeOwnerReview && p.RequiredApprovals == 0 && len(p.ApprovalRules) == 0 &&
+		len(p.PushRelations) == 0 && !p.BlockForcePush
+}
+
+// PolicyEvaluation is the outcome of evaluating a branch policy
+type PolicyEvaluation struct {
+	Policy     *EffectivePolicy
+	SHA        string
+	Satisfied  bool
+	Violations []string
+	Details    []string
+}
+
+// Summary returns a one line description of the evaluation
+func (e *PolicyEvaluation) Summary() string {
+	if e.Satisfied {
+		return "Branch policy satisfied"
+	}
+	return strings.Join(e.Violations, "; ")
+}
+
+// PolicyEngine evaluates branch policies against pushes and pull requests
+type PolicyEngine struct {
+	policies       []BranchPolicy
+	githubClient   *github.Client
+	identityClient *identity.Client
+	reportMode     string
+	statusContext  string
+}
+
+// NewPolicyEngine creates a policy engine and loads the policy file when configured
+func NewPolicyEngine(cfg config.EnforcementConfig, githubClient *github.Client, identityClient *identity.Client) (*PolicyEngine, error) {
+	engine := &PolicyEngine{
+		githubClient:   githubClient,
+		identityClient: identityClient,
+		reportMode:     cfg.ReportMode,
+		statusContext:  cfg.StatusContext,
+	}
+
+	if cfg.PolicyFile != "" {
+		policies, err := LoadPolicies(cfg.PolicyFile)
+		if err != nil {
+			return nil, err
+		}
+		engine.policies = policies
+	}
+
+	return engine, nil
+}
+
+// LoadPolicies reads and validates a branch policy file
+func LoadPolicies(path string) ([]BranchPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read branch policy file: %w", err)
+	}
+
+	var file PolicyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse branch policy file: %w", err)
+	}
+
+	for i, policy := range file.Policies {
+		if policy.Name == "" {
+			return nil, fmt.Errorf("branch policy %d has no name", i)
+		}
+		if len(policy.Branches) == 0 {
+			return nil, fmt.Errorf("branch policy %s has no branch pattern", policy.Name)
+		}
+		for _, rule := range policy.ApprovalRules {
+			if rule.Object == "" || rule.Relation == "" || rule.Count < 1 {
+				return nil, fmt.Errorf("branch policy %s has an invalid approval rule %q", policy.Name, rule.Name)
+			}
+		}
+	}
+
+	return file.Policies, nil
+}
+
+// Resolve returns the effective policy of a branch from the policy file and GitHub branch protection
+func (p *PolicyEngine) Resolve(ctx context.Context, installationID int64, owner, repo, branch string) (*EffectivePolicy, error) {
+	effective := &EffectivePolicy{}
+	fullName := owner + "/" + repo
+
+	for _, policy := range p.policies {
+		if !policy.matches(fullName, branch) {
+			continue
+		}
+		effective.Sources = append(effective.Sources, policy.Name)
+		effective.RequireCodeOwnerReview = effective.RequireCodeOwnerReview || policy.RequireCodeOwnerReview
+		effective.RequiredApprovals = max(effective.RequiredApprovals, policy.RequiredApprovals)
+		effective.BlockForcePush = effective.BlockForcePush || policy.BlockForcePush
+		for _, rule := range policy.ApprovalRules {
+			rule.Object = strings.ReplaceAll(rule.Object, "{repository}", fullName)
+			effective.ApprovalRules = append(effective.ApprovalRules, rule)
+		}
+		if policy.PushRelation != "" {
+			effective.PushRelations = append(effective.PushRelations, policy.PushRelation)
+		}
+	}
+
+	// Branch protection needs the administration permission; without it only the policy file applies
+	protection, err := p.githubClient.GetBranchProtection(ctx, installationID, owner, repo, branch)
+	if err != nil {
+		log.Printf("Branch protection of %s@%s unavailable: %v", fullName, branch, err)
+	} else if protection != nil && protection.RequiredPullRequestReviews != nil {
+		reviews := protection.RequiredPullRequestReviews
+		effective.Sources = append(effective.Sources, "github-branch-protection")
+		effective.RequireCodeOwnerReview = effective.RequireCodeOwnerReview || reviews.RequireCodeOwnerReviews
+		effective.RequiredApprovals = max(effective.RequiredApprovals, reviews.RequiredApprovingReviewCount)
+	}
+
+	return effective, nil
+}
+
+// EvaluatePush checks a push against the push restrictions of the branch
+func (p *PolicyEngine) EvaluatePush(ctx context.Context, installationID int64, owner, repo, branch string, pusher *gh.User, forced bool, sha string) (*PolicyEvaluation, error) {
+	policy, err := p.Resolve(ctx, installationID, owner, repo, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluation := &PolicyEvaluation{Policy: policy, SHA: sha}
+
+	if policy.BlockForcePush && forced {
+		evaluation.Violations = append(evaluation.Violations, fmt.Sprintf("force push to %s is not allowed", branch))
+	}
+
+	if len(policy.PushRelations) > 0 {
+		subject, err := p.subject(ctx, pusher)
+		if err != nil {
+			return nil, err
+		}
+		object := "repo:" + owner + "/" + repo
+		for _, relation := range policy.PushRelations {
+			allowed, err := p.checkRelation(ctx, object, relation, subject)
+			if err != nil {
+				return nil, err
+			}
+			if !allowed {
+				evaluation.Violations = append(evaluation.Violations, fmt.Sprintf("%s is not %s of %s", pusher.GetLogin(), relation, object))
+			}
+		}
+	}
+
+	evaluation.Satisfied = len(evaluation.Violations) == 0
+	return evaluation, nil
+}
+
+// EvaluatePullRequest checks the reviews of a pull request against the policy of its base branch
+func (p *PolicyEngine) EvaluatePullRequest(ctx context.Context, installationID int64, owner, repo string, pr *gh.PullRequest) (*PolicyEvaluation, error) {
+	policy, err := p.Resolve(ctx, installationID, owner, repo, pr.GetBase().GetRef())
+	if err != nil {
+		return nil, err
+	}
+
+	evaluation := &PolicyEvaluation{Policy: policy, SHA: pr.GetHead().GetSHA()}
+	if policy.RequiredApprovals == 0 && !policy.RequireCodeOwnerReview && len(policy.ApprovalRules) == 0 {
+		evaluation.Satisfied = true
+		return evaluation, nil
+	}
+
+	reviews, err := p.githubClient.ListPullRequestReviews(ctx, installationID, owner, repo, pr.GetNumber())
+	if err != nil {
+		return nil, err
+	}
+	approvers := latestApprovers(reviews, pr.GetUser().GetLogin())
+
+	if len(approvers) < policy.RequiredApprovals {
+		evaluation.Violations = append(evaluation.Violations, fmt.Sprintf("%d of %d required approvals", len(approvers), policy.RequiredApprovals))
+	}
+
+	if policy.RequireCodeOwnerReview {
+		missing, err := p.missingCodeOwners(ctx, installationID, owner, repo, pr, approvers)
+		if err != nil {
+			return nil, err
+		}
+		for _, owners := range missing {
+			evaluation.Violations = append(evaluation.Violations, "code owner review required from "+owners)
+		}
+	}
+
+	for _, rule := range policy.ApprovalRules {
+		count := 0
+		for _, approver := range approvers {
+			subject, err := p.subject(ctx, approver)
+			if err != nil {
+				return nil, err
+			}
+			allowed, err := p.checkRelation(ctx, rule.Object, rule.Relation, subject)
+			if err != nil {
+				return nil, err
+			}
+			if allowed {
+				count++
+			}
+		}
+		evaluation.Details = append(evaluation.Details, fmt.Sprintf("%s: %d of %d approvals from %s of %s", rule.Name, count, rule.Count, rule.Relation, rule.Object))
+		if count < rule.Count {
+			evaluation.Violations = append(evaluation.Violations, fmt.Sprintf("%d of %d approvals from %s of %s", count, rule.Count, rule.Relation, rule.Object))
+		}
+	}
+
+	evaluation.Satisfied = len(evaluation.Violations) == 0
+	return evaluation, nil
+}
+
+// Report publishes an evaluation on GitHub as a check run or a commit status
+func (p *PolicyEngine) Report(ctx context.Context, installationID int64, owner, repo string, evaluation *PolicyEvaluation) error {
+	summary := evaluation.Summary()
+
+	if p.reportMode == "status" {
+		state := "success"
+		if !evaluation.Satisfied {
+			state = "failure"
+		}
+		description := summary
+		if len(description) > 140 {
+			description = description[:137] + "..."
+		}
+		return p.githubClient.CreateCommitStatus(ctx, installationID, owner, repo, evaluation.SHA, &gh.RepoStatus{
+			State:       gh.String(state),
+			Context:     gh.String(p.statusContext),
+			Description: gh.String(description),
+		})
+	}
+
+	conclusion := "success"
+	if !evaluation.Satisfied {
+		conclusion = "failure"
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Policies: %s\n", strings.Join(evaluation.Policy.Sources, ", "))
+	for _, violation := range evaluation.Violations {
+		fmt.Fprintf(&text, "\n- :x: %s", violation)
+	}
+	for _, detail := range evaluation.Details {
+		fmt.Fprintf(&text, "\n- %s", detail)
+	}
+
+	_, err := p.githubClient.CreateCheckRun(ctx, installationID, owner, repo, gh.CreateCheckRunOptions{
+		Name:        p.statusContext,
+		HeadSHA:     evaluation.SHA,
+		Status:      gh.String("completed"),
+		Conclusion:  gh.String(conclusion),
+		CompletedAt: &gh.Timestamp{Time: time.Now().UTC()},
+		Output: &gh.CheckRunOutput{
+			Title:   gh.String(summary),
+			Summary: gh.String(summary),
+			Text:    gh.String(text.String()),
+		},
+	})
+	return err
+}
+
+// missingCodeOwners returns, for each group of changed files, the owners of which no one approved
+func (p *PolicyEngine) missingCodeOwners(ctx context.Context, installationID int64, owner, repo string, pr *gh.PullRequest, approvers []*gh.User) ([]string, error) {
+	codeOwners, err := p.loadCodeOwners(ctx, installationID, owner, repo, pr.GetBase().GetRef())
+	if err != nil || codeOwners == nil {
+		return nil, err
+	}
+
+	files, err := p.githubClient.ListPullRequestFiles(ctx, installationID, owner, repo, pr.GetNumber())
+	if err != nil {
+		return nil, err
+	}
+
+	ownerSets := make(map[string][]string)
+	for _, file := range files {
+		for _, path := range []string{file.GetFilename(), file.GetPreviousFilename()} {
+			if path == "" {
+				continue
+			}
+			if owners := codeOwners.Owners(path); len(owners) > 0 {
+				ownerSets[strings.Join(owners, " ")] = owners
+			}
+		}
+	}
+
+	membership := make(map[string]bool)
+	var missing []string
+	for key, owners := range ownerSets {
+		approved, err := p.ownerApproved(ctx, installationID, owners, approvers, membership)
+		if err != nil {
+			return nil, err
+		}
+		if !approved {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+
+	return missing, nil
+}
+
+// ownerApproved reports whether one of the approvers is one of the owners or belongs to an owner team
+func (p *PolicyEngine) ownerApproved(ctx context.Context, installationID int64, owners []string, approvers []*gh.User, membership map[string]bool) (bool, error) {
+	for _, codeOwner := range owners {
+		name, ok := strings.CutPrefix(codeOwner, "@")
+		if !ok {
+			// Email owners cannot be matched against review authors
+			continue
+		}
+		org, team, isTeam := strings.Cut(name, "/")
+
+		for _, approver := range approvers {
+			if !isTeam {
+				if strings.EqualFold(name, approver.GetLogin()) {
+					return true, nil
+				}
+				continue
+			}
+
+			key := strings.ToLower(name + "@" + approver.GetLogin())
+			member, cached := membership[key]
+			if !cached {
+				var err error
+				member, err = p.githubClient.IsTeamMember(ctx, installationID, org, team, approver.GetLogin())
+				if err != nil {
+					return false, err
+				}
+				membership[key] = member
+			}
+			if member {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// loadCodeOwners reads the CODEOWNERS file of the base branch; it returns nil when there is none
+func (p *PolicyEngine) loadCodeOwners(ctx context.Context, installationID int64, owner, repo, ref string) (*CodeOwners, error) {
+	for _, path := range CodeOwnersPaths {
+		content, err := p.githubClient.GetFileContent(ctx, installationID, owner, repo, path, ref)
+		if err != nil {
+			return nil, err
+		}
+		if content != nil {
+			return ParseCodeOwners(content), nil
+		}
+	}
+	return nil, nil
+}
+
+// subject returns the Identity subject of a GitHub user: the linked Identity user when there is one
+func (p *PolicyEngine) subject(ctx context.Context, user *gh.User) (string, error) {
+	githubUserID := fmt.Sprintf("%d", user.GetID())
+
+	identityUser, err := p.identityClient.ResolveUser(ctx, string(forge.ProviderGitHub), githubUserID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrUserNotFound) {
+			return forge.UserSubject(forge.ProviderGitHub, user.GetID()), nil
+		}
+		return "", apperrors.Wrap(err, "AUTHZ", "RESOLUTION_ERROR", "failed to resolve user")
+	}
+
+	return "user:" + identityUser.ID, nil
+}
+
+// checkRelation asks Identity whether a subject has a relation on an object
+func (p *PolicyEngine) checkRelation(ctx context.Context, object, relation, subject string) (bool, error) {
+	resp, err := p.identityClient.CheckRelation(ctx, identity.RelationCheckRequest{
+		Object:    object,
+		Relation:  relation,
+		Subject:   subject,
+		RequestID: generateRequestID(),
+	})
+	if err != nil {
+		return false, apperrors.Wrap(err, "AUTHZ", "CHECK_ERROR", "relation check failed")
+	}
+	return resp.Allowed, nil
+}
+
+// matches reports whether the policy applies to a branch of a repository
+func (b *BranchPolicy) matches(fullName, branch string) bool {
+	if len(b.Repositories) > 0 && !matchAny(b.Repositories, fullName) {
+		return false
+	}
+	return matchAny(b.Branches, branch)
+}
+
+// matchAny reports whether name matches one of the glob patterns
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// latestApprovers returns the users whose latest review approves the pull request, the author excluded
+func latestApprovers(reviews []*gh.PullRequestReview, author string) []*gh.User {
+	latest := make(map[string]*gh.PullRequestReview)
+	var order []string
+
+	for _, review := range reviews {
+		login := review.GetUser().GetLogin()
+		if login == "" || strings.EqualFold(login, author) {
+			continue
+		}
+		// Comments do not change the approval state of a reviewer
+		if review.GetState() == "COMMENTED" || review.GetState() == "PENDING" {
+			continue
+		}
+		if _, seen := latest[login]; !seen {
+			order = append(order, login)
+		}
+		latest[login] = review
+	}
+
+	var approvers []*gh.User
+	for _, login := range order {
+		if latest[login].GetState() == "APPROVED" {
+			approvers = append(approvers, latest[login].GetUser())
+		}
+	}
+	return approvers
+}
@@ -0,0 +1,158 @@
+package queue
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileStore persists each delivery as a JSON file in a directory.
+// Writes go through a temporary file and a rename so a crash never leaves a partial delivery.
+type FileStore struct {
+	dir        string
+	deliveries map[string]*Delivery
+	mutex      sync.RWMutex
+}
+
+// NewFileStore opens (or creates) a file-backed delivery store and loads its index
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("webhook queue path is required for the file backend")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create webhook queue directory: %w", err)
+	}
+
+	s := &FileStore{dir: dir, deliveries: make(map[string]*Delivery)}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook queue directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read delivery %s: %w", entry.Name(), err)
+		}
+		var d Delivery
+		if err := json.Unmarshal(data, &d); err != nil {
+			return nil, fmt.Errorf("failed to decode delivery %s: %w", entry.Name(), err)
+		}
+		s.deliveries[d.ID] = &d
+	}
+
+	return s, nil
+}
+
+// Put stores a new delivery
+func (s *FileStore) Put(ctx context.Context, d *Delivery) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.deliveries[d.ID]; exists {
+		return false, nil
+	}
+	if err := s.write(d); err != nil {
+		return false, err
+	}
+	s.deliveries[d.ID] = copyDelivery(d)
+	return true, nil
+}
+
+// Get returns a delivery by ID
+func (s *FileStore) Get(ctx context.Context, id string) (*Delivery, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	d, ok := s.deliveries[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyDelivery(d), nil
+}
+
+// Update replaces a stored delivery
+func (s *FileStore) Update(ctx context.Context, d *Delivery) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.deliveries[d.ID]; !ok {
+		return ErrNotFound
+	}
+	if err := s.write(d); err != nil {
+		return err
+	}
+	s.deliveries[d.ID] = copyDelivery(d)
+	return nil
+}
+
+// Due returns pending deliveries whose next attempt is due
+func (s *FileStore) Due(ctx context.Context, now time.Time, limit int) ([]*Delivery, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return selectDeliveries(s.deliveries, func(d *Delivery) bool {
+		return d.Status == StatusPending && !d.NextAttemptAt.After(now)
+	}, limit), nil
+}
+
+// List returns deliveries matching the filter
+func (s *FileStore) List(ctx context.Context, filter Filter) ([]*Delivery, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return selectDeliveries(s.deliveries, filter.Matches, filter.Limit), nil
+}
+
+// Delete removes a delivery
+func (s *FileStore) Delete(ctx context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	delete(s.deliveries, id)
+	return nil
+}
+
+// write atomically persists a delivery
+func (s *FileStore) write(d *Delivery) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to encode delivery %s: %w", d.ID, err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".delivery-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(d.ID))
+}
+
+// path returns the file of a delivery; the ID is hex-encoded so any delivery ID is a safe file name
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, hex.EncodeToString([]byte(id))+".json")
+}
@@ -0,0 +1,109 @@
+package queue
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps deliveries in memory; deliveries are lost on restart
+type MemoryStore struct {
+	deliveries map[string]*Delivery
+	mutex      sync.RWMutex
+}
+
+// NewMemoryStore creates a new in-memory delivery store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{deliveries: make(map[string]*Delivery)}
+}
+
+// Put stores a new delivery
+func (s *MemoryStore) Put(ctx context.Context, d *Delivery) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.deliveries[d.ID]; exists {
+		return false, nil
+	}
+	s.deliveries[d.ID] = copyDelivery(d)
+	return true, nil
+}
+
+// Get returns a delivery by ID
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Delivery, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	d, ok := s.deliveries[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyDelivery(d), nil
+}
+
+// Update replaces a stored delivery
+func (s *MemoryStore) Update(ctx context.Context, d *Delivery) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.deliveries[d.ID]; !ok {
+		return ErrNotFound
+	}
+	s.deliveries[d.ID] = copyDelivery(d)
+	return nil
+}
+
+// Due returns pending deliveries whose next attempt is due
+func (s *MemoryStore) Due(ctx context.Context, now time.Time, limit int) ([]*Delivery, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return selectDeliveries(s.deliveries, func(d *Delivery) bool {
+		return d.Status == StatusPending && !d.NextAttemptAt.After(now)
+	}, limit), nil
+}
+
+// List returns deliveries matching the filter
+func (s *MemoryStore) List(ctx context.Context, filter Filter) ([]*Delivery, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return selectDeliveries(s.deliveries, filter.Matches, filter.Limit), nil
+}
+
+// Delete removes a delivery
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.deliveries, id)
+	return nil
+}
+
+// selectDeliveries returns copies of the matching deliveries, oldest first
+func selectDeliveries(deliveries map[string]*Delivery, match func(*Delivery) bool, limit int) []*Delivery {
+	selected := make([]*Delivery, 0)
+	for _, d := range deliveries {
+		if match(d) {
+			selected = append(selected, copyDelivery(d))
+		}
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].ReceivedAt.Before(selected[j].ReceivedAt)
+	})
+	if limit > 0 && len(selected) > limit {
+		selected = selected[:limit]
+	}
+	return selected
+}
+
+func copyDelivery(d *Delivery) *Delivery {
+	c := *d
+	if d.CompletedAt != nil {
+		completedAt := *d.CompletedAt
+		c.CompletedAt = &completedAt
+	}
+	return &c
+}
@@ -0,0 +1,432 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/package/github/config"
+)
+
+// DeliveryStatus represents the processing state of a webhook delivery
+type DeliveryStatus string
+
+const (
+	StatusPending    DeliveryStatus = "pending"
+	StatusProcessing DeliveryStatus = "processing"
+	StatusSucceeded  DeliveryStatus = "succeeded"
+	StatusDead       DeliveryStatus = "dead"
+)
+
+// ErrNotFound is returned when a delivery does not exist in the store
+var ErrNotFound = errors.New("delivery not found")
+
+// Delivery represents a persisted forge webhook delivery, keyed by its delivery ID
+// (X-GitHub-Delivery for GitHub, prefixed with the provider for other forges)
+type Delivery struct {
+	ID            string         `json:"id"`
+	Provider      string         `json:"provider,omitempty"` // Forge that sent the delivery; github when empty
+	EventType     string         `json:"event_type"`
+	Payload       []byte         `json:"payload"`
+	ReceivedAt    time.Time      `json:"received_at"`
+	Status        DeliveryStatus `json:"status"`
+	Attempts      int            `json:"attempts"`
+	NextAttemptAt time.Time      `json:"next_attempt_at"`
+	LastError     string         `json:"last_error,omitempty"`
+	CompletedAt   *time.Time     `json:"completed_at,omitempty"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// Filter selects deliveries by status and reception time
+type Filter struct {
+	Statuses []DeliveryStatus
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
+
+// Matches reports whether a delivery satisfies the filter
+func (f Filter) Matches(d *Delivery) bool {
+	if len(f.Statuses) > 0 {
+		found := false
+		for _, status := range f.Statuses {
+			if d.Status == status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !f.From.IsZero() && d.ReceivedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && d.ReceivedAt.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// Store persists deliveries. Implementations must be safe for concurrent use.
+type Store interface {
+	// Put stores a new delivery; it returns false without error if the ID already exists
+	Put(ctx context.Context, d *Delivery) (bool, error)
+	// Get returns a delivery by ID or ErrNotFound
+	Get(ctx context.Context, id string) (*Delivery, error)
+	// Update replaces a stored delivery
+	Update(ctx context.Context, d *Delivery) error
+	// Due returns pending deliveries whose next attempt is due, oldest first
+	Due(ctx context.Context, now time.Time, limit int) ([]*Delivery, error)
+	// List returns deliveries matching the filter, oldest first
+	List(ctx context.Context, filter Filter) ([]*Delivery, error)
+	// Delete removes a delivery
+	Delete(ctx context.Context, id string) error
+}
+
+// NewStore creates the store configured for the queue
+func NewStore(cfg config.QueueConfig) (Store, error) {
+	switch cfg.Backend {
+	case "", "file":
+		return NewFileStore(cfg.Path)
+	case "memory":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown webhook queue backend %q", cfg.Backend)
+	}
+}
+
+// Handler processes a delivery; returning a PermanentError dead-letters it immediately
+type Handler func(ctx context.Context, d *Delivery) error
+
+// PermanentError marks a failure that retrying cannot fix
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent wraps an error so the delivery is dead-lettered without retry
+func Permanent(err error) error {
+	return &PermanentError{Err: err}
+}
+
+// Queue dispatches stored deliveries to workers with retry, backoff and dead-lettering
+type Queue struct {
+	store    Store
+	config   config.QueueConfig
+	wake     chan struct{}
+	inFlight map[string]bool
+	mutex    sync.Mutex
+}
+
+// New creates a new delivery queue
+func New(store Store, cfg config.QueueConfig) *Queue {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	return &Queue{
+		store:    store,
+		config:   cfg,
+		wake:     make(chan struct{}, 1),
+		inFlight: make(map[string]bool),
+	}
+}
+
+// Enqueue persists a delivery; it returns false if the delivery ID was already received
+func (q *Queue) Enqueue(ctx context.Context, d *Delivery) (bool, error) {
+	now := time.Now().UTC()
+	d.Status = StatusPending
+	d.Attempts = 0
+	d.NextAttemptAt = now
+	d.UpdatedAt = now
+	if d.ReceivedAt.IsZero() {
+		d.ReceivedAt = now
+	}
+
+	created, err := q.store.Put(ctx, d)
+	if err != nil || !created {
+		return created, err
+	}
+
+	q.notify()
+	return true, nil
+}
+
+// Get returns a delivery by ID
+func (q *Queue) Get(ctx context.Context, id string) (*Delivery, error) {
+	return q.store.Get(ctx, id)
+}
+
+// List returns deliveries matching the filter
+func (q *Queue) List(ctx context.Context, filter Filter) ([]*Delivery, error) {
+	return q.store.List(ctx, filter)
+}
+
+// Replay resets the matching deliveries to pending so they are processed again
+func (q *Queue) Replay(ctx context.Context, filter Filter) (int, error) {
+	deliveries, err := q.store.List(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, d := range deliveries {
+		if d.Status == StatusProcessing || q.isInFlight(d.ID) {
+			continue
+		}
+		if err := q.reset(ctx, d); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+
+	if replayed > 0 {
+		q.notify()
+	}
+	return replayed, nil
+}
+
+// ReplayDelivery resets a single delivery to pending
+func (q *Queue) ReplayDelivery(ctx context.Context, id string) error {
+	d, err := q.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if d.Status == StatusProcessing || q.isInFlight(d.ID) {
+		return fmt.Errorf("delivery %s is being processed", id)
+	}
+	if err := q.reset(ctx, d); err != nil {
+		return err
+	}
+
+	q.notify()
+	return nil
+}
+
+// Start recovers interrupted deliveries and runs the workers until ctx is cancelled
+func (q *Queue) Start(ctx context.Context, handler Handler) error {
+	if err := q.recover(ctx); err != nil {
+		return err
+	}
+
+	jobs := make(chan *Delivery)
+	for i := 0; i < q.config.Workers; i++ {
+		go q.worker(ctx, handler, jobs)
+	}
+	go q.dispatch(ctx, jobs)
+	if q.config.Retention > 0 {
+		go q.purgeLoop(ctx)
+	}
+
+	log.Printf("Webhook queue started with %d workers", q.config.Workers)
+	return nil
+}
+
+// recover returns deliveries left in processing by a crash to the pending state
+func (q *Queue) recover(ctx context.Context) error {
+	deliveries, err := q.store.List(ctx, Filter{Statuses: []DeliveryStatus{StatusProcessing}})
+	if err != nil {
+		return err
+	}
+	for _, d := range deliveries {
+		d.Status = StatusPending
+		d.NextAttemptAt = time.Now().UTC()
+		d.UpdatedAt = time.Now().UTC()
+		if err := q.store.Update(ctx, d); err != nil {
+			return err
+		}
+	}
+	if len(deliveries) > 0 {
+		log.Printf("Webhook queue recovered %d interrupted deliveries", len(deliveries))
+	}
+	return nil
+}
+
+// dispatch polls the store for due deliveries and hands them to the workers
+func (q *Queue) dispatch(ctx context.Context, jobs chan<- *Delivery) {
+	ticker := time.NewTicker(q.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		due, err := q.store.Due(ctx, time.Now().UTC(), q.config.Workers*4)
+		if err != nil {
+			log.Printf("Webhook queue poll error: %v", err)
+		}
+
+		for _, d := range due {
+			if !q.claim(d.ID) {
+				continue
+			}
+			select {
+			case jobs <- d:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+func (q *Queue) worker(ctx context.Context, handler Handler, jobs <-chan *Delivery) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-jobs:
+			q.process(ctx, handler, d)
+			q.release(d.ID)
+		}
+	}
+}
+
+// process runs the handler for a delivery and records the outcome
+func (q *Queue) process(ctx context.Context, handler Handler, d *Delivery) {
+	d.Status = StatusProcessing
+	d.Attempts++
+	d.UpdatedAt = time.Now().UTC()
+	if err := q.store.Update(ctx, d); err != nil {
+		log.Printf("Webhook queue update error for %s: %v", d.ID, err)
+		return
+	}
+
+	err := q.safeHandle(ctx, handler, d)
+	now := time.Now().UTC()
+	d.UpdatedAt = now
+
+	var permanent *PermanentError
+	switch {
+	case err == nil:
+		d.Status = StatusSucceeded
+		d.LastError = ""
+		d.CompletedAt = &now
+	case errors.As(err, &permanent) || d.Attempts >= q.config.MaxAttempts:
+		d.Status = StatusDead
+		d.LastError = err.Error()
+		d.CompletedAt = &now
+		log.Printf("Webhook delivery %s dead-lettered after %d attempts: %v", d.ID, d.Attempts, err)
+	default:
+		d.Status = StatusPending
+		d.LastError = err.Error()
+		d.NextAttemptAt = now.Add(q.backoff(d.Attempts))
+		log.Printf("Webhook delivery %s failed (attempt %d/%d), retrying at %s: %v", d.ID, d.Attempts, q.config.MaxAttempts, d.NextAttemptAt.Format(time.RFC3339), err)
+	}
+
+	// The outcome must be recorded even if the queue is shutting down
+	if err := q.store.Update(context.Background(), d); err != nil {
+		log.Printf("Webhook queue update error for %s: %v", d.ID, err)
+	}
+}
+
+// safeHandle runs the handler and converts a panic into a permanent failure
+func (q *Queue) safeHandle(ctx context.Context, handler Handler, d *Delivery) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = Permanent(fmt.Errorf("panic: %v", r))
+		}
+	}()
+	return handler(ctx, d)
+}
+
+// backoff returns the delay before the next attempt
+func (q *Queue) backoff(attempts int) time.Duration {
+	delay := q.config.BaseBackoff
+	if delay <= 0 {
+		delay = 5 * time.Second
+	}
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if q.config.MaxBackoff > 0 && delay >= q.config.MaxBackoff {
+			return q.config.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// purgeLoop removes succeeded deliveries older than the retention period
+func (q *Queue) purgeLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deliveries, err := q.store.List(ctx, Filter{
+				Statuses: []DeliveryStatus{StatusSucceeded},
+				To:       time.Now().UTC().Add(-q.config.Retention),
+			})
+			if err != nil {
+				log.Printf("Webhook queue purge error: %v", err)
+				continue
+			}
+			for _, d := range deliveries {
+				if err := q.store.Delete(ctx, d.ID); err != nil {
+					log.Printf("Webhook queue purge error for %s: %v", d.ID, err)
+				}
+			}
+		}
+	}
+}
+
+func (q *Queue) reset(ctx context.Context, d *Delivery) error {
+	now := time.Now().UTC()
+	d.Status = StatusPending
+	d.Attempts = 0
+	d.NextAttemptAt = now
+	d.LastError = ""
+	d.CompletedAt = nil
+	d.UpdatedAt = now
+	return q.store.Update(ctx, d)
+}
+
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *Queue) claim(id string) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.inFlight[id] {
+		return false
+	}
+	q.inFlight[id] = true
+	return true
+}
+
+func (q *Queue) release(id string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	delete(q.inFlight, id)
+}
+
+func (q *Queue) isInFlight(id string) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.inFlight[id]
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/package/github/queue"
+	"github.com/skygenesisenterprise/aether-identity/package/github/sync"
+)
+
+// AdminHandler exposes the webhook delivery queue and the sync reconciliation to operators
+type AdminHandler struct {
+	queue     *queue.Queue
+	scheduler *sync.Scheduler
+	token     string
+}
+
+// NewAdminHandler creates a new admin handler; endpoints are disabled when token is empty
+func NewAdminHandler(deliveryQueue *queue.Queue, scheduler *sync.Scheduler, token string) *AdminHandler {
+	return &AdminHandler{
+		queue:     deliveryQueue,
+		scheduler: scheduler,
+		token:     token,
+	}
+}
+
+// ReconcileRequest controls a manual reconciliation
+type ReconcileRequest struct {
+	DryRun bool `json:"dry_run"`
+	Prune  bool `json:"prune"`
+}
+
+// ReplayRequest selects the deliveries to replay, by ID or by time range and status
+type ReplayRequest struct {
+	IDs      []string               `json:"ids,omitempty"`
+	From     time.Time              `json:"from"`
+	To       time.Time              `json:"to"`
+	Statuses []queue.DeliveryStatus `json:"statuses,omitempty"`
+}
+
+// ListDeliveries lists deliveries filtered by status (comma separated) and RFC 3339 from/to
+func (h *AdminHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	requestID := generateRequestID()
+	if !h.authorize(w, r, requestID) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parseDeliveryFilter(r)
+	if err != nil {
+		WriteError(w, err, requestID, http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := h.queue.List(r.Context(), filter)
+	if err != nil {
+		WriteError(w, err, requestID, http.StatusInternalServerError)
+		return
+	}
+
+	// Payloads can be large; the list only returns delivery metadata
+	for _, d := range deliveries {
+		d.Payload = nil
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
+
+// ReplayDeliveries resets deliveries to pending so the workers process them again
+func (h *AdminHandler) ReplayDeliveries(w http.ResponseWriter, r *http.Request) {
+	requestID := generateRequestID()
+	if !h.authorize(w, r, requestID) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, fmt.Errorf("invalid request body: %w", err), requestID, http.StatusBadRequest)
+		return
+	}
+
+	if len(req.IDs) > 0 {
+		for _, id := range req.IDs {
+			if err := h.queue.ReplayDelivery(r.Context(), id); err != nil {
+				status := http.StatusConflict
+				if errors.Is(err, queue.ErrNotFound) {
+					status = http.StatusNotFound
+				}
+				WriteError(w, fmt.Errorf("%s: %w", id, err), requestID, status)
+				return
+			}
+		}
+		writeJSON(w, http.StatusAccepted, map[string]interface{}{"replayed": len(req.IDs)})
+		return
+	}
+
+	if req.From.IsZero() || req.To.IsZero() || req.To.Before(req.From) {
+		WriteError(w, fmt.Errorf("ids or a valid from/to range is required"), requestID, http.StatusBadRequest)
+		return
+	}
+
+	statuses := req.Statuses
+	if len(statuses) == 0 {
+		statuses = []queue.DeliveryStatus{queue.StatusSucceeded, queue.StatusDead}
+	}
+
+	replayed, err := h.queue.Replay(r.Context(), queue.Filter{Statuses: statuses, From: req.From, To: req.To})
+	if err != nil {
+		WriteError(w, err, requestID, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"replayed": replayed})
+}
+
+// SyncDrift reports the drift between GitHub and Identity without changing anything
+func (h *AdminHandler) SyncDrift(w http.ResponseWriter, r *http.Request) {
+	requestID := generateRequestID()
+	if !h.authorize(w, r, requestID) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.reconcile(w, r, requestID, sync.ReconcileOptions{DryRun: true})
+}
+
+// Reconcile repairs the drift between GitHub and Identity, deleting stale entities when prune is set
+func (h *AdminHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	requestID := generateRequestID()
+	if !h.authorize(w, r, requestID) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ReconcileRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteError(w, fmt.Errorf("invalid request body: %w", err), requestID, http.StatusBadRequest)
+			return
+		}
+	}
+
+	h.reconcile(w, r, requestID, sync.ReconcileOptions{DryRun: req.DryRun, Prune: req.Prune})
+}
+
+// SyncStatus returns the reports of the last reconciliation that applied changes
+func (h *AdminHandler) SyncStatus(w http.ResponseWriter, r *http.Request) {
+	requestID := generateRequestID()
+	if !h.authorize(w, r, requestID) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	reports, lastRun := h.scheduler.LastReports()
+	body := map[string]interface{}{"reports": reports, "last_run": nil}
+	if !lastRun.IsZero() {
+		body["last_run"] = lastRun
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// reconcile runs a reconciliation and writes its reports
+func (h *AdminHandler) reconcile(w http.ResponseWriter, r *http.Request, requestID string, opts sync.ReconcileOptions) {
+	reports, err := h.scheduler.Run(r.Context(), opts)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, sync.ErrReconcileRunning) {
+			status = http.StatusConflict
+		}
+		WriteError(w, err, requestID, status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"dry_run": opts.DryRun,
+		"prune":   opts.Prune,
+		"reports": reports,
+	})
+}
+
+// authorize checks the admin bearer token
+func (h *AdminHandler) authorize(w http.ResponseWriter, r *http.Request, requestID string) bool {
+	if h.token == "" {
+		WriteError(w, fmt.Errorf("admin endpoints are disabled"), requestID, http.StatusForbidden)
+		return false
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		WriteError(w, fmt.Errorf("invalid admin token"), requestID, http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// parseDeliveryFilter reads the status, from, to and limit query parameters
+func parseDeliveryFilter(r *http.Request) (queue.Filter, error) {
+	query := r.URL.Query()
+	filter := queue.Filter{Limit: 100}
+
+	if statuses := query.Get("status"); statuses != "" {
+		for _, status := range strings.Split(statuses, ",") {
+			filter.Statuses = append(filter.Statuses, queue.DeliveryStatus(strings.TrimSpace(status)))
+		}
+	}
+	if from := query.Get("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from: %w", err)
+		}
+		filter.From = t
+	}
+	if to := query.Get("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to: %w", err)
+		}
+		filter.To = t
+	}
+	if limit := query.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 || l > 1000 {
+			return filter, fmt.Errorf("invalid limit")
+		}
+		filter.Limit = l
+	}
+	return filter, nil
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+}
@@ -19,10 +19,18 @@ type Server struct {
 
 // Handlers contains all HTTP handlers
 type Handlers struct {
-	WebhookHandler      http.HandlerFunc
-	HealthHandler       http.HandlerFunc
-	MetricsHandler      http.HandlerFunc
-	InstallationHandler http.HandlerFunc
+	WebhookHandler http.HandlerFunc
+	// GitLab and Gitea webhook handlers; their routes are only registered when set
+	GitLabWebhookHandler http.HandlerFunc
+	GiteaWebhookHandler  http.HandlerFunc
+	HealthHandler        http.HandlerFunc
+	MetricsHandler       http.HandlerFunc
+	InstallationHandler  http.HandlerFunc
+	DeliveriesHandler    http.HandlerFunc
+	ReplayHandler        http.HandlerFunc
+	DriftHandler         http.HandlerFunc
+	ReconcileHandler     http.HandlerFunc
+	SyncStatusHandler    http.HandlerFunc
 }
 
 // NewServer creates a new HTTP server
@@ -60,10 +68,23 @@ func (s *Server) setupRoutes() {
 
 	// Webhook endpoint - GitHub sends events here
 	s.mux.HandleFunc("/webhooks", s.handlers.WebhookHandler)
+	if s.handlers.GitLabWebhookHandler != nil {
+		s.mux.HandleFunc("/webhooks/gitlab", s.handlers.GitLabWebhookHandler)
+	}
+	if s.handlers.GiteaWebhookHandler != nil {
+		s.mux.HandleFunc("/webhooks/gitea", s.handlers.GiteaWebhookHandler)
+	}
 
 	// Installation management endpoint
 	s.mux.HandleFunc("/installations", s.handlers.InstallationHandler)
 
+	// Webhook delivery queue administration
+	s.mux.HandleFunc("/admin/deliveries", s.handlers.DeliveriesHandler)
+	s.mux.HandleFunc("/admin/deliveries/replay", s.handlers.ReplayHandler)
+	s.mux.HandleFunc("/admin/sync/drift", s.handlers.DriftHandler)
+	s.mux.HandleFunc("/admin/sync/reconcile", s.handlers.ReconcileHandler)
+	s.mux.HandleFunc("/admin/sync/status", s.handlers.SyncStatusHandler)
+
 	// Default handler for unknown paths
 	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/" {
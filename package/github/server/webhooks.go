@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"log"
@@ -10,42 +11,66 @@ import (
 	"time"
 
 	"github.com/skygenesisenterprise/aether-identity/package/github/errors"
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
 	"github.com/skygenesisenterprise/aether-identity/package/github/github"
 	"github.com/skygenesisenterprise/aether-identity/package/github/identity"
 	"github.com/skygenesisenterprise/aether-identity/package/github/permissions"
+	"github.com/skygenesisenterprise/aether-identity/package/github/queue"
 	"github.com/skygenesisenterprise/aether-identity/package/github/sync"
 )
 
-// WebhookHandler handles GitHub webhook events
+// WebhookHandler handles forge webhook events
 type WebhookHandler struct {
-	eventParser  *github.EventParser
-	syncManager  *sync.Manager
-	enforcer     *permissions.Enforcer
-	auditLogger  *identity.AuditLogger
-	githubClient *github.Client
+	sources     map[forge.Provider]forge.WebhookSource
+	syncManager *sync.Manager
+	enforcer    *permissions.Enforcer
+	auditLogger *identity.AuditLogger
+	queue       *queue.Queue
 }
 
-// NewWebhookHandler creates a new webhook handler
+// NewWebhookHandler creates a new webhook handler accepting deliveries from the given forges
 func NewWebhookHandler(
-	eventParser *github.EventParser,
 	syncManager *sync.Manager,
 	enforcer *permissions.Enforcer,
 	auditLogger *identity.AuditLogger,
-	githubClient *github.Client,
+	deliveryQueue *queue.Queue,
+	sources ...forge.WebhookSource,
 ) *WebhookHandler {
-	return &WebhookHandler{
-		eventParser:  eventParser,
-		syncManager:  syncManager,
-		enforcer:     enforcer,
-		auditLogger:  auditLogger,
-		githubClient: githubClient,
+	h := &WebhookHandler{
+		sources:     make(map[forge.Provider]forge.WebhookSource, len(sources)),
+		syncManager: syncManager,
+		enforcer:    enforcer,
+		auditLogger: auditLogger,
+		queue:       deliveryQueue,
 	}
+	for _, source := range sources {
+		h.sources[source.Provider()] = source
+	}
+	return h
 }
 
-// Handle processes incoming webhook requests
+// Handle processes incoming GitHub webhook requests
 func (h *WebhookHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	h.HandleProvider(forge.ProviderGitHub)(w, r)
+}
+
+// HandleProvider returns the handler processing the webhook requests of a forge
+func (h *WebhookHandler) HandleProvider(provider forge.Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		source, ok := h.sources[provider]
+		if !ok {
+			http.Error(w, fmt.Sprintf("%s webhooks are not configured", provider), http.StatusNotFound)
+			return
+		}
+		h.handle(w, r, source)
+	}
+}
+
+// handle validates, parses and queues a webhook delivery from source
+func (h *WebhookHandler) handle(w http.ResponseWriter, r *http.Request, source forge.WebhookSource) {
 	requestID := generateRequestID()
 	startTime := time.Now()
+	provider := source.Provider()
 
 	// Only accept POST requests
 	if r.Method != http.MethodPost {
@@ -53,23 +78,19 @@ func (h *WebhookHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get event type from header
-	eventType := r.Header.Get("X-GitHub-Event")
+	// Get event type and delivery ID from the forge headers
+	eventType, deliveryID := source.Delivery(r)
 	if eventType == "" {
-		http.Error(w, "Missing X-GitHub-Event header", http.StatusBadRequest)
+		http.Error(w, fmt.Sprintf("Missing %s event header", provider), http.StatusBadRequest)
 		return
 	}
 
-	// Get delivery ID
-	deliveryID := r.Header.Get("X-GitHub-Delivery")
 	if deliveryID == "" {
 		deliveryID = requestID
 	}
-
-	// Get signature
-	signature := r.Header.Get("X-Hub-Signature-256")
-	if signature == "" {
-		signature = r.Header.Get("X-Hub-Signature")
+	// GitHub delivery IDs are kept as-is so existing queue entries stay deduplicated
+	if provider != forge.ProviderGitHub {
+		deliveryID = fmt.Sprintf("%s:%s", provider, deliveryID)
 	}
 
 	// Read body
@@ -82,14 +103,14 @@ func (h *WebhookHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	// Validate signature
-	if err := h.githubClient.ValidateWebhookSignature(body, signature); err != nil {
+	if err := source.ValidateWebhook(r, body); err != nil {
 		log.Printf("[%s] Invalid signature: %v", requestID, err)
 		http.Error(w, "Invalid signature", http.StatusUnauthorized)
 		return
 	}
 
 	// Parse event
-	event, err := h.eventParser.ParseEvent(eventType, body)
+	event, err := source.ParseEvent(eventType, body)
 	if err != nil {
 		log.Printf("[%s] Error parsing event: %v", requestID, err)
 		http.Error(w, "Error parsing event", http.StatusBadRequest)
@@ -99,10 +120,10 @@ func (h *WebhookHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	event.DeliveryID = deliveryID
 	event.ReceivedAt = startTime
 
-	log.Printf("[%s] Received %s event (delivery: %s)", requestID, eventType, deliveryID)
+	log.Printf("[%s] Received %s %s event (delivery: %s)", requestID, provider, eventType, deliveryID)
 
 	// Handle ping events immediately
-	if eventType == "ping" {
+	if provider == forge.ProviderGitHub && eventType == "ping" {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(`{"status": "pong"}`))
 		return
@@ -129,21 +150,71 @@ func (h *WebhookHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Sync event data to Identity
-	go func() {
-		ctx := context.Background()
-		if err := h.syncManager.HandleEvent(ctx, event); err != nil {
-			log.Printf("[%s] Sync error: %v", requestID, err)
-		}
-	}()
+	// Persist the delivery; the queue workers sync it to Identity with retries
+	created, err := h.queue.Enqueue(r.Context(), &queue.Delivery{
+		ID:         deliveryID,
+		Provider:   string(provider),
+		EventType:  eventType,
+		Payload:    body,
+		ReceivedAt: startTime.UTC(),
+	})
+	if err != nil {
+		log.Printf("[%s] Error queueing delivery %s: %v", requestID, deliveryID, err)
+		http.Error(w, "Error queueing event", http.StatusServiceUnavailable)
+		return
+	}
+
+	if !created {
+		log.Printf("[%s] Duplicate delivery %s ignored", requestID, deliveryID)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status": "duplicate"}`))
+		return
+	}
 
-	// Return success immediately
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status": "ok"}`))
+	// Return success once the delivery is stored
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte(`{"status": "queued"}`))
 
 	log.Printf("[%s] Event processed in %v", requestID, time.Since(startTime))
 }
 
+// ProcessDelivery syncs a queued delivery to Identity and enforces branch policies;
+// it is the queue worker handler
+func (h *WebhookHandler) ProcessDelivery(ctx context.Context, d *queue.Delivery) error {
+	provider := forge.ProviderGitHub
+	if d.Provider != "" {
+		provider = forge.Provider(d.Provider)
+	}
+
+	source, ok := h.sources[provider]
+	if !ok {
+		return queue.Permanent(fmt.Errorf("no webhook source configured for %s", provider))
+	}
+
+	event, err := source.ParseEvent(d.EventType, d.Payload)
+	if err != nil {
+		return queue.Permanent(fmt.Errorf("failed to parse event: %w", err))
+	}
+
+	event.DeliveryID = d.ID
+	event.ReceivedAt = d.ReceivedAt
+
+	syncErr := h.syncManager.HandleEvent(ctx, event)
+
+	result, policyErr := h.enforcer.EnforceBranchPolicy(ctx, event)
+	if stderrors.Is(policyErr, errors.ErrBranchPolicyUnsupported) {
+		// Retrying cannot help and the sync already succeeded: report it without failing the delivery
+		log.Printf("[%s] Branch policy not enforced on %s: %v", d.ID, event.Repository.FullName, policyErr)
+		policyErr = nil
+	} else if policyErr != nil {
+		policyErr = fmt.Errorf("branch policy enforcement failed: %w", policyErr)
+	} else if result != nil && result.ShouldBlock() {
+		log.Printf("[%s] Branch policy violated on %s: %s", d.ID, event.Repository.FullName, result.Reason)
+	}
+
+	return stderrors.Join(syncErr, policyErr)
+}
+
 // enforceAuthorization enforces authorization for sensitive actions
 func (h *WebhookHandler) enforceAuthorization(ctx context.Context, event *github.Event) (*permissions.EnforcementResult, error) {
 	// Extract repository info
@@ -154,7 +225,7 @@ func (h *WebhookHandler) enforceAuthorization(ctx context.Context, event *github
 
 	// Get actor
 	actor := event.GetActor()
-	githubUserID := fmt.Sprintf("%d", actor.ID)
+	externalUserID := fmt.Sprintf("%d", actor.ID)
 
 	switch event.Type {
 	case github.EventTypePush:
@@ -163,7 +234,7 @@ func (h *WebhookHandler) enforceAuthorization(ctx context.Context, event *github
 		if payload, ok := event.Payload["ref"].(string); ok {
 			branch = extractBranchFromRef(payload)
 		}
-		return h.enforcer.EnforcePush(ctx, githubUserID, owner, repo, branch, event.Installation.ID)
+		return h.enforcer.EnforcePush(ctx, event.Provider, externalUserID, owner, repo, branch, event.Installation.ID)
 
 	case github.EventTypePullRequest:
 		// For PR events, check PR authorization
@@ -171,7 +242,7 @@ func (h *WebhookHandler) enforceAuthorization(ctx context.Context, event *github
 		if payload, ok := event.Payload["number"].(float64); ok {
 			prNumber = int(payload)
 		}
-		return h.enforcer.EnforcePullRequest(ctx, githubUserID, owner, repo, prNumber, event.Action, event.Installation.ID)
+		return h.enforcer.EnforcePullRequest(ctx, event.Provider, externalUserID, owner, repo, prNumber, event.Action, event.Installation.ID)
 
 	default:
 		// For other events, allow by default (Identity will handle via sync)
@@ -219,5 +290,5 @@ func WriteError(w http.ResponseWriter, err error, requestID string, statusCode i
 
 // IsRetryableError checks if an error is retryable
 func IsRetryableError(err error) bool {
-	return errors.IsGitHubError(err) || errors.IsIdentityError(err)
+	return errors.IsForgeError(err) || errors.IsIdentityError(err)
 }
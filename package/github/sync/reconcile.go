@@ -0,0 +1,409 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
+	"github.com/skygenesisenterprise/aether-identity/package/github/identity"
+)
+
+// DriftKind describes how an Identity entity differs from its forge
+type DriftKind string
+
+const (
+	DriftMissing DriftKind = "missing" // Exists on the forge, unknown to Identity
+	DriftChanged DriftKind = "changed" // Exists on both sides with different data
+	DriftStale   DriftKind = "stale"   // Known to Identity, gone from the forge
+)
+
+// DriftItem is a single difference between a forge and Identity
+type DriftItem struct {
+	Kind       DriftKind `json:"kind"`
+	EntityType string    `json:"entity_type"`
+	ExternalID string    `json:"external_id"`
+	Name       string    `json:"name"`
+	Detail     string    `json:"detail,omitempty"`
+	Applied    bool      `json:"applied"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// DriftReport is the outcome of reconciling one namespace
+type DriftReport struct {
+	Provider       string      `json:"provider"`
+	InstallationID int64       `json:"installation_id"`
+	Account        string      `json:"account"`
+	DryRun         bool        `json:"dry_run"`
+	Prune          bool        `json:"prune"`
+	StartedAt      time.Time   `json:"started_at"`
+	CompletedAt    time.Time   `json:"completed_at"`
+	Repositories   int         `json:"repositories"`
+	Teams          int         `json:"teams"`
+	Items          []DriftItem `json:"items"`
+	Error          string      `json:"error,omitempty"`
+}
+
+// ReconcileOptions controls what a reconciliation changes
+type ReconcileOptions struct {
+	DryRun bool // Only report the drift
+	Prune  bool // Delete stale entities from Identity
+}
+
+// forgeState is the forge side of a namespace
+type forgeState struct {
+	repositories map[string]forge.Repository
+	teams        map[string]forge.Team
+	members      map[string][]forge.User
+}
+
+// namespaceRef is a namespace together with the directory it belongs to
+type namespaceRef struct {
+	dir forge.Directory
+	ns  forge.Namespace
+}
+
+// Reconcile compares every namespace of every forge with Identity and, unless DryRun is set,
+// repairs the drift. Namespaces are reconciled concurrently, up to MaxConcurrency at a time.
+// A forge whose namespaces cannot be listed gets a single report carrying the error.
+func (m *Manager) Reconcile(ctx context.Context, opts ReconcileOptions) ([]*DriftReport, error) {
+	var refs []namespaceRef
+	var failed []*DriftReport
+	var listErrs []error
+
+	for _, dir := range m.directories {
+		namespaces, err := dir.ListNamespaces(ctx)
+		if err != nil {
+			err = fmt.Errorf("failed to list %s namespaces: %w", dir.Provider(), err)
+			listErrs = append(listErrs, err)
+			failed = append(failed, &DriftReport{
+				Provider:  string(dir.Provider()),
+				DryRun:    opts.DryRun,
+				Prune:     opts.Prune,
+				StartedAt: time.Now().UTC(),
+				Items:     []DriftItem{},
+				Error:     err.Error(),
+			})
+			continue
+		}
+		for _, ns := range namespaces {
+			refs = append(refs, namespaceRef{dir: dir, ns: ns})
+		}
+	}
+
+	if len(refs) == 0 && len(listErrs) > 0 {
+		return nil, errors.Join(listErrs...)
+	}
+
+	reports := make([]*DriftReport, len(refs))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, max(m.config.MaxConcurrency, 1))
+
+	for i, ref := range refs {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(i int, ref namespaceRef) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			reports[i] = m.ReconcileNamespace(ctx, ref.dir, ref.ns, opts)
+		}(i, ref)
+	}
+
+	wg.Wait()
+	return append(reports, failed...), nil
+}
+
+// ReconcileNamespace compares one namespace with Identity
+func (m *Manager) ReconcileNamespace(ctx context.Context, dir forge.Directory, ns forge.Namespace, opts ReconcileOptions) *DriftReport {
+	requestID := generateRequestID()
+	provider := dir.Provider()
+	installationID := ns.ID
+	report := &DriftReport{
+		Provider:       string(provider),
+		InstallationID: installationID,
+		Account:        ns.Login,
+		DryRun:         opts.DryRun,
+		Prune:          opts.Prune,
+		StartedAt:      time.Now().UTC(),
+		Items:          []DriftItem{},
+	}
+	defer func() { report.CompletedAt = time.Now().UTC() }()
+
+	state, err := m.loadForgeState(ctx, dir, ns)
+	if err != nil {
+		report.Error = err.Error()
+		m.logSyncError(ctx, provider, "installation", namespaceKey(provider, installationID), err, requestID)
+		return report
+	}
+	report.Repositories = len(state.repositories)
+	report.Teams = len(state.teams)
+
+	batchSize := max(m.config.BatchSize, 1)
+	resources, err := m.identityClient.ListSyncedEntities(ctx, string(provider), string(identity.EntityTypeResource), installationID, batchSize)
+	if err == nil {
+		var roles, memberships []identity.SyncedEntity
+		if roles, err = m.identityClient.ListSyncedEntities(ctx, string(provider), string(identity.EntityTypeRole), installationID, batchSize); err == nil {
+			memberships, err = m.identityClient.ListSyncedEntities(ctx, string(provider), string(identity.EntityTypeMembership), installationID, batchSize)
+		}
+		if err == nil {
+			report.Items = append(report.Items, diffRepositories(state, resources)...)
+			report.Items = append(report.Items, diffTeams(state, ns.Login, roles)...)
+			report.Items = append(report.Items, diffMemberships(state, memberships)...)
+		}
+	}
+	if err != nil {
+		report.Error = err.Error()
+		m.logSyncError(ctx, provider, "installation", namespaceKey(provider, installationID), err, requestID)
+		return report
+	}
+
+	if opts.DryRun {
+		return report
+	}
+
+	for i := range report.Items {
+		item := &report.Items[i]
+		if item.Kind == DriftStale && !opts.Prune {
+			continue
+		}
+		if err := m.applyDrift(ctx, dir, ns, state, item, requestID); err != nil {
+			item.Error = err.Error()
+			m.logSyncError(ctx, provider, item.EntityType, item.ExternalID, err, requestID)
+			continue
+		}
+		item.Applied = true
+	}
+
+	m.updateLastSyncTime(namespaceKey(provider, installationID))
+	return report
+}
+
+// loadForgeState reads the repositories, teams and team members of a namespace
+func (m *Manager) loadForgeState(ctx context.Context, dir forge.Directory, ns forge.Namespace) (*forgeState, error) {
+	batchSize := max(m.config.BatchSize, 1)
+	state := &forgeState{
+		repositories: make(map[string]forge.Repository),
+		teams:        make(map[string]forge.Team),
+		members:      make(map[string][]forge.User),
+	}
+
+	repos, err := dir.ListRepositories(ctx, ns, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	for _, repo := range repos {
+		state.repositories[fmt.Sprintf("%d", repo.ID)] = repo
+	}
+
+	// Teams only exist in organizations
+	if !ns.HasTeams() {
+		return state, nil
+	}
+
+	teams, err := dir.ListTeams(ctx, ns, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	for _, team := range teams {
+		teamID := fmt.Sprintf("%d", team.ID)
+		state.teams[teamID] = team
+
+		members, err := dir.ListTeamMembers(ctx, ns, team, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		state.members[teamID] = members
+	}
+
+	return state, nil
+}
+
+// applyDrift syncs a missing or changed entity, or deletes a stale one
+func (m *Manager) applyDrift(ctx context.Context, dir forge.Directory, ns forge.Namespace, state *forgeState, item *DriftItem, requestID string) error {
+	provider := dir.Provider()
+	org := ns.Login
+
+	if item.Kind == DriftStale {
+		if err := m.identityClient.DeleteSyncedEntity(ctx, string(provider), item.EntityType, item.ExternalID, requestID); err != nil {
+			return err
+		}
+		switch item.EntityType {
+		case string(identity.EntityTypeResource):
+			if item.Name != "" {
+				return m.relationships.DeleteRepository(ctx, provider, item.Name, requestID)
+			}
+		case string(identity.EntityTypeRole):
+			if item.Name != "" {
+				teamOrg, slug := forge.SplitTeamName(item.Name)
+				return m.relationships.DeleteTeam(ctx, provider, teamOrg, slug, requestID)
+			}
+		}
+		return nil
+	}
+
+	switch item.EntityType {
+	case string(identity.EntityTypeResource):
+		repo := state.repositories[item.ExternalID]
+		if err := m.repositorySync.SyncRepository(ctx, provider, repo, ns.ID, requestID); err != nil {
+			return err
+		}
+		return m.syncRepositoryRelationships(ctx, dir, ns, repo, make(map[int64]bool), requestID)
+	case string(identity.EntityTypeRole):
+		return m.teamSync.SyncTeam(ctx, provider, state.teams[item.ExternalID], org, ns.ID, requestID)
+	case string(identity.EntityTypeMembership):
+		team := state.teams[item.ExternalID]
+		members := state.members[item.ExternalID]
+		if err := m.teamSync.SyncTeamMembers(ctx, provider, team.ID, members, org, ns.ID, requestID); err != nil {
+			return err
+		}
+		return m.relationships.SyncTeamMembers(ctx, provider, org, team.Slug, members, requestID)
+	}
+	return nil
+}
+
+// diffRepositories compares forge repositories with Identity resources
+func diffRepositories(state *forgeState, resources []identity.SyncedEntity) []DriftItem {
+	var items []DriftItem
+	known := make(map[string]bool)
+
+	for _, resource := range resources {
+		known[resource.ExternalID] = true
+		repo, ok := state.repositories[resource.ExternalID]
+		if !ok {
+			items = append(items, DriftItem{Kind: DriftStale, EntityType: resource.EntityType, ExternalID: resource.ExternalID, Name: resource.Name})
+			continue
+		}
+		if resource.Name != repo.FullName {
+			items = append(items, DriftItem{
+				Kind:       DriftChanged,
+				EntityType: resource.EntityType,
+				ExternalID: resource.ExternalID,
+				Name:       repo.FullName,
+				Detail:     fmt.Sprintf("renamed from %s", resource.Name),
+			})
+		}
+	}
+
+	for id, repo := range state.repositories {
+		if !known[id] {
+			items = append(items, DriftItem{Kind: DriftMissing, EntityType: string(identity.EntityTypeResource), ExternalID: id, Name: repo.FullName})
+		}
+	}
+
+	return sortDrift(items)
+}
+
+// diffTeams compares forge teams with Identity roles
+func diffTeams(state *forgeState, org string, roles []identity.SyncedEntity) []DriftItem {
+	var items []DriftItem
+	known := make(map[string]bool)
+
+	for _, role := range roles {
+		known[role.ExternalID] = true
+		team, ok := state.teams[role.ExternalID]
+		if !ok {
+			items = append(items, DriftItem{Kind: DriftStale, EntityType: role.EntityType, ExternalID: role.ExternalID, Name: role.Name})
+			continue
+		}
+		name := forge.TeamName(org, team.Slug)
+		if role.Name != name {
+			items = append(items, DriftItem{
+				Kind:       DriftChanged,
+				EntityType: role.EntityType,
+				ExternalID: role.ExternalID,
+				Name:       name,
+				Detail:     fmt.Sprintf("renamed from %s", role.Name),
+			})
+		}
+	}
+
+	for id, team := range state.teams {
+		if !known[id] {
+			items = append(items, DriftItem{Kind: DriftMissing, EntityType: string(identity.EntityTypeRole), ExternalID: id, Name: forge.TeamName(org, team.Slug)})
+		}
+	}
+
+	return sortDrift(items)
+}
+
+// diffMemberships compares forge team members with the memberships held by Identity
+func diffMemberships(state *forgeState, memberships []identity.SyncedEntity) []DriftItem {
+	var items []DriftItem
+	known := make(map[string]bool)
+
+	for _, membership := range memberships {
+		known[membership.ExternalID] = true
+		members, ok := state.members[membership.ExternalID]
+		if !ok {
+			items = append(items, DriftItem{Kind: DriftStale, EntityType: membership.EntityType, ExternalID: membership.ExternalID, Name: membership.Name})
+			continue
+		}
+
+		identityMembers := make(map[string]bool)
+		if ids, ok := membership.Data["member_ids"].([]interface{}); ok {
+			for _, id := range ids {
+				identityMembers[fmt.Sprint(id)] = true
+			}
+		}
+
+		added := 0
+		for _, member := range members {
+			id := fmt.Sprintf("%d", member.ID)
+			if identityMembers[id] {
+				delete(identityMembers, id)
+			} else {
+				added++
+			}
+		}
+		removed := len(identityMembers)
+
+		if added > 0 || removed > 0 {
+			items = append(items, DriftItem{
+				Kind:       DriftChanged,
+				EntityType: membership.EntityType,
+				ExternalID: membership.ExternalID,
+				Name:       teamLabel(state.teams[membership.ExternalID]),
+				Detail:     fmt.Sprintf("%d members to add, %d to remove", added, removed),
+			})
+		}
+	}
+
+	for id, members := range state.members {
+		if !known[id] {
+			items = append(items, DriftItem{
+				Kind:       DriftMissing,
+				EntityType: string(identity.EntityTypeMembership),
+				ExternalID: id,
+				Name:       teamLabel(state.teams[id]),
+				Detail:     fmt.Sprintf("%d members", len(members)),
+			})
+		}
+	}
+
+	return sortDrift(items)
+}
+
+// teamLabel names a team in drift reports; a GitLab top-level group has no slug
+func teamLabel(team forge.Team) string {
+	if team.Slug == "" {
+		return team.Name
+	}
+	return team.Slug
+}
+
+// sortDrift orders drift items by kind then external ID so reports are stable
+func sortDrift(items []DriftItem) []DriftItem {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Kind != items[j].Kind {
+			return items[i].Kind < items[j].Kind
+		}
+		return items[i].ExternalID < items[j].ExternalID
+	})
+	return items
+}
@@ -0,0 +1,161 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
+	"github.com/skygenesisenterprise/aether-identity/package/github/identity"
+)
+
+// repositoryRelations lists the repo relations derived from team and member permissions
+var repositoryRelations = []string{"admin", "maintainer", "writer", "triager", "reader"}
+
+// teamPermissionRelations maps normalized repository permissions to repo relations
+var teamPermissionRelations = map[string]string{
+	"admin":    "admin",
+	"maintain": "maintainer",
+	"push":     "writer",
+	"triage":   "triager",
+	"pull":     "reader",
+}
+
+// RelationshipSync writes forge teams and repository access as relation tuples in Identity.
+// Users are referenced as "<provider>_user:<id>"; Identity resolves them through linked accounts.
+type RelationshipSync struct {
+	identityClient *identity.Client
+	auditLogger    *identity.AuditLogger
+}
+
+// NewRelationshipSync creates a new relationship synchronizer
+func NewRelationshipSync(identityClient *identity.Client, auditLogger *identity.AuditLogger) *RelationshipSync {
+	return &RelationshipSync{
+		identityClient: identityClient,
+		auditLogger:    auditLogger,
+	}
+}
+
+// SyncTeamMembers replaces the members of a team with the given forge users
+func (s *RelationshipSync) SyncTeamMembers(ctx context.Context, provider forge.Provider, org, teamSlug string, members []forge.User, requestID string) error {
+	team := teamObject(provider, org, teamSlug)
+
+	writes := make([]string, 0, len(members))
+	for _, member := range members {
+		writes = append(writes, fmt.Sprintf("%s#member@%s", team, forge.UserSubject(provider, member.ID)))
+	}
+
+	return s.write(ctx, provider, identity.RelationshipWriteRequest{
+		Writes:  writes,
+		Replace: []string{team + "#member"},
+	}, "sync_team_relationships", team, requestID)
+}
+
+// AddTeamMember adds a forge user to a team
+func (s *RelationshipSync) AddTeamMember(ctx context.Context, provider forge.Provider, org, teamSlug string, userID int64, requestID string) error {
+	team := teamObject(provider, org, teamSlug)
+	return s.write(ctx, provider, identity.RelationshipWriteRequest{
+		Writes: []string{fmt.Sprintf("%s#member@%s", team, forge.UserSubject(provider, userID))},
+	}, "add_team_member", team, requestID)
+}
+
+// RemoveTeamMember removes a forge user from a team
+func (s *RelationshipSync) RemoveTeamMember(ctx context.Context, provider forge.Provider, org, teamSlug string, userID int64, requestID string) error {
+	team := teamObject(provider, org, teamSlug)
+	return s.write(ctx, provider, identity.RelationshipWriteRequest{
+		Deletes: []string{fmt.Sprintf("%s#member@%s", team, forge.UserSubject(provider, userID))},
+	}, "remove_team_member", team, requestID)
+}
+
+// DeleteTeam removes all members of a team
+func (s *RelationshipSync) DeleteTeam(ctx context.Context, provider forge.Provider, org, teamSlug string, requestID string) error {
+	team := teamObject(provider, org, teamSlug)
+	return s.write(ctx, provider, identity.RelationshipWriteRequest{
+		Replace: []string{team + "#member", team + "#maintainer"},
+	}, "delete_team_relationships", team, requestID)
+}
+
+// SyncRepositoryAccess replaces the team and collaborator access of a repository and links it to
+// the organization of its namespace
+func (s *RelationshipSync) SyncRepositoryAccess(ctx context.Context, provider forge.Provider, ns forge.Namespace, repo forge.Repository, teams []forge.Team, collaborators []forge.Collaborator, requestID string) error {
+	object := repositoryObject(provider, repo.FullName)
+
+	req := identity.RelationshipWriteRequest{}
+	if ns.HasTeams() {
+		req.Writes = append(req.Writes, fmt.Sprintf("%s#organization@organization:%s", object, forge.OrganizationID(provider, ns.Login)))
+	}
+	req.Replace = append(req.Replace, object+"#organization")
+
+	for _, team := range teams {
+		relation, ok := teamPermissionRelations[team.Permission]
+		if !ok {
+			continue
+		}
+		req.Writes = append(req.Writes, fmt.Sprintf("%s#%s@%s#member", object, relation, teamObject(provider, ns.Login, team.Slug)))
+	}
+	for _, collaborator := range collaborators {
+		relation, ok := teamPermissionRelations[collaborator.Permission]
+		if !ok {
+			continue
+		}
+		req.Writes = append(req.Writes, fmt.Sprintf("%s#%s@%s", object, relation, forge.UserSubject(provider, collaborator.User.ID)))
+	}
+	for _, relation := range repositoryRelations {
+		req.Replace = append(req.Replace, object+"#"+relation)
+	}
+
+	return s.write(ctx, provider, req, "sync_repository_relationships", object, requestID)
+}
+
+// DeleteRepository removes all relationships of a repository
+func (s *RelationshipSync) DeleteRepository(ctx context.Context, provider forge.Provider, fullName string, requestID string) error {
+	object := repositoryObject(provider, fullName)
+
+	req := identity.RelationshipWriteRequest{Replace: []string{object + "#organization"}}
+	for _, relation := range repositoryRelations {
+		req.Replace = append(req.Replace, object+"#"+relation)
+	}
+
+	return s.write(ctx, provider, req, "delete_repository_relationships", object, requestID)
+}
+
+// write sends the tuples to Identity and records the outcome in the audit log
+func (s *RelationshipSync) write(ctx context.Context, provider forge.Provider, req identity.RelationshipWriteRequest, action, object, requestID string) error {
+	_, err := s.identityClient.WriteRelationships(ctx, req, requestID)
+
+	if s.auditLogger != nil {
+		auditEntry := identity.AuditEntry{
+			RequestID: requestID,
+			Provider:  string(provider),
+			Actor: identity.Actor{
+				Type: "system",
+				ID:   "github-app",
+			},
+			Action: action,
+			Resource: identity.Resource{
+				Type: "relationship",
+				ID:   object,
+			},
+			Decision: "success",
+			Reason:   fmt.Sprintf("%d tuples written, %d deleted, %d relations replaced", len(req.Writes), len(req.Deletes), len(req.Replace)),
+		}
+		if err != nil {
+			auditEntry.Decision = "failed"
+			auditEntry.Reason = err.Error()
+			s.auditLogger.LogError(ctx, auditEntry, err)
+		} else {
+			s.auditLogger.LogSync(ctx, auditEntry)
+		}
+	}
+
+	return err
+}
+
+// teamObject returns the Identity object of a forge team
+func teamObject(provider forge.Provider, org, slug string) string {
+	return "team:" + forge.TeamID(provider, org, slug)
+}
+
+// repositoryObject returns the Identity object of a forge repository
+func repositoryObject(provider forge.Provider, fullName string) string {
+	return "repo:" + forge.RepositoryID(provider, fullName)
+}
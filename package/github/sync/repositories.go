@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"time"
 
-	gh "github.com/google/go-github/v57/github"
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
 	"github.com/skygenesisenterprise/aether-identity/package/github/identity"
 )
 
-// RepositorySync handles synchronization of GitHub repositories to Identity resources
+// RepositorySync handles synchronization of forge repositories to Identity resources
 type RepositorySync struct {
 	identityClient *identity.Client
 	auditLogger    *identity.AuditLogger
@@ -23,19 +23,19 @@ func NewRepositorySync(identityClient *identity.Client, auditLogger *identity.Au
 	}
 }
 
-// SyncRepository synchronizes a single GitHub repository to Identity as a resource
-func (s *RepositorySync) SyncRepository(ctx context.Context, ghRepo *gh.Repository, installationID int64, requestID string) error {
-	resource := s.convertGitHubRepository(ghRepo)
+// SyncRepository synchronizes a single forge repository to Identity as a resource
+func (s *RepositorySync) SyncRepository(ctx context.Context, provider forge.Provider, repo forge.Repository, installationID int64, requestID string) error {
+	resource := s.convertRepository(provider, repo)
 
 	event := identity.SyncEvent{
 		EventType:  "repository_sync",
-		Provider:   "github",
+		Provider:   string(provider),
 		EntityType: "resource",
-		EntityID:   fmt.Sprintf("%d", ghRepo.GetID()),
+		EntityID:   fmt.Sprintf("%d", repo.ID),
 		Data: map[string]interface{}{
 			"resource":        resource,
-			"github_id":       ghRepo.GetID(),
-			"full_name":       ghRepo.GetFullName(),
+			"external_id":     repo.ID,
+			"full_name":       repo.FullName,
 			"installation_id": installationID,
 		},
 		Timestamp: time.Now().UTC(),
@@ -50,6 +50,7 @@ func (s *RepositorySync) SyncRepository(ctx context.Context, ghRepo *gh.Reposito
 	if s.auditLogger != nil {
 		auditEntry := identity.AuditEntry{
 			RequestID: requestID,
+			Provider:  string(provider),
 			Actor: identity.Actor{
 				Type:       "system",
 				ID:         "github-app",
@@ -58,8 +59,8 @@ func (s *RepositorySync) SyncRepository(ctx context.Context, ghRepo *gh.Reposito
 			Action: "sync_repository",
 			Resource: identity.Resource{
 				Type: "resource",
-				ID:   fmt.Sprintf("%d", ghRepo.GetID()),
-				Name: ghRepo.GetFullName(),
+				ID:   resource.ID,
+				Name: repo.FullName,
 			},
 			Decision: "success",
 			Reason:   "Repository synchronized to Identity as resource",
@@ -70,14 +71,15 @@ func (s *RepositorySync) SyncRepository(ctx context.Context, ghRepo *gh.Reposito
 	return nil
 }
 
-// SyncRepositories synchronizes multiple GitHub repositories to Identity
-func (s *RepositorySync) SyncRepositories(ctx context.Context, ghRepos []*gh.Repository, installationID int64, requestID string) error {
-	for _, ghRepo := range ghRepos {
-		if err := s.SyncRepository(ctx, ghRepo, installationID, requestID); err != nil {
+// SyncRepositories synchronizes multiple forge repositories to Identity
+func (s *RepositorySync) SyncRepositories(ctx context.Context, provider forge.Provider, repos []forge.Repository, installationID int64, requestID string) error {
+	for _, repo := range repos {
+		if err := s.SyncRepository(ctx, provider, repo, installationID, requestID); err != nil {
 			// Log error but continue with other repositories
 			if s.auditLogger != nil {
 				auditEntry := identity.AuditEntry{
 					RequestID: requestID,
+					Provider:  string(provider),
 					Actor: identity.Actor{
 						Type:       "system",
 						ID:         "github-app",
@@ -86,8 +88,8 @@ func (s *RepositorySync) SyncRepositories(ctx context.Context, ghRepos []*gh.Rep
 					Action: "sync_repository",
 					Resource: identity.Resource{
 						Type: "resource",
-						ID:   fmt.Sprintf("%d", ghRepo.GetID()),
-						Name: ghRepo.GetFullName(),
+						ID:   fmt.Sprintf("%s-repo:%d", provider, repo.ID),
+						Name: repo.FullName,
 					},
 					Decision: "failed",
 					Reason:   err.Error(),
@@ -102,14 +104,14 @@ func (s *RepositorySync) SyncRepositories(ctx context.Context, ghRepos []*gh.Rep
 }
 
 // SyncRepositoryFromEvent synchronizes a repository from a webhook event
-func (s *RepositorySync) SyncRepositoryFromEvent(ctx context.Context, repoID int64, repoName string, fullName string, private bool, eventType string, eventAction string, requestID string) error {
+func (s *RepositorySync) SyncRepositoryFromEvent(ctx context.Context, provider forge.Provider, repoID int64, repoName string, fullName string, private bool, eventType string, eventAction string, requestID string) error {
 	if repoID == 0 {
 		return fmt.Errorf("no repository information in event")
 	}
 
 	syncEvent := identity.SyncEvent{
 		EventType:  "repository_sync_from_event",
-		Provider:   "github",
+		Provider:   string(provider),
 		EntityType: "resource",
 		EntityID:   fmt.Sprintf("%d", repoID),
 		Data: map[string]interface{}{
@@ -128,15 +130,15 @@ func (s *RepositorySync) SyncRepositoryFromEvent(ctx context.Context, repoID int
 }
 
 // SyncRepositoryTeams synchronizes repository team access to Identity
-func (s *RepositorySync) SyncRepositoryTeams(ctx context.Context, repoID int64, teams []*gh.Team, installationID int64, requestID string) error {
+func (s *RepositorySync) SyncRepositoryTeams(ctx context.Context, provider forge.Provider, repoID int64, teams []forge.Team, installationID int64, requestID string) error {
 	teamIDs := make([]string, 0, len(teams))
 	for _, team := range teams {
-		teamIDs = append(teamIDs, fmt.Sprintf("%d", team.GetID()))
+		teamIDs = append(teamIDs, fmt.Sprintf("%d", team.ID))
 	}
 
 	event := identity.SyncEvent{
 		EventType:  "repository_teams_sync",
-		Provider:   "github",
+		Provider:   string(provider),
 		EntityType: "resource_access",
 		EntityID:   fmt.Sprintf("%d", repoID),
 		Data: map[string]interface{}{
@@ -152,10 +154,10 @@ func (s *RepositorySync) SyncRepositoryTeams(ctx context.Context, repoID int64,
 }
 
 // DeleteRepository marks a repository as deleted in Identity
-func (s *RepositorySync) DeleteRepository(ctx context.Context, repoID int64, installationID int64, requestID string) error {
+func (s *RepositorySync) DeleteRepository(ctx context.Context, provider forge.Provider, repoID int64, installationID int64, requestID string) error {
 	event := identity.SyncEvent{
 		EventType:  "repository_delete",
-		Provider:   "github",
+		Provider:   string(provider),
 		EntityType: "resource",
 		EntityID:   fmt.Sprintf("%d", repoID),
 		Data: map[string]interface{}{
@@ -175,6 +177,7 @@ func (s *RepositorySync) DeleteRepository(ctx context.Context, repoID int64, ins
 	if s.auditLogger != nil {
 		auditEntry := identity.AuditEntry{
 			RequestID: requestID,
+			Provider:  string(provider),
 			Actor: identity.Actor{
 				Type:       "system",
 				ID:         "github-app",
@@ -183,7 +186,7 @@ func (s *RepositorySync) DeleteRepository(ctx context.Context, repoID int64, ins
 			Action: "delete_repository",
 			Resource: identity.Resource{
 				Type: "resource",
-				ID:   fmt.Sprintf("%d", repoID),
+				ID:   fmt.Sprintf("%s-repo:%d", provider, repoID),
 			},
 			Decision: "success",
 			Reason:   "Repository marked as deleted in Identity",
@@ -194,23 +197,24 @@ func (s *RepositorySync) DeleteRepository(ctx context.Context, repoID int64, ins
 	return nil
 }
 
-// convertGitHubRepository converts a GitHub repository to Identity resource format
-func (s *RepositorySync) convertGitHubRepository(ghRepo *gh.Repository) identity.Resource {
+// convertRepository converts a forge repository to Identity resource format
+func (s *RepositorySync) convertRepository(provider forge.Provider, repo forge.Repository) identity.Resource {
 	return identity.Resource{
-		ID:          fmt.Sprintf("github-repo:%d", ghRepo.GetID()),
+		ID:          fmt.Sprintf("%s-repo:%d", provider, repo.ID),
 		Type:        "repository",
-		Name:        ghRepo.GetFullName(),
-		Description: ghRepo.GetDescription(),
+		Name:        repo.FullName,
+		Description: repo.Description,
 		Attributes: map[string]interface{}{
-			"github_id":      ghRepo.GetID(),
-			"name":           ghRepo.GetName(),
-			"full_name":      ghRepo.GetFullName(),
-			"private":        ghRepo.GetPrivate(),
-			"default_branch": ghRepo.GetDefaultBranch(),
-			"html_url":       ghRepo.GetHTMLURL(),
+			"provider":       provider,
+			"external_id":    repo.ID,
+			"name":           repo.Name,
+			"full_name":      repo.FullName,
+			"private":        repo.Private,
+			"default_branch": repo.DefaultBranch,
+			"html_url":       repo.HTMLURL,
 		},
 		ExternalIDs: map[string]string{
-			"github": fmt.Sprintf("%d", ghRepo.GetID()),
+			string(provider): fmt.Sprintf("%d", repo.ID),
 		},
 	}
 }
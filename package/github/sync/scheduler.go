@@ -0,0 +1,102 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/package/github/config"
+)
+
+// ErrReconcileRunning is returned when a reconciliation is requested while another one runs
+var ErrReconcileRunning = errors.New("a reconciliation is already running")
+
+// Scheduler runs a full reconciliation every SyncConfig.Interval so changes missed by
+// lost webhooks are eventually repaired
+type Scheduler struct {
+	manager     *Manager
+	interval    time.Duration
+	prune       bool
+	running     sync.Mutex
+	mutex       sync.RWMutex
+	lastReports []*DriftReport
+	lastRun     time.Time
+}
+
+// NewScheduler creates a new reconciliation scheduler
+func NewScheduler(manager *Manager, cfg config.SyncConfig) *Scheduler {
+	return &Scheduler{
+		manager:  manager,
+		interval: cfg.Interval,
+		prune:    cfg.Prune,
+	}
+}
+
+// Start runs a reconciliation immediately, then every interval until ctx is done.
+// It does nothing when sync is disabled or the interval is not positive.
+func (s *Scheduler) Start(ctx context.Context) {
+	if !s.manager.config.Enabled || s.interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.Run(ctx, ReconcileOptions{Prune: s.prune}); err != nil && !errors.Is(err, ErrReconcileRunning) {
+				log.Printf("Scheduled reconciliation failed: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Run reconciles all installations now; only one reconciliation runs at a time
+func (s *Scheduler) Run(ctx context.Context, opts ReconcileOptions) ([]*DriftReport, error) {
+	if !s.running.TryLock() {
+		return nil, ErrReconcileRunning
+	}
+	defer s.running.Unlock()
+
+	startedAt := time.Now()
+	reports, err := s.manager.Reconcile(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	drift, applied := 0, 0
+	for _, report := range reports {
+		for _, item := range report.Items {
+			drift++
+			if item.Applied {
+				applied++
+			}
+		}
+	}
+	log.Printf("Reconciliation of %d installations found %d differences, %d repaired (dry run: %v, prune: %v) in %v",
+		len(reports), drift, applied, opts.DryRun, opts.Prune, time.Since(startedAt))
+
+	if !opts.DryRun {
+		s.mutex.Lock()
+		s.lastReports = reports
+		s.lastRun = startedAt.UTC()
+		s.mutex.Unlock()
+	}
+
+	return reports, nil
+}
+
+// LastReports returns the reports of the last reconciliation that applied changes
+func (s *Scheduler) LastReports() ([]*DriftReport, time.Time) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.lastReports, s.lastRun
+}
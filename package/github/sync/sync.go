@@ -2,50 +2,53 @@ package sync
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
-	gh "github.com/google/go-github/v57/github"
 	"github.com/skygenesisenterprise/aether-identity/package/github/config"
-	internalgithub "github.com/skygenesisenterprise/aether-identity/package/github/github"
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
 	"github.com/skygenesisenterprise/aether-identity/package/github/identity"
 )
 
-// Manager coordinates all synchronization operations
+// Manager coordinates all synchronization operations across the configured forges
 type Manager struct {
 	config         config.SyncConfig
 	userSync       *UserSync
 	teamSync       *TeamSync
 	repositorySync *RepositorySync
+	relationships  *RelationshipSync
 	identityClient *identity.Client
 	auditLogger    *identity.AuditLogger
-	githubClient   *internalgithub.Client
+	directories    []forge.Directory
 	lastSyncTime   map[string]time.Time
 	syncMutex      sync.RWMutex
 }
 
-// NewManager creates a new sync manager
+// NewManager creates a new sync manager for the given forge directories
 func NewManager(
 	cfg config.SyncConfig,
 	identityClient *identity.Client,
 	auditLogger *identity.AuditLogger,
-	githubClient *internalgithub.Client,
+	directories ...forge.Directory,
 ) *Manager {
 	return &Manager{
 		config:         cfg,
 		userSync:       NewUserSync(identityClient, auditLogger),
 		teamSync:       NewTeamSync(identityClient, auditLogger),
 		repositorySync: NewRepositorySync(identityClient, auditLogger),
+		relationships:  NewRelationshipSync(identityClient, auditLogger),
 		identityClient: identityClient,
 		auditLogger:    auditLogger,
-		githubClient:   githubClient,
+		directories:    directories,
 		lastSyncTime:   make(map[string]time.Time),
 	}
 }
 
-// HandleEvent processes a webhook event and triggers appropriate sync operations
-func (m *Manager) HandleEvent(ctx context.Context, event *internalgithub.Event) error {
+// HandleEvent processes a webhook event and triggers appropriate sync operations.
+// Errors are logged to the audit log and returned so the delivery can be retried.
+func (m *Manager) HandleEvent(ctx context.Context, event *forge.Event) error {
 	if !m.config.Enabled {
 		return nil
 	}
@@ -53,92 +56,143 @@ func (m *Manager) HandleEvent(ctx context.Context, event *internalgithub.Event)
 	requestID := generateRequestID()
 
 	switch event.Type {
-	case internalgithub.EventTypePush:
+	case forge.EventTypePush:
 		return m.handlePushEvent(ctx, event, requestID)
-	case internalgithub.EventTypePullRequest:
+	case forge.EventTypePullRequest:
 		return m.handlePullRequestEvent(ctx, event, requestID)
-	case internalgithub.EventTypeMembership:
+	case forge.EventTypeMembership:
 		return m.handleMembershipEvent(ctx, event, requestID)
-	case internalgithub.EventTypeRepository:
+	case forge.EventTypeRepository:
 		return m.handleRepositoryEvent(ctx, event, requestID)
-	case internalgithub.EventTypeInstallation:
+	case forge.EventTypeInstallation:
 		return m.handleInstallationEvent(ctx, event, requestID)
-	case internalgithub.EventTypeTeam:
+	case forge.EventTypeTeam:
 		return m.handleTeamEvent(ctx, event, requestID)
 	default:
 		// For other events, just sync the sender
-		return m.userSync.SyncUserFromEvent(ctx, event.Sender.ID, event.Sender.Login, string(event.Type), event.Action, requestID)
+		return m.userSync.SyncUserFromEvent(ctx, event.Provider, event.Sender.ID, event.Sender.Login, string(event.Type), event.Action, requestID)
 	}
 }
 
-// SyncInstallation performs a full sync of an installation
-func (m *Manager) SyncInstallation(ctx context.Context, installationID int64) error {
+// SyncNamespace performs a full sync of a namespace: a GitHub installation,
+// a GitLab top-level group or a Gitea organization
+func (m *Manager) SyncNamespace(ctx context.Context, dir forge.Directory, ns forge.Namespace) error {
 	if !m.config.Enabled {
 		return nil
 	}
 
 	requestID := generateRequestID()
+	provider := dir.Provider()
 
-	// Get installation client
-	client, err := m.githubClient.GetInstallationClient(ctx, installationID)
+	repos, err := dir.ListRepositories(ctx, ns, m.config.BatchSize)
 	if err != nil {
-		return fmt.Errorf("failed to get installation client: %w", err)
+		return fmt.Errorf("failed to list repositories: %w", err)
 	}
 
-	// Sync repositories
-	opts := &gh.ListOptions{PerPage: m.config.BatchSize}
-	for {
-		repoList, resp, err := client.Apps.ListRepos(ctx, opts)
-		if err != nil {
-			return fmt.Errorf("failed to list repositories: %w", err)
+	syncedTeams := make(map[int64]bool)
+	for _, repo := range repos {
+		if err := m.repositorySync.SyncRepository(ctx, provider, repo, ns.ID, requestID); err != nil {
+			m.logSyncError(ctx, provider, "repository", repo.FullName, err, requestID)
+		}
+		if err := m.syncRepositoryRelationships(ctx, dir, ns, repo, syncedTeams, requestID); err != nil {
+			m.logSyncError(ctx, provider, "relationships", repo.FullName, err, requestID)
 		}
+	}
 
-		for _, repo := range repoList.Repositories {
-			if err := m.repositorySync.SyncRepository(ctx, repo, installationID, requestID); err != nil {
-				m.logSyncError(ctx, "repository", repo.GetFullName(), err, requestID)
-			}
+	m.updateLastSyncTime(namespaceKey(provider, ns.ID))
+
+	return nil
+}
+
+// syncRepositoryRelationships writes the team and collaborator access of a repository and the
+// members of its teams as relation tuples; each team's members are synced once per namespace
+func (m *Manager) syncRepositoryRelationships(ctx context.Context, dir forge.Directory, ns forge.Namespace, repo forge.Repository, syncedTeams map[int64]bool, requestID string) error {
+	provider := dir.Provider()
+
+	var teams []forge.Team
+	if ns.HasTeams() {
+		var err error
+		teams, err = dir.ListRepositoryTeams(ctx, ns, repo)
+		if err != nil {
+			return err
 		}
+	}
 
-		if resp.NextPage == 0 {
-			break
+	var collaborators []forge.Collaborator
+	if lister, ok := dir.(forge.CollaboratorLister); ok {
+		var err error
+		collaborators, err = lister.ListRepositoryCollaborators(ctx, ns, repo, m.config.BatchSize)
+		if err != nil {
+			return err
 		}
-		opts.Page = resp.NextPage
 	}
 
-	m.updateLastSyncTime(fmt.Sprintf("installation:%d", installationID))
+	if err := m.relationships.SyncRepositoryAccess(ctx, provider, ns, repo, teams, collaborators, requestID); err != nil {
+		return err
+	}
+
+	for _, team := range teams {
+		if syncedTeams[team.ID] {
+			continue
+		}
+		syncedTeams[team.ID] = true
+
+		members, err := dir.ListTeamMembers(ctx, ns, team, m.config.BatchSize)
+		if err != nil {
+			m.logSyncError(ctx, provider, "team", team.Slug, err, requestID)
+			continue
+		}
+		if err := m.relationships.SyncTeamMembers(ctx, provider, ns.Login, team.Slug, members, requestID); err != nil {
+			m.logSyncError(ctx, provider, "team", team.Slug, err, requestID)
+		}
+	}
 
 	return nil
 }
 
-// SyncAllInstallations syncs all installations
+// SyncAllInstallations syncs every namespace of every configured forge
 func (m *Manager) SyncAllInstallations(ctx context.Context) error {
 	if !m.config.Enabled {
 		return nil
 	}
 
-	installations, err := m.githubClient.ListInstallations(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to list installations: %w", err)
-	}
-
 	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, m.config.MaxConcurrency)
+	semaphore := make(chan struct{}, max(m.config.MaxConcurrency, 1))
 
-	for _, inst := range installations {
-		wg.Add(1)
-		semaphore <- struct{}{}
+	var errs []error
+	for _, dir := range m.directories {
+		namespaces, err := dir.ListNamespaces(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list %s namespaces: %w", dir.Provider(), err))
+			continue
+		}
 
-		go func(installationID int64) {
-			defer wg.Done()
-			defer func() { <-semaphore }()
+		for _, ns := range namespaces {
+			wg.Add(1)
+			semaphore <- struct{}{}
 
-			if err := m.SyncInstallation(ctx, installationID); err != nil {
-				m.logSyncError(ctx, "installation", fmt.Sprintf("%d", installationID), err, generateRequestID())
-			}
-		}(inst.GetID())
+			go func(dir forge.Directory, ns forge.Namespace) {
+				defer wg.Done()
+				defer func() { <-semaphore }()
+
+				if err := m.SyncNamespace(ctx, dir, ns); err != nil {
+					m.logSyncError(ctx, dir.Provider(), "installation", namespaceKey(dir.Provider(), ns.ID), err, generateRequestID())
+				}
+			}(dir, ns)
+		}
 	}
 
 	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// directory returns the directory of a provider, or nil when the forge is not configured
+func (m *Manager) directory(provider forge.Provider) forge.Directory {
+	for _, dir := range m.directories {
+		if dir.Provider() == provider {
+			return dir
+		}
+	}
 	return nil
 }
 
@@ -157,79 +211,133 @@ func (m *Manager) updateLastSyncTime(key string) {
 }
 
 // handlePushEvent handles push events
-func (m *Manager) handlePushEvent(ctx context.Context, event *internalgithub.Event, requestID string) error {
+func (m *Manager) handlePushEvent(ctx context.Context, event *forge.Event, requestID string) error {
+	var errs []error
+
 	// Sync the sender
-	if err := m.userSync.SyncUserFromEvent(ctx, event.Sender.ID, event.Sender.Login, string(event.Type), event.Action, requestID); err != nil {
-		m.logSyncError(ctx, "user", event.Sender.Login, err, requestID)
+	if err := m.userSync.SyncUserFromEvent(ctx, event.Provider, event.Sender.ID, event.Sender.Login, string(event.Type), event.Action, requestID); err != nil {
+		m.logSyncError(ctx, event.Provider, "user", event.Sender.Login, err, requestID)
+		errs = append(errs, err)
 	}
 
 	// Sync the repository
 	if event.Repository.ID != 0 {
-		if err := m.repositorySync.SyncRepositoryFromEvent(ctx, event.Repository.ID, event.Repository.Name, event.Repository.FullName, event.Repository.Private, string(event.Type), event.Action, requestID); err != nil {
-			m.logSyncError(ctx, "repository", event.Repository.FullName, err, requestID)
+		if err := m.repositorySync.SyncRepositoryFromEvent(ctx, event.Provider, event.Repository.ID, event.Repository.Name, event.Repository.FullName, event.Repository.Private, string(event.Type), event.Action, requestID); err != nil {
+			m.logSyncError(ctx, event.Provider, "repository", event.Repository.FullName, err, requestID)
+			errs = append(errs, err)
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 // handlePullRequestEvent handles pull request events
-func (m *Manager) handlePullRequestEvent(ctx context.Context, event *internalgithub.Event, requestID string) error {
+func (m *Manager) handlePullRequestEvent(ctx context.Context, event *forge.Event, requestID string) error {
+	var errs []error
+
 	// Sync the sender
-	if err := m.userSync.SyncUserFromEvent(ctx, event.Sender.ID, event.Sender.Login, string(event.Type), event.Action, requestID); err != nil {
-		m.logSyncError(ctx, "user", event.Sender.Login, err, requestID)
+	if err := m.userSync.SyncUserFromEvent(ctx, event.Provider, event.Sender.ID, event.Sender.Login, string(event.Type), event.Action, requestID); err != nil {
+		m.logSyncError(ctx, event.Provider, "user", event.Sender.Login, err, requestID)
+		errs = append(errs, err)
 	}
 
 	// Sync the repository
 	if event.Repository.ID != 0 {
-		if err := m.repositorySync.SyncRepositoryFromEvent(ctx, event.Repository.ID, event.Repository.Name, event.Repository.FullName, event.Repository.Private, string(event.Type), event.Action, requestID); err != nil {
-			m.logSyncError(ctx, "repository", event.Repository.FullName, err, requestID)
+		if err := m.repositorySync.SyncRepositoryFromEvent(ctx, event.Provider, event.Repository.ID, event.Repository.Name, event.Repository.FullName, event.Repository.Private, string(event.Type), event.Action, requestID); err != nil {
+			m.logSyncError(ctx, event.Provider, "repository", event.Repository.FullName, err, requestID)
+			errs = append(errs, err)
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 // handleMembershipEvent handles membership events
-func (m *Manager) handleMembershipEvent(ctx context.Context, event *internalgithub.Event, requestID string) error {
+func (m *Manager) handleMembershipEvent(ctx context.Context, event *forge.Event, requestID string) error {
+	var errs []error
+
 	// Sync the member
-	if err := m.userSync.SyncUserFromEvent(ctx, event.Sender.ID, event.Sender.Login, string(event.Type), event.Action, requestID); err != nil {
-		m.logSyncError(ctx, "user", event.Sender.Login, err, requestID)
+	if err := m.userSync.SyncUserFromEvent(ctx, event.Provider, event.Sender.ID, event.Sender.Login, string(event.Type), event.Action, requestID); err != nil {
+		m.logSyncError(ctx, event.Provider, "user", event.Sender.Login, err, requestID)
+		errs = append(errs, err)
 	}
 
-	return nil
+	// Update the team membership relationship
+	member, _ := event.Payload["member"].(map[string]interface{})
+	team, _ := event.Payload["team"].(map[string]interface{})
+	memberID, _ := member["id"].(float64)
+	teamSlug, hasSlug := team["slug"].(string)
+	// An empty slug designates a GitLab top-level group
+	if memberID == 0 || !hasSlug || (teamSlug == "" && event.Provider != forge.ProviderGitLab) || event.Organization.Login == "" {
+		return errors.Join(errs...)
+	}
+
+	var err error
+	switch event.Action {
+	case "added":
+		err = m.relationships.AddTeamMember(ctx, event.Provider, event.Organization.Login, teamSlug, int64(memberID), requestID)
+	case "removed":
+		err = m.relationships.RemoveTeamMember(ctx, event.Provider, event.Organization.Login, teamSlug, int64(memberID), requestID)
+	}
+	if err != nil {
+		m.logSyncError(ctx, event.Provider, "team", teamSlug, err, requestID)
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
 }
 
 // handleRepositoryEvent handles repository events
-func (m *Manager) handleRepositoryEvent(ctx context.Context, event *internalgithub.Event, requestID string) error {
+func (m *Manager) handleRepositoryEvent(ctx context.Context, event *forge.Event, requestID string) error {
+	var errs []error
+
 	switch event.Action {
 	case "created", "edited", "transferred", "publicized", "privatized":
 		// Sync the repository
 		if event.Repository.ID != 0 {
-			if err := m.repositorySync.SyncRepositoryFromEvent(ctx, event.Repository.ID, event.Repository.Name, event.Repository.FullName, event.Repository.Private, string(event.Type), event.Action, requestID); err != nil {
-				m.logSyncError(ctx, "repository", event.Repository.FullName, err, requestID)
+			if err := m.repositorySync.SyncRepositoryFromEvent(ctx, event.Provider, event.Repository.ID, event.Repository.Name, event.Repository.FullName, event.Repository.Private, string(event.Type), event.Action, requestID); err != nil {
+				m.logSyncError(ctx, event.Provider, "repository", event.Repository.FullName, err, requestID)
+				errs = append(errs, err)
 			}
 		}
 	case "deleted", "archived":
 		// Mark repository as deleted
 		if event.Repository.ID != 0 {
-			if err := m.repositorySync.DeleteRepository(ctx, event.Repository.ID, event.Installation.ID, requestID); err != nil {
-				m.logSyncError(ctx, "repository", event.Repository.FullName, err, requestID)
+			if err := m.repositorySync.DeleteRepository(ctx, event.Provider, event.Repository.ID, event.Installation.ID, requestID); err != nil {
+				m.logSyncError(ctx, event.Provider, "repository", event.Repository.FullName, err, requestID)
+				errs = append(errs, err)
+			}
+			// Archived repositories stay readable; only deletion drops their relationships
+			if event.Action == "deleted" {
+				if err := m.relationships.DeleteRepository(ctx, event.Provider, event.Repository.FullName, requestID); err != nil {
+					m.logSyncError(ctx, event.Provider, "relationships", event.Repository.FullName, err, requestID)
+					errs = append(errs, err)
+				}
 			}
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 // handleInstallationEvent handles installation events
-func (m *Manager) handleInstallationEvent(ctx context.Context, event *internalgithub.Event, requestID string) error {
+func (m *Manager) handleInstallationEvent(ctx context.Context, event *forge.Event, requestID string) error {
+	var errs []error
+
 	switch event.Action {
 	case "created":
 		// Full sync of the new installation
-		if event.Installation.ID != 0 {
-			if err := m.SyncInstallation(ctx, event.Installation.ID); err != nil {
-				m.logSyncError(ctx, "installation", fmt.Sprintf("%d", event.Installation.ID), err, requestID)
+		dir := m.directory(event.Provider)
+		if event.Installation.ID != 0 && dir != nil {
+			ns := forge.Namespace{
+				ID:        event.Installation.ID,
+				Login:     event.Installation.Account.Login,
+				Type:      event.Installation.Account.Type,
+				AccountID: event.Installation.Account.ID,
+			}
+			if err := m.SyncNamespace(ctx, dir, ns); err != nil {
+				m.logSyncError(ctx, event.Provider, "installation", namespaceKey(event.Provider, ns.ID), err, requestID)
+				errs = append(errs, err)
 			}
 		}
 	case "deleted":
@@ -237,33 +345,44 @@ func (m *Manager) handleInstallationEvent(ctx context.Context, event *internalgi
 		// This would require additional logic to handle cleanup
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 // handleTeamEvent handles team events
-func (m *Manager) handleTeamEvent(ctx context.Context, event *internalgithub.Event, requestID string) error {
+func (m *Manager) handleTeamEvent(ctx context.Context, event *forge.Event, requestID string) error {
+	var errs []error
+
 	switch event.Action {
 	case "created", "edited":
 		// Sync the team
-		if err := m.teamSync.SyncTeamFromEvent(ctx, event.Organization.Login, event.Payload, string(event.Type), event.Action, requestID); err != nil {
-			m.logSyncError(ctx, "team", event.Organization.Login, err, requestID)
+		if err := m.teamSync.SyncTeamFromEvent(ctx, event.Provider, event.Organization.Login, event.Payload, string(event.Type), event.Action, requestID); err != nil {
+			m.logSyncError(ctx, event.Provider, "team", event.Organization.Login, err, requestID)
+			errs = append(errs, err)
 		}
 	case "deleted":
-		// Handle team deletion
-		// This would require additional logic to handle cleanup
+		// Remove the team's membership relationships
+		team, _ := event.Payload["team"].(map[string]interface{})
+		teamSlug, hasSlug := team["slug"].(string)
+		if hasSlug && (teamSlug != "" || event.Provider == forge.ProviderGitLab) && event.Organization.Login != "" {
+			if err := m.relationships.DeleteTeam(ctx, event.Provider, event.Organization.Login, teamSlug, requestID); err != nil {
+				m.logSyncError(ctx, event.Provider, "team", teamSlug, err, requestID)
+				errs = append(errs, err)
+			}
+		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 // logSyncError logs a synchronization error
-func (m *Manager) logSyncError(ctx context.Context, entityType, entityID string, err error, requestID string) {
+func (m *Manager) logSyncError(ctx context.Context, provider forge.Provider, entityType, entityID string, err error, requestID string) {
 	if m.auditLogger == nil {
 		return
 	}
 
 	auditEntry := identity.AuditEntry{
 		RequestID: requestID,
+		Provider:  string(provider),
 		Actor: identity.Actor{
 			Type: "system",
 			ID:   "github-app",
@@ -280,6 +399,14 @@ func (m *Manager) logSyncError(ctx context.Context, entityType, entityID string,
 	m.auditLogger.LogError(ctx, auditEntry, err)
 }
 
+// namespaceKey returns the last sync time key of a namespace
+func namespaceKey(provider forge.Provider, namespaceID int64) string {
+	if provider == forge.ProviderGitHub {
+		return fmt.Sprintf("installation:%d", namespaceID)
+	}
+	return fmt.Sprintf("%s:%d", provider, namespaceID)
+}
+
 // generateRequestID generates a unique request ID
 func generateRequestID() string {
 	return fmt.Sprintf("req-%d", time.Now().UnixNano())
@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"time"
 
-	gh "github.com/google/go-github/v57/github"
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
 	"github.com/skygenesisenterprise/aether-identity/package/github/identity"
 )
 
-// TeamSync handles synchronization of GitHub teams to Identity roles
+// TeamSync handles synchronization of forge teams to Identity roles
 type TeamSync struct {
 	identityClient *identity.Client
 	auditLogger    *identity.AuditLogger
@@ -23,19 +23,19 @@ func NewTeamSync(identityClient *identity.Client, auditLogger *identity.AuditLog
 	}
 }
 
-// SyncTeam synchronizes a single GitHub team to Identity as a role
-func (s *TeamSync) SyncTeam(ctx context.Context, ghTeam *gh.Team, org string, installationID int64, requestID string) error {
-	role := s.convertGitHubTeam(ghTeam, org)
+// SyncTeam synchronizes a single forge team to Identity as a role
+func (s *TeamSync) SyncTeam(ctx context.Context, provider forge.Provider, team forge.Team, org string, installationID int64, requestID string) error {
+	role := s.convertTeam(provider, team, org)
 
 	event := identity.SyncEvent{
 		EventType:  "team_sync",
-		Provider:   "github",
+		Provider:   string(provider),
 		EntityType: "role",
-		EntityID:   fmt.Sprintf("%d", ghTeam.GetID()),
+		EntityID:   fmt.Sprintf("%d", team.ID),
 		Data: map[string]interface{}{
 			"role":            role,
-			"github_id":       ghTeam.GetID(),
-			"github_slug":     ghTeam.GetSlug(),
+			"external_id":     team.ID,
+			"slug":            team.Slug,
 			"organization":    org,
 			"installation_id": installationID,
 		},
@@ -51,6 +51,7 @@ func (s *TeamSync) SyncTeam(ctx context.Context, ghTeam *gh.Team, org string, in
 	if s.auditLogger != nil {
 		auditEntry := identity.AuditEntry{
 			RequestID: requestID,
+			Provider:  string(provider),
 			Actor: identity.Actor{
 				Type:       "system",
 				ID:         "github-app",
@@ -59,8 +60,8 @@ func (s *TeamSync) SyncTeam(ctx context.Context, ghTeam *gh.Team, org string, in
 			Action: "sync_team",
 			Resource: identity.Resource{
 				Type: "role",
-				ID:   fmt.Sprintf("%d", ghTeam.GetID()),
-				Name: ghTeam.GetName(),
+				ID:   role.ID,
+				Name: role.Name,
 			},
 			Decision: "success",
 			Reason:   "Team synchronized to Identity as role",
@@ -71,14 +72,15 @@ func (s *TeamSync) SyncTeam(ctx context.Context, ghTeam *gh.Team, org string, in
 	return nil
 }
 
-// SyncTeams synchronizes multiple GitHub teams to Identity
-func (s *TeamSync) SyncTeams(ctx context.Context, ghTeams []*gh.Team, org string, installationID int64, requestID string) error {
-	for _, ghTeam := range ghTeams {
-		if err := s.SyncTeam(ctx, ghTeam, org, installationID, requestID); err != nil {
+// SyncTeams synchronizes multiple forge teams to Identity
+func (s *TeamSync) SyncTeams(ctx context.Context, provider forge.Provider, teams []forge.Team, org string, installationID int64, requestID string) error {
+	for _, team := range teams {
+		if err := s.SyncTeam(ctx, provider, team, org, installationID, requestID); err != nil {
 			// Log error but continue with other teams
 			if s.auditLogger != nil {
 				auditEntry := identity.AuditEntry{
 					RequestID: requestID,
+					Provider:  string(provider),
 					Actor: identity.Actor{
 						Type:       "system",
 						ID:         "github-app",
@@ -87,8 +89,8 @@ func (s *TeamSync) SyncTeams(ctx context.Context, ghTeams []*gh.Team, org string
 					Action: "sync_team",
 					Resource: identity.Resource{
 						Type: "role",
-						ID:   fmt.Sprintf("%d", ghTeam.GetID()),
-						Name: ghTeam.GetName(),
+						ID:   fmt.Sprintf("%s-team:%d", provider, team.ID),
+						Name: forge.TeamName(org, team.Slug),
 					},
 					Decision: "failed",
 					Reason:   err.Error(),
@@ -103,7 +105,7 @@ func (s *TeamSync) SyncTeams(ctx context.Context, ghTeams []*gh.Team, org string
 }
 
 // SyncTeamFromEvent synchronizes a team from a webhook event
-func (s *TeamSync) SyncTeamFromEvent(ctx context.Context, orgLogin string, payload map[string]interface{}, eventType string, eventAction string, requestID string) error {
+func (s *TeamSync) SyncTeamFromEvent(ctx context.Context, provider forge.Provider, orgLogin string, payload map[string]interface{}, eventType string, eventAction string, requestID string) error {
 	if orgLogin == "" {
 		return fmt.Errorf("no organization information in event")
 	}
@@ -121,7 +123,7 @@ func (s *TeamSync) SyncTeamFromEvent(ctx context.Context, orgLogin string, paylo
 
 	syncEvent := identity.SyncEvent{
 		EventType:  "team_sync_from_event",
-		Provider:   "github",
+		Provider:   string(provider),
 		EntityType: "role",
 		EntityID:   fmt.Sprintf("%d", int64(teamID)),
 		Data: map[string]interface{}{
@@ -138,15 +140,15 @@ func (s *TeamSync) SyncTeamFromEvent(ctx context.Context, orgLogin string, paylo
 }
 
 // SyncTeamMembers synchronizes team membership to Identity
-func (s *TeamSync) SyncTeamMembers(ctx context.Context, teamID int64, members []*gh.User, org string, installationID int64, requestID string) error {
+func (s *TeamSync) SyncTeamMembers(ctx context.Context, provider forge.Provider, teamID int64, members []forge.User, org string, installationID int64, requestID string) error {
 	memberIDs := make([]string, 0, len(members))
 	for _, member := range members {
-		memberIDs = append(memberIDs, fmt.Sprintf("%d", member.GetID()))
+		memberIDs = append(memberIDs, fmt.Sprintf("%d", member.ID))
 	}
 
 	event := identity.SyncEvent{
 		EventType:  "team_members_sync",
-		Provider:   "github",
+		Provider:   string(provider),
 		EntityType: "membership",
 		EntityID:   fmt.Sprintf("%d", teamID),
 		Data: map[string]interface{}{
@@ -162,12 +164,12 @@ func (s *TeamSync) SyncTeamMembers(ctx context.Context, teamID int64, members []
 	return s.identityClient.SyncEntity(ctx, event)
 }
 
-// convertGitHubTeam converts a GitHub team to Identity role format
-func (s *TeamSync) convertGitHubTeam(ghTeam *gh.Team, org string) identity.Role {
+// convertTeam converts a forge team to Identity role format
+func (s *TeamSync) convertTeam(provider forge.Provider, team forge.Team, org string) identity.Role {
 	return identity.Role{
-		ID:          fmt.Sprintf("github-team:%d", ghTeam.GetID()),
-		Name:        fmt.Sprintf("%s/%s", org, ghTeam.GetSlug()),
-		Description: ghTeam.GetDescription(),
+		ID:          fmt.Sprintf("%s-team:%d", provider, team.ID),
+		Name:        forge.TeamName(org, team.Slug),
+		Description: team.Description,
 		Permissions: []string{}, // Permissions will be managed by Identity
 	}
 }
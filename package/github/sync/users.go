@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"time"
 
-	gh "github.com/google/go-github/v57/github"
+	"github.com/skygenesisenterprise/aether-identity/package/github/forge"
 	"github.com/skygenesisenterprise/aether-identity/package/github/identity"
 )
 
-// UserSync handles synchronization of GitHub users to Identity
+// UserSync handles synchronization of forge users to Identity
 type UserSync struct {
 	identityClient *identity.Client
 	auditLogger    *identity.AuditLogger
@@ -23,19 +23,19 @@ func NewUserSync(identityClient *identity.Client, auditLogger *identity.AuditLog
 	}
 }
 
-// SyncUser synchronizes a single GitHub user to Identity
-func (s *UserSync) SyncUser(ctx context.Context, ghUser *gh.User, installationID int64, requestID string) error {
-	user := s.convertGitHubUser(ghUser)
+// SyncUser synchronizes a single forge user to Identity
+func (s *UserSync) SyncUser(ctx context.Context, provider forge.Provider, forgeUser forge.User, installationID int64, requestID string) error {
+	user := s.convertUser(provider, forgeUser)
 
 	event := identity.SyncEvent{
 		EventType:  "user_sync",
-		Provider:   "github",
+		Provider:   string(provider),
 		EntityType: "user",
-		EntityID:   fmt.Sprintf("%d", ghUser.GetID()),
+		EntityID:   fmt.Sprintf("%d", forgeUser.ID),
 		Data: map[string]interface{}{
 			"user":            user,
-			"github_id":       ghUser.GetID(),
-			"github_login":    ghUser.GetLogin(),
+			"external_id":     forgeUser.ID,
+			"login":           forgeUser.Login,
 			"installation_id": installationID,
 		},
 		Timestamp: time.Now().UTC(),
@@ -50,6 +50,7 @@ func (s *UserSync) SyncUser(ctx context.Context, ghUser *gh.User, installationID
 	if s.auditLogger != nil {
 		auditEntry := identity.AuditEntry{
 			RequestID: requestID,
+			Provider:  string(provider),
 			Actor: identity.Actor{
 				Type:       "system",
 				ID:         "github-app",
@@ -58,8 +59,8 @@ func (s *UserSync) SyncUser(ctx context.Context, ghUser *gh.User, installationID
 			Action: "sync_user",
 			Resource: identity.Resource{
 				Type: "user",
-				ID:   fmt.Sprintf("%d", ghUser.GetID()),
-				Name: ghUser.GetLogin(),
+				ID:   fmt.Sprintf("%s:%d", provider, forgeUser.ID),
+				Name: forgeUser.Login,
 			},
 			Decision: "success",
 			Reason:   "User synchronized to Identity",
@@ -70,14 +71,15 @@ func (s *UserSync) SyncUser(ctx context.Context, ghUser *gh.User, installationID
 	return nil
 }
 
-// SyncUsers synchronizes multiple GitHub users to Identity
-func (s *UserSync) SyncUsers(ctx context.Context, ghUsers []*gh.User, installationID int64, requestID string) error {
-	for _, ghUser := range ghUsers {
-		if err := s.SyncUser(ctx, ghUser, installationID, requestID); err != nil {
+// SyncUsers synchronizes multiple forge users to Identity
+func (s *UserSync) SyncUsers(ctx context.Context, provider forge.Provider, forgeUsers []forge.User, installationID int64, requestID string) error {
+	for _, forgeUser := range forgeUsers {
+		if err := s.SyncUser(ctx, provider, forgeUser, installationID, requestID); err != nil {
 			// Log error but continue with other users
 			if s.auditLogger != nil {
 				auditEntry := identity.AuditEntry{
 					RequestID: requestID,
+					Provider:  string(provider),
 					Actor: identity.Actor{
 						Type:       "system",
 						ID:         "github-app",
@@ -86,8 +88,8 @@ func (s *UserSync) SyncUsers(ctx context.Context, ghUsers []*gh.User, installati
 					Action: "sync_user",
 					Resource: identity.Resource{
 						Type: "user",
-						ID:   fmt.Sprintf("%d", ghUser.GetID()),
-						Name: ghUser.GetLogin(),
+						ID:   fmt.Sprintf("%s:%d", provider, forgeUser.ID),
+						Name: forgeUser.Login,
 					},
 					Decision: "failed",
 					Reason:   err.Error(),
@@ -102,19 +104,19 @@ func (s *UserSync) SyncUsers(ctx context.Context, ghUsers []*gh.User, installati
 }
 
 // SyncUserFromEvent synchronizes a user from a webhook event
-func (s *UserSync) SyncUserFromEvent(ctx context.Context, senderID int64, senderLogin string, eventType string, eventAction string, requestID string) error {
+func (s *UserSync) SyncUserFromEvent(ctx context.Context, provider forge.Provider, senderID int64, senderLogin string, eventType string, eventAction string, requestID string) error {
 	if senderID == 0 {
 		return fmt.Errorf("no sender information in event")
 	}
 
 	syncEvent := identity.SyncEvent{
 		EventType:  "user_sync_from_event",
-		Provider:   "github",
+		Provider:   string(provider),
 		EntityType: "user",
 		EntityID:   fmt.Sprintf("%d", senderID),
 		Data: map[string]interface{}{
-			"github_id":    senderID,
-			"github_login": senderLogin,
+			"external_id":  senderID,
+			"login":        senderLogin,
 			"event_type":   eventType,
 			"event_action": eventAction,
 		},
@@ -125,18 +127,18 @@ func (s *UserSync) SyncUserFromEvent(ctx context.Context, senderID int64, sender
 	return s.identityClient.SyncEntity(ctx, syncEvent)
 }
 
-// convertGitHubUser converts a GitHub user to Identity user format
-func (s *UserSync) convertGitHubUser(ghUser *gh.User) identity.User {
+// convertUser converts a forge user to Identity user format
+func (s *UserSync) convertUser(provider forge.Provider, forgeUser forge.User) identity.User {
 	return identity.User{
-		ID:         fmt.Sprintf("github:%d", ghUser.GetID()),
-		ExternalID: fmt.Sprintf("%d", ghUser.GetID()),
-		Provider:   "github",
-		Email:      ghUser.GetEmail(),
-		Name:       ghUser.GetName(),
+		ID:         fmt.Sprintf("%s:%d", provider, forgeUser.ID),
+		ExternalID: fmt.Sprintf("%d", forgeUser.ID),
+		Provider:   string(provider),
+		Email:      forgeUser.Email,
+		Name:       forgeUser.Name,
 		Attributes: map[string]interface{}{
-			"login":      ghUser.GetLogin(),
-			"avatar_url": ghUser.GetAvatarURL(),
-			"type":       ghUser.GetType(),
+			"login":      forgeUser.Login,
+			"avatar_url": forgeUser.AvatarURL,
+			"type":       forgeUser.Type,
 		},
 		CreatedAt: time.Now().UTC(),
 		UpdatedAt: time.Now().UTC(),
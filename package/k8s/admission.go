@@ -0,0 +1,243 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxAdmissionReviewSize bounds the admission review bodies read by the webhook
+const maxAdmissionReviewSize = 3 << 20
+
+// AdmissionReview is an admission.k8s.io/v1 AdmissionReview
+type AdmissionReview struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Request    *AdmissionRequest  `json:"request,omitempty"`
+	Response   *AdmissionResponse `json:"response,omitempty"`
+}
+
+// AdmissionRequest is the request of an admission review
+type AdmissionRequest struct {
+	UID       string           `json:"uid"`
+	Kind      GroupVersionKind `json:"kind"`
+	Namespace string           `json:"namespace,omitempty"`
+	Operation string           `json:"operation"`
+	Object    json.RawMessage  `json:"object,omitempty"`
+}
+
+// GroupVersionKind identifies the kind of the reviewed object
+type GroupVersionKind struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+// AdmissionResponse is the response of an admission review
+type AdmissionResponse struct {
+	UID       string        `json:"uid"`
+	Allowed   bool          `json:"allowed"`
+	Result    *StatusResult `json:"status,omitempty"`
+	PatchType *string       `json:"patchType,omitempty"`
+	Patch     []byte        `json:"patch,omitempty"`
+	Warnings  []string      `json:"warnings,omitempty"`
+}
+
+// StatusResult explains a rejected admission request
+type StatusResult struct {
+	Code    int32  `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// patchOperation is a JSON Patch (RFC 6902) operation
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// admissionPod holds the fields of a pod read by the webhook; the patch only
+// appends to the pod, so the rest of the object does not need to be decoded
+type admissionPod struct {
+	Metadata struct {
+		Name         string            `json:"name"`
+		GenerateName string            `json:"generateName"`
+		Namespace    string            `json:"namespace"`
+		Labels       map[string]string `json:"labels"`
+		Annotations  map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec struct {
+		ServiceAccountName string `json:"serviceAccountName"`
+		Containers         []struct {
+			Name         string            `json:"name"`
+			Env          []json.RawMessage `json:"env"`
+			VolumeMounts []json.RawMessage `json:"volumeMounts"`
+		} `json:"containers"`
+		Volumes []json.RawMessage `json:"volumes"`
+	} `json:"spec"`
+}
+
+// AdmissionWebhook is a mutating admission webhook injecting the identity agent sidecar
+// into pods annotated with identity.aether.dev/inject=true
+type AdmissionWebhook struct {
+	client      *Client
+	agentConfig AgentConfig
+}
+
+// NewAdmissionWebhook creates an admission webhook injecting agents configured by agent
+func (c *Client) NewAdmissionWebhook(agent AgentConfig) *AdmissionWebhook {
+	return &AdmissionWebhook{
+		client:      c,
+		agentConfig: agent,
+	}
+}
+
+// ServeHTTP handles AdmissionReview requests from the Kubernetes API server
+func (w *AdmissionWebhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxAdmissionReviewSize))
+	if err != nil {
+		http.Error(rw, "Error reading body", http.StatusBadRequest)
+		return
+	}
+
+	var review AdmissionReview
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(rw, "Invalid admission review", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = w.Mutate(review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(review)
+}
+
+// Mutate reviews an admission request and returns the JSON patch injecting the agent.
+// Pods without the inject annotation, or already injected, are admitted unchanged.
+func (w *AdmissionWebhook) Mutate(req *AdmissionRequest) *AdmissionResponse {
+	response := &AdmissionResponse{UID: req.UID, Allowed: true}
+
+	if req.Kind.Kind != "Pod" || req.Operation != "CREATE" {
+		return response
+	}
+
+	var pod admissionPod
+	if err := json.Unmarshal(req.Object, &pod); err != nil {
+		return deny(response, http.StatusBadRequest, fmt.Sprintf("failed to decode pod: %v", err))
+	}
+
+	annotations := pod.Metadata.Annotations
+	if annotations[AnnotationInject] != "true" || annotations[AnnotationInjected] == "true" {
+		return response
+	}
+	for _, container := range pod.Spec.Containers {
+		if container.Name == AgentContainerName {
+			return response
+		}
+	}
+
+	binding := bindingFromAnnotations(annotations)
+	if err := binding.Validate(); err != nil {
+		return deny(response, http.StatusUnprocessableEntity, err.Error())
+	}
+	if !binding.HasRequirements() {
+		response.Warnings = append(response.Warnings, "identity agent injected without scopes or roles")
+	}
+
+	namespace := req.Namespace
+	if namespace == "" {
+		namespace = pod.Metadata.Namespace
+	}
+
+	patch, err := json.Marshal(w.patch(&pod, workload{
+		stack:     firstNonEmpty(annotations[AnnotationStack], pod.Metadata.Labels["app.kubernetes.io/part-of"]),
+		service:   podServiceName(&pod),
+		namespace: namespace,
+		binding:   binding,
+	}))
+	if err != nil {
+		return deny(response, http.StatusInternalServerError, fmt.Sprintf("failed to encode patch: %v", err))
+	}
+
+	patchType := "JSONPatch"
+	response.PatchType = &patchType
+	response.Patch = patch
+	return response
+}
+
+// patch returns the operations adding the agent sidecar, its volumes and the socket
+// mount of every workload container, mirroring injectPod
+func (w *AdmissionWebhook) patch(pod *admissionPod, wl workload) []patchOperation {
+	c := w.client
+	var ops []patchOperation
+
+	for i, container := range pod.Spec.Containers {
+		base := fmt.Sprintf("/spec/containers/%d", i)
+		ops = appendAll(ops, base+"/env", len(container.Env) == 0, c.workloadEnv(wl.service))
+		ops = appendAll(ops, base+"/volumeMounts", len(container.VolumeMounts) == 0, []VolumeMount{c.workloadMount()})
+	}
+
+	ops = append(ops, patchOperation{Op: "add", Path: "/spec/containers/-", Value: c.agentContainer(wl, w.agentConfig)})
+	ops = appendAll(ops, "/spec/volumes", len(pod.Spec.Volumes) == 0, c.identityVolumes())
+
+	ops = append(ops, patchOperation{
+		Op:    "add",
+		Path:  "/metadata/annotations/" + escapeJSONPointer(AnnotationInjected),
+		Value: "true",
+	})
+
+	return ops
+}
+
+// appendAll adds items to the array at path, creating the array when it does not exist
+func appendAll[T any](ops []patchOperation, path string, create bool, items []T) []patchOperation {
+	if create {
+		return append(ops, patchOperation{Op: "add", Path: path, Value: items})
+	}
+	for _, item := range items {
+		ops = append(ops, patchOperation{Op: "add", Path: path + "/-", Value: item})
+	}
+	return ops
+}
+
+// podServiceName returns the identity service name of a pod
+func podServiceName(pod *admissionPod) string {
+	return firstNonEmpty(
+		pod.Metadata.Annotations[AnnotationService],
+		pod.Metadata.Labels["app.kubernetes.io/name"],
+		pod.Spec.ServiceAccountName,
+		pod.Metadata.Name,
+		strings.TrimSuffix(pod.Metadata.GenerateName, "-"),
+	)
+}
+
+// deny rejects an admission request
+func deny(response *AdmissionResponse, code int32, message string) *AdmissionResponse {
+	response.Allowed = false
+	response.Result = &StatusResult{Code: code, Message: message}
+	return response
+}
+
+// escapeJSONPointer escapes a key for use in a JSON pointer (RFC 6901)
+func escapeJSONPointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,189 @@
+package k8s
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// AgentContainerName is the name of the injected identity agent container
+	AgentContainerName = "aether-identity-agent"
+
+	// socketVolumeName is the volume sharing the agent socket with the workload
+	socketVolumeName = "aether-identity-socket"
+
+	// tokenVolumeName is the projected service account token exchanged by the agent
+	tokenVolumeName = "aether-identity-token"
+
+	// tokenMountPath is where the projected token volume is mounted in the agent
+	tokenMountPath = "/var/run/secrets/aether"
+
+	// DefaultTokenPath is the path of the projected service account token in the agent
+	DefaultTokenPath = tokenMountPath + "/token"
+)
+
+// AgentConfig holds configuration for the identity agent sidecar
+type AgentConfig struct {
+	// ExtraEnv provides additional environment variables for the agent
+	ExtraEnv map[string]string
+
+	// LogLevel sets the agent logging level
+	LogLevel string
+
+	// TokenRefreshInterval controls how often to refresh tokens
+	TokenRefreshInterval string
+
+	// Resources are the agent container requests and limits
+	Resources *Resources
+}
+
+// workload identifies the pod the agent is added to
+type workload struct {
+	stack     string
+	service   string
+	namespace string
+	binding   IdentityBinding
+}
+
+// agentContainer builds the identity agent sidecar. The agent exchanges the projected
+// service account token for Aether access tokens and serves them on the shared socket.
+func (c *Client) agentContainer(w workload, agent AgentConfig) Container {
+	config := c.config
+
+	env := map[string]string{
+		"AETHER_IDENTITY_ENDPOINT": config.IdentityEndpoint,
+		"AETHER_PROJECT":           config.Project,
+		"AETHER_STACK":             w.stack,
+		"AETHER_AGENT_SOCKET":      config.AgentSocketPath,
+		"AETHER_ENVIRONMENT":       config.Environment,
+		"AETHER_SERVICE":           w.service,
+		"AETHER_NAMESPACE":         w.namespace,
+		"AETHER_AUTH_METHOD":       "service_account_token",
+		"AETHER_TOKEN_PATH":        DefaultTokenPath,
+	}
+	if scopes := w.binding.GetScopes(); len(scopes) > 0 {
+		env["AETHER_SCOPES"] = strings.Join(scopes, ",")
+	}
+	if roles := w.binding.GetRoles(); len(roles) > 0 {
+		env["AETHER_ROLES"] = strings.Join(roles, ",")
+	}
+	if w.binding.Audience != "" {
+		env["AETHER_AUDIENCE"] = w.binding.Audience
+	}
+	if w.binding.RequireIdentity {
+		env["AETHER_REQUIRE_IDENTITY"] = "true"
+	}
+	if agent.LogLevel != "" {
+		env["AETHER_LOG_LEVEL"] = agent.LogLevel
+	}
+	if agent.TokenRefreshInterval != "" {
+		env["AETHER_TOKEN_REFRESH_INTERVAL"] = agent.TokenRefreshInterval
+	}
+
+	// Add agent-specific config
+	for k, v := range agent.ExtraEnv {
+		env[k] = v
+	}
+
+	return Container{
+		Name:      AgentContainerName,
+		Image:     config.AgentImage,
+		Env:       envVars(env, nil),
+		Resources: resourceRequirements(agent.Resources),
+		VolumeMounts: []VolumeMount{
+			{Name: socketVolumeName, MountPath: filepath.Dir(config.AgentSocketPath)},
+			{Name: tokenVolumeName, MountPath: tokenMountPath, ReadOnly: true},
+		},
+		ReadinessProbe: &Probe{
+			Exec:          &ExecAction{Command: []string{"test", "-S", config.AgentSocketPath}},
+			PeriodSeconds: 10,
+		},
+	}
+}
+
+// injectPod adds the agent sidecar, its volumes and the socket mount of every workload container
+func (c *Client) injectPod(pod *PodSpec, w workload, agent AgentConfig) {
+	for i := range pod.Containers {
+		container := &pod.Containers[i]
+		container.Env = append(container.Env, c.workloadEnv(w.service)...)
+		container.VolumeMounts = append(container.VolumeMounts, c.workloadMount())
+	}
+	pod.Containers = append(pod.Containers, c.agentContainer(w, agent))
+	pod.Volumes = append(pod.Volumes, c.identityVolumes()...)
+}
+
+// identityVolumes returns the socket and projected token volumes of an identity-aware pod
+func (c *Client) identityVolumes() []Volume {
+	return []Volume{
+		{
+			Name:     socketVolumeName,
+			EmptyDir: &EmptyDirVolumeSource{Medium: "Memory"},
+		},
+		{
+			Name: tokenVolumeName,
+			Projected: &ProjectedVolumeSource{
+				Sources: []VolumeProjection{{
+					ServiceAccountToken: &ServiceAccountTokenProjection{
+						Audience:          c.config.TokenAudience,
+						ExpirationSeconds: c.config.TokenExpirationSeconds,
+						Path:              filepath.Base(DefaultTokenPath),
+					},
+				}},
+			},
+		},
+	}
+}
+
+// workloadEnv returns the environment variables pointing a workload container to the agent
+func (c *Client) workloadEnv(serviceName string) []EnvVar {
+	return []EnvVar{
+		{Name: "AETHER_IDENTITY_SERVICE", Value: serviceName},
+		{Name: "AETHER_IDENTITY_SOCKET", Value: c.config.AgentSocketPath},
+	}
+}
+
+// workloadMount returns the read-only mount of the agent socket in a workload container
+func (c *Client) workloadMount() VolumeMount {
+	return VolumeMount{
+		Name:      socketVolumeName,
+		MountPath: filepath.Dir(c.config.AgentSocketPath),
+		ReadOnly:  true,
+	}
+}
+
+// envVars converts plain and secret environment variables to a list sorted by name
+func envVars(plain map[string]string, secrets map[string]SecretKeyRef) []EnvVar {
+	names := make([]string, 0, len(plain)+len(secrets))
+	for name := range plain {
+		names = append(names, name)
+	}
+	for name := range secrets {
+		if _, ok := plain[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	vars := make([]EnvVar, 0, len(names))
+	for _, name := range names {
+		// Secret references take precedence over plain values
+		if ref, ok := secrets[name]; ok {
+			vars = append(vars, EnvVar{
+				Name:      name,
+				ValueFrom: &EnvVarSource{SecretKeyRef: &SecretKeySelector{Name: ref.Name, Key: ref.Key}},
+			})
+			continue
+		}
+		vars = append(vars, EnvVar{Name: name, Value: plain[name]})
+	}
+	return vars
+}
+
+// resourceRequirements converts resources to container requirements
+func resourceRequirements(r *Resources) *ResourceRequirements {
+	if r == nil || (len(r.Requests) == 0 && len(r.Limits) == 0) {
+		return nil
+	}
+	return &ResourceRequirements{Requests: r.Requests, Limits: r.Limits}
+}
@@ -0,0 +1,83 @@
+package k8s
+
+// Client is the main entrypoint for the Kubernetes SDK
+// It provides configuration and factory methods for creating stacks
+type Client struct {
+	config Config
+}
+
+// Config holds the global configuration for the Kubernetes SDK
+type Config struct {
+	// IdentityEndpoint is the URL of the Aether Identity service
+	IdentityEndpoint string
+
+	// Project is the project name for resource organization
+	Project string
+
+	// Namespace is the Kubernetes namespace of the generated resources
+	// Defaults to "default" if empty
+	Namespace string
+
+	// AgentImage is the container image for the identity agent sidecar
+	// Defaults to "aether/identity-agent:latest" if empty
+	AgentImage string
+
+	// AgentSocketPath is the path where the agent socket is mounted
+	// Defaults to "/var/run/aether/identity.sock" if empty
+	AgentSocketPath string
+
+	// Environment is the deployment environment (dev, staging, prod)
+	Environment string
+
+	// TokenAudience is the audience of the projected service account tokens
+	// exchanged with Aether Identity. Defaults to IdentityEndpoint if empty
+	TokenAudience string
+
+	// TokenExpirationSeconds is the lifetime of the projected service account tokens
+	// Defaults to 3600 if zero; Kubernetes requires at least 600
+	TokenExpirationSeconds int64
+}
+
+// NewClient creates a new Kubernetes SDK client with the provided configuration
+func NewClient(config Config) *Client {
+	// Apply defaults
+	if config.Namespace == "" {
+		config.Namespace = "default"
+	}
+	if config.AgentImage == "" {
+		config.AgentImage = "aether/identity-agent:latest"
+	}
+	if config.AgentSocketPath == "" {
+		config.AgentSocketPath = "/var/run/aether/identity.sock"
+	}
+	if config.Environment == "" {
+		config.Environment = "development"
+	}
+	if config.TokenAudience == "" {
+		config.TokenAudience = config.IdentityEndpoint
+	}
+	if config.TokenExpirationSeconds == 0 {
+		config.TokenExpirationSeconds = 3600
+	}
+
+	return &Client{config: config}
+}
+
+// NewStack creates a new Kubernetes stack with the given name
+func (c *Client) NewStack(name string, opts ...StackOption) *Stack {
+	s := &Stack{
+		name:     name,
+		client:   c,
+		services: make(map[string]Service),
+		secrets:  make(map[string]Secret),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Config returns the client configuration (read-only copy)
+func (c *Client) Config() Config {
+	return c.config
+}
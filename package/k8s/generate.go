@@ -0,0 +1,366 @@
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// generateManifests generates the manifests file for the stack
+func (c *Client) generateManifests(stack *Stack, path string) error {
+	data, err := stack.RenderManifests()
+	if err != nil {
+		return err
+	}
+
+	// Ensure directory exists
+	dir := filepath.Dir(path)
+	if dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return WrapError(err, "failed to create directory %q", dir)
+		}
+	}
+
+	// Write file
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return WrapError(err, "failed to write manifests to %q", path)
+	}
+
+	return nil
+}
+
+// buildManifests builds the resources of the stack
+func (c *Client) buildManifests(stack *Stack) ([]Object, error) {
+	if err := stack.Validate(); err != nil {
+		return nil, err
+	}
+
+	var accounts, secrets, deployments, services []Object
+	seenAccounts := make(map[string]bool)
+
+	for _, name := range sortedKeys(stack.services) {
+		svc := stack.services[name]
+
+		account := svc.ServiceAccountName(name)
+		if !seenAccounts[account] {
+			seenAccounts[account] = true
+			accounts = append(accounts, c.buildServiceAccount(account, name, svc, stack))
+		}
+
+		deployments = append(deployments, c.buildDeployment(name, svc, stack))
+
+		if len(svc.Ports) > 0 {
+			services = append(services, c.buildService(name, svc, stack))
+		}
+	}
+
+	for _, name := range sortedKeys(stack.secrets) {
+		secrets = append(secrets, c.buildSecret(name, stack.secrets[name], stack))
+	}
+
+	objects := append(accounts, secrets...)
+	objects = append(objects, deployments...)
+	return append(objects, services...), nil
+}
+
+// buildServiceAccount builds the service account of a workload; the subject annotation is
+// the identity Aether Identity federates for the projected tokens of the account
+func (c *Client) buildServiceAccount(account, name string, svc Service, stack *Stack) ServiceAccount {
+	annotations := svc.Identity.Annotations(name)
+	if svc.HasIdentity() {
+		annotations[AnnotationSubject] = fmt.Sprintf("system:serviceaccount:%s:%s", c.config.Namespace, account)
+		annotations[AnnotationStack] = stack.name
+	}
+
+	return ServiceAccount{
+		TypeMeta: TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		Metadata: ObjectMeta{
+			Name:        account,
+			Namespace:   c.config.Namespace,
+			Labels:      c.stackLabels(name, stack, nil),
+			Annotations: emptyToNil(annotations),
+		},
+	}
+}
+
+// buildSecret builds an Opaque secret of the stack
+func (c *Client) buildSecret(name string, secret Secret, stack *Stack) SecretManifest {
+	labels := map[string]string{
+		"app.kubernetes.io/part-of":    stack.name,
+		"app.kubernetes.io/managed-by": "aether-identity",
+		"aether.project":               c.config.Project,
+		"aether.stack":                 stack.name,
+	}
+	for k, v := range secret.Labels {
+		labels[k] = v
+	}
+
+	return SecretManifest{
+		TypeMeta: TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		Metadata: ObjectMeta{
+			Name:      name,
+			Namespace: c.config.Namespace,
+			Labels:    labels,
+		},
+		Type:       "Opaque",
+		StringData: secret.StringData,
+	}
+}
+
+// buildDeployment builds the deployment of a workload. Identity-aware pods are annotated for
+// the admission webhook, or carry the agent sidecar directly when the stack inlines it.
+func (c *Client) buildDeployment(name string, svc Service, stack *Stack) Deployment {
+	replicas := svc.Replicas
+	if replicas == 0 {
+		replicas = 1
+	}
+
+	labels := c.stackLabels(name, stack, svc.Labels)
+
+	podAnnotations := make(map[string]string)
+	for k, v := range svc.Annotations {
+		podAnnotations[k] = v
+	}
+
+	container := Container{
+		Name:       name,
+		Image:      svc.Image,
+		Command:    svc.Command,
+		Args:       svc.Args,
+		WorkingDir: svc.WorkingDir,
+		Resources:  resourceRequirements(svc.Resources),
+	}
+
+	for _, port := range svc.Ports {
+		container.Ports = append(container.Ports, ContainerPort{
+			Name:          port.Name,
+			ContainerPort: port.ContainerPort,
+			Protocol:      port.Protocol,
+		})
+	}
+
+	if probe := buildProbe(svc.HealthCheck); probe != nil {
+		container.ReadinessProbe = probe
+		container.LivenessProbe = probe
+	}
+
+	container.Env = envVars(svc.Environment, svc.SecretEnvironment)
+	pod := PodSpec{
+		ServiceAccountName: svc.ServiceAccountName(name),
+		Containers:         []Container{container},
+	}
+
+	if svc.HasIdentity() {
+		for k, v := range svc.Identity.Annotations(name) {
+			podAnnotations[k] = v
+		}
+		podAnnotations[AnnotationStack] = stack.name
+
+		if stack.inlineSidecar {
+			podAnnotations[AnnotationInjected] = "true"
+			c.injectPod(&pod, workload{
+				stack:     stack.name,
+				service:   podAnnotations[AnnotationService],
+				namespace: c.config.Namespace,
+				binding:   svc.Identity,
+			}, stack.agentConfig)
+		} else {
+			podAnnotations[AnnotationInject] = "true"
+		}
+	}
+
+	return Deployment{
+		TypeMeta: TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		Metadata: ObjectMeta{
+			Name:        name,
+			Namespace:   c.config.Namespace,
+			Labels:      labels,
+			Annotations: emptyToNil(svc.Identity.Annotations(name)),
+		},
+		Spec: DeploymentSpec{
+			Replicas: &replicas,
+			Selector: LabelSelector{MatchLabels: c.selectorLabels(name, stack)},
+			Template: PodTemplateSpec{
+				Metadata: ObjectMeta{
+					Labels:      labels,
+					Annotations: emptyToNil(podAnnotations),
+				},
+				Spec: pod,
+			},
+		},
+	}
+}
+
+// buildService builds the service exposing the ports of a workload
+func (c *Client) buildService(name string, svc Service, stack *Stack) ServiceManifest {
+	serviceType := svc.ServiceType
+	if serviceType == "" {
+		serviceType = ServiceClusterIP
+	}
+
+	ports := make([]ServicePort, 0, len(svc.Ports))
+	for _, port := range svc.Ports {
+		servicePort := port.ServicePort
+		if servicePort == 0 {
+			servicePort = port.ContainerPort
+		}
+		ports = append(ports, ServicePort{
+			Name:       port.Name,
+			Port:       servicePort,
+			TargetPort: port.ContainerPort,
+			Protocol:   port.Protocol,
+		})
+	}
+
+	return ServiceManifest{
+		TypeMeta: TypeMeta{APIVersion: "v1", Kind: "Service"},
+		Metadata: ObjectMeta{
+			Name:      name,
+			Namespace: c.config.Namespace,
+			Labels:    c.stackLabels(name, stack, svc.Labels),
+		},
+		Spec: ServiceSpec{
+			Type:     string(serviceType),
+			Selector: c.selectorLabels(name, stack),
+			Ports:    ports,
+		},
+	}
+}
+
+// buildProbe converts a health check to a container probe
+func buildProbe(hc *HealthCheck) *Probe {
+	if hc == nil {
+		return nil
+	}
+
+	probe := &Probe{
+		InitialDelaySeconds: hc.InitialDelaySeconds,
+		PeriodSeconds:       hc.PeriodSeconds,
+		TimeoutSeconds:      hc.TimeoutSeconds,
+		FailureThreshold:    hc.FailureThreshold,
+	}
+	if hc.HTTPPath != "" {
+		probe.HTTPGet = &HTTPGetAction{Path: hc.HTTPPath, Port: hc.Port}
+	} else {
+		probe.Exec = &ExecAction{Command: hc.Command}
+	}
+	return probe
+}
+
+// stackLabels returns the labels of a resource of the stack merged with user labels
+func (c *Client) stackLabels(name string, stack *Stack, extra map[string]string) map[string]string {
+	labels := make(map[string]string, len(extra)+6)
+	for k, v := range extra {
+		labels[k] = v
+	}
+
+	labels["app.kubernetes.io/name"] = name
+	labels["app.kubernetes.io/part-of"] = stack.name
+	labels["app.kubernetes.io/managed-by"] = "aether-identity"
+	labels["aether.project"] = c.config.Project
+	labels["aether.stack"] = stack.name
+	labels["aether.service"] = name
+
+	return labels
+}
+
+// selectorLabels returns the immutable selector of a workload
+func (c *Client) selectorLabels(name string, stack *Stack) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":    name,
+		"app.kubernetes.io/part-of": stack.name,
+	}
+}
+
+// WebhookOptions configures the registration of the admission webhook
+type WebhookOptions struct {
+	// Name of the MutatingWebhookConfiguration; defaults to "aether-identity-injector"
+	Name string
+
+	// ServiceName is the Kubernetes Service in front of the webhook server
+	ServiceName string
+
+	// ServiceNamespace defaults to the client namespace
+	ServiceNamespace string
+
+	// Path of the webhook handler; defaults to "/mutate"
+	Path string
+
+	// Port of the webhook Service; defaults to 443
+	Port int32
+
+	// CABundle is the PEM bundle of the CA that signed the webhook certificate
+	CABundle []byte
+
+	// FailurePolicy is "Fail" (default) or "Ignore"
+	FailurePolicy string
+}
+
+// WebhookConfiguration returns the MutatingWebhookConfiguration that routes pod creations in
+// namespaces labelled identity.aether.dev/injection=enabled to the admission webhook
+func (c *Client) WebhookConfiguration(opts WebhookOptions) (MutatingWebhookConfiguration, error) {
+	if opts.ServiceName == "" {
+		return MutatingWebhookConfiguration{}, NewValidationError("webhook service name is required")
+	}
+	if opts.Name == "" {
+		opts.Name = "aether-identity-injector"
+	}
+	if opts.ServiceNamespace == "" {
+		opts.ServiceNamespace = c.config.Namespace
+	}
+	if opts.Path == "" {
+		opts.Path = "/mutate"
+	}
+	if opts.Port == 0 {
+		opts.Port = 443
+	}
+	if opts.FailurePolicy == "" {
+		opts.FailurePolicy = "Fail"
+	}
+	if opts.FailurePolicy != "Fail" && opts.FailurePolicy != "Ignore" {
+		return MutatingWebhookConfiguration{}, NewValidationError("webhook failure policy must be Fail or Ignore")
+	}
+
+	timeout := int32(10)
+
+	return MutatingWebhookConfiguration{
+		TypeMeta: TypeMeta{APIVersion: "admissionregistration.k8s.io/v1", Kind: "MutatingWebhookConfiguration"},
+		Metadata: ObjectMeta{
+			Name: opts.Name,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "aether-identity",
+			},
+		},
+		Webhooks: []MutatingWebhook{{
+			Name:                    "inject.identity.aether.dev",
+			AdmissionReviewVersions: []string{"v1"},
+			SideEffects:             "None",
+			FailurePolicy:           opts.FailurePolicy,
+			ClientConfig: WebhookClientConfig{
+				Service: &ServiceReference{
+					Namespace: opts.ServiceNamespace,
+					Name:      opts.ServiceName,
+					Path:      opts.Path,
+					Port:      opts.Port,
+				},
+				CABundle: opts.CABundle,
+			},
+			Rules: []RuleWithOperations{{
+				Operations:  []string{"CREATE"},
+				APIGroups:   []string{""},
+				APIVersions: []string{"v1"},
+				Resources:   []string{"pods"},
+			}},
+			NamespaceSelector: &LabelSelector{MatchLabels: map[string]string{LabelInjection: "enabled"}},
+			TimeoutSeconds:    &timeout,
+		}},
+	}, nil
+}
+
+// emptyToNil drops empty maps so they are omitted from the manifests
+func emptyToNil(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
@@ -0,0 +1,183 @@
+package k8s
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Run `go test -update` to rewrite the golden files after an intended change of the output
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func testClient() *Client {
+	return NewClient(Config{
+		IdentityEndpoint: "https://identity.aether.dev",
+		Project:          "my-project",
+		Namespace:        "apps",
+		Environment:      "production",
+	})
+}
+
+// apiStack is the stack of the README quick start, with a worker and a second secret
+func apiStack(opts ...StackOption) *Stack {
+	stack := testClient().NewStack("api-services", opts...)
+
+	stack.Service("api", Service{
+		Image:    "myapp/api:1.4.2",
+		Replicas: 2,
+		Ports:    []Port{{Name: "http", ContainerPort: 8080, ServicePort: 80}},
+		Environment: map[string]string{
+			"LOG_LEVEL": "info",
+			"PORT":      "8080",
+		},
+		SecretEnvironment: map[string]SecretKeyRef{
+			"DATABASE_URL": {Name: "api-database", Key: "url"},
+		},
+		Identity: IdentityBinding{
+			Scopes: []string{"vault.read", "account.read"},
+			Roles:  []string{"service"},
+		},
+		Labels: map[string]string{"tier": "frontend"},
+		Resources: &Resources{
+			Requests: map[string]string{"cpu": "100m", "memory": "128Mi"},
+			Limits:   map[string]string{"memory": "256Mi"},
+		},
+		HealthCheck: &HealthCheck{HTTPPath: "/healthz", Port: 8080, InitialDelaySeconds: 5, PeriodSeconds: 10},
+	})
+
+	stack.Service("worker", Service{
+		Image:   "myapp/worker:1.4.2",
+		Command: []string{"/bin/worker"},
+		Args:    []string{"--queue", "jobs"},
+		Identity: IdentityBinding{
+			Scopes:          []string{"queue.consume"},
+			ServiceName:     "jobs-worker",
+			ServiceAccount:  "worker-sa",
+			RequireIdentity: true,
+			Audience:        "https://queue.aether.dev",
+		},
+		HealthCheck: &HealthCheck{Command: []string{"/bin/worker", "health"}, TimeoutSeconds: 2, FailureThreshold: 3},
+	})
+
+	stack.Service("docs", Service{
+		Image:       "myapp/docs:1.4.2",
+		Ports:       []Port{{Name: "http", ContainerPort: 3000}, {Name: "metrics", ContainerPort: 9090, Protocol: "TCP"}},
+		ServiceType: ServiceNodePort,
+		Annotations: map[string]string{"prometheus.io/scrape": "true"},
+	})
+
+	stack.Secret("api-database", Secret{StringData: map[string]string{"url": "postgres://api@db:5432/api"}})
+	stack.Secret("worker-queue", Secret{
+		StringData: map[string]string{"password": "changeme"},
+		Labels:     map[string]string{"rotation": "weekly"},
+	})
+
+	return stack
+}
+
+func TestRenderManifestsGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		stack *Stack
+	}{
+		{"webhook-injection", apiStack()},
+		{"inline-sidecar", apiStack(
+			WithInlineSidecar(),
+			WithAgentConfig(AgentConfig{
+				LogLevel:             "debug",
+				TokenRefreshInterval: "5m",
+				ExtraEnv:             map[string]string{"HTTP_PROXY": "http://proxy:3128"},
+				Resources:            &Resources{Requests: map[string]string{"cpu": "10m", "memory": "32Mi"}},
+			}),
+		)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.stack.RenderManifests()
+			if err != nil {
+				t.Fatalf("RenderManifests: %v", err)
+			}
+			assertGolden(t, tt.name+".yaml", got)
+
+			// The output must not depend on map iteration order
+			again, err := tt.stack.RenderManifests()
+			if err != nil {
+				t.Fatalf("RenderManifests: %v", err)
+			}
+			if !bytes.Equal(got, again) {
+				t.Fatal("rendering the same stack twice produced different output")
+			}
+		})
+	}
+}
+
+func TestGenerateManifestsWritesRenderedYAML(t *testing.T) {
+	stack := apiStack()
+	path := filepath.Join(t.TempDir(), "deploy", "api-services.yaml")
+
+	if err := stack.GenerateManifests(path); err != nil {
+		t.Fatalf("GenerateManifests: %v", err)
+	}
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "webhook-injection.yaml", written)
+}
+
+func TestWebhookConfigurationGolden(t *testing.T) {
+	config, err := testClient().WebhookConfiguration(WebhookOptions{
+		ServiceName: "identity-injector",
+		CABundle:    []byte("-----BEGIN CERTIFICATE-----\ntest\n-----END CERTIFICATE-----\n"),
+	})
+	if err != nil {
+		t.Fatalf("WebhookConfiguration: %v", err)
+	}
+	got, err := RenderYAML(config)
+	if err != nil {
+		t.Fatalf("RenderYAML: %v", err)
+	}
+	assertGolden(t, "webhook-configuration.yaml", got)
+}
+
+func TestRenderManifestsRejectsInvalidStack(t *testing.T) {
+	stack := testClient().NewStack("api-services")
+	stack.Service("api", Service{
+		Image:             "myapp/api:1.4.2",
+		SecretEnvironment: map[string]SecretKeyRef{"DATABASE_URL": {Name: "api-database"}},
+	})
+
+	_, err := stack.RenderManifests()
+	var validationErr ValidationError
+	if !IsGenerationError(err) || !errors.As(err, &validationErr) {
+		t.Fatalf("RenderManifests = %v, want a wrapped validation error", err)
+	}
+}
+
+// assertGolden compares output with testdata/name, or rewrites the file with -update
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run go test -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s (run go test -update after an intended change)\n--- got\n%s\n--- want\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,120 @@
+package k8s
+
+import "strings"
+
+// IdentityBinding defines identity requirements for a Kubernetes workload
+type IdentityBinding struct {
+	// Scopes are the OAuth2 scopes required by this service
+	Scopes []string
+
+	// Roles are the RBAC roles assigned to this service
+	Roles []string
+
+	// ServiceName overrides the service identifier
+	// If empty, the service name from the stack is used
+	ServiceName string
+
+	// ServiceAccount overrides the name of the generated service account
+	// If empty, the service name from the stack is used
+	ServiceAccount string
+
+	// RequireIdentity indicates if identity is mandatory
+	// When true, the pod will fail to start without identity
+	RequireIdentity bool
+
+	// Audience specifies the intended audience for tokens
+	Audience string
+}
+
+// Validate validates the identity binding
+func (ib IdentityBinding) Validate() error {
+	// Identity is optional, but if specified, must be valid
+	if !ib.HasRequirements() {
+		return nil
+	}
+
+	// Scopes and roles cannot both be empty if identity is enabled
+	if len(ib.Scopes) == 0 && len(ib.Roles) == 0 {
+		return NewValidationError("identity binding must specify at least one scope or role")
+	}
+
+	// Annotations carry scopes and roles as comma separated lists
+	for _, value := range append(ib.GetScopes(), ib.GetRoles()...) {
+		if value == "" || strings.ContainsAny(value, ", ") {
+			return NewValidationError("identity scopes and roles must not be empty or contain commas or spaces")
+		}
+	}
+
+	return nil
+}
+
+// HasRequirements returns true if the service has any identity requirements
+func (ib IdentityBinding) HasRequirements() bool {
+	return len(ib.Scopes) > 0 || len(ib.Roles) > 0 || ib.RequireIdentity
+}
+
+// GetScopes returns the required scopes
+func (ib IdentityBinding) GetScopes() []string {
+	if ib.Scopes == nil {
+		return []string{}
+	}
+	return append([]string{}, ib.Scopes...)
+}
+
+// GetRoles returns the assigned roles
+func (ib IdentityBinding) GetRoles() []string {
+	if ib.Roles == nil {
+		return []string{}
+	}
+	return append([]string{}, ib.Roles...)
+}
+
+// Annotations returns the identity annotations of a workload
+func (ib IdentityBinding) Annotations(serviceName string) map[string]string {
+	annotations := make(map[string]string)
+
+	if !ib.HasRequirements() {
+		return annotations
+	}
+
+	annotations[AnnotationService] = ib.ServiceName
+	if ib.ServiceName == "" {
+		annotations[AnnotationService] = serviceName
+	}
+	if len(ib.Scopes) > 0 {
+		annotations[AnnotationScopes] = strings.Join(ib.Scopes, ",")
+	}
+	if len(ib.Roles) > 0 {
+		annotations[AnnotationRoles] = strings.Join(ib.Roles, ",")
+	}
+	if ib.Audience != "" {
+		annotations[AnnotationAudience] = ib.Audience
+	}
+	if ib.RequireIdentity {
+		annotations[AnnotationRequired] = "true"
+	}
+
+	return annotations
+}
+
+// bindingFromAnnotations rebuilds an identity binding from workload annotations
+func bindingFromAnnotations(annotations map[string]string) IdentityBinding {
+	return IdentityBinding{
+		Scopes:          splitList(annotations[AnnotationScopes]),
+		Roles:           splitList(annotations[AnnotationRoles]),
+		ServiceName:     annotations[AnnotationService],
+		RequireIdentity: annotations[AnnotationRequired] == "true",
+		Audience:        annotations[AnnotationAudience],
+	}
+}
+
+// splitList splits a comma separated annotation value
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
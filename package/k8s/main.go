@@ -1 +1 @@
-package docker
+package k8s
@@ -0,0 +1,264 @@
+package k8s
+
+// Object is a Kubernetes resource produced by the SDK
+type Object interface {
+	GetKind() string
+	GetMetadata() ObjectMeta
+}
+
+// TypeMeta holds the API version and kind of a resource
+type TypeMeta struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+// GetKind returns the kind of the resource
+func (t TypeMeta) GetKind() string {
+	return t.Kind
+}
+
+// ObjectMeta holds the metadata of a resource
+type ObjectMeta struct {
+	Name        string            `json:"name,omitempty"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ServiceAccount is a core/v1 ServiceAccount
+type ServiceAccount struct {
+	TypeMeta
+	Metadata ObjectMeta `json:"metadata"`
+}
+
+// GetMetadata returns the metadata of the service account
+func (o ServiceAccount) GetMetadata() ObjectMeta {
+	return o.Metadata
+}
+
+// SecretManifest is a core/v1 Secret
+type SecretManifest struct {
+	TypeMeta
+	Metadata   ObjectMeta        `json:"metadata"`
+	Type       string            `json:"type"`
+	StringData map[string]string `json:"stringData,omitempty"`
+}
+
+// GetMetadata returns the metadata of the secret
+func (o SecretManifest) GetMetadata() ObjectMeta {
+	return o.Metadata
+}
+
+// Deployment is an apps/v1 Deployment
+type Deployment struct {
+	TypeMeta
+	Metadata ObjectMeta     `json:"metadata"`
+	Spec     DeploymentSpec `json:"spec"`
+}
+
+// GetMetadata returns the metadata of the deployment
+func (o Deployment) GetMetadata() ObjectMeta {
+	return o.Metadata
+}
+
+// DeploymentSpec is the specification of a deployment
+type DeploymentSpec struct {
+	Replicas *int32          `json:"replicas,omitempty"`
+	Selector LabelSelector   `json:"selector"`
+	Template PodTemplateSpec `json:"template"`
+}
+
+// LabelSelector selects resources by labels
+type LabelSelector struct {
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+}
+
+// PodTemplateSpec is the pod template of a workload
+type PodTemplateSpec struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     PodSpec    `json:"spec"`
+}
+
+// PodSpec is the specification of a pod
+type PodSpec struct {
+	ServiceAccountName string      `json:"serviceAccountName,omitempty"`
+	Containers         []Container `json:"containers"`
+	Volumes            []Volume    `json:"volumes,omitempty"`
+}
+
+// Container is a container of a pod
+type Container struct {
+	Name           string                `json:"name"`
+	Image          string                `json:"image"`
+	Command        []string              `json:"command,omitempty"`
+	Args           []string              `json:"args,omitempty"`
+	WorkingDir     string                `json:"workingDir,omitempty"`
+	Ports          []ContainerPort       `json:"ports,omitempty"`
+	Env            []EnvVar              `json:"env,omitempty"`
+	Resources      *ResourceRequirements `json:"resources,omitempty"`
+	VolumeMounts   []VolumeMount         `json:"volumeMounts,omitempty"`
+	ReadinessProbe *Probe                `json:"readinessProbe,omitempty"`
+	LivenessProbe  *Probe                `json:"livenessProbe,omitempty"`
+}
+
+// ContainerPort is a port exposed by a container
+type ContainerPort struct {
+	Name          string `json:"name,omitempty"`
+	ContainerPort int32  `json:"containerPort"`
+	Protocol      string `json:"protocol,omitempty"`
+}
+
+// EnvVar is an environment variable of a container
+type EnvVar struct {
+	Name      string        `json:"name"`
+	Value     string        `json:"value,omitempty"`
+	ValueFrom *EnvVarSource `json:"valueFrom,omitempty"`
+}
+
+// EnvVarSource is the source of an environment variable
+type EnvVarSource struct {
+	SecretKeyRef *SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+// SecretKeySelector selects a key of a secret
+type SecretKeySelector struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// ResourceRequirements are the compute resources of a container
+type ResourceRequirements struct {
+	Requests map[string]string `json:"requests,omitempty"`
+	Limits   map[string]string `json:"limits,omitempty"`
+}
+
+// VolumeMount mounts a volume into a container
+type VolumeMount struct {
+	Name      string `json:"name"`
+	MountPath string `json:"mountPath"`
+	ReadOnly  bool   `json:"readOnly,omitempty"`
+}
+
+// Volume is a volume of a pod
+type Volume struct {
+	Name      string                 `json:"name"`
+	EmptyDir  *EmptyDirVolumeSource  `json:"emptyDir,omitempty"`
+	Projected *ProjectedVolumeSource `json:"projected,omitempty"`
+}
+
+// EmptyDirVolumeSource is a scratch volume shared by the containers of a pod
+type EmptyDirVolumeSource struct {
+	Medium string `json:"medium,omitempty"`
+}
+
+// ProjectedVolumeSource projects several sources into one volume
+type ProjectedVolumeSource struct {
+	Sources []VolumeProjection `json:"sources"`
+}
+
+// VolumeProjection is a source of a projected volume
+type VolumeProjection struct {
+	ServiceAccountToken *ServiceAccountTokenProjection `json:"serviceAccountToken,omitempty"`
+}
+
+// ServiceAccountTokenProjection projects a bound service account token
+type ServiceAccountTokenProjection struct {
+	Audience          string `json:"audience,omitempty"`
+	ExpirationSeconds int64  `json:"expirationSeconds,omitempty"`
+	Path              string `json:"path"`
+}
+
+// Probe is a container health probe
+type Probe struct {
+	HTTPGet             *HTTPGetAction `json:"httpGet,omitempty"`
+	Exec                *ExecAction    `json:"exec,omitempty"`
+	InitialDelaySeconds int32          `json:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       int32          `json:"periodSeconds,omitempty"`
+	TimeoutSeconds      int32          `json:"timeoutSeconds,omitempty"`
+	FailureThreshold    int32          `json:"failureThreshold,omitempty"`
+}
+
+// HTTPGetAction probes a container over HTTP
+type HTTPGetAction struct {
+	Path string `json:"path"`
+	Port int32  `json:"port"`
+}
+
+// ExecAction probes a container by running a command
+type ExecAction struct {
+	Command []string `json:"command"`
+}
+
+// ServiceManifest is a core/v1 Service
+type ServiceManifest struct {
+	TypeMeta
+	Metadata ObjectMeta  `json:"metadata"`
+	Spec     ServiceSpec `json:"spec"`
+}
+
+// GetMetadata returns the metadata of the service
+func (o ServiceManifest) GetMetadata() ObjectMeta {
+	return o.Metadata
+}
+
+// ServiceSpec is the specification of a service
+type ServiceSpec struct {
+	Type     string            `json:"type,omitempty"`
+	Selector map[string]string `json:"selector"`
+	Ports    []ServicePort     `json:"ports"`
+}
+
+// ServicePort is a port exposed by a service
+type ServicePort struct {
+	Name       string `json:"name,omitempty"`
+	Port       int32  `json:"port"`
+	TargetPort int32  `json:"targetPort"`
+	Protocol   string `json:"protocol,omitempty"`
+}
+
+// MutatingWebhookConfiguration is an admissionregistration.k8s.io/v1 MutatingWebhookConfiguration
+type MutatingWebhookConfiguration struct {
+	TypeMeta
+	Metadata ObjectMeta        `json:"metadata"`
+	Webhooks []MutatingWebhook `json:"webhooks"`
+}
+
+// GetMetadata returns the metadata of the webhook configuration
+func (o MutatingWebhookConfiguration) GetMetadata() ObjectMeta {
+	return o.Metadata
+}
+
+// MutatingWebhook is a webhook of a MutatingWebhookConfiguration
+type MutatingWebhook struct {
+	Name                    string               `json:"name"`
+	AdmissionReviewVersions []string             `json:"admissionReviewVersions"`
+	SideEffects             string               `json:"sideEffects"`
+	FailurePolicy           string               `json:"failurePolicy"`
+	ReinvocationPolicy      string               `json:"reinvocationPolicy,omitempty"`
+	ClientConfig            WebhookClientConfig  `json:"clientConfig"`
+	Rules                   []RuleWithOperations `json:"rules"`
+	NamespaceSelector       *LabelSelector       `json:"namespaceSelector,omitempty"`
+	TimeoutSeconds          *int32               `json:"timeoutSeconds,omitempty"`
+}
+
+// WebhookClientConfig tells the API server how to reach the webhook
+type WebhookClientConfig struct {
+	Service  *ServiceReference `json:"service,omitempty"`
+	CABundle []byte            `json:"caBundle,omitempty"`
+}
+
+// ServiceReference references the service of the webhook
+type ServiceReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Path      string `json:"path,omitempty"`
+	Port      int32  `json:"port,omitempty"`
+}
+
+// RuleWithOperations selects the requests sent to the webhook
+type RuleWithOperations struct {
+	Operations  []string `json:"operations"`
+	APIGroups   []string `json:"apiGroups"`
+	APIVersions []string `json:"apiVersions"`
+	Resources   []string `json:"resources"`
+}
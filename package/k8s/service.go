@@ -0,0 +1,139 @@
+package k8s
+
+import "regexp"
+
+// Service represents a Kubernetes workload with identity requirements.
+// It is generated as a Deployment, a ServiceAccount and, when it exposes ports, a Service.
+type Service struct {
+	// Image is the container image for the service
+	Image string
+
+	// Replicas is the number of pods; defaults to 1
+	Replicas int32
+
+	// Ports exposed by the container and by the Kubernetes Service
+	Ports []Port
+
+	// ServiceType is the type of the Kubernetes Service; defaults to ClusterIP
+	ServiceType ServiceType
+
+	// Environment variables (non-sensitive only)
+	Environment map[string]string
+
+	// SecretEnvironment maps environment variables to keys of Kubernetes Secrets
+	SecretEnvironment map[string]SecretKeyRef
+
+	// Identity defines the identity requirements for this service
+	Identity IdentityBinding
+
+	// Labels for the workload, merged into the pod template
+	Labels map[string]string
+
+	// Annotations for the pod template
+	Annotations map[string]string
+
+	// Command override
+	Command []string
+
+	// Args override
+	Args []string
+
+	// Working directory
+	WorkingDir string
+
+	// Resources are the container requests and limits
+	Resources *Resources
+
+	// Health check configuration, used as readiness and liveness probe
+	HealthCheck *HealthCheck
+}
+
+// Port is a container port, exposed by the Kubernetes Service on ServicePort
+type Port struct {
+	Name          string
+	ContainerPort int32
+
+	// ServicePort defaults to ContainerPort
+	ServicePort int32
+
+	// Protocol defaults to TCP
+	Protocol string
+}
+
+// SecretKeyRef references a key of a Kubernetes Secret
+type SecretKeyRef struct {
+	Name string
+	Key  string
+}
+
+// Resources defines container requests and limits, keyed by resource name
+type Resources struct {
+	Requests map[string]string
+	Limits   map[string]string
+}
+
+// HealthCheck defines container health check settings.
+// HTTPPath and Port probe over HTTP; Command runs inside the container.
+type HealthCheck struct {
+	HTTPPath            string
+	Port                int32
+	Command             []string
+	InitialDelaySeconds int32
+	PeriodSeconds       int32
+	TimeoutSeconds      int32
+	FailureThreshold    int32
+}
+
+// dnsLabel matches valid Kubernetes resource names (RFC 1123 labels)
+var dnsLabel = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Validate validates the service configuration
+func (s Service) Validate() error {
+	if s.Image == "" {
+		return NewValidationError("service image is required")
+	}
+
+	if s.Replicas < 0 {
+		return NewValidationError("service replicas must not be negative")
+	}
+
+	for _, port := range s.Ports {
+		if port.ContainerPort <= 0 || port.ContainerPort > 65535 {
+			return NewValidationError("service container ports must be between 1 and 65535")
+		}
+	}
+
+	for env, ref := range s.SecretEnvironment {
+		if ref.Name == "" || ref.Key == "" {
+			return NewValidationError("secret environment variable " + env + " must reference a secret name and key")
+		}
+	}
+
+	if s.HealthCheck != nil && s.HealthCheck.HTTPPath == "" && len(s.HealthCheck.Command) == 0 {
+		return NewValidationError("health check requires an HTTP path or a command")
+	}
+
+	if s.Identity.ServiceAccount != "" && !dnsLabel.MatchString(s.Identity.ServiceAccount) {
+		return NewValidationError("identity service account must be a valid DNS label")
+	}
+
+	// Validate identity binding
+	if err := s.Identity.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// HasIdentity returns true if the service has identity requirements
+func (s Service) HasIdentity() bool {
+	return s.Identity.HasRequirements()
+}
+
+// ServiceAccountName returns the name of the service account of the workload
+func (s Service) ServiceAccountName(serviceName string) string {
+	if s.Identity.ServiceAccount != "" {
+		return s.Identity.ServiceAccount
+	}
+	return serviceName
+}
@@ -0,0 +1,174 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ClientAssertionTypeJWTBearer is the RFC 7523 client assertion type of service account tokens
+const ClientAssertionTypeJWTBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// WorkloadIdentityConfig configures the exchange of projected service account tokens
+// for Aether access tokens (workload identity federation)
+type WorkloadIdentityConfig struct {
+	// IdentityEndpoint is the URL of the Aether Identity service
+	IdentityEndpoint string
+
+	// ClientID is the OAuth client bound to the service account
+	// If empty, Aether Identity resolves the client from the token subject
+	ClientID string
+
+	// TokenPath is the path of the projected service account token
+	// Defaults to DefaultTokenPath if empty
+	TokenPath string
+
+	// Scopes are the OAuth2 scopes requested for the access token
+	Scopes []string
+
+	// Audience specifies the intended audience for the access token
+	Audience string
+
+	// RefreshBefore is how long before expiry a cached token is renewed
+	// Defaults to one minute if zero
+	RefreshBefore time.Duration
+
+	// HTTPClient sends the token requests; defaults to a client with a 30s timeout
+	HTTPClient *http.Client
+}
+
+// Token is an Aether access token obtained through workload identity federation
+type Token struct {
+	AccessToken string
+	TokenType   string
+	Scope       string
+	ExpiresAt   time.Time
+}
+
+// Valid returns true if the token is set and does not expire within margin
+func (t *Token) Valid(margin time.Duration) bool {
+	return t != nil && t.AccessToken != "" && (t.ExpiresAt.IsZero() || time.Now().Add(margin).Before(t.ExpiresAt))
+}
+
+// WorkloadTokenSource exchanges the projected service account token of the pod for Aether
+// access tokens and caches them until they are about to expire
+type WorkloadTokenSource struct {
+	config WorkloadIdentityConfig
+	mu     sync.Mutex
+	token  *Token
+}
+
+// NewWorkloadTokenSource creates a token source for the given configuration
+func NewWorkloadTokenSource(config WorkloadIdentityConfig) *WorkloadTokenSource {
+	// Apply defaults
+	if config.TokenPath == "" {
+		config.TokenPath = DefaultTokenPath
+	}
+	if config.RefreshBefore == 0 {
+		config.RefreshBefore = time.Minute
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &WorkloadTokenSource{config: config}
+}
+
+// Token returns a cached access token, exchanging a new one when it is about to expire
+func (s *WorkloadTokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token.Valid(s.config.RefreshBefore) {
+		return s.token, nil
+	}
+
+	token, err := s.exchange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.token = token
+	return token, nil
+}
+
+// Exchange exchanges the current projected token for a new access token, bypassing the cache
+func (s *WorkloadTokenSource) Exchange(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, err := s.exchange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.token = token
+	return token, nil
+}
+
+// exchange sends the projected token as a client assertion to the token endpoint.
+// The file is read on every exchange because the kubelet rotates it.
+func (s *WorkloadTokenSource) exchange(ctx context.Context) (*Token, error) {
+	assertion, err := os.ReadFile(s.config.TokenPath)
+	if err != nil {
+		return nil, WrapError(err, "failed to read service account token %q", s.config.TokenPath)
+	}
+
+	form := url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_assertion_type": {ClientAssertionTypeJWTBearer},
+		"client_assertion":      {strings.TrimSpace(string(assertion))},
+	}
+	if s.config.ClientID != "" {
+		form.Set("client_id", s.config.ClientID)
+	}
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+	if s.config.Audience != "" {
+		form.Set("audience", s.config.Audience)
+	}
+
+	endpoint := strings.TrimSuffix(s.config.IdentityEndpoint, "/") + "/oauth/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, WrapError(err, "failed to create token request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, WrapError(err, "token request to %q failed", endpoint)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Scope            string `json:"scope"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, WrapError(err, "failed to decode token response")
+	}
+
+	if resp.StatusCode != http.StatusOK || body.AccessToken == "" {
+		return nil, ExchangeError{StatusCode: resp.StatusCode, Code: body.Error, Description: body.ErrorDescription}
+	}
+
+	token := &Token{
+		AccessToken: body.AccessToken,
+		TokenType:   body.TokenType,
+		Scope:       body.Scope,
+	}
+	if body.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
@@ -0,0 +1,84 @@
+package k8s
+
+// Scope represents an OAuth2 scope
+type Scope string
+
+// Common OAuth2 scopes for Aether Identity
+const (
+	ScopeVaultRead    Scope = "vault.read"
+	ScopeVaultWrite   Scope = "vault.write"
+	ScopeAccountRead  Scope = "account.read"
+	ScopeAccountWrite Scope = "account.write"
+	ScopeQueueConsume Scope = "queue.consume"
+	ScopeQueueProduce Scope = "queue.produce"
+	ScopeAdmin        Scope = "admin"
+)
+
+// String returns the scope as a string
+func (s Scope) String() string {
+	return string(s)
+}
+
+// Role represents an RBAC role
+type Role string
+
+// Common RBAC roles for Aether Identity
+const (
+	RoleAdmin     Role = "admin"
+	RoleOperator  Role = "operator"
+	RoleViewer    Role = "viewer"
+	RoleService   Role = "service"
+	RoleDeveloper Role = "developer"
+)
+
+// String returns the role as a string
+func (r Role) String() string {
+	return string(r)
+}
+
+// ServiceType represents Kubernetes Service types
+type ServiceType string
+
+const (
+	// ServiceClusterIP exposes the service on a cluster-internal IP
+	ServiceClusterIP ServiceType = "ClusterIP"
+
+	// ServiceNodePort exposes the service on each node's IP at a static port
+	ServiceNodePort ServiceType = "NodePort"
+
+	// ServiceLoadBalancer exposes the service through a cloud load balancer
+	ServiceLoadBalancer ServiceType = "LoadBalancer"
+)
+
+// Annotations and labels read by the admission webhook and the identity agent
+const (
+	// AnnotationInject requests the injection of the identity agent sidecar
+	AnnotationInject = "identity.aether.dev/inject"
+
+	// AnnotationInjected marks pods that already carry the identity agent
+	AnnotationInjected = "identity.aether.dev/injected"
+
+	// AnnotationService is the identity service name of the workload
+	AnnotationService = "identity.aether.dev/service"
+
+	// AnnotationScopes lists the required scopes, comma separated
+	AnnotationScopes = "identity.aether.dev/scopes"
+
+	// AnnotationRoles lists the assigned roles, comma separated
+	AnnotationRoles = "identity.aether.dev/roles"
+
+	// AnnotationAudience is the audience of the Aether access tokens
+	AnnotationAudience = "identity.aether.dev/audience"
+
+	// AnnotationRequired makes the workload fail without an identity
+	AnnotationRequired = "identity.aether.dev/required"
+
+	// AnnotationStack is the stack the workload belongs to
+	AnnotationStack = "identity.aether.dev/stack"
+
+	// AnnotationSubject is the federated subject of a service account
+	AnnotationSubject = "identity.aether.dev/subject"
+
+	// LabelInjection enables the admission webhook on a namespace when set to "enabled"
+	LabelInjection = "identity.aether.dev/injection"
+)
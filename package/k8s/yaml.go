@@ -0,0 +1,147 @@
+package k8s
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RenderYAML renders resources as multi-document YAML. Keys are sorted, so the output
+// is stable across runs and can be compared with golden files.
+func RenderYAML(objects ...Object) ([]byte, error) {
+	var sb strings.Builder
+
+	for _, obj := range objects {
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return nil, WrapError(err, "failed to marshal %s", obj.GetKind())
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var doc interface{}
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, WrapError(err, "failed to decode %s", obj.GetKind())
+		}
+
+		sb.WriteString("---\n")
+		writeYAML(&sb, doc, 0)
+	}
+
+	return []byte(sb.String()), nil
+}
+
+// writeYAML writes a decoded JSON value in block style at the given indentation
+func writeYAML(sb *strings.Builder, value interface{}, indent int) {
+	pad := strings.Repeat(" ", indent)
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			sb.WriteString(pad + formatYAMLScalar(k) + ":")
+			switch child := v[k].(type) {
+			case map[string]interface{}:
+				if len(child) == 0 {
+					sb.WriteString(" {}\n")
+					continue
+				}
+				sb.WriteString("\n")
+				writeYAML(sb, child, indent+2)
+			case []interface{}:
+				if len(child) == 0 {
+					sb.WriteString(" []\n")
+					continue
+				}
+				sb.WriteString("\n")
+				writeYAML(sb, child, indent)
+			default:
+				sb.WriteString(" " + formatYAMLValue(child) + "\n")
+			}
+		}
+
+	case []interface{}:
+		for _, item := range v {
+			switch child := item.(type) {
+			case map[string]interface{}:
+				if len(child) == 0 {
+					sb.WriteString(pad + "- {}\n")
+					continue
+				}
+				// The first key of a mapping shares the line of the dash
+				var nested strings.Builder
+				writeYAML(&nested, child, indent+2)
+				sb.WriteString(pad + "- " + strings.TrimPrefix(nested.String(), pad+"  "))
+			case []interface{}:
+				if len(child) == 0 {
+					sb.WriteString(pad + "- []\n")
+					continue
+				}
+				sb.WriteString(pad + "-\n")
+				writeYAML(sb, child, indent+2)
+			default:
+				sb.WriteString(pad + "- " + formatYAMLValue(child) + "\n")
+			}
+		}
+
+	default:
+		sb.WriteString(pad + formatYAMLValue(v) + "\n")
+	}
+}
+
+// formatYAMLValue formats a scalar JSON value
+func formatYAMLValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return formatYAMLScalar(v)
+	default:
+		return formatYAMLScalar(fmt.Sprint(v))
+	}
+}
+
+// formatYAMLScalar quotes a string when YAML would read it as another type or syntax
+func formatYAMLScalar(s string) string {
+	if needsQuoting(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// needsQuoting reports whether a plain YAML scalar would not round-trip as the string s
+func needsQuoting(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~":
+		return true
+	}
+
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0o") {
+		return true
+	}
+
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+
+	return strings.Contains(s, ": ") || strings.HasSuffix(s, ":") || strings.Contains(s, " #") || strings.ContainsAny(s, "\n\t\r\\")
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"runtime"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -48,6 +50,16 @@ func main() {
 
 	cfg := config.LoadConfig()
 
+	// Export des traces vers un collecteur OpenTelemetry, si OTEL_EXPORTER_OTLP_ENDPOINT est défini
+	if services.StartTracing(context.Background(), services.TracingConfig{
+		Endpoint:    cfg.OTLPEndpoint,
+		Headers:     services.ParseTracingHeaders(cfg.OTLPHeaders),
+		ServiceName: cfg.OTelServiceName,
+		SampleRatio: cfg.TraceSampleRatio,
+	}) {
+		fmt.Printf("\033[1;32m[✓] OpenTelemetry tracing exporting to %s\033[0m\n", cfg.OTLPEndpoint)
+	}
+
 	// Initialize database if DSN is provided
 	var dbService interfaces.IDatabaseService
 	var db *gorm.DB
@@ -128,6 +140,48 @@ func main() {
 		fmt.Printf("\033[1;33m[!] Warning: DATABASE_URL not set and USE_EMBEDDED_DB not enabled, running in database-less mode\033[0m\n")
 	}
 
+	if db != nil {
+		if err := services.NewPermissionService(db).EnsureDefaults(); err != nil {
+			fmt.Printf("\033[1;33m[!] Warning: Failed to ensure default permissions: %v\033[0m\n", err)
+		} else {
+			fmt.Printf("\033[1;32m[✓] Default roles and permissions validated\033[0m\n")
+		}
+
+		if err := services.NewActionService(db).EnsureDefaultTriggers(); err != nil {
+			fmt.Printf("\033[1;33m[!] Warning: Failed to ensure action triggers: %v\033[0m\n", err)
+		} else {
+			fmt.Printf("\033[1;32m[✓] Action triggers validated\033[0m\n")
+		}
+
+		// Les scripts des connexions de base de données personnalisées s'exécutent comme des actions
+		services.SetDatabaseScriptRunner(services.NewActionService(db))
+
+		// Traitement en arrière-plan du provisioning SCIM sortant
+		services.NewProvisioningService(db).Start(context.Background(), 15*time.Second)
+		fmt.Printf("\033[1;32m[✓] SCIM provisioning worker started\033[0m\n")
+
+		// Détection des agents hors ligne et expiration des commandes
+		services.NewAgentService(db).Start(context.Background(), 30*time.Second)
+		fmt.Printf("\033[1;32m[✓] Agent heartbeat monitor started\033[0m\n")
+
+		// Acheminement des logs et de l'audit vers les SIEM configurés
+		services.NewLogStreamService(db).Start(context.Background(), time.Minute)
+		fmt.Printf("\033[1;32m[✓] Log stream forwarder started\033[0m\n")
+
+		// Livraison des événements d'identité aux webhooks abonnés
+		services.NewWebhookService(db).Start(context.Background(), 15*time.Second)
+		fmt.Printf("\033[1;32m[✓] Webhook delivery worker started\033[0m\n")
+
+		// Agrégation des connexions, inscriptions et échecs en statistiques d'activité
+		services.NewActivityService(db).Start(context.Background(), 15*time.Minute)
+		fmt.Printf("\033[1;32m[✓] Activity aggregation worker started\033[0m\n")
+	}
+
+	// Sondes des dépendances en arrière-plan : les endpoints de santé lisent leur dernier résultat
+	services.DefaultHealthMonitor.ConfigureDefaults(db, cfg)
+	services.DefaultHealthMonitor.Start(context.Background(), time.Duration(cfg.HealthCheckInterval)*time.Second)
+	fmt.Printf("\033[1;32m[✓] Health probes started\033[0m\n")
+
 	router := gin.New()
 	router.Use(gin.Recovery())
 
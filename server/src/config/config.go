@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -17,6 +18,20 @@ type Config struct {
 	CORSAllowedOrigins    []string // Origines CORS autorisées
 	DefaultPostLoginPath  string   // Chemin par défaut après login
 	DefaultPostLogoutPath string   // Chemin par défaut après logout
+	ActionTimeoutMs       int      // Durée maximale d'exécution d'une action
+	ActionMemoryMB        int      // Mémoire (tas) maximale d'une action
+	ActionCPUSeconds      int      // Temps CPU maximal d'une action
+	ActionNodePath        string   // Binaire Node.js du runtime nodejs des actions
+	MetricsToken          string   // Jeton Bearer exigé par /metrics (vide : endpoint désactivé)
+	OTLPEndpoint          string   // Collecteur OTLP/HTTP des traces (vide : traçage désactivé)
+	OTLPHeaders           string   // En-têtes d'export OTLP, "clé=valeur,clé2=valeur2"
+	OTelServiceName       string   // Nom du service dans les traces
+	TraceSampleRatio      float64  // Part des nouvelles traces enregistrées, entre 0 et 1
+	HealthCheckInterval   int      // Intervalle des sondes de santé, en secondes
+	HealthSlowThresholdMs int      // Latence au-delà de laquelle une dépendance est signalée dégradée
+	SMTPHost              string   // Serveur SMTP sondé par les contrôles de santé (vide : non sondé)
+	SMTPPort              string   // Port du serveur SMTP
+	RedisURL              string   // URL Redis, vide si REDIS_ENABLED vaut false
 }
 
 // LoadConfig charge la configuration depuis les variables d'environnement
@@ -31,9 +46,43 @@ func LoadConfig() *Config {
 		CORSAllowedOrigins:    parseEnvList(getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000,http://localhost:8080")),
 		DefaultPostLoginPath:  getEnv("DEFAULT_POST_LOGIN_PATH", "/"),
 		DefaultPostLogoutPath: getEnv("DEFAULT_POST_LOGOUT_PATH", "/"),
+		ActionTimeoutMs:       getEnvAsInt("ACTION_TIMEOUT_MS", 5000),
+		ActionMemoryMB:        getEnvAsInt("ACTION_MEMORY_MB", 128),
+		ActionCPUSeconds:      getEnvAsInt("ACTION_CPU_SECONDS", 2),
+		ActionNodePath:        getEnv("ACTION_NODE_PATH", "node"),
+		MetricsToken:          getEnv("METRICS_TOKEN", ""),
+		OTLPEndpoint:          otlpTracesEndpoint(),
+		OTLPHeaders:           getEnv("OTEL_EXPORTER_OTLP_HEADERS", ""),
+		OTelServiceName:       getEnv("OTEL_SERVICE_NAME", "aether-identity"),
+		TraceSampleRatio:      getEnvAsFloat("OTEL_TRACES_SAMPLER_ARG", 1),
+		HealthCheckInterval:   getEnvAsPositiveInt("HEALTH_CHECK_INTERVAL", 30),
+		HealthSlowThresholdMs: getEnvAsPositiveInt("HEALTH_SLOW_THRESHOLD_MS", 1000),
+		SMTPHost:              getEnv("SMTP_HOST", ""),
+		SMTPPort:              getEnv("SMTP_PORT", "587"),
+		RedisURL:              redisURL(),
 	}
 }
 
+// redisURL lit REDIS_URL, sauf si Redis est explicitement désactivé par REDIS_ENABLED=false
+func redisURL() string {
+	if strings.EqualFold(os.Getenv("REDIS_ENABLED"), "false") {
+		return ""
+	}
+	return os.Getenv("REDIS_URL")
+}
+
+// otlpTracesEndpoint lit OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, ou dérive l'URL des traces de
+// OTEL_EXPORTER_OTLP_ENDPOINT comme le prévoit la spécification OpenTelemetry
+func otlpTracesEndpoint() string {
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); endpoint != "" {
+		return endpoint
+	}
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		return strings.TrimRight(endpoint, "/") + "/v1/traces"
+	}
+	return ""
+}
+
 // parseEnvList parse une liste de valeurs séparées par des virgules
 func parseEnvList(value string) []string {
 	if value == "" {
@@ -71,3 +120,20 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return value
 }
+
+// getEnvAsPositiveInt récupère un entier strictement positif, la valeur par défaut remplaçant zéro ou un nombre négatif
+func getEnvAsPositiveInt(key string, defaultValue int) int {
+	if value := getEnvAsInt(key, defaultValue); value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsFloat récupère une variable d'environnement en tant que nombre décimal
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -52,7 +52,7 @@ func LoadOAuthConfig() *OAuthConfig {
 		JWKSURL:            getEnv("OIDC_JWKS_URL", "/api/v1/oauth2/jwks"),
 		RevocationURL:      getEnv("OIDC_REVOCATION_URL", "/api/v1/oauth2/revoke"),
 		Scopes:             []string{"openid", "profile", "email", "api"},
-		GrantTypes:         []string{"authorization_code", "refresh_token", "password", "client_credentials"},
+		GrantTypes:         []string{"authorization_code", "refresh_token", "password", "client_credentials", "urn:ietf:params:oauth:grant-type:token-exchange"},
 		ResponseTypes:      []string{"code", "token", "id_token", "code token", "code id_token", "token id_token", "code token id_token"},
 		TokenEndpointAuth:  TokenEndpointAuthClientSecretBasic,
 		PKCEEnabled:        getEnvAsBool("OIDC_PKCE_ENABLED", true),
@@ -1,11 +1,14 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/skygenesisenterprise/aether-identity/server/src/models"
 	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+	"gorm.io/gorm"
 )
 
 func CreateAction(c *gin.Context) {
@@ -15,7 +18,7 @@ func CreateAction(c *gin.Context) {
 		return
 	}
 
-	actionService := services.NewActionService(services.DB)
+	actionService := services.NewActionService(requestDB(c))
 	if err := actionService.CreateAction(&action); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -26,7 +29,7 @@ func CreateAction(c *gin.Context) {
 
 func GetAction(c *gin.Context) {
 	id := c.Param("id")
-	actionService := services.NewActionService(services.DB)
+	actionService := services.NewActionService(requestDB(c))
 
 	action, err := actionService.GetAction(id)
 	if err != nil {
@@ -38,7 +41,7 @@ func GetAction(c *gin.Context) {
 }
 
 func ListActions(c *gin.Context) {
-	actionService := services.NewActionService(services.DB)
+	actionService := services.NewActionService(requestDB(c))
 
 	actions, err := actionService.ListActions()
 	if err != nil {
@@ -58,7 +61,7 @@ func UpdateAction(c *gin.Context) {
 	}
 
 	action.ID = id
-	actionService := services.NewActionService(services.DB)
+	actionService := services.NewActionService(requestDB(c))
 
 	if err := actionService.UpdateAction(&action); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -70,7 +73,7 @@ func UpdateAction(c *gin.Context) {
 
 func DeleteAction(c *gin.Context) {
 	id := c.Param("id")
-	actionService := services.NewActionService(services.DB)
+	actionService := services.NewActionService(requestDB(c))
 
 	if err := actionService.DeleteAction(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -81,7 +84,7 @@ func DeleteAction(c *gin.Context) {
 }
 
 func ListActionTriggers(c *gin.Context) {
-	actionService := services.NewActionService(services.DB)
+	actionService := services.NewActionService(requestDB(c))
 
 	triggers, err := actionService.ListActionTriggers()
 	if err != nil {
@@ -94,7 +97,7 @@ func ListActionTriggers(c *gin.Context) {
 
 func ListActionLogs(c *gin.Context) {
 	actionID := c.Param("id")
-	actionService := services.NewActionService(services.DB)
+	actionService := services.NewActionService(requestDB(c))
 
 	logs, err := actionService.GetActionLogsByAction(actionID)
 	if err != nil {
@@ -107,7 +110,7 @@ func ListActionLogs(c *gin.Context) {
 
 func GetActionDetails(c *gin.Context) {
 	id := c.Param("id")
-	actionService := services.NewActionService(services.DB)
+	actionService := services.NewActionService(requestDB(c))
 	action, err := actionService.GetAction(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Action not found"})
@@ -117,23 +120,175 @@ func GetActionDetails(c *gin.Context) {
 	c.JSON(http.StatusOK, action)
 }
 
+// ActionVersionRequest désigne une version d'action ; sans version, la dernière est utilisée
+type ActionVersionRequest struct {
+	Version *int `json:"version"`
+}
+
+// DeployAction déploie une version de l'action et l'épingle sur ses bindings
 func DeployAction(c *gin.Context) {
-	id := c.Param("id")
-	actionService := services.NewActionService(services.DB)
-	if err := actionService.DeployAction(id); err != nil {
+	var req ActionVersionRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	actionService := services.NewActionService(requestDB(c))
+	action, err := actionService.DeployAction(c.Param("id"), req.Version)
+	if err != nil {
+		actionVersionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, action)
+}
+
+// ListActionVersions liste les versions immuables d'une action
+func ListActionVersions(c *gin.Context) {
+	actionService := services.NewActionService(requestDB(c))
+	versions, err := actionService.ListActionVersions(c.Param("id"))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Action deployed"})
+	c.JSON(http.StatusOK, versions)
 }
 
+func GetActionVersion(c *gin.Context) {
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return
+	}
+
+	actionService := services.NewActionService(requestDB(c))
+	version, err := actionService.GetActionVersion(c.Param("id"), number)
+	if err != nil {
+		actionVersionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, version)
+}
+
+// DiffActionVersions compare deux versions (?from=1&to=2)
+func DiffActionVersions(c *gin.Context) {
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := strconv.Atoi(c.Query("to"))
+	if errFrom != nil || errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to versions are required"})
+		return
+	}
+
+	actionService := services.NewActionService(requestDB(c))
+	diff, err := actionService.DiffActionVersions(c.Param("id"), from, to)
+	if err != nil {
+		actionVersionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// StartActionRollout envoie un pourcentage des exécutions vers une nouvelle version
+func StartActionRollout(c *gin.Context) {
+	var req services.ActionRolloutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	actionService := services.NewActionService(requestDB(c))
+	action, err := actionService.StartRollout(c.Param("id"), req)
+	if err != nil {
+		actionVersionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, action)
+}
+
+// PromoteActionRollout déploie la version canary sur toutes les exécutions
+func PromoteActionRollout(c *gin.Context) {
+	actionService := services.NewActionService(requestDB(c))
+	action, err := actionService.PromoteRollout(c.Param("id"))
+	if err != nil {
+		actionVersionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, action)
+}
+
+// RollbackAction annule le déploiement progressif en cours ou redéploie une version antérieure
+func RollbackAction(c *gin.Context) {
+	var req ActionVersionRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	actionService := services.NewActionService(requestDB(c))
+	action, err := actionService.RollbackAction(c.Param("id"), req.Version)
+	if err != nil {
+		actionVersionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, action)
+}
+
+// actionVersionError traduit une erreur de versionnage ou de déploiement en réponse HTTP
+func actionVersionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Action or version not found"})
+	case errors.Is(err, services.ErrActionNoCode),
+		errors.Is(err, services.ErrActionRuntimeUnsupported),
+		errors.Is(err, services.ErrActionInvalidRollout):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrActionNotDeployed), errors.Is(err, services.ErrActionNoRollback):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// bindOptionalJSON lit un corps JSON facultatif
+func bindOptionalJSON(c *gin.Context, v interface{}) bool {
+	if c.Request.ContentLength == 0 {
+		return true
+	}
+	if err := c.ShouldBindJSON(v); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return false
+	}
+	return true
+}
+
+// TestAction exécute une version de l'action (?version=, la dernière par défaut) sur un événement
+// d'essai (par défaut post-login) et enregistre l'exécution
 func TestAction(c *gin.Context) {
 	id := c.Param("id")
-	actionService := services.NewActionService(services.DB)
-	result, err := actionService.TestAction(id)
+	event := newActionEvent(c, models.ActionTriggerPostLogin)
+	if !bindOptionalJSON(c, event) {
+		return
+	}
+	event.Secrets = nil
+
+	var version *int
+	if value := c.Query("version"); value != "" {
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+			return
+		}
+		version = &number
+	}
+
+	actionService := services.NewActionService(requestDB(c))
+	result, err := actionService.TestAction(c.Request.Context(), id, version, event)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		actionVersionError(c, err)
 		return
 	}
 
@@ -142,7 +297,7 @@ func TestAction(c *gin.Context) {
 
 func GetActionLogs(c *gin.Context) {
 	id := c.Param("id")
-	actionService := services.NewActionService(services.DB)
+	actionService := services.NewActionService(requestDB(c))
 	logs, err := actionService.GetActionLogsByAction(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -153,7 +308,7 @@ func GetActionLogs(c *gin.Context) {
 }
 
 func ListAvailableTriggers(c *gin.Context) {
-	actionService := services.NewActionService(services.DB)
+	actionService := services.NewActionService(requestDB(c))
 	triggers, err := actionService.ListActionTriggers()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -165,7 +320,7 @@ func ListAvailableTriggers(c *gin.Context) {
 
 func ListActionsForTrigger(c *gin.Context) {
 	triggerID := c.Param("triggerId")
-	actionService := services.NewActionService(services.DB)
+	actionService := services.NewActionService(requestDB(c))
 	actions, err := actionService.ListActionsForTrigger(triggerID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -175,8 +330,122 @@ func ListActionsForTrigger(c *gin.Context) {
 	c.JSON(http.StatusOK, actions)
 }
 
+func ListTriggerBindings(c *gin.Context) {
+	actionService := services.NewActionService(requestDB(c))
+	bindings, err := actionService.ListTriggerBindings(c.Param("triggerId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, bindings)
+}
+
+// BindActionRequest représente le binding d'une action à un déclencheur
+type BindActionRequest struct {
+	ActionID  string `json:"actionId" binding:"required"`
+	Order     int    `json:"order"`
+	IsEnabled *bool  `json:"isEnabled"`
+}
+
+// BindAction lie une action à un déclencheur à la position donnée
+func BindAction(c *gin.Context) {
+	var req BindActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	binding := models.ActionTriggerBinding{
+		ActionID:  req.ActionID,
+		TriggerID: c.Param("triggerId"),
+		Order:     req.Order,
+		IsEnabled: req.IsEnabled == nil || *req.IsEnabled,
+	}
+	actionService := services.NewActionService(requestDB(c))
+	if err := actionService.BindAction(&binding); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Action or trigger not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, binding)
+}
+
+func UnbindAction(c *gin.Context) {
+	actionService := services.NewActionService(requestDB(c))
+	if err := actionService.UnbindAction(c.Param("triggerId"), c.Param("bindingId")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Binding not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// abortOnActionResult interrompt la requête si une action a échoué, refusé ou redirigé le flux
+func abortOnActionResult(c *gin.Context, result *services.ActionResult, err error) bool {
+	switch {
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "An action failed to run",
+		})
+	case result.Denied:
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   actionDenyReason(result),
+		})
+	case result.RedirectURL != "":
+		c.JSON(http.StatusForbidden, gin.H{
+			"success":  false,
+			"error":    "redirect_required",
+			"redirect": result.RedirectURL,
+		})
+	default:
+		return false
+	}
+	return true
+}
+
+// actionDenyReason retourne le motif d'un refus, ou un message générique si l'action n'en donne pas
+func actionDenyReason(result *services.ActionResult) string {
+	if result.DenyReason == "" {
+		return "Access denied"
+	}
+	return result.DenyReason
+}
+
+// runPostLoginActions exécute les actions post-login avant la délivrance de jetons à un utilisateur,
+// quel que soit le flux (mot de passe, OAuth, TOTP, fournisseur externe, rafraîchissement)
+func runPostLoginActions(c *gin.Context, user *models.User, client *services.ActionEventClient, scopes []string) (*services.ActionResult, error) {
+	event := newActionEvent(c, models.ActionTriggerPostLogin)
+	event.User = services.NewActionEventUser(user)
+	event.Client = client
+	event.Scopes = scopes
+	return services.NewActionService(requestDB(c)).ExecuteTrigger(c.Request.Context(), event)
+}
+
+// newActionEvent prépare l'événement d'un déclencheur à partir de la requête HTTP
+func newActionEvent(c *gin.Context, trigger string) *services.ActionEvent {
+	return &services.ActionEvent{
+		Trigger: trigger,
+		Request: &services.ActionEventRequest{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			Hostname:  c.Request.Host,
+		},
+	}
+}
+
 func ListActionLibrary(c *gin.Context) {
-	actionService := services.NewActionService(services.DB)
+	actionService := services.NewActionService(requestDB(c))
 	library, err := actionService.ListActionLibrary()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -193,7 +462,7 @@ func AddActionToLibrary(c *gin.Context) {
 		return
 	}
 
-	actionService := services.NewActionService(services.DB)
+	actionService := services.NewActionService(requestDB(c))
 	if err := actionService.AddActionToLibrary(&action); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -204,7 +473,7 @@ func AddActionToLibrary(c *gin.Context) {
 
 func RemoveActionFromLibrary(c *gin.Context) {
 	id := c.Param("id")
-	actionService := services.NewActionService(services.DB)
+	actionService := services.NewActionService(requestDB(c))
 	if err := actionService.RemoveActionFromLibrary(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -214,7 +483,7 @@ func RemoveActionFromLibrary(c *gin.Context) {
 }
 
 func ListFormActions(c *gin.Context) {
-	actionService := services.NewActionService(services.DB)
+	actionService := services.NewActionService(requestDB(c))
 	actions, err := actionService.ListFormActions()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -231,7 +500,7 @@ func CreateFormAction(c *gin.Context) {
 		return
 	}
 
-	actionService := services.NewActionService(services.DB)
+	actionService := services.NewActionService(requestDB(c))
 	if err := actionService.CreateFormAction(&action); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
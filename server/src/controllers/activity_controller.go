@@ -2,6 +2,9 @@ package controllers
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/skygenesisenterprise/aether-identity/server/src/services"
@@ -9,7 +12,7 @@ import (
 
 func GetActivityStats(c *gin.Context) {
 	date := c.Query("date")
-	activityService := services.NewActivityService(services.DB)
+	activityService := services.NewActivityService(requestDB(c))
 
 	activity, err := activityService.GetActivity(date)
 	if err != nil {
@@ -20,56 +23,115 @@ func GetActivityStats(c *gin.Context) {
 	c.JSON(http.StatusOK, activity)
 }
 
+// parseActivityQuery lit from et to (AAAA-MM-JJ, inclus), ou timeRange (7d, 30d, 90d…) se terminant
+// aujourd'hui, et tenant_id. Sans bornes, la période couvre defaultDays jours. Répond 400 si la période est invalide.
+// tenant_id n'est libre qu'avec tenant:admin : les autres appelants lisent leur propre tenant (l'instance pour
+// un utilisateur sans tenant) et reçoivent 403 s'ils en demandent un autre.
+func parseActivityQuery(c *gin.Context, defaultDays int) (services.ActivityQuery, bool) {
+	today := time.Now().UTC()
+	query := services.ActivityQuery{From: today.AddDate(0, 0, 1-defaultDays), To: today, TenantID: c.Query("tenant_id")}
+
+	if timeRange := c.Query("timeRange"); timeRange != "" {
+		days, err := strconv.Atoi(strings.TrimSuffix(timeRange, "d"))
+		if err != nil || !strings.HasSuffix(timeRange, "d") || days < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "timeRange must be a number of days, such as 30d"})
+			return query, false
+		}
+		query.From = today.AddDate(0, 0, 1-days)
+	}
+	for param, bound := range map[string]*time.Time{"from": &query.From, "to": &query.To} {
+		if value := c.Query(param); value != "" {
+			t, err := time.Parse(time.DateOnly, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": param + " must be a date (YYYY-MM-DD)"})
+				return query, false
+			}
+			*bound = t
+		}
+	}
+	if err := query.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return query, false
+	}
+
+	tenantID, global, err := services.NewPermissionService(requestDB(c)).TenantScope(c.GetString("user_id"))
+	if err != nil || (!global && query.TenantID != "" && query.TenantID != tenantID) {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "Forbidden"})
+		return query, false
+	}
+	if !global {
+		query.TenantID = tenantID
+	}
+	return query, true
+}
+
 func ListActivities(c *gin.Context) {
-	activityService := services.NewActivityService(services.DB)
+	query, ok := parseActivityQuery(c, 30)
+	if !ok {
+		return
+	}
+	activityService := services.NewActivityService(requestDB(c))
 
-	activities, err := activityService.ListActivities(30)
+	activities, err := activityService.ListActivities(query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, activities)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": activities})
 }
 
 func ListDAU(c *gin.Context) {
-	activityService := services.NewActivityService(services.DB)
+	query, ok := parseActivityQuery(c, 30)
+	if !ok {
+		return
+	}
+	activityService := services.NewActivityService(requestDB(c))
 
-	dauList, err := activityService.ListDAU(30)
+	dauList, err := activityService.ListDAU(query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, dauList)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": dauList})
 }
 
+// ListRetentions couvre par défaut les cohortes des douze derniers mois
 func ListRetentions(c *gin.Context) {
-	activityService := services.NewActivityService(services.DB)
+	query, ok := parseActivityQuery(c, 365)
+	if !ok {
+		return
+	}
+	activityService := services.NewActivityService(requestDB(c))
 
-	retentions, err := activityService.ListRetentions()
+	retentions, err := activityService.ListRetentions(query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, retentions)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": retentions})
 }
 
 func ListSignups(c *gin.Context) {
-	activityService := services.NewActivityService(services.DB)
+	query, ok := parseActivityQuery(c, 30)
+	if !ok {
+		return
+	}
+	activityService := services.NewActivityService(requestDB(c))
 
-	signups, err := activityService.ListSignups(30)
+	signups, err := activityService.ListSignups(query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, signups)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": signups})
 }
 
 func GetActivityOverview(c *gin.Context) {
-	GetActivityStats(c)
+	ListActivities(c)
 }
 
 func GetDailyActiveUsers(c *gin.Context) {
@@ -85,14 +147,44 @@ func GetSignupData(c *gin.Context) {
 }
 
 func GetFailedLoginData(c *gin.Context) {
-	activityService := services.NewActivityService(services.DB)
-	data, err := activityService.GetFailedLogins()
+	query, ok := parseActivityQuery(c, 30)
+	if !ok {
+		return
+	}
+	activityService := services.NewActivityService(requestDB(c))
+
+	data, err := activityService.ListFailedLogins(query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": data})
+}
+
+// RefreshActivity recalcule sans attendre le prochain passage planifié les statistiques de la période
+// (from et to, ou timeRange), pour toute l'instance et chaque tenant, puis les cohortes concernées
+func RefreshActivity(c *gin.Context) {
+	query, ok := parseActivityQuery(c, 1)
+	if !ok {
+		return
+	}
+	activityService := services.NewActivityService(requestDB(c))
+
+	if err := activityService.Materialize(query.From, query.To); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if err := activityService.MaterializeRetention(query.From, query.To); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, data)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"from":    query.From.Format(time.DateOnly),
+		"to":      query.To.Format(time.DateOnly),
+	})
 }
 
 func GetDashboardStats(c *gin.Context) {
@@ -100,7 +192,7 @@ func GetDashboardStats(c *gin.Context) {
 }
 
 func GetUserStats(c *gin.Context) {
-	activityService := services.NewActivityService(services.DB)
+	activityService := services.NewActivityService(requestDB(c))
 	stats, err := activityService.GetUserStats()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -111,7 +203,7 @@ func GetUserStats(c *gin.Context) {
 }
 
 func GetSessionStats(c *gin.Context) {
-	activityService := services.NewActivityService(services.DB)
+	activityService := services.NewActivityService(requestDB(c))
 	stats, err := activityService.GetSessionStats()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -122,7 +214,7 @@ func GetSessionStats(c *gin.Context) {
 }
 
 func GetLoginStats(c *gin.Context) {
-	activityService := services.NewActivityService(services.DB)
+	activityService := services.NewActivityService(requestDB(c))
 	stats, err := activityService.GetLoginStats()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -1,11 +1,15 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/skygenesisenterprise/aether-identity/server/src/models"
 	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+	"gorm.io/gorm"
 )
 
 func CreateAgent(c *gin.Context) {
@@ -15,7 +19,7 @@ func CreateAgent(c *gin.Context) {
 		return
 	}
 
-	agentService := services.NewAgentService(services.DB)
+	agentService := services.NewAgentService(requestDB(c))
 	if err := agentService.CreateAgent(&agent); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -26,7 +30,7 @@ func CreateAgent(c *gin.Context) {
 
 func GetAgent(c *gin.Context) {
 	id := c.Param("id")
-	agentService := services.NewAgentService(services.DB)
+	agentService := services.NewAgentService(requestDB(c))
 
 	agent, err := agentService.GetAgent(id)
 	if err != nil {
@@ -38,7 +42,7 @@ func GetAgent(c *gin.Context) {
 }
 
 func ListAgents(c *gin.Context) {
-	agentService := services.NewAgentService(services.DB)
+	agentService := services.NewAgentService(requestDB(c))
 
 	agents, err := agentService.ListAgents()
 	if err != nil {
@@ -58,7 +62,7 @@ func UpdateAgent(c *gin.Context) {
 	}
 
 	agent.ID = id
-	agentService := services.NewAgentService(services.DB)
+	agentService := services.NewAgentService(requestDB(c))
 
 	if err := agentService.UpdateAgent(&agent); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -70,7 +74,7 @@ func UpdateAgent(c *gin.Context) {
 
 func DeleteAgent(c *gin.Context) {
 	id := c.Param("id")
-	agentService := services.NewAgentService(services.DB)
+	agentService := services.NewAgentService(requestDB(c))
 
 	if err := agentService.DeleteAgent(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -90,7 +94,7 @@ func GetAgentDetails(c *gin.Context) {
 
 func GetAgentStatus(c *gin.Context) {
 	id := c.Param("id")
-	agentService := services.NewAgentService(services.DB)
+	agentService := services.NewAgentService(requestDB(c))
 	status, err := agentService.GetAgentStatus(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Agent status not found"})
@@ -101,12 +105,199 @@ func GetAgentStatus(c *gin.Context) {
 }
 
 func RestartAgent(c *gin.Context) {
-	id := c.Param("id")
-	agentService := services.NewAgentService(services.DB)
-	if err := agentService.RestartAgent(id); err != nil {
+	agentService := services.NewAgentService(requestDB(c))
+	command, err := agentService.RestartAgent(c.Param("id"), currentUserID(c))
+	if err != nil {
+		agentCommandError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, command)
+}
+
+// CreateAgentEnrollmentTokenRequest représente une demande de jeton d'enrôlement
+type CreateAgentEnrollmentTokenRequest struct {
+	Name       string  `json:"name" binding:"required"`
+	Type       string  `json:"type" binding:"required"`
+	TenantID   *string `json:"tenantId"`
+	TTLSeconds int     `json:"ttlSeconds"`
+}
+
+// CreateAgentEnrollmentToken crée un jeton d'enrôlement à usage unique (affiché une seule fois)
+func CreateAgentEnrollmentToken(c *gin.Context) {
+	var req CreateAgentEnrollmentTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	agentService := services.NewAgentService(requestDB(c))
+	token, enrollment, err := agentService.CreateEnrollmentToken(req.Name, req.Type, req.TenantID, time.Duration(req.TTLSeconds)*time.Second, currentUserID(c))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Agent restarted"})
+	c.JSON(http.StatusCreated, gin.H{
+		"token":     token,
+		"id":        enrollment.ID,
+		"name":      enrollment.Name,
+		"type":      enrollment.Type,
+		"expiresAt": enrollment.ExpiresAt,
+	})
+}
+
+// IssueAgentCommandRequest représente une commande à envoyer à un agent
+type IssueAgentCommandRequest struct {
+	Type    models.AgentCommandType `json:"type" binding:"required"`
+	Payload interface{}             `json:"payload"`
+}
+
+// IssueAgentCommand met en file une commande restart, config-reload ou token-rotation
+func IssueAgentCommand(c *gin.Context) {
+	var req IssueAgentCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	agentService := services.NewAgentService(requestDB(c))
+	command, err := agentService.IssueCommand(c.Param("id"), req.Type, req.Payload, currentUserID(c))
+	if err != nil {
+		agentCommandError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, command)
+}
+
+// ListAgentCommands liste les commandes récentes d'un agent
+func ListAgentCommands(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	agentService := services.NewAgentService(requestDB(c))
+	commands, err := agentService.ListCommands(c.Param("id"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, commands)
+}
+
+// EnrollAgent enrôle un agent avec un jeton à usage unique et retourne son credential
+func EnrollAgent(c *gin.Context) {
+	var req services.AgentEnrollmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	agentService := services.NewAgentService(requestDB(c))
+	agent, credential, err := agentService.Enroll(req)
+	if err != nil {
+		if errors.Is(err, services.ErrAgentInvalidEnrollmentToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"agent":                    agent,
+		"credential":               credential,
+		"heartbeatIntervalSeconds": int(services.AgentHeartbeatInterval.Seconds()),
+	})
+}
+
+// AgentHeartbeat met à jour l'état de l'agent authentifié
+func AgentHeartbeat(c *gin.Context) {
+	var req services.AgentHeartbeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	agent := c.MustGet("agent").(*models.Agent)
+	agentService := services.NewAgentService(requestDB(c))
+	if err := agentService.Heartbeat(agent, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":                   "ok",
+		"heartbeatIntervalSeconds": int(services.AgentHeartbeatInterval.Seconds()),
+	})
+}
+
+// PollAgentCommands distribue les commandes en attente (long-poll, ?wait=30s)
+func PollAgentCommands(c *gin.Context) {
+	wait, err := time.ParseDuration(c.DefaultQuery("wait", "30s"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wait duration"})
+		return
+	}
+
+	agent := c.MustGet("agent").(*models.Agent)
+	agentService := services.NewAgentService(requestDB(c))
+	commands, err := agentService.PollCommands(c.Request.Context(), agent, wait)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"commands": commands})
+}
+
+// AcknowledgeAgentCommandRequest représente le résultat d'exécution d'une commande
+type AcknowledgeAgentCommandRequest struct {
+	Success bool    `json:"success"`
+	Message *string `json:"message"`
+}
+
+// AcknowledgeAgentCommand enregistre l'acquittement d'une commande par l'agent
+func AcknowledgeAgentCommand(c *gin.Context) {
+	var req AcknowledgeAgentCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	agent := c.MustGet("agent").(*models.Agent)
+	agentService := services.NewAgentService(requestDB(c))
+	command, err := agentService.AcknowledgeCommand(agent, c.Param("commandId"), req.Success, req.Message)
+	if err != nil {
+		agentCommandError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, command)
+}
+
+// agentCommandError traduit une erreur de commande d'agent en réponse HTTP
+func agentCommandError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Agent or command not found"})
+	case errors.Is(err, services.ErrAgentInvalidCommand):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAgentCommandNotDelivered):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// currentUserID retourne l'identifiant de l'utilisateur authentifié, s'il existe
+func currentUserID(c *gin.Context) *string {
+	if id := c.GetString("user_id"); id != "" {
+		return &id
+	}
+	return nil
 }
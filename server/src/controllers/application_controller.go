@@ -16,7 +16,7 @@ func CreateApplication(c *gin.Context) {
 		return
 	}
 
-	appService := services.NewApplicationService(services.DB)
+	appService := services.NewApplicationService(requestDB(c))
 	if err := appService.Create(&app); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -27,7 +27,7 @@ func CreateApplication(c *gin.Context) {
 
 func GetApplication(c *gin.Context) {
 	id := c.Param("id")
-	appService := services.NewApplicationService(services.DB)
+	appService := services.NewApplicationService(requestDB(c))
 
 	app, err := appService.GetByID(id)
 	if err != nil {
@@ -39,7 +39,7 @@ func GetApplication(c *gin.Context) {
 }
 
 func ListApplications(c *gin.Context) {
-	appService := services.NewApplicationService(services.DB)
+	appService := services.NewApplicationService(requestDB(c))
 
 	apps, _, err := appService.List(1, 100)
 	if err != nil {
@@ -58,7 +58,7 @@ func UpdateApplication(c *gin.Context) {
 		return
 	}
 
-	appService := services.NewApplicationService(services.DB)
+	appService := services.NewApplicationService(requestDB(c))
 
 	if err := appService.Update(id, &app); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -70,7 +70,7 @@ func UpdateApplication(c *gin.Context) {
 
 func DeleteApplication(c *gin.Context) {
 	id := c.Param("id")
-	appService := services.NewApplicationService(services.DB)
+	appService := services.NewApplicationService(requestDB(c))
 
 	if err := appService.Delete(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -82,7 +82,7 @@ func DeleteApplication(c *gin.Context) {
 
 func GetApplicationCredentials(c *gin.Context) {
 	id := c.Param("id")
-	appService := services.NewApplicationService(services.DB)
+	appService := services.NewApplicationService(requestDB(c))
 
 	app, err := appService.GetByID(id)
 	if err != nil {
@@ -98,7 +98,7 @@ func GetApplicationCredentials(c *gin.Context) {
 
 func RotateApplicationSecret(c *gin.Context) {
 	id := c.Param("id")
-	appService := services.NewApplicationService(services.DB)
+	appService := services.NewApplicationService(requestDB(c))
 
 	newSecret, err := appService.RotateSecret(id)
 	if err != nil {
@@ -111,7 +111,7 @@ func RotateApplicationSecret(c *gin.Context) {
 
 func GetApplicationStats(c *gin.Context) {
 	id := c.Param("id")
-	appService := services.NewApplicationService(services.DB)
+	appService := services.NewApplicationService(requestDB(c))
 
 	stats, err := appService.GetStats(id, time.Now().AddDate(0, -1, 0), time.Now())
 	if err != nil {
@@ -123,7 +123,7 @@ func GetApplicationStats(c *gin.Context) {
 }
 
 func ListApiApplications(c *gin.Context) {
-	appService := services.NewApplicationService(services.DB)
+	appService := services.NewApplicationService(requestDB(c))
 	apps, _, err := appService.ListByType(models.ApplicationTypeAPI, 1, 100)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -133,7 +133,7 @@ func ListApiApplications(c *gin.Context) {
 }
 
 func ListExternalApplications(c *gin.Context) {
-	appService := services.NewApplicationService(services.DB)
+	appService := services.NewApplicationService(requestDB(c))
 	apps, _, err := appService.ListByType(models.ApplicationTypeExternal, 1, 100)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
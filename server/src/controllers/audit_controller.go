@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+	"gorm.io/gorm"
+)
+
+// auditDetails retourne le détail d'audit de la requête en cours, créé au besoin.
+// Le middleware AuditTrail le complète et l'enregistre une fois le handler terminé.
+func auditDetails(c *gin.Context) *services.AuditDetails {
+	if details, ok := c.Value(services.AuditContextKey).(*services.AuditDetails); ok {
+		return details
+	}
+	details := &services.AuditDetails{}
+	c.Set(services.AuditContextKey, details)
+	return details
+}
+
+// auditTarget renseigne la cible de l'opération et ses états avant et après
+func auditTarget(c *gin.Context, targetType, targetID string, before, after interface{}) {
+	details := auditDetails(c)
+	details.TargetType = targetType
+	details.TargetID = targetID
+	details.Before = before
+	details.After = after
+}
+
+// ListAuditEntries liste le journal d'audit
+func ListAuditEntries(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	filter, err := auditFilterFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, total, err := services.NewAuditService(requestDB(c)).ListAuditEntries(filter, limit, (page-1)*limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list audit entries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// ExportAuditEntries exporte en flux (NDJSON, CSV ou CEF), dans l'ordre de la chaîne, les entrées filtrées
+func ExportAuditEntries(c *gin.Context) {
+	filter, err := auditFilterFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	auditService := services.NewAuditService(requestDB(c))
+	streamExport(c, "audit", func(write func(services.ExportRecord) error, flush func() error) error {
+		return auditService.StreamAuditEntries(filter, func(entries []models.AuditEntry) error {
+			for i := range entries {
+				if err := write(services.AuditRecord(&entries[i])); err != nil {
+					return err
+				}
+			}
+			return flush()
+		})
+	})
+}
+
+// GetAuditEntry renvoie une entrée du journal d'audit
+func GetAuditEntry(c *gin.Context) {
+	entry, err := services.NewAuditService(requestDB(c)).GetAuditEntry(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Audit entry not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get audit entry"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// VerifyAuditChain recalcule la chaîne d'empreintes du journal d'audit
+func VerifyAuditChain(c *gin.Context) {
+	result, err := services.NewAuditService(requestDB(c)).VerifyChain()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify audit chain"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// auditFilterFromRequest lit les filtres du journal d'audit
+func auditFilterFromRequest(c *gin.Context) (services.AuditFilter, error) {
+	filter := services.AuditFilter{
+		Action:     c.Query("action"),
+		ActorID:    c.Query("actor_id"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		RequestID:  c.Query("request_id"),
+		Source:     c.Query("source"),
+	}
+	for param, bound := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := c.Query(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s date, expected RFC 3339", param)
+			}
+			*bound = &t
+		}
+	}
+	return filter, nil
+}
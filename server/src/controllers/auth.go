@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/skygenesisenterprise/aether-identity/server/src/config"
 	"github.com/skygenesisenterprise/aether-identity/server/src/models"
 	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+	"gorm.io/gorm"
 )
 
 // LoginResponse représente la réponse de connexion avec redirection
@@ -60,10 +62,36 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	auditDetails(c).Metadata = map[string]interface{}{"email": loginData.Email}
+
 	// Authentifier l'utilisateur
-	userService := services.NewUserService(services.DB)
+	userService := services.NewUserService(requestDB(c))
 	user, err := userService.AuthenticateUser(loginData.Email, loginData.Password)
+	connection := services.LocalConnection
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, services.ErrNoPasswordSet) {
+		// Utilisateur inconnu localement, ou compte sans mot de passe local : essayer les bases externes.
+		// Un compte local existant n'est délégué qu'aux connexions auxquelles il est déjà rattaché.
+		customDBService := services.NewCustomDatabaseService(requestDB(c))
+		user, err = customDBService.Login(loginData.Connection, loginData.Email, loginData.Password)
+		connection = customDatabaseConnectionLabel(loginData.Connection, err)
+		if errors.Is(err, services.ErrUserInactive) {
+			services.TrackLogin(requestDB(c), services.LoginActivity{
+				TenantID:   services.ResolveTenantID(requestDB(c), loginData.ClientID, ""),
+				Connection: connection,
+				ClientID:   loginData.ClientID,
+				Result:     services.LoginFailure,
+				Reason:     services.LoginReasonAccountInactive,
+			})
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Account is inactive. Please contact support.",
+			})
+			return
+		}
+	}
+	// Un compte verrouillé reçoit la même réponse qu'un mot de passe erroné, pour ne pas révéler son existence
 	if err != nil {
+		services.TrackLogin(requestDB(c), services.LoginActivity{TenantID: services.ResolveTenantID(requestDB(c), loginData.ClientID, ""), Connection: connection, ClientID: loginData.ClientID, Result: loginFailureResult(err)})
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
 			"error":   "Invalid email or password",
@@ -73,6 +101,14 @@ func Login(c *gin.Context) {
 
 	// Vérifier que le compte est actif
 	if !user.IsActive {
+		services.TrackLogin(requestDB(c), services.LoginActivity{
+			UserID:     user.ID,
+			TenantID:   services.ResolveTenantID(requestDB(c), loginData.ClientID, user.ID),
+			Connection: connection,
+			ClientID:   loginData.ClientID,
+			Result:     services.LoginFailure,
+			Reason:     services.LoginReasonAccountInactive,
+		})
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
 			"error":   "Account is inactive. Please contact support.",
@@ -80,10 +116,23 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	auditDetails(c).Actor = services.AuditUserActor(user)
+	auditTarget(c, "users", user.ID, nil, nil)
+
+	// Exécuter les actions post-login : refus, redirection ou claims personnalisés
+	var actionClient *services.ActionEventClient
+	if loginData.ClientID != "" {
+		actionClient = &services.ActionEventClient{ClientID: loginData.ClientID}
+	}
+	actionResult, err := runPostLoginActions(c, user, actionClient, nil)
+	if abortOnActionResult(c, actionResult, err) {
+		return
+	}
+
 	// Générer les tokens JWT
 	cfg := config.LoadConfig()
 	jwtService := services.NewJWTService(cfg.JWTSecret, cfg.AccessTokenExp, cfg.RefreshTokenExp)
-	accessToken, err := jwtService.GenerateToken(user)
+	accessToken, err := jwtService.GenerateTokenWithClaims(user, actionResult.AccessTokenClaims)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -102,7 +151,7 @@ func Login(c *gin.Context) {
 	}
 
 	// Stocker le refresh token en base
-	emailService := services.NewEmailService(services.DB)
+	emailService := services.NewEmailService(requestDB(c))
 	_, err = emailService.CreateRefreshToken(user.ID, refreshTokenString)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -117,7 +166,7 @@ func Login(c *gin.Context) {
 	isLocalhost := isLocalhostRequest(origin)
 
 	// Déterminer l'URL de redirection personnalisée
-	redirectURL := determineRedirectURL(loginData, cfg)
+	redirectURL := determineRedirectURL(c, loginData, cfg)
 
 	// Set cookies HTTPOnly pour le token d'accès et le refresh
 	// En localhost, on désactive Secure pour permettre les cookies non-HTTPS
@@ -143,6 +192,9 @@ func Login(c *gin.Context) {
 		Expires:  ExpiresRefresh,
 	})
 
+	services.TrackLogin(requestDB(c), services.LoginActivity{UserID: user.ID, TenantID: services.ResolveTenantID(requestDB(c), loginData.ClientID, user.ID), Connection: connection, ClientID: loginData.ClientID, Result: services.LoginSuccess})
+	services.RecordTokenIssued("password")
+
 	// Retourner la réponse avec redirection
 	c.JSON(http.StatusOK, LoginResponse{
 		Success:      true,
@@ -154,8 +206,25 @@ func Login(c *gin.Context) {
 	})
 }
 
+// loginFailureResult distingue, pour les métriques, un compte verrouillé d'un échec d'authentification
+func loginFailureResult(err error) string {
+	if errors.Is(err, services.ErrAccountLocked) {
+		return services.LoginLocked
+	}
+	return services.LoginFailure
+}
+
+// customDatabaseConnectionLabel nomme la connexion d'une authentification déléguée aux bases externes.
+// Le nom fourni par le client n'est repris qu'après un succès, pour ne pas créer une série par valeur inventée.
+func customDatabaseConnectionLabel(connection string, err error) string {
+	if connection == "" || err != nil {
+		return "custom-database"
+	}
+	return connection
+}
+
 // determineRedirectURL calcule l'URL de redirection post-login
-func determineRedirectURL(loginData models.LoginRequest, cfg *config.Config) string {
+func determineRedirectURL(c *gin.Context, loginData models.LoginRequest, cfg *config.Config) string {
 	// Priorité 1: RedirectURI spécifié explicitement
 	if loginData.RedirectURI != "" {
 		return loginData.RedirectURI
@@ -163,7 +232,7 @@ func determineRedirectURL(loginData models.LoginRequest, cfg *config.Config) str
 
 	// Priorité 2: Client OAuth avec redirection personnalisée
 	if loginData.ClientID != "" && services.DB != nil {
-		oauthService := services.NewOAuthService(services.DB, nil)
+		oauthService := services.NewOAuthService(requestDB(c), nil)
 		if client, err := oauthService.GetClientByID(loginData.ClientID); err == nil {
 			// Construire l'URL de redirection avec le chemin personnalisé
 			postLoginPath := loginData.PostLoginPath
@@ -267,7 +336,7 @@ func Register(c *gin.Context) {
 	registerData.Email = services.SanitizeEmail(registerData.Email)
 
 	// Vérifier si l'email existe déjà
-	userService := services.NewUserService(services.DB)
+	userService := services.NewUserService(requestDB(c))
 	if userService.CheckEmailExists(registerData.Email) {
 		c.JSON(http.StatusConflict, ValidationErrorResponse{
 			Success: false,
@@ -280,11 +349,23 @@ func Register(c *gin.Context) {
 	// Créer l'utilisateur
 	name := registerData.Name
 	email := registerData.Email
+
+	// Exécuter les actions pre-user-registration, qui peuvent refuser l'inscription
+	actionEvent := newActionEvent(c, models.ActionTriggerPreUserRegistration)
+	actionEvent.User = &services.ActionEventUser{Email: &email, Name: &name}
+	actionResult, err := services.NewActionService(requestDB(c)).ExecuteTrigger(c.Request.Context(), actionEvent)
+	if abortOnActionResult(c, actionResult, err) {
+		return
+	}
 	user := &models.User{
 		Name:     &name,
 		Email:    &email,
 		IsActive: true,
 	}
+	tenantID := services.ResolveTenantID(requestDB(c), registerData.ClientID, "")
+	if tenantID != "" {
+		user.TenantID = &tenantID
+	}
 
 	if err := userService.CreateUser(user, registerData.Password); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -294,10 +375,25 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	services.NotifyUserProvisioning(requestDB(c), user.ID, models.ProvisioningEventUserCreated)
+	services.TrackSignup(requestDB(c), services.SignupActivity{UserID: user.ID, TenantID: tenantID, ClientID: registerData.ClientID})
+	auditDetails(c).Actor = services.AuditUserActor(user)
+	auditTarget(c, "users", user.ID, nil, user.ToResponse())
+
+	// L'inscription ouvre une session : les actions post-login s'appliquent aussi
+	var loginClient *services.ActionEventClient
+	if registerData.ClientID != "" {
+		loginClient = &services.ActionEventClient{ClientID: registerData.ClientID}
+	}
+	loginResult, err := runPostLoginActions(c, user, loginClient, nil)
+	if abortOnActionResult(c, loginResult, err) {
+		return
+	}
+
 	// Générer les tokens JWT
 	cfg := config.LoadConfig()
 	jwtService := services.NewJWTService(cfg.JWTSecret, cfg.AccessTokenExp, cfg.RefreshTokenExp)
-	accessToken, err := jwtService.GenerateToken(user)
+	accessToken, err := jwtService.GenerateTokenWithClaims(user, loginResult.AccessTokenClaims)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -316,7 +412,7 @@ func Register(c *gin.Context) {
 	}
 
 	// Stocker le refresh token en base
-	emailService := services.NewEmailService(services.DB)
+	emailService := services.NewEmailService(requestDB(c))
 	_, err = emailService.CreateRefreshToken(user.ID, refreshTokenString)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -375,7 +471,7 @@ func Logout(c *gin.Context) {
 	}
 
 	// Révoquer le refresh token
-	emailService := services.NewEmailService(services.DB)
+	emailService := services.NewEmailService(requestDB(c))
 	if err := emailService.RevokeRefreshToken(request.RefreshToken); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Failed to revoke refresh token",
@@ -424,7 +520,7 @@ func RefreshToken(c *gin.Context) {
 	}
 
 	// Valider le refresh token en base
-	emailService := services.NewEmailService(services.DB)
+	emailService := services.NewEmailService(requestDB(c))
 	refreshToken, err := emailService.ValidateRefreshToken(refreshData.RefreshToken)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -434,7 +530,7 @@ func RefreshToken(c *gin.Context) {
 	}
 
 	// Récupérer l'utilisateur
-	userService := services.NewUserService(services.DB)
+	userService := services.NewUserService(requestDB(c))
 	user, err := userService.GetUserByID(refreshToken.UserID)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -451,10 +547,16 @@ func RefreshToken(c *gin.Context) {
 		return
 	}
 
+	// Les actions post-login peuvent révoquer l'accès d'une session existante
+	actionResult, err := runPostLoginActions(c, user, nil, nil)
+	if abortOnActionResult(c, actionResult, err) {
+		return
+	}
+
 	// Générer un nouveau token d'accès
 	cfg := config.LoadConfig()
 	jwtService := services.NewJWTService(cfg.JWTSecret, cfg.AccessTokenExp, cfg.RefreshTokenExp)
-	newAccessToken, err := jwtService.GenerateToken(user)
+	newAccessToken, err := jwtService.GenerateTokenWithClaims(user, actionResult.AccessTokenClaims)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to generate new access token",
@@ -477,6 +579,7 @@ func RefreshToken(c *gin.Context) {
 		Expires:  ExpiresAccess,
 	})
 
+	services.RecordTokenIssued("refresh_token")
 	c.JSON(http.StatusOK, models.TokenResponse{
 		AccessToken:  newAccessToken,
 		RefreshToken: refreshData.RefreshToken,
@@ -0,0 +1,304 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+	"gorm.io/gorm"
+)
+
+// AuthzCheckRequest représente une requête de vérification.
+// Deux formes sont acceptées : object/relation/subject, ou user_id/action/resource
+// telle qu'envoyée par le client GitHub (package/github/identity).
+type AuthzCheckRequest struct {
+	Object      string                    `json:"object"`   // "repo:owner/name"
+	Relation    string                    `json:"relation"` // "writer"
+	Subject     string                    `json:"subject"`  // "user:<id>" ou "team:org/slug#member"
+	Consistency services.AuthzConsistency `json:"consistency"`
+	UserID      string                    `json:"user_id"`
+	Action      string                    `json:"action"`
+	Resource    *AuthzCheckResource       `json:"resource"`
+	RequestID   string                    `json:"request_id"`
+	Context     map[string]interface{}    `json:"context"`
+}
+
+// AuthzCheckResource représente la ressource d'une requête au format du client GitHub
+type AuthzCheckResource struct {
+	Type         string `json:"type"`
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Organization string `json:"organization"`
+}
+
+// AuthzExpandRequest représente une requête d'expansion d'une relation
+type AuthzExpandRequest struct {
+	Object      string                    `json:"object" binding:"required"`
+	Relation    string                    `json:"relation" binding:"required"`
+	Consistency services.AuthzConsistency `json:"consistency"`
+}
+
+// AuthzListObjectsRequest représente une recherche des objets accessibles à un sujet
+type AuthzListObjectsRequest struct {
+	Namespace   string                    `json:"namespace" binding:"required"`
+	Relation    string                    `json:"relation" binding:"required"`
+	Subject     string                    `json:"subject" binding:"required"`
+	Limit       int                       `json:"limit"`
+	Consistency services.AuthzConsistency `json:"consistency"`
+}
+
+// authzResourceNamespaces associe les types de ressource du client GitHub aux namespaces
+var authzResourceNamespaces = map[string]string{
+	"repository": "repo",
+}
+
+func newAuthzService(c *gin.Context) *services.AuthzService {
+	return services.NewAuthzService(requestDB(c))
+}
+
+// authzError convertit une erreur du service d'autorisation en réponse HTTP
+func authzError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrAuthzInvalidTuple),
+		errors.Is(err, services.ErrAuthzInvalidObject),
+		errors.Is(err, services.ErrAuthzInvalidSubject),
+		errors.Is(err, services.ErrAuthzUnknownNamespace),
+		errors.Is(err, services.ErrAuthzUnknownRelation),
+		errors.Is(err, services.ErrAuthzInvalidToken):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAuthzDepthExceeded):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// AuthzRequireDatabase refuse les requêtes /authz sans base de données
+func AuthzRequireDatabase(c *gin.Context) {
+	if services.DB == nil {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Database connection not available"})
+		return
+	}
+	c.Next()
+}
+
+// AuthzCheck vérifie qu'un sujet dispose d'une relation sur un objet
+func AuthzCheck(c *gin.Context) {
+	var req AuthzCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	object, relation, subject := req.Object, req.Relation, req.Subject
+	if req.Resource != nil {
+		namespace := req.Resource.Type
+		if mapped, ok := authzResourceNamespaces[namespace]; ok {
+			namespace = mapped
+		}
+		// "pull_request.opened" ou "admin.settings" : seule la catégorie d'action est une relation
+		action, _, _ := strings.Cut(req.Action, ".")
+		object = namespace + ":" + req.Resource.ID
+		relation = action
+		subject = "user:" + req.UserID
+	}
+	if object == "" || relation == "" || subject == "" || subject == "user:" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "object, relation and subject are required"})
+		return
+	}
+
+	authzService := newAuthzService(c)
+	revision, err := authzService.Snapshot(req.Consistency)
+	if err != nil {
+		authzError(c, err)
+		return
+	}
+
+	allowed, err := authzService.Check(revision, object, relation, subject)
+	if err != nil {
+		authzError(c, err)
+		return
+	}
+
+	decision, reason := "deny", "No relation grants "+relation+" on "+object
+	if allowed {
+		decision, reason = "allow", "Relation "+relation+" granted on "+object
+	}
+	token := services.EncodeAuthzToken(revision)
+
+	c.JSON(http.StatusOK, gin.H{
+		"allowed":           allowed,
+		"decision":          decision,
+		"reason":            reason,
+		"consistency_token": token,
+		"metadata": gin.H{
+			"object":     object,
+			"relation":   relation,
+			"subject":    subject,
+			"request_id": req.RequestID,
+		},
+	})
+}
+
+// AuthzExpand renvoie l'arbre des sujets d'une relation
+func AuthzExpand(c *gin.Context) {
+	var req AuthzExpandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	authzService := newAuthzService(c)
+	revision, err := authzService.Snapshot(req.Consistency)
+	if err != nil {
+		authzError(c, err)
+		return
+	}
+
+	tree, err := authzService.Expand(revision, req.Object, req.Relation)
+	if err != nil {
+		authzError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tree":              tree,
+		"consistency_token": services.EncodeAuthzToken(revision),
+	})
+}
+
+// AuthzListObjects liste les objets d'un namespace accessibles à un sujet
+func AuthzListObjects(c *gin.Context) {
+	var req AuthzListObjectsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if req.Limit <= 0 || req.Limit > 1000 {
+		req.Limit = 100
+	}
+
+	authzService := newAuthzService(c)
+	revision, err := authzService.Snapshot(req.Consistency)
+	if err != nil {
+		authzError(c, err)
+		return
+	}
+
+	objects, err := authzService.ListObjects(revision, req.Namespace, req.Relation, req.Subject, req.Limit)
+	if err != nil {
+		authzError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"objects":           objects,
+		"consistency_token": services.EncodeAuthzToken(revision),
+	})
+}
+
+// AuthzWrite écrit et supprime des tuples de relation dans une même révision
+func AuthzWrite(c *gin.Context) {
+	var req services.AuthzWriteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if len(req.Writes) == 0 && len(req.Deletes) == 0 && len(req.Replace) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "writes, deletes or replace is required"})
+		return
+	}
+
+	revision, err := newAuthzService(c).Write(req)
+	if err != nil {
+		authzError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"consistency_token": services.EncodeAuthzToken(revision)})
+}
+
+// AuthzReadTuples liste les tuples de relation, filtrés par namespace, objet, relation et sujet
+func AuthzReadTuples(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	authzService := newAuthzService(c)
+	revision, err := authzService.Snapshot(services.AuthzConsistency{
+		Mode:  c.DefaultQuery("consistency", services.AuthzConsistencyFullyConsistent),
+		Token: c.Query("token"),
+	})
+	if err != nil {
+		authzError(c, err)
+		return
+	}
+
+	tuples, total, err := authzService.ReadTuples(revision, c.Query("namespace"), c.Query("object_id"), c.Query("relation"), c.Query("subject"), page, limit)
+	if err != nil {
+		authzError(c, err)
+		return
+	}
+
+	data := make([]string, 0, len(tuples))
+	for i := range tuples {
+		data = append(data, services.FormatRelationTuple(&tuples[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tuples":            data,
+		"total":             total,
+		"page":              page,
+		"limit":             limit,
+		"consistency_token": services.EncodeAuthzToken(revision),
+	})
+}
+
+// ListAuthzNamespaces renvoie la configuration effective des namespaces
+func ListAuthzNamespaces(c *gin.Context) {
+	namespaces, err := newAuthzService(c).Namespaces()
+	if err != nil {
+		authzError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, namespaces)
+}
+
+// SaveAuthzNamespace crée ou remplace la configuration d'un namespace
+func SaveAuthzNamespace(c *gin.Context) {
+	var config models.AuthzNamespaceConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	namespace, err := newAuthzService(c).SaveNamespace(c.Param("name"), config)
+	if err != nil {
+		authzError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, namespace)
+}
+
+// DeleteAuthzNamespace supprime la surcharge d'un namespace
+func DeleteAuthzNamespace(c *gin.Context) {
+	if err := newAuthzService(c).DeleteNamespace(c.Param("name")); err != nil {
+		authzError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
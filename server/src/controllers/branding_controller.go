@@ -15,7 +15,7 @@ func CreateBranding(c *gin.Context) {
 		return
 	}
 
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 	if err := brandingService.CreateBranding(&branding); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -26,7 +26,7 @@ func CreateBranding(c *gin.Context) {
 
 func GetBranding(c *gin.Context) {
 	id := c.Param("id")
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 
 	branding, err := brandingService.GetBranding(id)
 	if err != nil {
@@ -46,7 +46,7 @@ func UpdateBranding(c *gin.Context) {
 	}
 
 	branding.ID = id
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 
 	if err := brandingService.UpdateBranding(&branding); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -58,7 +58,7 @@ func UpdateBranding(c *gin.Context) {
 
 func DeleteBranding(c *gin.Context) {
 	id := c.Param("id")
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 
 	if err := brandingService.DeleteBranding(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -69,7 +69,7 @@ func DeleteBranding(c *gin.Context) {
 }
 
 func ListCustomDomains(c *gin.Context) {
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 
 	domains, err := brandingService.ListCustomDomains()
 	if err != nil {
@@ -81,7 +81,7 @@ func ListCustomDomains(c *gin.Context) {
 }
 
 func ListBrandingTemplates(c *gin.Context) {
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 
 	templates, err := brandingService.ListTemplates()
 	if err != nil {
@@ -93,7 +93,7 @@ func ListBrandingTemplates(c *gin.Context) {
 }
 
 func GetBrandingSettings(c *gin.Context) {
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 	branding, err := brandingService.GetBrandingByTenant(c.Query("tenant_id"))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Branding settings not found"})
@@ -110,7 +110,7 @@ func UpdateBrandingSettings(c *gin.Context) {
 		return
 	}
 
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 	if err := brandingService.UpdateBranding(&branding); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -124,7 +124,7 @@ func GetUniversalLoginConfig(c *gin.Context) {
 	if id == "" {
 		id = "default"
 	}
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 	config, err := brandingService.GetUniversalLoginConfig(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Universal login config not found"})
@@ -141,7 +141,7 @@ func UpdateUniversalLogin(c *gin.Context) {
 		return
 	}
 
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 	if err := brandingService.UpdateUniversalLoginConfig(&config); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -151,7 +151,7 @@ func UpdateUniversalLogin(c *gin.Context) {
 }
 
 func ListLoginPages(c *gin.Context) {
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 	pages, err := brandingService.ListUniversalLoginConfigs()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -168,7 +168,7 @@ func CreateLoginPage(c *gin.Context) {
 		return
 	}
 
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 	if err := brandingService.CreateLoginPage(&page); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -186,7 +186,7 @@ func UpdateLoginPage(c *gin.Context) {
 	}
 
 	page.ID = id
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 	if err := brandingService.UpdateLoginPage(&page); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -196,7 +196,7 @@ func UpdateLoginPage(c *gin.Context) {
 }
 
 func GetCustomLoginSettings(c *gin.Context) {
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 	branding, err := brandingService.GetBrandingByTenant(c.Query("tenant_id"))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Custom login settings not found"})
@@ -213,7 +213,7 @@ func UpdateCustomLogin(c *gin.Context) {
 		return
 	}
 
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 	if err := brandingService.UpdateBranding(&branding); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -224,7 +224,7 @@ func UpdateCustomLogin(c *gin.Context) {
 
 func GetTemplateDetails(c *gin.Context) {
 	id := c.Param("id")
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 	template, err := brandingService.GetTemplate(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
@@ -241,7 +241,7 @@ func CreateTemplate(c *gin.Context) {
 		return
 	}
 
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 	if err := brandingService.CreateTemplate(&template); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -252,7 +252,7 @@ func CreateTemplate(c *gin.Context) {
 
 func DeleteTemplate(c *gin.Context) {
 	id := c.Param("id")
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 	if err := brandingService.DeleteTemplate(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -268,7 +268,7 @@ func CreateCustomDomain(c *gin.Context) {
 		return
 	}
 
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 	if err := brandingService.CreateCustomDomain(&domain); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -279,7 +279,7 @@ func CreateCustomDomain(c *gin.Context) {
 
 func GetCustomDomainDetails(c *gin.Context) {
 	id := c.Param("id")
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 	domain, err := brandingService.GetCustomDomain(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Custom domain not found"})
@@ -291,7 +291,7 @@ func GetCustomDomainDetails(c *gin.Context) {
 
 func DeleteCustomDomain(c *gin.Context) {
 	id := c.Param("id")
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 	if err := brandingService.DeleteCustomDomain(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -311,7 +311,7 @@ func VerifyCustomDomain(c *gin.Context) {
 		return
 	}
 
-	brandingService := services.NewBrandingService(services.DB)
+	brandingService := services.NewBrandingService(requestDB(c))
 	domain, err := brandingService.GetCustomDomain(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Custom domain not found"})
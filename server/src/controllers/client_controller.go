@@ -15,6 +15,7 @@ type CreateClientRequest struct {
 	RedirectURIs []string `json:"redirectUris" binding:"required"`
 	Scopes       []string `json:"scopes" binding:"required"`
 	GrantTypes   []string `json:"grantTypes" binding:"required"`
+	TenantID     *string  `json:"tenantId"` // Optionnel, tenant auquel rattacher les connexions du client
 }
 
 // ClientResponse représente une réponse de client
@@ -26,6 +27,7 @@ type ClientResponse struct {
 	RedirectURIs []string `json:"redirectUris"`
 	Scopes       []string `json:"scopes"`
 	GrantTypes   []string `json:"grantTypes"`
+	TenantID     *string  `json:"tenantId,omitempty"`
 }
 
 // CreateClient crée un nouveau client OAuth
@@ -57,7 +59,7 @@ func CreateClient(c *gin.Context) {
 	}
 
 	// Créer le client
-	oauthService := services.NewOAuthService(services.DB, services.NewJWTService(
+	oauthService := services.NewOAuthService(requestDB(c), services.NewJWTService(
 		"test-secret-key", // À remplacer par la clé réelle
 		15,
 		720,
@@ -71,6 +73,15 @@ func CreateClient(c *gin.Context) {
 		Scopes:       req.Scopes,
 		GrantTypes:   req.GrantTypes,
 	}
+	if req.TenantID != nil && *req.TenantID != "" {
+		if _, err := services.NewTenantService(requestDB(c)).GetTenant(*req.TenantID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Unknown tenant",
+			})
+			return
+		}
+		client.TenantID = req.TenantID
+	}
 
 	if err := oauthService.CreateClient(client); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -78,6 +89,7 @@ func CreateClient(c *gin.Context) {
 		})
 		return
 	}
+	auditTarget(c, "clients", client.ClientID, nil, client)
 
 	// Retourner la réponse
 	response := ClientResponse{
@@ -88,6 +100,7 @@ func CreateClient(c *gin.Context) {
 		RedirectURIs: client.RedirectURIs,
 		Scopes:       client.Scopes,
 		GrantTypes:   client.GrantTypes,
+		TenantID:     client.TenantID,
 	}
 
 	c.JSON(http.StatusCreated, response)
@@ -97,7 +110,7 @@ func CreateClient(c *gin.Context) {
 func GetClient(c *gin.Context) {
 	clientID := c.Param("clientId")
 
-	oauthService := services.NewOAuthService(services.DB, services.NewJWTService(
+	oauthService := services.NewOAuthService(requestDB(c), services.NewJWTService(
 		"test-secret-key", // À remplacer par la clé réelle
 		15,
 		720,
@@ -128,7 +141,7 @@ func GetClient(c *gin.Context) {
 func ListClients(c *gin.Context) {
 	var clients []models.OAuthClient
 
-	if err := services.DB.Find(&clients).Error; err != nil {
+	if err := requestDB(c).Find(&clients).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to list clients",
 		})
@@ -163,7 +176,7 @@ func UpdateClient(c *gin.Context) {
 		return
 	}
 
-	oauthService := services.NewOAuthService(services.DB, services.NewJWTService(
+	oauthService := services.NewOAuthService(requestDB(c), services.NewJWTService(
 		"test-secret-key", // À remplacer par la clé réelle
 		15,
 		720,
@@ -179,17 +192,19 @@ func UpdateClient(c *gin.Context) {
 	}
 
 	// Mettre à jour les champs
+	before := *client
 	client.Name = req.Name
 	client.RedirectURIs = req.RedirectURIs
 	client.Scopes = req.Scopes
 	client.GrantTypes = req.GrantTypes
 
-	if err := services.DB.Save(client).Error; err != nil {
+	if err := requestDB(c).Save(client).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update client",
 		})
 		return
 	}
+	auditTarget(c, "clients", client.ClientID, before, client)
 
 	response := ClientResponse{
 		ID:           client.ID,
@@ -208,7 +223,7 @@ func UpdateClient(c *gin.Context) {
 func RotateClientSecret(c *gin.Context) {
 	clientID := c.Param("clientId")
 
-	oauthService := services.NewOAuthService(services.DB, services.NewJWTService(
+	oauthService := services.NewOAuthService(requestDB(c), services.NewJWTService(
 		"test-secret-key", // À remplacer par la clé réelle
 		15,
 		720,
@@ -233,13 +248,15 @@ func RotateClientSecret(c *gin.Context) {
 	}
 
 	// Mettre à jour le secret
+	before := *client
 	client.ClientSecret = newSecret
-	if err := services.DB.Save(client).Error; err != nil {
+	if err := requestDB(c).Save(client).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update client secret",
 		})
 		return
 	}
+	auditTarget(c, "clients", client.ClientID, before, client)
 
 	response := ClientResponse{
 		ID:           client.ID,
@@ -258,7 +275,7 @@ func RotateClientSecret(c *gin.Context) {
 func DeleteClient(c *gin.Context) {
 	clientID := c.Param("clientId")
 
-	oauthService := services.NewOAuthService(services.DB, services.NewJWTService(
+	oauthService := services.NewOAuthService(requestDB(c), services.NewJWTService(
 		"test-secret-key", // À remplacer par la clé réelle
 		15,
 		720,
@@ -274,12 +291,13 @@ func DeleteClient(c *gin.Context) {
 	}
 
 	// Supprimer le client
-	if err := services.DB.Delete(client).Error; err != nil {
+	if err := requestDB(c).Delete(client).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to delete client",
 		})
 		return
 	}
+	auditTarget(c, "clients", client.ClientID, client, nil)
 
 	c.Status(http.StatusNoContent)
 }
@@ -15,7 +15,7 @@ func CreateConnection(c *gin.Context) {
 		return
 	}
 
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 	if err := connService.CreateConnection(&conn); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -26,7 +26,7 @@ func CreateConnection(c *gin.Context) {
 
 func GetConnection(c *gin.Context) {
 	id := c.Param("id")
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 
 	conn, err := connService.GetConnectionByID(id)
 	if err != nil {
@@ -38,7 +38,7 @@ func GetConnection(c *gin.Context) {
 }
 
 func ListConnections(c *gin.Context) {
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 
 	conns, err := connService.ListConnections()
 	if err != nil {
@@ -58,7 +58,7 @@ func UpdateConnection(c *gin.Context) {
 	}
 
 	conn.ID = id
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 
 	if err := connService.UpdateConnection(&conn); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -70,7 +70,7 @@ func UpdateConnection(c *gin.Context) {
 
 func DeleteConnection(c *gin.Context) {
 	id := c.Param("id")
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 
 	if err := connService.DeleteConnection(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -82,7 +82,7 @@ func DeleteConnection(c *gin.Context) {
 
 func EnableConnection(c *gin.Context) {
 	id := c.Param("id")
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 
 	conn, err := connService.GetConnectionByID(id)
 	if err != nil {
@@ -106,7 +106,7 @@ func CreateAuthenticationProfile(c *gin.Context) {
 		return
 	}
 
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 	if err := connService.CreateAuthenticationProfile(&profile); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -116,7 +116,7 @@ func CreateAuthenticationProfile(c *gin.Context) {
 }
 
 func ListAuthenticationProfiles(c *gin.Context) {
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 
 	profiles, err := connService.ListAuthenticationProfiles()
 	if err != nil {
@@ -129,7 +129,7 @@ func ListAuthenticationProfiles(c *gin.Context) {
 
 func DisableConnection(c *gin.Context) {
 	id := c.Param("id")
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 	conn, err := connService.GetConnectionByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Connection not found"})
@@ -149,7 +149,7 @@ func CreateDatabaseConnection(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 	if err := connService.CreateDatabaseConnection(&dbConn); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -165,7 +165,7 @@ func ConfigureDatabaseConnection(c *gin.Context) {
 		return
 	}
 	dbConn.ID = id
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 	if err := connService.UpdateDatabaseConnection(&dbConn); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -175,7 +175,7 @@ func ConfigureDatabaseConnection(c *gin.Context) {
 
 func ListDatabaseConnectionUsers(c *gin.Context) {
 	id := c.Param("id")
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 	users, err := connService.ListDatabaseConnectionUsers(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -185,7 +185,7 @@ func ListDatabaseConnectionUsers(c *gin.Context) {
 }
 
 func ListSocialProviders(c *gin.Context) {
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 	providers, err := connService.ListSocialProviders()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -200,7 +200,7 @@ func ConfigureSocialProvider(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 	if err := connService.CreateSocialProvider(&provider); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -209,7 +209,7 @@ func ConfigureSocialProvider(c *gin.Context) {
 }
 
 func ListEnterpriseConnections(c *gin.Context) {
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 	conns, err := connService.ListEnterpriseConnections()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -224,7 +224,7 @@ func CreateSamlConnection(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 	if err := connService.CreateEnterpriseConnection(&conn); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -240,7 +240,7 @@ func UpdateSamlSettings(c *gin.Context) {
 		return
 	}
 	conn.ID = id
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 	if err := connService.UpdateEnterpriseConnection(&conn); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -256,7 +256,7 @@ func UpdateSamlMetadata(c *gin.Context) {
 		return
 	}
 	conn.ID = id
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 	if err := connService.UpdateEnterpriseConnection(&conn); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -270,7 +270,7 @@ func CreateOidcConnection(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 	if err := connService.CreateEnterpriseConnection(&conn); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -279,7 +279,7 @@ func CreateOidcConnection(c *gin.Context) {
 }
 
 func ListPasswordlessSettings(c *gin.Context) {
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 	settings, err := connService.ListPasswordlessConnections()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -295,7 +295,7 @@ func EnablePasswordless(c *gin.Context) {
 		return
 	}
 	conn.IsEnabled = true
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 	if err := connService.CreatePasswordlessConnection(&conn); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -311,7 +311,7 @@ func ConfigurePasswordless(c *gin.Context) {
 		return
 	}
 	conn.ID = id
-	connService := services.NewConnectionService(services.DB)
+	connService := services.NewConnectionService(requestDB(c))
 	if err := connService.UpdatePasswordlessConnection(&conn); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -6,9 +6,17 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/skygenesisenterprise/aether-identity/server/src/interfaces"
 	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"github.com/skygenesisenterprise/aether-identity/server/src/services"
 	"github.com/skygenesisenterprise/aether-identity/server/src/utils"
+	"gorm.io/gorm"
 )
 
+// requestDB renvoie la base rattachée au contexte de la requête : ses requêtes SQL sont
+// annulées avec la requête et apparaissent dans sa trace. nil en mode sans base de données.
+func requestDB(c *gin.Context) *gorm.DB {
+	return services.RequestDB(c.Request.Context())
+}
+
 // DatabaseController gère les opérations liées à la base de données
 type DatabaseController struct {
 	dbService interfaces.IDatabaseService
@@ -156,6 +164,9 @@ func (dc *DatabaseController) Migrate(c *gin.Context) {
 		&models.User{},
 		&models.Organization{},
 		&models.Role{},
+		&models.Permission{},
+		&models.UserRole{},
+		&models.RolePermission{},
 		&models.Membership{},
 		&models.OAuthClient{},
 		&models.OAuthAuthorizationCode{},
@@ -168,6 +179,30 @@ func (dc *DatabaseController) Migrate(c *gin.Context) {
 		&models.DomainSettings{},
 		&models.ExternalAccount{},
 		&models.OAuthState{},
+		&models.ApplicationProvisioning{},
+		&models.ProvisionedUser{},
+		&models.ProvisioningTask{},
+		&models.ProvisioningLog{},
+		&models.RelationTuple{},
+		&models.AuthzRevision{},
+		&models.AuthzNamespace{},
+		&models.ProviderEntity{},
+		&models.FederatedIssuer{},
+		&models.FederatedIdentityBinding{},
+		&models.FederationTrustPolicy{},
+		&models.AgentEnrollmentToken{},
+		&models.AgentCommand{},
+		&models.ActionVersion{},
+		&models.AuditEntry{},
+		&models.LogStream{},
+		&models.LogStreamDeadLetter{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.ActivityOverview{},
+		&models.DailyActiveUsers{},
+		&models.UserRetention{},
+		&models.SignupData{},
+		&models.FailedLoginData{},
 	); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Migration failed",
@@ -176,6 +211,15 @@ func (dc *DatabaseController) Migrate(c *gin.Context) {
 		return
 	}
 
+	// Rattachement des utilisateurs à un tenant : colonne, index et clé étrangère vers tenants
+	if err := services.MigrateUserTenant(dc.dbService.GetDB()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Migration failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Migrations completed successfully",
@@ -62,8 +62,8 @@ func DiscoveryHandler(c *gin.Context) {
 		GrantTypesSupported:         cfg.GrantTypes,
 		SubjectTypesSupported:       []string{"public"},
 		IDTokenSigningAlgValuesSupported: []string{"RS256", "HS256"},
-		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post"},
-		TokenEndpointAuthSigningAlgValuesSupported: []string{"RS256", "HS256"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "private_key_jwt"},
+		TokenEndpointAuthSigningAlgValuesSupported: []string{"RS256", "HS256", "ES256"},
 		RevocationEndpoint:          cfg.IssuerURL + cfg.RevocationURL,
 		RevocationEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post"},
 		CodeChallengeMethodsSupported: []string{"plain", "S256"},
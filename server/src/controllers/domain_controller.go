@@ -44,7 +44,7 @@ func CreateDomain(c *gin.Context) {
 		return
 	}
 
-	domainService := services.NewDomainService(services.DB)
+	domainService := services.NewDomainService(requestDB(c))
 
 	// Créer le domaine
 	displayName := req.DisplayName
@@ -88,7 +88,7 @@ func CreateDomain(c *gin.Context) {
 func GetDomain(c *gin.Context) {
 	domainID := c.Param("domainId")
 
-	domainService := services.NewDomainService(services.DB)
+	domainService := services.NewDomainService(requestDB(c))
 
 	domain, err := domainService.GetDomainByID(domainID)
 	if err != nil {
@@ -118,7 +118,7 @@ func GetDomain(c *gin.Context) {
 
 // ListDomains liste tous les domaines
 func ListDomains(c *gin.Context) {
-	domainService := services.NewDomainService(services.DB)
+	domainService := services.NewDomainService(requestDB(c))
 
 	domains, err := domainService.ListDomains()
 	if err != nil {
@@ -161,7 +161,7 @@ func UpdateDomain(c *gin.Context) {
 		return
 	}
 
-	domainService := services.NewDomainService(services.DB)
+	domainService := services.NewDomainService(requestDB(c))
 
 	id := 0
 	fmt.Sscanf(domainID, "%d", &id)
@@ -209,7 +209,7 @@ func UpdateDomain(c *gin.Context) {
 func DeleteDomain(c *gin.Context) {
 	domainID := c.Param("domainId")
 
-	domainService := services.NewDomainService(services.DB)
+	domainService := services.NewDomainService(requestDB(c))
 
 	id := 0
 	fmt.Sscanf(domainID, "%d", &id)
@@ -255,7 +255,7 @@ func VerifyDomain(c *gin.Context) {
 		return
 	}
 
-	domainService := services.NewDomainService(services.DB)
+	domainService := services.NewDomainService(requestDB(c))
 
 	id := 0
 	fmt.Sscanf(domainID, "%d", &id)
@@ -276,8 +276,8 @@ func VerifyDomain(c *gin.Context) {
 func GetDomainUsers(c *gin.Context) {
 	domainID := c.Param("domainId")
 
-	domainService := services.NewDomainService(services.DB)
-	userService := services.NewUserService(services.DB)
+	domainService := services.NewDomainService(requestDB(c))
+	userService := services.NewUserService(requestDB(c))
 
 	id := 0
 	fmt.Sscanf(domainID, "%d", &id)
@@ -314,7 +314,7 @@ func AddUserToDomain(c *gin.Context) {
 		return
 	}
 
-	domainService := services.NewDomainService(services.DB)
+	domainService := services.NewDomainService(requestDB(c))
 
 	domainIDInt := 0
 	fmt.Sscanf(domainID, "%d", &domainIDInt)
@@ -336,7 +336,7 @@ func RemoveUserFromDomain(c *gin.Context) {
 	domainID := c.Param("domainId")
 	userID := c.Param("userId")
 
-	domainService := services.NewDomainService(services.DB)
+	domainService := services.NewDomainService(requestDB(c))
 
 	domainIDInt := 0
 	fmt.Sscanf(domainID, "%d", &domainIDInt)
@@ -357,7 +357,7 @@ func RemoveUserFromDomain(c *gin.Context) {
 func GetDomainDetails(c *gin.Context) {
 	domainID := c.Param("domainId")
 
-	domainService := services.NewDomainService(services.DB)
+	domainService := services.NewDomainService(requestDB(c))
 
 	id := 0
 	fmt.Sscanf(domainID, "%d", &id)
@@ -384,7 +384,7 @@ func CheckEmailDomain(c *gin.Context) {
 		return
 	}
 
-	domainService := services.NewDomainService(services.DB)
+	domainService := services.NewDomainService(requestDB(c))
 
 	isManaged, domain, err := domainService.IsEmailFromManagedDomain(email)
 	if err != nil {
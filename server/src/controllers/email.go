@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -20,7 +21,7 @@ func SendEmailVerification(c *gin.Context) {
 	}
 
 	// Récupérer l'utilisateur
-	userService := services.NewUserService(services.DB)
+	userService := services.NewUserService(requestDB(c))
 	user, err := userService.GetUserByEmail(request.Email)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -30,7 +31,7 @@ func SendEmailVerification(c *gin.Context) {
 	}
 
 	// Créer le token de vérification
-	emailService := services.NewEmailService(services.DB)
+	emailService := services.NewEmailService(requestDB(c))
 	verification, err := emailService.CreateEmailVerification(user.ID, request.Email)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -63,7 +64,7 @@ func VerifyEmail(c *gin.Context) {
 		return
 	}
 
-	emailService := services.NewEmailService(services.DB)
+	emailService := services.NewEmailService(requestDB(c))
 	user, err := emailService.VerifyEmail(request.Token)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -89,7 +90,7 @@ func RequestPasswordReset(c *gin.Context) {
 		return
 	}
 
-	emailService := services.NewEmailService(services.DB)
+	emailService := services.NewEmailService(requestDB(c))
 	reset, err := emailService.CreatePasswordReset(request.Email)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -122,14 +123,22 @@ func ConfirmPasswordReset(c *gin.Context) {
 		return
 	}
 
-	emailService := services.NewEmailService(services.DB)
-	if err := emailService.ResetPassword(request.Token, request.Password); err != nil {
+	emailService := services.NewEmailService(requestDB(c))
+	user, err := emailService.ResetPassword(request.Token, request.Password)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
+	// Les actions post-change-password sont informatives : leur échec n'annule pas la réinitialisation
+	actionEvent := newActionEvent(c, models.ActionTriggerPostChangePassword)
+	actionEvent.User = services.NewActionEventUser(user)
+	if _, err := services.NewActionService(requestDB(c)).ExecuteTrigger(c.Request.Context(), actionEvent); err != nil {
+		fmt.Printf("Actions: post-change-password failed: %v\n", err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Password reset successfully",
 	})
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -15,7 +16,7 @@ func CreateEvent(c *gin.Context) {
 		return
 	}
 
-	eventService := services.NewEventService(services.DB)
+	eventService := services.NewEventService(requestDB(c))
 	if err := eventService.CreateEvent(&event); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -26,7 +27,7 @@ func CreateEvent(c *gin.Context) {
 
 func GetEvent(c *gin.Context) {
 	id := c.Param("id")
-	eventService := services.NewEventService(services.DB)
+	eventService := services.NewEventService(requestDB(c))
 
 	event, err := eventService.GetEvent(id)
 	if err != nil {
@@ -37,21 +38,58 @@ func GetEvent(c *gin.Context) {
 	c.JSON(http.StatusOK, event)
 }
 
+// ListEvents recherche dans les événements, du plus récent au plus ancien, avec pagination par curseur
 func ListEvents(c *gin.Context) {
-	eventService := services.NewEventService(services.DB)
+	query, err := logQueryFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !scopeLogQuery(c, &query) {
+		return
+	}
 
-	events, err := eventService.ListEvents(100, 0)
+	eventService := services.NewEventService(requestDB(c))
+	events, nextCursor, err := eventService.QueryEvents(query)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, events)
+	c.JSON(http.StatusOK, gin.H{"events": events, "nextCursor": nextCursor})
+}
+
+// ExportEvents exporte en flux (NDJSON, CSV ou CEF) tous les événements correspondant à la recherche
+func ExportEvents(c *gin.Context) {
+	query, err := logQueryFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !scopeLogQuery(c, &query) {
+		return
+	}
+
+	eventService := services.NewEventService(requestDB(c))
+	streamExport(c, "events", func(write func(services.ExportRecord) error, flush func() error) error {
+		return eventService.StreamEvents(query, func(events []models.Event) error {
+			for i := range events {
+				if err := write(services.EventRecord(&events[i])); err != nil {
+					return err
+				}
+			}
+			return flush()
+		})
+	})
 }
 
 func GetEventsByType(c *gin.Context) {
 	eventType := c.Query("type")
-	eventService := services.NewEventService(services.DB)
+	eventService := services.NewEventService(requestDB(c))
 
 	events, err := eventService.GetEventsByType(eventType)
 	if err != nil {
@@ -62,6 +100,18 @@ func GetEventsByType(c *gin.Context) {
 	c.JSON(http.StatusOK, events)
 }
 
+// GetEventDetails retourne un événement, s'il relève du tenant de l'appelant
 func GetEventDetails(c *gin.Context) {
-	GetEvent(c)
+	var query services.LogQuery
+	if !scopeLogQuery(c, &query) {
+		return
+	}
+	eventService := services.NewEventService(requestDB(c))
+	event, err := eventService.FindEvent(c.Param("id"), query)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
 }
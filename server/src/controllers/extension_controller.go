@@ -15,7 +15,7 @@ func CreateExtension(c *gin.Context) {
 		return
 	}
 
-	extService := services.NewExtensionService(services.DB)
+	extService := services.NewExtensionService(requestDB(c))
 	if err := extService.CreateExtension(&ext); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -26,7 +26,7 @@ func CreateExtension(c *gin.Context) {
 
 func GetExtension(c *gin.Context) {
 	id := c.Param("id")
-	extService := services.NewExtensionService(services.DB)
+	extService := services.NewExtensionService(requestDB(c))
 
 	ext, err := extService.GetExtension(id)
 	if err != nil {
@@ -38,7 +38,7 @@ func GetExtension(c *gin.Context) {
 }
 
 func ListExtensions(c *gin.Context) {
-	extService := services.NewExtensionService(services.DB)
+	extService := services.NewExtensionService(requestDB(c))
 
 	extensions, err := extService.ListExtensions()
 	if err != nil {
@@ -58,7 +58,7 @@ func UpdateExtension(c *gin.Context) {
 	}
 
 	ext.ID = id
-	extService := services.NewExtensionService(services.DB)
+	extService := services.NewExtensionService(requestDB(c))
 
 	if err := extService.UpdateExtension(&ext); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -70,7 +70,7 @@ func UpdateExtension(c *gin.Context) {
 
 func DeleteExtension(c *gin.Context) {
 	id := c.Param("id")
-	extService := services.NewExtensionService(services.DB)
+	extService := services.NewExtensionService(requestDB(c))
 
 	if err := extService.DeleteExtension(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -87,7 +87,7 @@ func InstallExtension(c *gin.Context) {
 		return
 	}
 
-	extService := services.NewExtensionService(services.DB)
+	extService := services.NewExtensionService(requestDB(c))
 	if err := extService.InstallExtension(&ext); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -98,7 +98,7 @@ func InstallExtension(c *gin.Context) {
 
 func UninstallExtension(c *gin.Context) {
 	id := c.Param("id")
-	extService := services.NewExtensionService(services.DB)
+	extService := services.NewExtensionService(requestDB(c))
 	if err := extService.UninstallExtension(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -109,7 +109,7 @@ func UninstallExtension(c *gin.Context) {
 
 func GetExtensionConfig(c *gin.Context) {
 	id := c.Param("id")
-	extService := services.NewExtensionService(services.DB)
+	extService := services.NewExtensionService(requestDB(c))
 	config, err := extService.GetExtensionConfig(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Extension config not found"})
@@ -127,7 +127,7 @@ func UpdateExtensionConfig(c *gin.Context) {
 		return
 	}
 
-	extService := services.NewExtensionService(services.DB)
+	extService := services.NewExtensionService(requestDB(c))
 	if err := extService.UpdateExtensionConfig(id, config); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
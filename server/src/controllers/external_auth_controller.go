@@ -10,6 +10,7 @@ import (
 	"github.com/skygenesisenterprise/aether-identity/server/src/config"
 	"github.com/skygenesisenterprise/aether-identity/server/src/models"
 	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+	"gorm.io/gorm"
 )
 
 func uintFromStringPtr(s *string) uint {
@@ -30,6 +31,10 @@ type ExternalAuthController struct {
 
 // NewExternalAuthController crée une nouvelle instance du contrôleur
 func NewExternalAuthController() *ExternalAuthController {
+	return newExternalAuthController(services.DB)
+}
+
+func newExternalAuthController(db *gorm.DB) *ExternalAuthController {
 	encryptKey := os.Getenv("OAUTH_ENCRYPT_KEY")
 	if encryptKey == "" {
 		encryptKey = config.LoadConfig().JWTSecret // Fallback sur JWTSecret
@@ -38,13 +43,18 @@ func NewExternalAuthController() *ExternalAuthController {
 	cfg := config.LoadConfig()
 
 	return &ExternalAuthController{
-		externalAuthService: services.NewExternalAuthService(services.DB, encryptKey),
-		oauthService:        services.NewOAuthService(services.DB, services.NewJWTService(cfg.JWTSecret, cfg.AccessTokenExp, cfg.RefreshTokenExp)),
+		externalAuthService: services.NewExternalAuthService(db, encryptKey),
+		oauthService:        services.NewOAuthService(db, services.NewJWTService(cfg.JWTSecret, cfg.AccessTokenExp, cfg.RefreshTokenExp)),
 		jwtService:          services.NewJWTService(cfg.JWTSecret, cfg.AccessTokenExp, cfg.RefreshTokenExp),
-		userService:         services.NewUserService(services.DB),
+		userService:         services.NewUserService(db),
 	}
 }
 
+// forRequest rattache les services du contrôleur à la base de la requête
+func (ctrl *ExternalAuthController) forRequest(c *gin.Context) *ExternalAuthController {
+	return newExternalAuthController(requestDB(c))
+}
+
 // InitiateOAuthRequest représente la requête d'initiation OAuth
 type InitiateOAuthRequest struct {
 	Provider string `json:"provider" binding:"required,oneof=github google microsoft discord"`
@@ -53,6 +63,8 @@ type InitiateOAuthRequest struct {
 
 // InitiateOAuth initie le flux OAuth avec un provider externe
 func (ctrl *ExternalAuthController) InitiateOAuth(c *gin.Context) {
+	ctrl = ctrl.forRequest(c)
+
 	provider := c.Param("provider")
 	action := c.Query("action")
 	if action == "" {
@@ -92,6 +104,8 @@ func (ctrl *ExternalAuthController) InitiateOAuth(c *gin.Context) {
 
 // HandleOAuthCallback gère le callback OAuth des providers externes
 func (ctrl *ExternalAuthController) HandleOAuthCallback(c *gin.Context) {
+	ctrl = ctrl.forRequest(c)
+
 	provider := c.Param("provider")
 	code := c.Query("code")
 	state := c.Query("state")
@@ -106,23 +120,33 @@ func (ctrl *ExternalAuthController) HandleOAuthCallback(c *gin.Context) {
 		return
 	}
 
+	// Les appels au provider apparaissent dans la trace de la requête
+	externalAuthService := ctrl.externalAuthService.WithContext(c.Request.Context())
+
 	// Valider le state
-	oauthState, err := ctrl.externalAuthService.ValidateOAuthState(state)
+	oauthState, err := externalAuthService.ValidateOAuthState(state)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired state"})
 		return
 	}
+	isLogin := oauthState.Action != nil && *oauthState.Action == "login"
 
 	// Échanger le code contre des tokens
-	tokenResult, err := ctrl.externalAuthService.ExchangeCode(provider, code)
+	tokenResult, err := externalAuthService.ExchangeCode(provider, code)
 	if err != nil {
+		if isLogin {
+			services.TrackLogin(requestDB(c), services.LoginActivity{Connection: oauthState.Provider, Result: services.LoginFailure, Reason: services.LoginReasonProviderError})
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to exchange code", "details": err.Error()})
 		return
 	}
 
 	// Récupérer les informations utilisateur
-	userInfo, err := ctrl.externalAuthService.GetUserInfo(provider, tokenResult.AccessToken)
+	userInfo, err := externalAuthService.GetUserInfo(provider, tokenResult.AccessToken)
 	if err != nil {
+		if isLogin {
+			services.TrackLogin(requestDB(c), services.LoginActivity{Connection: oauthState.Provider, Result: services.LoginFailure, Reason: services.LoginReasonProviderError})
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get user info", "details": err.Error()})
 		return
 	}
@@ -148,8 +172,9 @@ func (ctrl *ExternalAuthController) HandleOAuthCallback(c *gin.Context) {
 // handleLoginOAuth gère la connexion via OAuth
 func (ctrl *ExternalAuthController) handleLoginOAuth(c *gin.Context, provider string, userInfo *models.ProviderUserInfo, tokenResult *services.TokenExchangeResult) {
 	// Chercher ou créer l'utilisateur
-	user, isNew, err := ctrl.externalAuthService.FindOrCreateUser(provider, userInfo)
+	user, isNew, err := ctrl.externalAuthService.WithContext(c.Request.Context()).FindOrCreateUser(provider, userInfo)
 	if err != nil {
+		services.TrackLogin(requestDB(c), services.LoginActivity{Connection: provider, Result: services.LoginFailure, Reason: services.LoginReasonProviderError})
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process user", "details": err.Error()})
 		return
 	}
@@ -159,7 +184,12 @@ func (ctrl *ExternalAuthController) handleLoginOAuth(c *gin.Context, provider st
 		ClientID: "external_auth",
 	}
 
-	accessToken, err := ctrl.oauthService.GenerateAccessToken(user, client, []string{"openid", "profile", "email"})
+	actionResult, err := runPostLoginActions(c, user, &services.ActionEventClient{ClientID: client.ClientID}, []string{"openid", "profile", "email"})
+	if abortOnActionResult(c, actionResult, err) {
+		return
+	}
+
+	accessToken, err := ctrl.oauthService.GenerateAccessTokenWithClaims(user, client, []string{"openid", "profile", "email"}, actionResult.AccessTokenClaims)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate access token"})
 		return
@@ -185,12 +215,17 @@ func (ctrl *ExternalAuthController) handleLoginOAuth(c *gin.Context, provider st
 	}
 
 	// Générer l'ID token
-	idToken, err := ctrl.oauthService.GenerateIDToken(user, client, []string{"openid", "profile", "email"}, "")
+	idToken, err := ctrl.oauthService.GenerateIDTokenWithClaims(user, client, []string{"openid", "profile", "email"}, "", actionResult.IDTokenClaims)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate ID token"})
 		return
 	}
 
+	if isNew {
+		services.TrackSignup(requestDB(c), services.SignupActivity{UserID: user.ID, TenantID: services.ResolveTenantID(requestDB(c), "", user.ID), Connection: provider})
+	}
+	services.TrackLogin(requestDB(c), services.LoginActivity{UserID: user.ID, TenantID: services.ResolveTenantID(requestDB(c), "", user.ID), Connection: provider, Result: services.LoginSuccess})
+	services.RecordTokenIssued("external")
 	c.JSON(http.StatusOK, gin.H{
 		"access_token":  accessToken,
 		"refresh_token": refreshToken,
@@ -225,6 +260,8 @@ func (ctrl *ExternalAuthController) handleLinkOAuth(c *gin.Context, provider str
 
 // GetLinkedAccounts retourne les comptes externes liés à l'utilisateur
 func (ctrl *ExternalAuthController) GetLinkedAccounts(c *gin.Context) {
+	ctrl = ctrl.forRequest(c)
+
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
@@ -244,6 +281,8 @@ func (ctrl *ExternalAuthController) GetLinkedAccounts(c *gin.Context) {
 
 // UnlinkAccount supprime le lien avec un compte externe
 func (ctrl *ExternalAuthController) UnlinkAccount(c *gin.Context) {
+	ctrl = ctrl.forRequest(c)
+
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
@@ -266,6 +305,8 @@ func (ctrl *ExternalAuthController) UnlinkAccount(c *gin.Context) {
 
 // GetEnabledProviders retourne la liste des providers OAuth configurés
 func (ctrl *ExternalAuthController) GetEnabledProviders(c *gin.Context) {
+	ctrl = ctrl.forRequest(c)
+
 	providers := ctrl.externalAuthService.Config.GetEnabledProviders()
 
 	// Construire les URLs d'autorisation pour chaque provider
@@ -288,6 +329,8 @@ func (ctrl *ExternalAuthController) GetEnabledProviders(c *gin.Context) {
 
 // RedirectToOAuth redirige directement vers le provider OAuth
 func (ctrl *ExternalAuthController) RedirectToOAuth(c *gin.Context) {
+	ctrl = ctrl.forRequest(c)
+
 	provider := c.Param("provider")
 	action := c.Query("action")
 	if action == "" {
@@ -324,12 +367,16 @@ func (ctrl *ExternalAuthController) RedirectToOAuth(c *gin.Context) {
 
 // GetMigrationStatus retourne le statut de la migration des comptes externes
 func (ctrl *ExternalAuthController) GetMigrationStatus(c *gin.Context) {
+	ctrl = ctrl.forRequest(c)
+
 	status := ctrl.externalAuthService.GetMigrationStatus()
 	c.JSON(http.StatusOK, status)
 }
 
 // MigrateExternalAccounts exécute la migration des comptes externes (Discord)
 func (ctrl *ExternalAuthController) MigrateExternalAccounts(c *gin.Context) {
+	ctrl = ctrl.forRequest(c)
+
 	err := ctrl.externalAuthService.MigrateDiscordAccounts()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Migration failed", "details": err.Error()})
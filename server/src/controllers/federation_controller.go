@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+	"gorm.io/gorm"
+)
+
+// FederatedIssuerRequest représente la création ou la mise à jour d'un émetteur fédéré
+type FederatedIssuerRequest struct {
+	Name        string                     `json:"name" binding:"required"`
+	Type        models.FederatedIssuerType `json:"type"`
+	Issuer      string                     `json:"issuer" binding:"required"`
+	JWKSURI     *string                    `json:"jwksUri"`
+	JWKS        interface{}                `json:"jwks"`
+	Audiences   []string                   `json:"audiences" binding:"required"`
+	IsActive    *bool                      `json:"isActive"`
+	Description *string                    `json:"description"`
+}
+
+// FederatedBindingRequest représente la liaison d'un sujet fédéré à un client.
+// Pour un émetteur Kubernetes, namespace et serviceAccount peuvent remplacer subject.
+type FederatedBindingRequest struct {
+	Subject        string   `json:"subject"`
+	Namespace      string   `json:"namespace"`
+	ServiceAccount string   `json:"serviceAccount"`
+	ClientID       *string  `json:"clientId"`
+	ApplicationID  *string  `json:"applicationId"`
+	Scopes         []string `json:"scopes"`
+	IsActive       *bool    `json:"isActive"`
+}
+
+// FederationTrustPolicyRequest représente une politique de confiance d'un fournisseur CI.
+// Repository, branch et environment sont des motifs path.Match ("org/*", "release/*").
+type FederationTrustPolicyRequest struct {
+	Name          string            `json:"name" binding:"required"`
+	Repository    string            `json:"repository" binding:"required"`
+	Branch        string            `json:"branch"`
+	Environment   string            `json:"environment"`
+	Claims        map[string]string `json:"claims"`
+	ClientID      *string           `json:"clientId"`
+	ApplicationID *string           `json:"applicationId"`
+	Scopes        []string          `json:"scopes"`
+	IsActive      *bool             `json:"isActive"`
+}
+
+func newFederationService(c *gin.Context) *services.FederationService {
+	return services.NewFederationService(requestDB(c))
+}
+
+// federationError convertit une erreur du service de fédération en réponse HTTP
+func federationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrFederationInvalidIssuer),
+		errors.Is(err, services.ErrFederationInvalidBinding),
+		errors.Is(err, services.ErrFederationInvalidPolicy):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ListFederatedIssuers liste les émetteurs dont les jetons sont acceptés comme assertion client
+func ListFederatedIssuers(c *gin.Context) {
+	issuers, err := newFederationService(c).ListIssuers()
+	if err != nil {
+		federationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"issuers": issuers})
+}
+
+// GetFederatedIssuer récupère un émetteur fédéré et ses liaisons
+func GetFederatedIssuer(c *gin.Context) {
+	issuer, err := newFederationService(c).GetIssuer(c.Param("id"))
+	if err != nil {
+		federationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, issuer)
+}
+
+// CreateFederatedIssuer enregistre un émetteur fédéré (cluster Kubernetes, fournisseur OIDC)
+func CreateFederatedIssuer(c *gin.Context) {
+	var req FederatedIssuerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	issuer := &models.FederatedIssuer{IsActive: true}
+	req.apply(issuer)
+
+	if err := newFederationService(c).SaveIssuer(issuer); err != nil {
+		federationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, issuer)
+}
+
+// UpdateFederatedIssuer met à jour un émetteur fédéré ; son JWKS en cache est invalidé
+func UpdateFederatedIssuer(c *gin.Context) {
+	var req FederatedIssuerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	federationService := newFederationService(c)
+	issuer, err := federationService.GetIssuer(c.Param("id"))
+	if err != nil {
+		federationError(c, err)
+		return
+	}
+	issuer.Bindings = nil
+	issuer.Policies = nil
+	req.apply(issuer)
+
+	if err := federationService.SaveIssuer(issuer); err != nil {
+		federationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, issuer)
+}
+
+// DeleteFederatedIssuer supprime un émetteur fédéré et ses liaisons
+func DeleteFederatedIssuer(c *gin.Context) {
+	if err := newFederationService(c).DeleteIssuer(c.Param("id")); err != nil {
+		federationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Federated issuer deleted successfully"})
+}
+
+// CreateFederatedBinding lie un sujet de l'émetteur à un client OAuth ou à une application
+func CreateFederatedBinding(c *gin.Context) {
+	var req FederatedBindingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	subject := req.Subject
+	if subject == "" && req.Namespace != "" && req.ServiceAccount != "" {
+		subject = models.KubernetesServiceAccountSubject(req.Namespace, req.ServiceAccount)
+	}
+
+	binding := &models.FederatedIdentityBinding{
+		IssuerID:      c.Param("id"),
+		Subject:       subject,
+		ClientID:      req.ClientID,
+		ApplicationID: req.ApplicationID,
+		Scopes:        req.Scopes,
+		IsActive:      req.IsActive == nil || *req.IsActive,
+	}
+
+	if err := newFederationService(c).SaveBinding(binding); err != nil {
+		federationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, binding)
+}
+
+// DeleteFederatedBinding supprime une liaison d'un émetteur fédéré
+func DeleteFederatedBinding(c *gin.Context) {
+	if err := newFederationService(c).DeleteBinding(c.Param("id"), c.Param("bindingId")); err != nil {
+		federationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Federated binding deleted successfully"})
+}
+
+// CreateFederationTrustPolicy autorise les pipelines d'un dépôt à échanger leur jeton OIDC
+func CreateFederationTrustPolicy(c *gin.Context) {
+	var req FederationTrustPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	policy := &models.FederationTrustPolicy{
+		IssuerID:      c.Param("id"),
+		Name:          req.Name,
+		Repository:    req.Repository,
+		Branch:        req.Branch,
+		Environment:   req.Environment,
+		ClientID:      req.ClientID,
+		ApplicationID: req.ApplicationID,
+		Scopes:        req.Scopes,
+		IsActive:      req.IsActive == nil || *req.IsActive,
+	}
+	if len(req.Claims) > 0 {
+		policy.Claims = req.Claims
+	}
+
+	if err := newFederationService(c).SavePolicy(policy); err != nil {
+		federationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, policy)
+}
+
+// DeleteFederationTrustPolicy supprime une politique de confiance d'un émetteur fédéré
+func DeleteFederationTrustPolicy(c *gin.Context) {
+	if err := newFederationService(c).DeletePolicy(c.Param("id"), c.Param("policyId")); err != nil {
+		federationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Federation trust policy deleted successfully"})
+}
+
+// apply recopie la requête dans l'émetteur
+func (req FederatedIssuerRequest) apply(issuer *models.FederatedIssuer) {
+	issuer.Name = req.Name
+	issuer.Type = req.Type
+	issuer.Issuer = req.Issuer
+	issuer.JWKSURI = req.JWKSURI
+	issuer.JWKS = req.JWKS
+	issuer.Audiences = req.Audiences
+	issuer.Description = req.Description
+	if req.IsActive != nil {
+		issuer.IsActive = *req.IsActive
+	}
+}
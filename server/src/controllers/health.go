@@ -4,18 +4,67 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-identity/server/src/services"
 )
 
-// HealthCheck vérifie l'état de santé de l'API
+// HealthCheck vérifie l'état de santé de l'API à partir du dernier passage des sondes.
+// Il répond toujours 200 : la disponibilité est portée par /health/ready.
 func HealthCheck(c *gin.Context) {
 	if c.Request.Method == "HEAD" {
 		c.Status(http.StatusOK)
 		return
 	}
 
+	report := services.DefaultHealthMonitor.Report()
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "healthy",
-		"message": "API is running successfully",
-		"version": "1.0.0",
+		"status":    report.Status,
+		"message":   "API is running successfully",
+		"version":   "1.0.0",
+		"checkedAt": report.CheckedAt,
+		"checks":    publicHealthChecks(report.Checks),
 	})
 }
+
+// LivenessCheck indique seulement que le processus répond ; il ne dépend d'aucune dépendance,
+// pour qu'une panne de la base ne provoque pas de redémarrage en boucle
+func LivenessCheck(c *gin.Context) {
+	if c.Request.Method == "HEAD" {
+		c.Status(http.StatusOK)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "alive"})
+}
+
+// ReadinessCheck répond 503 tant que les sondes n'ont pas tourné ou qu'une dépendance critique est hors service.
+// Un état dégradé reste prêt.
+func ReadinessCheck(c *gin.Context) {
+	report := services.DefaultHealthMonitor.Report()
+	status := http.StatusOK
+	if !services.DefaultHealthMonitor.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	if c.Request.Method == "HEAD" {
+		c.Status(status)
+		return
+	}
+	c.JSON(status, gin.H{
+		"status":    report.Status,
+		"ready":     status == http.StatusOK,
+		"checkedAt": report.CheckedAt,
+		"checks":    publicHealthChecks(report.Checks),
+	})
+}
+
+// publicHealthChecks retire les messages d'erreur, qui peuvent révéler des adresses internes
+func publicHealthChecks(checks []services.HealthCheckResult) []gin.H {
+	public := make([]gin.H, 0, len(checks))
+	for _, check := range checks {
+		public = append(public, gin.H{
+			"name":      check.Name,
+			"status":    check.Status,
+			"critical":  check.Critical,
+			"latencyMs": check.LatencyMs,
+		})
+	}
+	return public
+}
@@ -1,11 +1,20 @@
 package controllers
 
 import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/skygenesisenterprise/aether-identity/server/src/models"
 	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+	"github.com/skygenesisenterprise/aether-identity/server/src/utils"
 )
 
 func CreateLog(c *gin.Context) {
@@ -15,7 +24,7 @@ func CreateLog(c *gin.Context) {
 		return
 	}
 
-	logService := services.NewLogService(services.DB)
+	logService := services.NewLogService(requestDB(c))
 	if err := logService.CreateLog(&log); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -26,7 +35,7 @@ func CreateLog(c *gin.Context) {
 
 func GetLog(c *gin.Context) {
 	id := c.Param("id")
-	logService := services.NewLogService(services.DB)
+	logService := services.NewLogService(requestDB(c))
 
 	log, err := logService.GetLog(id)
 	if err != nil {
@@ -37,20 +46,33 @@ func GetLog(c *gin.Context) {
 	c.JSON(http.StatusOK, log)
 }
 
+// ListLogs recherche dans les logs, du plus récent au plus ancien, avec pagination par curseur
 func ListLogs(c *gin.Context) {
-	logService := services.NewLogService(services.DB)
+	query, err := logQueryFromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !scopeLogQuery(c, &query) {
+		return
+	}
 
-	logs, err := logService.ListLogs(100, 0)
+	logService := services.NewLogService(requestDB(c))
+	logs, nextCursor, err := logService.QueryLogs(query)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, logs)
+	c.JSON(http.StatusOK, gin.H{"logs": logs, "nextCursor": nextCursor})
 }
 
 func ListMonitoringStatuses(c *gin.Context) {
-	logService := services.NewLogService(services.DB)
+	logService := services.NewLogService(requestDB(c))
 
 	statuses, err := logService.ListMonitoringStatuses()
 	if err != nil {
@@ -63,7 +85,7 @@ func ListMonitoringStatuses(c *gin.Context) {
 
 func GetLogStats(c *gin.Context) {
 	date := c.Query("date")
-	logService := services.NewLogService(services.DB)
+	logService := services.NewLogService(requestDB(c))
 
 	stats, err := logService.GetLogStats(date)
 	if err != nil {
@@ -74,10 +96,14 @@ func GetLogStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// GetLogDetails retourne un log, s'il relève du tenant de l'appelant
 func GetLogDetails(c *gin.Context) {
-	id := c.Param("id")
-	logService := services.NewLogService(services.DB)
-	logEntry, err := logService.GetLog(id)
+	var query services.LogQuery
+	if !scopeLogQuery(c, &query) {
+		return
+	}
+	logService := services.NewLogService(requestDB(c))
+	logEntry, err := logService.FindLog(c.Param("id"), query)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Log not found"})
 		return
@@ -86,31 +112,201 @@ func GetLogDetails(c *gin.Context) {
 	c.JSON(http.StatusOK, logEntry)
 }
 
+// ExportLogs exporte en flux (NDJSON, CSV ou CEF) tous les logs correspondant à la recherche
 func ExportLogs(c *gin.Context) {
-	logService := services.NewLogService(services.DB)
-	logs, err := logService.ListLogs(1000, 0)
+	query, err := logQueryFromRequest(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !scopeLogQuery(c, &query) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"logs": logs, "count": len(logs)})
+	logService := services.NewLogService(requestDB(c))
+	streamExport(c, "logs", func(write func(services.ExportRecord) error, flush func() error) error {
+		return logService.StreamLogs(query, func(logs []models.Log) error {
+			for i := range logs {
+				if err := write(services.LogRecord(&logs[i])); err != nil {
+					return err
+				}
+			}
+			return flush()
+		})
+	})
 }
 
+// GetLogStream diffuse en direct les logs, événements, activités de sécurité et entrées d'audit,
+// en Server-Sent Events ou en WebSocket lorsque la requête demande un Upgrade.
+// Filtres : kind, level, event (un préfixe se termine par un point : event=auth.), user_id, ip, tenant_id et q.
+// tenant_id n'est libre qu'avec tenant:admin : les autres abonnés sont limités à leur propre tenant,
+// celui de l'auteur de chaque message (utilisateur, client OAuth de l'audit, ou tenant de l'événement).
+// Un client reconnecté reprend après Last-Event-ID (en-tête, ou paramètre last_event_id) ;
+// sans position, tail rejoue les N derniers messages conservés.
 func GetLogStream(c *gin.Context) {
-	logService := services.NewLogService(services.DB)
-	logs, err := logService.ListLogs(50, 0)
+	filter := services.StreamFilter{
+		Kinds:     queryList(c, "kind"),
+		Levels:    queryList(c, "level"),
+		Events:    append(queryList(c, "event"), queryList(c, "type")...),
+		UserID:    c.Query("user_id"),
+		IPAddress: c.Query("ip"),
+		TenantID:  c.Query("tenant_id"),
+		Search:    strings.TrimSpace(c.Query("q")),
+	}
+	permissionService := services.NewPermissionService(requestDB(c))
+	tenantID, global, err := permissionService.TenantScope(c.GetString("user_id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
 		return
 	}
+	if !global {
+		filter.TenantID = tenantID
+		filter.TenantStrict = true
+	}
 
-	c.JSON(http.StatusOK, gin.H{"logs": logs, "stream": true})
+	// Les entrées d'audit ne sont diffusées qu'aux détenteurs de audit:read, comme /audit
+	filter.DenyKinds = []string{services.StreamKindAudit}
+	if allowed, err := permissionService.HasPermission(c.GetString("user_id"), "", "audit:read"); err == nil && allowed {
+		filter.DenyKinds = nil
+	}
+
+	lastEventID := cmp.Or(c.GetHeader("Last-Event-ID"), c.Query("last_event_id"))
+	tail, _ := strconv.Atoi(c.Query("tail"))
+	tail = min(max(tail, 0), 500)
+
+	if utils.IsWebSocketUpgrade(c.Request) {
+		streamLogsWebSocket(c, filter, lastEventID, tail)
+		return
+	}
+	streamLogsSSE(c, filter, lastEventID, tail)
+}
+
+// logStreamHeartbeat maintient la connexion ouverte à travers les proxys lorsqu'aucun message n'est émis
+const logStreamHeartbeat = 15 * time.Second
+
+// streamLogsSSE diffuse le flux en Server-Sent Events
+func streamLogsSSE(c *gin.Context, filter services.StreamFilter, lastEventID string, tail int) {
+	subscription, replay, resumed := services.DefaultLogBus.Subscribe(filter, lastEventID, tail)
+	defer subscription.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	write := func(event, id string, data interface{}) bool {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return true
+		}
+		if id != "" {
+			fmt.Fprintf(c.Writer, "id: %s\n", id)
+		}
+		if event != "" {
+			fmt.Fprintf(c.Writer, "event: %s\n", event)
+		}
+		if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", payload); err != nil {
+			return false
+		}
+		c.Writer.Flush()
+		return true
+	}
+
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+	if !resumed {
+		write("reset", "", gin.H{"lastEventId": lastEventID, "reason": "position no longer available, replaying buffered messages"})
+	}
+	for i := range replay {
+		if !write("", replay[i].EventID, replay[i]) {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(logStreamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case msg, ok := <-subscription.Messages():
+			if !ok {
+				return
+			}
+			if dropped := subscription.TakeDropped(); dropped > 0 {
+				write("lagged", "", gin.H{"dropped": dropped})
+			}
+			if !write("", msg.EventID, msg) {
+				return
+			}
+		}
+	}
+}
+
+// streamLogsWebSocket diffuse le flux en WebSocket, un message JSON par trame texte.
+// Les notifications reset et lagged sont envoyées sous la forme {"type": ..., ...}.
+func streamLogsWebSocket(c *gin.Context, filter services.StreamFilter, lastEventID string, tail int) {
+	ws, err := utils.UpgradeWebSocket(c.Writer, c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer ws.Close(1000, "")
+
+	subscription, replay, resumed := services.DefaultLogBus.Subscribe(filter, lastEventID, tail)
+	defer subscription.Close()
+
+	send := func(data interface{}) bool {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return true
+		}
+		return ws.WriteText(payload) == nil
+	}
+
+	if !resumed {
+		send(gin.H{"type": "reset", "lastEventId": lastEventID})
+	}
+	for i := range replay {
+		if !send(replay[i]) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(logStreamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ws.Done():
+			return
+		case <-heartbeat.C:
+			if ws.WritePing() != nil {
+				return
+			}
+		case msg, ok := <-subscription.Messages():
+			if !ok {
+				_ = ws.Close(1001, "stream closed")
+				return
+			}
+			if dropped := subscription.TakeDropped(); dropped > 0 {
+				send(gin.H{"type": "lagged", "dropped": dropped})
+			}
+			if !send(msg) {
+				return
+			}
+		}
+	}
 }
 
 func GetActionLogDetails(c *gin.Context) {
 	id := c.Param("id")
-	actionService := services.NewActionService(services.DB)
+	actionService := services.NewActionService(requestDB(c))
 	logEntry, err := actionService.GetActionLog(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Action log not found"})
@@ -120,17 +316,139 @@ func GetActionLogDetails(c *gin.Context) {
 	c.JSON(http.StatusOK, logEntry)
 }
 
+// GetSystemStatus renvoie l'état global issu des sondes de santé, avec le détail des erreurs par dépendance
 func GetSystemStatus(c *gin.Context) {
+	report := services.DefaultHealthMonitor.Report()
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "healthy",
-		"version": "1.0.0",
+		"status":    report.Status,
+		"uptime":    int64(services.Uptime().Seconds()),
+		"lastCheck": report.CheckedAt,
+		"version":   "1.0.0",
+		"checks":    report.Checks,
 	})
 }
 
+// GetHealthMetrics renvoie un instantané des ressources du processus : CPU (en % d'un cœur depuis
+// l'appel précédent, -1 hors Linux), mémoire, requêtes servies et pool de connexions
 func GetHealthMetrics(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"cpu":    0.0,
-		"memory": 0.0,
-		"disk":   0.0,
-	})
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	requests, avgLatency := services.HTTPRequestTotals()
+
+	metrics := gin.H{
+		"cpu":        services.ProcessCPUPercent(),
+		"memory":     mem.Sys,
+		"heapInUse":  mem.HeapInuse,
+		"goroutines": runtime.NumGoroutine(),
+		"requests": gin.H{
+			"total":            requests,
+			"averageLatencyMs": avgLatency * 1000,
+		},
+	}
+	if services.DB != nil {
+		if sqlDB, err := requestDB(c).DB(); err == nil {
+			stats := sqlDB.Stats()
+			metrics["database"] = gin.H{
+				"openConnections": stats.OpenConnections,
+				"inUse":           stats.InUse,
+				"idle":            stats.Idle,
+				"waitCount":       stats.WaitCount,
+			}
+		}
+	}
+	c.JSON(http.StatusOK, metrics)
+}
+
+// logQueryFromRequest lit les filtres d'une recherche dans les logs ou les événements :
+// from et to (RFC 3339), level et event (répétables ou séparés par des virgules), user_id, ip,
+// connection, q (texte libre), cursor et limit
+func logQueryFromRequest(c *gin.Context) (services.LogQuery, error) {
+	query := services.LogQuery{
+		Levels:     queryList(c, "level"),
+		EventTypes: append(queryList(c, "event"), queryList(c, "type")...),
+		UserID:     c.Query("user_id"),
+		IPAddress:  c.Query("ip"),
+		Connection: c.Query("connection"),
+		Search:     strings.TrimSpace(c.Query("q")),
+		TenantID:   c.Query("tenant_id"),
+		Cursor:     c.Query("cursor"),
+	}
+	for param, bound := range map[string]**time.Time{"from": &query.From, "to": &query.To} {
+		if value := c.Query(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return query, fmt.Errorf("invalid %s date, expected RFC 3339", param)
+			}
+			*bound = &t
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return query, errors.New("invalid limit")
+		}
+		query.Limit = limit
+	}
+	return query, nil
+}
+
+// scopeLogQuery limite une recherche de logs ou d'événements au tenant de l'appelant, comme le flux en direct :
+// seul un détenteur de tenant:admin choisit librement tenant_id. Répond 403 et retourne false en cas d'échec.
+func scopeLogQuery(c *gin.Context, query *services.LogQuery) bool {
+	tenantID, global, err := services.NewPermissionService(requestDB(c)).TenantScope(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		return false
+	}
+	if !global {
+		query.TenantID = tenantID
+		query.TenantStrict = true
+	}
+	return true
+}
+
+// queryList lit un paramètre répétable dont les valeurs peuvent aussi être séparées par des virgules
+func queryList(c *gin.Context, name string) []string {
+	var values []string
+	for _, value := range c.QueryArray(name) {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	}
+	return values
+}
+
+// streamExport envoie un export en flux dans le format demandé par ?format= (ndjson par défaut, csv, cef).
+// produce écrit les enregistrements avec write et appelle flush après chaque lot pour les transmettre au client.
+func streamExport(c *gin.Context, name string, produce func(write func(services.ExportRecord) error, flush func() error) error) {
+	format := c.DefaultQuery("format", services.ExportFormatNDJSON)
+	exporter, err := services.NewLogExporter(format, c.Writer)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType, extension := services.ExportContentType(format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%s-%s.%s", name, time.Now().UTC().Format("20060102T150405Z"), extension)))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	flush := func() error {
+		if err := exporter.Flush(); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+	if err := produce(exporter.Write, flush); err != nil {
+		// Les en-têtes sont déjà envoyés : l'export est interrompu et le client reçoit un fichier tronqué
+		fmt.Printf("Export: %s export interrupted: %v\n", name, err)
+		return
+	}
+	if err := flush(); err != nil {
+		fmt.Printf("Export: %s export interrupted: %v\n", name, err)
+	}
 }
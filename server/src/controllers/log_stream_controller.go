@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+)
+
+// logStreamError renvoie 404 pour un flux inconnu, sinon le statut donné
+func logStreamError(c *gin.Context, status int, err error) {
+	if errors.Is(err, services.ErrLogStreamNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// ListLogStreams liste les flux de logs vers les SIEM et leur état
+func ListLogStreams(c *gin.Context) {
+	streams, err := services.NewLogStreamService(requestDB(c)).ListStreams()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, streams)
+}
+
+// GetLogStreamDetails renvoie un flux de logs
+func GetLogStreamDetails(c *gin.Context) {
+	stream, err := services.NewLogStreamService(requestDB(c)).GetStream(c.Param("id"))
+	if err != nil {
+		logStreamError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stream)
+}
+
+// CreateLogStream crée un flux de logs
+func CreateLogStream(c *gin.Context) {
+	var req models.LogStreamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	stream, err := services.NewLogStreamService(requestDB(c)).SaveStream("", &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	auditTarget(c, "log-streams", stream.ID, nil, stream)
+	c.JSON(http.StatusCreated, stream)
+}
+
+// UpdateLogStream modifie un flux de logs ; le secret n'est remplacé que s'il est fourni
+func UpdateLogStream(c *gin.Context) {
+	var req models.LogStreamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	logStreamService := services.NewLogStreamService(requestDB(c))
+	before, err := logStreamService.GetStream(c.Param("id"))
+	if err != nil {
+		logStreamError(c, http.StatusInternalServerError, err)
+		return
+	}
+	stream, err := logStreamService.SaveStream(c.Param("id"), &req)
+	if err != nil {
+		logStreamError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	auditTarget(c, "log-streams", stream.ID, before, stream)
+	c.JSON(http.StatusOK, stream)
+}
+
+// DeleteLogStream supprime un flux de logs et sa file d'erreurs
+func DeleteLogStream(c *gin.Context) {
+	if err := services.NewLogStreamService(requestDB(c)).DeleteStream(c.Param("id")); err != nil {
+		logStreamError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// TestLogStream envoie un message de test à la destination du flux
+func TestLogStream(c *gin.Context) {
+	if err := services.NewLogStreamService(requestDB(c)).TestStream(c.Param("id")); err != nil {
+		logStreamError(c, http.StatusBadGateway, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ListLogStreamDeadLetters liste les lots qui n'ont pas pu être livrés
+func ListLogStreamDeadLetters(c *gin.Context) {
+	page, limit := provisioningPage(c)
+	letters, total, err := services.NewLogStreamService(requestDB(c)).ListDeadLetters(c.Param("id"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deadLetters": letters,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+	})
+}
+
+// ReplayLogStreamDeadLetters renvoie les lots non livrés vers la destination
+func ReplayLogStreamDeadLetters(c *gin.Context) {
+	replayed, err := services.NewLogStreamService(requestDB(c)).ReplayDeadLetters(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrLogStreamNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"replayed": replayed, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"replayed": replayed})
+}
+
+// PurgeLogStreamDeadLetters supprime les lots non livrés
+func PurgeLogStreamDeadLetters(c *gin.Context) {
+	deleted, err := services.NewLogStreamService(requestDB(c)).PurgeDeadLetters(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
@@ -9,7 +9,7 @@ import (
 )
 
 func ListMarketplaceIntegrations(c *gin.Context) {
-	marketplaceService := services.NewMarketplaceService(services.DB)
+	marketplaceService := services.NewMarketplaceService(requestDB(c))
 
 	integrations, err := marketplaceService.ListMarketplaceIntegrations()
 	if err != nil {
@@ -22,7 +22,7 @@ func ListMarketplaceIntegrations(c *gin.Context) {
 
 func GetMarketplaceIntegration(c *gin.Context) {
 	id := c.Param("id")
-	marketplaceService := services.NewMarketplaceService(services.DB)
+	marketplaceService := services.NewMarketplaceService(requestDB(c))
 
 	integration, err := marketplaceService.GetMarketplaceIntegration(id)
 	if err != nil {
@@ -35,7 +35,7 @@ func GetMarketplaceIntegration(c *gin.Context) {
 
 func InstallMarketplaceIntegration(c *gin.Context) {
 	id := c.Param("id")
-	marketplaceService := services.NewMarketplaceService(services.DB)
+	marketplaceService := services.NewMarketplaceService(requestDB(c))
 
 	integration, err := marketplaceService.GetMarketplaceIntegration(id)
 	if err != nil {
@@ -59,7 +59,7 @@ func CreateMarketplaceIntegration(c *gin.Context) {
 		return
 	}
 
-	marketplaceService := services.NewMarketplaceService(services.DB)
+	marketplaceService := services.NewMarketplaceService(requestDB(c))
 	if err := marketplaceService.CreateMarketplaceIntegration(&integration); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -78,7 +78,7 @@ func InstallIntegration(c *gin.Context) {
 
 func UninstallIntegration(c *gin.Context) {
 	id := c.Param("id")
-	marketplaceService := services.NewMarketplaceService(services.DB)
+	marketplaceService := services.NewMarketplaceService(requestDB(c))
 	integration, err := marketplaceService.GetMarketplaceIntegration(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Integration not found"})
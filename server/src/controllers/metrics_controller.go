@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-identity/server/src/config"
+	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+)
+
+// GetMetrics expose les métriques au format texte de Prometheus.
+// Le collecteur doit envoyer METRICS_TOKEN en Bearer ; sans jeton configuré, l'endpoint est désactivé.
+func GetMetrics(c *gin.Context) {
+	token := config.LoadConfig().MetricsToken
+	if token == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Metrics are disabled: set METRICS_TOKEN to enable them"})
+		return
+	}
+	provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid metrics token"})
+		return
+	}
+
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := services.WriteMetrics(c.Writer, requestDB(c)); err != nil {
+		_ = c.Error(err)
+	}
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -22,6 +23,9 @@ type OAuthTokenResponse struct {
 	RefreshToken string `json:"refresh_token,omitempty"`
 	IDToken      string `json:"id_token,omitempty"`
 	Scope        string `json:"scope,omitempty"`
+
+	// Échange de jeton RFC 8693
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
 }
 
 // AuthorizationHandler gère les requêtes d'autorisation OAuth2
@@ -34,7 +38,7 @@ func AuthorizationHandler(c *gin.Context) {
 	}
 
 	// Valider le client
-	oauthService := services.NewOAuthService(services.DB, services.NewJWTService(config.LoadConfig().JWTSecret, config.LoadConfig().AccessTokenExp, config.LoadConfig().RefreshTokenExp))
+	oauthService := services.NewOAuthService(requestDB(c), services.NewJWTService(config.LoadConfig().JWTSecret, config.LoadConfig().AccessTokenExp, config.LoadConfig().RefreshTokenExp))
 	client, err := oauthService.GetClientByID(authReq.ClientID)
 	if err != nil {
 		c.Redirect(http.StatusFound, buildErrorRedirect(authReq.RedirectURI, "invalid_client", "Invalid client"))
@@ -99,7 +103,7 @@ func AuthorizationHandler(c *gin.Context) {
 	if authReq.ResponseType == "token" {
 		// Flux implicite (non recommandé pour la production)
 		// Rediriger avec le token directement dans l'URL
-		redirectURL = buildImplicitFlowRedirect(authReq, uint(userID.(uint)), client, validScopes)
+		redirectURL = buildImplicitFlowRedirect(c, authReq, uint(userID.(uint)), client, validScopes)
 	}
 
 	c.Redirect(http.StatusFound, redirectURL)
@@ -117,8 +121,31 @@ func TokenHandler(c *gin.Context) {
 		return
 	}
 
+	// Le client, et non l'utilisateur, est l'auteur de la demande de jeton dans le journal d'audit
+	auditDetails(c).Actor = &services.AuditActor{Type: models.AuditActorClient, ID: tokenReq.ClientID}
+	auditDetails(c).Metadata = map[string]interface{}{"grant_type": tokenReq.GrantType}
+
+	// Seuls les jetons effectivement délivrés sont comptés, ce qui borne les types de grant possibles
+	defer func() {
+		if c.Writer.Status() == http.StatusOK {
+			services.RecordTokenIssued(tokenReq.GrantType)
+		}
+	}()
+
 	cfg := config.LoadConfig()
-	oauthService := services.NewOAuthService(services.DB, services.NewJWTService(cfg.JWTSecret, cfg.AccessTokenExp, cfg.RefreshTokenExp))
+	oauthService := services.NewOAuthService(requestDB(c), services.NewJWTService(cfg.JWTSecret, cfg.AccessTokenExp, cfg.RefreshTokenExp))
+
+	// Les workloads fédérés s'authentifient par assertion, sans secret client
+	if tokenReq.ClientAssertionType != "" || tokenReq.ClientAssertion != "" {
+		handleClientAssertion(c, tokenReq, oauthService)
+		return
+	}
+
+	// Les pipelines CI échangent le jeton OIDC de leur plateforme, sans secret client
+	if tokenReq.GrantType == services.GrantTypeTokenExchange {
+		handleTokenExchange(c, tokenReq, oauthService)
+		return
+	}
 
 	// Valider le client
 	client, err := oauthService.ValidateClient(tokenReq.ClientID, tokenReq.ClientSecret)
@@ -163,7 +190,7 @@ func UserInfoHandler(c *gin.Context) {
 	tokenString := accessToken[7:]
 
 	cfg := config.LoadConfig()
-	oauthService := services.NewOAuthService(services.DB, services.NewJWTService(cfg.JWTSecret, cfg.AccessTokenExp, cfg.RefreshTokenExp))
+	oauthService := services.NewOAuthService(requestDB(c), services.NewJWTService(cfg.JWTSecret, cfg.AccessTokenExp, cfg.RefreshTokenExp))
 
 	// Valider le token
 	token, err := oauthService.ValidateToken(tokenString)
@@ -185,7 +212,7 @@ func UserInfoHandler(c *gin.Context) {
 	}
 
 	// Récupérer l'utilisateur
-	userService := services.NewUserService(services.DB)
+	userService := services.NewUserService(requestDB(c))
 	user, err := userService.GetUserByID(fmt.Sprintf("%d", uint(claims["sub"].(float64))))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -225,7 +252,10 @@ func RevokeHandler(c *gin.Context) {
 		return
 	}
 
-	oauthService := services.NewOAuthService(services.DB, services.NewJWTService(config.LoadConfig().JWTSecret, config.LoadConfig().AccessTokenExp, config.LoadConfig().RefreshTokenExp))
+	auditDetails(c).Actor = &services.AuditActor{Type: models.AuditActorClient, ID: clientID}
+	auditDetails(c).Metadata = map[string]interface{}{"token_type_hint": tokenTypeHint}
+
+	oauthService := services.NewOAuthService(requestDB(c), services.NewJWTService(config.LoadConfig().JWTSecret, config.LoadConfig().AccessTokenExp, config.LoadConfig().RefreshTokenExp))
 
 	// Valider le client
 	_, err := oauthService.ValidateClient(clientID, clientSecret)
@@ -297,7 +327,7 @@ func handleAuthorizationCodeGrant(c *gin.Context, tokenReq models.TokenRequest,
 	oauthService.DeleteAuthorizationCode(tokenReq.Code)
 
 	// Récupérer l'utilisateur
-	userService := services.NewUserService(services.DB)
+	userService := services.NewUserService(requestDB(c))
 	user, err := userService.GetUserByID(authCode.UserID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -307,8 +337,13 @@ func handleAuthorizationCodeGrant(c *gin.Context, tokenReq models.TokenRequest,
 		return
 	}
 
+	actionResult, err := runPostLoginActions(c, user, &services.ActionEventClient{ClientID: client.ClientID, Name: client.Name}, authCode.Scopes)
+	if abortOnOAuthActionResult(c, actionResult, err) {
+		return
+	}
+
 	// Générer les tokens
-	accessToken, err := oauthService.GenerateAccessToken(user, client, authCode.Scopes)
+	accessToken, err := oauthService.GenerateAccessTokenWithClaims(user, client, authCode.Scopes, actionResult.AccessTokenClaims)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":             "server_error",
@@ -346,7 +381,7 @@ func handleAuthorizationCodeGrant(c *gin.Context, tokenReq models.TokenRequest,
 	}
 
 	// Générer l'ID token pour OpenID Connect
-	idToken, err := oauthService.GenerateIDToken(user, client, authCode.Scopes, "")
+	idToken, err := oauthService.GenerateIDTokenWithClaims(user, client, authCode.Scopes, "", actionResult.IDTokenClaims)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":             "server_error",
@@ -389,7 +424,7 @@ func handleRefreshTokenGrant(c *gin.Context, tokenReq models.TokenRequest, clien
 	}
 
 	// Récupérer l'utilisateur
-	userService := services.NewUserService(services.DB)
+	userService := services.NewUserService(requestDB(c))
 	user, err := userService.GetUserByID(refreshToken.UserID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -399,8 +434,13 @@ func handleRefreshTokenGrant(c *gin.Context, tokenReq models.TokenRequest, clien
 		return
 	}
 
+	actionResult, err := runPostLoginActions(c, user, &services.ActionEventClient{ClientID: client.ClientID, Name: client.Name}, []string{"openid", "profile", "email"})
+	if abortOnOAuthActionResult(c, actionResult, err) {
+		return
+	}
+
 	// Générer un nouveau token d'accès
-	accessToken, err := oauthService.GenerateAccessToken(user, client, []string{"openid", "profile", "email"})
+	accessToken, err := oauthService.GenerateAccessTokenWithClaims(user, client, []string{"openid", "profile", "email"}, actionResult.AccessTokenClaims)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":             "server_error",
@@ -420,7 +460,7 @@ func handleRefreshTokenGrant(c *gin.Context, tokenReq models.TokenRequest, clien
 	}
 
 	// Générer un nouvel ID token
-	idToken, err := oauthService.GenerateIDToken(user, client, []string{"openid", "profile", "email"}, "")
+	idToken, err := oauthService.GenerateIDTokenWithClaims(user, client, []string{"openid", "profile", "email"}, "", actionResult.IDTokenClaims)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":             "server_error",
@@ -453,9 +493,10 @@ func handlePasswordGrant(c *gin.Context, tokenReq models.TokenRequest, client *m
 	}
 
 	// Authentifier l'utilisateur
-	userService := services.NewUserService(services.DB)
+	userService := services.NewUserService(requestDB(c))
 	user, err := userService.AuthenticateUser(tokenReq.Username, tokenReq.Password)
 	if err != nil {
+		services.TrackLogin(requestDB(c), services.LoginActivity{TenantID: services.ResolveTenantID(requestDB(c), client.ClientID, ""), ClientID: client.ClientID, Result: loginFailureResult(err)})
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error":             "invalid_grant",
 			"error_description": "Invalid username or password",
@@ -463,8 +504,14 @@ func handlePasswordGrant(c *gin.Context, tokenReq models.TokenRequest, client *m
 		return
 	}
 
+	actionResult, err := runPostLoginActions(c, user, &services.ActionEventClient{ClientID: client.ClientID, Name: client.Name}, []string{"openid", "profile", "email"})
+	if abortOnOAuthActionResult(c, actionResult, err) {
+		return
+	}
+	services.TrackLogin(requestDB(c), services.LoginActivity{UserID: user.ID, TenantID: services.ResolveTenantID(requestDB(c), client.ClientID, user.ID), ClientID: client.ClientID, Result: services.LoginSuccess})
+
 	// Générer les tokens
-	accessToken, err := oauthService.GenerateAccessToken(user, client, []string{"openid", "profile", "email"})
+	accessToken, err := oauthService.GenerateAccessTokenWithClaims(user, client, []string{"openid", "profile", "email"}, actionResult.AccessTokenClaims)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":             "server_error",
@@ -502,7 +549,7 @@ func handlePasswordGrant(c *gin.Context, tokenReq models.TokenRequest, client *m
 	}
 
 	// Générer l'ID token
-	idToken, err := oauthService.GenerateIDToken(user, client, []string{"openid", "profile", "email"}, "")
+	idToken, err := oauthService.GenerateIDTokenWithClaims(user, client, []string{"openid", "profile", "email"}, "", actionResult.IDTokenClaims)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":             "server_error",
@@ -526,8 +573,28 @@ func handlePasswordGrant(c *gin.Context, tokenReq models.TokenRequest, client *m
 
 // handleClientCredentialsGrant gère le flux client credentials
 func handleClientCredentialsGrant(c *gin.Context, tokenReq models.TokenRequest, client *models.OAuthClient, oauthService *services.OAuthService) {
+	// Exécuter les actions credentials-exchange : refus ou claims personnalisés
+	actionEvent := newActionEvent(c, models.ActionTriggerCredentialsExchange)
+	actionEvent.Client = &services.ActionEventClient{ClientID: client.ClientID, Name: client.Name}
+	actionEvent.Scopes = []string{"api"}
+	actionResult, err := services.NewActionService(requestDB(c)).ExecuteTrigger(c.Request.Context(), actionEvent)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "An action failed to run",
+		})
+		return
+	}
+	if actionResult.Denied {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":             "access_denied",
+			"error_description": actionResult.DenyReason,
+		})
+		return
+	}
+
 	// Générer un token d'accès pour le client
-	accessToken, err := oauthService.GenerateAccessToken(nil, client, []string{"api"})
+	accessToken, err := oauthService.GenerateAccessTokenWithClaims(nil, client, []string{"api"}, actionResult.AccessTokenClaims)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":             "server_error",
@@ -557,24 +624,180 @@ func handleClientCredentialsGrant(c *gin.Context, tokenReq models.TokenRequest,
 	c.JSON(http.StatusOK, response)
 }
 
+// handleClientAssertion gère le flux client credentials authentifié par une assertion RFC 7523
+// signée par un émetteur fédéré (jeton projeté de compte de service Kubernetes, OIDC externe)
+func handleClientAssertion(c *gin.Context, tokenReq models.TokenRequest, oauthService *services.OAuthService) {
+	if tokenReq.ClientAssertionType != services.ClientAssertionTypeJWTBearer || tokenReq.ClientAssertion == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "client_assertion_type must be " + services.ClientAssertionTypeJWTBearer,
+		})
+		return
+	}
+	if tokenReq.GrantType != "client_credentials" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "unsupported_grant_type",
+			"error_description": "Client assertions are only supported with the client_credentials grant",
+		})
+		return
+	}
+
+	federationService := services.NewFederationService(requestDB(c))
+	identity, err := federationService.ValidateAssertion(c.Request.Context(), tokenReq.ClientAssertion)
+	if err != nil {
+		description := "Invalid client assertion"
+		switch {
+		case errors.Is(err, services.ErrFederationUntrustedIssuer),
+			errors.Is(err, services.ErrFederationUnknownSubject),
+			errors.Is(err, services.ErrFederationInactiveClient):
+			description = err.Error()
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "invalid_client",
+			"error_description": description,
+		})
+		return
+	}
+
+	if tokenReq.ClientID != "" && tokenReq.ClientID != identity.ClientID {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":             "invalid_client",
+			"error_description": "client_id does not match the assertion subject",
+		})
+		return
+	}
+
+	issueFederatedToken(c, tokenReq, identity, oauthService, "")
+}
+
+// handleTokenExchange échange le jeton OIDC d'un pipeline CI (GitHub, GitLab, Gitea, Jenkins)
+// contre un token Aether, selon la première politique de confiance qui correspond (RFC 8693)
+func handleTokenExchange(c *gin.Context, tokenReq models.TokenRequest, oauthService *services.OAuthService) {
+	if tokenReq.SubjectToken == "" || (tokenReq.SubjectTokenType != services.TokenTypeJWT && tokenReq.SubjectTokenType != services.TokenTypeIDToken) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_request",
+			"error_description": "subject_token is required and subject_token_type must be " + services.TokenTypeJWT + " or " + services.TokenTypeIDToken,
+		})
+		return
+	}
+
+	federationService := services.NewFederationService(requestDB(c))
+	identity, err := federationService.ExchangeSubjectToken(c.Request.Context(), tokenReq.SubjectToken)
+	if err != nil {
+		description := "Invalid subject token"
+		switch {
+		case errors.Is(err, services.ErrFederationUntrustedIssuer),
+			errors.Is(err, services.ErrFederationNoPolicy),
+			errors.Is(err, services.ErrFederationInactiveClient):
+			description = err.Error()
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_grant",
+			"error_description": description,
+		})
+		return
+	}
+
+	if tokenReq.ClientID != "" && tokenReq.ClientID != identity.ClientID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             "invalid_grant",
+			"error_description": "client_id does not match the trust policy",
+		})
+		return
+	}
+
+	issueFederatedToken(c, tokenReq, identity, oauthService, services.TokenTypeAccessToken)
+}
+
+// issueFederatedToken délivre le token d'accès d'une identité fédérée ; sans scope demandé,
+// l'identité reçoit tous les scopes de sa liaison ou de sa politique
+func issueFederatedToken(c *gin.Context, tokenReq models.TokenRequest, identity *services.FederatedIdentity, oauthService *services.OAuthService, issuedTokenType string) {
+	scopes := identity.Scopes
+	if requested := services.ParseScopes(tokenReq.Scope); len(requested) > 0 {
+		var err error
+		scopes, err = oauthService.ValidateScopes(requested, identity.Scopes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":             "invalid_scope",
+				"error_description": "Requested scopes are not granted to this identity",
+			})
+			return
+		}
+	}
+
+	accessToken, err := oauthService.GenerateFederatedAccessToken(identity, scopes, tokenReq.Audience)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "Failed to generate access token",
+		})
+		return
+	}
+
+	// ACCESS_TOKEN_EXP est exprimé en minutes ; expires_in est en secondes
+	c.JSON(http.StatusOK, OAuthTokenResponse{
+		AccessToken:     accessToken,
+		TokenType:       "Bearer",
+		ExpiresIn:       config.LoadConfig().AccessTokenExp * 60,
+		Scope:           strings.Join(scopes, " "),
+		IssuedTokenType: issuedTokenType,
+	})
+}
+
+// abortOnOAuthActionResult répond au format d'erreur OAuth quand une action post-login échoue,
+// refuse l'accès ou exige une redirection que l'endpoint token ne peut pas suivre
+func abortOnOAuthActionResult(c *gin.Context, result *services.ActionResult, err error) bool {
+	switch {
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":             "server_error",
+			"error_description": "An action failed to run",
+		})
+	case result.Denied:
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":             "access_denied",
+			"error_description": actionDenyReason(result),
+		})
+	case result.RedirectURL != "":
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":             "interaction_required",
+			"error_description": "An action requires the user to complete an interactive step",
+			"redirect":          result.RedirectURL,
+		})
+	default:
+		return false
+	}
+	return true
+}
+
 // buildErrorRedirect construit une URL de redirection avec une erreur
 func buildErrorRedirect(redirectURI, errorType, errorDescription string) string {
 	return redirectURI + "?error=" + errorType + "&error_description=" + url.QueryEscape(errorDescription)
 }
 
 // buildImplicitFlowRedirect construit une URL de redirection pour le flux implicite
-func buildImplicitFlowRedirect(authReq models.AuthorizationRequest, userID uint, client *models.OAuthClient, scopes []string) string {
+func buildImplicitFlowRedirect(c *gin.Context, authReq models.AuthorizationRequest, userID uint, client *models.OAuthClient, scopes []string) string {
 	cfg := config.LoadConfig()
-	oauthService := services.NewOAuthService(services.DB, services.NewJWTService(cfg.JWTSecret, cfg.AccessTokenExp, cfg.RefreshTokenExp))
+	oauthService := services.NewOAuthService(requestDB(c), services.NewJWTService(cfg.JWTSecret, cfg.AccessTokenExp, cfg.RefreshTokenExp))
 
-	userService := services.NewUserService(services.DB)
+	userService := services.NewUserService(requestDB(c))
 	user, _ := userService.GetUserByID(fmt.Sprintf("%d", userID))
 
+	actionResult, err := runPostLoginActions(c, user, &services.ActionEventClient{ClientID: client.ClientID, Name: client.Name}, scopes)
+	switch {
+	case err != nil:
+		return buildErrorRedirect(authReq.RedirectURI, "server_error", "An action failed to run")
+	case actionResult.Denied:
+		return buildErrorRedirect(authReq.RedirectURI, "access_denied", actionDenyReason(actionResult))
+	case actionResult.RedirectURL != "":
+		return actionResult.RedirectURL
+	}
+
 	// Générer le token d'accès
-	accessToken, _ := oauthService.GenerateAccessToken(user, client, scopes)
+	accessToken, _ := oauthService.GenerateAccessTokenWithClaims(user, client, scopes, actionResult.AccessTokenClaims)
 
 	// Générer l'ID token
-	idToken, _ := oauthService.GenerateIDToken(user, client, scopes, authReq.Nonce)
+	idToken, _ := oauthService.GenerateIDTokenWithClaims(user, client, scopes, authReq.Nonce, actionResult.IDTokenClaims)
 
 	return authReq.RedirectURI + "#access_token=" + accessToken + "&token_type=Bearer&expires_in=" + strconv.Itoa(cfg.AccessTokenExp) + "&id_token=" + idToken + "&state=" + authReq.State
 }
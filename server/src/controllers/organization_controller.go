@@ -15,7 +15,7 @@ func CreateOrganization(c *gin.Context) {
 		return
 	}
 
-	orgService := services.NewOrganizationService(services.DB)
+	orgService := services.NewOrganizationService(requestDB(c))
 	if err := orgService.CreateOrganization(&org); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -26,7 +26,7 @@ func CreateOrganization(c *gin.Context) {
 
 func GetOrganization(c *gin.Context) {
 	id := c.Param("id")
-	orgService := services.NewOrganizationService(services.DB)
+	orgService := services.NewOrganizationService(requestDB(c))
 
 	org, err := orgService.GetOrganizationByID(id)
 	if err != nil {
@@ -38,7 +38,7 @@ func GetOrganization(c *gin.Context) {
 }
 
 func ListOrganizations(c *gin.Context) {
-	orgService := services.NewOrganizationService(services.DB)
+	orgService := services.NewOrganizationService(requestDB(c))
 
 	orgs, err := orgService.ListOrganizations()
 	if err != nil {
@@ -58,7 +58,7 @@ func UpdateOrganization(c *gin.Context) {
 	}
 
 	org.ID = id
-	orgService := services.NewOrganizationService(services.DB)
+	orgService := services.NewOrganizationService(requestDB(c))
 
 	if err := orgService.UpdateOrganization(&org); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -70,7 +70,7 @@ func UpdateOrganization(c *gin.Context) {
 
 func DeleteOrganization(c *gin.Context) {
 	id := c.Param("id")
-	orgService := services.NewOrganizationService(services.DB)
+	orgService := services.NewOrganizationService(requestDB(c))
 
 	if err := orgService.DeleteOrganization(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -82,7 +82,7 @@ func DeleteOrganization(c *gin.Context) {
 
 func ListOrganizationMembers(c *gin.Context) {
 	orgID := c.Param("id")
-	orgService := services.NewOrganizationService(services.DB)
+	orgService := services.NewOrganizationService(requestDB(c))
 
 	members, err := orgService.GetMembers(orgID)
 	if err != nil {
@@ -104,7 +104,7 @@ func AddOrganizationMember(c *gin.Context) {
 		return
 	}
 
-	orgService := services.NewOrganizationService(services.DB)
+	orgService := services.NewOrganizationService(requestDB(c))
 	if err := orgService.AddMember(orgID, req.UserID, req.RoleID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -117,7 +117,7 @@ func RemoveOrganizationMember(c *gin.Context) {
 	orgID := c.Param("id")
 	userID := c.Param("userId")
 
-	orgService := services.NewOrganizationService(services.DB)
+	orgService := services.NewOrganizationService(requestDB(c))
 	if err := orgService.RemoveMember(orgID, userID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -137,7 +137,7 @@ func UpdateOrganizationMember(c *gin.Context) {
 		return
 	}
 
-	orgService := services.NewOrganizationService(services.DB)
+	orgService := services.NewOrganizationService(requestDB(c))
 	if err := orgService.UpdateMemberRole(orgID, userID, req.RoleID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
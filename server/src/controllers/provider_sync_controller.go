@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+	"gorm.io/gorm"
+)
+
+// ProviderSyncRequireDatabase interrompt la requête lorsque la base de données est indisponible
+func ProviderSyncRequireDatabase(c *gin.Context) {
+	if services.DB == nil {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Database connection not available"})
+		return
+	}
+	c.Next()
+}
+
+// IngestProviderSync enregistre un événement de synchronisation d'un fournisseur externe
+func IngestProviderSync(c *gin.Context) {
+	var event services.ProviderSyncEvent
+	if err := c.ShouldBindJSON(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	// Le fournisseur de l'URL fait foi
+	event.Provider = c.Param("provider")
+
+	// Les entrées d'AuditLogger rejoignent le journal d'audit chaîné au lieu des entités synchronisées
+	if event.EventType == "audit" {
+		entry, err := services.NewAuditService(requestDB(c)).RecordProviderEvent(event)
+		if err != nil {
+			if errors.Is(err, services.ErrProviderSyncInvalidEvent) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record audit entry"})
+			return
+		}
+		c.JSON(http.StatusCreated, entry)
+		return
+	}
+
+	providerSyncService := services.NewProviderSyncService(requestDB(c))
+	entity, err := providerSyncService.Ingest(event)
+	if err != nil {
+		if errors.Is(err, services.ErrProviderSyncInvalidEvent) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sync entity"})
+		return
+	}
+
+	if entity == nil {
+		c.JSON(http.StatusOK, gin.H{"deleted": true})
+		return
+	}
+	c.JSON(http.StatusOK, entity)
+}
+
+// ListProviderEntities liste les entités synchronisées d'un fournisseur
+func ListProviderEntities(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	var installationID *int64
+	if param := c.Query("installation_id"); param != "" {
+		id, err := strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid installation_id"})
+			return
+		}
+		installationID = &id
+	}
+
+	providerSyncService := services.NewProviderSyncService(requestDB(c))
+	entities, total, err := providerSyncService.List(c.Param("provider"), c.Query("entity_type"), installationID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list entities"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entities": entities,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+// GetProviderEntity renvoie une entité synchronisée
+func GetProviderEntity(c *gin.Context) {
+	providerSyncService := services.NewProviderSyncService(requestDB(c))
+	entity, err := providerSyncService.Get(c.Param("provider"), c.Param("entityType"), c.Param("externalId"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Entity not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get entity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entity)
+}
+
+// DeleteProviderEntity supprime une entité qui n'existe plus chez le fournisseur
+func DeleteProviderEntity(c *gin.Context) {
+	providerSyncService := services.NewProviderSyncService(requestDB(c))
+	if err := providerSyncService.Delete(c.Param("provider"), c.Param("entityType"), c.Param("externalId")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete entity"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+	"gorm.io/gorm"
+)
+
+// provisioningPage lit les paramètres de pagination page et limit
+func provisioningPage(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+	return page, limit
+}
+
+// GetApplicationProvisioning renvoie la configuration de provisioning SCIM d'une application
+func GetApplicationProvisioning(c *gin.Context) {
+	provisioningService := services.NewProvisioningService(requestDB(c))
+
+	config, err := provisioningService.GetConfig(c.Param("id"))
+	if errors.Is(err, services.ErrProvisioningNotConfigured) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"config":         config,
+		"hasSecret":      config.Secret != nil && *config.Secret != "",
+		"defaultMapping": services.DefaultProvisioningMapping,
+	})
+}
+
+// UpdateApplicationProvisioning crée ou met à jour la configuration de provisioning SCIM
+func UpdateApplicationProvisioning(c *gin.Context) {
+	var req models.ApplicationProvisioningRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	provisioningService := services.NewProvisioningService(requestDB(c))
+	config, err := provisioningService.SaveConfig(c.Param("id"), &req)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+// DeleteApplicationProvisioning supprime la configuration de provisioning SCIM
+func DeleteApplicationProvisioning(c *gin.Context) {
+	provisioningService := services.NewProvisioningService(requestDB(c))
+
+	if err := provisioningService.DeleteConfig(c.Param("id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// TestApplicationProvisioning vérifie la connexion à l'endpoint SCIM de l'application
+func TestApplicationProvisioning(c *gin.Context) {
+	provisioningService := services.NewProvisioningService(requestDB(c))
+
+	if err := provisioningService.TestConnection(c.Param("id")); err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, services.ErrProvisioningNotConfigured) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ReconcileApplicationProvisioning compare l'état local et distant et corrige les écarts
+func ReconcileApplicationProvisioning(c *gin.Context) {
+	dryRun := c.DefaultQuery("dryRun", "true") != "false"
+	provisioningService := services.NewProvisioningService(requestDB(c))
+
+	report, err := provisioningService.Reconcile(c.Param("id"), dryRun)
+	if errors.Is(err, services.ErrProvisioningNotConfigured) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// SyncApplicationProvisioningUser planifie l'envoi immédiat d'un utilisateur vers l'application
+func SyncApplicationProvisioningUser(c *gin.Context) {
+	provisioningService := services.NewProvisioningService(requestDB(c))
+
+	if _, err := provisioningService.GetConfig(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	userService := services.NewUserService(requestDB(c))
+	user, err := userService.GetUserByID(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := provisioningService.EnqueueUserEvent(user.ID, services.UserLifecycleEvent(user)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"success": true})
+}
+
+// ListApplicationProvisioningTasks liste les tâches de provisioning d'une application
+func ListApplicationProvisioningTasks(c *gin.Context) {
+	page, limit := provisioningPage(c)
+	provisioningService := services.NewProvisioningService(requestDB(c))
+
+	tasks, total, err := provisioningService.ListTasks(c.Param("id"), c.Query("status"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tasks": tasks,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// RetryApplicationProvisioningTask remet en file une tâche de provisioning en échec
+func RetryApplicationProvisioningTask(c *gin.Context) {
+	provisioningService := services.NewProvisioningService(requestDB(c))
+
+	if err := provisioningService.RetryTask(c.Param("id"), c.Param("taskId")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Failed task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"success": true})
+}
+
+// ListApplicationProvisioningLogs liste les journaux de provisioning d'une application
+func ListApplicationProvisioningLogs(c *gin.Context) {
+	page, limit := provisioningPage(c)
+	provisioningService := services.NewProvisioningService(requestDB(c))
+
+	logs, total, err := provisioningService.ListLogs(c.Param("id"), c.Query("userId"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs":  logs,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
@@ -0,0 +1,274 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+	"gorm.io/gorm"
+)
+
+// RoleRequest représente la création ou la mise à jour d'un rôle
+type RoleRequest struct {
+	Name        *string  `json:"name"`
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"` // noms "ressource:action" ou identifiants
+}
+
+// PermissionRequest représente la création d'une permission
+type PermissionRequest struct {
+	Name        string  `json:"name" binding:"required"` // "ressource:action"
+	Description *string `json:"description"`
+}
+
+// RolePermissionRequest représente l'ajout d'une permission à un rôle
+type RolePermissionRequest struct {
+	Permission string `json:"permission" binding:"required"`
+}
+
+// UserRoleRequest représente l'attribution d'un rôle à un utilisateur
+type UserRoleRequest struct {
+	RoleID         string  `json:"roleId" binding:"required"`
+	OrganizationID *string `json:"organizationId"`
+}
+
+// rbacError convertit une erreur du service de permissions en réponse HTTP
+func rbacError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrSystemRole), errors.Is(err, services.ErrSystemRoleAssign), errors.Is(err, services.ErrPermissionNotHeld):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidPermission), errors.Is(err, services.ErrPermissionUnknown):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ListRoles liste les rôles et leurs permissions
+func ListRoles(c *gin.Context) {
+	permissionService := services.NewPermissionService(requestDB(c))
+
+	roles, err := permissionService.ListRoles()
+	if err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// GetRole renvoie un rôle et ses permissions
+func GetRole(c *gin.Context) {
+	permissionService := services.NewPermissionService(requestDB(c))
+
+	role, err := permissionService.GetRole(c.Param("id"))
+	if err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// CreateRole crée un rôle
+func CreateRole(c *gin.Context) {
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Name == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	permissionService := services.NewPermissionService(requestDB(c))
+	role, err := permissionService.CreateRole(c.GetString("user_id"), *req.Name, req.Description, req.Permissions)
+	if err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole met à jour un rôle ; "permissions" remplace la liste existante si elle est fournie
+func UpdateRole(c *gin.Context) {
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	permissionService := services.NewPermissionService(requestDB(c))
+	role, err := permissionService.UpdateRole(c.GetString("user_id"), c.Param("id"), req.Name, req.Description, req.Permissions)
+	if err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole supprime un rôle
+func DeleteRole(c *gin.Context) {
+	permissionService := services.NewPermissionService(requestDB(c))
+
+	if err := permissionService.DeleteRole(c.Param("id")); err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AddRolePermission ajoute une permission à un rôle
+func AddRolePermission(c *gin.Context) {
+	var req RolePermissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	permissionService := services.NewPermissionService(requestDB(c))
+	if err := permissionService.GrantPermission(c.GetString("user_id"), c.Param("id"), req.Permission); err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	role, err := permissionService.GetRole(c.Param("id"))
+	if err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// RemoveRolePermission retire une permission d'un rôle
+func RemoveRolePermission(c *gin.Context) {
+	permissionService := services.NewPermissionService(requestDB(c))
+
+	if err := permissionService.RevokePermission(c.Param("id"), c.Param("permissionId")); err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListPermissions liste les permissions définies
+func ListPermissions(c *gin.Context) {
+	permissionService := services.NewPermissionService(requestDB(c))
+
+	permissions, err := permissionService.ListPermissions()
+	if err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, permissions)
+}
+
+// CreatePermission crée une permission "ressource:action"
+func CreatePermission(c *gin.Context) {
+	var req PermissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	permissionService := services.NewPermissionService(requestDB(c))
+	permission, err := permissionService.CreatePermission(req.Name, req.Description)
+	if err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, permission)
+}
+
+// DeletePermission supprime une permission
+func DeletePermission(c *gin.Context) {
+	permissionService := services.NewPermissionService(requestDB(c))
+
+	if err := permissionService.DeletePermission(c.Param("id")); err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListUserRoles liste les rôles attribués à un utilisateur
+func ListUserRoles(c *gin.Context) {
+	permissionService := services.NewPermissionService(requestDB(c))
+
+	userRoles, err := permissionService.ListUserRoles(c.Param("id"))
+	if err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, userRoles)
+}
+
+// AssignUserRole attribue un rôle à un utilisateur, globalement ou dans une organisation
+func AssignUserRole(c *gin.Context) {
+	var req UserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	permissionService := services.NewPermissionService(requestDB(c))
+	userRole, err := permissionService.AssignRole(c.GetString("user_id"), c.Param("id"), req.RoleID, req.OrganizationID)
+	if err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, userRole)
+}
+
+// RevokeUserRole retire une attribution de rôle
+func RevokeUserRole(c *gin.Context) {
+	permissionService := services.NewPermissionService(requestDB(c))
+
+	if err := permissionService.RevokeRole(c.Param("id"), c.Param("userRoleId")); err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetUserPermissions renvoie les permissions effectives d'un utilisateur
+func GetUserPermissions(c *gin.Context) {
+	permissionService := services.NewPermissionService(requestDB(c))
+
+	permissions, err := permissionService.EffectivePermissions(c.Param("id"), c.Query("organizationId"))
+	if err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"permissions": permissions})
+}
+
+// GetCurrentUserPermissions renvoie les permissions effectives de l'utilisateur connecté
+func GetCurrentUserPermissions(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	permissionService := services.NewPermissionService(requestDB(c))
+	permissions, err := permissionService.EffectivePermissions(userID, c.Query("organizationId"))
+	if err != nil {
+		rbacError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"permissions": permissions})
+}
@@ -0,0 +1,434 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+)
+
+const scimContentType = "application/scim+json; charset=utf-8"
+
+// scimBaseURL construit l'URL de base SCIM à partir de la requête
+func scimBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host + "/scim/v2"
+}
+
+func newScimService(c *gin.Context) *services.ScimService {
+	return services.NewScimService(requestDB(c), scimBaseURL(c))
+}
+
+// scimRespond écrit une réponse SCIM avec le bon Content-Type
+func scimRespond(c *gin.Context, status int, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		scimError(c, http.StatusInternalServerError, "", "failed to encode response")
+		return
+	}
+	c.Data(status, scimContentType, data)
+}
+
+// scimRespondResource écrit une ressource SCIM avec son ETag et gère If-None-Match
+func scimRespondResource(c *gin.Context, status int, body interface{}, meta *models.ScimMeta) {
+	if meta != nil && meta.Version != "" {
+		c.Header("ETag", meta.Version)
+		if meta.Location != "" && status == http.StatusCreated {
+			c.Header("Location", meta.Location)
+		}
+		if c.Request.Method == http.MethodGet && c.GetHeader("If-None-Match") == meta.Version {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+	scimRespond(c, status, body)
+}
+
+// scimError écrit une erreur au format SCIM
+func scimError(c *gin.Context, status int, scimType, detail string) {
+	data, _ := json.Marshal(models.ScimError{
+		Schemas:  []string{models.ScimSchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+	c.Data(status, scimContentType, data)
+}
+
+// scimHandleError convertit une erreur de service en réponse SCIM
+func scimHandleError(c *gin.Context, err error) {
+	var scimErr *services.ScimServiceError
+	if errors.As(err, &scimErr) {
+		scimError(c, scimErr.Status, scimErr.ScimType, scimErr.Detail)
+		return
+	}
+	scimError(c, http.StatusInternalServerError, "", err.Error())
+}
+
+// scimPage lit les paramètres de pagination startIndex et count
+func scimPage(c *gin.Context) (int, int) {
+	startIndex, err := strconv.Atoi(c.DefaultQuery("startIndex", "1"))
+	if err != nil {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(services.ScimDefaultCount)))
+	if err != nil {
+		count = services.ScimDefaultCount
+	}
+	return startIndex, count
+}
+
+// ScimRequireDatabase renvoie une erreur SCIM si la base de données n'est pas disponible
+func ScimRequireDatabase(c *gin.Context) {
+	if services.DB == nil {
+		scimError(c, http.StatusServiceUnavailable, "", "database is not available")
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// ScimListUsers liste les utilisateurs (GET /scim/v2/Users)
+func ScimListUsers(c *gin.Context) {
+	startIndex, count := scimPage(c)
+	list, err := newScimService(c).ListUsers(c.Query("filter"), startIndex, count)
+	if err != nil {
+		scimHandleError(c, err)
+		return
+	}
+	scimRespond(c, http.StatusOK, list)
+}
+
+// ScimGetUser renvoie un utilisateur (GET /scim/v2/Users/:id)
+func ScimGetUser(c *gin.Context) {
+	user, err := newScimService(c).GetUser(c.Param("id"))
+	if err != nil {
+		scimHandleError(c, err)
+		return
+	}
+	scimRespondResource(c, http.StatusOK, user, user.Meta)
+}
+
+// ScimCreateUser crée un utilisateur (POST /scim/v2/Users)
+func ScimCreateUser(c *gin.Context) {
+	var resource models.ScimUser
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		scimError(c, http.StatusBadRequest, "invalidSyntax", "Invalid request body")
+		return
+	}
+
+	user, err := newScimService(c).CreateUser(&resource)
+	if err != nil {
+		scimHandleError(c, err)
+		return
+	}
+	scimRespondResource(c, http.StatusCreated, user, user.Meta)
+}
+
+// ScimReplaceUser remplace un utilisateur (PUT /scim/v2/Users/:id)
+func ScimReplaceUser(c *gin.Context) {
+	var resource models.ScimUser
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		scimError(c, http.StatusBadRequest, "invalidSyntax", "Invalid request body")
+		return
+	}
+
+	user, err := newScimService(c).ReplaceUser(c.Param("id"), &resource, c.GetHeader("If-Match"))
+	if err != nil {
+		scimHandleError(c, err)
+		return
+	}
+	scimRespondResource(c, http.StatusOK, user, user.Meta)
+}
+
+// ScimPatchUser modifie partiellement un utilisateur (PATCH /scim/v2/Users/:id)
+func ScimPatchUser(c *gin.Context) {
+	var patch models.ScimPatchRequest
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		scimError(c, http.StatusBadRequest, "invalidSyntax", "Invalid request body")
+		return
+	}
+
+	user, err := newScimService(c).PatchUser(c.Param("id"), &patch, c.GetHeader("If-Match"))
+	if err != nil {
+		scimHandleError(c, err)
+		return
+	}
+	scimRespondResource(c, http.StatusOK, user, user.Meta)
+}
+
+// ScimDeleteUser supprime un utilisateur (DELETE /scim/v2/Users/:id)
+func ScimDeleteUser(c *gin.Context) {
+	if err := newScimService(c).DeleteUser(c.Param("id"), c.GetHeader("If-Match")); err != nil {
+		scimHandleError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ScimListGroups liste les groupes (GET /scim/v2/Groups)
+func ScimListGroups(c *gin.Context) {
+	startIndex, count := scimPage(c)
+	list, err := newScimService(c).ListGroups(c.Query("filter"), startIndex, count)
+	if err != nil {
+		scimHandleError(c, err)
+		return
+	}
+	scimRespond(c, http.StatusOK, list)
+}
+
+// ScimGetGroup renvoie un groupe (GET /scim/v2/Groups/:id)
+func ScimGetGroup(c *gin.Context) {
+	group, err := newScimService(c).GetGroup(c.Param("id"))
+	if err != nil {
+		scimHandleError(c, err)
+		return
+	}
+	scimRespondResource(c, http.StatusOK, group, group.Meta)
+}
+
+// ScimCreateGroup crée un groupe (POST /scim/v2/Groups)
+func ScimCreateGroup(c *gin.Context) {
+	var resource models.ScimGroup
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		scimError(c, http.StatusBadRequest, "invalidSyntax", "Invalid request body")
+		return
+	}
+
+	group, err := newScimService(c).CreateGroup(&resource)
+	if err != nil {
+		scimHandleError(c, err)
+		return
+	}
+	scimRespondResource(c, http.StatusCreated, group, group.Meta)
+}
+
+// ScimReplaceGroup remplace un groupe (PUT /scim/v2/Groups/:id)
+func ScimReplaceGroup(c *gin.Context) {
+	var resource models.ScimGroup
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		scimError(c, http.StatusBadRequest, "invalidSyntax", "Invalid request body")
+		return
+	}
+
+	group, err := newScimService(c).ReplaceGroup(c.Param("id"), &resource, c.GetHeader("If-Match"))
+	if err != nil {
+		scimHandleError(c, err)
+		return
+	}
+	scimRespondResource(c, http.StatusOK, group, group.Meta)
+}
+
+// ScimPatchGroup modifie partiellement un groupe (PATCH /scim/v2/Groups/:id)
+func ScimPatchGroup(c *gin.Context) {
+	var patch models.ScimPatchRequest
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		scimError(c, http.StatusBadRequest, "invalidSyntax", "Invalid request body")
+		return
+	}
+
+	group, err := newScimService(c).PatchGroup(c.Param("id"), &patch, c.GetHeader("If-Match"))
+	if err != nil {
+		scimHandleError(c, err)
+		return
+	}
+	scimRespondResource(c, http.StatusOK, group, group.Meta)
+}
+
+// ScimDeleteGroup supprime un groupe (DELETE /scim/v2/Groups/:id)
+func ScimDeleteGroup(c *gin.Context) {
+	if err := newScimService(c).DeleteGroup(c.Param("id"), c.GetHeader("If-Match")); err != nil {
+		scimHandleError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ScimServiceProviderConfig décrit les fonctionnalités SCIM supportées
+func ScimServiceProviderConfig(c *gin.Context) {
+	scimRespond(c, http.StatusOK, gin.H{
+		"schemas":          []string{models.ScimSchemaServiceProviderConfig},
+		"documentationUri": "https://github.com/skygenesisenterprise/aether-identity",
+		"patch":            gin.H{"supported": true},
+		"bulk":             gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":           gin.H{"supported": true, "maxResults": services.ScimMaxCount},
+		"changePassword":   gin.H{"supported": true},
+		"sort":             gin.H{"supported": false},
+		"etag":             gin.H{"supported": true},
+		"authenticationSchemes": []gin.H{{
+			"type":        "oauthbearertoken",
+			"name":        "Service Key",
+			"description": "Authentication with an Aether Identity service key sent as a bearer token",
+			"primary":     true,
+		}},
+		"meta": gin.H{
+			"resourceType": "ServiceProviderConfig",
+			"location":     scimBaseURL(c) + "/ServiceProviderConfig",
+		},
+	})
+}
+
+// scimResourceTypes renvoie la définition des types de ressources exposés
+func scimResourceTypes(baseURL string) []gin.H {
+	return []gin.H{
+		{
+			"schemas":     []string{models.ScimSchemaResourceType},
+			"id":          "User",
+			"name":        "User",
+			"endpoint":    "/Users",
+			"description": "User Account",
+			"schema":      models.ScimSchemaUser,
+			"meta":        gin.H{"resourceType": "ResourceType", "location": baseURL + "/ResourceTypes/User"},
+		},
+		{
+			"schemas":     []string{models.ScimSchemaResourceType},
+			"id":          "Group",
+			"name":        "Group",
+			"endpoint":    "/Groups",
+			"description": "Roles and organizations",
+			"schema":      models.ScimSchemaGroup,
+			"schemaExtensions": []gin.H{
+				{"schema": models.ScimSchemaAetherGroup, "required": false},
+			},
+			"meta": gin.H{"resourceType": "ResourceType", "location": baseURL + "/ResourceTypes/Group"},
+		},
+	}
+}
+
+// ScimListResourceTypes liste les types de ressources (GET /scim/v2/ResourceTypes)
+func ScimListResourceTypes(c *gin.Context) {
+	resourceTypes := scimResourceTypes(scimBaseURL(c))
+	scimRespond(c, http.StatusOK, models.ScimListResponse{
+		Schemas:      []string{models.ScimSchemaListResponse},
+		TotalResults: int64(len(resourceTypes)),
+		StartIndex:   1,
+		ItemsPerPage: len(resourceTypes),
+		Resources:    resourceTypes,
+	})
+}
+
+// ScimGetResourceType renvoie un type de ressource (GET /scim/v2/ResourceTypes/:id)
+func ScimGetResourceType(c *gin.Context) {
+	for _, resourceType := range scimResourceTypes(scimBaseURL(c)) {
+		if resourceType["id"] == c.Param("id") {
+			scimRespond(c, http.StatusOK, resourceType)
+			return
+		}
+	}
+	scimError(c, http.StatusNotFound, "", "ResourceType "+c.Param("id")+" not found")
+}
+
+func scimAttribute(name, attrType string, multiValued, required bool, mutability string, subAttributes ...gin.H) gin.H {
+	attr := gin.H{
+		"name":        name,
+		"type":        attrType,
+		"multiValued": multiValued,
+		"required":    required,
+		"caseExact":   false,
+		"mutability":  mutability,
+		"returned":    "default",
+		"uniqueness":  "none",
+	}
+	if name == "password" {
+		attr["returned"] = "never"
+	}
+	if len(subAttributes) > 0 {
+		attr["subAttributes"] = subAttributes
+	}
+	return attr
+}
+
+// scimSchemas renvoie la définition des schémas supportés
+func scimSchemas(baseURL string) []gin.H {
+	multiValue := []gin.H{
+		scimAttribute("value", "string", false, false, "readWrite"),
+		scimAttribute("display", "string", false, false, "readWrite"),
+		scimAttribute("type", "string", false, false, "readWrite"),
+		scimAttribute("primary", "boolean", false, false, "readWrite"),
+	}
+
+	userName := scimAttribute("userName", "string", false, true, "readWrite")
+	userName["uniqueness"] = "server"
+
+	return []gin.H{
+		{
+			"schemas":     []string{models.ScimSchemaSchema},
+			"id":          models.ScimSchemaUser,
+			"name":        "User",
+			"description": "User Account",
+			"attributes": []gin.H{
+				userName,
+				scimAttribute("externalId", "string", false, false, "readWrite"),
+				scimAttribute("name", "complex", false, false, "readWrite",
+					scimAttribute("formatted", "string", false, false, "readWrite"),
+					scimAttribute("givenName", "string", false, false, "readWrite"),
+					scimAttribute("familyName", "string", false, false, "readWrite"),
+				),
+				scimAttribute("displayName", "string", false, false, "readWrite"),
+				scimAttribute("locale", "string", false, false, "readWrite"),
+				scimAttribute("timezone", "string", false, false, "readWrite"),
+				scimAttribute("active", "boolean", false, false, "readWrite"),
+				scimAttribute("password", "string", false, false, "writeOnly"),
+				scimAttribute("emails", "complex", true, false, "readWrite", multiValue...),
+				scimAttribute("photos", "complex", true, false, "readWrite", multiValue...),
+				scimAttribute("groups", "complex", true, false, "readOnly", multiValue...),
+			},
+			"meta": gin.H{"resourceType": "Schema", "location": baseURL + "/Schemas/" + models.ScimSchemaUser},
+		},
+		{
+			"schemas":     []string{models.ScimSchemaSchema},
+			"id":          models.ScimSchemaGroup,
+			"name":        "Group",
+			"description": "Group",
+			"attributes": []gin.H{
+				scimAttribute("displayName", "string", false, true, "readWrite"),
+				scimAttribute("externalId", "string", false, false, "readWrite"),
+				scimAttribute("members", "complex", true, false, "readWrite", multiValue...),
+			},
+			"meta": gin.H{"resourceType": "Schema", "location": baseURL + "/Schemas/" + models.ScimSchemaGroup},
+		},
+		{
+			"schemas":     []string{models.ScimSchemaSchema},
+			"id":          models.ScimSchemaAetherGroup,
+			"name":        "AetherGroup",
+			"description": "Maps a group to an Aether role or organization",
+			"attributes": []gin.H{
+				scimAttribute("type", "string", false, false, "immutable"),
+			},
+			"meta": gin.H{"resourceType": "Schema", "location": baseURL + "/Schemas/" + models.ScimSchemaAetherGroup},
+		},
+	}
+}
+
+// ScimListSchemas liste les schémas (GET /scim/v2/Schemas)
+func ScimListSchemas(c *gin.Context) {
+	schemas := scimSchemas(scimBaseURL(c))
+	scimRespond(c, http.StatusOK, models.ScimListResponse{
+		Schemas:      []string{models.ScimSchemaListResponse},
+		TotalResults: int64(len(schemas)),
+		StartIndex:   1,
+		ItemsPerPage: len(schemas),
+		Resources:    schemas,
+	})
+}
+
+// ScimGetSchema renvoie un schéma (GET /scim/v2/Schemas/:id)
+func ScimGetSchema(c *gin.Context) {
+	for _, schema := range scimSchemas(scimBaseURL(c)) {
+		if schema["id"] == c.Param("id") {
+			scimRespond(c, http.StatusOK, schema)
+			return
+		}
+	}
+	scimError(c, http.StatusNotFound, "", "Schema "+c.Param("id")+" not found")
+}
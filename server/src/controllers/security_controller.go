@@ -9,7 +9,7 @@ import (
 )
 
 func ListMfaMethods(c *gin.Context) {
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 
 	methods, err := securityService.ListMfaMethods()
 	if err != nil {
@@ -27,7 +27,7 @@ func CreateMfaMethod(c *gin.Context) {
 		return
 	}
 
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 	if err := securityService.CreateMfaMethod(&method); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -38,7 +38,7 @@ func CreateMfaMethod(c *gin.Context) {
 
 func GetMfaMethod(c *gin.Context) {
 	id := c.Param("id")
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 
 	method, err := securityService.GetMfaMethod(id)
 	if err != nil {
@@ -58,7 +58,7 @@ func UpdateMfaMethod(c *gin.Context) {
 	}
 
 	method.ID = id
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 
 	if err := securityService.UpdateMfaMethod(&method); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -70,7 +70,7 @@ func UpdateMfaMethod(c *gin.Context) {
 
 func DeleteMfaMethod(c *gin.Context) {
 	id := c.Param("id")
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 
 	if err := securityService.DeleteMfaMethod(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -81,7 +81,7 @@ func DeleteMfaMethod(c *gin.Context) {
 }
 
 func ListMfaPolicies(c *gin.Context) {
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 
 	policies, err := securityService.ListMfaPolicies()
 	if err != nil {
@@ -93,7 +93,7 @@ func ListMfaPolicies(c *gin.Context) {
 }
 
 func GetBruteForceConfig(c *gin.Context) {
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 
 	config, err := securityService.GetBruteForceConfig()
 	if err != nil {
@@ -111,7 +111,7 @@ func UpdateBruteForceConfig(c *gin.Context) {
 		return
 	}
 
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 	if err := securityService.UpdateBruteForceConfig(&config); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -129,7 +129,7 @@ func EnableDisableMfaMethod(c *gin.Context) {
 	}
 
 	method.ID = id
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 	if err := securityService.EnableDisableMfaMethod(&method); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -145,7 +145,7 @@ func CreateMfaPolicy(c *gin.Context) {
 		return
 	}
 
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 	if err := securityService.CreateMfaPolicy(&policy); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -163,7 +163,7 @@ func UpdateMfaPolicy(c *gin.Context) {
 	}
 
 	policy.ID = id
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 	if err := securityService.UpdateMfaPolicy(&policy); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -174,7 +174,7 @@ func UpdateMfaPolicy(c *gin.Context) {
 
 func DeleteMfaPolicy(c *gin.Context) {
 	id := c.Param("id")
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 	if err := securityService.DeleteMfaPolicy(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -184,7 +184,7 @@ func DeleteMfaPolicy(c *gin.Context) {
 }
 
 func GetMfaStats(c *gin.Context) {
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 	stats, err := securityService.GetMfaStats()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -195,7 +195,7 @@ func GetMfaStats(c *gin.Context) {
 }
 
 func GetMfaActivity(c *gin.Context) {
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 	activity, err := securityService.GetMfaActivity()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -212,7 +212,7 @@ func InitiateMfaChallenge(c *gin.Context) {
 		return
 	}
 
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 	if err := securityService.InitiateMfaChallenge(&challenge); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -231,7 +231,7 @@ func VerifyMfaCode(c *gin.Context) {
 		return
 	}
 
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 	result, err := securityService.VerifyMfaCode(input.ChallengeID, input.Code)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -242,7 +242,7 @@ func VerifyMfaCode(c *gin.Context) {
 }
 
 func GetAttackProtectionSettings(c *gin.Context) {
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 	settings, err := securityService.GetAttackProtectionSettings()
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Attack protection settings not found"})
@@ -259,7 +259,7 @@ func UpdateAttackProtectionSettings(c *gin.Context) {
 		return
 	}
 
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 	if err := securityService.UpdateAttackProtectionSettings(settings); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -269,7 +269,7 @@ func UpdateAttackProtectionSettings(c *gin.Context) {
 }
 
 func GetBreachedPasswordsConfig(c *gin.Context) {
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 	config, err := securityService.GetBreachedPasswordsConfig()
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Breached passwords config not found"})
@@ -286,7 +286,7 @@ func UpdateBreachedPasswordsConfig(c *gin.Context) {
 		return
 	}
 
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 	if err := securityService.UpdateBreachedPasswordsConfig(&config); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -296,7 +296,7 @@ func UpdateBreachedPasswordsConfig(c *gin.Context) {
 }
 
 func GetSecurityAnalytics(c *gin.Context) {
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 	analytics, err := securityService.GetSecurityAnalytics()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -307,7 +307,7 @@ func GetSecurityAnalytics(c *gin.Context) {
 }
 
 func GetThreatData(c *gin.Context) {
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 	threats, err := securityService.GetThreatData()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -318,7 +318,7 @@ func GetThreatData(c *gin.Context) {
 }
 
 func GetMonitoringStatus(c *gin.Context) {
-	securityService := services.NewSecurityService(services.DB)
+	securityService := services.NewSecurityService(requestDB(c))
 	status, err := securityService.GetMonitoringStatus()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
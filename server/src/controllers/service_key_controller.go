@@ -59,7 +59,7 @@ func CreateServiceKey(ctx *gin.Context) {
 		return
 	}
 
-	serviceKeyService := services.NewServiceKeyService(services.DB)
+	serviceKeyService := services.NewServiceKeyService(requestDB(ctx))
 	serviceKey, err := serviceKeyService.CreateServiceKey(req.Name, req.Description, req.ExpiresAt, userIDUint)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -90,7 +90,7 @@ func GetServiceKey(ctx *gin.Context) {
 		return
 	}
 
-	serviceKeyService := services.NewServiceKeyService(services.DB)
+	serviceKeyService := services.NewServiceKeyService(requestDB(ctx))
 	serviceKey, err := serviceKeyService.GetServiceKey(uint(id))
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{
@@ -153,7 +153,7 @@ func UpdateServiceKey(ctx *gin.Context) {
 		return
 	}
 
-	serviceKeyService := services.NewServiceKeyService(services.DB)
+	serviceKeyService := services.NewServiceKeyService(requestDB(ctx))
 	serviceKey, err := serviceKeyService.UpdateServiceKey(uint(id), req.Name, req.Description, req.IsActive, req.ExpiresAt, userIDUint)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -184,7 +184,7 @@ func DeleteServiceKey(ctx *gin.Context) {
 		return
 	}
 
-	serviceKeyService := services.NewServiceKeyService(services.DB)
+	serviceKeyService := services.NewServiceKeyService(requestDB(ctx))
 	err = serviceKeyService.DeleteServiceKey(uint(id))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -220,7 +220,7 @@ func ListServiceKeys(ctx *gin.Context) {
 		}
 	}
 
-	serviceKeyService := services.NewServiceKeyService(services.DB)
+	serviceKeyService := services.NewServiceKeyService(requestDB(ctx))
 	serviceKeys, count, err := serviceKeyService.ListServiceKeys(limit, offset)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -284,7 +284,7 @@ func ValidateServiceKey(ctx *gin.Context) {
 		return
 	}
 
-	serviceKeyService := services.NewServiceKeyService(services.DB)
+	serviceKeyService := services.NewServiceKeyService(requestDB(ctx))
 	isValid, err := serviceKeyService.ValidateServiceKey(req.Key)
 	if err != nil {
 		ctx.JSON(http.StatusUnauthorized, gin.H{
@@ -9,7 +9,7 @@ import (
 )
 
 func GetSystemSettings(c *gin.Context) {
-	settingsService := services.NewSettingsService(services.DB)
+	settingsService := services.NewSettingsService(requestDB(c))
 
 	settings, err := settingsService.GetSystemSettings()
 	if err != nil {
@@ -27,7 +27,7 @@ func UpdateSystemSettings(c *gin.Context) {
 		return
 	}
 
-	settingsService := services.NewSettingsService(services.DB)
+	settingsService := services.NewSettingsService(requestDB(c))
 	if err := settingsService.UpdateSystemSettings(&settings); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -38,7 +38,7 @@ func UpdateSystemSettings(c *gin.Context) {
 
 func GetFeatureFlags(c *gin.Context) {
 	tenantID := c.Query("tenantId")
-	settingsService := services.NewSettingsService(services.DB)
+	settingsService := services.NewSettingsService(requestDB(c))
 
 	flags, err := settingsService.GetFeatureFlags(tenantID)
 	if err != nil {
@@ -56,7 +56,7 @@ func CreateFeatureFlag(c *gin.Context) {
 		return
 	}
 
-	settingsService := services.NewSettingsService(services.DB)
+	settingsService := services.NewSettingsService(requestDB(c))
 	if err := settingsService.CreateFeatureFlag(&flag); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -74,7 +74,7 @@ func UpdateFeatureFlag(c *gin.Context) {
 	}
 
 	flag.ID = id
-	settingsService := services.NewSettingsService(services.DB)
+	settingsService := services.NewSettingsService(requestDB(c))
 	if err := settingsService.UpdateFeatureFlag(&flag); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -85,7 +85,7 @@ func UpdateFeatureFlag(c *gin.Context) {
 
 func DeleteFeatureFlag(c *gin.Context) {
 	id := c.Param("id")
-	settingsService := services.NewSettingsService(services.DB)
+	settingsService := services.NewSettingsService(requestDB(c))
 
 	if err := settingsService.DeleteFeatureFlag(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
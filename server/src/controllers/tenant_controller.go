@@ -15,7 +15,7 @@ func CreateTenant(c *gin.Context) {
 		return
 	}
 
-	tenantService := services.NewTenantService(services.DB)
+	tenantService := services.NewTenantService(requestDB(c))
 	if err := tenantService.CreateTenant(&tenant); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -26,7 +26,7 @@ func CreateTenant(c *gin.Context) {
 
 func GetTenant(c *gin.Context) {
 	id := c.Param("id")
-	tenantService := services.NewTenantService(services.DB)
+	tenantService := services.NewTenantService(requestDB(c))
 
 	tenant, err := tenantService.GetTenant(id)
 	if err != nil {
@@ -38,7 +38,7 @@ func GetTenant(c *gin.Context) {
 }
 
 func ListTenants(c *gin.Context) {
-	tenantService := services.NewTenantService(services.DB)
+	tenantService := services.NewTenantService(requestDB(c))
 
 	tenants, err := tenantService.ListTenants()
 	if err != nil {
@@ -58,7 +58,7 @@ func UpdateTenant(c *gin.Context) {
 	}
 
 	tenant.ID = id
-	tenantService := services.NewTenantService(services.DB)
+	tenantService := services.NewTenantService(requestDB(c))
 
 	if err := tenantService.UpdateTenant(&tenant); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -70,7 +70,7 @@ func UpdateTenant(c *gin.Context) {
 
 func DeleteTenant(c *gin.Context) {
 	id := c.Param("id")
-	tenantService := services.NewTenantService(services.DB)
+	tenantService := services.NewTenantService(requestDB(c))
 
 	if err := tenantService.DeleteTenant(id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -82,7 +82,7 @@ func DeleteTenant(c *gin.Context) {
 
 func GetTenantUsage(c *gin.Context) {
 	tenantID := c.Param("id")
-	tenantService := services.NewTenantService(services.DB)
+	tenantService := services.NewTenantService(requestDB(c))
 
 	usage, err := tenantService.GetTenantUsage(tenantID)
 	if err != nil {
@@ -95,7 +95,7 @@ func GetTenantUsage(c *gin.Context) {
 
 func GetBillingInfo(c *gin.Context) {
 	tenantID := c.Param("id")
-	tenantService := services.NewTenantService(services.DB)
+	tenantService := services.NewTenantService(requestDB(c))
 
 	billing, err := tenantService.GetBillingInfo(tenantID)
 	if err != nil {
@@ -115,7 +115,7 @@ func UpdateBillingInfo(c *gin.Context) {
 	}
 
 	billing.TenantID = tenantID
-	tenantService := services.NewTenantService(services.DB)
+	tenantService := services.NewTenantService(requestDB(c))
 
 	if err := tenantService.UpdateBillingInfo(&billing); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -127,7 +127,7 @@ func UpdateBillingInfo(c *gin.Context) {
 
 func GetTenantInfo(c *gin.Context) {
 	tenantID := c.Query("tenant_id")
-	tenantService := services.NewTenantService(services.DB)
+	tenantService := services.NewTenantService(requestDB(c))
 	tenant, err := tenantService.GetTenant(tenantID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
@@ -18,16 +18,26 @@ func Token(c *gin.Context) {
 		return
 	}
 
-	userService := services.NewUserService(services.DB)
+	userService := services.NewUserService(requestDB(c))
 	user, err := userService.AuthenticateUser(loginData.Email, loginData.Password)
 	if err != nil {
+		services.TrackLogin(requestDB(c), services.LoginActivity{TenantID: services.ResolveTenantID(requestDB(c), loginData.ClientID, ""), ClientID: loginData.ClientID, Result: loginFailureResult(err)})
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
+	var actionClient *services.ActionEventClient
+	if loginData.ClientID != "" {
+		actionClient = &services.ActionEventClient{ClientID: loginData.ClientID}
+	}
+	actionResult, err := runPostLoginActions(c, user, actionClient, nil)
+	if abortOnActionResult(c, actionResult, err) {
+		return
+	}
+
 	cfg := config.LoadConfig()
 	jwtService := services.NewJWTService(cfg.JWTSecret, cfg.AccessTokenExp, cfg.RefreshTokenExp)
-	accessToken, err := jwtService.GenerateToken(user)
+	accessToken, err := jwtService.GenerateTokenWithClaims(user, actionResult.AccessTokenClaims)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate access token"})
 		return
@@ -38,7 +48,7 @@ func Token(c *gin.Context) {
 		return
 	}
 
-	emailService := services.NewEmailService(services.DB)
+	emailService := services.NewEmailService(requestDB(c))
 	_, err = emailService.CreateRefreshToken(user.ID, refreshTokenString)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store refresh token"})
@@ -50,5 +60,7 @@ func Token(c *gin.Context) {
 	expiresRefresh := time.Now().Add(time.Duration(cfg.RefreshTokenExp) * time.Minute)
 	http.SetCookie(c.Writer, &http.Cookie{Name: "AETHER_REFRESH_TOKEN", Value: refreshTokenString, Path: "/", HttpOnly: true, Secure: true, SameSite: http.SameSiteLaxMode, Expires: expiresRefresh})
 
+	services.TrackLogin(requestDB(c), services.LoginActivity{UserID: user.ID, TenantID: services.ResolveTenantID(requestDB(c), loginData.ClientID, user.ID), ClientID: loginData.ClientID, Result: services.LoginSuccess})
+	services.RecordTokenIssued("password")
 	c.JSON(http.StatusOK, models.TokenResponse{AccessToken: accessToken, RefreshToken: refreshTokenString, ExpiresIn: cfg.AccessTokenExp})
 }
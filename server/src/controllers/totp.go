@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -21,7 +22,7 @@ func GenerateTOTPSecret(c *gin.Context) {
 		return
 	}
 
-	totpService := services.NewTOTPService(services.DB)
+	totpService := services.NewTOTPService(requestDB(c))
 	secretString, url, err := totpService.GenerateTOTPSecret(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -66,7 +67,7 @@ func VerifyTOTPCode(c *gin.Context) {
 		return
 	}
 
-	totpService := services.NewTOTPService(services.DB)
+	totpService := services.NewTOTPService(requestDB(c))
 
 	// Vérifier le code TOTP
 	valid, err := totpService.VerifyTOTPCode(verifyRequest.Code, verifyRequest.Secret)
@@ -107,7 +108,7 @@ func DisableTOTP(c *gin.Context) {
 		return
 	}
 
-	totpService := services.NewTOTPService(services.DB)
+	totpService := services.NewTOTPService(requestDB(c))
 	if err := totpService.DisableTOTP(fmt.Sprintf("%d", userID)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to disable TOTP",
@@ -130,19 +131,31 @@ func VerifyTOTPLogin(c *gin.Context) {
 		return
 	}
 
-	totpService := services.NewTOTPService(services.DB)
+	totpService := services.NewTOTPService(requestDB(c))
 	user, err := totpService.VerifyTOTPLogin(loginRequest.Email, loginRequest.Password, loginRequest.TOTPCode)
 	if err != nil {
+		services.TrackLogin(requestDB(c), services.LoginActivity{Result: loginFailureResult(err)})
+		message := err.Error()
+		if errors.Is(err, services.ErrAccountLocked) {
+			// Même réponse qu'un mot de passe erroné : le verrouillage ne révèle pas l'existence du compte
+			message = "invalid email or password"
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": err.Error(),
+			"error": message,
 		})
 		return
 	}
 
+	// Exécuter les actions post-login : refus, redirection ou claims personnalisés
+	actionResult, err := runPostLoginActions(c, user, nil, nil)
+	if abortOnActionResult(c, actionResult, err) {
+		return
+	}
+
 	// Générer les tokens JWT
 	cfg := config.LoadConfig()
 	jwtService := services.NewJWTService(cfg.JWTSecret, cfg.AccessTokenExp, cfg.RefreshTokenExp)
-	accessToken, err := jwtService.GenerateToken(user)
+	accessToken, err := jwtService.GenerateTokenWithClaims(user, actionResult.AccessTokenClaims)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to generate access token",
@@ -159,7 +172,7 @@ func VerifyTOTPLogin(c *gin.Context) {
 	}
 
 	// Stocker le refresh token en base
-	emailService := services.NewEmailService(services.DB)
+	emailService := services.NewEmailService(requestDB(c))
 	_, err = emailService.CreateRefreshToken(user.ID, refreshTokenString)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -191,6 +204,8 @@ func VerifyTOTPLogin(c *gin.Context) {
 		Expires:  ExpiresRefresh,
 	})
 
+	services.TrackLogin(requestDB(c), services.LoginActivity{UserID: user.ID, TenantID: services.ResolveTenantID(requestDB(c), "", user.ID), Result: services.LoginSuccess})
+	services.RecordTokenIssued("password")
 	c.JSON(http.StatusOK, models.JWTTokenResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshTokenString,
@@ -208,7 +223,7 @@ func GetTOTPStatus(c *gin.Context) {
 		return
 	}
 
-	totpService := services.NewTOTPService(services.DB)
+	totpService := services.NewTOTPService(requestDB(c))
 	enabled, err := totpService.GetTOTPStatus(fmt.Sprintf("%d", userID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
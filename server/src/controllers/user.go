@@ -21,7 +21,7 @@ func GetUser(c *gin.Context) {
 	}
 
 	// Récupérer l'utilisateur depuis la base de données
-	userService := services.NewUserService(services.DB)
+	userService := services.NewUserService(requestDB(c))
 	user, err := userService.GetUserByID(strconv.FormatUint(userID, 10))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -57,7 +57,7 @@ func UpdateUser(c *gin.Context) {
 	}
 
 	// Récupérer l'utilisateur existant
-	userService := services.NewUserService(services.DB)
+	userService := services.NewUserService(requestDB(c))
 	user, err := userService.GetUserByID(strconv.FormatUint(userID, 10))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -67,6 +67,7 @@ func UpdateUser(c *gin.Context) {
 	}
 
 	// Mettre à jour les champs
+	before := user.ToResponse()
 	name := updateData.Name
 	email := updateData.Email
 	if name != "" {
@@ -92,6 +93,13 @@ func UpdateUser(c *gin.Context) {
 		}
 	}
 
+	services.NotifyUserProvisioning(requestDB(c), user.ID, services.UserLifecycleEvent(user))
+
+	auditTarget(c, "users", user.ID, before, user.ToResponse())
+	if updateData.Password != "" {
+		auditDetails(c).Metadata = map[string]interface{}{"passwordChanged": true}
+	}
+
 	c.JSON(http.StatusOK, user.ToResponse())
 }
 
@@ -106,7 +114,7 @@ func DeleteUser(c *gin.Context) {
 	}
 
 	// Supprimer l'utilisateur
-	userService := services.NewUserService(services.DB)
+	userService := services.NewUserService(requestDB(c))
 	if err := userService.DeleteUser(strconv.FormatUint(userID, 10)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to delete user",
@@ -114,6 +122,8 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
+	services.NotifyUserProvisioning(requestDB(c), strconv.FormatUint(userID, 10), models.ProvisioningEventUserDeleted)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "User deleted successfully",
 	})
@@ -140,7 +150,7 @@ func ListUsers(c *gin.Context) {
 	}
 
 	// Récupérer les utilisateurs
-	userService := services.NewUserService(services.DB)
+	userService := services.NewUserService(requestDB(c))
 	result, err := userService.ListUsers(page, limit, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
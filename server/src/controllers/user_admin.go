@@ -10,11 +10,12 @@ import (
 
 // CreateUserRequest représente les données pour créer un utilisateur (admin)
 type CreateUserRequest struct {
-	Name     string `json:"name" binding:"required"`
-	Email    string `json:"email" binding:"required"`
-	Password string `json:"password" binding:"required"`
-	Role     string `json:"role"`     // Optionnel, défaut: "user"
-	IsActive bool   `json:"isActive"` // Optionnel, défaut: true
+	Name     string  `json:"name" binding:"required"`
+	Email    string  `json:"email" binding:"required"`
+	Password string  `json:"password" binding:"required"`
+	Role     string  `json:"role"`     // Optionnel, défaut: "user"
+	IsActive bool    `json:"isActive"` // Optionnel, défaut: true
+	TenantID *string `json:"tenantId"` // Optionnel, tenant de rattachement
 }
 
 // CreateUserAdmin permet aux admins de créer des utilisateurs
@@ -63,6 +64,14 @@ func CreateUserAdmin(c *gin.Context) {
 		validationErrors["role"] = "Invalid role. Must be: user, admin, or moderator"
 	}
 
+	if req.TenantID != nil && *req.TenantID != "" {
+		if _, err := services.NewTenantService(requestDB(c)).GetTenant(*req.TenantID); err != nil {
+			validationErrors["tenantId"] = "Unknown tenant"
+		}
+	} else {
+		req.TenantID = nil
+	}
+
 	if len(validationErrors) > 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -77,7 +86,7 @@ func CreateUserAdmin(c *gin.Context) {
 	req.Email = services.SanitizeEmail(req.Email)
 
 	// Vérifier si l'email existe déjà
-	userService := services.NewUserService(services.DB)
+	userService := services.NewUserService(requestDB(c))
 	if userService.CheckEmailExists(req.Email) {
 		c.JSON(http.StatusConflict, gin.H{
 			"success": false,
@@ -94,6 +103,7 @@ func CreateUserAdmin(c *gin.Context) {
 		Name:     &name,
 		Email:    &email,
 		IsActive: req.IsActive,
+		TenantID: req.TenantID,
 	}
 
 	if err := userService.CreateUser(user, req.Password); err != nil {
@@ -104,6 +114,9 @@ func CreateUserAdmin(c *gin.Context) {
 		return
 	}
 
+	services.NotifyUserProvisioning(requestDB(c), user.ID, models.ProvisioningEventUserCreated)
+	auditTarget(c, "users", user.ID, nil, user.ToResponse())
+
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
 		"message": "User created successfully",
@@ -134,7 +147,7 @@ func CheckEmailAvailability(c *gin.Context) {
 
 	email = services.SanitizeEmail(email)
 
-	userService := services.NewUserService(services.DB)
+	userService := services.NewUserService(requestDB(c))
 	exists := userService.CheckEmailExists(email)
 
 	c.JSON(http.StatusOK, gin.H{
@@ -155,7 +168,7 @@ func GetCurrentUser(c *gin.Context) {
 		return
 	}
 
-	userService := services.NewUserService(services.DB)
+	userService := services.NewUserService(requestDB(c))
 	user, err := userService.GetUserByID(userID.(string))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
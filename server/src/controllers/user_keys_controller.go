@@ -77,7 +77,7 @@ func GenerateUserKey(c *gin.Context) {
 	}
 
 	// Generate the service key
-	serviceKeyService := services.NewServiceKeyService(services.DB)
+	serviceKeyService := services.NewServiceKeyService(requestDB(c))
 	serviceKey, err := serviceKeyService.CreateServiceKey(req.Name, req.Description, req.ExpiresAt, userIDUint)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -138,7 +138,7 @@ func ListUserKeys(c *gin.Context) {
 	}
 
 	// Get the user's keys
-	serviceKeyService := services.NewServiceKeyService(services.DB)
+	serviceKeyService := services.NewServiceKeyService(requestDB(c))
 	keys, total, err := serviceKeyService.ListServiceKeysByUser(userIDUint, page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -213,7 +213,7 @@ func RevokeUserKey(c *gin.Context) {
 	}
 
 	// Get the service key
-	serviceKeyService := services.NewServiceKeyService(services.DB)
+	serviceKeyService := services.NewServiceKeyService(requestDB(c))
 	serviceKey, err := serviceKeyService.GetServiceKey(uint(keyID))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -237,7 +237,7 @@ func RevokeUserKey(c *gin.Context) {
 	// Revoke (deactivate) the key
 	serviceKey.IsActive = false
 	serviceKey.UpdatedBy = userIDUint
-	if err := services.DB.Save(serviceKey).Error; err != nil {
+	if err := requestDB(c).Save(serviceKey).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to revoke service key",
@@ -297,7 +297,7 @@ func DeleteUserKey(c *gin.Context) {
 	}
 
 	// Get the service key
-	serviceKeyService := services.NewServiceKeyService(services.DB)
+	serviceKeyService := services.NewServiceKeyService(requestDB(c))
 	serviceKey, err := serviceKeyService.GetServiceKey(uint(keyID))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -370,7 +370,7 @@ func GetUserKeyInfo(c *gin.Context) {
 	}
 
 	// Get the service key
-	serviceKeyService := services.NewServiceKeyService(services.DB)
+	serviceKeyService := services.NewServiceKeyService(requestDB(c))
 	serviceKey, err := serviceKeyService.GetServiceKey(uint(keyID))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
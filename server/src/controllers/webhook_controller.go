@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+)
+
+// webhookError renvoie 404 pour un abonnement ou une livraison inconnus, sinon le statut donné
+func webhookError(c *gin.Context, status int, err error) {
+	if errors.Is(err, services.ErrWebhookNotFound) || errors.Is(err, services.ErrWebhookDeliveryNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
+// Clés de contexte posées par WebhookTenantScope
+const (
+	webhookTenantKey = "webhook_tenant_id"
+	webhookGlobalKey = "webhook_tenant_global"
+)
+
+// WebhookTenantScope limite les routes /webhooks au tenant de l'appelant, sauf avec tenant:admin.
+// Un abonnement sans tenant reçoit les événements de tous les tenants : un appelant sans tenant ni
+// tenant:admin n'a donc accès à aucun abonnement. Un abonnement d'un autre tenant répond 404.
+func WebhookTenantScope(c *gin.Context) {
+	tenantID, global, err := services.NewPermissionService(requestDB(c)).TenantScope(c.GetString("user_id"))
+	if err != nil || (!global && tenantID == "") {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		return
+	}
+	if id := c.Param("id"); id != "" && !global {
+		webhook, err := services.NewWebhookService(requestDB(c)).GetWebhook(id)
+		if err == nil && (webhook.TenantID == nil || *webhook.TenantID != tenantID) {
+			err = services.ErrWebhookNotFound
+		}
+		if err != nil {
+			webhookError(c, http.StatusInternalServerError, err)
+			c.Abort()
+			return
+		}
+	}
+	c.Set(webhookTenantKey, tenantID)
+	c.Set(webhookGlobalKey, global)
+	c.Next()
+}
+
+// scopeWebhookRequest rattache au tenant de l'appelant l'abonnement créé ou modifié ; seul un détenteur
+// de tenant:admin choisit librement tenantId. Répond 403 et retourne false pour un autre tenant.
+func scopeWebhookRequest(c *gin.Context, req *models.WebhookRequest, create bool) bool {
+	if c.GetBool(webhookGlobalKey) {
+		return true
+	}
+	tenantID := c.GetString(webhookTenantKey)
+	if req.TenantID != nil && *req.TenantID != tenantID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "tenantId must be the caller's tenant"})
+		return false
+	}
+	if create {
+		req.TenantID = &tenantID
+	}
+	return true
+}
+
+// ListWebhooks liste les abonnements webhook, éventuellement filtrés par tenant_id (imposé hors tenant:admin)
+func ListWebhooks(c *gin.Context) {
+	tenantID := c.Query("tenant_id")
+	if !c.GetBool(webhookGlobalKey) {
+		tenantID = c.GetString(webhookTenantKey)
+	}
+	webhooks, err := services.NewWebhookService(requestDB(c)).ListWebhooks(tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks, "eventTypes": models.WebhookEventTypes})
+}
+
+// GetWebhook renvoie un abonnement webhook
+func GetWebhook(c *gin.Context) {
+	webhook, err := services.NewWebhookService(requestDB(c)).GetWebhook(c.Param("id"))
+	if err != nil {
+		webhookError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// CreateWebhook crée un abonnement ; le secret de signature n'est renvoyé qu'à cette occasion
+func CreateWebhook(c *gin.Context) {
+	var req models.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if !scopeWebhookRequest(c, &req, true) {
+		return
+	}
+
+	webhook, secret, err := services.NewWebhookService(requestDB(c)).CreateWebhook(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	auditTarget(c, "webhooks", webhook.ID, nil, webhook)
+	c.JSON(http.StatusCreated, gin.H{"webhook": webhook, "secret": secret})
+}
+
+// UpdateWebhook modifie un abonnement webhook
+func UpdateWebhook(c *gin.Context) {
+	var req models.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if !scopeWebhookRequest(c, &req, false) {
+		return
+	}
+
+	webhookService := services.NewWebhookService(requestDB(c))
+	before, err := webhookService.GetWebhook(c.Param("id"))
+	if err != nil {
+		webhookError(c, http.StatusInternalServerError, err)
+		return
+	}
+	webhook, err := webhookService.UpdateWebhook(c.Param("id"), &req)
+	if err != nil {
+		webhookError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	auditTarget(c, "webhooks", webhook.ID, before, webhook)
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook supprime un abonnement webhook et son historique de livraisons
+func DeleteWebhook(c *gin.Context) {
+	if err := services.NewWebhookService(requestDB(c)).DeleteWebhook(c.Param("id")); err != nil {
+		webhookError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	auditTarget(c, "webhooks", c.Param("id"), nil, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// RotateWebhookSecret génère un nouveau secret ; l'ancien reste accepté pendant grace_hours (24 par défaut)
+func RotateWebhookSecret(c *gin.Context) {
+	grace := services.WebhookSecretGrace
+	if value := c.Query("grace_hours"); value != "" {
+		hours, err := strconv.Atoi(value)
+		if err != nil || hours < 0 || hours > 168 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "grace_hours must be between 0 and 168"})
+			return
+		}
+		grace = time.Duration(hours) * time.Hour
+	}
+
+	webhook, secret, err := services.NewWebhookService(requestDB(c)).RotateSecret(c.Param("id"), grace)
+	if err != nil {
+		webhookError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	auditTarget(c, "webhooks", webhook.ID, nil, nil)
+	c.JSON(http.StatusOK, gin.H{"webhook": webhook, "secret": secret})
+}
+
+// TestWebhook planifie l'envoi d'un événement webhook.test à l'abonnement
+func TestWebhook(c *gin.Context) {
+	delivery, err := services.NewWebhookService(requestDB(c)).SendTest(c.Param("id"))
+	if err != nil {
+		webhookError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// ListWebhookDeliveries liste l'historique des livraisons d'un abonnement, filtrable par status
+func ListWebhookDeliveries(c *gin.Context) {
+	page, limit := provisioningPage(c)
+	deliveries, total, err := services.NewWebhookService(requestDB(c)).ListDeliveries(c.Param("id"), c.Query("status"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+	})
+}
+
+// GetWebhookDelivery renvoie une livraison, avec la charge utile et la réponse du destinataire
+func GetWebhookDelivery(c *gin.Context) {
+	delivery, err := services.NewWebhookService(requestDB(c)).GetDelivery(c.Param("id"), c.Param("deliveryId"))
+	if err != nil {
+		webhookError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// RedeliverWebhook renvoie l'événement d'une livraison passée
+func RedeliverWebhook(c *gin.Context) {
+	delivery, err := services.NewWebhookService(requestDB(c)).Redeliver(c.Param("id"), c.Param("deliveryId"))
+	if err != nil {
+		webhookError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
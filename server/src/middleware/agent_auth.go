@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+)
+
+// AgentAuth authentifie un agent enrôlé par son credential ("Bearer agt.<id>.<secret>")
+func AgentAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if services.DB == nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Database connection not available"})
+			return
+		}
+
+		credential, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || credential == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Agent credential required"})
+			return
+		}
+
+		agent, err := services.NewAgentService(services.DB).AuthenticateAgent(credential)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid agent credential"})
+			return
+		}
+
+		c.Set("agent", agent)
+		c.Next()
+	}
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+)
+
+// AuditTrail enregistre dans le journal d'audit chaque requête qui modifie l'état (POST, PUT, PATCH, DELETE),
+// ainsi que toute requête dont le handler a déposé un services.AuditDetails dans le contexte.
+// L'entrée est écrite après le handler : acteur, cible, différence avant/après, identifiant de requête et IP.
+// Doit être utilisé après RequestID et, le cas échéant, AuthMiddleware.
+func AuditTrail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		details, _ := c.Value(services.AuditContextKey).(*services.AuditDetails)
+		if details == nil {
+			if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions {
+				return
+			}
+			details = &services.AuditDetails{}
+		}
+		if services.DB == nil || c.FullPath() == "" {
+			return
+		}
+
+		entry := auditEntryFromRequest(c, details)
+		if err := services.NewAuditService(services.DB).Record(entry); err != nil {
+			fmt.Printf("Audit: failed to record %s: %v\n", entry.Action, err)
+		}
+	}
+}
+
+// auditEntryFromRequest construit l'entrée d'audit d'une requête terminée
+func auditEntryFromRequest(c *gin.Context, details *services.AuditDetails) *models.AuditEntry {
+	resource, action := auditActionFromRoute(c.Request.Method, c.FullPath())
+	entry := &models.AuditEntry{
+		Action:     action,
+		Outcome:    models.AuditOutcomeSuccess,
+		TargetType: resource,
+		RequestID:  c.GetString(RequestIDContextKey),
+		IPAddress:  c.ClientIP(),
+		UserAgent:  truncate(c.Request.UserAgent(), 500),
+	}
+	if len(c.Params) > 0 {
+		entry.TargetID = c.Params[0].Value
+	}
+	if details.Action != "" {
+		entry.Action = details.Action
+	}
+	if details.TargetType != "" {
+		entry.TargetType = details.TargetType
+		entry.TargetID = details.TargetID
+	}
+
+	switch status := c.Writer.Status(); {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		entry.Outcome = models.AuditOutcomeDenied
+	case status >= http.StatusBadRequest:
+		entry.Outcome = models.AuditOutcomeFailure
+	}
+
+	actor := details.Actor
+	if actor == nil {
+		actor = auditActorFromRequest(c)
+	}
+	entry.ActorType, entry.ActorID, entry.ActorName = actor.Type, actor.ID, actor.Name
+
+	changes, err := services.AuditChanges(details.Before, details.After)
+	if err != nil {
+		fmt.Printf("Audit: failed to compute changes for %s: %v\n", entry.Action, err)
+	}
+	entry.Changes = changes
+
+	entry.Metadata = map[string]interface{}{
+		"method": c.Request.Method,
+		"route":  c.FullPath(),
+		"status": c.Writer.Status(),
+	}
+	for key, value := range details.Metadata {
+		entry.Metadata[key] = value
+	}
+	return entry
+}
+
+// auditActorFromRequest identifie l'auteur de la requête : utilisateur authentifié, sinon clé de service ou agent
+func auditActorFromRequest(c *gin.Context) *services.AuditActor {
+	if userID := c.GetString("user_id"); userID != "" {
+		return &services.AuditActor{Type: models.AuditActorUser, ID: userID}
+	}
+	if key, ok := c.Value("service_key").(*models.ServiceKey); ok && key != nil {
+		if c.GetBool("is_system_key") {
+			return &services.AuditActor{Type: models.AuditActorSystem, Name: key.Name}
+		}
+		return &services.AuditActor{Type: models.AuditActorServiceKey, ID: strconv.FormatUint(uint64(key.ID), 10), Name: key.Name}
+	}
+	if agent, ok := c.Value("agent").(*models.Agent); ok && agent != nil {
+		return &services.AuditActor{Type: models.AuditActorAgent, ID: agent.ID, Name: agent.Name}
+	}
+	return &services.AuditActor{Type: models.AuditActorAnonymous}
+}
+
+// auditActionFromRoute dérive la ressource et l'action de la route :
+// PUT /api/v1/roles/:id → roles.update, POST /api/v1/clients/:clientId/rotate-secret → clients.rotate-secret,
+// PATCH /scim/v2/Users/:id → scim.users.update
+func auditActionFromRoute(method, route string) (string, string) {
+	prefix := ""
+	if strings.HasPrefix(route, "/scim/v2") {
+		prefix = "scim."
+	}
+	route = strings.TrimPrefix(strings.TrimPrefix(route, "/api/v1"), "/scim/v2")
+	var parts []string
+	endsWithParam := true
+	for _, segment := range strings.Split(route, "/") {
+		switch {
+		case segment == "" || segment == "admin":
+			continue
+		case strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*"):
+			endsWithParam = true
+		default:
+			parts = append(parts, strings.ToLower(segment))
+			endsWithParam = false
+		}
+	}
+	if len(parts) == 0 {
+		parts = []string{"api"}
+	}
+
+	action := prefix + strings.Join(parts, ".")
+	if endsWithParam || len(parts) == 1 {
+		switch method {
+		case http.MethodPost:
+			action += ".create"
+		case http.MethodPut, http.MethodPatch:
+			action += ".update"
+		case http.MethodDelete:
+			action += ".delete"
+		default:
+			action += "." + strings.ToLower(method)
+		}
+	}
+	return parts[0], action
+}
+
+func truncate(value string, limit int) string {
+	if len(value) > limit {
+		return value[:limit]
+	}
+	return value
+}
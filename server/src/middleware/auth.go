@@ -64,7 +64,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		// Récupérer l'ID de l'utilisateur
-		userID, ok := claims["userId"].(float64)
+		userIDStr, ok := userIDFromClaims(claims)
 		if !ok {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": "Invalid user ID in token",
@@ -72,12 +72,21 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Convertir en string
-		userIDStr := fmt.Sprintf("%.0f", userID)
-
 		// Stocker l'ID de l'utilisateur dans le contexte
 		c.Set("userId", userIDStr)
+		c.Set("user_id", userIDStr)
 
 		c.Next()
 	}
 }
+
+// userIDFromClaims lit l'identifiant utilisateur : "sub" (émis par JWTService) ou l'ancien claim numérique "userId"
+func userIDFromClaims(claims jwt.MapClaims) (string, bool) {
+	if sub, ok := claims["sub"].(string); ok && sub != "" {
+		return sub, true
+	}
+	if userID, ok := claims["userId"].(float64); ok {
+		return fmt.Sprintf("%.0f", userID), true
+	}
+	return "", false
+}
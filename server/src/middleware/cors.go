@@ -42,8 +42,8 @@ func AdaptiveCORSMiddleware() gin.HandlerFunc {
 		}
 
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Accept, Origin, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Expose-Headers", "Authorization, Content-Type")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Accept, Origin, X-Requested-With, X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Authorization, Content-Type, X-Request-ID")
 
 		// Gérer les requêtes preflight OPTIONS
 		if c.Request.Method == "OPTIONS" {
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+)
+
+// RequirePermission vérifie que l'utilisateur authentifié dispose de la permission "ressource:action".
+// Les rôles globaux sont toujours pris en compte ; les rôles d'organisation ne le sont que sur
+// les routes /organizations/:id, où l'organisation ciblée est connue.
+// Doit être utilisé après AuthMiddleware.
+func RequirePermission(permission string) gin.HandlerFunc {
+	resource, _, err := services.ParsePermission(permission)
+	if err != nil {
+		panic("invalid permission " + permission + ": " + err.Error())
+	}
+
+	return func(c *gin.Context) {
+		userID := c.GetString("user_id")
+		if userID == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Unauthorized",
+			})
+			return
+		}
+
+		if services.DB == nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Database connection not available",
+			})
+			return
+		}
+
+		organizationID := ""
+		if resource == "organizations" {
+			organizationID = c.Param("id")
+		}
+		allowed, err := services.NewPermissionService(services.DB).HasPermission(userID, organizationID, permission)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Forbidden",
+			})
+			return
+		}
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":      "Forbidden",
+				"message":    "Missing permission",
+				"permission": permission,
+			})
+			return
+		}
+
+		c.Set("permission", permission)
+		if organizationID != "" {
+			c.Set("organization_id", organizationID)
+		}
+		c.Next()
+	}
+}
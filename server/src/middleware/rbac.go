@@ -7,86 +7,100 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/skygenesisenterprise/aether-identity/server/src/config"
+	"github.com/skygenesisenterprise/aether-identity/server/src/services"
 )
 
-// RoleMiddleware vérifie si l'utilisateur a le rôle requis
-func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Récupérer le token du header Authorization
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization header is required",
-			})
-			return
-		}
+// requestUserID récupère l'utilisateur posé par AuthMiddleware, ou à défaut valide le token Bearer
+func requestUserID(c *gin.Context) (string, bool) {
+	if userID := c.GetString("user_id"); userID != "" {
+		return userID, true
+	}
 
-		// Vérifier le format du token
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization header must be Bearer token",
-			})
-			return
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "Authorization header is required",
+		})
+		return "", false
+	}
+
+	// Vérifier le format du token
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "Authorization header must be Bearer token",
+		})
+		return "", false
+	}
+
+	// Valider le token JWT et extraire les claims
+	cfg := config.LoadConfig()
+	token, err := jwt.Parse(parts[1], func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
 		}
+		return []byte(cfg.JWTSecret), nil
+	})
 
-		tokenString := parts[1]
+	if err != nil || !token.Valid {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid or expired token",
+		})
+		return "", false
+	}
 
-		// Valider le token JWT et extraire les claims
-		cfg := config.LoadConfig()
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(cfg.JWTSecret), nil
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid token claims",
 		})
+		return "", false
+	}
 
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid or expired token",
-			})
-			return
-		}
+	userID, ok := userIDFromClaims(claims)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid user ID in token",
+		})
+		return "", false
+	}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
+	c.Set("userId", userID)
+	c.Set("user_id", userID)
+	return userID, true
+}
+
+// RoleMiddleware vérifie si l'utilisateur a l'un des rôles requis.
+// Les rôles ne sont pas portés par le token : ils sont lus en base (user_roles et users.role).
+func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requestUserID(c)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid token claims",
-			})
 			return
 		}
 
-		// Vérifier le rôle de l'utilisateur
-		userRole, ok := claims["role"].(string)
-		if !ok {
+		roles, err := services.NewPermissionService(services.DB).UserRoleNames(userID)
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-				"error": "User role not found in token",
+				"error": "User roles could not be resolved",
 			})
 			return
 		}
 
-		// Vérifier si le rôle est autorisé
-		hasPermission := false
-		for _, role := range allowedRoles {
-			if userRole == role {
-				hasPermission = true
-				break
+		// Vérifier si l'un des rôles est autorisé
+		for _, role := range roles {
+			for _, allowed := range allowedRoles {
+				if strings.EqualFold(role, allowed) {
+					c.Set("userRole", role)
+					c.Next()
+					return
+				}
 			}
 		}
 
-		if !hasPermission {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-				"error": "Insufficient permissions",
-			})
-			return
-		}
-
-		// Ajouter les informations utilisateur au contexte
-		c.Set("userID", uint(claims["userID"].(float64)))
-		c.Set("userEmail", claims["email"].(string))
-		c.Set("userRole", userRole)
-
-		c.Next()
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "Insufficient permissions",
+		})
 	}
 }
 
@@ -108,60 +122,12 @@ func RequireUser() gin.HandlerFunc {
 // RequireSelfOrAdmin permet à un utilisateur d'accéder à ses propres ressources ou aux ressources si admin
 func RequireSelfOrAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Récupérer le token et valider
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization header is required",
-			})
-			return
-		}
-
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization header must be Bearer token",
-			})
-			return
-		}
-
-		tokenString := parts[1]
-		cfg := config.LoadConfig()
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(cfg.JWTSecret), nil
-		})
-
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid or expired token",
-			})
-			return
-		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
+		userID, ok := requestUserID(c)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid token claims",
-			})
 			return
 		}
 
-		userID := uint(claims["userID"].(float64))
-		userRole := claims["role"].(string)
-
-		// Si admin, autoriser l'accès
-		if userRole == "admin" {
-			c.Set("userID", userID)
-			c.Set("userEmail", claims["email"].(string))
-			c.Set("userRole", userRole)
-			c.Next()
-			return
-		}
-
-		// Sinon, vérifier que l'utilisateur accède à ses propres ressources
+		// Accès à ses propres ressources
 		targetUserID := c.Param("id")
 		if targetUserID == "" {
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
@@ -169,12 +135,14 @@ func RequireSelfOrAdmin() gin.HandlerFunc {
 			})
 			return
 		}
+		if targetUserID == userID {
+			c.Next()
+			return
+		}
 
-		// Comparer les IDs (conversion simple pour cet exemple)
-		if targetUserID == string(rune(userID)) {
-			c.Set("userID", userID)
-			c.Set("userEmail", claims["email"].(string))
-			c.Set("userRole", userRole)
+		// Sinon, il faut la permission d'administration des utilisateurs
+		allowed, err := services.NewPermissionService(services.DB).HasPermission(userID, "", "users:admin")
+		if err == nil && allowed {
 			c.Next()
 			return
 		}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader est l'en-tête portant l'identifiant de corrélation d'une requête
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDContextKey est la clé du contexte où l'identifiant de requête est stocké
+const RequestIDContextKey = "request_id"
+
+// RequestID attribue à chaque requête un identifiant de corrélation : celui fourni par l'appelant
+// (proxy, connecteur) s'il est valide, sinon un identifiant aléatoire. Il est renvoyé dans la réponse.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			buf := make([]byte, 16)
+			_, _ = rand.Read(buf)
+			requestID = hex.EncodeToString(buf)
+		}
+		c.Set(RequestIDContextKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// validRequestID accepte un identifiant court composé de caractères sûrs pour les journaux
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 100 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' || r == ':') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skygenesisenterprise/aether-identity/server/src/services"
+)
+
+// unmatchedRoute regroupe les requêtes sans route, pour que les chemins inconnus ne créent pas une série par URL
+const unmatchedRoute = "unmatched"
+
+// Telemetry mesure la latence de chaque requête par route et ouvre le span serveur de la trace,
+// rattaché au traceparent de l'appelant s'il est fourni. Le span est porté par c.Request.Context() :
+// les requêtes SQL et les appels sortants faits avec ce contexte en deviennent les enfants.
+// Doit être utilisé après RequestID.
+func Telemetry() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		ctx := services.ContextWithTraceparent(c.Request.Context(), c.GetHeader(services.TraceparentHeader))
+		ctx, span := services.StartSpan(ctx, c.Request.Method+" "+route, services.SpanKindServer)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		services.ObserveHTTPRequest(c.Request.Method, route, status, time.Since(start))
+
+		span.SetAttribute("http.request.method", c.Request.Method)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("http.response.status_code", status)
+		span.SetAttribute("client.address", c.ClientIP())
+		span.SetAttribute("user_agent.original", c.Request.UserAgent())
+		if requestID := c.GetString(RequestIDContextKey); requestID != "" {
+			span.SetAttribute("request.id", requestID)
+		}
+		if userID := c.GetString("user_id"); userID != "" {
+			span.SetAttribute("enduser.id", userID)
+		}
+		if status >= http.StatusInternalServerError {
+			span.RecordError(fmt.Errorf("HTTP %d", status))
+		}
+		span.End()
+	}
+}
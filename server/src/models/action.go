@@ -6,20 +6,50 @@ import (
 	"gorm.io/gorm"
 )
 
+// Points de déclenchement des actions
+const (
+	ActionTriggerPostLogin           = "post-login"
+	ActionTriggerPreUserRegistration = "pre-user-registration"
+	ActionTriggerPostChangePassword  = "post-change-password"
+	ActionTriggerCredentialsExchange = "credentials-exchange"
+)
+
+// Statuts d'une exécution d'action (ActionLog)
+const (
+	ActionLogStatusSuccess = "success"
+	ActionLogStatusFailed  = "failed"
+)
+
 type Action struct {
-	ID          string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	Name        string         `gorm:"size:255;not null" json:"name"`
-	DisplayName *string        `gorm:"size:255" json:"displayName,omitempty"`
-	Description *string        `gorm:"size:500" json:"description,omitempty"`
-	Code        *string        `gorm:"type:text" json:"code,omitempty"`
-	Runtime     string         `gorm:"size:50;not null;default:'nodejs'" json:"runtime"` // nodejs, python, go
-	Version     int            `gorm:"default:1" json:"version"`
-	Status      string         `gorm:"size:50;default:'draft'" json:"status"` // draft, deployed, archived
-	DeployedAt  *time.Time     `gorm:"column:deployed_at" json:"deployedAt,omitempty"`
-	Secrets     []string       `gorm:"type:text[]" json:"secrets,omitempty"`
-	CreatedAt   time.Time      `gorm:"column:created_at" json:"createdAt"`
-	UpdatedAt   time.Time      `gorm:"column:updated_at" json:"updatedAt"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID              string         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Name            string         `gorm:"size:255;not null" json:"name"`
+	DisplayName     *string        `gorm:"size:255" json:"displayName,omitempty"`
+	Description     *string        `gorm:"size:500" json:"description,omitempty"`
+	Code            *string        `gorm:"type:text" json:"code,omitempty"`
+	Runtime         string         `gorm:"size:50;not null;default:'nodejs'" json:"runtime"` // nodejs, python, go
+	Version         int            `gorm:"default:1" json:"version"`
+	Status          string         `gorm:"size:50;default:'draft'" json:"status"` // draft, deployed, archived
+	DeployedAt      *time.Time     `gorm:"column:deployed_at" json:"deployedAt,omitempty"`
+	DeployedVersion *int           `gorm:"column:deployed_version" json:"deployedVersion,omitempty"` // Version est la dernière enregistrée
+	Secrets         []string       `gorm:"type:text[]" json:"secrets,omitempty"`
+	CreatedAt       time.Time      `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt       time.Time      `gorm:"column:updated_at" json:"updatedAt"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// ActionVersion est un instantané immuable du code d'une action
+type ActionVersion struct {
+	ID        string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ActionID  string    `gorm:"type:uuid;not null;column:action_id;uniqueIndex:idx_action_versions_number" json:"actionId"`
+	Number    int       `gorm:"not null;uniqueIndex:idx_action_versions_number" json:"number"`
+	Code      string    `gorm:"type:text;not null" json:"code"`
+	Runtime   string    `gorm:"size:50;not null" json:"runtime"`
+	Checksum  string    `gorm:"size:64;not null" json:"checksum"` // SHA-256 du code
+	CreatedAt time.Time `gorm:"column:created_at" json:"createdAt"`
+}
+
+func (ActionVersion) TableName() string {
+	return "action_versions"
 }
 
 type ActionTrigger struct {
@@ -31,11 +61,21 @@ type ActionTrigger struct {
 }
 
 type ActionTriggerBinding struct {
-	ID        string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	ActionID  string    `gorm:"type:uuid;not null;column:action_id" json:"actionId"`
-	TriggerID string    `gorm:"type:uuid;not null;column:trigger_id" json:"triggerId"`
-	Order     int       `gorm:"default:0" json:"order"`
-	IsEnabled bool      `gorm:"default:true;column:is_enabled" json:"isEnabled"`
+	ID        string `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ActionID  string `gorm:"type:uuid;not null;column:action_id" json:"actionId"`
+	TriggerID string `gorm:"type:uuid;not null;column:trigger_id" json:"triggerId"`
+	Order     int    `gorm:"default:0" json:"order"`
+	IsEnabled bool   `gorm:"default:true;column:is_enabled" json:"isEnabled"`
+	Version   *int   `gorm:"column:version" json:"version,omitempty"` // Version épinglée au déploiement
+
+	// Déploiement progressif : CanaryPercent % des exécutions utilisent CanaryVersion, annulé
+	// automatiquement si son taux d'erreur dépasse CanaryErrorThreshold après CanaryMinExecutions
+	CanaryVersion        *int       `gorm:"column:canary_version" json:"canaryVersion,omitempty"`
+	CanaryPercent        int        `gorm:"column:canary_percent;default:0" json:"canaryPercent"`
+	CanaryErrorThreshold float64    `gorm:"column:canary_error_threshold;default:0" json:"canaryErrorThreshold"`
+	CanaryMinExecutions  int        `gorm:"column:canary_min_executions;default:0" json:"canaryMinExecutions"`
+	CanaryStartedAt      *time.Time `gorm:"column:canary_started_at" json:"canaryStartedAt,omitempty"`
+
 	CreatedAt time.Time `gorm:"column:created_at" json:"createdAt"`
 	UpdatedAt time.Time `gorm:"column:updated_at" json:"updatedAt"`
 }
@@ -69,6 +109,8 @@ type ActionLog struct {
 	ID        string      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
 	ActionID  string      `gorm:"type:uuid;column:action_id;index" json:"actionId"`
 	TriggerID *string     `gorm:"type:uuid;column:trigger_id" json:"triggerId,omitempty"`
+	Version   *int        `gorm:"column:version" json:"version,omitempty"`
+	Canary    bool        `gorm:"column:canary;default:false" json:"canary"`
 	Status    string      `gorm:"size:50" json:"status"` // started, success, failed
 	StartTime time.Time   `gorm:"column:start_time" json:"startTime"`
 	EndTime   *time.Time  `gorm:"column:end_time" json:"endTime,omitempty"`
@@ -2,43 +2,74 @@ package models
 
 import "time"
 
+// Événements d'authentification consommés par l'agrégation des statistiques d'activité
+const (
+	ActivityEventLoginSucceeded = "login.succeeded"
+	ActivityEventLoginFailed    = "login.failed"
+	ActivityEventSignup         = "signup.completed"
+)
+
+// ActivityBreakdown ventile un compteur par dimension (connection, application, reason), puis par valeur
+type ActivityBreakdown map[string]map[string]int
+
+// ActivityOverview résume une journée. Les lignes sans tenant portent sur toute l'instance, tenants compris.
 type ActivityOverview struct {
-	ID          string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	Date        time.Time `gorm:"type:date;not null" json:"date"`
-	TotalUsers  int       `gorm:"default:0;column:total_users" json:"totalUsers"`
-	ActiveUsers int       `gorm:"default:0;column:active_users" json:"activeUsers"`
-	Logins      int       `gorm:"default:0" json:"logins"`
-	Signups     int       `gorm:"default:0" json:"signups"`
+	ID           string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID     *string    `gorm:"type:uuid;column:tenant_id;index" json:"tenantId,omitempty"`
+	Date         time.Time  `gorm:"type:date;not null;index" json:"date"`
+	TotalUsers   int        `gorm:"default:0;column:total_users" json:"totalUsers"`
+	ActiveUsers  int        `gorm:"default:0;column:active_users" json:"activeUsers"`
+	Logins       int        `gorm:"default:0" json:"logins"`
+	Signups      int        `gorm:"default:0" json:"signups"`
+	FailedLogins int        `gorm:"default:0;column:failed_logins" json:"failedLogins"`
+	ComputedAt   *time.Time `gorm:"column:computed_at" json:"computedAt,omitempty"`
+}
+
+func (ActivityOverview) TableName() string {
+	return "activity_overview"
 }
 
 type DailyActiveUsers struct {
-	ID        string      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	Date      time.Time   `gorm:"type:date;not null" json:"date"`
-	Count     int         `gorm:"default:0" json:"count"`
-	Breakdown interface{} `gorm:"type:jsonb" json:"breakdown,omitempty"`
+	ID            string            `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID      *string           `gorm:"type:uuid;column:tenant_id;index" json:"tenantId,omitempty"`
+	Date          time.Time         `gorm:"type:date;not null;index" json:"date"`
+	Count         int               `gorm:"default:0" json:"count"`
+	WeeklyActive  int               `gorm:"default:0;column:weekly_active" json:"weeklyActive"`   // 7 jours glissants
+	MonthlyActive int               `gorm:"default:0;column:monthly_active" json:"monthlyActive"` // 30 jours glissants
+	Stickiness    float64           `gorm:"default:0" json:"stickiness"`                          // DAU / MAU
+	Breakdown     ActivityBreakdown `gorm:"type:jsonb;serializer:json" json:"breakdown,omitempty"`
 }
 
 type UserRetention struct {
 	ID          string  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	CohortMonth string  `gorm:"size:10;not null;column:cohort_month" json:"cohortMonth"`
-	Period      int     `gorm:"not null" json:"period"` // weeks/months
+	TenantID    *string `gorm:"type:uuid;column:tenant_id;index" json:"tenantId,omitempty"`
+	CohortMonth string  `gorm:"size:10;not null;column:cohort_month" json:"cohortMonth"` // 2006-01
+	Period      int     `gorm:"not null" json:"period"`                                  // Mois écoulés depuis l'inscription
+	CohortSize  int     `gorm:"default:0;column:cohort_size" json:"cohortSize"`
 	Retained    int     `gorm:"default:0" json:"retained"`
 	Churned     int     `gorm:"default:0" json:"churned"`
 	Rate        float64 `gorm:"default:0" json:"rate"`
 }
 
+// SignupData suit l'entonnoir des inscrits du jour : e-mail vérifié, première connexion, MFA activée
 type SignupData struct {
-	ID        string      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	Date      time.Time   `gorm:"type:date;not null" json:"date"`
-	Count     int         `gorm:"default:0" json:"count"`
-	Breakdown interface{} `gorm:"type:jsonb" json:"breakdown,omitempty"`
+	ID          string            `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID    *string           `gorm:"type:uuid;column:tenant_id;index" json:"tenantId,omitempty"`
+	Date        time.Time         `gorm:"type:date;not null;index" json:"date"`
+	Count       int               `gorm:"default:0" json:"count"`
+	Verified    int               `gorm:"default:0" json:"verified"`
+	LoggedIn    int               `gorm:"default:0;column:logged_in" json:"loggedIn"`
+	MfaEnrolled int               `gorm:"default:0;column:mfa_enrolled" json:"mfaEnrolled"`
+	Breakdown   ActivityBreakdown `gorm:"type:jsonb;serializer:json" json:"breakdown,omitempty"`
 }
 
 type FailedLoginData struct {
-	ID        string      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	Date      time.Time   `gorm:"type:date;not null" json:"date"`
-	Count     int         `gorm:"default:0" json:"count"`
-	Breakdown interface{} `gorm:"type:jsonb" json:"breakdown,omitempty"`
+	ID        string            `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID  *string           `gorm:"type:uuid;column:tenant_id;index" json:"tenantId,omitempty"`
+	Date      time.Time         `gorm:"type:date;not null;index" json:"date"`
+	Count     int               `gorm:"default:0" json:"count"`
+	LockedOut int               `gorm:"default:0;column:locked_out" json:"lockedOut"`
+	Breakdown ActivityBreakdown `gorm:"type:jsonb;serializer:json" json:"breakdown,omitempty"`
 }
 
 type DashboardStats struct {
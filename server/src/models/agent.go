@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+)
+
+// Statuts d'un agent
+const (
+	AgentStatusOnline  = "online"
+	AgentStatusOffline = "offline"
+	AgentStatusError   = "error"
+)
+
+// AgentCommandType identifie une commande envoyée à un agent
+type AgentCommandType string
+
+const (
+	AgentCommandRestart       AgentCommandType = "restart"
+	AgentCommandConfigReload  AgentCommandType = "config-reload"
+	AgentCommandTokenRotation AgentCommandType = "token-rotation"
+)
+
+// AgentCommandStatus représente le cycle de vie d'une commande
+type AgentCommandStatus string
+
+const (
+	AgentCommandPending   AgentCommandStatus = "pending"   // En attente de distribution
+	AgentCommandDelivered AgentCommandStatus = "delivered" // Distribuée, en attente d'acquittement
+	AgentCommandSucceeded AgentCommandStatus = "succeeded"
+	AgentCommandFailed    AgentCommandStatus = "failed"
+	AgentCommandExpired   AgentCommandStatus = "expired" // Non acquittée avant ExpiresAt
+)
+
+// AgentEnrollmentToken est un jeton à usage unique permettant à un agent de s'enrôler.
+// Seule l'empreinte SHA-256 du jeton est conservée.
+type AgentEnrollmentToken struct {
+	ID        string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null;column:token_hash" json:"-"`
+	Name      string     `gorm:"size:255;not null" json:"name"` // Nom de l'agent créé
+	Type      string     `gorm:"size:100;not null" json:"type"`
+	TenantID  *string    `gorm:"type:uuid;column:tenant_id" json:"tenantId,omitempty"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null" json:"expiresAt"`
+	UsedAt    *time.Time `gorm:"column:used_at" json:"usedAt,omitempty"`
+	AgentID   *string    `gorm:"type:uuid;column:agent_id" json:"agentId,omitempty"`
+	CreatedBy *string    `gorm:"type:uuid;column:created_by" json:"createdBy,omitempty"`
+	CreatedAt time.Time  `gorm:"column:created_at" json:"createdAt"`
+}
+
+func (AgentEnrollmentToken) TableName() string {
+	return "agent_enrollment_tokens"
+}
+
+// AgentCommand est une commande distante distribuée à un agent par long-poll
+// puis acquittée par celui-ci
+type AgentCommand struct {
+	ID             string             `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AgentID        string             `gorm:"type:uuid;not null;column:agent_id;index:idx_agent_commands_agent_status" json:"agentId"`
+	Type           AgentCommandType   `gorm:"type:varchar(50);not null" json:"type"`
+	Payload        interface{}        `gorm:"type:jsonb" json:"payload,omitempty"`
+	Status         AgentCommandStatus `gorm:"type:varchar(20);not null;default:'pending';index:idx_agent_commands_agent_status" json:"status"`
+	Result         *string            `gorm:"type:text" json:"result,omitempty"` // Message d'acquittement de l'agent
+	IssuedBy       *string            `gorm:"type:uuid;column:issued_by" json:"issuedBy,omitempty"`
+	ExpiresAt      time.Time          `gorm:"column:expires_at;not null" json:"expiresAt"`
+	DeliveredAt    *time.Time         `gorm:"column:delivered_at" json:"deliveredAt,omitempty"`
+	AcknowledgedAt *time.Time         `gorm:"column:acknowledged_at" json:"acknowledgedAt,omitempty"`
+	CreatedAt      time.Time          `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt      time.Time          `gorm:"column:updated_at" json:"updatedAt"`
+
+	// Nouveau credential d'une rotation, renvoyé une seule fois à la distribution et jamais stocké
+	Credential string `gorm:"-" json:"credential,omitempty"`
+}
+
+func (AgentCommand) TableName() string {
+	return "agent_commands"
+}
@@ -36,6 +36,7 @@ type Application struct {
 	contacts                []ApplicationContact     `gorm:"foreignKey:ApplicationID" json:"contacts,omitempty"`
 	OwnerID                 *string                  `gorm:"type:uuid;column:owner_id" json:"ownerId,omitempty"`
 	OrganizationID          *string                  `gorm:"type:uuid;column:organization_id" json:"organizationId,omitempty"`
+	TenantID                *string                  `gorm:"type:uuid;column:tenant_id;index" json:"tenantId,omitempty"`
 	CreatedAt               time.Time                `gorm:"column:created_at" json:"createdAt"`
 	UpdatedAt               time.Time                `gorm:"column:updated_at" json:"updatedAt"`
 	DeletedAt               gorm.DeletedAt           `gorm:"index;column:deleted_at" json:"-"`
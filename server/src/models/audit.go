@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+)
+
+// Résultats d'une opération auditée
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+	AuditOutcomeDenied  = "denied"
+)
+
+// Types d'acteurs du journal d'audit
+const (
+	AuditActorUser       = "user"
+	AuditActorServiceKey = "service_key"
+	AuditActorSystem     = "system"
+	AuditActorClient     = "client" // Client OAuth identifié par son client_id
+	AuditActorAgent      = "agent"
+	AuditActorAnonymous  = "anonymous"
+)
+
+// AuditEntry est une entrée du journal d'audit. Les entrées forment une chaîne :
+// Hash est le SHA-256 du contenu de l'entrée et du Hash de l'entrée précédente (PrevHash),
+// de sorte que toute modification, suppression ou insertion est détectée à la vérification.
+type AuditEntry struct {
+	ID         string                 `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Sequence   int64                  `gorm:"not null;uniqueIndex" json:"sequence"`
+	Action     string                 `gorm:"size:150;not null;index" json:"action"` // Par exemple clients.create, auth.login
+	Outcome    string                 `gorm:"size:20;not null" json:"outcome"`
+	ActorType  string                 `gorm:"size:50;column:actor_type;not null" json:"actorType"`
+	ActorID    string                 `gorm:"size:255;column:actor_id;index" json:"actorId,omitempty"`
+	ActorName  string                 `gorm:"size:255;column:actor_name" json:"actorName,omitempty"`
+	TargetType string                 `gorm:"size:100;column:target_type;index:idx_audit_entries_target" json:"targetType,omitempty"`
+	TargetID   string                 `gorm:"size:255;column:target_id;index:idx_audit_entries_target" json:"targetId,omitempty"`
+	Changes    map[string]interface{} `gorm:"type:jsonb;serializer:json" json:"changes,omitempty"` // champ → {before, after}
+	Metadata   map[string]interface{} `gorm:"type:jsonb;serializer:json" json:"metadata,omitempty"`
+	RequestID  string                 `gorm:"size:100;column:request_id;index" json:"requestId,omitempty"`
+	IPAddress  string                 `gorm:"size:45;column:ip_address" json:"ipAddress,omitempty"`
+	UserAgent  string                 `gorm:"size:500;column:user_agent" json:"userAgent,omitempty"`
+	Source     string                 `gorm:"size:50;not null;index" json:"source"` // identity, ou le connecteur (github...)
+	PrevHash   string                 `gorm:"size:64;column:prev_hash;not null" json:"prevHash"`
+	Hash       string                 `gorm:"size:64;not null;uniqueIndex" json:"hash"`
+	CreatedAt  time.Time              `gorm:"column:created_at;index" json:"createdAt"`
+}
+
+func (AuditEntry) TableName() string {
+	return "audit_entries"
+}
+
+// AuditVerification est le résultat de la vérification de la chaîne d'audit
+type AuditVerification struct {
+	Valid          bool   `json:"valid"`
+	Checked        int64  `json:"checked"`
+	LastSequence   int64  `json:"lastSequence"`
+	LastHash       string `json:"lastHash,omitempty"`
+	BrokenSequence *int64 `json:"brokenSequence,omitempty"` // Première entrée invalide
+	Reason         string `json:"reason,omitempty"`
+}
@@ -8,6 +8,7 @@ type LoginRequest struct {
 	ClientID      string `json:"clientId"`
 	RedirectURI   string `json:"redirectUri"`
 	PostLoginPath string `json:"postLoginPath"`
+	Connection    string `json:"connection"`
 }
 
 type RegisterRequest struct {
@@ -15,6 +16,7 @@ type RegisterRequest struct {
 	Email           string `json:"email" binding:"required"`
 	Password        string `json:"password" binding:"required"`
 	ConfirmPassword string `json:"confirmPassword" binding:"required"`
+	ClientID        string `json:"clientId"` // Application d'origine, dont le tenant est attribué au nouvel utilisateur
 }
 
 type JWTTokenResponse struct {
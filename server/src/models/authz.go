@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+)
+
+// RelationTuple représente une relation "namespace:objet#relation@sujet".
+// Le sujet est soit un utilisateur (SubjectRelation vide), soit un userset "namespace:objet#relation".
+// Les tuples sont versionnés par révision pour permettre des lectures à un instantané donné.
+type RelationTuple struct {
+	ID               string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Namespace        string    `gorm:"size:100;not null;index:idx_relation_tuples_object" json:"namespace"`
+	ObjectID         string    `gorm:"size:255;column:object_id;not null;index:idx_relation_tuples_object" json:"objectId"`
+	Relation         string    `gorm:"size:100;not null;index:idx_relation_tuples_object" json:"relation"`
+	SubjectNamespace string    `gorm:"size:100;column:subject_namespace;not null;index:idx_relation_tuples_subject" json:"subjectNamespace"`
+	SubjectID        string    `gorm:"size:255;column:subject_id;not null;index:idx_relation_tuples_subject" json:"subjectId"`
+	SubjectRelation  string    `gorm:"size:100;column:subject_relation;not null;default:''" json:"subjectRelation,omitempty"`
+	CreatedRevision  int64     `gorm:"column:created_revision;not null;index" json:"createdRevision"`
+	DeletedRevision  *int64    `gorm:"column:deleted_revision;index" json:"deletedRevision,omitempty"`
+	CreatedAt        time.Time `gorm:"column:created_at" json:"createdAt"`
+}
+
+func (RelationTuple) TableName() string {
+	return "relation_tuples"
+}
+
+// AuthzRevision représente une écriture dans le magasin de relations ; son ID sert de jeton de cohérence
+type AuthzRevision struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	Written   int       `gorm:"default:0" json:"written"`
+	Deleted   int       `gorm:"default:0" json:"deleted"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"createdAt"`
+}
+
+func (AuthzRevision) TableName() string {
+	return "authz_revisions"
+}
+
+// AuthzNamespace représente la configuration d'un type d'objet (relations et règles de réécriture)
+type AuthzNamespace struct {
+	ID        string      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Name      string      `gorm:"size:100;uniqueIndex;not null" json:"name"`
+	Config    interface{} `gorm:"type:jsonb" json:"config"` // AuthzNamespaceConfig
+	CreatedAt time.Time   `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt time.Time   `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (AuthzNamespace) TableName() string {
+	return "authz_namespaces"
+}
+
+// AuthzNamespaceConfig décrit les relations d'un namespace
+type AuthzNamespaceConfig struct {
+	Relations map[string]AuthzRelationConfig `json:"relations"`
+}
+
+// AuthzRelationConfig décrit comment une relation est calculée.
+// Les sujets retenus sont l'union des tuples directs, des relations calculées et des relations héritées.
+type AuthzRelationConfig struct {
+	// Direct inclut les tuples écrits pour cette relation
+	Direct bool `json:"direct"`
+	// Computed liste les relations du même objet qui impliquent celle-ci (ex: writer pour reader)
+	Computed []string `json:"computed,omitempty"`
+	// Inherited liste les relations héritées d'un objet lié (ex: admin de l'organisation du dépôt)
+	Inherited []AuthzTupleToUserset `json:"inherited,omitempty"`
+}
+
+// AuthzTupleToUserset suit les tuples "Tupleset" de l'objet puis évalue "Relation" sur l'objet cible
+type AuthzTupleToUserset struct {
+	Tupleset string `json:"tupleset"`
+	Relation string `json:"relation"`
+}
+
+// AuthzExpandNode représente un nœud de l'arbre renvoyé par /authz/expand
+type AuthzExpandNode struct {
+	Type     string             `json:"type"` // union, direct, computed, inherited
+	Object   string             `json:"object,omitempty"`
+	Relation string             `json:"relation,omitempty"`
+	Subjects []string           `json:"subjects,omitempty"`
+	Children []*AuthzExpandNode `json:"children,omitempty"`
+}
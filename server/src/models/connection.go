@@ -30,28 +30,55 @@ type Connection struct {
 }
 
 type DatabaseConnection struct {
-	ID             string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	ConnectionID   string    `gorm:"type:uuid;not null;column:connection_id" json:"connectionId"`
-	DatabaseType   *string   `gorm:"size:50;column:database_type" json:"databaseType,omitempty"`
-	Host           *string   `gorm:"size:255" json:"host,omitempty"`
-	Port           *int      `json:"port,omitempty"`
-	Database       *string   `gorm:"size:255" json:"database,omitempty"`
-	Username       *string   `gorm:"size:255" json:"username,omitempty"`
-	Password       *string   `gorm:"size:255" json:"-"`
-	SSLMode        *string   `gorm:"size:50;column:ssl_mode" json:"sslMode,omitempty"`
-	MinConnections *int      `gorm:"default:1;column:min_connections" json:"minConnections,omitempty"`
-	MaxConnections *int      `gorm:"default:10;column:max_connections" json:"maxConnections,omitempty"`
-	CreatedAt      time.Time `gorm:"column:created_at" json:"createdAt"`
-	UpdatedAt      time.Time `gorm:"column:updated_at" json:"updatedAt"`
+	ID             string  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ConnectionID   string  `gorm:"type:uuid;not null;column:connection_id" json:"connectionId"`
+	DatabaseType   *string `gorm:"size:50;column:database_type" json:"databaseType,omitempty"`
+	Host           *string `gorm:"size:255" json:"host,omitempty"`
+	Port           *int    `json:"port,omitempty"`
+	Database       *string `gorm:"size:255" json:"database,omitempty"`
+	Username       *string `gorm:"size:255" json:"username,omitempty"`
+	Password       *string `gorm:"size:255" json:"-"`
+	SSLMode        *string `gorm:"size:50;column:ssl_mode" json:"sslMode,omitempty"`
+	MinConnections *int    `gorm:"default:1;column:min_connections" json:"minConnections,omitempty"`
+	MaxConnections *int    `gorm:"default:10;column:max_connections" json:"maxConnections,omitempty"`
+
+	// Requêtes SQL exécutées sur la base externe (paramètres nommés @email, @password_hash)
+	LoginQuery          *string `gorm:"type:text;column:login_query" json:"loginQuery,omitempty"`
+	GetUserQuery        *string `gorm:"type:text;column:get_user_query" json:"getUserQuery,omitempty"`
+	ChangePasswordQuery *string `gorm:"type:text;column:change_password_query" json:"changePasswordQuery,omitempty"`
+
+	// Scripts d'action utilisés à la place des requêtes SQL
+	LoginScriptID          *string `gorm:"type:uuid;column:login_script_id" json:"loginScriptId,omitempty"`
+	GetUserScriptID        *string `gorm:"type:uuid;column:get_user_script_id" json:"getUserScriptId,omitempty"`
+	ChangePasswordScriptID *string `gorm:"type:uuid;column:change_password_script_id" json:"changePasswordScriptId,omitempty"`
+
+	// ImportMode active la migration progressive des utilisateurs vers la table users
+	ImportMode bool      `gorm:"default:false;column:import_mode" json:"importMode"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt  time.Time `gorm:"column:updated_at" json:"updatedAt"`
 }
 
 type DatabaseConnectionUser struct {
-	ID                   string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	DatabaseConnectionID string    `gorm:"type:uuid;not null;column:database_connection_id" json:"databaseConnectionId"`
-	Email                string    `gorm:"size:255;not null" json:"email"`
-	Username             string    `gorm:"size:255" json:"username,omitempty"`
-	IsActive             bool      `gorm:"default:true;column:is_active" json:"isActive"`
-	CreatedAt            time.Time `gorm:"column:created_at" json:"createdAt"`
+	ID                   string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	DatabaseConnectionID string     `gorm:"type:uuid;not null;column:database_connection_id" json:"databaseConnectionId"`
+	Email                string     `gorm:"size:255;not null" json:"email"`
+	Username             string     `gorm:"size:255" json:"username,omitempty"`
+	IsActive             bool       `gorm:"default:true;column:is_active" json:"isActive"`
+	UserID               *string    `gorm:"type:uuid;column:user_id;index" json:"userId,omitempty"`
+	ExternalID           *string    `gorm:"size:255;column:external_id" json:"externalId,omitempty"`
+	ImportedAt           *time.Time `gorm:"column:imported_at" json:"importedAt,omitempty"`
+	LastLoginAt          *time.Time `gorm:"column:last_login_at" json:"lastLoginAt,omitempty"`
+	CreatedAt            time.Time  `gorm:"column:created_at" json:"createdAt"`
+}
+
+// CustomDatabaseUser représente un utilisateur renvoyé par une base de données externe
+type CustomDatabaseUser struct {
+	ExternalID    string `json:"id"`
+	Email         string `json:"email"`
+	Username      string `json:"username,omitempty"`
+	Name          string `json:"name,omitempty"`
+	PasswordHash  string `json:"-"`
+	EmailVerified bool   `json:"emailVerified"`
 }
 
 type SocialProvider struct {
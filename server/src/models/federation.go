@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+)
+
+// FederatedIssuerType identifie la nature d'un émetteur fédéré
+type FederatedIssuerType string
+
+const (
+	FederatedIssuerKubernetes FederatedIssuerType = "kubernetes"
+	FederatedIssuerOIDC       FederatedIssuerType = "oidc"
+	FederatedIssuerGitHub     FederatedIssuerType = "github"
+	FederatedIssuerGitLab     FederatedIssuerType = "gitlab"
+	FederatedIssuerGitea      FederatedIssuerType = "gitea"
+	FederatedIssuerJenkins    FederatedIssuerType = "jenkins"
+)
+
+// FederatedIssuer représente un émetteur OIDC externe (cluster Kubernetes, fournisseur CI...)
+// dont les jetons signés sont acceptés comme assertion client sur /oauth/token.
+// Les clés sont lues depuis JWKS (clés statiques, pour les clusters dont l'émetteur n'est pas
+// joignable), sinon depuis JWKSURI, sinon depuis la découverte OpenID de l'émetteur.
+type FederatedIssuer struct {
+	ID          string              `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Name        string              `gorm:"size:255;not null" json:"name"`
+	Type        FederatedIssuerType `gorm:"type:varchar(50);not null;default:'oidc'" json:"type"`
+	Issuer      string              `gorm:"size:500;uniqueIndex;not null" json:"issuer"`
+	JWKSURI     *string             `gorm:"size:500;column:jwks_uri" json:"jwksUri,omitempty"`
+	JWKS        interface{}         `gorm:"type:jsonb;column:jwks" json:"jwks,omitempty"`
+	Audiences   []string            `gorm:"type:text[]" json:"audiences"`
+	IsActive    bool                `gorm:"default:true;column:is_active" json:"isActive"`
+	Description *string             `gorm:"type:text" json:"description,omitempty"`
+	CreatedAt   time.Time           `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt   time.Time           `gorm:"column:updated_at" json:"updatedAt"`
+
+	Bindings []FederatedIdentityBinding `gorm:"foreignKey:IssuerID" json:"bindings,omitempty"`
+	Policies []FederationTrustPolicy    `gorm:"foreignKey:IssuerID" json:"policies,omitempty"`
+}
+
+func (FederatedIssuer) TableName() string {
+	return "federated_issuers"
+}
+
+// FederatedIdentityBinding associe le sujet d'un émetteur fédéré (par exemple
+// "system:serviceaccount:<namespace>:<serviceaccount>") à un OAuthClient ou à une Application.
+// Scopes borne les scopes délivrés ; vide, ce sont ceux du client.
+type FederatedIdentityBinding struct {
+	ID            string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	IssuerID      string    `gorm:"type:uuid;not null;column:issuer_id;uniqueIndex:idx_federated_bindings_subject" json:"issuerId"`
+	Subject       string    `gorm:"size:500;not null;uniqueIndex:idx_federated_bindings_subject" json:"subject"`
+	ClientID      *string   `gorm:"size:255;column:client_id;index" json:"clientId,omitempty"`
+	ApplicationID *string   `gorm:"type:uuid;column:application_id;index" json:"applicationId,omitempty"`
+	Scopes        []string  `gorm:"type:text[]" json:"scopes"`
+	IsActive      bool      `gorm:"default:true;column:is_active" json:"isActive"`
+	CreatedAt     time.Time `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt     time.Time `gorm:"column:updated_at" json:"updatedAt"`
+
+	Issuer *FederatedIssuer `gorm:"foreignKey:IssuerID" json:"issuer,omitempty"`
+}
+
+func (FederatedIdentityBinding) TableName() string {
+	return "federated_identity_bindings"
+}
+
+// FederationTrustPolicy autorise les jetons OIDC d'un fournisseur CI dont le dépôt, la branche et
+// l'environnement correspondent aux motifs (syntaxe path.Match, vide = tous) à être échangés
+// contre un token du client ou de l'application lié. Claims ajoute des conditions sur d'autres claims.
+type FederationTrustPolicy struct {
+	ID            string      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	IssuerID      string      `gorm:"type:uuid;not null;column:issuer_id;index" json:"issuerId"`
+	Name          string      `gorm:"size:255;not null" json:"name"`
+	Repository    string      `gorm:"size:500;not null" json:"repository"`
+	Branch        string      `gorm:"size:255" json:"branch,omitempty"`
+	Environment   string      `gorm:"size:255" json:"environment,omitempty"`
+	Claims        interface{} `gorm:"type:jsonb" json:"claims,omitempty"` // map[string]string
+	ClientID      *string     `gorm:"size:255;column:client_id;index" json:"clientId,omitempty"`
+	ApplicationID *string     `gorm:"type:uuid;column:application_id;index" json:"applicationId,omitempty"`
+	Scopes        []string    `gorm:"type:text[]" json:"scopes"`
+	IsActive      bool        `gorm:"default:true;column:is_active" json:"isActive"`
+	CreatedAt     time.Time   `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt     time.Time   `gorm:"column:updated_at" json:"updatedAt"`
+
+	Issuer *FederatedIssuer `gorm:"foreignKey:IssuerID" json:"issuer,omitempty"`
+}
+
+func (FederationTrustPolicy) TableName() string {
+	return "federation_trust_policies"
+}
+
+// KubernetesServiceAccountSubject retourne le sujet des jetons projetés d'un compte de service
+func KubernetesServiceAccountSubject(namespace, serviceAccount string) string {
+	return "system:serviceaccount:" + namespace + ":" + serviceAccount
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Destinations des flux de logs
+const (
+	LogStreamSyslog        = "syslog"
+	LogStreamSplunk        = "splunk"
+	LogStreamElasticsearch = "elasticsearch"
+	LogStreamDatadog       = "datadog"
+	LogStreamHTTP          = "http"
+)
+
+// États de santé d'un flux, alignés sur MonitoringStatus
+const (
+	LogStreamHealthy  = "healthy"
+	LogStreamDegraded = "degraded"
+	LogStreamDown     = "down"
+	LogStreamPaused   = "paused"
+)
+
+// LogStream transfère en continu les logs, événements et entrées d'audit vers un SIEM ou un endpoint HTTP.
+// Config contient les paramètres propres à la destination (adresse, index, site...) ; Secret le jeton,
+// la clé d'API ou le secret de signature, jamais renvoyé par l'API.
+type LogStream struct {
+	ID                  string                 `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Name                string                 `gorm:"size:100;uniqueIndex;not null" json:"name"`
+	Type                string                 `gorm:"size:20;not null" json:"type"`
+	Enabled             bool                   `gorm:"default:true" json:"enabled"`
+	Config              map[string]interface{} `gorm:"type:jsonb;serializer:json" json:"config"`
+	Secret              *string                `gorm:"type:text" json:"-"`
+	Kinds               []string               `gorm:"type:jsonb;serializer:json" json:"kinds"`  // log, event, security, audit ; vide = tous
+	Events              []string               `gorm:"type:jsonb;serializer:json" json:"events"` // Un préfixe se termine par un point (auth.)
+	Levels              []string               `gorm:"type:jsonb;serializer:json" json:"levels"`
+	BatchSize           int                    `gorm:"default:100;column:batch_size" json:"batchSize"`
+	FlushInterval       int                    `gorm:"default:5;column:flush_interval" json:"flushInterval"` // secondes
+	MaxRetries          int                    `gorm:"default:5;column:max_retries" json:"maxRetries"`
+	Status              string                 `gorm:"size:20;default:healthy" json:"status"`
+	ConsecutiveFailures int                    `gorm:"default:0;column:consecutive_failures" json:"consecutiveFailures"`
+	LastDeliveredAt     *time.Time             `gorm:"column:last_delivered_at" json:"lastDeliveredAt,omitempty"`
+	LastError           *string                `gorm:"type:text;column:last_error" json:"lastError,omitempty"`
+	HasSecret           bool                   `gorm:"-" json:"hasSecret"`
+	CreatedAt           time.Time              `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt           time.Time              `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (LogStream) TableName() string {
+	return "log_streams"
+}
+
+// LogStreamDeadLetter conserve un lot qui n'a pas pu être livré après toutes les tentatives
+type LogStreamDeadLetter struct {
+	ID        string          `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	StreamID  string          `gorm:"type:uuid;column:stream_id;not null;index" json:"streamId"`
+	Messages  json.RawMessage `gorm:"type:jsonb;serializer:json" json:"messages"`
+	Count     int             `gorm:"not null" json:"count"`
+	Attempts  int             `gorm:"default:0" json:"attempts"`
+	Error     string          `gorm:"type:text" json:"error"`
+	CreatedAt time.Time       `gorm:"column:created_at;index" json:"createdAt"`
+}
+
+func (LogStreamDeadLetter) TableName() string {
+	return "log_stream_dead_letters"
+}
+
+// LogStreamRequest représente la configuration envoyée par l'API d'administration
+type LogStreamRequest struct {
+	Name          *string                `json:"name"`
+	Type          *string                `json:"type"`
+	Enabled       *bool                  `json:"enabled"`
+	Config        map[string]interface{} `json:"config"`
+	Secret        *string                `json:"secret"`
+	Kinds         []string               `json:"kinds"`
+	Events        []string               `json:"events"`
+	Levels        []string               `json:"levels"`
+	BatchSize     *int                   `json:"batchSize"`
+	FlushInterval *int                   `json:"flushInterval"`
+	MaxRetries    *int                   `json:"maxRetries"`
+}
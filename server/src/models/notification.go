@@ -38,8 +38,12 @@ type MarkNotificationReadRequest struct {
 	NotificationIDs []string `json:"notification_ids" binding:"required"`
 }
 
+// WebhookPayload est le corps JSON signé envoyé aux abonnements webhook
 type WebhookPayload struct {
+	ID        string      `json:"id"` // Identique pour toutes les livraisons et relivraisons d'un même événement
 	Type      string      `json:"type"`
-	AccountID string      `json:"account_id"`
+	AccountID string      `json:"account_id,omitempty"`
+	TenantID  *string     `json:"tenant_id,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
 	Data      interface{} `json:"data"`
 }
@@ -19,6 +19,7 @@ type OAuthClient struct {
 	PostLoginPath  string    `gorm:"size:255;default:/;column:post_login_path" json:"postLoginPath"`
 	PostLogoutPath string    `gorm:"size:255;default:/;column:post_logout_path" json:"postLogoutPath"`
 	AllowedOrigins []string  `gorm:"type:text[];column:allowed_origins" json:"allowedOrigins"`
+	TenantID       *string   `gorm:"type:uuid;column:tenant_id;index" json:"tenantId,omitempty"`
 	IsActive       bool      `gorm:"default:true;column:is_active" json:"isActive"`
 	CreatedAt      time.Time `gorm:"column:created_at" json:"createdAt"`
 	UpdatedAt      time.Time `gorm:"column:updated_at" json:"createdAt"`
@@ -139,6 +140,16 @@ type TokenRequest struct {
 	RefreshToken string `form:"refresh_token"`
 	Username     string `form:"username"`
 	Password     string `form:"password"`
+	Scope        string `form:"scope"`
+	Audience     string `form:"audience"`
+
+	// Assertion client RFC 7523 (jeton de compte de service Kubernetes, OIDC externe)
+	ClientAssertionType string `form:"client_assertion_type"`
+	ClientAssertion     string `form:"client_assertion"`
+
+	// Échange de jeton RFC 8693 (jeton OIDC d'un pipeline CI)
+	SubjectToken     string `form:"subject_token"`
+	SubjectTokenType string `form:"subject_token_type"`
 }
 
 // TokenResponse représente une réponse de token OAuth2
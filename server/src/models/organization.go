@@ -15,6 +15,7 @@ type Organization struct {
 	Website     *string        `gorm:"size:255" json:"website,omitempty"`
 	IsActive    bool           `gorm:"default:true;column:is_active" json:"isActive"`
 	OwnerID     string         `gorm:"type:uuid;column:owner_id;not null;index" json:"ownerId"`
+	ExternalID  *string        `gorm:"size:255;column:external_id;index" json:"externalId,omitempty"`
 	CreatedAt   time.Time      `gorm:"column:created_at" json:"createdAt"`
 	UpdatedAt   time.Time      `gorm:"column:updated_at" json:"updatedAt"`
 	DeletedAt   gorm.DeletedAt `gorm:"index;column:deleted_at" json:"-"`
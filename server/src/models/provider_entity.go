@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// ProviderEntity représente une entité synchronisée depuis un fournisseur externe (GitHub, GitLab...) :
+// un rôle (équipe), une ressource (dépôt), une adhésion ou un utilisateur, identifié par son ID côté fournisseur
+type ProviderEntity struct {
+	ID             string      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Provider       string      `gorm:"size:50;not null;uniqueIndex:idx_provider_entities_external" json:"provider"`
+	EntityType     string      `gorm:"size:50;column:entity_type;not null;uniqueIndex:idx_provider_entities_external" json:"entityType"`
+	ExternalID     string      `gorm:"size:255;column:external_id;not null;uniqueIndex:idx_provider_entities_external" json:"externalId"`
+	Name           string      `gorm:"size:255" json:"name"`
+	Data           interface{} `gorm:"type:jsonb" json:"data,omitempty"`
+	InstallationID *int64      `gorm:"column:installation_id;index" json:"installationId,omitempty"`
+	LastEventType  string      `gorm:"size:100;column:last_event_type" json:"lastEventType"`
+	SyncedAt       time.Time   `gorm:"column:synced_at" json:"syncedAt"`
+	CreatedAt      time.Time   `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt      time.Time   `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (ProviderEntity) TableName() string {
+	return "provider_entities"
+}
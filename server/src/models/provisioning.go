@@ -0,0 +1,152 @@
+package models
+
+import "time"
+
+// Événements du cycle de vie utilisateur déclenchant un provisioning sortant
+const (
+	ProvisioningEventUserCreated     = "user.created"
+	ProvisioningEventUserUpdated     = "user.updated"
+	ProvisioningEventUserDeactivated = "user.deactivated"
+	ProvisioningEventUserDeleted     = "user.deleted"
+)
+
+// Statuts d'une tâche de provisioning
+const (
+	ProvisioningTaskPending    = "pending"
+	ProvisioningTaskProcessing = "processing"
+	ProvisioningTaskSucceeded  = "succeeded"
+	ProvisioningTaskFailed     = "failed"
+)
+
+// Modes d'authentification auprès de l'endpoint SCIM distant
+const (
+	ProvisioningAuthBearer = "bearer"
+	ProvisioningAuthBasic  = "basic"
+	ProvisioningAuthHeader = "header"
+)
+
+// Comportement lors de la suppression d'un utilisateur local
+const (
+	ProvisioningDeprovisionDeactivate = "deactivate"
+	ProvisioningDeprovisionDelete     = "delete"
+)
+
+// ApplicationProvisioning configure une application comme cible de provisioning SCIM
+type ApplicationProvisioning struct {
+	ID                string      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ApplicationID     string      `gorm:"type:uuid;uniqueIndex;column:application_id;not null" json:"applicationId"`
+	Enabled           bool        `gorm:"default:false" json:"enabled"`
+	BaseURL           string      `gorm:"size:500;column:base_url;not null" json:"baseUrl"`
+	AuthType          string      `gorm:"size:20;column:auth_type;default:bearer" json:"authType"`
+	AuthHeaderName    *string     `gorm:"size:100;column:auth_header_name" json:"authHeaderName,omitempty"`
+	Username          *string     `gorm:"size:255" json:"username,omitempty"`
+	Secret            *string     `gorm:"type:text" json:"-"`
+	AttributeMapping  interface{} `gorm:"type:jsonb;column:attribute_mapping" json:"attributeMapping,omitempty"`
+	DeprovisionMode   string      `gorm:"size:20;column:deprovision_mode;default:deactivate" json:"deprovisionMode"`
+	MaxRetries        int         `gorm:"default:5;column:max_retries" json:"maxRetries"`
+	ReconcileInterval int         `gorm:"default:0;column:reconcile_interval" json:"reconcileInterval"` // minutes, 0 = désactivé
+	LastReconciledAt  *time.Time  `gorm:"column:last_reconciled_at" json:"lastReconciledAt,omitempty"`
+	LastError         *string     `gorm:"type:text;column:last_error" json:"lastError,omitempty"`
+	CreatedAt         time.Time   `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt         time.Time   `gorm:"column:updated_at" json:"updatedAt"`
+
+	Application *Application `gorm:"foreignKey:ApplicationID" json:"application,omitempty"`
+}
+
+func (ApplicationProvisioning) TableName() string {
+	return "application_provisionings"
+}
+
+// ProvisionedUser associe un utilisateur local à sa ressource distante
+type ProvisionedUser struct {
+	ID            string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ApplicationID string     `gorm:"type:uuid;column:application_id;not null;uniqueIndex:idx_provisioned_users_app_user" json:"applicationId"`
+	UserID        string     `gorm:"type:uuid;column:user_id;not null;uniqueIndex:idx_provisioned_users_app_user" json:"userId"`
+	RemoteID      string     `gorm:"size:255;column:remote_id;not null" json:"remoteId"`
+	Active        bool       `gorm:"default:true" json:"active"`
+	LastSyncedAt  *time.Time `gorm:"column:last_synced_at" json:"lastSyncedAt,omitempty"`
+	CreatedAt     time.Time  `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (ProvisionedUser) TableName() string {
+	return "provisioned_users"
+}
+
+// ProvisioningTask représente un envoi en attente vers une application cible
+type ProvisioningTask struct {
+	ID            string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ApplicationID string    `gorm:"type:uuid;column:application_id;not null;index" json:"applicationId"`
+	UserID        string    `gorm:"type:uuid;column:user_id;not null;index" json:"userId"`
+	Event         string    `gorm:"size:50;not null" json:"event"`
+	Status        string    `gorm:"size:20;not null;default:pending;index" json:"status"`
+	Attempts      int       `gorm:"default:0" json:"attempts"`
+	NextAttemptAt time.Time `gorm:"column:next_attempt_at;index" json:"nextAttemptAt"`
+	LastError     *string   `gorm:"type:text;column:last_error" json:"lastError,omitempty"`
+	CreatedAt     time.Time `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt     time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (ProvisioningTask) TableName() string {
+	return "provisioning_tasks"
+}
+
+// ProvisioningLog trace un appel vers l'endpoint SCIM d'une application
+type ProvisioningLog struct {
+	ID            string    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ApplicationID string    `gorm:"type:uuid;column:application_id;not null;index" json:"applicationId"`
+	UserID        *string   `gorm:"type:uuid;column:user_id;index" json:"userId,omitempty"`
+	TaskID        *string   `gorm:"type:uuid;column:task_id" json:"taskId,omitempty"`
+	Operation     string    `gorm:"size:50;not null" json:"operation"` // create, update, deactivate, delete, reconcile, test
+	Method        string    `gorm:"size:10" json:"method,omitempty"`
+	Path          string    `gorm:"size:500" json:"path,omitempty"`
+	StatusCode    int       `gorm:"column:status_code" json:"statusCode,omitempty"`
+	Success       bool      `gorm:"default:false" json:"success"`
+	Message       string    `gorm:"type:text" json:"message"`
+	DurationMs    int64     `gorm:"column:duration_ms" json:"durationMs"`
+	CreatedAt     time.Time `gorm:"column:created_at;index" json:"createdAt"`
+}
+
+func (ProvisioningLog) TableName() string {
+	return "provisioning_logs"
+}
+
+// ApplicationProvisioningRequest représente la configuration envoyée par l'API d'administration
+type ApplicationProvisioningRequest struct {
+	Enabled           *bool             `json:"enabled"`
+	BaseURL           *string           `json:"baseUrl"`
+	AuthType          *string           `json:"authType"`
+	AuthHeaderName    *string           `json:"authHeaderName"`
+	Username          *string           `json:"username"`
+	Secret            *string           `json:"secret"`
+	AttributeMapping  map[string]string `json:"attributeMapping"`
+	DeprovisionMode   *string           `json:"deprovisionMode"`
+	MaxRetries        *int              `json:"maxRetries"`
+	ReconcileInterval *int              `json:"reconcileInterval"`
+}
+
+// ProvisioningDrift décrit un écart entre l'état local et l'état distant
+type ProvisioningDrift struct {
+	UserID     string               `json:"userId,omitempty"`
+	RemoteID   string               `json:"remoteId,omitempty"`
+	UserName   string               `json:"userName"`
+	Action     string               `json:"action"` // create, update, deprovision, none
+	Attributes map[string][2]string `json:"attributes,omitempty"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// ProvisioningReconcileReport résume une réconciliation entre l'état local et distant
+type ProvisioningReconcileReport struct {
+	ApplicationID string              `json:"applicationId"`
+	DryRun        bool                `json:"dryRun"`
+	StartedAt     time.Time           `json:"startedAt"`
+	CompletedAt   time.Time           `json:"completedAt"`
+	LocalUsers    int                 `json:"localUsers"`
+	RemoteUsers   int                 `json:"remoteUsers"`
+	Missing       []ProvisioningDrift `json:"missing"`
+	Drifted       []ProvisioningDrift `json:"drifted"`
+	Deprovision   []ProvisioningDrift `json:"deprovision"`
+	Unmanaged     []ProvisioningDrift `json:"unmanaged"`
+	Applied       int                 `json:"applied"`
+	Failed        int                 `json:"failed"`
+}
@@ -20,6 +20,7 @@ type Role struct {
 	Name        string    `gorm:"size:100;uniqueIndex;not null" json:"name"`
 	Description *string   `gorm:"type:text" json:"description,omitempty"`
 	IsSystem    bool      `gorm:"default:false;column:is_system" json:"isSystem"`
+	ExternalID  *string   `gorm:"size:255;column:external_id;index" json:"externalId,omitempty"`
 	CreatedAt   time.Time `gorm:"column:created_at" json:"createdAt"`
 	UpdatedAt   time.Time `gorm:"column:updated_at" json:"updatedAt"`
 
@@ -56,6 +57,9 @@ type UserRole struct {
 	AssignedBy *string   `gorm:"type:uuid;column:assigned_by" json:"assignedBy,omitempty"`
 	AssignedAt time.Time `gorm:"column:assigned_at" json:"assignedAt"`
 
+	// Rôle limité à une organisation ; nil pour un rôle global
+	OrganizationID *string `gorm:"type:uuid;column:organization_id;index" json:"organizationId,omitempty"`
+
 	User User `gorm:"foreignKey:UserID"`
 	Role Role `gorm:"foreignKey:RoleID"`
 }
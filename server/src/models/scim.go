@@ -0,0 +1,111 @@
+package models
+
+import "time"
+
+// URNs des schémas SCIM 2.0 (RFC 7643 / RFC 7644)
+const (
+	ScimSchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	ScimSchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	ScimSchemaAetherGroup           = "urn:aether:params:scim:schemas:extension:2.0:Group"
+	ScimSchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	ScimSchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	ScimSchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	ScimSchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	ScimSchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	ScimSchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+)
+
+// Types de groupes SCIM : un groupe correspond à un rôle ou à une organisation
+const (
+	ScimGroupTypeRole         = "role"
+	ScimGroupTypeOrganization = "organization"
+)
+
+// ScimMeta représente les métadonnées d'une ressource SCIM
+type ScimMeta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location,omitempty"`
+	Version      string     `json:"version,omitempty"`
+}
+
+// ScimName représente le nom structuré d'un utilisateur SCIM
+type ScimName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// ScimMultiValue représente un attribut multi-valué (emails, photos, groups, members)
+type ScimMultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// ScimUser représente une ressource User SCIM
+type ScimUser struct {
+	Schemas     []string         `json:"schemas"`
+	ID          string           `json:"id,omitempty"`
+	ExternalID  string           `json:"externalId,omitempty"`
+	UserName    string           `json:"userName"`
+	Name        *ScimName        `json:"name,omitempty"`
+	DisplayName string           `json:"displayName,omitempty"`
+	Locale      string           `json:"locale,omitempty"`
+	Timezone    string           `json:"timezone,omitempty"`
+	Active      *bool            `json:"active,omitempty"`
+	Password    string           `json:"password,omitempty"`
+	Emails      []ScimMultiValue `json:"emails,omitempty"`
+	Photos      []ScimMultiValue `json:"photos,omitempty"`
+	Groups      []ScimMultiValue `json:"groups,omitempty"`
+	Meta        *ScimMeta        `json:"meta,omitempty"`
+}
+
+// ScimGroupExtension précise si un groupe SCIM est un rôle ou une organisation
+type ScimGroupExtension struct {
+	Type string `json:"type,omitempty"`
+}
+
+// ScimGroup représente une ressource Group SCIM
+type ScimGroup struct {
+	Schemas     []string            `json:"schemas"`
+	ID          string              `json:"id,omitempty"`
+	ExternalID  string              `json:"externalId,omitempty"`
+	DisplayName string              `json:"displayName"`
+	Members     []ScimMultiValue    `json:"members,omitempty"`
+	Extension   *ScimGroupExtension `json:"urn:aether:params:scim:schemas:extension:2.0:Group,omitempty"`
+	Meta        *ScimMeta           `json:"meta,omitempty"`
+}
+
+// ScimListResponse représente une réponse de liste paginée SCIM
+type ScimListResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int64       `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+// ScimPatchOperation représente une opération d'un PatchOp SCIM
+type ScimPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// ScimPatchRequest représente une requête PATCH SCIM
+type ScimPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []ScimPatchOperation `json:"Operations"`
+}
+
+// ScimError représente une erreur SCIM
+type ScimError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
@@ -60,6 +60,15 @@ type Agent struct {
 	CreatedAt  time.Time      `gorm:"column:created_at" json:"createdAt"`
 	UpdatedAt  time.Time      `gorm:"column:updated_at" json:"updatedAt"`
 	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Protocole agent : enrôlement, heartbeats et credential propre à l'agent
+	Hostname            *string    `gorm:"size:255" json:"hostname,omitempty"`
+	ConfigVersion       *string    `gorm:"size:100;column:config_version" json:"configVersion,omitempty"`
+	StatusMessage       *string    `gorm:"type:text;column:status_message" json:"statusMessage,omitempty"`
+	EnrolledAt          *time.Time `gorm:"column:enrolled_at" json:"enrolledAt,omitempty"`
+	CredentialHash      *string    `gorm:"size:64;column:credential_hash" json:"-"`
+	NextCredentialHash  *string    `gorm:"size:64;column:next_credential_hash" json:"-"` // Rotation en attente d'acquittement
+	CredentialRotatedAt *time.Time `gorm:"column:credential_rotated_at" json:"credentialRotatedAt,omitempty"`
 }
 
 type Event struct {
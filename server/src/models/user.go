@@ -31,6 +31,16 @@ type User struct {
 
 	// Role for admin checks
 	Role string `gorm:"size:50;column:role" json:"role"`
+
+	// Identifiant fourni par un système de provisioning (SCIM)
+	ExternalID *string `gorm:"size:255;column:external_id;index" json:"externalId,omitempty"`
+
+	// Tenant de rattachement ; nul pour un utilisateur de l'instance
+	TenantID *string `gorm:"type:uuid;column:tenant_id;index" json:"tenantId,omitempty"`
+
+	// Verrouillage après des échecs de connexion répétés (BruteForceConfig)
+	FailedLoginAttempts int        `gorm:"default:0;column:failed_login_attempts" json:"-"`
+	LockedUntil         *time.Time `gorm:"column:locked_until" json:"lockedUntil,omitempty"`
 }
 
 // TableName spécifie le nom de la table pour le modèle User
@@ -99,6 +109,7 @@ type UserResponse struct {
 	IsActive      bool       `json:"isActive"`
 	DiscordLinked bool       `json:"discordLinked"`
 	TotpEnabled   bool       `json:"totpEnabled"`
+	TenantID      *string    `json:"tenantId,omitempty"`
 	LastLoginAt   *time.Time `json:"lastLoginAt,omitempty"`
 	CreatedAt     time.Time  `json:"createdAt"`
 	UpdatedAt     time.Time  `json:"updatedAt"`
@@ -115,6 +126,7 @@ func (u *User) ToResponse() *UserResponse {
 		IsActive:      u.IsActive,
 		DiscordLinked: u.DiscordLinked,
 		TotpEnabled:   u.TotpEnabled,
+		TenantID:      u.TenantID,
 		LastLoginAt:   u.LastLoginAt,
 		CreatedAt:     u.CreatedAt,
 		UpdatedAt:     u.UpdatedAt,
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Événements transmis aux webhooks sortants
+const (
+	WebhookEventUserCreated     = ProvisioningEventUserCreated
+	WebhookEventUserUpdated     = ProvisioningEventUserUpdated
+	WebhookEventUserDeactivated = ProvisioningEventUserDeactivated
+	WebhookEventUserDeleted     = ProvisioningEventUserDeleted
+	WebhookEventUserVerified    = "user.verified"
+	WebhookEventUserLockedOut   = "user.locked_out"
+	WebhookEventMfaEnrolled     = "mfa.enrolled"
+	WebhookEventSessionRevoked  = "session.revoked"
+	WebhookEventTest            = "webhook.test"
+)
+
+// WebhookEventTypes liste les événements auxquels un webhook peut s'abonner
+var WebhookEventTypes = []string{
+	WebhookEventUserCreated, WebhookEventUserUpdated, WebhookEventUserDeactivated, WebhookEventUserDeleted,
+	WebhookEventUserVerified, WebhookEventUserLockedOut, WebhookEventMfaEnrolled, WebhookEventSessionRevoked,
+}
+
+// Statuts d'une livraison de webhook
+const (
+	WebhookDeliveryPending    = "pending"
+	WebhookDeliveryProcessing = "processing"
+	WebhookDeliverySucceeded  = "succeeded"
+	WebhookDeliveryFailed     = "failed"
+)
+
+// Webhook est un abonnement d'un endpoint HTTP aux événements d'identité. Sans TenantID, il reçoit
+// les événements de tous les tenants ; Events vide abonne à tous les événements, "user." à un préfixe.
+type Webhook struct {
+	ID                      string     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	TenantID                *string    `gorm:"type:uuid;column:tenant_id;index" json:"tenantId,omitempty"`
+	Name                    string     `gorm:"size:100;not null" json:"name"`
+	URL                     string     `gorm:"size:500;not null" json:"url"`
+	Events                  []string   `gorm:"type:jsonb;serializer:json" json:"events"`
+	Enabled                 bool       `gorm:"default:true" json:"enabled"`
+	Secret                  string     `gorm:"size:100;not null" json:"-"`
+	PreviousSecret          *string    `gorm:"size:100;column:previous_secret" json:"-"`
+	PreviousSecretExpiresAt *time.Time `gorm:"column:previous_secret_expires_at" json:"previousSecretExpiresAt,omitempty"`
+	MaxAttempts             int        `gorm:"default:8;column:max_attempts" json:"maxAttempts"`
+	LastDeliveryAt          *time.Time `gorm:"column:last_delivery_at" json:"lastDeliveryAt,omitempty"`
+	LastDeliveryStatus      *string    `gorm:"size:20;column:last_delivery_status" json:"lastDeliveryStatus,omitempty"`
+	ConsecutiveFailures     int        `gorm:"default:0;column:consecutive_failures" json:"consecutiveFailures"`
+	SecretRotatedAt         *time.Time `gorm:"column:secret_rotated_at" json:"secretRotatedAt,omitempty"`
+	CreatedAt               time.Time  `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt               time.Time  `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// WebhookDelivery trace l'envoi d'un événement à un webhook, jusqu'au succès ou à l'abandon
+type WebhookDelivery struct {
+	ID            string          `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	WebhookID     string          `gorm:"type:uuid;column:webhook_id;not null;index" json:"webhookId"`
+	EventID       string          `gorm:"size:100;column:event_id;not null;index" json:"eventId"`
+	EventType     string          `gorm:"size:100;column:event_type;not null" json:"eventType"`
+	Payload       json.RawMessage `gorm:"type:jsonb;serializer:json" json:"payload"`
+	Status        string          `gorm:"size:20;not null;default:pending;index" json:"status"`
+	Attempts      int             `gorm:"default:0" json:"attempts"`
+	NextAttemptAt time.Time       `gorm:"column:next_attempt_at;index" json:"nextAttemptAt"`
+	ResponseCode  *int            `gorm:"column:response_code" json:"responseCode,omitempty"`
+	ResponseBody  *string         `gorm:"type:text;column:response_body" json:"responseBody,omitempty"`
+	LastError     *string         `gorm:"type:text;column:last_error" json:"lastError,omitempty"`
+	DurationMs    int64           `gorm:"column:duration_ms" json:"durationMs"`
+	RedeliveryOf  *string         `gorm:"type:uuid;column:redelivery_of" json:"redeliveryOf,omitempty"`
+	DeliveredAt   *time.Time      `gorm:"column:delivered_at" json:"deliveredAt,omitempty"`
+	CreatedAt     time.Time       `gorm:"column:created_at;index" json:"createdAt"`
+	UpdatedAt     time.Time       `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookRequest représente un abonnement envoyé par l'API d'administration
+type WebhookRequest struct {
+	TenantID    *string  `json:"tenantId"`
+	Name        *string  `json:"name"`
+	URL         *string  `json:"url"`
+	Events      []string `json:"events"`
+	Enabled     *bool    `json:"enabled"`
+	MaxAttempts *int     `json:"maxAttempts"`
+}
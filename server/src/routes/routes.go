@@ -10,6 +10,10 @@ import (
 
 func SetupRoutes(router *gin.Engine, systemKey string, serviceKeyService *services.ServiceKeyService, dbService interfaces.IDatabaseService) {
 	router.Use(middleware.AdaptiveCORSMiddleware())
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Telemetry())
+
+	router.GET("/metrics", controllers.GetMetrics)
 
 	externalAuthController := controllers.NewExternalAuthController()
 	databaseController := controllers.NewDatabaseController(dbService)
@@ -18,9 +22,14 @@ func SetupRoutes(router *gin.Engine, systemKey string, serviceKeyService *servic
 	{
 		apiV1.GET("/health", controllers.HealthCheck)
 		apiV1.HEAD("/health", controllers.HealthCheck)
+		apiV1.GET("/health/live", controllers.LivenessCheck)
+		apiV1.HEAD("/health/live", controllers.LivenessCheck)
+		apiV1.GET("/health/ready", controllers.ReadinessCheck)
+		apiV1.HEAD("/health/ready", controllers.ReadinessCheck)
 
 		authPublic := apiV1.Group("/auth")
 		authPublic.Use(middleware.DatabaseMiddleware(dbService))
+		authPublic.Use(middleware.AuditTrail())
 		{
 			authPublic.POST("/login", controllers.Login)
 			authPublic.POST("/register", controllers.Register)
@@ -29,18 +38,19 @@ func SetupRoutes(router *gin.Engine, systemKey string, serviceKeyService *servic
 		protectedV1 := apiV1.Group("")
 		protectedV1.Use(middleware.AppAuth(systemKey, serviceKeyService))
 		protectedV1.Use(middleware.DatabaseMiddleware(dbService))
+		protectedV1.Use(middleware.AuditTrail())
 		{
 			protectedV1.GET("/check-email", controllers.CheckEmailAvailability)
 
 			databaseRoutes := protectedV1.Group("/database")
-			databaseRoutes.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			databaseRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("database:read"))
 			{
 				databaseRoutes.GET("/status", databaseController.GetStatus)
 				databaseRoutes.GET("/stats", databaseController.GetStats)
 				databaseRoutes.GET("/tables", databaseController.GetTables)
 				databaseRoutes.GET("/tables/:tableName/schema", databaseController.GetTableSchema)
-				databaseRoutes.POST("/migrate", databaseController.Migrate)
-				databaseRoutes.POST("/maintenance", databaseController.Maintenance)
+				databaseRoutes.POST("/migrate", middleware.RequirePermission("database:write"), databaseController.Migrate)
+				databaseRoutes.POST("/maintenance", middleware.RequirePermission("database:write"), databaseController.Maintenance)
 			}
 
 			authRoutes := protectedV1.Group("/auth")
@@ -83,28 +93,28 @@ func SetupRoutes(router *gin.Engine, systemKey string, serviceKeyService *servic
 			}
 
 			clientRoutes := protectedV1.Group("/clients")
-			clientRoutes.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			clientRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("clients:read"))
 			{
-				clientRoutes.POST("", controllers.CreateClient)
+				clientRoutes.POST("", middleware.RequirePermission("clients:write"), controllers.CreateClient)
 				clientRoutes.GET("", controllers.ListClients)
 				clientRoutes.GET(":clientId", controllers.GetClient)
-				clientRoutes.PUT(":clientId", controllers.UpdateClient)
-				clientRoutes.POST(":clientId/rotate-secret", controllers.RotateClientSecret)
-				clientRoutes.DELETE(":clientId", controllers.DeleteClient)
+				clientRoutes.PUT(":clientId", middleware.RequirePermission("clients:write"), controllers.UpdateClient)
+				clientRoutes.POST(":clientId/rotate-secret", middleware.RequirePermission("clients:write"), controllers.RotateClientSecret)
+				clientRoutes.DELETE(":clientId", middleware.RequirePermission("clients:delete"), controllers.DeleteClient)
 			}
 
 			domainRoutes := protectedV1.Group("/domains")
-			domainRoutes.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			domainRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("domains:read"))
 			{
-				domainRoutes.POST("", controllers.CreateDomain)
+				domainRoutes.POST("", middleware.RequirePermission("domains:write"), controllers.CreateDomain)
 				domainRoutes.GET("", controllers.ListDomains)
 				domainRoutes.GET(":domainId", controllers.GetDomain)
-				domainRoutes.PUT(":domainId", controllers.UpdateDomain)
-				domainRoutes.DELETE(":domainId", controllers.DeleteDomain)
-				domainRoutes.POST(":domainId/verify", controllers.VerifyDomain)
+				domainRoutes.PUT(":domainId", middleware.RequirePermission("domains:write"), controllers.UpdateDomain)
+				domainRoutes.DELETE(":domainId", middleware.RequirePermission("domains:delete"), controllers.DeleteDomain)
+				domainRoutes.POST(":domainId/verify", middleware.RequirePermission("domains:write"), controllers.VerifyDomain)
 				domainRoutes.GET(":domainId/users", controllers.GetDomainUsers)
-				domainRoutes.POST(":domainId/users", controllers.AddUserToDomain)
-				domainRoutes.DELETE(":domainId/users/:userId", controllers.RemoveUserFromDomain)
+				domainRoutes.POST(":domainId/users", middleware.RequirePermission("domains:write"), controllers.AddUserToDomain)
+				domainRoutes.DELETE(":domainId/users/:userId", middleware.RequirePermission("domains:delete"), controllers.RemoveUserFromDomain)
 				domainRoutes.GET(":domainId/details", controllers.GetDomainDetails)
 			}
 
@@ -112,6 +122,7 @@ func SetupRoutes(router *gin.Engine, systemKey string, serviceKeyService *servic
 			userRoutes.Use(middleware.AuthMiddleware())
 			{
 				userRoutes.GET("/me", controllers.GetCurrentUser)
+				userRoutes.GET("/me/permissions", controllers.GetCurrentUserPermissions)
 				userRoutes.GET(":id", controllers.GetUser)
 				userRoutes.PUT(":id", controllers.UpdateUser)
 				userRoutes.DELETE(":id", controllers.DeleteUser)
@@ -121,17 +132,50 @@ func SetupRoutes(router *gin.Engine, systemKey string, serviceKeyService *servic
 			}
 
 			adminUserRoutes := protectedV1.Group("/admin/users")
-			adminUserRoutes.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			adminUserRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("users:read"))
 			{
 				adminUserRoutes.GET("", controllers.ListUsers)
-				adminUserRoutes.POST("", controllers.CreateUserAdmin)
+				adminUserRoutes.POST("", middleware.RequirePermission("users:write"), controllers.CreateUserAdmin)
+				adminUserRoutes.GET(":id/roles", controllers.ListUserRoles)
+				adminUserRoutes.POST(":id/roles", middleware.RequirePermission("roles:write"), controllers.AssignUserRole)
+				adminUserRoutes.DELETE(":id/roles/:userRoleId", middleware.RequirePermission("roles:delete"), controllers.RevokeUserRole)
+				adminUserRoutes.GET(":id/permissions", controllers.GetUserPermissions)
+			}
+
+			roleRoutes := protectedV1.Group("/roles")
+			roleRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("roles:read"))
+			{
+				roleRoutes.GET("", controllers.ListRoles)
+				roleRoutes.POST("", middleware.RequirePermission("roles:write"), controllers.CreateRole)
+				roleRoutes.GET(":id", controllers.GetRole)
+				roleRoutes.PATCH(":id", middleware.RequirePermission("roles:write"), controllers.UpdateRole)
+				roleRoutes.DELETE(":id", middleware.RequirePermission("roles:delete"), controllers.DeleteRole)
+				roleRoutes.POST(":id/permissions", middleware.RequirePermission("roles:write"), controllers.AddRolePermission)
+				roleRoutes.DELETE(":id/permissions/:permissionId", middleware.RequirePermission("roles:delete"), controllers.RemoveRolePermission)
+			}
+
+			permissionRoutes := protectedV1.Group("/permissions")
+			permissionRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("roles:read"))
+			{
+				permissionRoutes.GET("", controllers.ListPermissions)
+				permissionRoutes.POST("", middleware.RequirePermission("roles:admin"), controllers.CreatePermission)
+				permissionRoutes.DELETE(":id", middleware.RequirePermission("roles:admin"), controllers.DeletePermission)
 			}
 
 			adminOAuthRoutes := protectedV1.Group("/admin/oauth")
-			adminOAuthRoutes.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			adminOAuthRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("oauth:read"))
 			{
 				adminOAuthRoutes.GET("/external-accounts/migration-status", externalAuthController.GetMigrationStatus)
-				adminOAuthRoutes.POST("/external-accounts/migrate", externalAuthController.MigrateExternalAccounts)
+				adminOAuthRoutes.POST("/external-accounts/migrate", middleware.RequirePermission("oauth:write"), externalAuthController.MigrateExternalAccounts)
+				adminOAuthRoutes.GET("/federation/issuers", controllers.ListFederatedIssuers)
+				adminOAuthRoutes.POST("/federation/issuers", middleware.RequirePermission("oauth:write"), controllers.CreateFederatedIssuer)
+				adminOAuthRoutes.GET("/federation/issuers/:id", controllers.GetFederatedIssuer)
+				adminOAuthRoutes.PUT("/federation/issuers/:id", middleware.RequirePermission("oauth:write"), controllers.UpdateFederatedIssuer)
+				adminOAuthRoutes.DELETE("/federation/issuers/:id", middleware.RequirePermission("oauth:write"), controllers.DeleteFederatedIssuer)
+				adminOAuthRoutes.POST("/federation/issuers/:id/bindings", middleware.RequirePermission("oauth:write"), controllers.CreateFederatedBinding)
+				adminOAuthRoutes.DELETE("/federation/issuers/:id/bindings/:bindingId", middleware.RequirePermission("oauth:write"), controllers.DeleteFederatedBinding)
+				adminOAuthRoutes.POST("/federation/issuers/:id/policies", middleware.RequirePermission("oauth:write"), controllers.CreateFederationTrustPolicy)
+				adminOAuthRoutes.DELETE("/federation/issuers/:id/policies/:policyId", middleware.RequirePermission("oauth:write"), controllers.DeleteFederationTrustPolicy)
 			}
 
 			userKeysRoutes := protectedV1.Group("/keys")
@@ -160,106 +204,115 @@ func SetupRoutes(router *gin.Engine, systemKey string, serviceKeyService *servic
 			serviceKeyRoutes.POST("/validate", controllers.ValidateServiceKey)
 
 			applicationRoutes := protectedV1.Group("/applications")
-			applicationRoutes.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			applicationRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("applications:read"))
 			{
 				applicationRoutes.GET("", controllers.ListApplications)
-				applicationRoutes.POST("", controllers.CreateApplication)
+				applicationRoutes.POST("", middleware.RequirePermission("applications:write"), controllers.CreateApplication)
 				applicationRoutes.GET(":id", controllers.GetApplication)
-				applicationRoutes.PATCH(":id", controllers.UpdateApplication)
-				applicationRoutes.DELETE(":id", controllers.DeleteApplication)
-				applicationRoutes.GET(":id/credentials", controllers.GetApplicationCredentials)
-				applicationRoutes.POST(":id/rotate-secret", controllers.RotateApplicationSecret)
+				applicationRoutes.PATCH(":id", middleware.RequirePermission("applications:write"), controllers.UpdateApplication)
+				applicationRoutes.DELETE(":id", middleware.RequirePermission("applications:delete"), controllers.DeleteApplication)
+				applicationRoutes.GET(":id/credentials", middleware.RequirePermission("applications:admin"), controllers.GetApplicationCredentials)
+				applicationRoutes.POST(":id/rotate-secret", middleware.RequirePermission("applications:write"), controllers.RotateApplicationSecret)
 				applicationRoutes.GET(":id/stats", controllers.GetApplicationStats)
+				applicationRoutes.GET(":id/provisioning", controllers.GetApplicationProvisioning)
+				applicationRoutes.PUT(":id/provisioning", middleware.RequirePermission("applications:write"), controllers.UpdateApplicationProvisioning)
+				applicationRoutes.DELETE(":id/provisioning", middleware.RequirePermission("applications:delete"), controllers.DeleteApplicationProvisioning)
+				applicationRoutes.POST(":id/provisioning/test", middleware.RequirePermission("applications:write"), controllers.TestApplicationProvisioning)
+				applicationRoutes.POST(":id/provisioning/reconcile", middleware.RequirePermission("applications:write"), controllers.ReconcileApplicationProvisioning)
+				applicationRoutes.POST(":id/provisioning/users/:userId/sync", middleware.RequirePermission("applications:write"), controllers.SyncApplicationProvisioningUser)
+				applicationRoutes.GET(":id/provisioning/tasks", controllers.ListApplicationProvisioningTasks)
+				applicationRoutes.POST(":id/provisioning/tasks/:taskId/retry", middleware.RequirePermission("applications:write"), controllers.RetryApplicationProvisioningTask)
+				applicationRoutes.GET(":id/provisioning/logs", controllers.ListApplicationProvisioningLogs)
 				applicationRoutes.GET("/apis", controllers.ListApiApplications)
 				applicationRoutes.GET("/external", controllers.ListExternalApplications)
 			}
 
 			organizationRoutes := protectedV1.Group("/organizations")
-			organizationRoutes.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			organizationRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("organizations:read"))
 			{
 				organizationRoutes.GET("", controllers.ListOrganizations)
-				organizationRoutes.POST("", controllers.CreateOrganization)
+				organizationRoutes.POST("", middleware.RequirePermission("organizations:write"), controllers.CreateOrganization)
 				organizationRoutes.GET(":id", controllers.GetOrganization)
-				organizationRoutes.PATCH(":id", controllers.UpdateOrganization)
-				organizationRoutes.DELETE(":id", controllers.DeleteOrganization)
+				organizationRoutes.PATCH(":id", middleware.RequirePermission("organizations:write"), controllers.UpdateOrganization)
+				organizationRoutes.DELETE(":id", middleware.RequirePermission("organizations:delete"), controllers.DeleteOrganization)
 				organizationRoutes.GET(":id/members", controllers.ListOrganizationMembers)
-				organizationRoutes.POST(":id/members", controllers.AddOrganizationMember)
-				organizationRoutes.DELETE(":id/members/:userId", controllers.RemoveOrganizationMember)
-				organizationRoutes.PATCH(":id/members/:userId", controllers.UpdateOrganizationMember)
+				organizationRoutes.POST(":id/members", middleware.RequirePermission("organizations:write"), controllers.AddOrganizationMember)
+				organizationRoutes.DELETE(":id/members/:userId", middleware.RequirePermission("organizations:delete"), controllers.RemoveOrganizationMember)
+				organizationRoutes.PATCH(":id/members/:userId", middleware.RequirePermission("organizations:write"), controllers.UpdateOrganizationMember)
 			}
 
 			connectionRoutes := protectedV1.Group("/connections")
-			connectionRoutes.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			connectionRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("connections:read"))
 			{
 				connectionRoutes.GET("", controllers.ListConnections)
-				connectionRoutes.POST("", controllers.CreateConnection)
+				connectionRoutes.POST("", middleware.RequirePermission("connections:write"), controllers.CreateConnection)
 				connectionRoutes.GET(":id", controllers.GetConnection)
-				connectionRoutes.PATCH(":id", controllers.UpdateConnection)
-				connectionRoutes.DELETE(":id", controllers.DeleteConnection)
-				connectionRoutes.POST(":id/enable", controllers.EnableConnection)
-				connectionRoutes.POST(":id/disable", controllers.DisableConnection)
+				connectionRoutes.PATCH(":id", middleware.RequirePermission("connections:write"), controllers.UpdateConnection)
+				connectionRoutes.DELETE(":id", middleware.RequirePermission("connections:delete"), controllers.DeleteConnection)
+				connectionRoutes.POST(":id/enable", middleware.RequirePermission("connections:write"), controllers.EnableConnection)
+				connectionRoutes.POST(":id/disable", middleware.RequirePermission("connections:write"), controllers.DisableConnection)
 
 				dbConnRoutes := connectionRoutes.Group("/database")
 				{
-					dbConnRoutes.POST("", controllers.CreateDatabaseConnection)
-					dbConnRoutes.PATCH(":id", controllers.ConfigureDatabaseConnection)
+					dbConnRoutes.POST("", middleware.RequirePermission("connections:write"), controllers.CreateDatabaseConnection)
+					dbConnRoutes.PATCH(":id", middleware.RequirePermission("connections:write"), controllers.ConfigureDatabaseConnection)
 					dbConnRoutes.GET(":id/users", controllers.ListDatabaseConnectionUsers)
 				}
 
 				socialRoutes := connectionRoutes.Group("/social")
 				{
 					socialRoutes.GET("", controllers.ListSocialProviders)
-					socialRoutes.POST("", controllers.ConfigureSocialProvider)
+					socialRoutes.POST("", middleware.RequirePermission("connections:write"), controllers.ConfigureSocialProvider)
 				}
 
 				enterpriseRoutes := connectionRoutes.Group("/enterprise")
 				{
 					enterpriseRoutes.GET("", controllers.ListEnterpriseConnections)
-					enterpriseRoutes.POST("/saml", controllers.CreateSamlConnection)
-					enterpriseRoutes.PATCH("/saml/:id", controllers.UpdateSamlSettings)
-					enterpriseRoutes.POST("/saml/:id/metadata", controllers.UpdateSamlMetadata)
-					enterpriseRoutes.POST("/oidc", controllers.CreateOidcConnection)
+					enterpriseRoutes.POST("/saml", middleware.RequirePermission("connections:write"), controllers.CreateSamlConnection)
+					enterpriseRoutes.PATCH("/saml/:id", middleware.RequirePermission("connections:write"), controllers.UpdateSamlSettings)
+					enterpriseRoutes.POST("/saml/:id/metadata", middleware.RequirePermission("connections:write"), controllers.UpdateSamlMetadata)
+					enterpriseRoutes.POST("/oidc", middleware.RequirePermission("connections:write"), controllers.CreateOidcConnection)
 				}
 
 				passwordlessRoutes := connectionRoutes.Group("/passwordless")
 				{
 					passwordlessRoutes.GET("", controllers.ListPasswordlessSettings)
-					passwordlessRoutes.POST("", controllers.EnablePasswordless)
-					passwordlessRoutes.PATCH(":id", controllers.ConfigurePasswordless)
+					passwordlessRoutes.POST("", middleware.RequirePermission("connections:write"), controllers.EnablePasswordless)
+					passwordlessRoutes.PATCH(":id", middleware.RequirePermission("connections:write"), controllers.ConfigurePasswordless)
 				}
 
 				authProfileRoutes := connectionRoutes.Group("/authentication-profiles")
 				{
 					authProfileRoutes.GET("", controllers.ListAuthenticationProfiles)
-					authProfileRoutes.POST("", controllers.CreateAuthenticationProfile)
+					authProfileRoutes.POST("", middleware.RequirePermission("connections:write"), controllers.CreateAuthenticationProfile)
 				}
 			}
 
 			securityRoutes := protectedV1.Group("/security")
-			securityRoutes.Use(middleware.AuthMiddleware())
+			securityRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("security:read"))
 			{
 				mfaRoutes := securityRoutes.Group("/mfa")
 				{
 					mfaRoutes.GET("/methods", controllers.ListMfaMethods)
-					mfaRoutes.PATCH("/methods/:id", controllers.EnableDisableMfaMethod)
+					mfaRoutes.PATCH("/methods/:id", middleware.RequirePermission("security:write"), controllers.EnableDisableMfaMethod)
 					mfaRoutes.GET("/policies", controllers.ListMfaPolicies)
-					mfaRoutes.POST("/policies", controllers.CreateMfaPolicy)
-					mfaRoutes.PATCH("/policies/:id", controllers.UpdateMfaPolicy)
-					mfaRoutes.DELETE("/policies/:id", controllers.DeleteMfaPolicy)
+					mfaRoutes.POST("/policies", middleware.RequirePermission("security:write"), controllers.CreateMfaPolicy)
+					mfaRoutes.PATCH("/policies/:id", middleware.RequirePermission("security:write"), controllers.UpdateMfaPolicy)
+					mfaRoutes.DELETE("/policies/:id", middleware.RequirePermission("security:delete"), controllers.DeleteMfaPolicy)
 					mfaRoutes.GET("/stats", controllers.GetMfaStats)
 					mfaRoutes.GET("/activity", controllers.GetMfaActivity)
-					mfaRoutes.POST("/challenge", controllers.InitiateMfaChallenge)
-					mfaRoutes.POST("/verify", controllers.VerifyMfaCode)
+					mfaRoutes.POST("/challenge", middleware.RequirePermission("security:write"), controllers.InitiateMfaChallenge)
+					mfaRoutes.POST("/verify", middleware.RequirePermission("security:write"), controllers.VerifyMfaCode)
 				}
 
 				attackRoutes := securityRoutes.Group("/attack-protection")
 				{
 					attackRoutes.GET("", controllers.GetAttackProtectionSettings)
-					attackRoutes.PATCH("", controllers.UpdateAttackProtectionSettings)
+					attackRoutes.PATCH("", middleware.RequirePermission("security:write"), controllers.UpdateAttackProtectionSettings)
 					attackRoutes.GET("/brute-force", controllers.GetBruteForceConfig)
-					attackRoutes.PATCH("/brute-force", controllers.UpdateBruteForceConfig)
+					attackRoutes.PATCH("/brute-force", middleware.RequirePermission("security:write"), controllers.UpdateBruteForceConfig)
 					attackRoutes.GET("/breached-passwords", controllers.GetBreachedPasswordsConfig)
-					attackRoutes.PATCH("/breached-passwords", controllers.UpdateBreachedPasswordsConfig)
+					attackRoutes.PATCH("/breached-passwords", middleware.RequirePermission("security:write"), controllers.UpdateBreachedPasswordsConfig)
 				}
 
 				securityRoutes.GET("/analytics", controllers.GetSecurityAnalytics)
@@ -268,79 +321,88 @@ func SetupRoutes(router *gin.Engine, systemKey string, serviceKeyService *servic
 			}
 
 			brandingRoutes := protectedV1.Group("/branding")
-			brandingRoutes.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			brandingRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("branding:read"))
 			{
 				brandingRoutes.GET("", controllers.GetBrandingSettings)
-				brandingRoutes.PATCH("", controllers.UpdateBrandingSettings)
+				brandingRoutes.PATCH("", middleware.RequirePermission("branding:write"), controllers.UpdateBrandingSettings)
 
 				ulRoutes := brandingRoutes.Group("/universal-login")
 				{
 					ulRoutes.GET("", controllers.GetUniversalLoginConfig)
-					ulRoutes.PATCH("", controllers.UpdateUniversalLogin)
+					ulRoutes.PATCH("", middleware.RequirePermission("branding:write"), controllers.UpdateUniversalLogin)
 					ulRoutes.GET("/pages", controllers.ListLoginPages)
-					ulRoutes.POST("/pages", controllers.CreateLoginPage)
-					ulRoutes.PATCH("/pages/:id", controllers.UpdateLoginPage)
+					ulRoutes.POST("/pages", middleware.RequirePermission("branding:write"), controllers.CreateLoginPage)
+					ulRoutes.PATCH("/pages/:id", middleware.RequirePermission("branding:write"), controllers.UpdateLoginPage)
 				}
 
 				clRoutes := brandingRoutes.Group("/custom-login")
 				{
 					clRoutes.GET("", controllers.GetCustomLoginSettings)
-					clRoutes.PATCH("", controllers.UpdateCustomLogin)
+					clRoutes.PATCH("", middleware.RequirePermission("branding:write"), controllers.UpdateCustomLogin)
 				}
 
 				templateRoutes := brandingRoutes.Group("/templates")
 				{
 					templateRoutes.GET("", controllers.ListBrandingTemplates)
 					templateRoutes.GET(":id", controllers.GetTemplateDetails)
-					templateRoutes.POST("", controllers.CreateTemplate)
-					templateRoutes.DELETE(":id", controllers.DeleteTemplate)
+					templateRoutes.POST("", middleware.RequirePermission("branding:write"), controllers.CreateTemplate)
+					templateRoutes.DELETE(":id", middleware.RequirePermission("branding:delete"), controllers.DeleteTemplate)
 				}
 
 				domainRoutes := brandingRoutes.Group("/custom-domains")
 				{
 					domainRoutes.GET("", controllers.ListCustomDomains)
-					domainRoutes.POST("", controllers.CreateCustomDomain)
+					domainRoutes.POST("", middleware.RequirePermission("branding:write"), controllers.CreateCustomDomain)
 					domainRoutes.GET(":id", controllers.GetCustomDomainDetails)
-					domainRoutes.DELETE(":id", controllers.DeleteCustomDomain)
-					domainRoutes.POST(":id/verify", controllers.VerifyCustomDomain)
+					domainRoutes.DELETE(":id", middleware.RequirePermission("branding:delete"), controllers.DeleteCustomDomain)
+					domainRoutes.POST(":id/verify", middleware.RequirePermission("branding:write"), controllers.VerifyCustomDomain)
 				}
 			}
 
 			actionRoutes := protectedV1.Group("/actions")
-			actionRoutes.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			actionRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("actions:read"))
 			{
 				actionRoutes.GET("", controllers.ListActions)
-				actionRoutes.POST("", controllers.CreateAction)
+				actionRoutes.POST("", middleware.RequirePermission("actions:write"), controllers.CreateAction)
 				actionRoutes.GET(":id", controllers.GetActionDetails)
-				actionRoutes.PATCH(":id", controllers.UpdateAction)
-				actionRoutes.DELETE(":id", controllers.DeleteAction)
-				actionRoutes.POST(":id/deploy", controllers.DeployAction)
-				actionRoutes.POST(":id/test", controllers.TestAction)
+				actionRoutes.PATCH(":id", middleware.RequirePermission("actions:write"), controllers.UpdateAction)
+				actionRoutes.DELETE(":id", middleware.RequirePermission("actions:delete"), controllers.DeleteAction)
+				actionRoutes.POST(":id/deploy", middleware.RequirePermission("actions:write"), controllers.DeployAction)
+				actionRoutes.POST(":id/test", middleware.RequirePermission("actions:write"), controllers.TestAction)
 				actionRoutes.GET(":id/logs", controllers.GetActionLogs)
+				actionRoutes.GET(":id/versions", controllers.ListActionVersions)
+				actionRoutes.GET(":id/versions/diff", controllers.DiffActionVersions)
+				actionRoutes.GET(":id/versions/:version", controllers.GetActionVersion)
+				actionRoutes.POST(":id/rollout", middleware.RequirePermission("actions:write"), controllers.StartActionRollout)
+				actionRoutes.POST(":id/rollout/promote", middleware.RequirePermission("actions:write"), controllers.PromoteActionRollout)
+				actionRoutes.POST(":id/rollback", middleware.RequirePermission("actions:write"), controllers.RollbackAction)
 
 				actionRoutes.GET("/triggers", controllers.ListAvailableTriggers)
 				actionRoutes.GET("/triggers/:triggerId/actions", controllers.ListActionsForTrigger)
+				actionRoutes.GET("/triggers/:triggerId/bindings", controllers.ListTriggerBindings)
+				actionRoutes.PUT("/triggers/:triggerId/bindings", middleware.RequirePermission("actions:write"), controllers.BindAction)
+				actionRoutes.DELETE("/triggers/:triggerId/bindings/:bindingId", middleware.RequirePermission("actions:write"), controllers.UnbindAction)
 
 				actionRoutes.GET("/library", controllers.ListActionLibrary)
-				actionRoutes.POST("/library", controllers.AddActionToLibrary)
-				actionRoutes.DELETE("/library/:id", controllers.RemoveActionFromLibrary)
+				actionRoutes.POST("/library", middleware.RequirePermission("actions:write"), controllers.AddActionToLibrary)
+				actionRoutes.DELETE("/library/:id", middleware.RequirePermission("actions:delete"), controllers.RemoveActionFromLibrary)
 
 				actionRoutes.GET("/forms", controllers.ListFormActions)
-				actionRoutes.POST("/forms", controllers.CreateFormAction)
+				actionRoutes.POST("/forms", middleware.RequirePermission("actions:write"), controllers.CreateFormAction)
 			}
 
 			extensionRoutes := protectedV1.Group("/extensions")
-			extensionRoutes.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			extensionRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("extensions:read"))
 			{
 				extensionRoutes.GET("", controllers.ListExtensions)
-				extensionRoutes.POST("", controllers.InstallExtension)
-				extensionRoutes.DELETE(":id", controllers.UninstallExtension)
+				extensionRoutes.POST("", middleware.RequirePermission("extensions:write"), controllers.InstallExtension)
+				extensionRoutes.DELETE(":id", middleware.RequirePermission("extensions:delete"), controllers.UninstallExtension)
 				extensionRoutes.GET(":id/config", controllers.GetExtensionConfig)
-				extensionRoutes.PATCH(":id/config", controllers.UpdateExtensionConfig)
+				extensionRoutes.PATCH(":id/config", middleware.RequirePermission("extensions:write"), controllers.UpdateExtensionConfig)
 			}
 
 			logRoutes := protectedV1.Group("/logs")
-			logRoutes.Use(middleware.AuthMiddleware())
+			logRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("logs:read"))
 			{
 				logRoutes.GET("", controllers.ListLogs)
 				logRoutes.GET(":id", controllers.GetLogDetails)
@@ -353,21 +415,55 @@ func SetupRoutes(router *gin.Engine, systemKey string, serviceKeyService *servic
 				logRoutes.GET("/stream", controllers.GetLogStream)
 			}
 
-			protectedV1.GET("/monitoring/status", controllers.GetSystemStatus)
-			protectedV1.GET("/monitoring/health", controllers.GetHealthMetrics)
+			logStreamRoutes := protectedV1.Group("/log-streams")
+			logStreamRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("logs:read"))
+			{
+				logStreamRoutes.GET("", controllers.ListLogStreams)
+				logStreamRoutes.POST("", middleware.RequirePermission("logs:write"), controllers.CreateLogStream)
+				logStreamRoutes.GET(":id", controllers.GetLogStreamDetails)
+				logStreamRoutes.PATCH(":id", middleware.RequirePermission("logs:write"), controllers.UpdateLogStream)
+				logStreamRoutes.DELETE(":id", middleware.RequirePermission("logs:delete"), controllers.DeleteLogStream)
+				logStreamRoutes.POST(":id/test", middleware.RequirePermission("logs:write"), controllers.TestLogStream)
+				logStreamRoutes.GET(":id/dead-letters", controllers.ListLogStreamDeadLetters)
+				logStreamRoutes.POST(":id/dead-letters/replay", middleware.RequirePermission("logs:write"), controllers.ReplayLogStreamDeadLetters)
+				logStreamRoutes.DELETE(":id/dead-letters", middleware.RequirePermission("logs:delete"), controllers.PurgeLogStreamDeadLetters)
+			}
+
+			webhookRoutes := protectedV1.Group("/webhooks")
+			webhookRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("webhooks:read"), controllers.WebhookTenantScope)
+			{
+				webhookRoutes.GET("", controllers.ListWebhooks)
+				webhookRoutes.POST("", middleware.RequirePermission("webhooks:write"), controllers.CreateWebhook)
+				webhookRoutes.GET(":id", controllers.GetWebhook)
+				webhookRoutes.PATCH(":id", middleware.RequirePermission("webhooks:write"), controllers.UpdateWebhook)
+				webhookRoutes.DELETE(":id", middleware.RequirePermission("webhooks:delete"), controllers.DeleteWebhook)
+				webhookRoutes.POST(":id/rotate-secret", middleware.RequirePermission("webhooks:write"), controllers.RotateWebhookSecret)
+				webhookRoutes.POST(":id/test", middleware.RequirePermission("webhooks:write"), controllers.TestWebhook)
+				webhookRoutes.GET(":id/deliveries", controllers.ListWebhookDeliveries)
+				webhookRoutes.GET(":id/deliveries/:deliveryId", controllers.GetWebhookDelivery)
+				webhookRoutes.POST(":id/deliveries/:deliveryId/redeliver", middleware.RequirePermission("webhooks:write"), controllers.RedeliverWebhook)
+			}
+
+			monitoringRoutes := protectedV1.Group("/monitoring")
+			monitoringRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("monitoring:read"))
+			{
+				monitoringRoutes.GET("/status", controllers.GetSystemStatus)
+				monitoringRoutes.GET("/health", controllers.GetHealthMetrics)
+			}
 
 			activityRoutes := protectedV1.Group("/activity")
-			activityRoutes.Use(middleware.AuthMiddleware())
+			activityRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("activity:read"))
 			{
 				activityRoutes.GET("", controllers.GetActivityOverview)
 				activityRoutes.GET("/dau", controllers.GetDailyActiveUsers)
 				activityRoutes.GET("/retention", controllers.GetUserRetention)
 				activityRoutes.GET("/signups", controllers.GetSignupData)
 				activityRoutes.GET("/failed-logins", controllers.GetFailedLoginData)
+				activityRoutes.POST("/refresh", middleware.RequirePermission("activity:write"), controllers.RefreshActivity)
 			}
 
 			statsRoutes := protectedV1.Group("/stats")
-			statsRoutes.Use(middleware.AuthMiddleware())
+			statsRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("activity:read"))
 			{
 				statsRoutes.GET("", controllers.GetDashboardStats)
 				statsRoutes.GET("/users", controllers.GetUserStats)
@@ -376,54 +472,67 @@ func SetupRoutes(router *gin.Engine, systemKey string, serviceKeyService *servic
 			}
 
 			settingRoutes := protectedV1.Group("/settings")
-			settingRoutes.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			settingRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("settings:read"))
 			{
 				settingRoutes.GET("", controllers.GetSystemSettings)
-				settingRoutes.PATCH("", controllers.UpdateSystemSettings)
+				settingRoutes.PATCH("", middleware.RequirePermission("settings:write"), controllers.UpdateSystemSettings)
 				settingRoutes.GET("/general", controllers.GetGeneralSettings)
-				settingRoutes.PATCH("/general", controllers.UpdateGeneralSettings)
+				settingRoutes.PATCH("/general", middleware.RequirePermission("settings:write"), controllers.UpdateGeneralSettings)
 				settingRoutes.GET("/docker", controllers.GetDockerSettings)
-				settingRoutes.PATCH("/docker", controllers.UpdateDockerSettings)
+				settingRoutes.PATCH("/docker", middleware.RequirePermission("settings:write"), controllers.UpdateDockerSettings)
 				settingRoutes.GET("/email", controllers.GetEmailSettings)
-				settingRoutes.PATCH("/email", controllers.UpdateEmailSettings)
-				settingRoutes.POST("/email/test", controllers.TestEmailConfig)
+				settingRoutes.PATCH("/email", middleware.RequirePermission("settings:write"), controllers.UpdateEmailSettings)
+				settingRoutes.POST("/email/test", middleware.RequirePermission("settings:write"), controllers.TestEmailConfig)
 				settingRoutes.GET("/features", controllers.GetFeatureFlags)
-				settingRoutes.PATCH("/features", controllers.UpdateFeatureFlags)
+				settingRoutes.PATCH("/features", middleware.RequirePermission("settings:write"), controllers.UpdateFeatureFlags)
 			}
 
 			agentRoutes := protectedV1.Group("/agents")
-			agentRoutes.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			agentRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("agents:read"))
 			{
 				agentRoutes.GET("", controllers.ListAgents)
-				agentRoutes.POST("", controllers.RegisterAgent)
+				agentRoutes.POST("", middleware.RequirePermission("agents:write"), controllers.RegisterAgent)
 				agentRoutes.GET(":id", controllers.GetAgentDetails)
-				agentRoutes.PATCH(":id", controllers.UpdateAgent)
-				agentRoutes.DELETE(":id", controllers.DeleteAgent)
+				agentRoutes.PATCH(":id", middleware.RequirePermission("agents:write"), controllers.UpdateAgent)
+				agentRoutes.DELETE(":id", middleware.RequirePermission("agents:delete"), controllers.DeleteAgent)
 				agentRoutes.GET(":id/status", controllers.GetAgentStatus)
-				agentRoutes.POST(":id/restart", controllers.RestartAgent)
+				agentRoutes.POST(":id/restart", middleware.RequirePermission("agents:write"), controllers.RestartAgent)
+				agentRoutes.GET(":id/commands", controllers.ListAgentCommands)
+				agentRoutes.POST(":id/commands", middleware.RequirePermission("agents:write"), controllers.IssueAgentCommand)
+				agentRoutes.POST("enrollment-tokens", middleware.RequirePermission("agents:write"), controllers.CreateAgentEnrollmentToken)
+			}
+
+			auditRoutes := protectedV1.Group("/audit")
+			auditRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("audit:read"))
+			{
+				auditRoutes.GET("", controllers.ListAuditEntries)
+				auditRoutes.GET("/verify", controllers.VerifyAuditChain)
+				auditRoutes.GET("/export", controllers.ExportAuditEntries)
+				auditRoutes.GET(":id", controllers.GetAuditEntry)
 			}
 
 			eventRoutes := protectedV1.Group("/events")
-			eventRoutes.Use(middleware.AuthMiddleware())
+			eventRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("logs:read"))
 			{
 				eventRoutes.GET("", controllers.ListEvents)
+				eventRoutes.GET("/export", controllers.ExportEvents)
 				eventRoutes.GET(":id", controllers.GetEventDetails)
 			}
 
 			marketplaceRoutes := protectedV1.Group("/marketplace")
-			marketplaceRoutes.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			marketplaceRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("marketplace:read"))
 			{
 				marketplaceRoutes.GET("", controllers.ListMarketplaceIntegrations)
 				marketplaceRoutes.GET(":id", controllers.GetIntegrationDetails)
-				marketplaceRoutes.POST(":id/install", controllers.InstallIntegration)
-				marketplaceRoutes.POST(":id/uninstall", controllers.UninstallIntegration)
+				marketplaceRoutes.POST(":id/install", middleware.RequirePermission("marketplace:write"), controllers.InstallIntegration)
+				marketplaceRoutes.POST(":id/uninstall", middleware.RequirePermission("marketplace:write"), controllers.UninstallIntegration)
 			}
 
 			tenantRoutes := protectedV1.Group("/tenant")
-			tenantRoutes.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+			tenantRoutes.Use(middleware.AuthMiddleware(), middleware.RequirePermission("tenant:read"))
 			{
 				tenantRoutes.GET("", controllers.GetTenantInfo)
-				tenantRoutes.PATCH("", controllers.UpdateTenantSettings)
+				tenantRoutes.PATCH("", middleware.RequirePermission("tenant:write"), controllers.UpdateTenantSettings)
 				tenantRoutes.GET("/usage", controllers.GetTenantUsage)
 				tenantRoutes.GET("/billing", controllers.GetBillingInfo)
 			}
@@ -441,6 +550,67 @@ func SetupRoutes(router *gin.Engine, systemKey string, serviceKeyService *servic
 		oauthRoutes.GET("/jwks", controllers.JWKSHandler)
 	}
 
+	scimRoutes := router.Group("/scim/v2")
+	scimRoutes.Use(middleware.ServiceKeyAuth(serviceKeyService, systemKey))
+	scimRoutes.Use(middleware.DatabaseMiddleware(dbService), controllers.ScimRequireDatabase)
+	scimRoutes.Use(middleware.AuditTrail())
+	{
+		scimRoutes.GET("/ServiceProviderConfig", controllers.ScimServiceProviderConfig)
+		scimRoutes.GET("/ResourceTypes", controllers.ScimListResourceTypes)
+		scimRoutes.GET("/ResourceTypes/:id", controllers.ScimGetResourceType)
+		scimRoutes.GET("/Schemas", controllers.ScimListSchemas)
+		scimRoutes.GET("/Schemas/:id", controllers.ScimGetSchema)
+
+		scimRoutes.GET("/Users", controllers.ScimListUsers)
+		scimRoutes.POST("/Users", controllers.ScimCreateUser)
+		scimRoutes.GET("/Users/:id", controllers.ScimGetUser)
+		scimRoutes.PUT("/Users/:id", controllers.ScimReplaceUser)
+		scimRoutes.PATCH("/Users/:id", controllers.ScimPatchUser)
+		scimRoutes.DELETE("/Users/:id", controllers.ScimDeleteUser)
+
+		scimRoutes.GET("/Groups", controllers.ScimListGroups)
+		scimRoutes.POST("/Groups", controllers.ScimCreateGroup)
+		scimRoutes.GET("/Groups/:id", controllers.ScimGetGroup)
+		scimRoutes.PUT("/Groups/:id", controllers.ScimReplaceGroup)
+		scimRoutes.PATCH("/Groups/:id", controllers.ScimPatchGroup)
+		scimRoutes.DELETE("/Groups/:id", controllers.ScimDeleteGroup)
+	}
+
+	authzRoutes := router.Group("/api/v1/authz")
+	authzRoutes.Use(middleware.ServiceKeyAuth(serviceKeyService, systemKey))
+	authzRoutes.Use(middleware.DatabaseMiddleware(dbService), controllers.AuthzRequireDatabase)
+	{
+		authzRoutes.POST("/check", controllers.AuthzCheck)
+		authzRoutes.POST("/expand", controllers.AuthzExpand)
+		authzRoutes.POST("/list-objects", controllers.AuthzListObjects)
+		authzRoutes.POST("/write", middleware.AuditTrail(), controllers.AuthzWrite)
+		authzRoutes.GET("/tuples", controllers.AuthzReadTuples)
+		authzRoutes.GET("/namespaces", controllers.ListAuthzNamespaces)
+		authzRoutes.PUT("/namespaces/:name", middleware.AuditTrail(), controllers.SaveAuthzNamespace)
+		authzRoutes.DELETE("/namespaces/:name", middleware.AuditTrail(), controllers.DeleteAuthzNamespace)
+	}
+
+	// Synchronisation des connecteurs externes (application GitHub...)
+	providerSyncRoutes := router.Group("/api/v1/sync/:provider")
+	providerSyncRoutes.Use(middleware.ServiceKeyAuth(serviceKeyService, systemKey))
+	providerSyncRoutes.Use(middleware.DatabaseMiddleware(dbService), controllers.ProviderSyncRequireDatabase)
+	{
+		providerSyncRoutes.POST("", controllers.IngestProviderSync)
+		providerSyncRoutes.GET("/entities", controllers.ListProviderEntities)
+		providerSyncRoutes.GET("/entities/:entityType/:externalId", controllers.GetProviderEntity)
+		providerSyncRoutes.DELETE("/entities/:entityType/:externalId", middleware.AuditTrail(), controllers.DeleteProviderEntity)
+	}
+
+	// Protocole des agents : enrôlement par jeton à usage unique, puis credential par agent
+	agentProtocolRoutes := router.Group("/api/v1/agent")
+	agentProtocolRoutes.Use(middleware.DatabaseMiddleware(dbService), middleware.AuditTrail())
+	{
+		agentProtocolRoutes.POST("/enroll", controllers.EnrollAgent)
+		agentProtocolRoutes.POST("/heartbeat", middleware.AgentAuth(), controllers.AgentHeartbeat)
+		agentProtocolRoutes.GET("/commands", middleware.AgentAuth(), controllers.PollAgentCommands)
+		agentProtocolRoutes.POST("/commands/:commandId/ack", middleware.AgentAuth(), controllers.AcknowledgeAgentCommand)
+	}
+
 	appRoutes := router.Group("/api/v1/app")
 	appRoutes.Use(middleware.AppAuth(systemKey, serviceKeyService))
 	{
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/config"
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+)
+
+var (
+	ErrActionFailed = errors.New("action failed")
+	ErrActionNoCode = errors.New("action has no code")
+)
+
+// actionReservedClaims ne peuvent pas être modifiés par une action
+var actionReservedClaims = []string{"sub", "iss", "aud", "exp", "nbf", "iat", "jti", "azp", "nonce", "client_id", "scope", "scopes", "token_type"}
+
+// defaultActionTriggers sont les points de déclenchement créés au démarrage
+var defaultActionTriggers = []models.ActionTrigger{
+	{Name: models.ActionTriggerPostLogin, DisplayName: "Post Login"},
+	{Name: models.ActionTriggerPreUserRegistration, DisplayName: "Pre User Registration"},
+	{Name: models.ActionTriggerPostChangePassword, DisplayName: "Post Change Password"},
+	{Name: models.ActionTriggerCredentialsExchange, DisplayName: "Credentials Exchange"},
+}
+
+// ActionEvent est l'événement transmis aux actions d'un déclencheur
+type ActionEvent struct {
+	Trigger string              `json:"trigger"`
+	User    *ActionEventUser    `json:"user,omitempty"`
+	Client  *ActionEventClient  `json:"client,omitempty"`
+	Request *ActionEventRequest `json:"request,omitempty"`
+	Scopes  []string            `json:"scopes,omitempty"`
+	Secrets map[string]string   `json:"secrets,omitempty"` // Propres à chaque action, jamais journalisés
+
+	Database *ActionEventDatabase `json:"database,omitempty"` // Scripts de connexion de base de données
+}
+
+// ActionEventDatabase est l'opération demandée à un script de base de données personnalisée
+type ActionEventDatabase struct {
+	Operation string                 `json:"operation"`
+	Params    map[string]interface{} `json:"params,omitempty"`
+	Password  string                 `json:"password,omitempty"` // Jamais journalisé
+}
+
+// ActionEventUser décrit l'utilisateur concerné par l'événement
+type ActionEventUser struct {
+	ID            string  `json:"id,omitempty"`
+	Email         *string `json:"email,omitempty"`
+	Name          *string `json:"name,omitempty"`
+	EmailVerified bool    `json:"emailVerified"`
+}
+
+// ActionEventClient décrit le client OAuth concerné par l'événement
+type ActionEventClient struct {
+	ClientID string `json:"clientId"`
+	Name     string `json:"name,omitempty"`
+}
+
+// ActionEventRequest décrit la requête HTTP à l'origine de l'événement
+type ActionEventRequest struct {
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"userAgent,omitempty"`
+	Hostname  string `json:"hostname,omitempty"`
+}
+
+// NewActionEventUser construit l'utilisateur d'un événement
+func NewActionEventUser(user *models.User) *ActionEventUser {
+	if user == nil {
+		return nil
+	}
+	return &ActionEventUser{ID: user.ID, Email: user.Email, Name: user.Name, EmailVerified: user.EmailVerified}
+}
+
+// ActionResult est l'effet cumulé des actions d'un déclencheur
+type ActionResult struct {
+	AccessTokenClaims map[string]interface{} `json:"accessTokenClaims,omitempty"`
+	IDTokenClaims     map[string]interface{} `json:"idTokenClaims,omitempty"`
+	Denied            bool                   `json:"denied"`
+	DenyReason        string                 `json:"denyReason,omitempty"`
+	RedirectURL       string                 `json:"redirectUrl,omitempty"`
+}
+
+// Stopped indique qu'une action a refusé ou redirigé le flux
+func (r *ActionResult) Stopped() bool {
+	return r.Denied || r.RedirectURL != ""
+}
+
+// EnsureDefaultTriggers crée les points de déclenchement standards manquants
+func (s *ActionService) EnsureDefaultTriggers() error {
+	for _, trigger := range defaultActionTriggers {
+		if err := s.DB.Where("name = ?", trigger.Name).FirstOrCreate(&trigger).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExecuteTrigger exécute dans l'ordre des bindings les actions déployées d'un déclencheur.
+// Un refus ou une redirection arrête la chaîne ; l'échec d'une action retourne ErrActionFailed.
+func (s *ActionService) ExecuteTrigger(ctx context.Context, event *ActionEvent) (*ActionResult, error) {
+	result := &ActionResult{}
+	if s.DB == nil {
+		return result, nil
+	}
+
+	trigger, err := s.GetActionTriggerByName(event.Trigger)
+	if err != nil {
+		// Aucun déclencheur configuré : rien à exécuter
+		return result, nil
+	}
+
+	bindings, err := s.ListTriggerBindings(trigger.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, binding := range bindings {
+		if !binding.IsEnabled {
+			continue
+		}
+		action, err := s.GetAction(binding.ActionID)
+		if err != nil || action.Status != "deployed" {
+			continue
+		}
+		version, canary, err := s.selectActionVersion(action, &binding)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrActionFailed, action.Name, err)
+		}
+
+		output, err := s.runAction(ctx, action, version, &trigger.ID, canary, event)
+		if err != nil {
+			if canary {
+				s.checkCanary(action, &binding)
+			}
+			return nil, fmt.Errorf("%w: %s: %v", ErrActionFailed, action.Name, err)
+		}
+		applyActionCommands(result, output.Commands)
+		if result.Stopped() {
+			break
+		}
+	}
+	return result, nil
+}
+
+// RunDatabaseScript exécute la version déployée d'une action pour une opération de base de
+// données personnalisée. Le handler appelé est onExecuteDatabase<Opération> (onExecuteDatabaseGetUser)
+// et sa valeur de retour décrit l'utilisateur, ou null s'il est inconnu.
+func (s *ActionService) RunDatabaseScript(actionID string, operation string, input map[string]interface{}) (map[string]interface{}, error) {
+	action, err := s.GetAction(actionID)
+	if err != nil {
+		return nil, err
+	}
+	if action.Status != "deployed" {
+		return nil, fmt.Errorf("%w: %s", ErrActionNotDeployed, action.Name)
+	}
+	version, _, err := s.selectActionVersion(action, &models.ActionTriggerBinding{})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrActionFailed, action.Name, err)
+	}
+
+	params := make(map[string]interface{}, len(input))
+	password := ""
+	for key, value := range input {
+		if key == "password" {
+			password, _ = value.(string)
+			continue
+		}
+		params[key] = value
+	}
+	event := &ActionEvent{
+		Trigger:  "database-" + strings.ReplaceAll(operation, "_", "-"),
+		Database: &ActionEventDatabase{Operation: operation, Params: params, Password: password},
+	}
+
+	output, err := s.runAction(context.Background(), action, version, nil, false, event)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrActionFailed, action.Name, err)
+	}
+	if output.Result == nil {
+		return nil, nil
+	}
+	record, ok := output.Result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: %s must return a user object or null", ErrActionFailed, action.Name)
+	}
+	return record, nil
+}
+
+// runAction exécute une version d'une action avec ses secrets et enregistre l'exécution dans ActionLog
+func (s *ActionService) runAction(ctx context.Context, action *models.Action, version *models.ActionVersion, triggerID *string, canary bool, event *ActionEvent) (*ActionRunOutput, error) {
+	start := time.Now()
+	log := &models.ActionLog{
+		ActionID:  action.ID,
+		TriggerID: triggerID,
+		Version:   &version.Number,
+		Canary:    canary,
+		StartTime: start,
+		Input:     event.withoutCredentials(), // Secrets vides : ils sont injectés sur une copie
+	}
+
+	output, err := s.execute(ctx, action, version, event)
+
+	end := time.Now()
+	duration := int(end.Sub(start).Milliseconds())
+	log.EndTime = &end
+	log.Duration = &duration
+	log.Status = models.ActionLogStatusSuccess
+	if output != nil {
+		log.Output = output
+	}
+	if err != nil {
+		message := err.Error()
+		log.Status = models.ActionLogStatusFailed
+		log.Error = &message
+		s.logActionFailure(action, version, canary, duration, output != nil, err)
+	}
+	if logErr := s.CreateActionLog(log); logErr != nil {
+		s.logActionError(action, "actions.execution_not_recorded", "failed to record execution", logErr)
+	}
+	return output, err
+}
+
+// logActionFailure journalise l'échec d'une exécution. Une erreur du runtime ou du bac à sable est de
+// niveau error ; une erreur levée par le code, un dépassement de délai ou une sortie trop
+// volumineuse relèvent de l'action (warn).
+func (s *ActionService) logActionFailure(action *models.Action, version *models.ActionVersion, canary bool, duration int, thrown bool, err error) {
+	level := models.LogLevelError
+	if thrown || errors.Is(err, ErrActionTimeout) || errors.Is(err, ErrActionOutputTooLarge) {
+		level = models.LogLevelWarn
+	}
+	NewLogService(s.DB).WriteLog(level, "actions.execution_failed", fmt.Sprintf("Action %s failed: %v", action.Name, err), map[string]interface{}{
+		"action_id":   action.ID,
+		"version":     version.Number,
+		"runtime":     version.Runtime,
+		"canary":      canary,
+		"duration_ms": duration,
+	})
+}
+
+// logActionError journalise une erreur interne au service des actions
+func (s *ActionService) logActionError(action *models.Action, event, message string, err error) {
+	NewLogService(s.DB).WriteLog(models.LogLevelError, event, fmt.Sprintf("Action %s: %s: %v", action.Name, message, err), map[string]interface{}{
+		"action_id": action.ID,
+	})
+}
+
+// execute lance le code d'une version de l'action dans son runtime
+func (s *ActionService) execute(ctx context.Context, action *models.Action, version *models.ActionVersion, event *ActionEvent) (*ActionRunOutput, error) {
+	if strings.TrimSpace(version.Code) == "" {
+		return nil, ErrActionNoCode
+	}
+	runtime, err := GetActionRuntime(version.Runtime)
+	if err != nil {
+		return nil, err
+	}
+
+	withSecrets := *event
+	withSecrets.Secrets = actionSecrets(action)
+
+	output, err := runtime.Run(ctx, ActionRunRequest{
+		Code:    version.Code,
+		Handler: actionHandlerName(event.Trigger),
+		Event:   &withSecrets,
+	}, actionLimitsFromConfig(config.LoadConfig()))
+	if err != nil {
+		return nil, err
+	}
+	if output.Error != "" {
+		return output, errors.New(output.Error)
+	}
+	return output, nil
+}
+
+// withoutCredentials retourne l'événement sans le mot de passe d'un script de base de données,
+// pour qu'il n'apparaisse pas dans ActionLog
+func (e *ActionEvent) withoutCredentials() *ActionEvent {
+	if e.Database == nil || e.Database.Password == "" {
+		return e
+	}
+	logged := *e
+	database := *e.Database
+	database.Password = ""
+	logged.Database = &database
+	return &logged
+}
+
+// applyActionCommands cumule les commandes d'une action dans le résultat du déclencheur
+func applyActionCommands(result *ActionResult, commands []ActionCommand) {
+	for _, command := range commands {
+		switch command.Type {
+		case "set_claim":
+			if command.Name == "" || slices.Contains(actionReservedClaims, command.Name) {
+				continue
+			}
+			claims := &result.AccessTokenClaims
+			if command.Target == "id_token" {
+				claims = &result.IDTokenClaims
+			}
+			if *claims == nil {
+				*claims = make(map[string]interface{})
+			}
+			(*claims)[command.Name] = command.Value
+		case "deny":
+			result.Denied = true
+			result.DenyReason = command.Reason
+		case "redirect":
+			if result.RedirectURL == "" {
+				result.RedirectURL = command.URL
+			}
+		}
+	}
+}
+
+// actionSecrets résout les secrets d'une action : "NOM=valeur", ou "NOM" lu dans la
+// variable d'environnement ACTION_SECRET_NOM du serveur
+func actionSecrets(action *models.Action) map[string]string {
+	if len(action.Secrets) == 0 {
+		return nil
+	}
+	secrets := make(map[string]string, len(action.Secrets))
+	for _, entry := range action.Secrets {
+		name, value, found := strings.Cut(entry, "=")
+		if !found {
+			value = os.Getenv("ACTION_SECRET_" + strings.ToUpper(name))
+		}
+		secrets[name] = value
+	}
+	return secrets
+}
+
+// actionHandlerName retourne la fonction exportée d'un déclencheur : post-login → onExecutePostLogin
+func actionHandlerName(trigger string) string {
+	var name strings.Builder
+	name.WriteString("onExecute")
+	for _, part := range strings.Split(trigger, "-") {
+		if part != "" {
+			name.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return name.String()
+}
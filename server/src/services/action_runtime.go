@@ -0,0 +1,271 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/config"
+)
+
+var (
+	ErrActionRuntimeUnsupported = errors.New("unsupported action runtime")
+	ErrActionTimeout            = errors.New("action exceeded its time limit")
+	ErrActionOutputTooLarge     = errors.New("action output exceeds the size limit")
+	ErrActionSandboxUnavailable = errors.New("action sandbox unavailable: user and network namespaces are required")
+)
+
+// actionMaxOutput borne la sortie d'une exécution (commandes et logs)
+const actionMaxOutput = 1 << 20
+
+// ActionLimits borne les ressources d'une exécution d'action
+type ActionLimits struct {
+	Timeout    time.Duration
+	MemoryMB   int
+	CPUSeconds int
+}
+
+// ActionRunRequest est le code d'une action et l'événement qu'elle traite
+type ActionRunRequest struct {
+	Code    string       `json:"code"`
+	Handler string       `json:"handler"` // Fonction exportée appelée, par exemple onExecutePostLogin
+	Event   *ActionEvent `json:"event"`
+}
+
+// ActionCommand est une opération demandée par une action via son API
+type ActionCommand struct {
+	Type   string      `json:"type"`             // set_claim, deny, redirect
+	Target string      `json:"target,omitempty"` // access_token ou id_token (set_claim)
+	Name   string      `json:"name,omitempty"`
+	Value  interface{} `json:"value,omitempty"`
+	Reason string      `json:"reason,omitempty"`
+	URL    string      `json:"url,omitempty"`
+}
+
+// ActionLogLine est une ligne écrite par une action sur sa console
+type ActionLogLine struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// ActionRunOutput est le résultat brut d'une exécution
+type ActionRunOutput struct {
+	Commands []ActionCommand `json:"commands"`
+	Logs     []ActionLogLine `json:"logs,omitempty"`
+	Error    string          `json:"error,omitempty"`  // Exception levée par l'action
+	Result   interface{}     `json:"result,omitempty"` // Valeur renvoyée par le handler (scripts de base de données)
+}
+
+// ActionRuntime exécute le code d'une action dans un bac à sable
+type ActionRuntime interface {
+	Run(ctx context.Context, req ActionRunRequest, limits ActionLimits) (*ActionRunOutput, error)
+}
+
+var actionRuntimes = struct {
+	sync.RWMutex
+	runtimes map[string]ActionRuntime
+}{runtimes: map[string]ActionRuntime{"nodejs": nodeActionRuntime{}}}
+
+// RegisterActionRuntime enregistre un runtime d'actions (par exemple WebAssembly) sous un nom
+func RegisterActionRuntime(name string, runtime ActionRuntime) {
+	actionRuntimes.Lock()
+	defer actionRuntimes.Unlock()
+	actionRuntimes.runtimes[name] = runtime
+}
+
+// GetActionRuntime retourne le runtime enregistré sous ce nom
+func GetActionRuntime(name string) (ActionRuntime, error) {
+	actionRuntimes.RLock()
+	defer actionRuntimes.RUnlock()
+	runtime, ok := actionRuntimes.runtimes[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrActionRuntimeUnsupported, name)
+	}
+	return runtime, nil
+}
+
+// actionLimitsFromConfig lit les limites des actions dans la configuration
+func actionLimitsFromConfig(cfg *config.Config) ActionLimits {
+	return ActionLimits{
+		Timeout:    time.Duration(max(cfg.ActionTimeoutMs, 100)) * time.Millisecond,
+		MemoryMB:   max(cfg.ActionMemoryMB, 16),
+		CPUSeconds: max(cfg.ActionCPUSeconds, 1),
+	}
+}
+
+// nodeActionRuntime exécute chaque action dans un processus Node.js à part :
+// espaces de noms utilisateur et réseau (pas d'accès au réseau ni d'identité sur l'hôte),
+// modèle de permissions Node (pas de système de fichiers ni de processus enfants),
+// environnement vide, tas borné, et limites CPU/mémoire du noyau via prlimit lorsqu'il est disponible.
+// Sans espaces de noms (hors Linux, ou user namespaces désactivés), l'action n'est pas exécutée.
+type nodeActionRuntime struct{}
+
+func (nodeActionRuntime) Run(ctx context.Context, req ActionRunRequest, limits ActionLimits) (*ActionRunOutput, error) {
+	if !actionSandboxSupported {
+		return nil, ErrActionSandboxUnavailable
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+
+	name := config.LoadConfig().ActionNodePath
+	args := []string{
+		"--no-warnings",
+		"--experimental-permission",
+		fmt.Sprintf("--max-old-space-size=%d", limits.MemoryMB),
+		"-e", nodeActionBootstrap,
+		strconv.FormatInt(limits.Timeout.Milliseconds(), 10),
+	}
+	if prlimit, err := exec.LookPath("prlimit"); err == nil {
+		// Le segment de données couvre le tas V8 plus une marge pour le runtime lui-même
+		args = append([]string{
+			fmt.Sprintf("--cpu=%d", limits.CPUSeconds),
+			fmt.Sprintf("--data=%d", (limits.MemoryMB+128)<<20),
+			"--", name,
+		}, args...)
+		name = prlimit
+	}
+
+	stdout := &limitedBuffer{limit: actionMaxOutput}
+	stderr := &limitedBuffer{limit: 4096}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = []string{} // Aucune variable du serveur n'est visible de l'action
+	cmd.Dir = os.TempDir()
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	sandboxActionCommand(cmd)
+
+	runErr := cmd.Run()
+	switch {
+	case cmd.Process == nil && actionSandboxError(runErr):
+		// Le noyau refuse les espaces de noms : on n'exécute pas l'action sans isolation
+		return nil, fmt.Errorf("%w: %v", ErrActionSandboxUnavailable, runErr)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return nil, ErrActionTimeout
+	case stdout.overflow:
+		return nil, ErrActionOutputTooLarge
+	case runErr != nil:
+		return nil, fmt.Errorf("action runtime failed: %v%s", runErr, nodeFailureReason(stderr.String()))
+	}
+
+	var output ActionRunOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return nil, fmt.Errorf("invalid action runtime output: %w", err)
+	}
+	return &output, nil
+}
+
+// nodeFailureReason résume la sortie d'erreur d'un processus Node arrêté
+func nodeFailureReason(stderr string) string {
+	switch {
+	case strings.Contains(stderr, "heap out of memory"):
+		return " (memory limit exceeded)"
+	case strings.TrimSpace(stderr) == "":
+		return " (CPU limit exceeded or process killed)"
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(stderr), "\n")
+	return ": " + line
+}
+
+// limitedBuffer retient au plus limit octets et signale le dépassement
+type limitedBuffer struct {
+	bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); len(p) > room {
+		b.overflow = true
+		b.Buffer.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// nodeActionBootstrap lit {code, handler, event} sur stdin et exécute l'action dans un contexte vm
+// vide. Aucun objet du processus Node n'y est exposé : le code du contexte est recompilé depuis sa
+// source, l'entrée y entre comme une chaîne JSON et le résultat {commands, logs, result, error} en
+// sort de même, lu après l'évaluation et ses microtâches. Le contexte n'est pas une frontière de
+// sécurité à lui seul : l'isolation repose sur le modèle de permissions Node et les espaces de noms.
+const nodeActionBootstrap = `"use strict";
+const vm = require("node:vm");
+
+function actionMain() {
+  "use strict";
+  const { parse, stringify } = JSON;
+  const input = parse(globalThis.__actionInput);
+  delete globalThis.__actionInput;
+  const commands = [];
+  const logs = [];
+  const format = (v) => typeof v === "string" ? v : stringify(v);
+  const log = (level) => (...args) => {
+    if (logs.length < 100) logs.push({ level, message: args.map(format).join(" ").slice(0, 2000) });
+  };
+  const claims = (target) => ({
+    setCustomClaim: (name, value) => commands.push({ type: "set_claim", target, name: String(name), value }),
+  });
+  const api = {
+    accessToken: claims("access_token"),
+    idToken: claims("id_token"),
+    access: { deny: (reason) => commands.push({ type: "deny", reason: String(reason ?? "") }) },
+    redirect: { sendUserTo: (url) => commands.push({ type: "redirect", url: String(url) }) },
+  };
+  const result = { commands, logs };
+  const finish = (err) => {
+    if (err !== undefined) result.error = String((err && err.message) || err);
+    globalThis.__actionOutput = stringify(result);
+  };
+  const module = { exports: {} };
+  globalThis.module = module;
+  globalThis.exports = module.exports;
+  globalThis.console = { log: log("info"), info: log("info"), debug: log("debug"), warn: log("warn"), error: log("error") };
+  try {
+    (0, eval)(input.code);
+    const fn = module.exports[input.handler] ?? module.exports.onExecute;
+    if (typeof fn !== "function") throw new Error("action does not export " + input.handler + " or onExecute");
+    Promise.resolve(fn(input.event, api)).then((returned) => {
+      if (returned !== undefined) result.result = returned;
+      finish();
+    }, (err) => finish(err ?? "action rejected"));
+  } catch (err) {
+    finish(err ?? "action threw");
+  }
+}
+
+let input = "";
+process.stdin.setEncoding("utf8");
+process.stdin.on("data", (chunk) => { input += chunk; });
+process.stdin.on("end", () => {
+  const sandbox = Object.create(null);
+  sandbox.__actionInput = input;
+  const context = vm.createContext(sandbox, {
+    codeGeneration: { strings: true, wasm: false },
+    microtaskMode: "afterEvaluate",
+  });
+  let output;
+  try {
+    vm.runInContext("(" + String(actionMain) + ")()", context, { filename: "action.js", timeout: Number(process.argv[1]) });
+    output = vm.runInContext("globalThis.__actionOutput", context);
+  } catch (err) {
+    output = JSON.stringify({ commands: [], error: String(err && err.message) });
+  }
+  if (typeof output !== "string") {
+    output = JSON.stringify({ commands: [], error: "action did not complete: its promise never settled" });
+  }
+  process.stdout.write(output);
+});
+`
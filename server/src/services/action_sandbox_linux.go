@@ -0,0 +1,38 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// actionSandboxSupported indique que les actions peuvent être isolées par des espaces de noms
+const actionSandboxSupported = true
+
+// actionSandboxUID est l'utilisateur du processus d'action, et son utilisateur hôte quand le serveur tourne en root
+const actionSandboxUID = 65534
+
+// sandboxActionCommand place le processus d'action dans ses propres espaces de noms :
+// utilisateur (aucune capacité sur l'hôte), réseau (aucune interface hormis lo), PID et IPC.
+func sandboxActionCommand(cmd *exec.Cmd) {
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid == 0 {
+		// Un serveur root ne transmet pas son identité : l'action tourne sous nobody
+		uid, gid = actionSandboxUID, actionSandboxUID
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET | syscall.CLONE_NEWPID | syscall.CLONE_NEWIPC,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: actionSandboxUID, HostID: uid, Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: actionSandboxUID, HostID: gid, Size: 1}},
+		Credential:  &syscall.Credential{Uid: actionSandboxUID, Gid: actionSandboxUID, NoSetGroups: true},
+		Pdeathsig:   syscall.SIGKILL,
+	}
+}
+
+// actionSandboxError distingue un refus de création des espaces de noms (user namespaces
+// désactivés, seccomp du conteneur) d'un autre échec de lancement
+func actionSandboxError(err error) bool {
+	return errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EINVAL) ||
+		errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EUSERS)
+}
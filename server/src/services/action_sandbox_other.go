@@ -0,0 +1,14 @@
+//go:build !linux
+
+package services
+
+import "os/exec"
+
+// Hors Linux, aucun espace de noms n'isole le processus : Run refuse d'exécuter les actions
+const actionSandboxSupported = false
+
+func sandboxActionCommand(cmd *exec.Cmd) {}
+
+func actionSandboxError(err error) bool {
+	return false
+}
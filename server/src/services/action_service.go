@@ -1,6 +1,10 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/skygenesisenterprise/aether-identity/server/src/models"
 	"gorm.io/gorm"
 )
@@ -13,8 +17,18 @@ func NewActionService(db *gorm.DB) *ActionService {
 	return &ActionService{DB: db}
 }
 
+// CreateAction crée l'action et enregistre sa version 1
 func (s *ActionService) CreateAction(action *models.Action) error {
-	return s.DB.Create(action).Error
+	action.Version = 1
+	action.DeployedVersion = nil
+	action.DeployedAt = nil
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(action).Error; err != nil {
+			return err
+		}
+		_, err := snapshotActionVersion(tx, action)
+		return err
+	})
 }
 
 func (s *ActionService) GetAction(id string) (*models.Action, error) {
@@ -41,8 +55,44 @@ func (s *ActionService) ListActions() ([]models.Action, error) {
 	return actions, nil
 }
 
+// UpdateAction met à jour l'action ; une modification du code ou du runtime crée une nouvelle
+// version sans toucher à la version déployée
 func (s *ActionService) UpdateAction(action *models.Action) error {
-	return s.DB.Save(action).Error
+	existing, err := s.GetAction(action.ID)
+	if err != nil {
+		return err
+	}
+	if action.Runtime == "" {
+		action.Runtime = existing.Runtime
+	}
+	// Le statut et la version déployée ne changent que par /deploy, /rollout et /rollback :
+	// une mise à jour du code ne dépublie pas l'action
+	action.Version = existing.Version
+	action.Status = existing.Status
+	action.DeployedVersion = existing.DeployedVersion
+	action.DeployedAt = existing.DeployedAt
+	action.CreatedAt = existing.CreatedAt
+
+	changed := action.Runtime != existing.Runtime ||
+		(action.Code != nil) != (existing.Code != nil) ||
+		(action.Code != nil && *action.Code != *existing.Code)
+
+	if changed {
+		// Les actions antérieures au versionnage n'ont pas d'instantané de leur version courante
+		if _, err := s.ensureActionVersion(existing); err != nil && !errors.Is(err, ErrActionNoCode) {
+			return err
+		}
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if changed {
+			action.Version = existing.Version + 1
+			if _, err := snapshotActionVersion(tx, action); err != nil {
+				return err
+			}
+		}
+		return tx.Save(action).Error
+	})
 }
 
 func (s *ActionService) DeleteAction(id string) error {
@@ -109,35 +159,112 @@ func (s *ActionService) UpdateActionLog(log *models.ActionLog) error {
 	return s.DB.Save(log).Error
 }
 
-func (s *ActionService) DeployAction(id string) error {
-	var action models.Action
-	if err := s.DB.First(&action, "id = ?", id).Error; err != nil {
-		return err
+// TestAction exécute une version d'une action (la dernière par défaut) sur un événement d'essai,
+// quel que soit son statut
+func (s *ActionService) TestAction(ctx context.Context, id string, number *int, event *ActionEvent) (map[string]interface{}, error) {
+	action, err := s.GetAction(id)
+	if err != nil {
+		return nil, err
 	}
-	action.Status = "deployed"
-	return s.DB.Save(&action).Error
-}
 
-func (s *ActionService) TestAction(id string) (map[string]interface{}, error) {
-	var action models.Action
-	if err := s.DB.First(&action, "id = ?", id).Error; err != nil {
+	var version *models.ActionVersion
+	if number == nil {
+		version, err = s.ensureActionVersion(action)
+	} else {
+		version, err = s.GetActionVersion(id, *number)
+	}
+	if err != nil {
 		return nil, err
 	}
-	return map[string]interface{}{
-		"tested": true,
-		"action": action.Name,
-		"result": "Test passed",
-	}, nil
+
+	var triggerID *string
+	if trigger, err := s.GetActionTriggerByName(event.Trigger); err == nil {
+		triggerID = &trigger.ID
+	}
+
+	start := time.Now()
+	output, err := s.runAction(ctx, action, version, triggerID, false, event)
+	response := map[string]interface{}{
+		"action":     action.Name,
+		"version":    version.Number,
+		"trigger":    event.Trigger,
+		"success":    err == nil,
+		"durationMs": time.Since(start).Milliseconds(),
+	}
+	if output != nil {
+		result := &ActionResult{}
+		applyActionCommands(result, output.Commands)
+		response["result"] = result
+		response["logs"] = output.Logs
+	}
+	if err != nil {
+		response["error"] = err.Error()
+	}
+	return response, nil
 }
 
+// ListActionsForTrigger liste les actions liées à un déclencheur, dans l'ordre des bindings actifs
 func (s *ActionService) ListActionsForTrigger(triggerID string) ([]models.Action, error) {
 	var actions []models.Action
-	if err := s.DB.Where("trigger_id = ?", triggerID).Find(&actions).Error; err != nil {
+	if err := s.DB.Joins("JOIN action_trigger_bindings ON action_trigger_bindings.action_id = actions.id").
+		Where("action_trigger_bindings.trigger_id = ? AND action_trigger_bindings.is_enabled = ?", triggerID, true).
+		Order("action_trigger_bindings.\"order\" ASC, action_trigger_bindings.created_at ASC").
+		Find(&actions).Error; err != nil {
 		return nil, err
 	}
 	return actions, nil
 }
 
+// ListTriggerBindings liste les bindings d'un déclencheur dans leur ordre d'exécution
+func (s *ActionService) ListTriggerBindings(triggerID string) ([]models.ActionTriggerBinding, error) {
+	var bindings []models.ActionTriggerBinding
+	if err := s.DB.Where("trigger_id = ?", triggerID).Order("\"order\" ASC, created_at ASC").Find(&bindings).Error; err != nil {
+		return nil, err
+	}
+	return bindings, nil
+}
+
+// BindAction lie une action à un déclencheur, ou met à jour l'ordre et l'état du binding existant
+func (s *ActionService) BindAction(binding *models.ActionTriggerBinding) error {
+	action, err := s.GetAction(binding.ActionID)
+	if err != nil {
+		return err
+	}
+	// Un nouveau binding exécute la version déployée de l'action
+	if binding.Version == nil {
+		binding.Version = action.DeployedVersion
+	}
+	if _, err := s.GetActionTrigger(binding.TriggerID); err != nil {
+		return err
+	}
+
+	var existing models.ActionTriggerBinding
+	if err := s.DB.Where("action_id = ? AND trigger_id = ?", binding.ActionID, binding.TriggerID).First(&existing).Error; err == nil {
+		binding.ID = existing.ID
+		binding.CreatedAt = existing.CreatedAt
+		binding.Version = existing.Version
+		binding.CanaryVersion = existing.CanaryVersion
+		binding.CanaryPercent = existing.CanaryPercent
+		binding.CanaryErrorThreshold = existing.CanaryErrorThreshold
+		binding.CanaryMinExecutions = existing.CanaryMinExecutions
+		binding.CanaryStartedAt = existing.CanaryStartedAt
+		return s.DB.Save(binding).Error
+	}
+	return s.DB.Create(binding).Error
+}
+
+// UnbindAction supprime un binding d'un déclencheur
+func (s *ActionService) UnbindAction(triggerID, bindingID string) error {
+	result := s.DB.Where("id = ? AND trigger_id = ?", bindingID, triggerID).Delete(&models.ActionTriggerBinding{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 func (s *ActionService) ListActionLibrary() ([]models.Action, error) {
 	var actions []models.Action
 	if err := s.DB.Where("is_library = ?", true).Find(&actions).Error; err != nil {
@@ -0,0 +1,438 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrActionNotDeployed    = errors.New("action is not deployed")
+	ErrActionInvalidRollout = errors.New("invalid rollout")
+	ErrActionNoRollback     = errors.New("no version to roll back to")
+)
+
+const (
+	defaultCanaryErrorThreshold = 0.1
+	defaultCanaryMinExecutions  = 20
+	maxActionDiffLines          = 2000
+)
+
+// ActionRolloutRequest démarre un déploiement progressif d'une version
+type ActionRolloutRequest struct {
+	Version        int     `json:"version" binding:"required"`
+	Percent        int     `json:"percent" binding:"required"`
+	ErrorThreshold float64 `json:"errorThreshold"` // Taux d'erreur (0-1) déclenchant l'annulation, 0.1 par défaut
+	MinExecutions  int     `json:"minExecutions"`  // Exécutions canary avant évaluation, 20 par défaut
+}
+
+// ActionDiff est la différence ligne à ligne entre deux versions d'une action
+type ActionDiff struct {
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Diff      string `json:"diff"` // Format unifié, sans en-têtes de blocs
+}
+
+// ListActionVersions liste les versions d'une action, la plus récente en premier
+func (s *ActionService) ListActionVersions(actionID string) ([]models.ActionVersion, error) {
+	var versions []models.ActionVersion
+	if err := s.DB.Where("action_id = ?", actionID).Order("number DESC").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetActionVersion retourne une version d'une action
+func (s *ActionService) GetActionVersion(actionID string, number int) (*models.ActionVersion, error) {
+	var version models.ActionVersion
+	if err := s.DB.Where("action_id = ? AND number = ?", actionID, number).First(&version).Error; err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// ensureActionVersion retourne la dernière version de l'action, en l'enregistrant si elle
+// n'existe pas encore (actions créées avant le versionnage)
+func (s *ActionService) ensureActionVersion(action *models.Action) (*models.ActionVersion, error) {
+	version, err := s.GetActionVersion(action.ID, action.Version)
+	if err == nil {
+		return version, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if action.Code == nil || strings.TrimSpace(*action.Code) == "" {
+		return nil, ErrActionNoCode
+	}
+	return snapshotActionVersion(s.DB, action)
+}
+
+// snapshotActionVersion enregistre le code courant de l'action sous le numéro action.Version
+func snapshotActionVersion(db *gorm.DB, action *models.Action) (*models.ActionVersion, error) {
+	code := ""
+	if action.Code != nil {
+		code = *action.Code
+	}
+	sum := sha256.Sum256([]byte(code))
+	version := &models.ActionVersion{
+		ActionID: action.ID,
+		Number:   action.Version,
+		Code:     code,
+		Runtime:  action.Runtime,
+		Checksum: hex.EncodeToString(sum[:]),
+	}
+	if err := db.Create(version).Error; err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// DeployAction déploie une version (la dernière par défaut) et l'épingle sur chaque binding
+// de l'action ; un déploiement progressif en cours est abandonné
+func (s *ActionService) DeployAction(id string, number *int) (*models.Action, error) {
+	action, err := s.GetAction(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var version *models.ActionVersion
+	if number == nil {
+		version, err = s.ensureActionVersion(action)
+	} else {
+		version, err = s.GetActionVersion(id, *number)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(version.Code) == "" {
+		return nil, ErrActionNoCode
+	}
+	if _, err := GetActionRuntime(version.Runtime); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(action).Updates(map[string]interface{}{
+			"status":           "deployed",
+			"deployed_version": version.Number,
+			"deployed_at":      now,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ActionTriggerBinding{}).Where("action_id = ?", id).
+			Updates(pinnedBinding(version.Number)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	action.Status = "deployed"
+	action.DeployedVersion = &version.Number
+	action.DeployedAt = &now
+
+	s.logActionEvent(action, "action.deployed", map[string]interface{}{"version": version.Number})
+	return action, nil
+}
+
+// StartRollout envoie un pourcentage des exécutions de chaque binding vers une nouvelle version
+func (s *ActionService) StartRollout(id string, req ActionRolloutRequest) (*models.Action, error) {
+	action, err := s.GetAction(id)
+	if err != nil {
+		return nil, err
+	}
+	if action.Status != "deployed" || action.DeployedVersion == nil {
+		return nil, ErrActionNotDeployed
+	}
+	if req.Percent < 1 || req.Percent > 99 {
+		return nil, fmt.Errorf("%w: percent must be between 1 and 99", ErrActionInvalidRollout)
+	}
+	if req.Version == *action.DeployedVersion {
+		return nil, fmt.Errorf("%w: version %d is already deployed", ErrActionInvalidRollout, req.Version)
+	}
+	if req.ErrorThreshold < 0 || req.ErrorThreshold >= 1 {
+		return nil, fmt.Errorf("%w: errorThreshold must be between 0 and 1", ErrActionInvalidRollout)
+	}
+	if req.ErrorThreshold == 0 {
+		req.ErrorThreshold = defaultCanaryErrorThreshold
+	}
+	if req.MinExecutions <= 0 {
+		req.MinExecutions = defaultCanaryMinExecutions
+	}
+
+	version, err := s.GetActionVersion(id, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := GetActionRuntime(version.Runtime); err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.Model(&models.ActionTriggerBinding{}).Where("action_id = ?", id).Updates(map[string]interface{}{
+		"canary_version":         version.Number,
+		"canary_percent":         req.Percent,
+		"canary_error_threshold": req.ErrorThreshold,
+		"canary_min_executions":  req.MinExecutions,
+		"canary_started_at":      time.Now(),
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	s.logActionEvent(action, "action.rollout.started", map[string]interface{}{
+		"version":        version.Number,
+		"from":           *action.DeployedVersion,
+		"percent":        req.Percent,
+		"errorThreshold": req.ErrorThreshold,
+		"minExecutions":  req.MinExecutions,
+	})
+	return action, nil
+}
+
+// PromoteRollout déploie la version canary sur l'ensemble des exécutions
+func (s *ActionService) PromoteRollout(id string) (*models.Action, error) {
+	var binding models.ActionTriggerBinding
+	if err := s.DB.Where("action_id = ? AND canary_version IS NOT NULL", id).First(&binding).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: no rollout in progress", ErrActionInvalidRollout)
+		}
+		return nil, err
+	}
+	return s.DeployAction(id, binding.CanaryVersion)
+}
+
+// RollbackAction annule le déploiement progressif en cours ; sans déploiement progressif, redéploie
+// la version demandée ou, à défaut, la version précédant la version déployée
+func (s *ActionService) RollbackAction(id string, number *int) (*models.Action, error) {
+	action, err := s.GetAction(id)
+	if err != nil {
+		return nil, err
+	}
+	if action.DeployedVersion == nil {
+		return nil, ErrActionNotDeployed
+	}
+
+	if number == nil {
+		var canaries int64
+		if err := s.DB.Model(&models.ActionTriggerBinding{}).Where("action_id = ? AND canary_version IS NOT NULL", id).
+			Count(&canaries).Error; err != nil {
+			return nil, err
+		}
+		if canaries > 0 {
+			if err := s.abortRollout(action, "manual rollback"); err != nil {
+				return nil, err
+			}
+			return action, nil
+		}
+
+		var previous models.ActionVersion
+		if err := s.DB.Where("action_id = ? AND number < ?", id, *action.DeployedVersion).
+			Order("number DESC").First(&previous).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrActionNoRollback
+			}
+			return nil, err
+		}
+		number = &previous.Number
+	}
+
+	from := *action.DeployedVersion
+	action, err = s.DeployAction(id, number)
+	if err != nil {
+		return nil, err
+	}
+	s.logActionEvent(action, "action.rolled_back", map[string]interface{}{"from": from, "to": *number})
+	return action, nil
+}
+
+// DiffActionVersions compare le code de deux versions d'une action
+func (s *ActionService) DiffActionVersions(actionID string, from, to int) (*ActionDiff, error) {
+	fromVersion, err := s.GetActionVersion(actionID, from)
+	if err != nil {
+		return nil, err
+	}
+	toVersion, err := s.GetActionVersion(actionID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	a := strings.Split(fromVersion.Code, "\n")
+	b := strings.Split(toVersion.Code, "\n")
+	if len(a) > maxActionDiffLines || len(b) > maxActionDiffLines {
+		return nil, fmt.Errorf("versions exceed %d lines", maxActionDiffLines)
+	}
+
+	diff := &ActionDiff{From: from, To: to}
+	var out strings.Builder
+	if fromVersion.Runtime != toVersion.Runtime {
+		fmt.Fprintf(&out, "# runtime: %s -> %s\n", fromVersion.Runtime, toVersion.Runtime)
+	}
+	for _, line := range diffLines(a, b) {
+		switch line[0] {
+		case '+':
+			diff.Additions++
+		case '-':
+			diff.Deletions++
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	diff.Diff = out.String()
+	return diff, nil
+}
+
+// selectActionVersion choisit la version exécutée pour un binding : la version canary pour
+// CanaryPercent % des exécutions, sinon la version épinglée (ou déployée)
+func (s *ActionService) selectActionVersion(action *models.Action, binding *models.ActionTriggerBinding) (*models.ActionVersion, bool, error) {
+	if binding.CanaryVersion != nil && rand.IntN(100) < binding.CanaryPercent {
+		version, err := s.GetActionVersion(action.ID, *binding.CanaryVersion)
+		return version, true, err
+	}
+
+	number := binding.Version
+	if number == nil {
+		number = action.DeployedVersion
+	}
+	if number == nil {
+		version, err := s.ensureActionVersion(action)
+		return version, false, err
+	}
+	version, err := s.GetActionVersion(action.ID, *number)
+	return version, false, err
+}
+
+// checkCanary annule le déploiement progressif d'un binding si le taux d'erreur de la
+// version canary, mesuré dans ActionLog, dépasse son seuil
+func (s *ActionService) checkCanary(action *models.Action, binding *models.ActionTriggerBinding) {
+	if binding.CanaryVersion == nil || binding.CanaryStartedAt == nil {
+		return
+	}
+
+	var stats struct {
+		Total  int64
+		Failed int64
+	}
+	err := s.DB.Model(&models.ActionLog{}).
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE status = ?) AS failed", models.ActionLogStatusFailed).
+		Where("action_id = ? AND trigger_id = ? AND version = ? AND canary = ? AND created_at >= ?",
+			action.ID, binding.TriggerID, *binding.CanaryVersion, true, *binding.CanaryStartedAt).
+		Scan(&stats).Error
+	if err != nil {
+		s.logActionError(action, "actions.canary_evaluation_failed", "failed to evaluate canary", err)
+		return
+	}
+	if stats.Total < int64(binding.CanaryMinExecutions) {
+		return
+	}
+
+	rate := float64(stats.Failed) / float64(stats.Total)
+	if rate > binding.CanaryErrorThreshold {
+		reason := fmt.Sprintf("error rate %.2f above threshold %.2f over %d executions", rate, binding.CanaryErrorThreshold, stats.Total)
+		if err := s.abortRollout(action, reason); err != nil {
+			s.logActionError(action, "actions.canary_rollback_failed", "failed to roll back canary", err)
+		}
+	}
+}
+
+// abortRollout retire la version canary de tous les bindings de l'action
+func (s *ActionService) abortRollout(action *models.Action, reason string) error {
+	var binding models.ActionTriggerBinding
+	if err := s.DB.Where("action_id = ? AND canary_version IS NOT NULL", action.ID).First(&binding).Error; err != nil {
+		return err
+	}
+
+	result := s.DB.Model(&models.ActionTriggerBinding{}).
+		Where("action_id = ? AND canary_version IS NOT NULL", action.ID).
+		Updates(map[string]interface{}{
+			"canary_version":    nil,
+			"canary_percent":    0,
+			"canary_started_at": nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	// Une autre exécution a déjà annulé le déploiement progressif
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	s.logActionEvent(action, "action.rollout.rolled_back", map[string]interface{}{
+		"version": *binding.CanaryVersion,
+		"reason":  reason,
+	})
+	return nil
+}
+
+// logActionEvent journalise un événement du cycle de vie d'une action
+func (s *ActionService) logActionEvent(action *models.Action, eventType string, data map[string]interface{}) {
+	source := "actions"
+	event := &models.Event{
+		Type:    eventType,
+		Source:  &source,
+		Subject: &action.ID,
+		Data:    data,
+	}
+	if err := NewEventService(s.DB).CreateEvent(event); err != nil {
+		s.logActionError(action, "actions.event_not_recorded", "failed to record "+eventType+" event", err)
+	}
+}
+
+// pinnedBinding épingle une version sur un binding et termine tout déploiement progressif
+func pinnedBinding(number int) map[string]interface{} {
+	return map[string]interface{}{
+		"version":           number,
+		"canary_version":    nil,
+		"canary_percent":    0,
+		"canary_started_at": nil,
+	}
+}
+
+// diffLines calcule une différence ligne à ligne (plus longue sous-séquence commune) au format
+// unifié : " " ligne commune, "-" ligne supprimée, "+" ligne ajoutée
+func diffLines(a, b []string) []string {
+	// lcs[i][j] est la longueur de la plus longue sous-séquence commune de a[i:] et b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]string, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "-"+a[i])
+			i++
+		default:
+			lines = append(lines, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, "+"+b[j])
+	}
+	return lines
+}
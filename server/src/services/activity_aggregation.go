@@ -0,0 +1,367 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"gorm.io/gorm"
+)
+
+const (
+	activityRefreshDays     = 2   // Jours recalculés à chaque passage : aujourd'hui et hier, pour les événements tardifs
+	activityRetentionMonths = 12  // Cohortes mensuelles recalculées à chaque passage
+	ActivityMaxRangeDays    = 366 // Étendue maximale d'une lecture ou d'un recalcul
+)
+
+var ErrActivityRange = errors.New("invalid date range: from must precede to and span at most 366 days")
+
+// ActivityQuery borne une lecture des statistiques matérialisées, dates incluses.
+// Un TenantID vide désigne l'ensemble de l'instance.
+type ActivityQuery struct {
+	From     time.Time
+	To       time.Time
+	TenantID string
+}
+
+// Validate vérifie l'intervalle et ramène les bornes au jour (UTC)
+func (q *ActivityQuery) Validate() error {
+	q.From, q.To = activityDay(q.From), activityDay(q.To)
+	if q.To.Before(q.From) || q.To.Sub(q.From) >= ActivityMaxRangeDays*24*time.Hour {
+		return ErrActivityRange
+	}
+	return nil
+}
+
+// Start recalcule les statistiques d'activité immédiatement puis à intervalle régulier
+func (s *ActivityService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := s.Refresh(time.Now()); err != nil {
+				fmt.Printf("Activity: aggregation failed: %v\n", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Refresh recalcule les derniers jours et les cohortes des douze derniers mois
+func (s *ActivityService) Refresh(now time.Time) error {
+	today := activityDay(now)
+	if err := s.Materialize(today.AddDate(0, 0, 1-activityRefreshDays), today); err != nil {
+		return err
+	}
+	return s.MaterializeRetention(activityMonth(today).AddDate(0, 1-activityRetentionMonths, 0), today)
+}
+
+// Materialize recalcule, pour chaque jour de [from, to], l'aperçu, les utilisateurs actifs, les inscriptions
+// et les échecs de connexion, pour l'instance et pour chaque tenant ayant des événements sur la période
+func (s *ActivityService) Materialize(from, to time.Time) error {
+	q := ActivityQuery{From: from, To: to}
+	if err := q.Validate(); err != nil {
+		return err
+	}
+	// La fenêtre de 30 jours du MAU remonte avant from
+	scopes, err := s.activityScopes(q.From.AddDate(0, 0, -29), q.To.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	for _, tenantID := range scopes {
+		for day := q.From; !day.After(q.To); day = day.AddDate(0, 0, 1) {
+			if err := s.materializeDay(day, tenantID); err != nil {
+				return fmt.Errorf("%s: %w", day.Format(time.DateOnly), err)
+			}
+		}
+	}
+	return nil
+}
+
+// MaterializeRetention recalcule les cohortes mensuelles d'inscrits de [from, to] : pour chaque mois
+// écoulé depuis l'inscription, la part des inscrits qui se sont connectés dans le mois
+func (s *ActivityService) MaterializeRetention(from, to time.Time) error {
+	q := ActivityQuery{From: activityMonth(from), To: activityDay(to)}
+	if q.To.Before(q.From) {
+		return ErrActivityRange
+	}
+	scopes, err := s.activityScopes(q.From, time.Now())
+	if err != nil {
+		return err
+	}
+	current := activityMonth(time.Now())
+	for _, tenantID := range scopes {
+		var rows []models.UserRetention
+		for cohort := q.From; !cohort.After(q.To) && !cohort.After(current); cohort = cohort.AddDate(0, 1, 0) {
+			cohortRows, err := s.computeCohort(cohort, current, tenantID)
+			if err != nil {
+				return fmt.Errorf("cohort %s: %w", cohort.Format("2006-01"), err)
+			}
+			rows = append(rows, cohortRows...)
+		}
+		err := s.DB.Transaction(func(tx *gorm.DB) error {
+			del := scopeMaterialized(tx, tenantID).Where("cohort_month >= ? AND cohort_month <= ?", q.From.Format("2006-01"), q.To.Format("2006-01"))
+			if err := del.Delete(&models.UserRetention{}).Error; err != nil {
+				return err
+			}
+			if len(rows) == 0 {
+				return nil
+			}
+			return tx.Create(&rows).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activityScopes retourne "" (toute l'instance) suivi des tenants ayant des événements d'activité dans [from, to[
+func (s *ActivityService) activityScopes(from, to time.Time) ([]string, error) {
+	var tenants []string
+	err := s.DB.Model(&models.Event{}).
+		Where("type IN ? AND created_at >= ? AND created_at < ? AND tenant_id IS NOT NULL", activityEventTypes(), from, to).
+		Distinct("tenant_id").Pluck("tenant_id", &tenants).Error
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{""}, tenants...), nil
+}
+
+func (s *ActivityService) materializeDay(day time.Time, tenantID string) error {
+	end := day.AddDate(0, 0, 1)
+	var tenant *string
+	if tenantID != "" {
+		tenant = &tenantID
+	}
+
+	dau, err := s.computeActiveUsers(day, end, tenantID)
+	if err != nil {
+		return err
+	}
+	dau.TenantID = tenant
+	signups, err := s.computeSignups(day, end, tenantID)
+	if err != nil {
+		return err
+	}
+	signups.TenantID = tenant
+	failed, err := s.computeFailedLogins(day, end, tenantID)
+	if err != nil {
+		return err
+	}
+	failed.TenantID = tenant
+
+	var logins, totalUsers int64
+	if err := s.activityEvents(tenantID, models.ActivityEventLoginSucceeded, day, end).Count(&logins).Error; err != nil {
+		return err
+	}
+	if tenantID == "" {
+		err = s.DB.Model(&models.User{}).Where("created_at < ?", end).Count(&totalUsers).Error
+	} else {
+		// Les utilisateurs n'appartiennent pas à un tenant : on compte ceux qui s'y sont inscrits ou connectés
+		err = s.DB.Model(&models.Event{}).
+			Where("tenant_id = ? AND type IN ? AND created_at < ? AND user_id IS NOT NULL", tenantID,
+				[]string{models.ActivityEventSignup, models.ActivityEventLoginSucceeded}, end).
+			Distinct("user_id").Count(&totalUsers).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	overview := models.ActivityOverview{
+		TenantID:     tenant,
+		Date:         day,
+		TotalUsers:   int(totalUsers),
+		ActiveUsers:  dau.Count,
+		Logins:       int(logins),
+		Signups:      signups.Count,
+		FailedLogins: failed.Count,
+		ComputedAt:   &now,
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.ActivityOverview{}, &models.DailyActiveUsers{}, &models.SignupData{}, &models.FailedLoginData{}} {
+			if err := scopeMaterialized(tx, tenantID).Where("date = ?", day.Format(time.DateOnly)).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		for _, row := range []interface{}{&overview, dau, signups, failed} {
+			if err := tx.Create(row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *ActivityService) computeActiveUsers(day, end time.Time, tenantID string) (*models.DailyActiveUsers, error) {
+	activeSince := func(start time.Time) (int, error) {
+		var count int64
+		err := s.activityEvents(tenantID, models.ActivityEventLoginSucceeded, start, end).
+			Where("user_id IS NOT NULL").Distinct("user_id").Count(&count).Error
+		return int(count), err
+	}
+
+	dau := &models.DailyActiveUsers{Date: day}
+	var err error
+	if dau.Count, err = activeSince(day); err != nil {
+		return nil, err
+	}
+	if dau.WeeklyActive, err = activeSince(day.AddDate(0, 0, -6)); err != nil {
+		return nil, err
+	}
+	if dau.MonthlyActive, err = activeSince(day.AddDate(0, 0, -29)); err != nil {
+		return nil, err
+	}
+	if dau.MonthlyActive > 0 {
+		dau.Stickiness = float64(dau.Count) / float64(dau.MonthlyActive)
+	}
+	byConnection, err := s.countBy(s.activityEvents(tenantID, models.ActivityEventLoginSucceeded, day, end), "data->>'connection'", "COUNT(DISTINCT user_id)")
+	if err != nil {
+		return nil, err
+	}
+	dau.Breakdown = models.ActivityBreakdown{"connection": byConnection}
+	return dau, nil
+}
+
+func (s *ActivityService) computeSignups(day, end time.Time, tenantID string) (*models.SignupData, error) {
+	// Les étapes de l'entonnoir sont évaluées à la date du calcul : un inscrit de la veille qui vérifie
+	// son e-mail aujourd'hui est compté au prochain recalcul de sa journée d'inscription
+	var funnel struct {
+		Count       int
+		Verified    int
+		LoggedIn    int
+		MfaEnrolled int
+	}
+	err := s.activityEvents(tenantID, models.ActivityEventSignup, day, end).
+		Select(`COUNT(DISTINCT events.user_id) AS count,
+			COUNT(DISTINCT events.user_id) FILTER (WHERE users.email_verified) AS verified,
+			COUNT(DISTINCT events.user_id) FILTER (WHERE EXISTS (
+				SELECT 1 FROM events logins WHERE logins.user_id = events.user_id AND logins.type = ? AND logins.created_at >= events.created_at)) AS logged_in,
+			COUNT(DISTINCT events.user_id) FILTER (WHERE users.totp_enabled OR EXISTS (
+				SELECT 1 FROM events mfa WHERE mfa.user_id = events.user_id AND mfa.type = ?)) AS mfa_enrolled`,
+			models.ActivityEventLoginSucceeded, models.WebhookEventMfaEnrolled).
+		Joins("LEFT JOIN users ON users.id = events.user_id").
+		Scan(&funnel).Error
+	if err != nil {
+		return nil, err
+	}
+	byConnection, err := s.countBy(s.activityEvents(tenantID, models.ActivityEventSignup, day, end), "data->>'connection'", "COUNT(*)")
+	if err != nil {
+		return nil, err
+	}
+	return &models.SignupData{
+		Date:        day,
+		Count:       funnel.Count,
+		Verified:    funnel.Verified,
+		LoggedIn:    funnel.LoggedIn,
+		MfaEnrolled: funnel.MfaEnrolled,
+		Breakdown:   models.ActivityBreakdown{"connection": byConnection},
+	}, nil
+}
+
+func (s *ActivityService) computeFailedLogins(day, end time.Time, tenantID string) (*models.FailedLoginData, error) {
+	failed := &models.FailedLoginData{Date: day, Breakdown: models.ActivityBreakdown{}}
+	dimensions := map[string]string{
+		"connection":  "data->>'connection'",
+		"application": "data->>'client_id'",
+		"reason":      "data->>'reason'",
+	}
+	for dimension, expr := range dimensions {
+		counts, err := s.countBy(s.activityEvents(tenantID, models.ActivityEventLoginFailed, day, end), expr, "COUNT(*)")
+		if err != nil {
+			return nil, err
+		}
+		failed.Breakdown[dimension] = counts
+	}
+	for _, count := range failed.Breakdown["reason"] {
+		failed.Count += count
+	}
+	failed.LockedOut = failed.Breakdown["reason"][LoginReasonAccountLocked]
+	return failed, nil
+}
+
+// computeCohort calcule la rétention d'une cohorte mensuelle, du mois d'inscription jusqu'au mois courant
+func (s *ActivityService) computeCohort(cohort, current time.Time, tenantID string) ([]models.UserRetention, error) {
+	signups := s.activityEvents(tenantID, models.ActivityEventSignup, cohort, cohort.AddDate(0, 1, 0)).
+		Where("user_id IS NOT NULL").Distinct("user_id")
+	var size int64
+	if err := s.DB.Table("(?) AS cohort", signups).Count(&size).Error; err != nil {
+		return nil, err
+	}
+
+	var tenant *string
+	if tenantID != "" {
+		tenant = &tenantID
+	}
+	var rows []models.UserRetention
+	for period, month := 0, cohort; !month.After(current); period, month = period+1, month.AddDate(0, 1, 0) {
+		row := models.UserRetention{TenantID: tenant, CohortMonth: cohort.Format("2006-01"), Period: period, CohortSize: int(size)}
+		if size > 0 {
+			var retained int64
+			err := s.activityEvents(tenantID, models.ActivityEventLoginSucceeded, month, month.AddDate(0, 1, 0)).
+				Where("user_id IN (?)", signups).Distinct("user_id").Count(&retained).Error
+			if err != nil {
+				return nil, err
+			}
+			row.Retained = int(retained)
+			row.Rate = float64(retained) / float64(size)
+		}
+		row.Churned = row.CohortSize - row.Retained
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// activityEvents sélectionne les événements d'un type dans [from, to[, restreints au tenant s'il est fourni
+func (s *ActivityService) activityEvents(tenantID, eventType string, from, to time.Time) *gorm.DB {
+	query := s.DB.Model(&models.Event{}).Where("events.type = ? AND events.created_at >= ? AND events.created_at < ?", eventType, from, to)
+	if tenantID != "" {
+		query = query.Where("events.tenant_id = ?", tenantID)
+	}
+	return query
+}
+
+// countBy ventile la requête selon l'expression ; une valeur absente est comptée sous "none"
+func (s *ActivityService) countBy(query *gorm.DB, expr, aggregate string) (map[string]int, error) {
+	var rows []struct {
+		Label string
+		Count int
+	}
+	err := query.Select("COALESCE(" + expr + ", 'none') AS label, " + aggregate + " AS count").Group("label").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Label] = row.Count
+	}
+	return counts, nil
+}
+
+// scopeMaterialized restreint une table matérialisée à un tenant, ou aux lignes de toute l'instance
+func scopeMaterialized(query *gorm.DB, tenantID string) *gorm.DB {
+	if tenantID == "" {
+		return query.Where("tenant_id IS NULL")
+	}
+	return query.Where("tenant_id = ?", tenantID)
+}
+
+func activityEventTypes() []string {
+	return []string{models.ActivityEventLoginSucceeded, models.ActivityEventLoginFailed, models.ActivityEventSignup}
+}
+
+func activityDay(t time.Time) time.Time {
+	return time.Date(t.UTC().Year(), t.UTC().Month(), t.UTC().Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func activityMonth(t time.Time) time.Time {
+	return time.Date(t.UTC().Year(), t.UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+}
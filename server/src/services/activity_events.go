@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"gorm.io/gorm"
+)
+
+// Source des événements d'authentification enregistrés pour l'agrégation d'activité
+const activityEventSource = "auth"
+
+// Causes d'échec d'une connexion, reprises dans la ventilation des échecs
+const (
+	LoginReasonInvalidCredentials = "invalid_credentials"
+	LoginReasonAccountLocked      = "account_locked"
+	LoginReasonAccountInactive    = "account_inactive"
+	LoginReasonProviderError      = "provider_error"
+)
+
+// LoginActivity décrit une tentative de connexion
+type LoginActivity struct {
+	UserID     string // Vide si l'utilisateur n'a pas été identifié
+	TenantID   string
+	Connection string // Vide : base locale
+	ClientID   string // Application à l'origine de la connexion
+	Result     string // LoginSuccess, LoginFailure ou LoginLocked
+	Reason     string // Cause d'un échec ; invalid_credentials par défaut
+}
+
+// SignupActivity décrit une inscription en libre-service
+type SignupActivity struct {
+	UserID     string
+	TenantID   string
+	Connection string // Vide : base locale
+	ClientID   string
+}
+
+// TrackLogin compte la tentative dans les métriques et l'enregistre en événement login.succeeded ou
+// login.failed, consommé par l'agrégation d'activité. Sans base, seule la métrique est mise à jour.
+func TrackLogin(db *gorm.DB, activity LoginActivity) {
+	RecordLogin(activity.Connection, activity.Result)
+	if db == nil {
+		return
+	}
+
+	eventType := models.ActivityEventLoginSucceeded
+	data := map[string]interface{}{"connection": activityConnection(activity.Connection), "result": activity.Result}
+	if activity.Result != LoginSuccess {
+		eventType = models.ActivityEventLoginFailed
+		reason := activity.Reason
+		if reason == "" && activity.Result == LoginLocked {
+			reason = LoginReasonAccountLocked
+		} else if reason == "" {
+			reason = LoginReasonInvalidCredentials
+		}
+		data["reason"] = reason
+	}
+	if activity.ClientID != "" {
+		data["client_id"] = activity.ClientID
+	}
+	recordActivityEvent(db, eventType, activity.UserID, activity.TenantID, data)
+}
+
+// TrackSignup enregistre une inscription en événement signup.completed
+func TrackSignup(db *gorm.DB, activity SignupActivity) {
+	if db == nil || activity.UserID == "" {
+		return
+	}
+	data := map[string]interface{}{"connection": activityConnection(activity.Connection)}
+	if activity.ClientID != "" {
+		data["client_id"] = activity.ClientID
+	}
+	recordActivityEvent(db, models.ActivityEventSignup, activity.UserID, activity.TenantID, data)
+}
+
+func activityConnection(connection string) string {
+	if connection == "" {
+		return LocalConnection
+	}
+	return connection
+}
+
+func recordActivityEvent(db *gorm.DB, eventType, userID, tenantID string, data map[string]interface{}) {
+	source := activityEventSource
+	event := &models.Event{Type: eventType, Source: &source, Data: data}
+	if userID != "" {
+		event.UserID = &userID
+		event.Subject = &userID
+	}
+	if tenantID != "" {
+		event.TenantID = &tenantID
+	}
+	if err := NewEventService(db).CreateEvent(event); err != nil {
+		fmt.Printf("Activity: failed to record %s: %v\n", eventType, err)
+	}
+}
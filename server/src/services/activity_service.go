@@ -1,6 +1,8 @@
 package services
 
 import (
+	"time"
+
 	"github.com/skygenesisenterprise/aether-identity/server/src/models"
 	"gorm.io/gorm"
 )
@@ -19,15 +21,22 @@ func (s *ActivityService) CreateActivity(activity *models.ActivityOverview) erro
 
 func (s *ActivityService) GetActivity(date string) (*models.ActivityOverview, error) {
 	var activity models.ActivityOverview
-	if err := s.DB.Where("date = ?", date).First(&activity).Error; err != nil {
+	if err := s.DB.Where("date = ? AND tenant_id IS NULL", date).First(&activity).Error; err != nil {
 		return nil, err
 	}
 	return &activity, nil
 }
 
-func (s *ActivityService) ListActivities(limit int) ([]models.ActivityOverview, error) {
+// materialized sélectionne les lignes matérialisées de l'intervalle et du tenant demandés, par date croissante
+func (s *ActivityService) materialized(q ActivityQuery) *gorm.DB {
+	return scopeMaterialized(s.DB, q.TenantID).
+		Where("date >= ? AND date <= ?", q.From.Format(time.DateOnly), q.To.Format(time.DateOnly)).
+		Order("date ASC")
+}
+
+func (s *ActivityService) ListActivities(q ActivityQuery) ([]models.ActivityOverview, error) {
 	var activities []models.ActivityOverview
-	if err := s.DB.Limit(limit).Order("date DESC").Find(&activities).Error; err != nil {
+	if err := s.materialized(q).Find(&activities).Error; err != nil {
 		return nil, err
 	}
 	return activities, nil
@@ -43,15 +52,15 @@ func (s *ActivityService) CreateDAU(dau *models.DailyActiveUsers) error {
 
 func (s *ActivityService) GetDAU(date string) (*models.DailyActiveUsers, error) {
 	var dau models.DailyActiveUsers
-	if err := s.DB.Where("date = ?", date).First(&dau).Error; err != nil {
+	if err := s.DB.Where("date = ? AND tenant_id IS NULL", date).First(&dau).Error; err != nil {
 		return nil, err
 	}
 	return &dau, nil
 }
 
-func (s *ActivityService) ListDAU(limit int) ([]models.DailyActiveUsers, error) {
+func (s *ActivityService) ListDAU(q ActivityQuery) ([]models.DailyActiveUsers, error) {
 	var dauList []models.DailyActiveUsers
-	if err := s.DB.Limit(limit).Order("date DESC").Find(&dauList).Error; err != nil {
+	if err := s.materialized(q).Find(&dauList).Error; err != nil {
 		return nil, err
 	}
 	return dauList, nil
@@ -63,15 +72,19 @@ func (s *ActivityService) CreateRetention(retention *models.UserRetention) error
 
 func (s *ActivityService) GetRetention(cohortMonth string, period int) (*models.UserRetention, error) {
 	var retention models.UserRetention
-	if err := s.DB.Where("cohort_month = ? AND period = ?", cohortMonth, period).First(&retention).Error; err != nil {
+	if err := s.DB.Where("cohort_month = ? AND period = ? AND tenant_id IS NULL", cohortMonth, period).First(&retention).Error; err != nil {
 		return nil, err
 	}
 	return &retention, nil
 }
 
-func (s *ActivityService) ListRetentions() ([]models.UserRetention, error) {
+// ListRetentions retourne les cohortes dont le mois d'inscription tombe dans l'intervalle
+func (s *ActivityService) ListRetentions(q ActivityQuery) ([]models.UserRetention, error) {
 	var retentions []models.UserRetention
-	if err := s.DB.Find(&retentions).Error; err != nil {
+	err := scopeMaterialized(s.DB, q.TenantID).
+		Where("cohort_month >= ? AND cohort_month <= ?", q.From.Format("2006-01"), q.To.Format("2006-01")).
+		Order("cohort_month ASC, period ASC").Find(&retentions).Error
+	if err != nil {
 		return nil, err
 	}
 	return retentions, nil
@@ -83,22 +96,26 @@ func (s *ActivityService) CreateSignup(signup *models.SignupData) error {
 
 func (s *ActivityService) GetSignup(date string) (*models.SignupData, error) {
 	var signup models.SignupData
-	if err := s.DB.Where("date = ?", date).First(&signup).Error; err != nil {
+	if err := s.DB.Where("date = ? AND tenant_id IS NULL", date).First(&signup).Error; err != nil {
 		return nil, err
 	}
 	return &signup, nil
 }
 
-func (s *ActivityService) ListSignups(limit int) ([]models.SignupData, error) {
+func (s *ActivityService) ListSignups(q ActivityQuery) ([]models.SignupData, error) {
 	var signups []models.SignupData
-	if err := s.DB.Limit(limit).Order("date DESC").Find(&signups).Error; err != nil {
+	if err := s.materialized(q).Find(&signups).Error; err != nil {
 		return nil, err
 	}
 	return signups, nil
 }
 
-func (s *ActivityService) GetFailedLogins() ([]map[string]interface{}, error) {
-	return []map[string]interface{}{}, nil
+func (s *ActivityService) ListFailedLogins(q ActivityQuery) ([]models.FailedLoginData, error) {
+	var failed []models.FailedLoginData
+	if err := s.materialized(q).Find(&failed).Error; err != nil {
+		return nil, err
+	}
+	return failed, nil
 }
 
 func (s *ActivityService) GetUserStats() (map[string]interface{}, error) {
@@ -1,10 +1,52 @@
 package services
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/skygenesisenterprise/aether-identity/server/src/models"
 	"gorm.io/gorm"
 )
 
+const (
+	// AgentHeartbeatInterval est la période de heartbeat annoncée aux agents
+	AgentHeartbeatInterval = 30 * time.Second
+
+	// AgentOfflineAfter est le délai sans heartbeat après lequel un agent passe hors ligne
+	AgentOfflineAfter = 3 * AgentHeartbeatInterval
+
+	// AgentMaxPollWait borne l'attente d'un long-poll de commandes
+	AgentMaxPollWait = 60 * time.Second
+
+	agentEnrollmentTokenTTL = 24 * time.Hour
+	agentCommandTTL         = 10 * time.Minute
+	agentPollInterval       = 2 * time.Second // Repli multi-instance quand la notification locale manque
+	agentCredentialPrefix   = "agt"
+)
+
+var (
+	ErrAgentInvalidEnrollmentToken = errors.New("invalid, expired or already used enrollment token")
+	ErrAgentInvalidCredential      = errors.New("invalid agent credential")
+	ErrAgentInvalidCommand         = errors.New("invalid agent command")
+	ErrAgentCommandNotDelivered    = errors.New("command is not awaiting acknowledgement")
+)
+
+// agentCommandWaiters réveille les long-polls en attente sur cette instance
+var agentCommandWaiters = struct {
+	sync.Mutex
+	waiters map[string][]chan struct{}
+}{waiters: make(map[string][]chan struct{})}
+
 type AgentService struct {
 	DB *gorm.DB
 }
@@ -57,18 +99,484 @@ func (s *AgentService) DeleteAgent(id string) error {
 	return s.DB.Delete(&models.Agent{}, "id = ?", id).Error
 }
 
+// AgentEnrollmentRequest est envoyé par un agent lors de son enrôlement
+type AgentEnrollmentRequest struct {
+	Token         string  `json:"token" binding:"required"`
+	Hostname      *string `json:"hostname"`
+	Version       *string `json:"version"`
+	ConfigVersion *string `json:"configVersion"`
+	Endpoint      *string `json:"endpoint"`
+}
+
+// AgentHeartbeatRequest rapporte l'état et les versions d'un agent
+type AgentHeartbeatRequest struct {
+	Status        string  `json:"status"` // online (défaut) ou error
+	Message       *string `json:"message"`
+	Version       *string `json:"version"`
+	ConfigVersion *string `json:"configVersion"`
+	Endpoint      *string `json:"endpoint"`
+}
+
+// CreateEnrollmentToken crée un jeton d'enrôlement à usage unique et retourne sa valeur en clair
+func (s *AgentService) CreateEnrollmentToken(name, agentType string, tenantID *string, ttl time.Duration, createdBy *string) (string, *models.AgentEnrollmentToken, error) {
+	if ttl <= 0 {
+		ttl = agentEnrollmentTokenTTL
+	}
+	secret, err := randomAgentSecret()
+	if err != nil {
+		return "", nil, err
+	}
+	token := "aet_" + secret
+
+	enrollment := &models.AgentEnrollmentToken{
+		TokenHash: hashAgentSecret(token),
+		Name:      name,
+		Type:      agentType,
+		TenantID:  tenantID,
+		ExpiresAt: time.Now().Add(ttl),
+		CreatedBy: createdBy,
+	}
+	if err := s.DB.Create(enrollment).Error; err != nil {
+		return "", nil, err
+	}
+	return token, enrollment, nil
+}
+
+// Enroll consomme un jeton d'enrôlement, crée l'agent et retourne son credential en clair
+func (s *AgentService) Enroll(req AgentEnrollmentRequest) (*models.Agent, string, error) {
+	var agent models.Agent
+	var credential string
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var enrollment models.AgentEnrollmentToken
+		if err := tx.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hashAgentSecret(req.Token), time.Now()).
+			First(&enrollment).Error; err != nil {
+			return ErrAgentInvalidEnrollmentToken
+		}
+
+		now := time.Now()
+		agent = models.Agent{
+			Name:          enrollment.Name,
+			Type:          enrollment.Type,
+			Status:        models.AgentStatusOnline,
+			Version:       req.Version,
+			Endpoint:      req.Endpoint,
+			Hostname:      req.Hostname,
+			ConfigVersion: req.ConfigVersion,
+			TenantID:      enrollment.TenantID,
+			LastSeenAt:    &now,
+			EnrolledAt:    &now,
+		}
+		if err := tx.Create(&agent).Error; err != nil {
+			return err
+		}
+
+		var err error
+		var hash string
+		credential, hash, err = newAgentCredential(agent.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&agent).Update("credential_hash", hash).Error; err != nil {
+			return err
+		}
+
+		// Réserver le jeton : un enrôlement concurrent avec le même jeton échoue
+		claim := tx.Model(&models.AgentEnrollmentToken{}).
+			Where("id = ? AND used_at IS NULL", enrollment.ID).
+			Updates(map[string]interface{}{"used_at": now, "agent_id": agent.ID})
+		if claim.Error != nil {
+			return claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			return ErrAgentInvalidEnrollmentToken
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.logAgentEvent(&agent, "agent.enrolled", nil, map[string]interface{}{"hostname": agent.Hostname, "version": agent.Version})
+	return &agent, credential, nil
+}
+
+// AuthenticateAgent vérifie un credential d'agent ("agt.<id>.<secret>").
+// Le credential issu d'une rotation devient le credential courant dès sa première utilisation.
+func (s *AgentService) AuthenticateAgent(credential string) (*models.Agent, error) {
+	parts := strings.Split(credential, ".")
+	if len(parts) != 3 || parts[0] != agentCredentialPrefix {
+		return nil, ErrAgentInvalidCredential
+	}
+
+	agent, err := s.GetAgent(parts[1])
+	if err != nil {
+		return nil, ErrAgentInvalidCredential
+	}
+
+	hash := hashAgentSecret(credential)
+	switch {
+	case agent.CredentialHash != nil && subtle.ConstantTimeCompare([]byte(*agent.CredentialHash), []byte(hash)) == 1:
+		return agent, nil
+	case agent.NextCredentialHash != nil && subtle.ConstantTimeCompare([]byte(*agent.NextCredentialHash), []byte(hash)) == 1:
+		if err := s.promoteCredential(agent); err != nil {
+			return nil, err
+		}
+		return agent, nil
+	}
+	return nil, ErrAgentInvalidCredential
+}
+
+// Heartbeat met à jour l'état, les versions et la dernière activité d'un agent
+func (s *AgentService) Heartbeat(agent *models.Agent, req AgentHeartbeatRequest) error {
+	status := req.Status
+	if status == "" {
+		status = models.AgentStatusOnline
+	}
+	if status != models.AgentStatusOnline && status != models.AgentStatusError {
+		return fmt.Errorf("invalid agent status %q", status)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":         status,
+		"status_message": req.Message,
+		"last_seen_at":   now,
+	}
+	if req.Version != nil {
+		updates["version"] = *req.Version
+	}
+	if req.ConfigVersion != nil {
+		updates["config_version"] = *req.ConfigVersion
+	}
+	if req.Endpoint != nil {
+		updates["endpoint"] = *req.Endpoint
+	}
+
+	previous := agent.Status
+	if err := s.DB.Model(agent).Updates(updates).Error; err != nil {
+		return err
+	}
+	if previous != status {
+		s.logAgentEvent(agent, "agent.status_changed", nil, map[string]interface{}{"from": previous, "to": status, "message": req.Message})
+	}
+	return nil
+}
+
+// MarkOfflineAgents passe hors ligne les agents sans heartbeat depuis AgentOfflineAfter
+// et expire les commandes non acquittées à temps
+func (s *AgentService) MarkOfflineAgents() (int, error) {
+	var agents []models.Agent
+	if err := s.DB.Where("status <> ? AND (last_seen_at IS NULL OR last_seen_at < ?)", models.AgentStatusOffline, time.Now().Add(-AgentOfflineAfter)).
+		Find(&agents).Error; err != nil {
+		return 0, err
+	}
+
+	for i := range agents {
+		agent := &agents[i]
+		if err := s.DB.Model(agent).Update("status", models.AgentStatusOffline).Error; err != nil {
+			return i, err
+		}
+		s.logAgentEvent(agent, "agent.status_changed", nil, map[string]interface{}{"from": agent.Status, "to": models.AgentStatusOffline, "lastSeenAt": agent.LastSeenAt})
+	}
+
+	s.DB.Model(&models.AgentCommand{}).
+		Where("status IN ? AND expires_at < ?", []models.AgentCommandStatus{models.AgentCommandPending, models.AgentCommandDelivered}, time.Now()).
+		Update("status", models.AgentCommandExpired)
+
+	return len(agents), nil
+}
+
+// Start lance la détection périodique des agents hors ligne
+func (s *AgentService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.MarkOfflineAgents(); err != nil {
+					fmt.Printf("Agents: failed to detect offline agents: %v\n", err)
+				}
+			}
+		}
+	}()
+}
+
+// GetAgentStatus retourne l'état rapporté par les heartbeats de l'agent
 func (s *AgentService) GetAgentStatus(id string) (map[string]interface{}, error) {
 	agent, err := s.GetAgent(id)
 	if err != nil {
 		return nil, err
 	}
-	return map[string]interface{}{
-		"agent_id": agent.ID,
-		"status":   "running",
-		"uptime":   "0s",
-	}, nil
+
+	var pending int64
+	s.DB.Model(&models.AgentCommand{}).
+		Where("agent_id = ? AND status IN ?", id, []models.AgentCommandStatus{models.AgentCommandPending, models.AgentCommandDelivered}).
+		Count(&pending)
+
+	status := map[string]interface{}{
+		"agentId":         agent.ID,
+		"status":          agent.Status,
+		"statusMessage":   agent.StatusMessage,
+		"version":         agent.Version,
+		"configVersion":   agent.ConfigVersion,
+		"lastSeenAt":      agent.LastSeenAt,
+		"enrolled":        agent.CredentialHash != nil,
+		"pendingCommands": pending,
+	}
+	if agent.LastSeenAt != nil {
+		status["secondsSinceLastSeen"] = int64(time.Since(*agent.LastSeenAt).Seconds())
+	}
+	return status, nil
 }
 
-func (s *AgentService) RestartAgent(id string) error {
+// RestartAgent demande le redémarrage de l'agent
+func (s *AgentService) RestartAgent(id string, issuedBy *string) (*models.AgentCommand, error) {
+	return s.IssueCommand(id, models.AgentCommandRestart, nil, issuedBy)
+}
+
+// IssueCommand met une commande en file pour l'agent et réveille ses long-polls
+func (s *AgentService) IssueCommand(agentID string, commandType models.AgentCommandType, payload interface{}, issuedBy *string) (*models.AgentCommand, error) {
+	if !slices.Contains([]models.AgentCommandType{models.AgentCommandRestart, models.AgentCommandConfigReload, models.AgentCommandTokenRotation}, commandType) {
+		return nil, fmt.Errorf("%w: unknown type %q", ErrAgentInvalidCommand, commandType)
+	}
+
+	agent, err := s.GetAgent(agentID)
+	if err != nil {
+		return nil, err
+	}
+	if agent.CredentialHash == nil {
+		return nil, fmt.Errorf("%w: agent is not enrolled", ErrAgentInvalidCommand)
+	}
+
+	command := &models.AgentCommand{
+		AgentID:   agentID,
+		Type:      commandType,
+		Payload:   payload,
+		Status:    models.AgentCommandPending,
+		IssuedBy:  issuedBy,
+		ExpiresAt: time.Now().Add(agentCommandTTL),
+	}
+	if err := s.DB.Create(command).Error; err != nil {
+		return nil, err
+	}
+
+	s.logAgentEvent(agent, "agent.command.issued", &command.ID, map[string]interface{}{"type": commandType, "issuedBy": issuedBy})
+	notifyAgentCommand(agentID)
+	return command, nil
+}
+
+// ListCommands liste les commandes récentes d'un agent
+func (s *AgentService) ListCommands(agentID string, limit int) ([]models.AgentCommand, error) {
+	var commands []models.AgentCommand
+	err := s.DB.Where("agent_id = ?", agentID).Order("created_at DESC").Limit(limit).Find(&commands).Error
+	return commands, err
+}
+
+// PollCommands distribue les commandes en attente de l'agent, en attendant au plus wait
+// qu'une commande arrive (long-poll). Une rotation de credential reçoit ici son nouveau credential.
+func (s *AgentService) PollCommands(ctx context.Context, agent *models.Agent, wait time.Duration) ([]models.AgentCommand, error) {
+	wait = min(max(wait, 0), AgentMaxPollWait)
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+
+	for {
+		wake, cancel := waitAgentCommand(agent.ID)
+		commands, err := s.deliverCommands(agent)
+		if err != nil || len(commands) > 0 || wait == 0 {
+			cancel()
+			return commands, err
+		}
+
+		poll := time.NewTimer(agentPollInterval)
+		select {
+		case <-ctx.Done():
+			poll.Stop()
+			cancel()
+			return []models.AgentCommand{}, nil
+		case <-deadline.C:
+			poll.Stop()
+			cancel()
+			return []models.AgentCommand{}, nil
+		case <-wake:
+		case <-poll.C:
+		}
+		poll.Stop()
+		cancel()
+	}
+}
+
+// deliverCommands réserve les commandes en attente de l'agent et les marque distribuées
+func (s *AgentService) deliverCommands(agent *models.Agent) ([]models.AgentCommand, error) {
+	var pending []models.AgentCommand
+	if err := s.DB.Where("agent_id = ? AND status = ? AND expires_at > ?", agent.ID, models.AgentCommandPending, time.Now()).
+		Order("created_at ASC").Find(&pending).Error; err != nil {
+		return nil, err
+	}
+
+	commands := make([]models.AgentCommand, 0, len(pending))
+	for _, command := range pending {
+		now := time.Now()
+		// Réserver la commande pour éviter une double distribution entre instances
+		claim := s.DB.Model(&models.AgentCommand{}).
+			Where("id = ? AND status = ?", command.ID, models.AgentCommandPending).
+			Updates(map[string]interface{}{"status": models.AgentCommandDelivered, "delivered_at": now})
+		if claim.Error != nil {
+			return nil, claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+		command.Status = models.AgentCommandDelivered
+		command.DeliveredAt = &now
+
+		if command.Type == models.AgentCommandTokenRotation {
+			credential, hash, err := newAgentCredential(agent.ID)
+			if err != nil {
+				return nil, err
+			}
+			if err := s.DB.Model(agent).Update("next_credential_hash", hash).Error; err != nil {
+				return nil, err
+			}
+			command.Credential = credential
+		}
+
+		s.logAgentEvent(agent, "agent.command.delivered", &command.ID, map[string]interface{}{"type": command.Type})
+		commands = append(commands, command)
+	}
+	return commands, nil
+}
+
+// AcknowledgeCommand enregistre le résultat d'une commande distribuée à l'agent.
+// L'acquittement réussi d'une rotation active le nouveau credential.
+func (s *AgentService) AcknowledgeCommand(agent *models.Agent, commandID string, success bool, message *string) (*models.AgentCommand, error) {
+	var command models.AgentCommand
+	if err := s.DB.Where("id = ? AND agent_id = ?", commandID, agent.ID).First(&command).Error; err != nil {
+		return nil, err
+	}
+	if command.Status != models.AgentCommandDelivered {
+		return nil, ErrAgentCommandNotDelivered
+	}
+
+	status := models.AgentCommandFailed
+	if success {
+		status = models.AgentCommandSucceeded
+	}
+	now := time.Now()
+	if err := s.DB.Model(&command).Updates(map[string]interface{}{
+		"status":          status,
+		"result":          message,
+		"acknowledged_at": now,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	if command.Type == models.AgentCommandTokenRotation {
+		if success {
+			if err := s.promoteCredential(agent); err != nil {
+				return nil, err
+			}
+		} else {
+			s.DB.Model(agent).Update("next_credential_hash", nil)
+		}
+	}
+
+	s.logAgentEvent(agent, "agent.command.acknowledged", &command.ID, map[string]interface{}{"type": command.Type, "status": status, "result": message})
+	return &command, nil
+}
+
+// promoteCredential remplace le credential courant par celui d'une rotation
+func (s *AgentService) promoteCredential(agent *models.Agent) error {
+	if agent.NextCredentialHash == nil {
+		return nil
+	}
+	now := time.Now()
+	if err := s.DB.Model(agent).Updates(map[string]interface{}{
+		"credential_hash":       *agent.NextCredentialHash,
+		"next_credential_hash":  nil,
+		"credential_rotated_at": now,
+	}).Error; err != nil {
+		return err
+	}
+	agent.CredentialHash, agent.NextCredentialHash = agent.NextCredentialHash, nil
 	return nil
 }
+
+// logAgentEvent journalise un événement du protocole agent
+func (s *AgentService) logAgentEvent(agent *models.Agent, eventType string, subject *string, data map[string]interface{}) {
+	source := "agent:" + agent.ID
+	if subject == nil {
+		subject = &agent.ID
+	}
+	event := &models.Event{
+		Type:     eventType,
+		Source:   &source,
+		Subject:  subject,
+		Data:     data,
+		TenantID: agent.TenantID,
+	}
+	if err := NewEventService(s.DB).CreateEvent(event); err != nil {
+		fmt.Printf("Agents: failed to log %s event: %v\n", eventType, err)
+	}
+}
+
+// newAgentCredential génère un credential d'agent et son empreinte
+func newAgentCredential(agentID string) (string, string, error) {
+	secret, err := randomAgentSecret()
+	if err != nil {
+		return "", "", err
+	}
+	credential := agentCredentialPrefix + "." + agentID + "." + secret
+	return credential, hashAgentSecret(credential), nil
+}
+
+// randomAgentSecret génère 32 octets aléatoires encodés en base64url
+func randomAgentSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashAgentSecret retourne l'empreinte SHA-256 hexadécimale d'un secret d'agent
+func hashAgentSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// waitAgentCommand enregistre une attente de commande pour l'agent
+func waitAgentCommand(agentID string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	agentCommandWaiters.Lock()
+	agentCommandWaiters.waiters[agentID] = append(agentCommandWaiters.waiters[agentID], ch)
+	agentCommandWaiters.Unlock()
+
+	return ch, func() {
+		agentCommandWaiters.Lock()
+		defer agentCommandWaiters.Unlock()
+		waiters := slices.DeleteFunc(agentCommandWaiters.waiters[agentID], func(w chan struct{}) bool { return w == ch })
+		if len(waiters) == 0 {
+			delete(agentCommandWaiters.waiters, agentID)
+		} else {
+			agentCommandWaiters.waiters[agentID] = waiters
+		}
+	}
+}
+
+// notifyAgentCommand réveille les long-polls de l'agent sur cette instance
+func notifyAgentCommand(agentID string) {
+	agentCommandWaiters.Lock()
+	defer agentCommandWaiters.Unlock()
+	for _, ch := range agentCommandWaiters.waiters[agentID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package services
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"gorm.io/gorm"
+)
+
+// AuditContextKey est la clé du contexte de requête où les handlers déposent le détail de l'opération auditée
+const AuditContextKey = "audit"
+
+// AuditSourceIdentity est la source des entrées enregistrées par le serveur lui-même
+const AuditSourceIdentity = "identity"
+
+// auditGenesisHash est le PrevHash de la première entrée de la chaîne
+var auditGenesisHash = strings.Repeat("0", 64)
+
+// auditChainLock sérialise l'ajout à la chaîne entre instances (verrou consultatif PostgreSQL)
+const auditChainLock = 0x61756469 // "audi"
+
+// auditRedacted remplace la valeur des champs sensibles dans les différences
+const auditRedacted = "[REDACTED]"
+
+// auditSensitiveFields sont masqués dans les différences avant/après (comparaison insensible à la casse)
+var auditSensitiveFields = []string{"password", "secret", "token", "hash", "privatekey", "private_key", "credential"}
+
+// auditMu sérialise l'ajout à la chaîne au sein du processus
+var auditMu sync.Mutex
+
+// AuditActor est l'auteur d'une opération auditée
+type AuditActor struct {
+	Type string
+	ID   string
+	Name string
+}
+
+// AuditUserActor retourne l'acteur correspondant à un utilisateur
+func AuditUserActor(user *models.User) *AuditActor {
+	actor := &AuditActor{Type: models.AuditActorUser, ID: user.ID}
+	if user.Email != nil {
+		actor.Name = *user.Email
+	}
+	return actor
+}
+
+// AuditDetails est le détail qu'un handler attache à sa requête ; AuditTrail le complète
+// (acteur, requête, résultat) et l'enregistre une fois le handler terminé
+type AuditDetails struct {
+	Action     string
+	TargetType string
+	TargetID   string
+	Actor      *AuditActor // Remplace l'acteur authentifié (connexion, inscription...)
+	Before     interface{}
+	After      interface{}
+	Metadata   map[string]interface{}
+}
+
+// AuditFilter filtre la liste des entrées d'audit
+type AuditFilter struct {
+	Action     string
+	ActorID    string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	Source     string
+	From       *time.Time
+	To         *time.Time
+}
+
+// AuditService tient le journal d'audit chaîné
+type AuditService struct {
+	DB *gorm.DB
+}
+
+// NewAuditService crée une nouvelle instance de AuditService
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{DB: db}
+}
+
+// Record ajoute une entrée à la fin de la chaîne : numéro de séquence, PrevHash et Hash sont calculés ici
+func (s *AuditService) Record(entry *models.AuditEntry) error {
+	var err error
+	if entry.Changes, err = normalizeAuditJSON(entry.Changes); err != nil {
+		return err
+	}
+	if entry.Metadata, err = normalizeAuditJSON(entry.Metadata); err != nil {
+		return err
+	}
+	if entry.Source == "" {
+		entry.Source = AuditSourceIdentity
+	}
+	if entry.Outcome == "" {
+		entry.Outcome = models.AuditOutcomeSuccess
+	}
+	if entry.ActorType == "" {
+		entry.ActorType = models.AuditActorAnonymous
+	}
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLock).Error; err != nil {
+			return err
+		}
+
+		entry.Sequence = 1
+		entry.PrevHash = auditGenesisHash
+		var last models.AuditEntry
+		err := tx.Select("sequence", "hash").Order("sequence DESC").First(&last).Error
+		switch {
+		case err == nil:
+			entry.Sequence = last.Sequence + 1
+			entry.PrevHash = last.Hash
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		// La précision de PostgreSQL est la microseconde : l'empreinte doit porter sur la valeur stockée
+		entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		hash, err := auditEntryHash(entry)
+		if err != nil {
+			return err
+		}
+		entry.Hash = hash
+		return tx.Create(entry).Error
+	})
+}
+
+// VerifyChain relit la chaîne dans l'ordre et recalcule chaque empreinte. La vérification s'arrête
+// à la première entrée dont la séquence, le PrevHash ou le Hash ne correspond pas.
+func (s *AuditService) VerifyChain() (*models.AuditVerification, error) {
+	result := &models.AuditVerification{Valid: true}
+	prevHash := auditGenesisHash
+
+	for {
+		var entries []models.AuditEntry
+		if err := s.DB.Where("sequence > ?", result.LastSequence).Order("sequence ASC").Limit(500).Find(&entries).Error; err != nil {
+			return nil, err
+		}
+		if len(entries) == 0 {
+			return result, nil
+		}
+
+		for i := range entries {
+			entry := &entries[i]
+			reason := ""
+			switch {
+			case entry.Sequence != result.LastSequence+1:
+				reason = fmt.Sprintf("expected sequence %d, found %d", result.LastSequence+1, entry.Sequence)
+			case entry.PrevHash != prevHash:
+				reason = "previous hash does not match the preceding entry"
+			default:
+				hash, err := auditEntryHash(entry)
+				if err != nil {
+					return nil, err
+				}
+				if hash != entry.Hash {
+					reason = "entry content does not match its hash"
+				}
+			}
+			if reason != "" {
+				sequence := entry.Sequence
+				result.Valid = false
+				result.BrokenSequence = &sequence
+				result.Reason = reason
+				return result, nil
+			}
+
+			result.Checked++
+			result.LastSequence = entry.Sequence
+			result.LastHash = entry.Hash
+			prevHash = entry.Hash
+		}
+	}
+}
+
+// GetAuditEntry récupère une entrée d'audit par son ID
+func (s *AuditService) GetAuditEntry(id string) (*models.AuditEntry, error) {
+	var entry models.AuditEntry
+	if err := s.DB.First(&entry, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// auditQuery construit la requête filtrée des entrées d'audit, sans pagination
+func (s *AuditService) auditQuery(filter AuditFilter) *gorm.DB {
+	query := s.DB.Model(&models.AuditEntry{})
+	if filter.Action != "" {
+		// Un préfixe terminé par un point sélectionne une famille d'actions : "clients." ou "auth."
+		if strings.HasSuffix(filter.Action, ".") {
+			query = query.Where("action LIKE ?", filter.Action+"%")
+		} else {
+			query = query.Where("action = ?", filter.Action)
+		}
+	}
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return query
+}
+
+// ListAuditEntries liste les entrées d'audit, les plus récentes en premier
+func (s *AuditService) ListAuditEntries(filter AuditFilter, limit, offset int) ([]models.AuditEntry, int64, error) {
+	query := s.auditQuery(filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.AuditEntry
+	if err := query.Order("sequence DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// StreamAuditEntries parcourt par lots, dans l'ordre de la chaîne, les entrées correspondant au filtre
+func (s *AuditService) StreamAuditEntries(filter AuditFilter, fn func([]models.AuditEntry) error) error {
+	var after int64
+	for {
+		var entries []models.AuditEntry
+		if err := s.auditQuery(filter).Where("sequence > ?", after).Order("sequence ASC").Limit(logExportBatchSize).Find(&entries).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		if err := fn(entries); err != nil {
+			return err
+		}
+		after = entries[len(entries)-1].Sequence
+	}
+}
+
+// RecordProviderEvent enregistre dans la chaîne une entrée envoyée par un connecteur
+// (AuditLogger de l'application GitHub) sous la forme d'un événement de synchronisation "audit"
+func (s *AuditService) RecordProviderEvent(event ProviderSyncEvent) (*models.AuditEntry, error) {
+	if event.Provider == "" || event.EntityID == "" {
+		return nil, ErrProviderSyncInvalidEvent
+	}
+	raw, _ := event.Data["entry"].(map[string]interface{})
+	if raw == nil {
+		raw = event.Data
+	}
+	text := func(m map[string]interface{}, key string) string {
+		value, _ := m[key].(string)
+		return value
+	}
+	actor, _ := raw["actor"].(map[string]interface{})
+	resource, _ := raw["resource"].(map[string]interface{})
+
+	eventType := strings.ToLower(text(raw, "event_type"))
+	action := event.Provider
+	for _, part := range []string{eventType, text(raw, "action")} {
+		if part != "" {
+			action += "." + part
+		}
+	}
+
+	outcome := models.AuditOutcomeSuccess
+	switch decision := strings.ToLower(text(raw, "decision")); {
+	case eventType == "error":
+		outcome = models.AuditOutcomeFailure
+	case decision == "deny" || decision == "denied":
+		outcome = models.AuditOutcomeDenied
+	}
+
+	metadata := map[string]interface{}{"externalId": event.EntityID}
+	for _, key := range []string{"decision", "reason", "github_context", "metadata"} {
+		if value, ok := raw[key]; ok && value != nil && value != "" {
+			metadata[key] = value
+		}
+	}
+	if name := text(resource, "name"); name != "" {
+		metadata["targetName"] = name
+	}
+	if !event.Timestamp.IsZero() {
+		metadata["occurredAt"] = event.Timestamp.UTC().Format(time.RFC3339Nano)
+	}
+
+	entry := &models.AuditEntry{
+		Action:     action,
+		Outcome:    outcome,
+		ActorType:  text(actor, "type"),
+		ActorID:    text(actor, "id"),
+		ActorName:  cmp.Or(text(actor, "name"), text(actor, "email"), text(actor, "external_id")),
+		TargetType: text(resource, "type"),
+		TargetID:   text(resource, "id"),
+		Metadata:   metadata,
+		RequestID:  cmp.Or(event.RequestID, text(raw, "request_id")),
+		Source:     event.Provider,
+	}
+	if err := s.Record(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// AuditChanges calcule la différence champ par champ entre deux états sérialisables en JSON.
+// Les champs sensibles (secrets, mots de passe, jetons) sont signalés comme modifiés sans leur valeur.
+func AuditChanges(before, after interface{}) (map[string]interface{}, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]interface{})
+	for _, fields := range []map[string]interface{}{beforeFields, afterFields} {
+		for name := range fields {
+			if _, done := changes[name]; done {
+				continue
+			}
+			oldValue, hadOld := beforeFields[name]
+			newValue, hasNew := afterFields[name]
+			if hadOld == hasNew && reflect.DeepEqual(oldValue, newValue) {
+				continue
+			}
+			if auditSensitiveField(name) {
+				oldValue, newValue = auditRedacted, auditRedacted
+			}
+			change := map[string]interface{}{}
+			if hadOld {
+				change["before"] = oldValue
+			}
+			if hasNew {
+				change["after"] = newValue
+			}
+			changes[name] = change
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return changes, nil
+}
+
+// auditFields retourne les champs JSON de premier niveau d'une valeur (nil pour une valeur absente)
+func auditFields(value interface{}) (map[string]interface{}, error) {
+	if value == nil || (reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil()) {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("audit state must be a JSON object: %w", err)
+	}
+	return fields, nil
+}
+
+func auditSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, sensitive := range auditSensitiveFields {
+		if strings.Contains(name, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeAuditJSON fait transiter une valeur par JSON pour que l'empreinte calculée à l'écriture
+// soit identique à celle recalculée après relecture depuis la base
+func normalizeAuditJSON(value map[string]interface{}) (map[string]interface{}, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// auditEntryHash calcule SHA-256(PrevHash + "\n" + JSON canonique de l'entrée).
+// L'ID est exclu : il est attribué par la base et la séquence identifie déjà l'entrée.
+func auditEntryHash(entry *models.AuditEntry) (string, error) {
+	payload, err := json.Marshal(struct {
+		Sequence   int64                  `json:"sequence"`
+		Action     string                 `json:"action"`
+		Outcome    string                 `json:"outcome"`
+		ActorType  string                 `json:"actorType"`
+		ActorID    string                 `json:"actorId"`
+		ActorName  string                 `json:"actorName"`
+		TargetType string                 `json:"targetType"`
+		TargetID   string                 `json:"targetId"`
+		Changes    map[string]interface{} `json:"changes"`
+		Metadata   map[string]interface{} `json:"metadata"`
+		RequestID  string                 `json:"requestId"`
+		IPAddress  string                 `json:"ipAddress"`
+		UserAgent  string                 `json:"userAgent"`
+		Source     string                 `json:"source"`
+		CreatedAt  string                 `json:"createdAt"`
+	}{
+		entry.Sequence, entry.Action, entry.Outcome, entry.ActorType, entry.ActorID, entry.ActorName,
+		entry.TargetType, entry.TargetID, entry.Changes, entry.Metadata, entry.RequestID,
+		entry.IPAddress, entry.UserAgent, entry.Source, entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(entry.PrevHash+"\n"), payload...))
+	return hex.EncodeToString(sum[:]), nil
+}
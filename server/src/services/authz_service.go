@@ -0,0 +1,782 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"gorm.io/gorm"
+)
+
+// Modes de cohérence acceptés par les API /authz
+const (
+	AuthzConsistencyMinimizeLatency = "minimize_latency"
+	AuthzConsistencyAtLeastAsFresh  = "at_least_as_fresh"
+	AuthzConsistencyAtExactSnapshot = "at_exact_snapshot"
+	AuthzConsistencyFullyConsistent = "fully_consistent"
+)
+
+const (
+	authzMaxDepth         = 25
+	authzCacheTTL         = time.Minute
+	authzCacheMaxEntries  = 10000
+	authzRevisionCacheTTL = time.Second
+	authzTokenPrefix      = "authz:v1:"
+)
+
+var (
+	ErrAuthzInvalidTuple     = errors.New("relation tuple must be in the form namespace:object#relation@subject")
+	ErrAuthzInvalidObject    = errors.New("object must be in the form namespace:object")
+	ErrAuthzInvalidSubject   = errors.New("subject must be in the form namespace:id or namespace:object#relation")
+	ErrAuthzUnknownNamespace = errors.New("unknown namespace")
+	ErrAuthzUnknownRelation  = errors.New("unknown relation")
+	ErrAuthzInvalidToken     = errors.New("invalid consistency token")
+	ErrAuthzDepthExceeded    = errors.New("maximum evaluation depth exceeded")
+)
+
+// DefaultAuthzNamespaces décrit les namespaces intégrés ; une configuration en base du même nom les remplace
+var DefaultAuthzNamespaces = map[string]models.AuthzNamespaceConfig{
+	"organization": {Relations: map[string]models.AuthzRelationConfig{
+		"owner":  {Direct: true},
+		"admin":  {Direct: true, Computed: []string{"owner"}},
+		"member": {Direct: true, Computed: []string{"admin"}},
+	}},
+	"team": {Relations: map[string]models.AuthzRelationConfig{
+		"maintainer": {Direct: true},
+		"member":     {Direct: true, Computed: []string{"maintainer"}},
+	}},
+	"repo": {Relations: map[string]models.AuthzRelationConfig{
+		"organization": {Direct: true},
+		"admin":        {Direct: true, Inherited: []models.AuthzTupleToUserset{{Tupleset: "organization", Relation: "admin"}}},
+		"maintainer":   {Direct: true, Computed: []string{"admin"}},
+		"writer":       {Direct: true, Computed: []string{"maintainer"}},
+		"triager":      {Direct: true, Computed: []string{"writer"}},
+		"reader":       {Direct: true, Computed: []string{"triager"}},
+		// Actions GitHub exprimées comme relations calculées
+		"push":         {Computed: []string{"writer"}},
+		"pull":         {Computed: []string{"reader"}},
+		"pull_request": {Computed: []string{"reader"}},
+		"triage":       {Computed: []string{"triager"}},
+		"maintain":     {Computed: []string{"maintainer"}},
+	}},
+}
+
+var authzUUIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+type authzCacheEntry struct {
+	allowed   bool
+	expiresAt time.Time
+}
+
+// authzCache conserve les résultats de check par révision ; un instantané étant immuable,
+// seule la configuration des namespaces ou des comptes liés peut les rendre obsolètes
+var authzCache = struct {
+	sync.RWMutex
+	entries  map[string]authzCacheEntry
+	revision int64
+	readAt   time.Time
+}{entries: make(map[string]authzCacheEntry)}
+
+// InvalidateAuthzCache vide le cache des résultats de check
+func InvalidateAuthzCache() {
+	authzCache.Lock()
+	authzCache.entries = make(map[string]authzCacheEntry)
+	authzCache.Unlock()
+}
+
+// AuthzConsistency précise l'instantané sur lequel une requête est évaluée
+type AuthzConsistency struct {
+	Mode  string `json:"mode"`
+	Token string `json:"token,omitempty"`
+}
+
+// AuthzWriteRequest regroupe les tuples à écrire et à supprimer dans une même révision.
+// Replace liste des "namespace:objet#relation" dont les tuples absents de Writes sont supprimés.
+type AuthzWriteRequest struct {
+	Writes  []string `json:"writes"`
+	Deletes []string `json:"deletes"`
+	Replace []string `json:"replace"`
+}
+
+// AuthzService gère le magasin de relations et évalue les autorisations (modèle Zanzibar)
+type AuthzService struct {
+	DB *gorm.DB
+}
+
+// NewAuthzService crée une nouvelle instance de AuthzService
+func NewAuthzService(db *gorm.DB) *AuthzService {
+	return &AuthzService{DB: db}
+}
+
+// ParseAuthzObject découpe un objet "namespace:objet"
+func ParseAuthzObject(object string) (string, string, error) {
+	namespace, id, ok := strings.Cut(strings.TrimSpace(object), ":")
+	if !ok || namespace == "" || id == "" || strings.ContainsAny(object, "#@") {
+		return "", "", ErrAuthzInvalidObject
+	}
+	return namespace, id, nil
+}
+
+// ParseAuthzSubject découpe un sujet "namespace:id" ou un userset "namespace:objet#relation"
+func ParseAuthzSubject(subject string) (string, string, string, error) {
+	object, relation, hasRelation := strings.Cut(strings.TrimSpace(subject), "#")
+	namespace, id, err := ParseAuthzObject(object)
+	if err != nil || (hasRelation && relation == "") || strings.Contains(relation, "@") {
+		return "", "", "", ErrAuthzInvalidSubject
+	}
+	return namespace, id, relation, nil
+}
+
+// ParseRelationTuple lit un tuple "namespace:objet#relation@sujet"
+func ParseRelationTuple(tuple string) (*models.RelationTuple, error) {
+	objectPart, subjectPart, ok := strings.Cut(strings.TrimSpace(tuple), "@")
+	if !ok {
+		return nil, ErrAuthzInvalidTuple
+	}
+	object, relation, ok := strings.Cut(objectPart, "#")
+	if !ok || relation == "" {
+		return nil, ErrAuthzInvalidTuple
+	}
+	namespace, objectID, err := ParseAuthzObject(object)
+	if err != nil {
+		return nil, ErrAuthzInvalidTuple
+	}
+	subjectNamespace, subjectID, subjectRelation, err := ParseAuthzSubject(subjectPart)
+	if err != nil {
+		return nil, ErrAuthzInvalidTuple
+	}
+
+	return &models.RelationTuple{
+		Namespace:        namespace,
+		ObjectID:         objectID,
+		Relation:         relation,
+		SubjectNamespace: subjectNamespace,
+		SubjectID:        subjectID,
+		SubjectRelation:  subjectRelation,
+	}, nil
+}
+
+// FormatRelationTuple renvoie la forme textuelle d'un tuple
+func FormatRelationTuple(tuple *models.RelationTuple) string {
+	return fmt.Sprintf("%s:%s#%s@%s", tuple.Namespace, tuple.ObjectID, tuple.Relation, formatAuthzSubject(tuple.SubjectNamespace, tuple.SubjectID, tuple.SubjectRelation))
+}
+
+func formatAuthzSubject(namespace, id, relation string) string {
+	if relation == "" {
+		return namespace + ":" + id
+	}
+	return namespace + ":" + id + "#" + relation
+}
+
+// EncodeAuthzToken encode une révision en jeton de cohérence opaque
+func EncodeAuthzToken(revision int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(authzTokenPrefix + strconv.FormatInt(revision, 10)))
+}
+
+// DecodeAuthzToken décode un jeton de cohérence
+func DecodeAuthzToken(token string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !strings.HasPrefix(string(raw), authzTokenPrefix) {
+		return 0, ErrAuthzInvalidToken
+	}
+	revision, err := strconv.ParseInt(strings.TrimPrefix(string(raw), authzTokenPrefix), 10, 64)
+	if err != nil || revision < 0 {
+		return 0, ErrAuthzInvalidToken
+	}
+	return revision, nil
+}
+
+// CurrentRevision renvoie la dernière révision écrite
+func (s *AuthzService) CurrentRevision() (int64, error) {
+	var revision int64
+	if err := s.DB.Model(&models.AuthzRevision{}).Select("COALESCE(MAX(id), 0)").Scan(&revision).Error; err != nil {
+		return 0, err
+	}
+
+	authzCache.Lock()
+	if revision > authzCache.revision {
+		authzCache.revision = revision
+	}
+	authzCache.readAt = time.Now()
+	authzCache.Unlock()
+	return revision, nil
+}
+
+// Snapshot résout la révision à laquelle évaluer une requête selon le mode de cohérence demandé
+func (s *AuthzService) Snapshot(consistency AuthzConsistency) (int64, error) {
+	switch consistency.Mode {
+	case AuthzConsistencyAtExactSnapshot, AuthzConsistencyAtLeastAsFresh:
+		requested, err := DecodeAuthzToken(consistency.Token)
+		if err != nil {
+			return 0, err
+		}
+		latest, err := s.CurrentRevision()
+		if err != nil {
+			return 0, err
+		}
+		if requested > latest {
+			return 0, ErrAuthzInvalidToken
+		}
+		if consistency.Mode == AuthzConsistencyAtExactSnapshot {
+			return requested, nil
+		}
+		return latest, nil
+	case AuthzConsistencyFullyConsistent:
+		return s.CurrentRevision()
+	case "", AuthzConsistencyMinimizeLatency:
+		authzCache.RLock()
+		revision, readAt := authzCache.revision, authzCache.readAt
+		authzCache.RUnlock()
+		if time.Since(readAt) < authzRevisionCacheTTL {
+			return revision, nil
+		}
+		return s.CurrentRevision()
+	default:
+		return 0, fmt.Errorf("unknown consistency mode %q", consistency.Mode)
+	}
+}
+
+// Namespaces renvoie la configuration effective des namespaces (intégrés et surcharges en base)
+func (s *AuthzService) Namespaces() (map[string]models.AuthzNamespaceConfig, error) {
+	namespaces := make(map[string]models.AuthzNamespaceConfig, len(DefaultAuthzNamespaces))
+	for name, config := range DefaultAuthzNamespaces {
+		namespaces[name] = config
+	}
+
+	var stored []models.AuthzNamespace
+	if err := s.DB.Find(&stored).Error; err != nil {
+		return nil, err
+	}
+	for _, namespace := range stored {
+		config, err := decodeAuthzNamespaceConfig(namespace.Config)
+		if err != nil {
+			return nil, fmt.Errorf("namespace %s: %w", namespace.Name, err)
+		}
+		namespaces[namespace.Name] = config
+	}
+	return namespaces, nil
+}
+
+// SaveNamespace crée ou remplace la configuration d'un namespace
+func (s *AuthzService) SaveNamespace(name string, config models.AuthzNamespaceConfig) (*models.AuthzNamespace, error) {
+	if name == "" || strings.ContainsAny(name, ":#@") {
+		return nil, ErrAuthzUnknownNamespace
+	}
+	if err := validateAuthzNamespaceConfig(config); err != nil {
+		return nil, err
+	}
+
+	var namespace models.AuthzNamespace
+	err := s.DB.Where("name = ?", name).First(&namespace).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	namespace.Name = name
+	namespace.Config = config
+	if err := s.DB.Save(&namespace).Error; err != nil {
+		return nil, err
+	}
+
+	InvalidateAuthzCache()
+	return &namespace, nil
+}
+
+// DeleteNamespace supprime la surcharge d'un namespace ; un namespace intégré revient à sa configuration par défaut
+func (s *AuthzService) DeleteNamespace(name string) error {
+	result := s.DB.Where("name = ?", name).Delete(&models.AuthzNamespace{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	InvalidateAuthzCache()
+	return nil
+}
+
+// Write applique atomiquement les écritures et suppressions et renvoie la nouvelle révision
+func (s *AuthzService) Write(req AuthzWriteRequest) (int64, error) {
+	namespaces, err := s.Namespaces()
+	if err != nil {
+		return 0, err
+	}
+
+	writes, err := parseAuthzTuples(req.Writes, namespaces)
+	if err != nil {
+		return 0, err
+	}
+	deletes, err := parseAuthzTuples(req.Deletes, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var revision int64
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		// Sérialiser les écritures pour que l'ordre des révisions suive l'ordre des commits
+		if err := tx.Exec("LOCK TABLE authz_revisions IN EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+
+		rev := models.AuthzRevision{}
+		if err := tx.Create(&rev).Error; err != nil {
+			return err
+		}
+		revision = rev.ID
+
+		deleted := 0
+		for _, set := range req.Replace {
+			namespace, objectID, relation, err := ParseAuthzSubject(set)
+			if err != nil || relation == "" {
+				return ErrAuthzInvalidObject
+			}
+			keep := []string{}
+			for _, tuple := range writes {
+				if tuple.Namespace == namespace && tuple.ObjectID == objectID && tuple.Relation == relation {
+					keep = append(keep, formatAuthzSubject(tuple.SubjectNamespace, tuple.SubjectID, tuple.SubjectRelation))
+				}
+			}
+
+			var existing []models.RelationTuple
+			if err := liveAuthzTuples(tx, revision).
+				Where("namespace = ? AND object_id = ? AND relation = ?", namespace, objectID, relation).
+				Find(&existing).Error; err != nil {
+				return err
+			}
+			for _, tuple := range existing {
+				if slices.Contains(keep, formatAuthzSubject(tuple.SubjectNamespace, tuple.SubjectID, tuple.SubjectRelation)) {
+					continue
+				}
+				if err := tx.Model(&models.RelationTuple{}).Where("id = ?", tuple.ID).Update("deleted_revision", revision).Error; err != nil {
+					return err
+				}
+				deleted++
+			}
+		}
+
+		for _, tuple := range deletes {
+			result := matchAuthzTuple(tx.Model(&models.RelationTuple{}), tuple).
+				Where("deleted_revision IS NULL").
+				Update("deleted_revision", revision)
+			if result.Error != nil {
+				return result.Error
+			}
+			deleted += int(result.RowsAffected)
+		}
+
+		written := 0
+		for _, tuple := range writes {
+			var count int64
+			if err := matchAuthzTuple(tx.Model(&models.RelationTuple{}), tuple).
+				Where("deleted_revision IS NULL").
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+			tuple.CreatedRevision = revision
+			if err := tx.Create(tuple).Error; err != nil {
+				return err
+			}
+			written++
+		}
+
+		return tx.Model(&models.AuthzRevision{}).Where("id = ?", revision).
+			Updates(map[string]interface{}{"written": written, "deleted": deleted}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	authzCache.Lock()
+	if revision > authzCache.revision {
+		authzCache.revision = revision
+	}
+	authzCache.readAt = time.Now()
+	authzCache.Unlock()
+	return revision, nil
+}
+
+// ReadTuples liste les tuples vivants à une révision, filtrés par objet, relation et sujet
+func (s *AuthzService) ReadTuples(revision int64, namespace, objectID, relation, subject string, page, limit int) ([]models.RelationTuple, int64, error) {
+	query := liveAuthzTuples(s.DB, revision)
+	if namespace != "" {
+		query = query.Where("namespace = ?", namespace)
+	}
+	if objectID != "" {
+		query = query.Where("object_id = ?", objectID)
+	}
+	if relation != "" {
+		query = query.Where("relation = ?", relation)
+	}
+	if subject != "" {
+		subjectNamespace, subjectID, subjectRelation, err := ParseAuthzSubject(subject)
+		if err != nil {
+			return nil, 0, err
+		}
+		query = query.Where("subject_namespace = ? AND subject_id = ? AND subject_relation = ?", subjectNamespace, subjectID, subjectRelation)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var tuples []models.RelationTuple
+	if err := query.Order("namespace, object_id, relation, created_revision").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&tuples).Error; err != nil {
+		return nil, 0, err
+	}
+	return tuples, total, nil
+}
+
+// Check indique si le sujet dispose de la relation sur l'objet à la révision donnée
+func (s *AuthzService) Check(revision int64, object, relation, subject string) (bool, error) {
+	key := fmt.Sprintf("%d|%s#%s@%s", revision, object, relation, subject)
+	authzCache.RLock()
+	entry, ok := authzCache.entries[key]
+	authzCache.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.allowed, nil
+	}
+
+	evaluator, err := s.newEvaluator(revision)
+	if err != nil {
+		return false, err
+	}
+	namespace, objectID, err := evaluator.validateObjectRelation(object, relation)
+	if err != nil {
+		return false, err
+	}
+	subjects, err := evaluator.subjectSet(subject)
+	if err != nil {
+		return false, err
+	}
+
+	allowed, err := evaluator.check(namespace, objectID, relation, subjects, 0, map[string]bool{})
+	if err != nil {
+		return false, err
+	}
+
+	authzCache.Lock()
+	if len(authzCache.entries) >= authzCacheMaxEntries {
+		authzCache.entries = make(map[string]authzCacheEntry)
+	}
+	authzCache.entries[key] = authzCacheEntry{allowed: allowed, expiresAt: time.Now().Add(authzCacheTTL)}
+	authzCache.Unlock()
+	return allowed, nil
+}
+
+// Expand renvoie l'arbre des sujets qui disposent de la relation sur l'objet
+func (s *AuthzService) Expand(revision int64, object, relation string) (*models.AuthzExpandNode, error) {
+	evaluator, err := s.newEvaluator(revision)
+	if err != nil {
+		return nil, err
+	}
+	namespace, objectID, err := evaluator.validateObjectRelation(object, relation)
+	if err != nil {
+		return nil, err
+	}
+	return evaluator.expand(namespace, objectID, relation, 0, map[string]bool{})
+}
+
+// ListObjects liste les objets d'un namespace sur lesquels le sujet dispose de la relation
+func (s *AuthzService) ListObjects(revision int64, namespace, relation, subject string, limit int) ([]string, error) {
+	evaluator, err := s.newEvaluator(revision)
+	if err != nil {
+		return nil, err
+	}
+	config, ok := evaluator.namespaces[namespace]
+	if !ok {
+		return nil, ErrAuthzUnknownNamespace
+	}
+	if _, ok := config.Relations[relation]; !ok {
+		return nil, ErrAuthzUnknownRelation
+	}
+	subjects, err := evaluator.subjectSet(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []string
+	if err := liveAuthzTuples(s.DB, revision).
+		Where("namespace = ?", namespace).
+		Distinct("object_id").Order("object_id").
+		Pluck("object_id", &candidates).Error; err != nil {
+		return nil, err
+	}
+
+	objects := []string{}
+	for _, objectID := range candidates {
+		allowed, err := evaluator.check(namespace, objectID, relation, subjects, 0, map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			objects = append(objects, namespace+":"+objectID)
+			if limit > 0 && len(objects) >= limit {
+				break
+			}
+		}
+	}
+	return objects, nil
+}
+
+// authzEvaluator évalue les règles de réécriture des namespaces sur un instantané
+type authzEvaluator struct {
+	db         *gorm.DB
+	revision   int64
+	namespaces map[string]models.AuthzNamespaceConfig
+}
+
+func (s *AuthzService) newEvaluator(revision int64) (*authzEvaluator, error) {
+	namespaces, err := s.Namespaces()
+	if err != nil {
+		return nil, err
+	}
+	return &authzEvaluator{db: s.DB, revision: revision, namespaces: namespaces}, nil
+}
+
+func (e *authzEvaluator) validateObjectRelation(object, relation string) (string, string, error) {
+	namespace, objectID, err := ParseAuthzObject(object)
+	if err != nil {
+		return "", "", err
+	}
+	config, ok := e.namespaces[namespace]
+	if !ok {
+		return "", "", ErrAuthzUnknownNamespace
+	}
+	if _, ok := config.Relations[relation]; !ok {
+		return "", "", ErrAuthzUnknownRelation
+	}
+	return namespace, objectID, nil
+}
+
+// subjectSet renvoie le sujet et ses alias : un utilisateur "user:<id>" est aussi désigné
+// par ses comptes externes liés, par exemple "github_user:<id GitHub>"
+func (e *authzEvaluator) subjectSet(subject string) (map[string]bool, error) {
+	namespace, id, relation, err := ParseAuthzSubject(subject)
+	if err != nil {
+		return nil, err
+	}
+	subjects := map[string]bool{formatAuthzSubject(namespace, id, relation): true}
+	if namespace != "user" || relation != "" || !authzUUIDPattern.MatchString(id) {
+		return subjects, nil
+	}
+
+	var accounts []models.ExternalAccount
+	if err := e.db.Select("provider", "provider_account_id").Where("user_id = ?", id).Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		subjects[strings.ToLower(account.Provider)+"_user:"+account.ProviderAccountID] = true
+	}
+	return subjects, nil
+}
+
+func (e *authzEvaluator) tuples(namespace, objectID, relation string) ([]models.RelationTuple, error) {
+	var tuples []models.RelationTuple
+	err := liveAuthzTuples(e.db, e.revision).
+		Where("namespace = ? AND object_id = ? AND relation = ?", namespace, objectID, relation).
+		Find(&tuples).Error
+	return tuples, err
+}
+
+func (e *authzEvaluator) check(namespace, objectID, relation string, subjects map[string]bool, depth int, visiting map[string]bool) (bool, error) {
+	if depth > authzMaxDepth {
+		return false, ErrAuthzDepthExceeded
+	}
+	config, ok := e.namespaces[namespace].Relations[relation]
+	if !ok {
+		return false, nil
+	}
+
+	// Un cycle de relations ne peut pas accorder d'accès
+	key := formatAuthzSubject(namespace, objectID, relation)
+	if visiting[key] {
+		return false, nil
+	}
+	visiting[key] = true
+	defer delete(visiting, key)
+
+	if config.Direct {
+		tuples, err := e.tuples(namespace, objectID, relation)
+		if err != nil {
+			return false, err
+		}
+		for _, tuple := range tuples {
+			if subjects[formatAuthzSubject(tuple.SubjectNamespace, tuple.SubjectID, tuple.SubjectRelation)] {
+				return true, nil
+			}
+		}
+		for _, tuple := range tuples {
+			if tuple.SubjectRelation == "" {
+				continue
+			}
+			allowed, err := e.check(tuple.SubjectNamespace, tuple.SubjectID, tuple.SubjectRelation, subjects, depth+1, visiting)
+			if err != nil || allowed {
+				return allowed, err
+			}
+		}
+	}
+
+	for _, computed := range config.Computed {
+		allowed, err := e.check(namespace, objectID, computed, subjects, depth+1, visiting)
+		if err != nil || allowed {
+			return allowed, err
+		}
+	}
+
+	for _, inherited := range config.Inherited {
+		parents, err := e.tuples(namespace, objectID, inherited.Tupleset)
+		if err != nil {
+			return false, err
+		}
+		for _, parent := range parents {
+			allowed, err := e.check(parent.SubjectNamespace, parent.SubjectID, inherited.Relation, subjects, depth+1, visiting)
+			if err != nil || allowed {
+				return allowed, err
+			}
+		}
+	}
+
+	return false, nil
+}
+
+func (e *authzEvaluator) expand(namespace, objectID, relation string, depth int, visiting map[string]bool) (*models.AuthzExpandNode, error) {
+	if depth > authzMaxDepth {
+		return nil, ErrAuthzDepthExceeded
+	}
+	object := namespace + ":" + objectID
+	node := &models.AuthzExpandNode{Type: "union", Object: object, Relation: relation}
+
+	config, ok := e.namespaces[namespace].Relations[relation]
+	key := formatAuthzSubject(namespace, objectID, relation)
+	if !ok || visiting[key] {
+		return node, nil
+	}
+	visiting[key] = true
+	defer delete(visiting, key)
+
+	if config.Direct {
+		tuples, err := e.tuples(namespace, objectID, relation)
+		if err != nil {
+			return nil, err
+		}
+		direct := &models.AuthzExpandNode{Type: "direct", Object: object, Relation: relation, Subjects: []string{}}
+		for _, tuple := range tuples {
+			direct.Subjects = append(direct.Subjects, formatAuthzSubject(tuple.SubjectNamespace, tuple.SubjectID, tuple.SubjectRelation))
+		}
+		sort.Strings(direct.Subjects)
+		node.Children = append(node.Children, direct)
+	}
+
+	for _, computed := range config.Computed {
+		child, err := e.expand(namespace, objectID, computed, depth+1, visiting)
+		if err != nil {
+			return nil, err
+		}
+		child.Type = "computed"
+		node.Children = append(node.Children, child)
+	}
+
+	for _, inherited := range config.Inherited {
+		parents, err := e.tuples(namespace, objectID, inherited.Tupleset)
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range parents {
+			child, err := e.expand(parent.SubjectNamespace, parent.SubjectID, inherited.Relation, depth+1, visiting)
+			if err != nil {
+				return nil, err
+			}
+			child.Type = "inherited"
+			node.Children = append(node.Children, child)
+		}
+	}
+
+	return node, nil
+}
+
+// liveAuthzTuples restreint la requête aux tuples visibles à la révision donnée
+func liveAuthzTuples(db *gorm.DB, revision int64) *gorm.DB {
+	return db.Model(&models.RelationTuple{}).
+		Where("created_revision <= ? AND (deleted_revision IS NULL OR deleted_revision > ?)", revision, revision)
+}
+
+func matchAuthzTuple(query *gorm.DB, tuple *models.RelationTuple) *gorm.DB {
+	return query.Where("namespace = ? AND object_id = ? AND relation = ? AND subject_namespace = ? AND subject_id = ? AND subject_relation = ?",
+		tuple.Namespace, tuple.ObjectID, tuple.Relation, tuple.SubjectNamespace, tuple.SubjectID, tuple.SubjectRelation)
+}
+
+// parseAuthzTuples lit des tuples ; si namespaces est fourni, l'objet et le userset sujet doivent y être déclarés
+func parseAuthzTuples(raw []string, namespaces map[string]models.AuthzNamespaceConfig) ([]*models.RelationTuple, error) {
+	tuples := make([]*models.RelationTuple, 0, len(raw))
+	for _, value := range raw {
+		tuple, err := ParseRelationTuple(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", value, err)
+		}
+		if namespaces != nil {
+			config, ok := namespaces[tuple.Namespace]
+			if !ok {
+				return nil, fmt.Errorf("%s: %w", value, ErrAuthzUnknownNamespace)
+			}
+			if relation, ok := config.Relations[tuple.Relation]; !ok || !relation.Direct {
+				return nil, fmt.Errorf("%s: %w", value, ErrAuthzUnknownRelation)
+			}
+			if tuple.SubjectRelation != "" {
+				if _, ok := namespaces[tuple.SubjectNamespace].Relations[tuple.SubjectRelation]; !ok {
+					return nil, fmt.Errorf("%s: %w", value, ErrAuthzUnknownRelation)
+				}
+			}
+		}
+		tuples = append(tuples, tuple)
+	}
+	return tuples, nil
+}
+
+func decodeAuthzNamespaceConfig(raw interface{}) (models.AuthzNamespaceConfig, error) {
+	var config models.AuthzNamespaceConfig
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+func validateAuthzNamespaceConfig(config models.AuthzNamespaceConfig) error {
+	if len(config.Relations) == 0 {
+		return fmt.Errorf("namespace must declare at least one relation: %w", ErrAuthzUnknownRelation)
+	}
+	for name, relation := range config.Relations {
+		if name == "" || strings.ContainsAny(name, ":#@") {
+			return fmt.Errorf("invalid relation name %q: %w", name, ErrAuthzUnknownRelation)
+		}
+		for _, computed := range relation.Computed {
+			if _, ok := config.Relations[computed]; !ok {
+				return fmt.Errorf("relation %s: computed %s: %w", name, computed, ErrAuthzUnknownRelation)
+			}
+		}
+		for _, inherited := range relation.Inherited {
+			if _, ok := config.Relations[inherited.Tupleset]; !ok || inherited.Relation == "" {
+				return fmt.Errorf("relation %s: inherited %s->%s: %w", name, inherited.Tupleset, inherited.Relation, ErrAuthzUnknownRelation)
+			}
+		}
+	}
+	return nil
+}
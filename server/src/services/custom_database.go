@@ -0,0 +1,519 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Opérations supportées par une connexion de base de données personnalisée
+const (
+	CustomDatabaseOperationLogin          = "login"
+	CustomDatabaseOperationGetUser        = "get_user"
+	CustomDatabaseOperationChangePassword = "change_password"
+)
+
+var (
+	// ErrCustomDatabaseUserNotFound est renvoyée quand aucune base externe ne connaît l'utilisateur
+	ErrCustomDatabaseUserNotFound = errors.New("user not found in custom databases")
+	// ErrCustomDatabaseInvalidPassword est renvoyée quand le mot de passe ne correspond pas
+	ErrCustomDatabaseInvalidPassword = errors.New("invalid password")
+	// ErrCustomDatabaseScriptRunnerMissing est renvoyée quand aucun runtime d'actions n'est enregistré
+	ErrCustomDatabaseScriptRunnerMissing = errors.New("action scripts require an action runtime")
+	// ErrCustomDatabaseAccountConflict est renvoyée quand l'email appartient à un compte local
+	// qui n'est pas rattaché à cette connexion (compte social, SCIM, sans mot de passe...)
+	ErrCustomDatabaseAccountConflict = errors.New("email belongs to a local account not linked to this database connection")
+)
+
+// DatabaseScriptRunner exécute les scripts d'action associés à une connexion de base de données.
+// Le script reçoit l'opération et ses paramètres, et renvoie les colonnes de l'utilisateur
+// (id, email, username, name, password_hash, email_verified) ou nil si l'utilisateur est inconnu.
+type DatabaseScriptRunner interface {
+	RunDatabaseScript(actionID string, operation string, input map[string]interface{}) (map[string]interface{}, error)
+}
+
+var databaseScriptRunner DatabaseScriptRunner
+
+// SetDatabaseScriptRunner enregistre le runtime utilisé pour les scripts de connexion
+func SetDatabaseScriptRunner(runner DatabaseScriptRunner) {
+	databaseScriptRunner = runner
+}
+
+// externalDatabases garde un pool de connexions par connexion de base de données configurée
+var externalDatabases = struct {
+	sync.Mutex
+	conns map[string]externalDatabase
+}{conns: make(map[string]externalDatabase)}
+
+type externalDatabase struct {
+	db        *gorm.DB
+	updatedAt time.Time
+}
+
+// CustomDatabaseService gère l'authentification contre des bases d'utilisateurs externes
+type CustomDatabaseService struct {
+	DB *gorm.DB
+}
+
+// NewCustomDatabaseService crée une nouvelle instance de CustomDatabaseService
+func NewCustomDatabaseService(db *gorm.DB) *CustomDatabaseService {
+	return &CustomDatabaseService{DB: db}
+}
+
+// Login authentifie un utilisateur contre les bases externes configurées.
+// Si connectionName est vide, toutes les connexions de type database actives sont essayées.
+// En mode import, l'utilisateur et son hash hérité sont copiés dans la table users.
+func (s *CustomDatabaseService) Login(connectionName, email, password string) (*models.User, error) {
+	dbConns, err := s.candidateConnections(connectionName, email)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range dbConns {
+		dbConn := &dbConns[i]
+		if dbConn.LoginQuery == nil && dbConn.LoginScriptID == nil {
+			continue
+		}
+
+		record, err := s.run(dbConn, CustomDatabaseOperationLogin, map[string]interface{}{
+			"email":    email,
+			"password": password,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("custom database %s: %w", dbConn.ID, err)
+		}
+		if record == nil {
+			continue
+		}
+
+		// Les requêtes SQL renvoient le hash, les scripts peuvent valider eux-mêmes le mot de passe
+		if record.PasswordHash != "" {
+			ok, err := VerifyPasswordHash(record.PasswordHash, password)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, ErrCustomDatabaseInvalidPassword
+			}
+		} else if dbConn.LoginScriptID == nil {
+			return nil, ErrCustomDatabaseInvalidPassword
+		}
+
+		return s.linkUser(dbConn, record, true)
+	}
+
+	return nil, ErrCustomDatabaseUserNotFound
+}
+
+// GetUser recherche un utilisateur dans les bases externes et le rattache à un compte local
+// sans mot de passe, par exemple pour permettre une réinitialisation de mot de passe.
+func (s *CustomDatabaseService) GetUser(email string) (*models.User, error) {
+	dbConns, err := s.candidateConnections("", email)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range dbConns {
+		dbConn := &dbConns[i]
+		if dbConn.GetUserQuery == nil && dbConn.GetUserScriptID == nil {
+			continue
+		}
+
+		record, err := s.run(dbConn, CustomDatabaseOperationGetUser, map[string]interface{}{
+			"email": email,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("custom database %s: %w", dbConn.ID, err)
+		}
+		if record == nil {
+			continue
+		}
+
+		// Le hash n'est importé qu'après une connexion réussie
+		record.PasswordHash = ""
+		return s.linkUser(dbConn, record, false)
+	}
+
+	return nil, ErrCustomDatabaseUserNotFound
+}
+
+// ChangePassword propage un changement de mot de passe vers la base externe de l'utilisateur.
+// Renvoie false si l'utilisateur n'est pas géré par une base externe (ou a déjà été importé),
+// auquel cas le mot de passe doit être stocké localement.
+func (s *CustomDatabaseService) ChangePassword(user *models.User, newPassword string) (bool, error) {
+	if s.DB == nil || user == nil || user.ID == "" {
+		return false, nil
+	}
+
+	var link models.DatabaseConnectionUser
+	if err := s.DB.Where("user_id = ?", user.ID).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	var dbConn models.DatabaseConnection
+	if err := s.DB.First(&dbConn, "id = ?", link.DatabaseConnectionID).Error; err != nil {
+		return false, err
+	}
+	if dbConn.ImportMode || (dbConn.ChangePasswordQuery == nil && dbConn.ChangePasswordScriptID == nil) {
+		return false, nil
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return false, err
+	}
+
+	input := map[string]interface{}{
+		"email":         link.Email,
+		"password":      newPassword,
+		"password_hash": string(hashed),
+	}
+	if _, err := s.run(&dbConn, CustomDatabaseOperationChangePassword, input); err != nil {
+		return false, fmt.Errorf("custom database %s: %w", dbConn.ID, err)
+	}
+
+	return true, nil
+}
+
+// activeDatabaseConnections renvoie les connexions de base de données dont la connexion parente est active
+func (s *CustomDatabaseService) activeDatabaseConnections(connectionName string) ([]models.DatabaseConnection, error) {
+	if s.DB == nil {
+		return nil, errors.New("database not available")
+	}
+
+	query := s.DB.Model(&models.DatabaseConnection{}).
+		Joins("JOIN connections ON connections.id = database_connections.connection_id").
+		Where("connections.type = ? AND connections.is_enabled = ? AND connections.deleted_at IS NULL", models.ConnectionTypeDatabase, true)
+	if connectionName != "" {
+		query = query.Where("connections.name = ?", connectionName)
+	}
+
+	var dbConns []models.DatabaseConnection
+	if err := query.Order("connections.name ASC").Find(&dbConns).Error; err != nil {
+		return nil, err
+	}
+	return dbConns, nil
+}
+
+// candidateConnections renvoie les connexions interrogeables pour cet email : toutes pour un email
+// inconnu localement, et seulement celles auxquelles le compte est déjà rattaché sinon. Une base
+// externe ne peut ainsi ni prendre le contrôle d'un compte local, ni recevoir ses mots de passe.
+func (s *CustomDatabaseService) candidateConnections(connectionName, email string) ([]models.DatabaseConnection, error) {
+	dbConns, err := s.activeDatabaseConnections(connectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing models.User
+	if err := s.DB.Select("id").Where("email = ?", email).First(&existing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dbConns, nil
+		}
+		return nil, err
+	}
+
+	var linked []string
+	if err := s.DB.Model(&models.DatabaseConnectionUser{}).Where("user_id = ?", existing.ID).
+		Pluck("database_connection_id", &linked).Error; err != nil {
+		return nil, err
+	}
+	candidates := dbConns[:0]
+	for _, dbConn := range dbConns {
+		if slices.Contains(linked, dbConn.ID) {
+			candidates = append(candidates, dbConn)
+		}
+	}
+	return candidates, nil
+}
+
+// run exécute une opération via le script d'action configuré, ou à défaut via la requête SQL
+func (s *CustomDatabaseService) run(dbConn *models.DatabaseConnection, operation string, input map[string]interface{}) (*models.CustomDatabaseUser, error) {
+	var scriptID, query *string
+	switch operation {
+	case CustomDatabaseOperationLogin:
+		scriptID, query = dbConn.LoginScriptID, dbConn.LoginQuery
+	case CustomDatabaseOperationGetUser:
+		scriptID, query = dbConn.GetUserScriptID, dbConn.GetUserQuery
+	case CustomDatabaseOperationChangePassword:
+		scriptID, query = dbConn.ChangePasswordScriptID, dbConn.ChangePasswordQuery
+	default:
+		return nil, fmt.Errorf("unknown custom database operation: %s", operation)
+	}
+
+	if scriptID != nil && *scriptID != "" {
+		if databaseScriptRunner == nil {
+			return nil, ErrCustomDatabaseScriptRunnerMissing
+		}
+		output, err := databaseScriptRunner.RunDatabaseScript(*scriptID, operation, input)
+		if err != nil {
+			return nil, err
+		}
+		return customDatabaseRecord(output), nil
+	}
+
+	if query == nil || strings.TrimSpace(*query) == "" {
+		return nil, fmt.Errorf("no query configured for %s", operation)
+	}
+
+	extDB, err := openExternalDatabase(dbConn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Le mot de passe en clair n'est jamais transmis aux requêtes SQL
+	args := make([]interface{}, 0, len(input))
+	for key, value := range input {
+		if key == "password" {
+			continue
+		}
+		args = append(args, sql.Named(key, value))
+	}
+
+	if operation == CustomDatabaseOperationChangePassword {
+		result := extDB.Exec(*query, args...)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil, ErrCustomDatabaseUserNotFound
+		}
+		return nil, nil
+	}
+
+	var rows []map[string]interface{}
+	if err := extDB.Raw(*query, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return customDatabaseRecord(rows[0]), nil
+}
+
+// linkUser crée ou met à jour le compte local associé à un utilisateur externe
+func (s *CustomDatabaseService) linkUser(dbConn *models.DatabaseConnection, record *models.CustomDatabaseUser, loggedIn bool) (*models.User, error) {
+	if record.Email == "" {
+		return nil, errors.New("custom database returned a user without email")
+	}
+
+	var user *models.User
+	created := false
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var existing models.User
+		err := tx.Where("email = ?", record.Email).First(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			// Seul un compte déjà rattaché à cette connexion peut être mis à jour par la base externe
+			var links int64
+			if err := tx.Model(&models.DatabaseConnectionUser{}).
+				Where("database_connection_id = ? AND user_id = ?", dbConn.ID, existing.ID).
+				Count(&links).Error; err != nil {
+				return err
+			}
+			if links == 0 {
+				return ErrCustomDatabaseAccountConflict
+			}
+			// Un compte local désactivé ne peut pas être réactivé par la base externe
+			if !existing.IsActive {
+				return ErrUserInactive
+			}
+		}
+
+		now := time.Now()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			created = true
+			existing = models.User{
+				Email:         &record.Email,
+				EmailVerified: record.EmailVerified,
+				IsActive:      true,
+			}
+			if record.Username != "" {
+				existing.Username = &record.Username
+			}
+			if record.Name != "" {
+				existing.Name = &record.Name
+			}
+		}
+
+		// Migration progressive : le hash hérité est conservé et vérifié par AuthenticateUser
+		if dbConn.ImportMode && loggedIn && record.PasswordHash != "" {
+			existing.PasswordHash = &record.PasswordHash
+		}
+		if loggedIn {
+			existing.LastLoginAt = &now
+		}
+
+		if err := tx.Save(&existing).Error; err != nil {
+			return err
+		}
+
+		var link models.DatabaseConnectionUser
+		err = tx.Where("database_connection_id = ? AND email = ?", dbConn.ID, record.Email).First(&link).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		link.DatabaseConnectionID = dbConn.ID
+		link.Email = record.Email
+		link.Username = record.Username
+		link.IsActive = true
+		link.UserID = &existing.ID
+		if record.ExternalID != "" {
+			link.ExternalID = &record.ExternalID
+		}
+		if dbConn.ImportMode && loggedIn && link.ImportedAt == nil {
+			link.ImportedAt = &now
+		}
+		if loggedIn {
+			link.LastLoginAt = &now
+		}
+		if err := tx.Save(&link).Error; err != nil {
+			return err
+		}
+
+		user = &existing
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if created {
+		NotifyUserProvisioning(s.DB, user.ID, models.ProvisioningEventUserCreated)
+	}
+	return user, nil
+}
+
+// customDatabaseRecord convertit une ligne SQL ou une sortie de script en utilisateur externe
+func customDatabaseRecord(row map[string]interface{}) *models.CustomDatabaseUser {
+	if len(row) == 0 {
+		return nil
+	}
+
+	lookup := func(keys ...string) string {
+		for _, key := range keys {
+			if value, ok := row[key]; ok && value != nil {
+				switch v := value.(type) {
+				case string:
+					return v
+				case []byte:
+					return string(v)
+				default:
+					return fmt.Sprint(v)
+				}
+			}
+		}
+		return ""
+	}
+
+	verified := false
+	switch v := row["email_verified"].(type) {
+	case bool:
+		verified = v
+	case string:
+		verified = v == "true" || v == "t" || v == "1"
+	case int64:
+		verified = v != 0
+	}
+	if v, ok := row["emailVerified"].(bool); ok {
+		verified = v
+	}
+
+	return &models.CustomDatabaseUser{
+		ExternalID:    lookup("id", "user_id", "external_id"),
+		Email:         lookup("email"),
+		Username:      lookup("username"),
+		Name:          lookup("name", "display_name"),
+		PasswordHash:  lookup("password_hash", "passwordHash", "password"),
+		EmailVerified: verified,
+	}
+}
+
+// openExternalDatabase ouvre (ou réutilise) le pool de connexions vers la base externe
+func openExternalDatabase(dbConn *models.DatabaseConnection) (*gorm.DB, error) {
+	externalDatabases.Lock()
+	cached, ok := externalDatabases.conns[dbConn.ID]
+	externalDatabases.Unlock()
+	if ok && cached.updatedAt.Equal(dbConn.UpdatedAt) {
+		return cached.db, nil
+	}
+
+	databaseType := "postgres"
+	if dbConn.DatabaseType != nil && *dbConn.DatabaseType != "" {
+		databaseType = strings.ToLower(*dbConn.DatabaseType)
+	}
+	if databaseType != "postgres" && databaseType != "postgresql" {
+		return nil, fmt.Errorf("unsupported database type: %s", databaseType)
+	}
+
+	// Les identifiants sont encodés dans l'URL : un espace ou une quote ne peut pas injecter de paramètre
+	dsn := (&url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(stringValue(dbConn.Username, ""), stringValue(dbConn.Password, "")),
+		Host:     net.JoinHostPort(stringValue(dbConn.Host, "localhost"), strconv.Itoa(intValue(dbConn.Port, 5432))),
+		Path:     "/" + stringValue(dbConn.Database, ""),
+		RawQuery: url.Values{"sslmode": {stringValue(dbConn.SSLMode, "disable")}}.Encode(),
+	}).String()
+
+	// La connexion est établie hors du verrou : une base lente ne bloque pas les autres connexions
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to custom database: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxIdleConns(intValue(dbConn.MinConnections, 1))
+	sqlDB.SetMaxOpenConns(intValue(dbConn.MaxConnections, 10))
+	sqlDB.SetConnMaxIdleTime(5 * time.Minute)
+
+	externalDatabases.Lock()
+	defer externalDatabases.Unlock()
+	if current, ok := externalDatabases.conns[dbConn.ID]; ok {
+		if current.updatedAt.Equal(dbConn.UpdatedAt) {
+			// Une requête concurrente a ouvert le même pool entre-temps
+			sqlDB.Close()
+			return current.db, nil
+		}
+		if staleDB, err := current.db.DB(); err == nil {
+			staleDB.Close()
+		}
+	}
+	externalDatabases.conns[dbConn.ID] = externalDatabase{db: db, updatedAt: dbConn.UpdatedAt}
+	return db, nil
+}
+
+func stringValue(value *string, fallback string) string {
+	if value == nil || *value == "" {
+		return fallback
+	}
+	return *value
+}
+
+func intValue(value *int, fallback int) int {
+	if value == nil || *value == 0 {
+		return fallback
+	}
+	return *value
+}
@@ -53,6 +53,16 @@ func (s *databaseService) connect() error {
 		return err
 	}
 
+	// Diffusion en direct des logs, événements et entrées d'audit créés
+	if err := registerLogBusCallbacks(s.db); err != nil {
+		return err
+	}
+
+	// Spans des requêtes SQL faites dans le contexte d'une requête tracée
+	if err := registerTracingCallbacks(s.db); err != nil {
+		return err
+	}
+
 	// Configuration du pool de connexions
 	s.sqlDB.SetMaxIdleConns(10)
 	s.sqlDB.SetMaxOpenConns(100)
@@ -84,6 +84,7 @@ func (s *EmailService) VerifyEmail(token string) (*models.User, error) {
 		return nil, err
 	}
 
+	NotifyUserWebhooks(s.DB, models.WebhookEventUserVerified, user.ID, nil)
 	return &user, nil
 }
 
@@ -92,10 +93,16 @@ func (s *EmailService) CreatePasswordReset(email string) (*models.PasswordResetT
 	// Récupérer l'utilisateur
 	var user models.User
 	if err := s.DB.Where("email = ?", email).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		// Rechercher l'utilisateur dans les bases de données externes
+		externalUser, err := NewCustomDatabaseService(s.DB).GetUser(email)
+		if err != nil {
 			return nil, errors.New("user not found")
 		}
-		return nil, err
+		user = *externalUser
 	}
 
 	// Supprimer les anciens tokens non utilisés
@@ -121,13 +128,13 @@ func (s *EmailService) CreatePasswordReset(email string) (*models.PasswordResetT
 }
 
 // ResetPassword réinitialise le mot de passe avec un token
-func (s *EmailService) ResetPassword(token, newPassword string) error {
+func (s *EmailService) ResetPassword(token, newPassword string) (*models.User, error) {
 	var reset models.PasswordResetToken
 	if err := s.DB.Where("token = ? AND used = false AND expires_at > ?", token, time.Now()).First(&reset).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("invalid or expired token")
+			return nil, errors.New("invalid or expired token")
 		}
-		return err
+		return nil, err
 	}
 
 	// Marquer le token comme utilisé
@@ -135,17 +142,20 @@ func (s *EmailService) ResetPassword(token, newPassword string) error {
 	reset.Used = true
 	reset.UsedAt = &now
 	if err := s.DB.Save(&reset).Error; err != nil {
-		return err
+		return nil, err
 	}
 
 	// Mettre à jour le mot de passe utilisateur
 	userService := NewUserService(s.DB)
 	user, err := userService.GetUserByID(reset.UserID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return userService.UpdateUser(user, &newPassword)
+	if err := userService.UpdateUser(user, &newPassword); err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
 // SendEmailVerificationEmail envoie un email de vérification (simulation)
@@ -215,5 +225,9 @@ func (s *EmailService) RevokeRefreshToken(token string) error {
 	refreshToken.Revoked = true
 	refreshToken.RevokedAt = &now
 
-	return s.DB.Save(&refreshToken).Error
+	if err := s.DB.Save(&refreshToken).Error; err != nil {
+		return err
+	}
+	NotifyUserWebhooks(s.DB, models.WebhookEventSessionRevoked, refreshToken.UserID, map[string]interface{}{"client_id": refreshToken.ClientID})
+	return nil
 }
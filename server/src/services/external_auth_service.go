@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
@@ -26,8 +27,12 @@ type ExternalAuthService struct {
 	DB         *gorm.DB
 	Config     *config.OAuthProvidersConfig
 	EncryptKey []byte
+	ctx        context.Context // Contexte de la requête en cours, pour tracer les appels aux providers
 }
 
+// providerHTTPClient trace les appels aux providers externes
+var providerHTTPClient = NewTracedHTTPClient(10 * time.Second)
+
 // NewExternalAuthService crée une nouvelle instance du service
 func NewExternalAuthService(db *gorm.DB, encryptKey string) *ExternalAuthService {
 	key := []byte(encryptKey)
@@ -46,6 +51,24 @@ func NewExternalAuthService(db *gorm.DB, encryptKey string) *ExternalAuthService
 	}
 }
 
+// WithContext renvoie une copie du service dont les appels aux providers et les requêtes SQL
+// sont rattachés au contexte donné, celui de la requête HTTP en cours
+func (s *ExternalAuthService) WithContext(ctx context.Context) *ExternalAuthService {
+	scoped := *s
+	scoped.ctx = ctx
+	if s.DB != nil {
+		scoped.DB = s.DB.WithContext(ctx)
+	}
+	return &scoped
+}
+
+func (s *ExternalAuthService) context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
 // GenerateOAuthURL génère l'URL d'authentification pour un provider
 func (s *ExternalAuthService) GenerateOAuthURL(provider, action string, userID *uint) (string, string, error) {
 	providerConfig := s.Config.GetProviderConfig(provider)
@@ -125,7 +148,7 @@ func (s *ExternalAuthService) ExchangeCode(provider, code string) (*TokenExchang
 	params.Set("client_id", providerConfig.ClientID)
 	params.Set("client_secret", providerConfig.ClientSecret)
 
-	req, err := http.NewRequest("POST", providerConfig.TokenURL, strings.NewReader(params.Encode()))
+	req, err := http.NewRequestWithContext(s.context(), "POST", providerConfig.TokenURL, strings.NewReader(params.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -133,8 +156,7 @@ func (s *ExternalAuthService) ExchangeCode(provider, code string) (*TokenExchang
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Accept", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := providerHTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -180,7 +202,7 @@ func (s *ExternalAuthService) GetUserInfo(provider, accessToken string) (*models
 
 // getGitHubUserInfo récupère les infos utilisateur GitHub
 func (s *ExternalAuthService) getGitHubUserInfo(accessToken string) (*models.ProviderUserInfo, error) {
-	req, err := http.NewRequest("GET", "https://api.github.com/user", nil)
+	req, err := http.NewRequestWithContext(s.context(), "GET", "https://api.github.com/user", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -188,8 +210,7 @@ func (s *ExternalAuthService) getGitHubUserInfo(accessToken string) (*models.Pro
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := providerHTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -228,7 +249,7 @@ func (s *ExternalAuthService) getGitHubUserInfo(accessToken string) (*models.Pro
 
 // getGitHubPrimaryEmail récupère l'email principal de GitHub
 func (s *ExternalAuthService) getGitHubPrimaryEmail(accessToken string) (string, error) {
-	req, err := http.NewRequest("GET", "https://api.github.com/user/emails", nil)
+	req, err := http.NewRequestWithContext(s.context(), "GET", "https://api.github.com/user/emails", nil)
 	if err != nil {
 		return "", err
 	}
@@ -236,8 +257,7 @@ func (s *ExternalAuthService) getGitHubPrimaryEmail(accessToken string) (string,
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := providerHTTPClient.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -264,15 +284,14 @@ func (s *ExternalAuthService) getGitHubPrimaryEmail(accessToken string) (string,
 
 // getGoogleUserInfo récupère les infos utilisateur Google
 func (s *ExternalAuthService) getGoogleUserInfo(accessToken string) (*models.ProviderUserInfo, error) {
-	req, err := http.NewRequest("GET", "https://www.googleapis.com/oauth2/v2/userinfo", nil)
+	req, err := http.NewRequestWithContext(s.context(), "GET", "https://www.googleapis.com/oauth2/v2/userinfo", nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := providerHTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -307,15 +326,14 @@ func (s *ExternalAuthService) getGoogleUserInfo(accessToken string) (*models.Pro
 
 // getMicrosoftUserInfo récupère les infos utilisateur Microsoft
 func (s *ExternalAuthService) getMicrosoftUserInfo(accessToken string) (*models.ProviderUserInfo, error) {
-	req, err := http.NewRequest("GET", "https://graph.microsoft.com/v1.0/me", nil)
+	req, err := http.NewRequestWithContext(s.context(), "GET", "https://graph.microsoft.com/v1.0/me", nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := providerHTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -353,15 +371,14 @@ func (s *ExternalAuthService) getMicrosoftUserInfo(accessToken string) (*models.
 
 // getDiscordUserInfo récupère les infos utilisateur Discord
 func (s *ExternalAuthService) getDiscordUserInfo(accessToken string) (*models.ProviderUserInfo, error) {
-	req, err := http.NewRequest("GET", "https://discord.com/api/users/@me", nil)
+	req, err := http.NewRequestWithContext(s.context(), "GET", "https://discord.com/api/users/@me", nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := providerHTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -653,7 +670,7 @@ func (s *ExternalAuthService) RefreshAccessToken(userID string, provider string)
 	params.Set("client_id", providerConfig.ClientID)
 	params.Set("client_secret", providerConfig.ClientSecret)
 
-	req, err := http.NewRequest("POST", providerConfig.TokenURL, bytes.NewBufferString(params.Encode()))
+	req, err := http.NewRequestWithContext(s.context(), "POST", providerConfig.TokenURL, bytes.NewBufferString(params.Encode()))
 	if err != nil {
 		return "", err
 	}
@@ -661,8 +678,7 @@ func (s *ExternalAuthService) RefreshAccessToken(userID string, provider string)
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Accept", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := providerHTTPClient.Do(req)
 	if err != nil {
 		return "", err
 	}
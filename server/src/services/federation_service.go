@@ -0,0 +1,686 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"gorm.io/gorm"
+)
+
+// ClientAssertionTypeJWTBearer est le type d'assertion client RFC 7523 accepté par /oauth/token
+const ClientAssertionTypeJWTBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// Grant et types de jetons de l'échange de jeton RFC 8693
+const (
+	GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	TokenTypeJWT           = "urn:ietf:params:oauth:token-type:jwt"
+	TokenTypeIDToken       = "urn:ietf:params:oauth:token-type:id_token"
+	TokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+const (
+	federationJWKSCacheTTL     = 10 * time.Minute
+	federationJWKSMinRefresh   = 30 * time.Second
+	federationMaxResponseBytes = 1 << 20
+)
+
+var (
+	ErrFederationInvalidAssertion = errors.New("invalid client assertion")
+	ErrFederationUntrustedIssuer  = errors.New("assertion issuer is not trusted")
+	ErrFederationUnknownSubject   = errors.New("no identity binding for assertion subject")
+	ErrFederationInactiveClient   = errors.New("bound client is not active")
+	ErrFederationInvalidIssuer    = errors.New("invalid federated issuer")
+	ErrFederationInvalidBinding   = errors.New("invalid federated identity binding")
+	ErrFederationInvalidPolicy    = errors.New("invalid federation trust policy")
+	ErrFederationNoPolicy         = errors.New("no trust policy matches the token claims")
+)
+
+// federationSigningMethods sont les algorithmes asymétriques acceptés pour les assertions ;
+// HS256 est exclu car un émetteur externe ne partage pas de secret avec Aether Identity
+var federationSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// FederatedIdentity est le résultat de la validation d'une assertion fédérée
+type FederatedIdentity struct {
+	Issuer     *models.FederatedIssuer
+	Binding    *models.FederatedIdentityBinding // Assertion client (sujet lié)
+	Policy     *models.FederationTrustPolicy    // Échange de jeton CI (politique de confiance)
+	Subject    string
+	ClientID   string
+	Scopes     []string // Scopes que l'identité peut obtenir
+	Claims     jwt.MapClaims
+	Attributes CIAttributes
+}
+
+// federationKeySet est un JWKS décodé et mis en cache pour un émetteur
+type federationKeySet struct {
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// federationKeyCache partage les JWKS distants entre les requêtes
+var federationKeyCache = struct {
+	sync.Mutex
+	sets map[string]*federationKeySet
+}{sets: make(map[string]*federationKeySet)}
+
+// FederationService valide les jetons d'émetteurs externes (comptes de service Kubernetes,
+// fournisseurs OIDC) et les associe aux clients OAuth par leurs liaisons
+type FederationService struct {
+	DB         *gorm.DB
+	HTTPClient *http.Client
+}
+
+// NewFederationService crée une nouvelle instance de FederationService
+func NewFederationService(db *gorm.DB) *FederationService {
+	return &FederationService{
+		DB:         db,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ListIssuers liste les émetteurs fédérés
+func (s *FederationService) ListIssuers() ([]models.FederatedIssuer, error) {
+	var issuers []models.FederatedIssuer
+	err := s.DB.Order("name ASC").Find(&issuers).Error
+	return issuers, err
+}
+
+// GetIssuer récupère un émetteur fédéré et ses liaisons
+func (s *FederationService) GetIssuer(id string) (*models.FederatedIssuer, error) {
+	var issuer models.FederatedIssuer
+	if err := s.DB.Preload("Bindings").Preload("Policies").Where("id = ?", id).First(&issuer).Error; err != nil {
+		return nil, err
+	}
+	return &issuer, nil
+}
+
+// SaveIssuer crée ou met à jour un émetteur fédéré
+func (s *FederationService) SaveIssuer(issuer *models.FederatedIssuer) error {
+	issuer.Issuer = strings.TrimSuffix(strings.TrimSpace(issuer.Issuer), "/")
+	if issuer.Name == "" || issuer.Issuer == "" {
+		return fmt.Errorf("%w: name and issuer are required", ErrFederationInvalidIssuer)
+	}
+	if len(issuer.Audiences) == 0 {
+		return fmt.Errorf("%w: at least one audience is required", ErrFederationInvalidIssuer)
+	}
+	switch issuer.Type {
+	case "":
+		issuer.Type = models.FederatedIssuerOIDC
+	case models.FederatedIssuerKubernetes, models.FederatedIssuerOIDC, models.FederatedIssuerGitHub,
+		models.FederatedIssuerGitLab, models.FederatedIssuerGitea, models.FederatedIssuerJenkins:
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrFederationInvalidIssuer, issuer.Type)
+	}
+	if issuer.JWKS != nil {
+		if _, err := decodeJWKS(issuer.JWKS); err != nil {
+			return fmt.Errorf("%w: %v", ErrFederationInvalidIssuer, err)
+		}
+	}
+
+	if err := s.DB.Save(issuer).Error; err != nil {
+		return err
+	}
+	invalidateFederationKeys(issuer.ID)
+	return nil
+}
+
+// DeleteIssuer supprime un émetteur fédéré et ses liaisons
+func (s *FederationService) DeleteIssuer(id string) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("issuer_id = ?", id).Delete(&models.FederatedIdentityBinding{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("issuer_id = ?", id).Delete(&models.FederationTrustPolicy{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ?", id).Delete(&models.FederatedIssuer{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		invalidateFederationKeys(id)
+		return nil
+	})
+}
+
+// SaveBinding crée ou met à jour la liaison d'un sujet vers un client OAuth ou une application
+func (s *FederationService) SaveBinding(binding *models.FederatedIdentityBinding) error {
+	if binding.Subject == "" {
+		return fmt.Errorf("%w: subject is required", ErrFederationInvalidBinding)
+	}
+	if (binding.ClientID == nil) == (binding.ApplicationID == nil) {
+		return fmt.Errorf("%w: exactly one of clientId and applicationId is required", ErrFederationInvalidBinding)
+	}
+
+	var issuer models.FederatedIssuer
+	if err := s.DB.Where("id = ?", binding.IssuerID).First(&issuer).Error; err != nil {
+		return err
+	}
+	if issuer.Type == models.FederatedIssuerKubernetes && !strings.HasPrefix(binding.Subject, "system:serviceaccount:") {
+		return fmt.Errorf("%w: kubernetes subjects must be system:serviceaccount:<namespace>:<name>", ErrFederationInvalidBinding)
+	}
+
+	if err := s.checkTarget(binding.ClientID, binding.ApplicationID); err != nil {
+		return fmt.Errorf("%w: %v", ErrFederationInvalidBinding, err)
+	}
+
+	return s.DB.Save(binding).Error
+}
+
+// SavePolicy crée ou met à jour une politique de confiance d'un fournisseur CI
+func (s *FederationService) SavePolicy(policy *models.FederationTrustPolicy) error {
+	if policy.Name == "" || policy.Repository == "" {
+		return fmt.Errorf("%w: name and repository are required", ErrFederationInvalidPolicy)
+	}
+	if (policy.ClientID == nil) == (policy.ApplicationID == nil) {
+		return fmt.Errorf("%w: exactly one of clientId and applicationId is required", ErrFederationInvalidPolicy)
+	}
+
+	conditions, err := decodePolicyClaims(policy.Claims)
+	if err != nil {
+		return fmt.Errorf("%w: claims must map claim names to patterns", ErrFederationInvalidPolicy)
+	}
+	patterns := []string{policy.Repository, policy.Branch, policy.Environment}
+	for _, pattern := range conditions {
+		patterns = append(patterns, pattern)
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: invalid pattern %q", ErrFederationInvalidPolicy, pattern)
+		}
+	}
+
+	var issuer models.FederatedIssuer
+	if err := s.DB.Where("id = ?", policy.IssuerID).First(&issuer).Error; err != nil {
+		return err
+	}
+	if issuer.Type == models.FederatedIssuerKubernetes {
+		return fmt.Errorf("%w: kubernetes issuers use identity bindings", ErrFederationInvalidPolicy)
+	}
+
+	if err := s.checkTarget(policy.ClientID, policy.ApplicationID); err != nil {
+		return fmt.Errorf("%w: %v", ErrFederationInvalidPolicy, err)
+	}
+
+	return s.DB.Save(policy).Error
+}
+
+// DeletePolicy supprime une politique de confiance d'un émetteur
+func (s *FederationService) DeletePolicy(issuerID, id string) error {
+	result := s.DB.Where("id = ? AND issuer_id = ?", id, issuerID).Delete(&models.FederationTrustPolicy{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteBinding supprime une liaison d'un émetteur
+func (s *FederationService) DeleteBinding(issuerID, id string) error {
+	result := s.DB.Where("id = ? AND issuer_id = ?", id, issuerID).Delete(&models.FederatedIdentityBinding{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// checkTarget vérifie que le client ou l'application cible d'une liaison existe
+func (s *FederationService) checkTarget(clientID, applicationID *string) error {
+	if clientID != nil {
+		if err := s.DB.Where("client_id = ?", *clientID).First(&models.OAuthClient{}).Error; err != nil {
+			return fmt.Errorf("unknown client %q", *clientID)
+		}
+		return nil
+	}
+	if err := s.DB.Where("id = ?", *applicationID).First(&models.Application{}).Error; err != nil {
+		return fmt.Errorf("unknown application %q", *applicationID)
+	}
+	return nil
+}
+
+// ValidateAssertion vérifie une assertion client puis résout le client OAuth lié à son sujet
+func (s *FederationService) ValidateAssertion(ctx context.Context, assertion string) (*FederatedIdentity, error) {
+	issuer, claims, err := s.verifyToken(ctx, assertion)
+	if err != nil {
+		return nil, err
+	}
+	subject, _ := claims.GetSubject()
+
+	var binding models.FederatedIdentityBinding
+	err = s.DB.Where("issuer_id = ? AND subject = ? AND is_active = ?", issuer.ID, subject, true).First(&binding).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFederationUnknownSubject
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &FederatedIdentity{
+		Issuer:  issuer,
+		Binding: &binding,
+		Subject: subject,
+		Claims:  claims,
+	}
+	if err := s.resolveClient(identity, binding.ClientID, binding.ApplicationID, binding.Scopes); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// ExchangeSubjectToken vérifie le jeton OIDC d'un fournisseur CI et retourne l'identité de la
+// première politique de confiance (par nom) dont le dépôt, la branche et l'environnement correspondent
+func (s *FederationService) ExchangeSubjectToken(ctx context.Context, subjectToken string) (*FederatedIdentity, error) {
+	issuer, claims, err := s.verifyToken(ctx, subjectToken)
+	if err != nil {
+		return nil, err
+	}
+	if issuer.Type == models.FederatedIssuerKubernetes {
+		return nil, ErrFederationNoPolicy
+	}
+	subject, _ := claims.GetSubject()
+
+	var policies []models.FederationTrustPolicy
+	if err := s.DB.Where("issuer_id = ? AND is_active = ?", issuer.ID, true).Order("name ASC").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+
+	attributes := ciAttributes(issuer.Type, claims)
+	for i := range policies {
+		policy := &policies[i]
+		if !policyMatches(policy, attributes, claims) {
+			continue
+		}
+
+		identity := &FederatedIdentity{
+			Issuer:     issuer,
+			Policy:     policy,
+			Subject:    subject,
+			Claims:     claims,
+			Attributes: attributes,
+		}
+		if err := s.resolveClient(identity, policy.ClientID, policy.ApplicationID, policy.Scopes); err != nil {
+			return nil, err
+		}
+		return identity, nil
+	}
+
+	return nil, ErrFederationNoPolicy
+}
+
+// verifyToken vérifie la signature, l'émetteur, l'audience et l'expiration d'un jeton fédéré
+func (s *FederationService) verifyToken(ctx context.Context, token string) (*models.FederatedIssuer, jwt.MapClaims, error) {
+	unverified, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrFederationInvalidAssertion, err)
+	}
+	iss, _ := unverified.Claims.GetIssuer()
+	if iss == "" {
+		return nil, nil, fmt.Errorf("%w: missing iss claim", ErrFederationInvalidAssertion)
+	}
+
+	var issuer models.FederatedIssuer
+	err = s.DB.Where("issuer = ? AND is_active = ?", strings.TrimSuffix(iss, "/"), true).First(&issuer).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrFederationUntrustedIssuer
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.issuerKey(ctx, &issuer, kid)
+	},
+		jwt.WithValidMethods(federationSigningMethods),
+		jwt.WithIssuer(iss),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrFederationInvalidAssertion, err)
+	}
+
+	audiences, _ := claims.GetAudience()
+	if !slices.ContainsFunc(audiences, func(aud string) bool { return slices.Contains(issuer.Audiences, aud) }) {
+		return nil, nil, fmt.Errorf("%w: audience not accepted", ErrFederationInvalidAssertion)
+	}
+
+	if subject, _ := claims.GetSubject(); subject == "" {
+		return nil, nil, fmt.Errorf("%w: missing sub claim", ErrFederationInvalidAssertion)
+	}
+
+	return &issuer, claims, nil
+}
+
+// resolveClient renseigne le client et les scopes autorisés de l'identité ;
+// scopes borne les scopes délivrés et vaut, vide, ceux du client
+func (s *FederationService) resolveClient(identity *FederatedIdentity, clientID, applicationID *string, scopes []string) error {
+	if clientID != nil {
+		var client models.OAuthClient
+		if err := s.DB.Where("client_id = ? AND is_active = ?", *clientID, true).First(&client).Error; err != nil {
+			return ErrFederationInactiveClient
+		}
+		identity.ClientID = client.ClientID
+		identity.Scopes = client.Scopes
+	} else {
+		var application models.Application
+		if err := s.DB.Where("id = ? AND is_active = ?", *applicationID, true).First(&application).Error; err != nil {
+			return ErrFederationInactiveClient
+		}
+		identity.ClientID = application.ClientID
+		identity.Scopes = []string{"api"}
+	}
+
+	if len(scopes) > 0 {
+		identity.Scopes = scopes
+	}
+	return nil
+}
+
+// CIAttributes sont les claims d'un jeton CI comparés aux politiques de confiance
+type CIAttributes struct {
+	Repository  string `json:"repository,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	Environment string `json:"environment,omitempty"`
+}
+
+// ciAttributes extrait dépôt, branche et environnement des claims selon le fournisseur CI.
+// Les émetteurs génériques (oidc, jenkins) utilisent les claims repository, branch (ou ref) et environment.
+func ciAttributes(issuerType models.FederatedIssuerType, claims jwt.MapClaims) CIAttributes {
+	var attributes CIAttributes
+	switch issuerType {
+	case models.FederatedIssuerGitLab:
+		attributes.Repository = stringClaim(claims, "project_path")
+		if stringClaim(claims, "ref_type") == "branch" {
+			attributes.Branch = stringClaim(claims, "ref")
+		}
+	default:
+		attributes.Repository = stringClaim(claims, "repository")
+		attributes.Branch = stringClaim(claims, "branch")
+		if ref := stringClaim(claims, "ref"); attributes.Branch == "" && strings.HasPrefix(ref, "refs/heads/") {
+			attributes.Branch = strings.TrimPrefix(ref, "refs/heads/")
+		}
+	}
+	attributes.Environment = stringClaim(claims, "environment")
+	return attributes
+}
+
+// policyMatches vérifie les motifs de la politique ; un motif vide accepte toute valeur,
+// y compris l'absence de claim (un tag n'a pas de branche, un job sans environnement n'en a pas)
+func policyMatches(policy *models.FederationTrustPolicy, attributes CIAttributes, claims jwt.MapClaims) bool {
+	if !matchPattern(policy.Repository, attributes.Repository) ||
+		!matchPattern(policy.Branch, attributes.Branch) ||
+		!matchPattern(policy.Environment, attributes.Environment) {
+		return false
+	}
+
+	conditions, err := decodePolicyClaims(policy.Claims)
+	if err != nil {
+		return false
+	}
+	for name, pattern := range conditions {
+		if !matchPattern(pattern, stringClaim(claims, name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPattern compare une valeur à un motif path.Match ; une valeur vide ne correspond qu'au motif vide
+func matchPattern(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	if value == "" {
+		return false
+	}
+	ok, err := path.Match(pattern, value)
+	return err == nil && ok
+}
+
+// stringClaim retourne un claim de type chaîne, vide sinon
+func stringClaim(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// issuerKey retourne la clé publique kid de l'émetteur. Un kid inconnu force un
+// rechargement du JWKS distant pour suivre les rotations de clés.
+func (s *FederationService) issuerKey(ctx context.Context, issuer *models.FederatedIssuer, kid string) (crypto.PublicKey, error) {
+	if issuer.JWKS != nil {
+		keys, err := decodeJWKS(issuer.JWKS)
+		if err != nil {
+			return nil, err
+		}
+		return selectFederationKey(keys, kid)
+	}
+
+	federationKeyCache.Lock()
+	set := federationKeyCache.sets[issuer.ID]
+	federationKeyCache.Unlock()
+
+	if set != nil && time.Since(set.fetchedAt) < federationJWKSCacheTTL {
+		if key, err := selectFederationKey(set.keys, kid); err == nil || time.Since(set.fetchedAt) < federationJWKSMinRefresh {
+			return key, err
+		}
+	}
+
+	keys, err := s.fetchJWKS(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	federationKeyCache.Lock()
+	federationKeyCache.sets[issuer.ID] = &federationKeySet{keys: keys, fetchedAt: time.Now()}
+	federationKeyCache.Unlock()
+
+	return selectFederationKey(keys, kid)
+}
+
+// fetchJWKS télécharge le JWKS de l'émetteur, via la découverte OpenID si aucune URI n'est configurée
+func (s *FederationService) fetchJWKS(ctx context.Context, issuer *models.FederatedIssuer) (map[string]crypto.PublicKey, error) {
+	jwksURI := ""
+	if issuer.JWKSURI != nil {
+		jwksURI = *issuer.JWKSURI
+	}
+
+	if jwksURI == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := s.getJSON(ctx, issuer.Issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+			return nil, fmt.Errorf("openid discovery failed for %s: %w", issuer.Issuer, err)
+		}
+		if discovery.JWKSURI == "" {
+			return nil, fmt.Errorf("openid discovery for %s has no jwks_uri", issuer.Issuer)
+		}
+		jwksURI = discovery.JWKSURI
+	}
+
+	var raw interface{}
+	if err := s.getJSON(ctx, jwksURI, &raw); err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS %s: %w", jwksURI, err)
+	}
+	return decodeJWKS(raw)
+}
+
+// getJSON décode la réponse JSON d'une requête GET
+func (s *FederationService) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, federationMaxResponseBytes)).Decode(v)
+}
+
+// invalidateFederationKeys oublie le JWKS en cache d'un émetteur
+func invalidateFederationKeys(issuerID string) {
+	federationKeyCache.Lock()
+	delete(federationKeyCache.sets, issuerID)
+	federationKeyCache.Unlock()
+}
+
+// selectFederationKey choisit la clé kid ; sans kid, le JWKS doit contenir une seule clé
+func selectFederationKey(keys map[string]crypto.PublicKey, kid string) (crypto.PublicKey, error) {
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// federationJWK est une clé publique JWK (RFC 7517) RSA ou EC
+type federationJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// decodeJWKS décode un JWKS en clés publiques indexées par kid ; les clés de chiffrement
+// et les types inconnus sont ignorés
+func decodeJWKS(raw interface{}) (map[string]crypto.PublicKey, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []federationJWK `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWK %q: %w", jwk.Kid, err)
+		}
+		if key != nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no signing keys")
+	}
+	return keys, nil
+}
+
+// publicKey convertit la JWK en clé publique ; nil pour un type de clé non géré
+func (k federationJWK) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// decodeJWKInt décode un entier base64url d'une JWK
+func decodeJWKInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// decodePolicyClaims décode les conditions supplémentaires d'une politique
+func decodePolicyClaims(raw interface{}) (map[string]string, error) {
+	conditions := map[string]string{}
+	if raw == nil {
+		return conditions, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &conditions); err != nil {
+		return nil, err
+	}
+	return conditions, nil
+}
@@ -0,0 +1,452 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/config"
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"gorm.io/gorm"
+)
+
+// États de santé, alignés sur MonitoringStatus ; HealthStarting précède la première série de sondes
+const (
+	HealthHealthy  = "healthy"
+	HealthDegraded = "degraded"
+	HealthDown     = "down"
+	HealthStarting = "starting"
+)
+
+const (
+	healthMonitorPrefix   = "health:"
+	healthProbeTimeout    = 5 * time.Second
+	defaultSlowThreshold  = time.Second
+	defaultHealthInterval = 30 * time.Second
+)
+
+// HealthProbe vérifie une dépendance du serveur. Une sonde critique en échec rend le serveur non prêt ;
+// les autres ne font que le signaler dégradé.
+type HealthProbe struct {
+	Name     string
+	Critical bool
+	Timeout  time.Duration // healthProbeTimeout si nul
+	Check    func(ctx context.Context) error
+}
+
+// HealthCheckResult est le dernier résultat d'une sonde
+type HealthCheckResult struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Critical  bool      `json:"critical"`
+	LatencyMs float64   `json:"latencyMs"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// HealthReport agrège les derniers résultats de toutes les sondes
+type HealthReport struct {
+	Status    string              `json:"status"`
+	CheckedAt *time.Time          `json:"checkedAt,omitempty"`
+	Checks    []HealthCheckResult `json:"checks"`
+}
+
+// HealthMonitor exécute les sondes en arrière-plan et conserve leurs résultats, pour que les
+// endpoints de santé répondent sans interroger les dépendances
+type HealthMonitor struct {
+	mu            sync.RWMutex
+	probes        []HealthProbe
+	slowThreshold time.Duration // Au-delà, une sonde réussie est signalée dégradée
+	report        HealthReport
+	db            *gorm.DB
+}
+
+// DefaultHealthMonitor est le moniteur interrogé par les endpoints de santé
+var DefaultHealthMonitor = NewHealthMonitor()
+
+// NewHealthMonitor crée un moniteur sans sonde
+func NewHealthMonitor() *HealthMonitor {
+	return &HealthMonitor{
+		slowThreshold: defaultSlowThreshold,
+		report:        HealthReport{Status: HealthStarting, Checks: []HealthCheckResult{}},
+	}
+}
+
+// Register ajoute une sonde, ou remplace celle qui porte le même nom
+func (m *HealthMonitor) Register(probe HealthProbe) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.probes {
+		if m.probes[i].Name == probe.Name {
+			m.probes[i] = probe
+			return
+		}
+	}
+	m.probes = append(m.probes, probe)
+}
+
+// ConfigureDefaults enregistre les sondes des dépendances configurées : base de données (critique),
+// SMTP, Redis et providers OAuth externes. db peut être nil en mode sans base.
+func (m *HealthMonitor) ConfigureDefaults(db *gorm.DB, cfg *config.Config) {
+	m.mu.Lock()
+	m.db = db
+	if cfg.HealthSlowThresholdMs > 0 {
+		m.slowThreshold = time.Duration(cfg.HealthSlowThresholdMs) * time.Millisecond
+	}
+	m.mu.Unlock()
+
+	if db != nil {
+		m.Register(NewDatabaseProbe(db))
+	}
+	if cfg.SMTPHost != "" {
+		m.Register(NewSMTPProbe(net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort)))
+	}
+	if cfg.RedisURL != "" {
+		m.Register(NewRedisProbe(cfg.RedisURL))
+	}
+	providers := config.LoadOAuthProvidersConfig()
+	for _, provider := range []*config.OAuthProviderConfig{providers.GitHub, providers.Google, providers.Microsoft, providers.Discord} {
+		if provider != nil {
+			m.Register(NewHTTPProbe("oauth:"+provider.Name, provider.AuthURL))
+		}
+	}
+}
+
+// Start lance les sondes immédiatement puis à intervalle régulier ; un intervalle nul ou négatif
+// retombe sur defaultHealthInterval plutôt que de faire paniquer time.NewTicker
+func (m *HealthMonitor) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultHealthInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			m.RunChecks(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunChecks exécute toutes les sondes en parallèle, met à jour le rapport et les entrées MonitoringStatus
+func (m *HealthMonitor) RunChecks(ctx context.Context) HealthReport {
+	m.mu.RLock()
+	probes := append([]HealthProbe(nil), m.probes...)
+	slowThreshold := m.slowThreshold
+	m.mu.RUnlock()
+
+	results := make([]HealthCheckResult, len(probes))
+	var wg sync.WaitGroup
+	for i, probe := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runProbe(ctx, probe, slowThreshold)
+		}()
+	}
+	wg.Wait()
+
+	now := time.Now()
+	report := HealthReport{Status: aggregateHealth(results), CheckedAt: &now, Checks: results}
+
+	m.mu.Lock()
+	previous := m.report
+	m.report = report
+	db := m.db
+	m.mu.Unlock()
+
+	logHealthTransitions(previous, report)
+	if db != nil {
+		recordHealthStatuses(db, report)
+	}
+	return report
+}
+
+// Report retourne le dernier rapport, sans exécuter de sonde
+func (m *HealthMonitor) Report() HealthReport {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	report := m.report
+	report.Checks = append([]HealthCheckResult{}, m.report.Checks...)
+	return report
+}
+
+// Uptime retourne la durée écoulée depuis le démarrage du processus
+func Uptime() time.Duration {
+	return time.Since(processStart)
+}
+
+// Ready indique si le serveur peut recevoir du trafic : les sondes ont tourné et aucune sonde critique n'est en échec
+func (m *HealthMonitor) Ready() bool {
+	status := m.Report().Status
+	return status == HealthHealthy || status == HealthDegraded
+}
+
+func runProbe(ctx context.Context, probe HealthProbe, slowThreshold time.Duration) HealthCheckResult {
+	timeout := probe.Timeout
+	if timeout <= 0 {
+		timeout = healthProbeTimeout
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := probe.Check(probeCtx)
+	latency := time.Since(start)
+
+	result := HealthCheckResult{
+		Name:      probe.Name,
+		Status:    HealthHealthy,
+		Critical:  probe.Critical,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+		CheckedAt: start,
+	}
+	switch {
+	case err != nil:
+		result.Status = HealthDown
+		result.Error = truncateString(err.Error(), 500)
+	case latency > slowThreshold:
+		result.Status = HealthDegraded
+		result.Error = fmt.Sprintf("slow response: %s exceeds %s", latency.Round(time.Millisecond), slowThreshold)
+	}
+	return result
+}
+
+// aggregateHealth : une sonde critique en échec met le serveur hors service, toute autre anomalie le dégrade
+func aggregateHealth(results []HealthCheckResult) string {
+	status := HealthHealthy
+	for _, result := range results {
+		if result.Status == HealthDown && result.Critical {
+			return HealthDown
+		}
+		if result.Status != HealthHealthy {
+			status = HealthDegraded
+		}
+	}
+	return status
+}
+
+// logHealthTransitions signale dans la sortie du serveur chaque changement d'état d'une dépendance
+func logHealthTransitions(previous, current HealthReport) {
+	before := make(map[string]string, len(previous.Checks))
+	for _, result := range previous.Checks {
+		before[result.Name] = result.Status
+	}
+	for _, result := range current.Checks {
+		if before[result.Name] == result.Status || (before[result.Name] == "" && result.Status == HealthHealthy) {
+			continue
+		}
+		if result.Error != "" {
+			fmt.Printf("Health: %s is %s: %s\n", result.Name, result.Status, result.Error)
+		} else {
+			fmt.Printf("Health: %s is %s\n", result.Name, result.Status)
+		}
+	}
+	if previous.Status != current.Status && previous.Status != HealthStarting {
+		fmt.Printf("Health: server is %s\n", current.Status)
+	}
+}
+
+// recordHealthStatuses écrit l'état de chaque dépendance dans MonitoringStatus ("health:<sonde>"),
+// ainsi que l'état global sous le nom du service. Les échecs d'écriture sont ignorés : si la base
+// est indisponible, sa sonde le signale déjà.
+func recordHealthStatuses(db *gorm.DB, report HealthReport) {
+	save := func(service, status string, details *string) {
+		monitor := models.MonitoringStatus{Service: service}
+		if err := db.Where("service = ?", service).FirstOrInit(&monitor).Error; err != nil {
+			return
+		}
+		monitor.Status = status
+		monitor.IsEnabled = true
+		monitor.LastCheck = report.CheckedAt
+		monitor.Details = details
+		_ = db.Save(&monitor).Error
+	}
+
+	var failing []string
+	for _, result := range report.Checks {
+		var details *string
+		if result.Error != "" {
+			message := result.Error
+			details = &message
+			failing = append(failing, result.Name)
+		}
+		save(healthMonitorPrefix+result.Name, result.Status, details)
+	}
+
+	var details *string
+	if len(failing) > 0 {
+		message := "Failing dependencies: " + strings.Join(failing, ", ")
+		details = &message
+	}
+	save(logSinkSource, report.Status, details)
+}
+
+// NewDatabaseProbe vérifie que la base répond à une requête
+func NewDatabaseProbe(db *gorm.DB) HealthProbe {
+	return HealthProbe{
+		Name:     "database",
+		Critical: true,
+		Check: func(ctx context.Context) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		},
+	}
+}
+
+// NewSMTPProbe vérifie que le serveur SMTP accepte une connexion et envoie sa bannière 220.
+// Le port 465 est contacté en TLS implicite.
+func NewSMTPProbe(address string) HealthProbe {
+	return HealthProbe{
+		Name: "smtp",
+		Check: func(ctx context.Context) error {
+			conn, err := dialProbe(ctx, address, strings.HasSuffix(address, ":465"))
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			reader := bufio.NewReader(conn)
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return fmt.Errorf("reading greeting: %w", err)
+				}
+				if !strings.HasPrefix(line, "220") {
+					return fmt.Errorf("unexpected greeting: %s", strings.TrimSpace(line))
+				}
+				// Une bannière sur plusieurs lignes se poursuit par "220-"
+				if !strings.HasPrefix(line, "220-") {
+					break
+				}
+			}
+			_, _ = conn.Write([]byte("QUIT\r\n"))
+			return nil
+		},
+	}
+}
+
+// NewRedisProbe envoie PING au serveur Redis (redis:// ou rediss://), après AUTH si l'URL porte des identifiants
+func NewRedisProbe(rawURL string) HealthProbe {
+	return HealthProbe{
+		Name: "redis",
+		Check: func(ctx context.Context) error {
+			u, err := url.Parse(rawURL)
+			if err != nil {
+				return fmt.Errorf("invalid redis url: %w", err)
+			}
+			address := u.Host
+			if u.Port() == "" {
+				address = net.JoinHostPort(u.Hostname(), "6379")
+			}
+			conn, err := dialProbe(ctx, address, u.Scheme == "rediss")
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			reader := bufio.NewReader(conn)
+			if password, ok := u.User.Password(); ok {
+				command := []string{"AUTH", password}
+				if username := u.User.Username(); username != "" {
+					command = []string{"AUTH", username, password}
+				}
+				if _, err := redisCommand(conn, reader, command...); err != nil {
+					return fmt.Errorf("AUTH: %w", err)
+				}
+			}
+			reply, err := redisCommand(conn, reader, "PING")
+			if err != nil {
+				return err
+			}
+			if reply != "+PONG" {
+				return fmt.Errorf("unexpected PING reply: %s", reply)
+			}
+			return nil
+		},
+	}
+}
+
+// NewHTTPProbe vérifie qu'un endpoint HTTP répond sans erreur serveur ; les réponses 4xx sont
+// attendues pour un endpoint OAuth appelé sans paramètres
+func NewHTTPProbe(name, target string) HealthProbe {
+	return HealthProbe{
+		Name: name,
+		Check: func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodHead, target, nil)
+			if err != nil {
+				return err
+			}
+			client := &http.Client{
+				// Une redirection prouve déjà que le provider répond
+				CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= http.StatusInternalServerError {
+				return fmt.Errorf("HTTP %d", resp.StatusCode)
+			}
+			return nil
+		},
+	}
+}
+
+// dialProbe ouvre une connexion TCP, ou TLS, bornée par l'échéance du contexte
+func dialProbe(ctx context.Context, address string, useTLS bool) (net.Conn, error) {
+	var conn net.Conn
+	var err error
+	if useTLS {
+		host, _, _ := net.SplitHostPort(address)
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}}
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
+
+// redisCommand envoie une commande au format RESP et retourne la première ligne de la réponse
+func redisCommand(conn net.Conn, reader *bufio.Reader, args ...string) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		return "", err
+	}
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, "-") {
+		return "", errors.New(strings.TrimPrefix(line, "-"))
+	}
+	return line, nil
+}
@@ -23,8 +23,15 @@ func NewJWTService(secretKey string, accessTokenExp, refreshTokenExp int) *JWTSe
 	}
 }
 
-// GenerateToken crée un token JWT
+// GenerateToken crée un token JWT.
+// Les rôles et permissions ne sont pas inclus : ils sont évalués côté serveur par PermissionService.
 func (s *JWTService) GenerateToken(user *models.User) (string, error) {
+	return s.GenerateTokenWithClaims(user, nil)
+}
+
+// GenerateTokenWithClaims crée un token JWT enrichi de claims personnalisés (ajoutés par les actions
+// post-login) ; ils ne remplacent jamais les claims standards
+func (s *JWTService) GenerateTokenWithClaims(user *models.User, extra map[string]interface{}) (string, error) {
 	claims := jwt.MapClaims{
 		"sub":            user.ID,
 		"email":          user.Email,
@@ -33,6 +40,11 @@ func (s *JWTService) GenerateToken(user *models.User) (string, error) {
 		"exp":            time.Now().Add(time.Duration(s.AccessTokenExp) * time.Minute).Unix(),
 		"iat":            time.Now().Unix(),
 	}
+	for name, value := range extra {
+		if _, exists := claims[name]; !exists {
+			claims[name] = value
+		}
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.SecretKey))
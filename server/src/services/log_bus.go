@@ -0,0 +1,365 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"gorm.io/gorm"
+)
+
+// Types de messages publiés sur le bus
+const (
+	StreamKindLog      = "log"
+	StreamKindEvent    = "event"
+	StreamKindSecurity = "security"
+	StreamKindAudit    = "audit"
+)
+
+const (
+	logBusBacklog          = 1000 // Messages conservés pour la reprise après Last-Event-ID
+	logBusSubscriberBuffer = 256  // Au-delà, les messages d'un abonné trop lent sont perdus
+)
+
+// StreamMessage est un log, un événement, une activité de sécurité ou une entrée d'audit diffusé en direct
+type StreamMessage struct {
+	EventID    string      `json:"eventId"` // Position dans le flux, à renvoyer dans Last-Event-ID
+	ID         string      `json:"id"`      // ID de l'enregistrement en base
+	Kind       string      `json:"kind"`
+	Timestamp  time.Time   `json:"timestamp"`
+	Level      string      `json:"level"`
+	Event      string      `json:"event"`
+	User       string      `json:"user,omitempty"`
+	Email      string      `json:"email,omitempty"`
+	IP         string      `json:"ip,omitempty"`
+	Connection string      `json:"connection,omitempty"`
+	TenantID   string      `json:"tenantId,omitempty"`
+	Details    string      `json:"details,omitempty"`
+	Data       interface{} `json:"data,omitempty"`
+
+	seq uint64
+}
+
+// StreamFilter sélectionne les messages d'un abonné. Un champ vide ne filtre pas ;
+// une valeur de Events terminée par un point sélectionne un préfixe ("auth.").
+type StreamFilter struct {
+	Kinds     []string
+	DenyKinds []string // Types exclus quelle que soit la demande (droits de l'abonné)
+	Levels    []string
+	Events    []string
+	UserID    string
+	IPAddress string
+	TenantID  string
+	// TenantStrict impose l'égalité du tenant, y compris vide : un abonné limité à l'instance
+	// ne reçoit alors que les messages sans tenant
+	TenantStrict bool
+	Search       string
+}
+
+// Match indique si le message passe le filtre
+func (f StreamFilter) Match(msg *StreamMessage) bool {
+	if slices.Contains(f.DenyKinds, msg.Kind) {
+		return false
+	}
+	if len(f.Kinds) > 0 && !slices.Contains(f.Kinds, msg.Kind) {
+		return false
+	}
+	if len(f.Levels) > 0 && !slices.Contains(f.Levels, msg.Level) {
+		return false
+	}
+	if len(f.Events) > 0 && !slices.ContainsFunc(f.Events, func(event string) bool {
+		if strings.HasSuffix(event, ".") {
+			return strings.HasPrefix(msg.Event, event)
+		}
+		return msg.Event == event
+	}) {
+		return false
+	}
+	if f.UserID != "" && msg.User != f.UserID {
+		return false
+	}
+	if f.IPAddress != "" && msg.IP != f.IPAddress {
+		return false
+	}
+	if (f.TenantID != "" || f.TenantStrict) && msg.TenantID != f.TenantID {
+		return false
+	}
+	if f.Search != "" {
+		search := strings.ToLower(f.Search)
+		if !strings.Contains(strings.ToLower(msg.Event+"\n"+msg.Details+"\n"+msg.Email), search) {
+			return false
+		}
+	}
+	return true
+}
+
+// LogBus diffuse en direct, au sein du processus, les logs, événements, activités de sécurité et entrées d'audit.
+// Les derniers messages sont conservés pour qu'un abonné reconnecté reprenne après Last-Event-ID.
+type LogBus struct {
+	mu          sync.Mutex
+	epoch       string // Change à chaque démarrage : un Last-Event-ID d'un autre processus n'est pas repris
+	seq         uint64
+	backlog     []StreamMessage // Anneau de logBusBacklog messages
+	next        int
+	subscribers map[*LogSubscription]struct{}
+}
+
+// DefaultLogBus est le bus alimenté par les créations en base (voir registerLogBusCallbacks)
+var DefaultLogBus = NewLogBus()
+
+// NewLogBus crée un bus vide
+func NewLogBus() *LogBus {
+	epoch := make([]byte, 4)
+	_, _ = rand.Read(epoch)
+	return &LogBus{
+		epoch:       hex.EncodeToString(epoch),
+		backlog:     make([]StreamMessage, 0, logBusBacklog),
+		subscribers: make(map[*LogSubscription]struct{}),
+	}
+}
+
+// LogSubscription reçoit les messages du bus correspondant à son filtre
+type LogSubscription struct {
+	bus      *LogBus
+	filter   StreamFilter
+	messages chan StreamMessage
+	dropped  atomic.Uint64
+	once     sync.Once
+}
+
+// Messages retourne le canal des messages reçus ; il est fermé par Close
+func (s *LogSubscription) Messages() <-chan StreamMessage {
+	return s.messages
+}
+
+// TakeDropped retourne le nombre de messages perdus depuis le dernier appel, faute de place dans le tampon
+func (s *LogSubscription) TakeDropped() uint64 {
+	return s.dropped.Swap(0)
+}
+
+// Close désabonne et ferme le canal des messages
+func (s *LogSubscription) Close() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subscribers, s)
+		s.bus.mu.Unlock()
+		close(s.messages)
+	})
+}
+
+// Publish numérote un message, le conserve dans l'historique et le transmet aux abonnés concernés.
+// Un abonné dont le tampon est plein perd le message plutôt que de bloquer l'émetteur.
+func (b *LogBus) Publish(msg StreamMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	msg.seq = b.seq
+	msg.EventID = b.epoch + "-" + strconv.FormatUint(b.seq, 10)
+	if len(b.backlog) < logBusBacklog {
+		b.backlog = append(b.backlog, msg)
+	} else {
+		b.backlog[b.next] = msg
+		b.next = (b.next + 1) % logBusBacklog
+	}
+
+	for subscriber := range b.subscribers {
+		if !subscriber.filter.Match(&msg) {
+			continue
+		}
+		select {
+		case subscriber.messages <- msg:
+		default:
+			subscriber.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribe abonne un client. Avec lastEventID, les messages conservés publiés après lui sont retournés
+// pour être envoyés avant le direct ; resumed est faux si cette position n'est plus dans l'historique.
+// Sans lastEventID, tail demande les derniers messages correspondant au filtre.
+func (b *LogBus) Subscribe(filter StreamFilter, lastEventID string, tail int) (subscription *LogSubscription, replay []StreamMessage, resumed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscription = &LogSubscription{bus: b, filter: filter, messages: make(chan StreamMessage, logBusSubscriberBuffer)}
+	b.subscribers[subscription] = struct{}{}
+
+	ordered := append(slices.Clone(b.backlog[b.next:]), b.backlog[:b.next]...)
+	resumed = true
+	after := uint64(0)
+	switch {
+	case lastEventID != "":
+		seq, ok := b.parseEventID(lastEventID)
+		oldest := b.seq + 1
+		if len(ordered) > 0 {
+			oldest = ordered[0].seq
+		}
+		if !ok || seq > b.seq || seq+1 < oldest {
+			// Position inconnue ou sortie de l'historique : reprise depuis tout ce qui est conservé
+			resumed = false
+		} else {
+			after = seq
+		}
+	case tail > 0:
+		after = b.seq // Seuls les tail derniers messages filtrés sont rejoués, voir plus bas
+	default:
+		return subscription, nil, true
+	}
+
+	if lastEventID == "" {
+		for i := len(ordered) - 1; i >= 0 && len(replay) < tail; i-- {
+			if filter.Match(&ordered[i]) {
+				replay = append(replay, ordered[i])
+			}
+		}
+		slices.Reverse(replay)
+		return subscription, replay, true
+	}
+	for i := range ordered {
+		if ordered[i].seq > after && filter.Match(&ordered[i]) {
+			replay = append(replay, ordered[i])
+		}
+	}
+	return subscription, replay, resumed
+}
+
+// parseEventID lit la séquence d'un identifiant "<epoch>-<séquence>" émis par ce bus
+func (b *LogBus) parseEventID(eventID string) (uint64, bool) {
+	epoch, seq, found := strings.Cut(eventID, "-")
+	if !found || epoch != b.epoch {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(seq, 10, 64)
+	return value, err == nil
+}
+
+// registerLogBusCallbacks publie sur DefaultLogBus chaque log, événement, activité de sécurité
+// et entrée d'audit créé via GORM, quel que soit le service à l'origine de la création.
+// La publication a lieu après l'INSERT : une transaction annulée ensuite aura tout de même été diffusée.
+// Chaque message porte le tenant de son auteur (utilisateur, ou client OAuth pour l'audit), lu via db
+// hors de la transaction en cours ; un message sans auteur rattaché à un tenant relève de l'instance.
+func registerLogBusCallbacks(db *gorm.DB) error {
+	return db.Callback().Create().After("gorm:create").Register("logbus:publish", func(tx *gorm.DB) {
+		if tx.Error != nil || tx.Statement.RowsAffected == 0 {
+			return
+		}
+		value := reflect.Indirect(tx.Statement.ReflectValue)
+		switch value.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < value.Len(); i++ {
+				publishCreated(db, reflect.Indirect(value.Index(i)))
+			}
+		case reflect.Struct:
+			publishCreated(db, value)
+		}
+	})
+}
+
+func publishCreated(db *gorm.DB, value reflect.Value) {
+	if !value.CanAddr() {
+		return
+	}
+	var msg StreamMessage
+	switch record := value.Addr().Interface().(type) {
+	case *models.Log:
+		msg = LogStreamMessage(record)
+		msg.TenantID = ResolveTenantID(db, "", msg.User)
+	case *models.Event:
+		msg = EventStreamMessage(record)
+	case *models.SecurityActivity:
+		msg = SecurityStreamMessage(record)
+		msg.TenantID = ResolveTenantID(db, "", msg.User)
+	case *models.AuditEntry:
+		msg = AuditStreamMessage(record)
+		switch record.ActorType {
+		case models.AuditActorUser:
+			msg.TenantID = ResolveTenantID(db, "", record.ActorID)
+		case models.AuditActorClient:
+			msg.TenantID = ResolveTenantID(db, record.ActorID, "")
+		}
+	default:
+		return
+	}
+	DefaultLogBus.Publish(msg)
+}
+
+// LogStreamMessage convertit un log pour le flux
+func LogStreamMessage(log *models.Log) StreamMessage {
+	return StreamMessage{
+		ID:         log.ID,
+		Kind:       StreamKindLog,
+		Timestamp:  log.CreatedAt,
+		Level:      string(log.Level),
+		Event:      log.Event,
+		User:       deref(log.UserID),
+		Email:      deref(log.UserEmail),
+		IP:         deref(log.IPAddress),
+		Connection: deref(log.Connection),
+		Details:    log.Message,
+		Data:       deref(log.Details),
+	}
+}
+
+// EventStreamMessage convertit un événement pour le flux
+func EventStreamMessage(event *models.Event) StreamMessage {
+	return StreamMessage{
+		ID:        event.ID,
+		Kind:      StreamKindEvent,
+		Timestamp: event.CreatedAt,
+		Level:     string(models.LogLevelInfo),
+		Event:     event.Type,
+		User:      deref(event.UserID),
+		TenantID:  deref(event.TenantID),
+		Details:   strings.TrimSpace(deref(event.Source) + " " + deref(event.Subject)),
+		Data:      JSONValue(event.Data),
+	}
+}
+
+// SecurityStreamMessage convertit une activité de sécurité pour le flux
+func SecurityStreamMessage(activity *models.SecurityActivity) StreamMessage {
+	details := activity.Title
+	if activity.Description != nil {
+		details += ": " + *activity.Description
+	}
+	return StreamMessage{
+		ID:        activity.ID,
+		Kind:      StreamKindSecurity,
+		Timestamp: activity.CreatedAt,
+		Level:     string(models.LogLevelWarn),
+		Event:     "security." + activity.Type,
+		User:      activity.UserID,
+		IP:        deref(activity.IPAddress),
+		Details:   details,
+		Data:      map[string]interface{}{"device": deref(activity.Device)},
+	}
+}
+
+// AuditStreamMessage convertit une entrée d'audit pour le flux
+func AuditStreamMessage(entry *models.AuditEntry) StreamMessage {
+	record := AuditRecord(entry)
+	details := fmt.Sprintf("%s %s", entry.Action, entry.Outcome)
+	if entry.TargetType != "" {
+		details += " " + record.Subject
+	}
+	return StreamMessage{
+		ID:        entry.ID,
+		Kind:      StreamKindAudit,
+		Timestamp: entry.CreatedAt,
+		Level:     record.Severity,
+		Event:     entry.Action,
+		User:      entry.ActorID,
+		Email:     entry.ActorName,
+		IP:        entry.IPAddress,
+		Details:   details,
+		Data:      map[string]interface{}{"requestId": entry.RequestID, "source": entry.Source, "changes": entry.Changes},
+	}
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+)
+
+var ErrUnsupportedExportFormat = errors.New("unsupported export format: use ndjson, csv or cef")
+
+// Formats d'export des logs, événements et entrées d'audit
+const (
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatCSV    = "csv"
+	ExportFormatCEF    = "cef"
+)
+
+// Identification du produit dans l'en-tête CEF
+const (
+	cefVendor  = "Sky Genesis Enterprise"
+	cefProduct = "Aether Identity"
+	cefVersion = "1.0.0"
+)
+
+// ExportRecord est la forme commune d'un log, d'un événement ou d'une entrée d'audit à l'export
+type ExportRecord struct {
+	Timestamp  time.Time   `json:"timestamp"`
+	ID         string      `json:"id"`
+	Kind       string      `json:"kind"` // log, event ou audit
+	Name       string      `json:"name"` // Événement du log, type de l'événement ou action auditée
+	Severity   string      `json:"severity"`
+	Message    string      `json:"message,omitempty"`
+	Outcome    string      `json:"outcome,omitempty"`
+	UserID     string      `json:"userId,omitempty"`
+	UserName   string      `json:"userName,omitempty"`
+	IPAddress  string      `json:"ipAddress,omitempty"`
+	UserAgent  string      `json:"userAgent,omitempty"`
+	Connection string      `json:"connection,omitempty"`
+	Location   string      `json:"location,omitempty"`
+	Source     string      `json:"source,omitempty"`
+	Subject    string      `json:"subject,omitempty"`
+	RequestID  string      `json:"requestId,omitempty"`
+	Data       interface{} `json:"data,omitempty"`
+}
+
+// exportCSVHeader sont les colonnes de l'export CSV
+var exportCSVHeader = []string{
+	"timestamp", "id", "kind", "name", "severity", "message", "outcome", "userId", "userName",
+	"ipAddress", "userAgent", "connection", "location", "source", "subject", "requestId", "data",
+}
+
+// LogRecord convertit un log pour l'export
+func LogRecord(log *models.Log) ExportRecord {
+	record := ExportRecord{
+		Timestamp:  log.CreatedAt,
+		ID:         log.ID,
+		Kind:       "log",
+		Name:       log.Event,
+		Severity:   string(log.Level),
+		Message:    log.Message,
+		UserID:     deref(log.UserID),
+		UserName:   deref(log.UserEmail),
+		IPAddress:  deref(log.IPAddress),
+		UserAgent:  deref(log.UserAgent),
+		Connection: deref(log.Connection),
+		Location:   deref(log.Location),
+	}
+	if log.Details != nil {
+		record.Data = *log.Details
+	}
+	return record
+}
+
+// EventRecord convertit un événement pour l'export
+func EventRecord(event *models.Event) ExportRecord {
+	return ExportRecord{
+		Timestamp: event.CreatedAt,
+		ID:        event.ID,
+		Kind:      "event",
+		Name:      event.Type,
+		Severity:  string(models.LogLevelInfo),
+		UserID:    deref(event.UserID),
+		Source:    deref(event.Source),
+		Subject:   deref(event.Subject),
+		Data:      JSONValue(event.Data),
+	}
+}
+
+// AuditRecord convertit une entrée d'audit pour l'export
+func AuditRecord(entry *models.AuditEntry) ExportRecord {
+	severity := models.LogLevelInfo
+	switch entry.Outcome {
+	case models.AuditOutcomeFailure:
+		severity = models.LogLevelWarn
+	case models.AuditOutcomeDenied:
+		severity = models.LogLevelError
+	}
+	record := ExportRecord{
+		Timestamp: entry.CreatedAt,
+		ID:        entry.ID,
+		Kind:      "audit",
+		Name:      entry.Action,
+		Severity:  string(severity),
+		Outcome:   entry.Outcome,
+		UserID:    entry.ActorID,
+		UserName:  entry.ActorName,
+		IPAddress: entry.IPAddress,
+		UserAgent: entry.UserAgent,
+		Source:    entry.Source,
+		RequestID: entry.RequestID,
+		Data: map[string]interface{}{
+			"sequence": entry.Sequence,
+			"hash":     entry.Hash,
+			"changes":  entry.Changes,
+			"metadata": entry.Metadata,
+		},
+	}
+	if entry.TargetType != "" {
+		record.Subject = entry.TargetType + ":" + entry.TargetID
+	}
+	return record
+}
+
+// JSONValue remplace le JSON brut lu dans une colonne jsonb ([]byte ou string) par un json.RawMessage,
+// pour qu'il soit sérialisé tel quel et non encodé en base64
+func JSONValue(value interface{}) interface{} {
+	var raw []byte
+	switch v := value.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return value
+	}
+	if !json.Valid(raw) {
+		return string(raw)
+	}
+	return json.RawMessage(raw)
+}
+
+// LogExporter écrit des enregistrements dans un format d'export
+type LogExporter interface {
+	Write(record ExportRecord) error
+	Flush() error
+}
+
+// NewLogExporter crée l'exporteur d'un format (ndjson, csv ou cef) écrivant dans w
+func NewLogExporter(format string, w io.Writer) (LogExporter, error) {
+	switch strings.ToLower(format) {
+	case "", ExportFormatNDJSON, "jsonl":
+		buffered := bufio.NewWriter(w)
+		return &ndjsonExporter{w: buffered, encoder: json.NewEncoder(buffered)}, nil
+	case ExportFormatCSV:
+		return &csvExporter{w: csv.NewWriter(w)}, nil
+	case ExportFormatCEF:
+		return &cefExporter{w: bufio.NewWriter(w)}, nil
+	}
+	return nil, ErrUnsupportedExportFormat
+}
+
+// ExportContentType retourne le type MIME et l'extension de fichier d'un format d'export
+func ExportContentType(format string) (string, string) {
+	switch strings.ToLower(format) {
+	case ExportFormatCSV:
+		return "text/csv; charset=utf-8", "csv"
+	case ExportFormatCEF:
+		return "text/plain; charset=utf-8", "cef"
+	}
+	return "application/x-ndjson", "ndjson"
+}
+
+// ndjsonExporter écrit un objet JSON par ligne
+type ndjsonExporter struct {
+	w       *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExporter) Write(record ExportRecord) error {
+	return e.encoder.Encode(record)
+}
+
+func (e *ndjsonExporter) Flush() error {
+	return e.w.Flush()
+}
+
+// csvExporter écrit une ligne d'en-tête puis une ligne par enregistrement
+type csvExporter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (e *csvExporter) Write(record ExportRecord) error {
+	if !e.headerWritten {
+		if err := e.w.Write(exportCSVHeader); err != nil {
+			return err
+		}
+		e.headerWritten = true
+	}
+	row := []string{
+		record.Timestamp.UTC().Format(time.RFC3339Nano), record.ID, record.Kind, record.Name, record.Severity,
+		record.Message, record.Outcome, record.UserID, record.UserName, record.IPAddress, record.UserAgent,
+		record.Connection, record.Location, record.Source, record.Subject, record.RequestID, exportDataString(record.Data),
+	}
+	for i := range row {
+		row[i] = csvSafeCell(row[i])
+	}
+	return e.w.Write(row)
+}
+
+// csvSafeCell neutralise une cellule qu'un tableur interpréterait comme une formule (injection CSV) :
+// les valeurs commençant par =, +, -, @, une tabulation ou un retour chariot sont préfixées d'une apostrophe
+func csvSafeCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (e *csvExporter) Flush() error {
+	if !e.headerWritten {
+		// Un export vide contient tout de même l'en-tête
+		if err := e.w.Write(exportCSVHeader); err != nil {
+			return err
+		}
+		e.headerWritten = true
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// cefExporter écrit au format ArcSight Common Event Format, une ligne par enregistrement
+type cefExporter struct {
+	w *bufio.Writer
+}
+
+func (e *cefExporter) Write(record ExportRecord) error {
+	name := record.Message
+	if name == "" {
+		name = record.Name
+	}
+
+	extensions := []string{
+		"rt=" + strconv.FormatInt(record.Timestamp.UnixMilli(), 10),
+		"externalId=" + cefExtensionValue(record.ID),
+		"cat=" + cefExtensionValue(record.Kind),
+	}
+	for _, field := range []struct{ key, value string }{
+		{"outcome", record.Outcome},
+		{"suid", record.UserID},
+		{"suser", record.UserName},
+		{"src", record.IPAddress},
+		{"requestClientApplication", record.UserAgent},
+		{"sproc", record.Source},
+		{"duid", record.Subject},
+		{"cs1Label", labelIf(record.Connection, "connection")},
+		{"cs1", record.Connection},
+		{"cs2Label", labelIf(record.RequestID, "requestId")},
+		{"cs2", record.RequestID},
+		{"cs3Label", labelIf(record.Location, "location")},
+		{"cs3", record.Location},
+		{"msg", exportDataString(record.Data)},
+	} {
+		if field.value != "" {
+			extensions = append(extensions, field.key+"="+cefExtensionValue(field.value))
+		}
+	}
+
+	_, err := fmt.Fprintf(e.w, "CEF:0|%s|%s|%s|%s|%s|%d|%s\n",
+		cefHeaderValue(cefVendor), cefHeaderValue(cefProduct), cefHeaderValue(cefVersion),
+		cefHeaderValue(record.Name), cefHeaderValue(name), cefSeverity(record.Severity),
+		strings.Join(extensions, " "))
+	return err
+}
+
+func (e *cefExporter) Flush() error {
+	return e.w.Flush()
+}
+
+// cefSeverity convertit un niveau de log en sévérité CEF (0 à 10)
+func cefSeverity(level string) int {
+	switch models.LogLevel(level) {
+	case models.LogLevelDebug:
+		return 1
+	case models.LogLevelWarn:
+		return 6
+	case models.LogLevelError:
+		return 8
+	}
+	return 3
+}
+
+// cefHeaderValue échappe un champ d'en-tête CEF : barre verticale et antislash
+func cefHeaderValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r", " ", "\n", " ").Replace(value)
+}
+
+// cefExtensionValue échappe une valeur d'extension CEF : signe égal, antislash et retours à la ligne
+func cefExtensionValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(value)
+}
+
+func labelIf(value, label string) string {
+	if value == "" {
+		return ""
+	}
+	return label
+}
+
+// exportDataString sérialise les données annexes d'un enregistrement sur une ligne
+func exportDataString(data interface{}) string {
+	switch v := data.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Bornes de pagination des recherches
+const (
+	LogQueryDefaultLimit = 100
+	LogQueryMaxLimit     = 1000
+	logExportBatchSize   = 500
+)
+
+// LogQuery filtre une recherche dans les logs ou les événements.
+// Level, IPAddress et Connection ne s'appliquent qu'aux logs ; EventTypes porte sur Log.Event ou Event.Type.
+type LogQuery struct {
+	From       *time.Time
+	To         *time.Time
+	Levels     []string
+	EventTypes []string
+	UserID     string
+	IPAddress  string
+	Connection string
+	Search     string // Texte libre, insensible à la casse
+	// Tenant des résultats : celui de l'événement, ou de l'utilisateur pour un log. TenantStrict impose
+	// l'égalité y compris vide : une recherche limitée à l'instance ne voit alors que les lignes sans tenant.
+	TenantID     string
+	TenantStrict bool
+	Cursor       string // Curseur opaque retourné par la page précédente
+	Limit        int
+}
+
+// pageLimit retourne la taille de page demandée, bornée
+func (q LogQuery) pageLimit() int {
+	switch {
+	case q.Limit <= 0:
+		return LogQueryDefaultLimit
+	case q.Limit > LogQueryMaxLimit:
+		return LogQueryMaxLimit
+	}
+	return q.Limit
+}
+
+// EncodeLogCursor encode la position (created_at, id) d'une ligne. Les résultats étant triés du plus
+// récent au plus ancien, la page suivante commence strictement après cette position.
+func EncodeLogCursor(createdAt time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.UTC().Format(time.RFC3339Nano) + "|" + id))
+}
+
+// decodeLogCursor décode un curseur produit par EncodeLogCursor
+func decodeLogCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	timestamp, id, found := strings.Cut(string(raw), "|")
+	if !found || id == "" {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	return createdAt, id, nil
+}
+
+// applyLogCursor restreint la requête aux lignes situées après le curseur (tri created_at DESC, id DESC)
+func applyLogCursor(query *gorm.DB, cursor string) (*gorm.DB, error) {
+	if cursor == "" {
+		return query, nil
+	}
+	createdAt, id, err := decodeLogCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	return query.Where("(created_at, id) < (?, ?)", createdAt, id), nil
+}
+
+// likePattern échappe un texte libre pour ILIKE
+func likePattern(search string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(search) + "%"
+}
+
+// applyTimeRange restreint la requête à l'intervalle [From, To[
+func applyTimeRange(query *gorm.DB, q LogQuery) *gorm.DB {
+	if q.From != nil {
+		query = query.Where("created_at >= ?", *q.From)
+	}
+	if q.To != nil {
+		query = query.Where("created_at < ?", *q.To)
+	}
+	return query
+}
+
+// logsQuery construit la requête filtrée des logs, sans pagination
+func (s *LogService) logsQuery(q LogQuery) *gorm.DB {
+	query := applyTimeRange(s.DB.Model(&models.Log{}), q)
+	if len(q.Levels) > 0 {
+		query = query.Where("level IN ?", q.Levels)
+	}
+	if len(q.EventTypes) > 0 {
+		query = query.Where("event IN ?", q.EventTypes)
+	}
+	if q.UserID != "" {
+		query = query.Where("user_id = ?", q.UserID)
+	}
+	if q.IPAddress != "" {
+		query = query.Where("ip_address = ?", q.IPAddress)
+	}
+	if q.Connection != "" {
+		query = query.Where("connection = ?", q.Connection)
+	}
+	if q.Search != "" {
+		pattern := likePattern(q.Search)
+		query = query.Where("(message ILIKE ? OR details ILIKE ? OR event ILIKE ? OR user_email ILIKE ?)", pattern, pattern, pattern, pattern)
+	}
+	// Un log n'a pas de tenant propre : il relève de celui de son utilisateur, à défaut de l'instance
+	switch {
+	case q.TenantID != "":
+		query = query.Where("user_id IN (SELECT id FROM users WHERE tenant_id = ?)", q.TenantID)
+	case q.TenantStrict:
+		query = query.Where("(user_id IS NULL OR user_id NOT IN (SELECT id FROM users WHERE tenant_id IS NOT NULL))")
+	}
+	return query
+}
+
+// FindLog retourne un log s'il correspond à la recherche, typiquement limitée au tenant de l'appelant
+func (s *LogService) FindLog(id string, q LogQuery) (*models.Log, error) {
+	var log models.Log
+	if err := s.logsQuery(q).Where("id = ?", id).First(&log).Error; err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+// QueryLogs retourne une page de logs, du plus récent au plus ancien, et le curseur de la page suivante
+// (vide lorsqu'il n'y a plus de résultats)
+func (s *LogService) QueryLogs(q LogQuery) ([]models.Log, string, error) {
+	query, err := applyLogCursor(s.logsQuery(q), q.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	limit := q.pageLimit()
+	var logs []models.Log
+	if err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&logs).Error; err != nil {
+		return nil, "", err
+	}
+	if len(logs) <= limit {
+		return logs, "", nil
+	}
+	logs = logs[:limit]
+	last := logs[limit-1]
+	return logs, EncodeLogCursor(last.CreatedAt, last.ID), nil
+}
+
+// StreamLogs parcourt par lots tous les logs correspondant à la recherche et appelle fn pour chaque lot.
+// Le parcours s'arrête à la première erreur retournée par fn.
+func (s *LogService) StreamLogs(q LogQuery, fn func([]models.Log) error) error {
+	q.Limit = logExportBatchSize
+	for {
+		logs, next, err := s.QueryLogs(q)
+		if err != nil {
+			return err
+		}
+		if len(logs) > 0 {
+			if err := fn(logs); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		q.Cursor = next
+	}
+}
+
+// eventsQuery construit la requête filtrée des événements, sans pagination
+func (s *EventService) eventsQuery(q LogQuery) *gorm.DB {
+	query := applyTimeRange(s.DB.Model(&models.Event{}), q)
+	if len(q.EventTypes) > 0 {
+		query = query.Where("type IN ?", q.EventTypes)
+	}
+	if q.UserID != "" {
+		query = query.Where("user_id = ?", q.UserID)
+	}
+	if q.Search != "" {
+		pattern := likePattern(q.Search)
+		query = query.Where("(type ILIKE ? OR source ILIKE ? OR subject ILIKE ? OR data::text ILIKE ?)", pattern, pattern, pattern, pattern)
+	}
+	switch {
+	case q.TenantID != "":
+		query = query.Where("tenant_id = ?", q.TenantID)
+	case q.TenantStrict:
+		query = query.Where("tenant_id IS NULL")
+	}
+	return query
+}
+
+// FindEvent retourne un événement s'il correspond à la recherche, typiquement limitée au tenant de l'appelant
+func (s *EventService) FindEvent(id string, q LogQuery) (*models.Event, error) {
+	var event models.Event
+	if err := s.eventsQuery(q).Where("id = ?", id).First(&event).Error; err != nil {
+		return nil, err
+	}
+	event.Data = JSONValue(event.Data)
+	return &event, nil
+}
+
+// QueryEvents retourne une page d'événements, du plus récent au plus ancien, et le curseur de la page suivante
+func (s *EventService) QueryEvents(q LogQuery) ([]models.Event, string, error) {
+	query, err := applyLogCursor(s.eventsQuery(q), q.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	limit := q.pageLimit()
+	var events []models.Event
+	if err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&events).Error; err != nil {
+		return nil, "", err
+	}
+	for i := range events {
+		events[i].Data = JSONValue(events[i].Data)
+	}
+	if len(events) <= limit {
+		return events, "", nil
+	}
+	events = events[:limit]
+	last := events[limit-1]
+	return events, EncodeLogCursor(last.CreatedAt, last.ID), nil
+}
+
+// StreamEvents parcourt par lots tous les événements correspondant à la recherche
+func (s *EventService) StreamEvents(q LogQuery, fn func([]models.Event) error) error {
+	q.Limit = logExportBatchSize
+	for {
+		events, next, err := s.QueryEvents(q)
+		if err != nil {
+			return err
+		}
+		if len(events) > 0 {
+			if err := fn(events); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		q.Cursor = next
+	}
+}
@@ -1,6 +1,9 @@
 package services
 
 import (
+	"encoding/json"
+	"log"
+
 	"github.com/skygenesisenterprise/aether-identity/server/src/models"
 	"gorm.io/gorm"
 )
@@ -17,6 +20,26 @@ func (s *LogService) CreateLog(log *models.Log) error {
 	return s.DB.Create(log).Error
 }
 
+// WriteLog enregistre un log du serveur. Comme tout log créé, il est diffusé en direct et transmis
+// aux flux de logs (SIEM). details est sérialisé en JSON. Si l'enregistrement échoue, le log est
+// écrit sur la sortie standard pour ne pas être perdu.
+func (s *LogService) WriteLog(level models.LogLevel, event, message string, details map[string]interface{}) {
+	entry := &models.Log{Level: level, Event: event, Message: message}
+	if len(details) > 0 {
+		if encoded, err := json.Marshal(details); err == nil {
+			value := string(encoded)
+			entry.Details = &value
+		}
+	}
+	if s.DB == nil {
+		log.Printf("%s %s: %s %s", level, event, message, deref(entry.Details))
+		return
+	}
+	if err := s.CreateLog(entry); err != nil {
+		log.Printf("%s %s: %s %s (not recorded: %v)", level, event, message, deref(entry.Details), err)
+	}
+}
+
 func (s *LogService) GetLog(id string) (*models.Log, error) {
 	var log models.Log
 	if err := s.DB.First(&log, "id = ?", id).Error; err != nil {
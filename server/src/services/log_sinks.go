@@ -0,0 +1,511 @@
+package services
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+)
+
+// Valeurs par défaut des destinations
+const (
+	logSinkSource         = "aether-identity"
+	logSinkTimeout        = 15 * time.Second
+	syslogDefaultFacility = 13    // log audit (RFC 5424)
+	syslogEnterpriseID    = 32473 // Numéro d'entreprise réservé à la documentation (RFC 5612)
+	elasticsearchIndex    = "aether-identity-logs"
+	datadogDefaultSite    = "datadoghq.com"
+)
+
+// En-têtes des requêtes signées (flux HTTP et webhooks)
+const (
+	SignatureHeader = "X-Aether-Signature"
+	TimestampHeader = "X-Aether-Timestamp"
+)
+
+// LogSinkError est un échec de livraison vers une destination. Les erreurs réseau, 408, 429 et 5xx
+// sont retentées ; les autres réponses sont définitives et le lot part directement en file d'erreurs.
+type LogSinkError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *LogSinkError) Error() string {
+	if e.StatusCode == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// Retryable indique si une nouvelle tentative peut réussir
+func (e *LogSinkError) Retryable() bool {
+	return e.StatusCode == 0 || e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+func logSinkRetryable(err error) bool {
+	var sinkErr *LogSinkError
+	if errors.As(err, &sinkErr) {
+		return sinkErr.Retryable()
+	}
+	return true
+}
+
+// LogSink livre un lot de messages à une destination
+type LogSink interface {
+	Send(ctx context.Context, batch []StreamMessage) error
+	Close() error
+}
+
+// NewLogSink crée la destination d'un flux à partir de sa configuration
+func NewLogSink(stream *models.LogStream) (LogSink, error) {
+	cfg := logSinkConfig(stream.Config)
+	secret := deref(stream.Secret)
+	switch stream.Type {
+	case models.LogStreamSyslog:
+		protocol := strings.ToLower(cfg.str("protocol", "udp"))
+		if protocol != "udp" && protocol != "tcp" && protocol != "tls" {
+			return nil, fmt.Errorf("unsupported syslog protocol: %s", protocol)
+		}
+		address := cfg.str("address", "")
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return nil, errors.New("syslog address must be host:port")
+		}
+		hostname, _ := os.Hostname()
+		return &syslogSink{
+			address:  address,
+			protocol: protocol,
+			facility: cfg.int("facility", syslogDefaultFacility),
+			appName:  cfg.str("appName", logSinkSource),
+			hostname: cfg.str("hostname", cmp.Or(hostname, "-")),
+			tls:      &tls.Config{InsecureSkipVerify: cfg.bool("tlsSkipVerify")},
+		}, nil
+	case models.LogStreamSplunk:
+		url, err := cfg.url("url")
+		if err != nil {
+			return nil, err
+		}
+		if secret == "" {
+			return nil, errors.New("splunk requires the HEC token as secret")
+		}
+		return &splunkSink{
+			httpSink:   newHTTPSink(strings.TrimRight(url, "/")+"/services/collector/event", cfg),
+			token:      secret,
+			index:      cfg.str("index", ""),
+			sourceType: cfg.str("sourcetype", "_json"),
+		}, nil
+	case models.LogStreamElasticsearch:
+		url, err := cfg.url("url")
+		if err != nil {
+			return nil, err
+		}
+		return &elasticsearchSink{
+			httpSink: newHTTPSink(strings.TrimRight(url, "/")+"/_bulk", cfg),
+			index:    cfg.str("index", elasticsearchIndex),
+			username: cfg.str("username", ""),
+			secret:   secret,
+		}, nil
+	case models.LogStreamDatadog:
+		if secret == "" {
+			return nil, errors.New("datadog requires the API key as secret")
+		}
+		url := cfg.str("url", "https://http-intake.logs."+cfg.str("site", datadogDefaultSite)+"/api/v2/logs")
+		return &datadogSink{
+			httpSink: newHTTPSink(url, cfg),
+			apiKey:   secret,
+			service:  cfg.str("service", logSinkSource),
+			tags:     cfg.str("tags", ""),
+		}, nil
+	case models.LogStreamHTTP:
+		url, err := cfg.url("url")
+		if err != nil {
+			return nil, err
+		}
+		sink := &webhookSink{httpSink: newHTTPSink(url, cfg), secret: secret, headers: map[string]string{}}
+		if headers, ok := stream.Config["headers"].(map[string]interface{}); ok {
+			for name, value := range headers {
+				sink.headers[name] = fmt.Sprint(value)
+			}
+		}
+		return sink, nil
+	}
+	return nil, fmt.Errorf("unsupported log stream type: %s", stream.Type)
+}
+
+// SignPayload signe un corps horodaté : HMAC-SHA256 de "<timestamp>.<corps>", encodé en hexadécimal.
+// Le destinataire recalcule la signature et rejette les horodatages trop anciens pour empêcher le rejeu.
+func SignPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// logSinkConfig lit les paramètres d'une destination
+type logSinkConfig map[string]interface{}
+
+func (c logSinkConfig) str(key, fallback string) string {
+	if value, ok := c[key].(string); ok && strings.TrimSpace(value) != "" {
+		return strings.TrimSpace(value)
+	}
+	return fallback
+}
+
+func (c logSinkConfig) int(key string, fallback int) int {
+	switch value := c[key].(type) {
+	case float64:
+		return int(value)
+	case int:
+		return value
+	case string:
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+func (c logSinkConfig) bool(key string) bool {
+	value, _ := c[key].(bool)
+	return value
+}
+
+func (c logSinkConfig) url(key string) (string, error) {
+	value := c.str(key, "")
+	if !strings.HasPrefix(value, "https://") && !strings.HasPrefix(value, "http://") {
+		return "", fmt.Errorf("%s must be an http(s) URL", key)
+	}
+	return value, nil
+}
+
+// httpSink envoie les lots par POST
+type httpSink struct {
+	url    string
+	client *http.Client
+}
+
+func newHTTPSink(url string, cfg logSinkConfig) httpSink {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: cfg.bool("tlsSkipVerify")}
+	return httpSink{url: url, client: &http.Client{Timeout: logSinkTimeout, Transport: transport}}
+}
+
+// post envoie le corps et renvoie la réponse en cas de succès (2xx)
+func (s *httpSink) post(ctx context.Context, body []byte, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Aether-Identity-LogStream/1.0")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, &LogSinkError{Message: err.Error()}
+	}
+	defer resp.Body.Close()
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &LogSinkError{StatusCode: resp.StatusCode, Message: truncateString(strings.TrimSpace(string(response)), 500)}
+	}
+	return response, nil
+}
+
+func (s *httpSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+func truncateString(value string, limit int) string {
+	if len(value) > limit {
+		return value[:limit]
+	}
+	return value
+}
+
+// splunkSink envoie au HTTP Event Collector de Splunk, un objet événement par message
+type splunkSink struct {
+	httpSink
+	token      string
+	index      string
+	sourceType string
+}
+
+func (s *splunkSink) Send(ctx context.Context, batch []StreamMessage) error {
+	hostname, _ := os.Hostname()
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for i := range batch {
+		event := map[string]interface{}{
+			"time":       float64(batch[i].Timestamp.UnixMicro()) / 1e6,
+			"host":       hostname,
+			"source":     logSinkSource,
+			"sourcetype": s.sourceType,
+			"event":      batch[i],
+		}
+		if s.index != "" {
+			event["index"] = s.index
+		}
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+	_, err := s.post(ctx, body.Bytes(), map[string]string{"Authorization": "Splunk " + s.token})
+	return err
+}
+
+// elasticsearchSink indexe les messages via l'API _bulk
+type elasticsearchSink struct {
+	httpSink
+	index    string
+	username string
+	secret   string
+}
+
+func (s *elasticsearchSink) Send(ctx context.Context, batch []StreamMessage) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for i := range batch {
+		if err := encoder.Encode(map[string]interface{}{"create": map[string]string{"_index": s.index}}); err != nil {
+			return err
+		}
+		document := struct {
+			StreamMessage
+			Timestamp time.Time `json:"@timestamp"`
+		}{batch[i], batch[i].Timestamp}
+		if err := encoder.Encode(document); err != nil {
+			return err
+		}
+	}
+
+	headers := map[string]string{"Content-Type": "application/x-ndjson"}
+	switch {
+	case s.username != "":
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(s.username+":"+s.secret))
+	case s.secret != "":
+		headers["Authorization"] = "ApiKey " + s.secret
+	}
+	response, err := s.post(ctx, body.Bytes(), headers)
+	if err != nil {
+		return err
+	}
+
+	// Le bulk répond 200 même lorsque certains documents sont rejetés
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(response, &result); err != nil || !result.Errors {
+		return nil
+	}
+	for _, item := range result.Items {
+		for _, action := range item {
+			if action.Status >= 300 {
+				return &LogSinkError{StatusCode: action.Status, Message: truncateString(string(action.Error), 500)}
+			}
+		}
+	}
+	return nil
+}
+
+// datadogSink envoie à l'API d'ingestion des logs de Datadog
+type datadogSink struct {
+	httpSink
+	apiKey  string
+	service string
+	tags    string
+}
+
+func (s *datadogSink) Send(ctx context.Context, batch []StreamMessage) error {
+	type datadogLog struct {
+		StreamMessage
+		DDSource string `json:"ddsource"`
+		DDTags   string `json:"ddtags,omitempty"`
+		Hostname string `json:"hostname"`
+		Service  string `json:"service"`
+		Status   string `json:"status"`
+		Message  string `json:"message"`
+	}
+	hostname, _ := os.Hostname()
+	logs := make([]datadogLog, len(batch))
+	for i := range batch {
+		logs[i] = datadogLog{
+			StreamMessage: batch[i],
+			DDSource:      logSinkSource,
+			DDTags:        s.tags,
+			Hostname:      hostname,
+			Service:       s.service,
+			Status:        batch[i].Level,
+			Message:       cmp.Or(batch[i].Details, batch[i].Event),
+		}
+	}
+	body, err := json.Marshal(logs)
+	if err != nil {
+		return err
+	}
+	_, err = s.post(ctx, body, map[string]string{"DD-API-KEY": s.apiKey})
+	return err
+}
+
+// webhookSink envoie le lot en tableau JSON, signé lorsque le flux a un secret
+type webhookSink struct {
+	httpSink
+	secret  string
+	headers map[string]string
+}
+
+func (s *webhookSink) Send(ctx context.Context, batch []StreamMessage) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	headers := make(map[string]string, len(s.headers)+2)
+	for name, value := range s.headers {
+		headers[name] = value
+	}
+	if s.secret != "" {
+		timestamp := time.Now().Unix()
+		headers[TimestampHeader] = strconv.FormatInt(timestamp, 10)
+		headers[SignatureHeader] = SignPayload(s.secret, timestamp, body)
+	}
+	_, err = s.post(ctx, body, headers)
+	return err
+}
+
+// syslogSink envoie au format RFC 5424, en UDP (un datagramme par message) ou en TCP/TLS
+// avec le cadrage par longueur de la RFC 6587 ; la connexion est conservée entre les lots.
+type syslogSink struct {
+	address  string
+	protocol string
+	facility int
+	appName  string
+	hostname string
+	tls      *tls.Config
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func (s *syslogSink) Send(ctx context.Context, batch []StreamMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		dialer := &net.Dialer{Timeout: logSinkTimeout}
+		var conn net.Conn
+		var err error
+		switch s.protocol {
+		case "tls":
+			conn, err = (&tls.Dialer{NetDialer: dialer, Config: s.tls}).DialContext(ctx, "tcp", s.address)
+		default:
+			conn, err = dialer.DialContext(ctx, s.protocol, s.address)
+		}
+		if err != nil {
+			return &LogSinkError{Message: err.Error()}
+		}
+		s.conn = conn
+	}
+
+	_ = s.conn.SetWriteDeadline(time.Now().Add(logSinkTimeout))
+	for i := range batch {
+		line := s.format(&batch[i])
+		var err error
+		if s.protocol == "udp" {
+			_, err = s.conn.Write(line)
+		} else {
+			_, err = s.conn.Write(append([]byte(strconv.Itoa(len(line))+" "), line...))
+		}
+		if err != nil {
+			s.conn.Close()
+			s.conn = nil
+			return &LogSinkError{Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+func (s *syslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// format produit une ligne RFC 5424 :
+// <PRI>1 HORODATAGE HÔTE APPLICATION PROCID MSGID [aether@32473 kind=... id=...] BOM{message JSON}
+func (s *syslogSink) format(msg *StreamMessage) []byte {
+	var line bytes.Buffer
+	fmt.Fprintf(&line, "<%d>1 %s %s %s %d %s [aether@%d",
+		s.facility*8+syslogSeverity(msg.Level), msg.Timestamp.UTC().Format("2006-01-02T15:04:05.000000Z"),
+		syslogHeaderValue(s.hostname, 255), syslogHeaderValue(s.appName, 48), os.Getpid(),
+		syslogHeaderValue(msg.Event, 32), syslogEnterpriseID)
+	for _, param := range []struct{ name, value string }{
+		{"kind", msg.Kind}, {"id", msg.ID}, {"user", msg.User}, {"ip", msg.IP}, {"tenant", msg.TenantID},
+	} {
+		if param.value != "" {
+			fmt.Fprintf(&line, ` %s="%s"`, param.name, syslogParamValue(param.value))
+		}
+	}
+	line.WriteString("] \xEF\xBB\xBF")
+	payload, _ := json.Marshal(msg)
+	line.Write(payload)
+	return line.Bytes()
+}
+
+// syslogSeverity convertit un niveau de log en sévérité syslog
+func syslogSeverity(level string) int {
+	switch models.LogLevel(level) {
+	case models.LogLevelDebug:
+		return 7
+	case models.LogLevelWarn:
+		return 4
+	case models.LogLevelError:
+		return 3
+	}
+	return 6
+}
+
+// syslogHeaderValue restreint un champ d'en-tête aux caractères ASCII imprimables, sans espace
+func syslogHeaderValue(value string, limit int) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, value)
+	if cleaned == "" {
+		return "-"
+	}
+	return truncateString(cleaned, limit)
+}
+
+// syslogParamValue échappe une valeur de donnée structurée : guillemet, antislash et crochet fermant
+func syslogParamValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "]", `\]`).Replace(value)
+}
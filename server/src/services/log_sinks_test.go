@@ -0,0 +1,696 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testBatch renvoie deux messages couvrant les champs repris par les destinations
+func testBatch() []StreamMessage {
+	at := time.Date(2026, 3, 14, 9, 26, 53, 589000000, time.UTC)
+	return []StreamMessage{
+		{EventID: "1", ID: "log-1", Kind: StreamKindLog, Timestamp: at, Level: string(models.LogLevelInfo),
+			Event: "auth.login", User: "user-1", IP: "192.0.2.10", TenantID: "tenant-1", Details: "Login succeeded"},
+		{EventID: "2", ID: "sec-1", Kind: StreamKindSecurity, Timestamp: at.Add(time.Second), Level: string(models.LogLevelError),
+			Event: "auth.login_failed", User: `eve"]\`},
+	}
+}
+
+func newTestSink(t *testing.T, streamType string, config map[string]interface{}, secret string) LogSink {
+	t.Helper()
+	stream := &models.LogStream{Name: "test", Type: streamType, Config: config}
+	if secret != "" {
+		stream.Secret = &secret
+	}
+	sink, err := NewLogSink(stream)
+	if err != nil {
+		t.Fatalf("NewLogSink(%s): %v", streamType, err)
+	}
+	t.Cleanup(func() { sink.Close() })
+	return sink
+}
+
+// capturedRequest est une requête reçue par le serveur de test
+type capturedRequest struct {
+	path   string
+	header http.Header
+	body   []byte
+}
+
+// newCaptureServer enregistre les requêtes reçues et répond avec les statuts donnés, puis 200
+func newCaptureServer(t *testing.T, response string, statuses ...int) (*httptest.Server, func() []capturedRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []capturedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, capturedRequest{path: r.URL.Path, header: r.Header.Clone(), body: body})
+		status := http.StatusOK
+		if len(requests) <= len(statuses) {
+			status = statuses[len(requests)-1]
+		}
+		mu.Unlock()
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []capturedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]capturedRequest(nil), requests...)
+	}
+}
+
+// decodeNDJSON décode un corps d'une ligne JSON par objet
+func decodeNDJSON(t *testing.T, body []byte) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(string(body)))
+	for decoder.More() {
+		var line map[string]interface{}
+		if err := decoder.Decode(&line); err != nil {
+			t.Fatalf("invalid NDJSON body %q: %v", body, err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestSyslogSinkUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sink := newTestSink(t, models.LogStreamSyslog, map[string]interface{}{
+		"address": conn.LocalAddr().String(), "hostname": "idp 01", "appName": "aether",
+	}, "")
+	batch := testBatch()
+	if err := sink.Send(context.Background(), batch); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	// Un datagramme par message, sans cadrage
+	buf := make([]byte, 64<<10)
+	var lines []string
+	for range batch {
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("ReadFrom: %v", err)
+		}
+		lines = append(lines, string(buf[:n]))
+	}
+	assertSyslogLines(t, lines, batch)
+}
+
+func TestSyslogSinkStream(t *testing.T) {
+	certServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer certServer.Close()
+
+	for _, protocol := range []string{"tcp", "tls"} {
+		t.Run(protocol, func(t *testing.T) {
+			var listener net.Listener
+			var err error
+			if protocol == "tls" {
+				listener, err = tls.Listen("tcp", "127.0.0.1:0", certServer.TLS.Clone())
+			} else {
+				listener, err = net.Listen("tcp", "127.0.0.1:0")
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer listener.Close()
+
+			received := make(chan []string, 1)
+			go func() {
+				conn, err := listener.Accept()
+				if err != nil {
+					received <- nil
+					return
+				}
+				defer conn.Close()
+				_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+				received <- readOctetCounted(conn, len(testBatch()))
+			}()
+
+			sink := newTestSink(t, models.LogStreamSyslog, map[string]interface{}{
+				"address": listener.Addr().String(), "protocol": protocol, "hostname": "idp 01",
+				"appName": "aether", "tlsSkipVerify": true,
+			}, "")
+			batch := testBatch()
+			if err := sink.Send(context.Background(), batch); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			assertSyslogLines(t, <-received, batch)
+		})
+	}
+}
+
+// readOctetCounted lit count trames "<longueur> <message>" (RFC 6587)
+func readOctetCounted(conn net.Conn, count int) []string {
+	reader := bufio.NewReader(conn)
+	var lines []string
+	for len(lines) < count {
+		prefix, err := reader.ReadString(' ')
+		if err != nil {
+			return lines
+		}
+		length, err := strconv.Atoi(strings.TrimSuffix(prefix, " "))
+		if err != nil {
+			return lines
+		}
+		frame := make([]byte, length)
+		if _, err := io.ReadFull(reader, frame); err != nil {
+			return lines
+		}
+		lines = append(lines, string(frame))
+	}
+	return lines
+}
+
+func assertSyslogLines(t *testing.T, lines []string, batch []StreamMessage) {
+	t.Helper()
+	if len(lines) != len(batch) {
+		t.Fatalf("received %d syslog messages, want %d", len(lines), len(batch))
+	}
+
+	// Facilité 13 : info = 13*8+6, error = 13*8+3
+	wantHeaders := []string{
+		"<110>1 2026-03-14T09:26:53.589000Z idp01 aether " + strconv.Itoa(os.Getpid()) + " auth.login [aether@32473",
+		"<107>1 2026-03-14T09:26:54.589000Z idp01 aether " + strconv.Itoa(os.Getpid()) + " auth.login_failed [aether@32473",
+	}
+	wantParams := []string{
+		` kind="log" id="log-1" user="user-1" ip="192.0.2.10" tenant="tenant-1"] `,
+		` kind="security" id="sec-1" user="eve\"\]\\"] `,
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, wantHeaders[i]) {
+			t.Errorf("message %d header = %q, want prefix %q", i, line, wantHeaders[i])
+			continue
+		}
+		rest := strings.TrimPrefix(line, wantHeaders[i])
+		params, payload, ok := strings.Cut(rest, "] \xEF\xBB\xBF")
+		if !ok || params+"] " != wantParams[i] {
+			t.Errorf("message %d structured data = %q, want %q", i, params+"] ", wantParams[i])
+			continue
+		}
+		var decoded StreamMessage
+		if err := json.Unmarshal([]byte(payload), &decoded); err != nil {
+			t.Errorf("message %d payload is not JSON: %v", i, err)
+			continue
+		}
+		if decoded.ID != batch[i].ID || decoded.Event != batch[i].Event || !decoded.Timestamp.Equal(batch[i].Timestamp) {
+			t.Errorf("message %d payload = %+v, want %+v", i, decoded, batch[i])
+		}
+	}
+}
+
+func TestSyslogSinkReconnectsAfterWriteFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	sink := newTestSink(t, models.LogStreamSyslog, map[string]interface{}{
+		"address": listener.Addr().String(), "protocol": "tcp",
+	}, "")
+	go func() {
+		// La première connexion est fermée aussitôt acceptée
+		if conn, err := listener.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+
+	syslog := sink.(*syslogSink)
+	var sendErr error
+	for i := 0; i < 50 && sendErr == nil; i++ {
+		sendErr = sink.Send(context.Background(), testBatch())
+		time.Sleep(10 * time.Millisecond)
+	}
+	var sinkErr *LogSinkError
+	if !errors.As(sendErr, &sinkErr) || !sinkErr.Retryable() {
+		t.Fatalf("Send on a closed connection = %v, want a retryable LogSinkError", sendErr)
+	}
+	if syslog.conn != nil {
+		t.Fatal("the broken connection was kept")
+	}
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			received <- nil
+			return
+		}
+		defer conn.Close()
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		received <- readOctetCounted(conn, len(testBatch()))
+	}()
+	if err := sink.Send(context.Background(), testBatch()); err != nil {
+		t.Fatalf("Send after reconnect: %v", err)
+	}
+	if lines := <-received; len(lines) != len(testBatch()) {
+		t.Fatalf("received %d messages after reconnect, want %d", len(lines), len(testBatch()))
+	}
+}
+
+func TestSplunkSink(t *testing.T) {
+	server, requests := newCaptureServer(t, `{"text":"Success","code":0}`)
+	sink := newTestSink(t, models.LogStreamSplunk, map[string]interface{}{
+		"url": server.URL + "/", "index": "identity", "sourcetype": "aether:log",
+	}, "hec-token")
+
+	if err := sink.Send(context.Background(), testBatch()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	reqs := requests()
+	if len(reqs) != 1 {
+		t.Fatalf("received %d requests, want 1", len(reqs))
+	}
+	req := reqs[0]
+	if req.path != "/services/collector/event" {
+		t.Errorf("path = %q, want /services/collector/event", req.path)
+	}
+	if got := req.header.Get("Authorization"); got != "Splunk hec-token" {
+		t.Errorf("Authorization = %q, want Splunk hec-token", got)
+	}
+
+	events := decodeNDJSON(t, req.body)
+	if len(events) != 2 {
+		t.Fatalf("received %d events, want 2", len(events))
+	}
+	for i, event := range events {
+		if event["index"] != "identity" || event["sourcetype"] != "aether:log" || event["source"] != logSinkSource {
+			t.Errorf("event %d metadata = %v", i, event)
+		}
+		wantTime := float64(testBatch()[i].Timestamp.UnixMicro()) / 1e6
+		if event["time"] != wantTime {
+			t.Errorf("event %d time = %v, want %v", i, event["time"], wantTime)
+		}
+		inner, _ := event["event"].(map[string]interface{})
+		if inner["id"] != testBatch()[i].ID {
+			t.Errorf("event %d payload = %v", i, inner)
+		}
+	}
+}
+
+func TestElasticsearchSink(t *testing.T) {
+	server, requests := newCaptureServer(t, `{"errors":false,"items":[]}`)
+	sink := newTestSink(t, models.LogStreamElasticsearch, map[string]interface{}{
+		"url": server.URL, "index": "identity-logs", "username": "shipper",
+	}, "s3cret")
+
+	if err := sink.Send(context.Background(), testBatch()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	req := requests()[0]
+	if req.path != "/_bulk" {
+		t.Errorf("path = %q, want /_bulk", req.path)
+	}
+	if got := req.header.Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", got)
+	}
+	wantAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("shipper:s3cret"))
+	if got := req.header.Get("Authorization"); got != wantAuth {
+		t.Errorf("Authorization = %q, want %q", got, wantAuth)
+	}
+
+	// Une ligne d'action suivie du document, pour chaque message
+	lines := decodeNDJSON(t, req.body)
+	if len(lines) != 4 {
+		t.Fatalf("received %d bulk lines, want 4", len(lines))
+	}
+	for i, batch := 0, testBatch(); i < len(batch); i++ {
+		action, _ := lines[2*i]["create"].(map[string]interface{})
+		if action["_index"] != "identity-logs" {
+			t.Errorf("bulk action %d = %v", i, lines[2*i])
+		}
+		document := lines[2*i+1]
+		if document["id"] != batch[i].ID || document["@timestamp"] != batch[i].Timestamp.Format(time.RFC3339Nano) {
+			t.Errorf("bulk document %d = %v", i, document)
+		}
+	}
+}
+
+func TestElasticsearchSinkAPIKeyAndRejectedDocuments(t *testing.T) {
+	server, requests := newCaptureServer(t, `{"errors":true,"items":[`+
+		`{"create":{"status":201}},`+
+		`{"create":{"status":400,"error":{"type":"mapper_parsing_exception"}}}]}`)
+	sink := newTestSink(t, models.LogStreamElasticsearch, map[string]interface{}{"url": server.URL}, "api-key")
+
+	err := sink.Send(context.Background(), testBatch())
+	var sinkErr *LogSinkError
+	if !errors.As(err, &sinkErr) || sinkErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Send = %v, want the rejected document status", err)
+	}
+	if sinkErr.Retryable() || !strings.Contains(sinkErr.Message, "mapper_parsing_exception") {
+		t.Errorf("rejected document error = %+v, want a permanent error with the cause", sinkErr)
+	}
+	if got := requests()[0].header.Get("Authorization"); got != "ApiKey api-key" {
+		t.Errorf("Authorization = %q, want ApiKey api-key", got)
+	}
+	if action, _ := decodeNDJSON(t, requests()[0].body)[0]["create"].(map[string]interface{}); action["_index"] != elasticsearchIndex {
+		t.Errorf("default index = %v, want %s", action["_index"], elasticsearchIndex)
+	}
+}
+
+func TestDatadogSink(t *testing.T) {
+	server, requests := newCaptureServer(t, `{}`, http.StatusAccepted)
+	sink := newTestSink(t, models.LogStreamDatadog, map[string]interface{}{
+		"url": server.URL + "/api/v2/logs", "service": "identity", "tags": "env:test",
+	}, "dd-key")
+
+	if err := sink.Send(context.Background(), testBatch()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	req := requests()[0]
+	if req.path != "/api/v2/logs" {
+		t.Errorf("path = %q, want /api/v2/logs", req.path)
+	}
+	if got := req.header.Get("DD-API-KEY"); got != "dd-key" {
+		t.Errorf("DD-API-KEY = %q, want dd-key", got)
+	}
+
+	var logs []map[string]interface{}
+	if err := json.Unmarshal(req.body, &logs); err != nil {
+		t.Fatalf("body is not a JSON array: %v", err)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("received %d logs, want 2", len(logs))
+	}
+	// Le message reprend les détails, ou le nom de l'événement à défaut
+	wantMessages := []string{"Login succeeded", "auth.login_failed"}
+	for i, entry := range logs {
+		if entry["ddsource"] != logSinkSource || entry["service"] != "identity" || entry["ddtags"] != "env:test" {
+			t.Errorf("log %d metadata = %v", i, entry)
+		}
+		if entry["status"] != testBatch()[i].Level || entry["message"] != wantMessages[i] {
+			t.Errorf("log %d status/message = %v/%v", i, entry["status"], entry["message"])
+		}
+	}
+}
+
+func TestDatadogSinkDefaultSite(t *testing.T) {
+	sink := newTestSink(t, models.LogStreamDatadog, map[string]interface{}{"site": "datadoghq.eu"}, "dd-key")
+	if got := sink.(*datadogSink).url; got != "https://http-intake.logs.datadoghq.eu/api/v2/logs" {
+		t.Errorf("url = %q", got)
+	}
+}
+
+func TestWebhookSinkSignsPayload(t *testing.T) {
+	server, requests := newCaptureServer(t, "")
+	sink := newTestSink(t, models.LogStreamHTTP, map[string]interface{}{
+		"url": server.URL + "/hook", "headers": map[string]interface{}{"X-Tenant": "acme"},
+	}, "whsec")
+
+	before := time.Now().Unix()
+	if err := sink.Send(context.Background(), testBatch()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	req := requests()[0]
+	if got := req.header.Get("X-Tenant"); got != "acme" {
+		t.Errorf("custom header = %q, want acme", got)
+	}
+
+	timestamp, err := strconv.ParseInt(req.header.Get(TimestampHeader), 10, 64)
+	if err != nil || timestamp < before || timestamp > time.Now().Unix() {
+		t.Fatalf("%s = %q, want the send time", TimestampHeader, req.header.Get(TimestampHeader))
+	}
+	// Le destinataire vérifie HMAC-SHA256("<timestamp>.<corps>") avec le secret partagé
+	mac := hmac.New(sha256.New, []byte("whsec"))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "." + string(req.body)))
+	want := "v1=" + hex.EncodeToString(mac.Sum(nil))
+	if got := req.header.Get(SignatureHeader); got != want {
+		t.Errorf("%s = %q, want %q", SignatureHeader, got, want)
+	}
+
+	var batch []StreamMessage
+	if err := json.Unmarshal(req.body, &batch); err != nil || len(batch) != 2 {
+		t.Errorf("body = %s, want the batch as a JSON array", req.body)
+	}
+}
+
+func TestWebhookSinkWithoutSecretIsUnsigned(t *testing.T) {
+	server, requests := newCaptureServer(t, "")
+	sink := newTestSink(t, models.LogStreamHTTP, map[string]interface{}{"url": server.URL}, "")
+
+	if err := sink.Send(context.Background(), testBatch()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	header := requests()[0].header
+	if header.Get(SignatureHeader) != "" || header.Get(TimestampHeader) != "" {
+		t.Errorf("unsigned stream sent signature headers: %v", header)
+	}
+}
+
+func TestSignPayload(t *testing.T) {
+	body := []byte(`[{"id":"log-1"}]`)
+	signature := SignPayload("whsec", 1700000000, body)
+	if !strings.HasPrefix(signature, "v1=") || len(signature) != len("v1=")+64 {
+		t.Fatalf("signature = %q, want v1=<hex sha256>", signature)
+	}
+	if SignPayload("whsec", 1700000000, body) != signature {
+		t.Error("signature is not deterministic")
+	}
+	if SignPayload("whsec", 1700000001, body) == signature {
+		t.Error("signature does not cover the timestamp")
+	}
+	if SignPayload("other", 1700000000, body) == signature {
+		t.Error("signature does not depend on the secret")
+	}
+	if SignPayload("whsec", 1700000000, []byte(`[{"id":"log-2"}]`)) == signature {
+		t.Error("signature does not cover the body")
+	}
+}
+
+func TestLogSinkErrorRetryable(t *testing.T) {
+	cases := []struct {
+		status    int
+		retryable bool
+	}{
+		{http.StatusBadRequest, false},
+		{http.StatusUnauthorized, false},
+		{http.StatusForbidden, false},
+		{http.StatusRequestEntityTooLarge, false},
+		{http.StatusRequestTimeout, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusServiceUnavailable, true},
+	}
+	for _, tc := range cases {
+		server, _ := newCaptureServer(t, "rejected", tc.status)
+		sink := newTestSink(t, models.LogStreamHTTP, map[string]interface{}{"url": server.URL}, "")
+
+		err := sink.Send(context.Background(), testBatch())
+		var sinkErr *LogSinkError
+		if !errors.As(err, &sinkErr) || sinkErr.StatusCode != tc.status || sinkErr.Message != "rejected" {
+			t.Errorf("HTTP %d: Send = %v", tc.status, err)
+			continue
+		}
+		if logSinkRetryable(err) != tc.retryable {
+			t.Errorf("HTTP %d: retryable = %v, want %v", tc.status, !tc.retryable, tc.retryable)
+		}
+	}
+
+	// Une destination injoignable est retentée
+	server, _ := newCaptureServer(t, "")
+	url := server.URL
+	server.Close()
+	sink := newTestSink(t, models.LogStreamHTTP, map[string]interface{}{"url": url}, "")
+	if err := sink.Send(context.Background(), testBatch()); err == nil || !logSinkRetryable(err) {
+		t.Errorf("unreachable endpoint: Send = %v, want a retryable error", err)
+	}
+}
+
+func TestNewLogSinkValidation(t *testing.T) {
+	cases := []struct {
+		name   string
+		stream models.LogStream
+	}{
+		{"syslog protocol", models.LogStream{Type: models.LogStreamSyslog, Config: map[string]interface{}{"address": "127.0.0.1:514", "protocol": "relp"}}},
+		{"syslog address", models.LogStream{Type: models.LogStreamSyslog, Config: map[string]interface{}{"address": "localhost"}}},
+		{"splunk token", models.LogStream{Type: models.LogStreamSplunk, Config: map[string]interface{}{"url": "https://splunk.example.com:8088"}}},
+		{"elasticsearch url", models.LogStream{Type: models.LogStreamElasticsearch, Config: map[string]interface{}{"url": "es.example.com"}}},
+		{"datadog api key", models.LogStream{Type: models.LogStreamDatadog}},
+		{"http url", models.LogStream{Type: models.LogStreamHTTP, Config: map[string]interface{}{"url": "ftp://example.com"}}},
+		{"type", models.LogStream{Type: "kafka"}},
+	}
+	for _, tc := range cases {
+		if _, err := NewLogSink(&tc.stream); err == nil {
+			t.Errorf("%s: NewLogSink accepted an invalid configuration", tc.name)
+		}
+	}
+}
+
+// deadLetterRecorder capture les lots placés en file d'erreurs sans base de données :
+// les requêtes sont construites en mode DryRun et les créations de LogStreamDeadLetter relevées
+type deadLetterRecorder struct {
+	mu      sync.Mutex
+	letters []models.LogStreamDeadLetter
+}
+
+func newDeadLetterRecorder(t *testing.T) (*gorm.DB, *deadLetterRecorder) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 user=test dbname=test sslmode=disable"}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := &deadLetterRecorder{}
+	err = db.Callback().Create().After("gorm:create").Register("test:dead_letters", func(tx *gorm.DB) {
+		if letter, ok := tx.Statement.Dest.(*models.LogStreamDeadLetter); ok {
+			recorder.mu.Lock()
+			recorder.letters = append(recorder.letters, *letter)
+			recorder.mu.Unlock()
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, recorder
+}
+
+func (r *deadLetterRecorder) all() []models.LogStreamDeadLetter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.LogStreamDeadLetter(nil), r.letters...)
+}
+
+func newTestWorker(t *testing.T, url string, maxRetries int) (*logStreamWorker, *deadLetterRecorder) {
+	t.Helper()
+	db, recorder := newDeadLetterRecorder(t)
+	stream := models.LogStream{ID: "stream-1", Name: "siem", Type: models.LogStreamHTTP,
+		Config: map[string]interface{}{"url": url}, MaxRetries: maxRetries, Status: models.LogStreamHealthy}
+	sink, err := NewLogSink(&stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sink.Close() })
+	return &logStreamWorker{service: NewLogStreamService(db), stream: stream, sink: sink, health: stream.Status}, recorder
+}
+
+func TestLogStreamWorkerRetriesTransientFailures(t *testing.T) {
+	server, requests := newCaptureServer(t, "", http.StatusServiceUnavailable)
+	worker, recorder := newTestWorker(t, server.URL, 3)
+
+	worker.deliver(context.Background(), testBatch())
+
+	if got := len(requests()); got != 2 {
+		t.Errorf("sent %d requests, want 2 (one 503, then success)", got)
+	}
+	if letters := recorder.all(); len(letters) != 0 {
+		t.Errorf("delivered batch was dead-lettered: %+v", letters)
+	}
+	if worker.health != models.LogStreamHealthy || worker.failures != 0 {
+		t.Errorf("health = %s with %d failures, want healthy", worker.health, worker.failures)
+	}
+}
+
+func TestLogStreamWorkerDeadLettersAfterMaxRetries(t *testing.T) {
+	server, requests := newCaptureServer(t, "unavailable",
+		http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	worker, recorder := newTestWorker(t, server.URL, 2)
+
+	batch := testBatch()
+	worker.deliver(context.Background(), batch)
+
+	if got := len(requests()); got != 2 {
+		t.Errorf("sent %d requests, want maxRetries = 2", got)
+	}
+	letters := recorder.all()
+	if len(letters) != 1 {
+		t.Fatalf("dead-lettered %d batches, want 1", len(letters))
+	}
+	assertDeadLetter(t, letters[0], batch, 2, "HTTP 503: unavailable")
+	if worker.health != models.LogStreamDown || worker.failures != 2 {
+		t.Errorf("health = %s with %d failures, want down with 2", worker.health, worker.failures)
+	}
+}
+
+func TestLogStreamWorkerDeadLettersPermanentFailuresImmediately(t *testing.T) {
+	server, requests := newCaptureServer(t, "bad request", http.StatusBadRequest)
+	worker, recorder := newTestWorker(t, server.URL, 5)
+
+	batch := testBatch()
+	worker.deliver(context.Background(), batch)
+
+	if got := len(requests()); got != 1 {
+		t.Errorf("sent %d requests, want 1: a 400 is not retried", got)
+	}
+	letters := recorder.all()
+	if len(letters) != 1 {
+		t.Fatalf("dead-lettered %d batches, want 1", len(letters))
+	}
+	assertDeadLetter(t, letters[0], batch, 1, "HTTP 400: bad request")
+}
+
+func TestLogStreamWorkerDeadLettersOnStop(t *testing.T) {
+	server, requests := newCaptureServer(t, "", http.StatusServiceUnavailable)
+	worker, recorder := newTestWorker(t, server.URL, 5)
+
+	// Le flux s'arrête pendant l'attente avant la deuxième tentative
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	batch := testBatch()
+	worker.deliver(ctx, batch)
+
+	if got := len(requests()); got != 1 {
+		t.Errorf("sent %d requests, want 1 before the stop", got)
+	}
+	letters := recorder.all()
+	if len(letters) != 1 {
+		t.Fatalf("dead-lettered %d batches, want 1", len(letters))
+	}
+	assertDeadLetter(t, letters[0], batch, 1, "HTTP 503: ")
+}
+
+func assertDeadLetter(t *testing.T, letter models.LogStreamDeadLetter, batch []StreamMessage, attempts int, cause string) {
+	t.Helper()
+	if letter.StreamID != "stream-1" || letter.Count != len(batch) || letter.Attempts != attempts || letter.Error != cause {
+		t.Errorf("dead letter = {stream %s, count %d, attempts %d, error %q}, want {stream-1, %d, %d, %q}",
+			letter.StreamID, letter.Count, letter.Attempts, letter.Error, len(batch), attempts, cause)
+	}
+	var messages []StreamMessage
+	if err := json.Unmarshal(letter.Messages, &messages); err != nil || len(messages) != len(batch) || messages[0].ID != batch[0].ID {
+		t.Errorf("dead letter messages = %s, want the undelivered batch", letter.Messages)
+	}
+}
+
+func TestLogStreamBackoff(t *testing.T) {
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 10: time.Minute} {
+		got := logStreamBackoff(attempt)
+		if got < want || got > want+want/10 {
+			t.Errorf("logStreamBackoff(%d) = %v, want %v plus at most 10%% jitter", attempt, got, want)
+		}
+	}
+}
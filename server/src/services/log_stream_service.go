@@ -0,0 +1,527 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"gorm.io/gorm"
+)
+
+var ErrLogStreamNotFound = errors.New("log stream not found")
+
+const (
+	logStreamQueuedBatches  = 32 // Lots en attente d'envoi ; au-delà, ils partent en file d'erreurs
+	logStreamBaseBackoff    = time.Second
+	logStreamMaxBackoff     = time.Minute
+	logStreamHealthInterval = 30 * time.Second // Fréquence maximale d'écriture de l'état lorsqu'il ne change pas
+	logStreamMonitorPrefix  = "log-stream:"
+)
+
+// LogStreamService gère les flux de logs et leur acheminement vers les SIEM
+type LogStreamService struct {
+	DB *gorm.DB
+}
+
+// NewLogStreamService crée une nouvelle instance de LogStreamService
+func NewLogStreamService(db *gorm.DB) *LogStreamService {
+	return &LogStreamService{DB: db}
+}
+
+// ListStreams liste les flux configurés
+func (s *LogStreamService) ListStreams() ([]models.LogStream, error) {
+	var streams []models.LogStream
+	if err := s.DB.Order("name ASC").Find(&streams).Error; err != nil {
+		return nil, err
+	}
+	for i := range streams {
+		streams[i].HasSecret = deref(streams[i].Secret) != ""
+	}
+	return streams, nil
+}
+
+// GetStream récupère un flux
+func (s *LogStreamService) GetStream(id string) (*models.LogStream, error) {
+	var stream models.LogStream
+	if err := s.DB.First(&stream, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLogStreamNotFound
+		}
+		return nil, err
+	}
+	stream.HasSecret = deref(stream.Secret) != ""
+	return &stream, nil
+}
+
+// SaveStream crée (id vide) ou met à jour un flux, puis recharge les flux actifs
+func (s *LogStreamService) SaveStream(id string, req *models.LogStreamRequest) (*models.LogStream, error) {
+	stream := &models.LogStream{BatchSize: 100, FlushInterval: 5, MaxRetries: 5, Enabled: true, Status: models.LogStreamHealthy}
+	if id != "" {
+		existing, err := s.GetStream(id)
+		if err != nil {
+			return nil, err
+		}
+		stream = existing
+	}
+
+	if req.Name != nil {
+		stream.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Type != nil {
+		stream.Type = strings.ToLower(*req.Type)
+	}
+	if req.Enabled != nil {
+		stream.Enabled = *req.Enabled
+	}
+	if req.Config != nil {
+		stream.Config = req.Config
+	}
+	if req.Secret != nil {
+		stream.Secret = req.Secret
+	}
+	if req.Kinds != nil {
+		stream.Kinds = req.Kinds
+	}
+	if req.Events != nil {
+		stream.Events = req.Events
+	}
+	if req.Levels != nil {
+		stream.Levels = req.Levels
+	}
+	if req.BatchSize != nil {
+		stream.BatchSize = *req.BatchSize
+	}
+	if req.FlushInterval != nil {
+		stream.FlushInterval = *req.FlushInterval
+	}
+	if req.MaxRetries != nil {
+		stream.MaxRetries = *req.MaxRetries
+	}
+
+	if err := validateLogStream(stream); err != nil {
+		return nil, err
+	}
+	if !stream.Enabled {
+		stream.Status = models.LogStreamPaused
+	} else if stream.Status == models.LogStreamPaused {
+		stream.Status = models.LogStreamHealthy
+	}
+
+	var err error
+	if stream.ID == "" {
+		err = s.DB.Create(stream).Error
+	} else {
+		err = s.DB.Save(stream).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	stream.HasSecret = deref(stream.Secret) != ""
+	s.Reload()
+	return stream, nil
+}
+
+// DeleteStream supprime un flux, sa file d'erreurs et son état de supervision
+func (s *LogStreamService) DeleteStream(id string) error {
+	stream, err := s.GetStream(id)
+	if err != nil {
+		return err
+	}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("stream_id = ?", id).Delete(&models.LogStreamDeadLetter{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("service = ?", logStreamMonitorPrefix+stream.Name).Delete(&models.MonitoringStatus{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.LogStream{}, "id = ?", id).Error
+	})
+	if err != nil {
+		return err
+	}
+	s.Reload()
+	return nil
+}
+
+// TestStream envoie un message de test à la destination et renvoie l'éventuelle erreur de livraison
+func (s *LogStreamService) TestStream(id string) error {
+	stream, err := s.GetStream(id)
+	if err != nil {
+		return err
+	}
+	sink, err := NewLogSink(stream)
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), logSinkTimeout)
+	defer cancel()
+	return sink.Send(ctx, []StreamMessage{{
+		EventID:   "test",
+		Kind:      StreamKindLog,
+		Timestamp: time.Now().UTC(),
+		Level:     string(models.LogLevelInfo),
+		Event:     "log_stream.test",
+		Details:   fmt.Sprintf("Test message from log stream %s", stream.Name),
+	}})
+}
+
+// ListDeadLetters liste les lots non livrés d'un flux
+func (s *LogStreamService) ListDeadLetters(streamID string, page, limit int) ([]models.LogStreamDeadLetter, int64, error) {
+	var letters []models.LogStreamDeadLetter
+	var total int64
+
+	query := s.DB.Model(&models.LogStreamDeadLetter{}).Where("stream_id = ?", streamID)
+	query.Count(&total)
+	if err := query.Order("created_at ASC").Offset((page - 1) * limit).Limit(limit).Find(&letters).Error; err != nil {
+		return nil, 0, err
+	}
+	return letters, total, nil
+}
+
+// ReplayDeadLetters renvoie les lots non livrés, du plus ancien au plus récent, et supprime ceux qui passent.
+// Le rejeu s'arrête au premier échec ; il renvoie le nombre de lots livrés.
+func (s *LogStreamService) ReplayDeadLetters(streamID string) (int, error) {
+	stream, err := s.GetStream(streamID)
+	if err != nil {
+		return 0, err
+	}
+	sink, err := NewLogSink(stream)
+	if err != nil {
+		return 0, err
+	}
+	defer sink.Close()
+
+	var letters []models.LogStreamDeadLetter
+	if err := s.DB.Where("stream_id = ?", streamID).Order("created_at ASC").Find(&letters).Error; err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for i := range letters {
+		var batch []StreamMessage
+		if err := json.Unmarshal(letters[i].Messages, &batch); err != nil {
+			return replayed, err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), logSinkTimeout)
+		err := sink.Send(ctx, batch)
+		cancel()
+		if err != nil {
+			s.DB.Model(&letters[i]).UpdateColumns(map[string]interface{}{"attempts": letters[i].Attempts + 1, "error": err.Error()})
+			return replayed, err
+		}
+		if err := s.DB.Delete(&letters[i]).Error; err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// PurgeDeadLetters supprime les lots non livrés d'un flux
+func (s *LogStreamService) PurgeDeadLetters(streamID string) (int64, error) {
+	result := s.DB.Where("stream_id = ?", streamID).Delete(&models.LogStreamDeadLetter{})
+	return result.RowsAffected, result.Error
+}
+
+// logStreamManager fait tourner un worker par flux actif dans ce processus
+type logStreamManager struct {
+	mu      sync.Mutex
+	ctx     context.Context // Nil tant que Start n'a pas été appelé
+	workers map[string]*logStreamWorker
+}
+
+var logStreams = &logStreamManager{workers: make(map[string]*logStreamWorker)}
+
+// Start démarre l'acheminement des flux actifs et recharge périodiquement leur configuration,
+// pour prendre en compte les modifications faites depuis une autre instance
+func (s *LogStreamService) Start(ctx context.Context, interval time.Duration) {
+	logStreams.mu.Lock()
+	logStreams.ctx = ctx
+	logStreams.mu.Unlock()
+	s.Reload()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				logStreams.mu.Lock()
+				for id, worker := range logStreams.workers {
+					worker.stop()
+					delete(logStreams.workers, id)
+				}
+				logStreams.mu.Unlock()
+				return
+			case <-ticker.C:
+				s.Reload()
+			}
+		}
+	}()
+}
+
+// Reload aligne les workers sur les flux actifs : démarre les nouveaux, redémarre les modifiés et arrête les autres
+func (s *LogStreamService) Reload() {
+	logStreams.mu.Lock()
+	defer logStreams.mu.Unlock()
+	if logStreams.ctx == nil {
+		return
+	}
+
+	var streams []models.LogStream
+	if err := s.DB.Where("enabled = ?", true).Find(&streams).Error; err != nil {
+		fmt.Printf("LogStream: failed to load streams: %v\n", err)
+		return
+	}
+
+	// Les workers survivent à la requête qui a pu déclencher le rechargement
+	workerService := &LogStreamService{DB: s.DB.WithContext(context.Background())}
+
+	active := make(map[string]bool, len(streams))
+	for i := range streams {
+		stream := streams[i]
+		active[stream.ID] = true
+		if worker, ok := logStreams.workers[stream.ID]; ok {
+			if worker.stream.UpdatedAt.Equal(stream.UpdatedAt) {
+				continue
+			}
+			worker.stop()
+			delete(logStreams.workers, stream.ID)
+		}
+
+		sink, err := NewLogSink(&stream)
+		if err != nil {
+			s.recordHealth(&stream, models.LogStreamDown, err)
+			continue
+		}
+		worker := &logStreamWorker{service: workerService, stream: stream, sink: sink, health: stream.Status}
+		worker.start(logStreams.ctx)
+		logStreams.workers[stream.ID] = worker
+	}
+	for id, worker := range logStreams.workers {
+		if !active[id] {
+			worker.stop()
+			delete(logStreams.workers, id)
+		}
+	}
+}
+
+// logStreamWorker regroupe par lots les messages du bus correspondant au filtre du flux et les livre
+type logStreamWorker struct {
+	service *LogStreamService
+	stream  models.LogStream
+	sink    LogSink
+
+	cancel context.CancelFunc
+
+	health      string
+	failures    int
+	healthWrite time.Time
+}
+
+func (w *logStreamWorker) start(parent context.Context) {
+	ctx, cancel := context.WithCancel(parent)
+	w.cancel = cancel
+
+	subscription, _, _ := DefaultLogBus.Subscribe(StreamFilter{
+		Kinds:  w.stream.Kinds,
+		Events: w.stream.Events,
+		Levels: w.stream.Levels,
+	}, "", 0)
+	batches := make(chan []StreamMessage, logStreamQueuedBatches)
+
+	go func() {
+		defer w.sink.Close()
+		for batch := range batches {
+			w.deliver(ctx, batch)
+		}
+	}()
+
+	go func() {
+		defer close(batches)
+		defer subscription.Close()
+
+		ticker := time.NewTicker(time.Duration(w.stream.FlushInterval) * time.Second)
+		defer ticker.Stop()
+
+		var batch []StreamMessage
+		flush := func() {
+			if len(batch) == 0 {
+				return
+			}
+			select {
+			case batches <- batch:
+			default:
+				w.deadLetter(batch, 0, errors.New("delivery queue full"))
+			}
+			batch = nil
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				flush()
+				return
+			case <-ticker.C:
+				flush()
+			case msg, ok := <-subscription.Messages():
+				if !ok {
+					flush()
+					return
+				}
+				batch = append(batch, msg)
+				if len(batch) >= w.stream.BatchSize {
+					flush()
+				}
+				if dropped := subscription.TakeDropped(); dropped > 0 {
+					fmt.Printf("LogStream %s: dropped %d messages, the stream cannot keep up\n", w.stream.Name, dropped)
+				}
+			}
+		}
+	}()
+}
+
+// stop arrête le worker sans attendre : les lots en attente sont tentés une fois en arrière-plan,
+// puis placés en file d'erreurs en cas d'échec
+func (w *logStreamWorker) stop() {
+	w.cancel()
+}
+
+// deliver envoie un lot en réessayant avec un délai exponentiel, puis le place en file d'erreurs
+func (w *logStreamWorker) deliver(ctx context.Context, batch []StreamMessage) {
+	var err error
+	attempt := 0
+	for attempt < w.stream.MaxRetries {
+		attempt++
+		sendCtx, cancel := context.WithTimeout(context.Background(), logSinkTimeout)
+		err = w.sink.Send(sendCtx, batch)
+		cancel()
+		if err == nil {
+			w.report(models.LogStreamHealthy, nil)
+			return
+		}
+		if !logSinkRetryable(err) || attempt == w.stream.MaxRetries {
+			break
+		}
+		w.report(models.LogStreamDegraded, err)
+
+		select {
+		case <-ctx.Done():
+			// Arrêt du flux : le lot est conservé pour un rejeu ultérieur
+			w.deadLetter(batch, attempt, err)
+			return
+		case <-time.After(logStreamBackoff(attempt)):
+		}
+	}
+	w.deadLetter(batch, attempt, err)
+	w.report(models.LogStreamDown, err)
+}
+
+// deadLetter conserve un lot non livré
+func (w *logStreamWorker) deadLetter(batch []StreamMessage, attempts int, cause error) {
+	messages, err := json.Marshal(batch)
+	if err == nil {
+		err = w.service.DB.Create(&models.LogStreamDeadLetter{
+			StreamID: w.stream.ID,
+			Messages: messages,
+			Count:    len(batch),
+			Attempts: attempts,
+			Error:    cause.Error(),
+		}).Error
+	}
+	if err != nil {
+		fmt.Printf("LogStream %s: failed to dead-letter %d messages: %v\n", w.stream.Name, len(batch), err)
+	}
+}
+
+// report enregistre l'état du flux lorsqu'il change, et au plus toutes les 30 secondes sinon
+func (w *logStreamWorker) report(status string, err error) {
+	if err != nil {
+		w.failures++
+	} else {
+		w.failures = 0
+	}
+	if status == w.health && time.Since(w.healthWrite) < logStreamHealthInterval {
+		return
+	}
+	w.health = status
+	w.healthWrite = time.Now()
+	w.stream.ConsecutiveFailures = w.failures
+	w.service.recordHealth(&w.stream, status, err)
+}
+
+// recordHealth met à jour l'état du flux et l'entrée MonitoringStatus "log-stream:<nom>"
+func (s *LogStreamService) recordHealth(stream *models.LogStream, status string, cause error) {
+	now := time.Now()
+	updates := map[string]interface{}{"status": status, "consecutive_failures": stream.ConsecutiveFailures}
+	var details *string
+	if cause != nil {
+		message := cause.Error()
+		details = &message
+		updates["last_error"] = message
+	} else {
+		updates["last_delivered_at"] = now
+		updates["last_error"] = nil
+	}
+	// UpdateColumns ne modifie pas updated_at, qui sert à détecter les changements de configuration
+	if err := s.DB.Model(&models.LogStream{}).Where("id = ?", stream.ID).UpdateColumns(updates).Error; err != nil {
+		fmt.Printf("LogStream %s: failed to record health: %v\n", stream.Name, err)
+	}
+
+	monitor := models.MonitoringStatus{Service: logStreamMonitorPrefix + stream.Name}
+	if err := s.DB.Where("service = ?", monitor.Service).FirstOrInit(&monitor).Error; err != nil {
+		return
+	}
+	monitor.Status = status
+	monitor.IsEnabled = stream.Enabled
+	monitor.LastCheck = &now
+	monitor.Details = details
+	if err := s.DB.Save(&monitor).Error; err != nil {
+		fmt.Printf("LogStream %s: failed to update monitoring status: %v\n", stream.Name, err)
+	}
+}
+
+func validateLogStream(stream *models.LogStream) error {
+	if stream.Name == "" {
+		return errors.New("name is required")
+	}
+	if _, err := NewLogSink(stream); err != nil {
+		return err
+	}
+	for _, kind := range stream.Kinds {
+		if !slices.Contains([]string{StreamKindLog, StreamKindEvent, StreamKindSecurity, StreamKindAudit}, kind) {
+			return fmt.Errorf("unsupported kind: %s", kind)
+		}
+	}
+	if stream.BatchSize < 1 || stream.BatchSize > 1000 {
+		return errors.New("batchSize must be between 1 and 1000")
+	}
+	if stream.FlushInterval < 1 || stream.FlushInterval > 300 {
+		return errors.New("flushInterval must be between 1 and 300 seconds")
+	}
+	if stream.MaxRetries < 1 || stream.MaxRetries > 20 {
+		return errors.New("maxRetries must be between 1 and 20")
+	}
+	return nil
+}
+
+// logStreamBackoff calcule le délai exponentiel avant la prochaine tentative
+func logStreamBackoff(attempt int) time.Duration {
+	delay := logStreamBaseBackoff
+	for i := 1; i < attempt && delay < logStreamMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > logStreamMaxBackoff {
+		delay = logStreamMaxBackoff
+	}
+	return delay + rand.N(delay/10+1)
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Préfixe des métriques exposées sur /metrics
+const metricsNamespace = "aether_identity_"
+
+// Résultats des connexions et des défis MFA
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+	LoginLocked  = "locked"
+
+	MfaSuccess = "success"
+	MfaFailure = "failure"
+)
+
+// Connexion utilisée quand l'utilisateur est authentifié par la base locale
+const LocalConnection = "local"
+
+var processStart = time.Now()
+
+// Bornes (en secondes) de l'histogramme de latence des requêtes
+var httpLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var (
+	httpRequestDuration = newHistogramVec("http_request_duration_seconds", "Latency of HTTP requests by route.", httpLatencyBuckets, "method", "route", "status")
+	loginAttempts       = newCounterVec("logins_total", "Login attempts by connection and result.", "connection", "result")
+	tokensIssued        = newCounterVec("tokens_issued_total", "Tokens issued by grant type.", "grant_type")
+	mfaChallenges       = newCounterVec("mfa_challenges_total", "MFA challenges by method and result.", "method", "result")
+)
+
+// ObserveHTTPRequest enregistre la durée d'une requête ; route est le motif gin (/users/:id), pas le chemin
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	httpRequestDuration.observe(duration.Seconds(), method, route, strconv.Itoa(status))
+}
+
+// RecordLogin compte une tentative de connexion ; une connexion vide désigne la base locale
+func RecordLogin(connection, result string) {
+	if connection == "" {
+		connection = LocalConnection
+	}
+	loginAttempts.inc(connection, result)
+}
+
+// RecordTokenIssued compte les jetons délivrés par type de grant OAuth
+func RecordTokenIssued(grantType string) {
+	tokensIssued.inc(grantType)
+}
+
+// RecordMfaChallenge compte l'issue d'un défi MFA
+func RecordMfaChallenge(method, result string) {
+	mfaChallenges.inc(method, result)
+}
+
+// HTTPRequestTotals renvoie le nombre de requêtes servies et leur latence moyenne en secondes
+func HTTPRequestTotals() (uint64, float64) {
+	count, sum := httpRequestDuration.totals()
+	if count == 0 {
+		return 0, 0
+	}
+	return count, sum / float64(count)
+}
+
+// WriteMetrics écrit toutes les métriques au format texte de Prometheus (version 0.0.4).
+// db est facultatif : sans base, les statistiques du pool de connexions sont omises.
+func WriteMetrics(w io.Writer, db *gorm.DB) error {
+	out := bufio.NewWriter(w)
+
+	httpRequestDuration.write(out)
+	loginAttempts.write(out)
+	tokensIssued.write(out)
+	mfaChallenges.write(out)
+
+	if db != nil {
+		if sqlDB, err := db.DB(); err == nil {
+			stats := sqlDB.Stats()
+			writeMetric(out, "db_connections", "gauge", "Database connections by state.",
+				sample{labels: `state="in_use"`, value: float64(stats.InUse)},
+				sample{labels: `state="idle"`, value: float64(stats.Idle)},
+				sample{labels: `state="open"`, value: float64(stats.OpenConnections)},
+			)
+			writeMetric(out, "db_max_open_connections", "gauge", "Maximum number of open database connections.", sample{value: float64(stats.MaxOpenConnections)})
+			writeMetric(out, "db_wait_count_total", "counter", "Connections waited for because the pool was exhausted.", sample{value: float64(stats.WaitCount)})
+			writeMetric(out, "db_wait_duration_seconds_total", "counter", "Time spent waiting for a database connection.", sample{value: stats.WaitDuration.Seconds()})
+			writeMetric(out, "db_closed_connections_total", "counter", "Connections closed by the pool limits.",
+				sample{labels: `reason="max_idle"`, value: float64(stats.MaxIdleClosed)},
+				sample{labels: `reason="max_idle_time"`, value: float64(stats.MaxIdleTimeClosed)},
+				sample{labels: `reason="max_lifetime"`, value: float64(stats.MaxLifetimeClosed)},
+			)
+		}
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	writeMetric(out, "go_goroutines", "gauge", "Number of goroutines.", sample{value: float64(runtime.NumGoroutine())})
+	writeMetric(out, "go_memory_bytes", "gauge", "Memory obtained from the OS and heap in use.",
+		sample{labels: `area="sys"`, value: float64(mem.Sys)},
+		sample{labels: `area="heap_inuse"`, value: float64(mem.HeapInuse)},
+	)
+	writeMetric(out, "go_gc_cycles_total", "counter", "Completed garbage collection cycles.", sample{value: float64(mem.NumGC)})
+	writeMetric(out, "process_start_time_seconds", "gauge", "Start time of the process since the Unix epoch.", sample{value: float64(processStart.Unix())})
+	if cpu, ok := processCPUSeconds(); ok {
+		writeMetric(out, "process_cpu_seconds_total", "counter", "User and system CPU time spent.", sample{value: cpu})
+	}
+
+	return out.Flush()
+}
+
+type sample struct {
+	labels string
+	value  float64
+}
+
+func writeMetric(w io.Writer, name, kind, help string, samples ...sample) {
+	fmt.Fprintf(w, "# HELP %s%s %s\n# TYPE %s%s %s\n", metricsNamespace, name, help, metricsNamespace, name, kind)
+	for _, s := range samples {
+		if s.labels == "" {
+			fmt.Fprintf(w, "%s%s %s\n", metricsNamespace, name, formatMetricValue(s.value))
+		} else {
+			fmt.Fprintf(w, "%s%s{%s} %s\n", metricsNamespace, name, s.labels, formatMetricValue(s.value))
+		}
+	}
+}
+
+func formatMetricValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// formatLabels rend les paires nom="valeur" en échappant les valeurs
+func formatLabels(names, values []string) string {
+	var b strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(values[i])
+		fmt.Fprintf(&b, `%s="%s"`, name, value)
+	}
+	return b.String()
+}
+
+// counterVec est un compteur ventilé par étiquettes
+type counterVec struct {
+	name, help string
+	labels     []string
+	mu         sync.Mutex
+	values     map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{name: name, help: help, labels: labels, values: make(map[string]*counterValue)}
+}
+
+func (c *counterVec) inc(values ...string) {
+	key := strings.Join(values, "\xff")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.values[key]
+	if !ok {
+		entry = &counterValue{labels: values}
+		c.values[key] = entry
+	}
+	entry.value++
+}
+
+func (c *counterVec) write(w io.Writer) {
+	c.mu.Lock()
+	samples := make([]sample, 0, len(c.values))
+	for _, entry := range c.values {
+		samples = append(samples, sample{labels: formatLabels(c.labels, entry.labels), value: entry.value})
+	}
+	c.mu.Unlock()
+	slices.SortFunc(samples, func(a, b sample) int { return strings.Compare(a.labels, b.labels) })
+	writeMetric(w, c.name, "counter", c.help, samples...)
+}
+
+// histogramVec est un histogramme cumulatif ventilé par étiquettes
+type histogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+	mu         sync.Mutex
+	values     map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64 // Une case par borne ; le dépassement n'est compté que dans count
+	count  uint64
+	sum    float64
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	return &histogramVec{name: name, help: help, labels: labels, buckets: buckets, values: make(map[string]*histogramValue)}
+}
+
+func (h *histogramVec) observe(value float64, values ...string) {
+	key := strings.Join(values, "\xff")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entry, ok := h.values[key]
+	if !ok {
+		entry = &histogramValue{labels: values, counts: make([]uint64, len(h.buckets))}
+		h.values[key] = entry
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			entry.counts[i]++
+		}
+	}
+	entry.count++
+	entry.sum += value
+}
+
+func (h *histogramVec) totals() (uint64, float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var count uint64
+	var sum float64
+	for _, entry := range h.values {
+		count += entry.count
+		sum += entry.sum
+	}
+	return count, sum
+}
+
+func (h *histogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	entries := make([]histogramValue, 0, len(h.values))
+	for _, entry := range h.values {
+		copied := *entry
+		copied.counts = slices.Clone(entry.counts)
+		entries = append(entries, copied)
+	}
+	h.mu.Unlock()
+	slices.SortFunc(entries, func(a, b histogramValue) int {
+		return strings.Compare(strings.Join(a.labels, "\xff"), strings.Join(b.labels, "\xff"))
+	})
+
+	name := metricsNamespace + h.name
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, h.help, name)
+	for _, entry := range entries {
+		labels := formatLabels(h.labels, entry.labels)
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatMetricValue(bound), entry.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, entry.count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, formatMetricValue(entry.sum))
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, entry.count)
+	}
+}
+
+// processCPUSeconds lit le temps CPU du processus dans /proc (Linux) ; ok est faux ailleurs
+func processCPUSeconds() (float64, bool) {
+	data, err := os.ReadFile("/proc/self/stat")
+	if err != nil {
+		return 0, false
+	}
+	// Le nom du processus, entre parenthèses, peut contenir des espaces : on lit après la dernière
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+	if len(fields) < 13 {
+		return 0, false
+	}
+	utime, err1 := strconv.ParseFloat(fields[11], 64)
+	stime, err2 := strconv.ParseFloat(fields[12], 64)
+	if err1 != nil || err2 != nil {
+		return 0, false
+	}
+	// USER_HZ vaut 100 sur les architectures Linux courantes
+	return (utime + stime) / 100, true
+}
+
+// cpuSampler calcule l'utilisation CPU du processus entre deux appels
+var cpuSampler struct {
+	mu      sync.Mutex
+	at      time.Time
+	seconds float64
+}
+
+// ProcessCPUPercent renvoie l'utilisation CPU du processus depuis l'appel précédent, en pourcentage
+// d'un cœur, et -1 si elle n'est pas mesurable sur ce système
+func ProcessCPUPercent() float64 {
+	seconds, ok := processCPUSeconds()
+	if !ok {
+		return -1
+	}
+	now := time.Now()
+	cpuSampler.mu.Lock()
+	defer cpuSampler.mu.Unlock()
+	prevAt, prevSeconds := cpuSampler.at, cpuSampler.seconds
+	if prevAt.IsZero() {
+		prevAt, prevSeconds = processStart, 0
+	}
+	cpuSampler.at, cpuSampler.seconds = now, seconds
+	elapsed := now.Sub(prevAt).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return math.Round((seconds-prevSeconds)/elapsed*10000) / 100
+}
@@ -161,7 +161,18 @@ func (s *OAuthService) GetRefreshTokenByToken(token string) (*models.OAuthRefres
 
 // RevokeRefreshToken révoque un token de rafraîchissement
 func (s *OAuthService) RevokeRefreshToken(token string) error {
-	return s.DB.Where("token = ?", token).Delete(&models.OAuthRefreshToken{}).Error
+	var refreshToken models.OAuthRefreshToken
+	if err := s.DB.Where("token = ?", token).First(&refreshToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if err := s.DB.Delete(&refreshToken).Error; err != nil {
+		return err
+	}
+	NotifyUserWebhooks(s.DB, models.WebhookEventSessionRevoked, refreshToken.UserID, map[string]interface{}{"client_id": refreshToken.ClientID})
+	return nil
 }
 
 // CreateConsent crée un consentement utilisateur
@@ -218,6 +229,12 @@ func (s *OAuthService) ValidateScopes(requestedScopes, allowedScopes []string) (
 
 // GenerateIDToken génère un ID token OpenID Connect
 func (s *OAuthService) GenerateIDToken(user *models.User, client *models.OAuthClient, scopes []string, nonce string) (string, error) {
+	return s.GenerateIDTokenWithClaims(user, client, scopes, nonce, nil)
+}
+
+// GenerateIDTokenWithClaims génère un ID token OpenID Connect enrichi de claims personnalisés
+// (ajoutés par les actions), sans écraser les claims standards
+func (s *OAuthService) GenerateIDTokenWithClaims(user *models.User, client *models.OAuthClient, scopes []string, nonce string, extra map[string]interface{}) (string, error) {
 	claims := jwt.MapClaims{
 		"sub":            user.ID,
 		"email":          user.Email,
@@ -232,6 +249,11 @@ func (s *OAuthService) GenerateIDToken(user *models.User, client *models.OAuthCl
 	if nonce != "" {
 		claims["nonce"] = nonce
 	}
+	for name, value := range extra {
+		if _, exists := claims[name]; !exists {
+			claims[name] = value
+		}
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.JWTService.SecretKey))
@@ -239,16 +261,61 @@ func (s *OAuthService) GenerateIDToken(user *models.User, client *models.OAuthCl
 
 // GenerateAccessToken génère un token d'accès OAuth2
 func (s *OAuthService) GenerateAccessToken(user *models.User, client *models.OAuthClient, scopes []string) (string, error) {
+	return s.GenerateAccessTokenWithClaims(user, client, scopes, nil)
+}
+
+// GenerateAccessTokenWithClaims génère un token d'accès OAuth2 enrichi de claims personnalisés
+// (ajoutés par les actions) ; sans utilisateur (client credentials), le sujet est le client
+func (s *OAuthService) GenerateAccessTokenWithClaims(user *models.User, client *models.OAuthClient, scopes []string, extra map[string]interface{}) (string, error) {
 	claims := jwt.MapClaims{
-		"sub":            user.ID,
-		"email":          user.Email,
-		"name":           user.Name,
-		"email_verified": user.EmailVerified,
-		"client_id":      client.ClientID,
-		"scopes":         strings.Join(scopes, " "),
-		"exp":            time.Now().Add(time.Duration(config.LoadConfig().AccessTokenExp) * time.Minute).Unix(),
-		"iat":            time.Now().Unix(),
-		"token_type":     "access_token",
+		"sub":        client.ClientID,
+		"client_id":  client.ClientID,
+		"scopes":     strings.Join(scopes, " "),
+		"exp":        time.Now().Add(time.Duration(config.LoadConfig().AccessTokenExp) * time.Minute).Unix(),
+		"iat":        time.Now().Unix(),
+		"token_type": "access_token",
+	}
+	if user != nil {
+		claims["sub"] = user.ID
+		claims["email"] = user.Email
+		claims["name"] = user.Name
+		claims["email_verified"] = user.EmailVerified
+	}
+	for name, value := range extra {
+		if _, exists := claims[name]; !exists {
+			claims[name] = value
+		}
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.JWTService.SecretKey))
+}
+
+// GenerateFederatedAccessToken génère un token d'accès pour une identité fédérée (workload sans utilisateur).
+// Le sujet est celui de l'assertion, par exemple "system:serviceaccount:<namespace>:<name>" ;
+// pour un pipeline CI, le token porte aussi le dépôt, la branche et l'environnement de la politique.
+func (s *OAuthService) GenerateFederatedAccessToken(identity *FederatedIdentity, scopes []string, audience string) (string, error) {
+	claims := jwt.MapClaims{
+		"sub":              identity.Subject,
+		"client_id":        identity.ClientID,
+		"scopes":           strings.Join(scopes, " "),
+		"federated_issuer": identity.Issuer.Issuer,
+		"exp":              time.Now().Add(time.Duration(config.LoadConfig().AccessTokenExp) * time.Minute).Unix(),
+		"iat":              time.Now().Unix(),
+		"token_type":       "access_token",
+	}
+	if audience != "" {
+		claims["aud"] = audience
+	}
+	if identity.Policy != nil {
+		claims["trust_policy"] = identity.Policy.Name
+		claims["repository"] = identity.Attributes.Repository
+		if identity.Attributes.Branch != "" {
+			claims["branch"] = identity.Attributes.Branch
+		}
+		if identity.Attributes.Environment != "" {
+			claims["environment"] = identity.Attributes.Environment
+		}
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrUnsupportedPasswordHash est renvoyée quand le format du hash n'est pas reconnu
+var ErrUnsupportedPasswordHash = errors.New("unsupported password hash format")
+
+// Bornes des hashes vérifiés : une empreinte trop courte accepterait n'importe quel mot de passe,
+// et des paramètres excessifs feraient consommer à chaque connexion une mémoire ou un temps CPU illimité
+const (
+	passwordHashMinDigest    = 16
+	passwordHashMaxDigest    = 128
+	passwordHashMaxMemoryKiB = 256 << 10 // 256 Mio pour argon2 (m) et scrypt (128·N·r)
+	argon2MaxIterations      = 16
+	argon2MaxParallelism     = 16
+	scryptMaxLogN            = 20
+	scryptMaxR               = 32
+	scryptMaxP               = 16
+	pbkdf2MaxIterations      = 10_000_000
+)
+
+// VerifyPasswordHash vérifie un mot de passe contre un hash bcrypt, scrypt, PBKDF2 ou argon2.
+// Les formats hérités acceptés sont :
+//   - bcrypt : $2a$, $2b$, $2y$
+//   - argon2 : $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash> (et $argon2i$)
+//   - scrypt : $scrypt$ln=15,r=8,p=1$<salt>$<hash>
+//   - PBKDF2 : $pbkdf2-sha256$<iterations>$<salt>$<hash> (passlib) ou pbkdf2_sha256$<iterations>$<salt>$<hash> (Django)
+func VerifyPasswordHash(encoded, password string) (bool, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return verifyBcrypt(encoded, password)
+	case strings.HasPrefix(encoded, "$argon2"):
+		return verifyArgon2(encoded, password)
+	case strings.HasPrefix(encoded, "$scrypt$"):
+		return verifyScrypt(encoded, password)
+	case strings.HasPrefix(encoded, "$pbkdf2"):
+		return verifyPasslibPBKDF2(encoded, password)
+	case strings.HasPrefix(encoded, "pbkdf2_"):
+		return verifyDjangoPBKDF2(encoded, password)
+	}
+	return false, ErrUnsupportedPasswordHash
+}
+
+// IsBcryptHash indique si le hash est déjà au format bcrypt utilisé nativement
+func IsBcryptHash(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// verifyBcrypt vérifie un hash bcrypt
+func verifyBcrypt(encoded, password string) (bool, error) {
+	// Convertir $2b$/$2y$ en $2a$ pour la compatibilité avec les hashes générés par Node.js et PHP
+	hashToCheck := encoded
+	if strings.HasPrefix(hashToCheck, "$2b$") || strings.HasPrefix(hashToCheck, "$2y$") {
+		hashToCheck = "$2a$" + hashToCheck[4:]
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(hashToCheck), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// verifyArgon2 vérifie un hash argon2 au format PHC
+func verifyArgon2(encoded, password string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return false, ErrUnsupportedPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, fmt.Errorf("unsupported argon2 version: %s", parts[2])
+	}
+
+	params := parsePHCParams(parts[3])
+	memory, errM := strconv.ParseUint(params["m"], 10, 32)
+	iterations, errT := strconv.ParseUint(params["t"], 10, 32)
+	parallelism, errP := strconv.ParseUint(params["p"], 10, 8)
+	if errM != nil || errT != nil || errP != nil ||
+		iterations < 1 || iterations > argon2MaxIterations ||
+		parallelism < 1 || parallelism > argon2MaxParallelism ||
+		memory < 8*parallelism || memory > passwordHashMaxMemoryKiB {
+		return false, ErrUnsupportedPasswordHash
+	}
+
+	salt, err := decodePHCBase64(parts[4])
+	if err != nil {
+		return false, err
+	}
+	expected, err := decodePHCBase64(parts[5])
+	if err != nil {
+		return false, err
+	}
+	if !validDigestLength(expected) {
+		return false, ErrUnsupportedPasswordHash
+	}
+
+	var computed []byte
+	switch parts[1] {
+	case "argon2id":
+		computed = argon2.IDKey([]byte(password), salt, uint32(iterations), uint32(memory), uint8(parallelism), uint32(len(expected)))
+	case "argon2i":
+		computed = argon2.Key([]byte(password), salt, uint32(iterations), uint32(memory), uint8(parallelism), uint32(len(expected)))
+	default:
+		return false, ErrUnsupportedPasswordHash
+	}
+
+	return subtle.ConstantTimeCompare(computed, expected) == 1, nil
+}
+
+// verifyScrypt vérifie un hash scrypt au format PHC ($scrypt$ln=..,r=..,p=..$salt$hash)
+func verifyScrypt(encoded, password string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 {
+		return false, ErrUnsupportedPasswordHash
+	}
+
+	params := parsePHCParams(parts[2])
+	logN, errN := strconv.Atoi(params["ln"])
+	r, errR := strconv.Atoi(params["r"])
+	p, errP := strconv.Atoi(params["p"])
+	if errN != nil || errR != nil || errP != nil ||
+		logN <= 0 || logN > scryptMaxLogN || r < 1 || r > scryptMaxR || p < 1 || p > scryptMaxP ||
+		(128<<logN)*r > passwordHashMaxMemoryKiB<<10 {
+		return false, ErrUnsupportedPasswordHash
+	}
+
+	salt, err := decodePHCBase64(parts[3])
+	if err != nil {
+		return false, err
+	}
+	expected, err := decodePHCBase64(parts[4])
+	if err != nil {
+		return false, err
+	}
+	if !validDigestLength(expected) {
+		return false, ErrUnsupportedPasswordHash
+	}
+
+	computed, err := scrypt.Key([]byte(password), salt, 1<<logN, r, p, len(expected))
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(computed, expected) == 1, nil
+}
+
+// verifyPasslibPBKDF2 vérifie un hash PBKDF2 au format passlib ($pbkdf2-sha256$iterations$salt$hash)
+func verifyPasslibPBKDF2(encoded, password string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 {
+		return false, ErrUnsupportedPasswordHash
+	}
+
+	var digest func() hash.Hash
+	switch parts[1] {
+	case "pbkdf2":
+		digest = sha1.New
+	case "pbkdf2-sha256":
+		digest = sha256.New
+	case "pbkdf2-sha512":
+		digest = sha512.New
+	default:
+		return false, ErrUnsupportedPasswordHash
+	}
+
+	iterations, err := strconv.Atoi(parts[2])
+	if err != nil || iterations <= 0 || iterations > pbkdf2MaxIterations {
+		return false, ErrUnsupportedPasswordHash
+	}
+
+	// passlib utilise un base64 adapté où "." remplace "+"
+	salt, err := decodePHCBase64(strings.ReplaceAll(parts[3], ".", "+"))
+	if err != nil {
+		return false, err
+	}
+	expected, err := decodePHCBase64(strings.ReplaceAll(parts[4], ".", "+"))
+	if err != nil {
+		return false, err
+	}
+	if !validDigestLength(expected) {
+		return false, ErrUnsupportedPasswordHash
+	}
+
+	computed, err := pbkdf2.Key(digest, password, salt, iterations, len(expected))
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(computed, expected) == 1, nil
+}
+
+// verifyDjangoPBKDF2 vérifie un hash PBKDF2 au format Django (pbkdf2_sha256$iterations$salt$hash)
+func verifyDjangoPBKDF2(encoded, password string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 {
+		return false, ErrUnsupportedPasswordHash
+	}
+
+	var digest func() hash.Hash
+	switch parts[0] {
+	case "pbkdf2_sha1":
+		digest = sha1.New
+	case "pbkdf2_sha256":
+		digest = sha256.New
+	default:
+		return false, ErrUnsupportedPasswordHash
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 || iterations > pbkdf2MaxIterations {
+		return false, ErrUnsupportedPasswordHash
+	}
+
+	expected, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false, err
+	}
+	if !validDigestLength(expected) {
+		return false, ErrUnsupportedPasswordHash
+	}
+
+	// Django utilise le sel tel quel, sans encodage
+	computed, err := pbkdf2.Key(digest, password, []byte(parts[2]), iterations, len(expected))
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(computed, expected) == 1, nil
+}
+
+// validDigestLength refuse une empreinte vide ou trop courte pour être comparée
+func validDigestLength(digest []byte) bool {
+	return len(digest) >= passwordHashMinDigest && len(digest) <= passwordHashMaxDigest
+}
+
+// parsePHCParams découpe une liste de paramètres PHC de la forme "m=65536,t=3,p=4"
+func parsePHCParams(raw string) map[string]string {
+	params := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			params[key] = value
+		}
+	}
+	return params
+}
+
+// decodePHCBase64 décode un segment base64 avec ou sans padding
+func decodePHCBase64(segment string) ([]byte, error) {
+	if decoded, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(segment, "=")); err == nil {
+		return decoded, nil
+	}
+	return base64.StdEncoding.DecodeString(segment)
+}
@@ -0,0 +1,612 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"gorm.io/gorm"
+)
+
+// Actions reconnues pour une permission "ressource:action" ; "admin" inclut toutes les autres
+const (
+	PermissionActionRead   = "read"
+	PermissionActionWrite  = "write"
+	PermissionActionDelete = "delete"
+	PermissionActionAdmin  = "admin"
+	PermissionWildcard     = "*"
+)
+
+// PermissionTenantGlobal donne accès aux données de tous les tenants ; sans elle, un utilisateur
+// ne voit que celles de son propre tenant
+const PermissionTenantGlobal = "tenant:admin"
+
+// PermissionResources liste les ressources protégées par les routes d'administration
+var PermissionResources = []string{
+	"actions", "activity", "agents", "applications", "audit", "branding", "clients", "connections",
+	"database", "domains", "extensions", "logs", "marketplace", "monitoring", "oauth", "organizations",
+	"roles", "security", "settings", "tenant", "users", "webhooks",
+}
+
+var (
+	ErrInvalidPermission = errors.New("permission must be in the form resource:action")
+	ErrSystemRole        = errors.New("system roles cannot be modified or deleted")
+	ErrSystemRoleAssign  = errors.New("system roles cannot be assigned")
+	ErrPermissionUnknown = errors.New("unknown permission")
+	ErrPermissionNotHeld = errors.New("cannot grant a permission you do not hold")
+)
+
+const permissionCacheTTL = time.Minute
+
+type permissionCacheEntry struct {
+	permissions []string
+	expiresAt   time.Time
+}
+
+// permissionCache conserve les permissions effectives par utilisateur et organisation
+var permissionCache = struct {
+	sync.RWMutex
+	entries map[string]permissionCacheEntry
+}{entries: make(map[string]permissionCacheEntry)}
+
+// InvalidatePermissionCache vide le cache après une modification de rôles ou de permissions
+func InvalidatePermissionCache() {
+	permissionCache.Lock()
+	permissionCache.entries = make(map[string]permissionCacheEntry)
+	permissionCache.Unlock()
+}
+
+// PermissionService évalue et gère les rôles et permissions RBAC
+type PermissionService struct {
+	DB *gorm.DB
+}
+
+// NewPermissionService crée une nouvelle instance de PermissionService
+func NewPermissionService(db *gorm.DB) *PermissionService {
+	return &PermissionService{DB: db}
+}
+
+// ParsePermission découpe une permission "ressource:action"
+func ParsePermission(permission string) (string, string, error) {
+	resource, action, ok := strings.Cut(strings.TrimSpace(permission), ":")
+	if !ok || resource == "" || action == "" {
+		return "", "", ErrInvalidPermission
+	}
+	return strings.ToLower(resource), strings.ToLower(action), nil
+}
+
+// PermissionGrants indique si une permission accordée couvre la permission demandée
+func PermissionGrants(granted, required string) bool {
+	grantedResource, grantedAction, err := ParsePermission(granted)
+	if err != nil {
+		return false
+	}
+	requiredResource, requiredAction, err := ParsePermission(required)
+	if err != nil {
+		return false
+	}
+
+	if grantedResource != PermissionWildcard && grantedResource != requiredResource {
+		return false
+	}
+	return grantedAction == PermissionWildcard || grantedAction == PermissionActionAdmin || grantedAction == requiredAction
+}
+
+// HasPermission indique si l'utilisateur dispose de la permission, globalement ou dans l'organisation
+func (s *PermissionService) HasPermission(userID, organizationID, permission string) (bool, error) {
+	permissions, err := s.EffectivePermissions(userID, organizationID)
+	if err != nil {
+		return false, err
+	}
+	for _, granted := range permissions {
+		if PermissionGrants(granted, permission) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// TenantScope renvoie le tenant auquel limiter les données vues par l'utilisateur. global est vrai
+// s'il détient PermissionTenantGlobal ; sinon tenantID est son tenant, vide pour un utilisateur de l'instance.
+func (s *PermissionService) TenantScope(userID string) (tenantID string, global bool, err error) {
+	if global, err = s.HasPermission(userID, "", PermissionTenantGlobal); err != nil || global {
+		return "", global, err
+	}
+	var user models.User
+	if err := s.DB.Select("id", "tenant_id").First(&user, "id = ?", userID).Error; err != nil {
+		return "", false, err
+	}
+	return deref(user.TenantID), false, nil
+}
+
+// EffectivePermissions résout les permissions issues des rôles globaux de l'utilisateur et,
+// si organizationID est fourni, des rôles qui lui sont attribués dans cette organisation
+func (s *PermissionService) EffectivePermissions(userID, organizationID string) ([]string, error) {
+	key := userID + "|" + organizationID
+
+	permissionCache.RLock()
+	entry, ok := permissionCache.entries[key]
+	permissionCache.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.permissions, nil
+	}
+
+	permissions, err := s.resolvePermissions(userID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	permissionCache.Lock()
+	permissionCache.entries[key] = permissionCacheEntry{permissions: permissions, expiresAt: time.Now().Add(permissionCacheTTL)}
+	permissionCache.Unlock()
+	return permissions, nil
+}
+
+func (s *PermissionService) resolvePermissions(userID, organizationID string) ([]string, error) {
+	var user models.User
+	if err := s.DB.Select("id", "role").First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	roleIDs, err := s.userRoleIDs(userID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]bool)
+	// Compatibilité : le champ users.role = ADMIN reste un accès complet
+	if strings.EqualFold(user.Role, string(models.RoleAdmin)) {
+		set[PermissionWildcard+":"+PermissionWildcard] = true
+	}
+
+	if len(roleIDs) > 0 {
+		var permissions []models.Permission
+		if err := s.DB.Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+			Where("role_permissions.role_id IN ?", roleIDs).Find(&permissions).Error; err != nil {
+			return nil, err
+		}
+		for _, permission := range permissions {
+			set[permission.Resource+":"+permission.Action] = true
+		}
+	}
+
+	result := make([]string, 0, len(set))
+	for permission := range set {
+		result = append(result, permission)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// userRoleIDs renvoie les rôles globaux et, pour une organisation, les rôles attribués dans celle-ci
+// ainsi que le rôle porté par l'appartenance active
+func (s *PermissionService) userRoleIDs(userID, organizationID string) ([]string, error) {
+	var roleIDs []string
+	query := s.DB.Model(&models.UserRole{}).Where("user_id = ?", userID)
+	if organizationID == "" {
+		query = query.Where("organization_id IS NULL")
+	} else {
+		query = query.Where("organization_id IS NULL OR organization_id = ?", organizationID)
+	}
+	if err := query.Pluck("role_id", &roleIDs).Error; err != nil {
+		return nil, err
+	}
+
+	if organizationID != "" {
+		var membershipRoles []string
+		if err := s.DB.Model(&models.Membership{}).
+			Where("user_id = ? AND organization_id = ? AND status = ? AND role_id IS NOT NULL", userID, organizationID, "active").
+			Pluck("role_id", &membershipRoles).Error; err != nil {
+			return nil, err
+		}
+		roleIDs = append(roleIDs, membershipRoles...)
+	}
+	return roleIDs, nil
+}
+
+// UserRoleNames renvoie les noms des rôles globaux de l'utilisateur, y compris le champ users.role
+func (s *PermissionService) UserRoleNames(userID string) ([]string, error) {
+	var user models.User
+	if err := s.DB.Select("id", "role").First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if err := s.DB.Model(&models.Role{}).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ? AND user_roles.organization_id IS NULL", userID).
+		Pluck("roles.name", &names).Error; err != nil {
+		return nil, err
+	}
+	if user.Role != "" {
+		names = append(names, user.Role)
+	}
+	return names, nil
+}
+
+// EnsureDefaults crée les permissions standard de chaque ressource et le rôle système ADMIN
+func (s *PermissionService) EnsureDefaults() error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		actions := []string{PermissionActionRead, PermissionActionWrite, PermissionActionDelete, PermissionActionAdmin}
+		for _, resource := range PermissionResources {
+			for _, action := range actions {
+				if _, err := ensurePermission(tx, resource, action); err != nil {
+					return err
+				}
+			}
+		}
+
+		all, err := ensurePermission(tx, PermissionWildcard, PermissionWildcard)
+		if err != nil {
+			return err
+		}
+
+		description := "Full access to every resource"
+		role := models.Role{Name: string(models.RoleAdmin), Description: &description, IsSystem: true}
+		if err := tx.Where("name = ?", role.Name).Attrs(role).FirstOrCreate(&role).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Role", "Permission").Where("role_id = ? AND permission_id = ?", role.ID, all.ID).
+			FirstOrCreate(&models.RolePermission{RoleID: role.ID, PermissionID: all.ID, CreatedAt: time.Now()}).Error
+	})
+}
+
+func ensurePermission(tx *gorm.DB, resource, action string) (*models.Permission, error) {
+	permission := models.Permission{Name: resource + ":" + action, Resource: resource, Action: action}
+	err := tx.Where("name = ?", permission.Name).Attrs(permission).FirstOrCreate(&permission).Error
+	return &permission, err
+}
+
+// ListRoles liste les rôles avec leurs permissions
+func (s *PermissionService) ListRoles() ([]models.Role, error) {
+	var roles []models.Role
+	err := s.DB.Preload("RolePermissions.Permission").Order("name ASC").Find(&roles).Error
+	return roles, err
+}
+
+// GetRole récupère un rôle avec ses permissions
+func (s *PermissionService) GetRole(id string) (*models.Role, error) {
+	var role models.Role
+	if err := s.DB.Preload("RolePermissions.Permission").First(&role, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// CreateRole crée un rôle et lui attribue les permissions fournies, que actorID doit lui-même détenir
+func (s *PermissionService) CreateRole(actorID, name string, description *string, permissions []string) (*models.Role, error) {
+	role := &models.Role{Name: strings.TrimSpace(name), Description: description}
+	if role.Name == "" {
+		return nil, errors.New("role name is required")
+	}
+	resolved, err := s.resolveGrantable(actorID, "", permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("UserRoles", "RolePermissions").Create(role).Error; err != nil {
+			return err
+		}
+		return setRolePermissions(tx, role.ID, resolved)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	InvalidatePermissionCache()
+	return s.GetRole(role.ID)
+}
+
+// UpdateRole met à jour un rôle ; permissions remplace la liste si elle n'est pas nil et doit être
+// détenue par actorID
+func (s *PermissionService) UpdateRole(actorID, id string, name, description *string, permissions []string) (*models.Role, error) {
+	role, err := s.GetRole(id)
+	if err != nil {
+		return nil, err
+	}
+	if role.IsSystem {
+		return nil, ErrSystemRole
+	}
+	var resolved []models.Permission
+	if permissions != nil {
+		if resolved, err = s.resolveGrantable(actorID, "", permissions); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"updated_at": time.Now()}
+		if name != nil && strings.TrimSpace(*name) != "" {
+			updates["name"] = strings.TrimSpace(*name)
+		}
+		if description != nil {
+			updates["description"] = *description
+		}
+		if err := tx.Model(&models.Role{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		if permissions == nil {
+			return nil
+		}
+		return setRolePermissions(tx, id, resolved)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	InvalidatePermissionCache()
+	return s.GetRole(id)
+}
+
+// DeleteRole supprime un rôle non système et ses attributions
+func (s *PermissionService) DeleteRole(id string) error {
+	var role models.Role
+	if err := s.DB.First(&role, "id = ?", id).Error; err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return ErrSystemRole
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", id).Delete(&models.UserRole{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", id).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Membership{}).Where("role_id = ?", id).Update("role_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Role{}, "id = ?", id).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	InvalidatePermissionCache()
+	return nil
+}
+
+// GrantPermission ajoute à un rôle une permission détenue par actorID
+func (s *PermissionService) GrantPermission(actorID, roleID, permission string) error {
+	resolved, err := s.resolveGrantable(actorID, "", []string{permission})
+	if err != nil {
+		return err
+	}
+	permissionModel := resolved[0]
+	if err := s.checkEditableRole(roleID); err != nil {
+		return err
+	}
+
+	err = s.DB.Omit("Role", "Permission").Where("role_id = ? AND permission_id = ?", roleID, permissionModel.ID).
+		FirstOrCreate(&models.RolePermission{RoleID: roleID, PermissionID: permissionModel.ID, CreatedAt: time.Now()}).Error
+	if err != nil {
+		return err
+	}
+
+	InvalidatePermissionCache()
+	return nil
+}
+
+// RevokePermission retire une permission d'un rôle
+func (s *PermissionService) RevokePermission(roleID, permissionID string) error {
+	if err := s.checkEditableRole(roleID); err != nil {
+		return err
+	}
+	if err := s.DB.Where("role_id = ? AND permission_id = ?", roleID, permissionID).Delete(&models.RolePermission{}).Error; err != nil {
+		return err
+	}
+
+	InvalidatePermissionCache()
+	return nil
+}
+
+// ListPermissions liste les permissions définies
+func (s *PermissionService) ListPermissions() ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := s.DB.Order("resource ASC, action ASC").Find(&permissions).Error
+	return permissions, err
+}
+
+// CreatePermission crée une permission "ressource:action"
+func (s *PermissionService) CreatePermission(permission string, description *string) (*models.Permission, error) {
+	resource, action, err := ParsePermission(permission)
+	if err != nil {
+		return nil, err
+	}
+
+	created := &models.Permission{Name: resource + ":" + action, Resource: resource, Action: action, Description: description}
+	if err := s.DB.Omit("RolePermissions").Create(created).Error; err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// DeletePermission supprime une permission et la retire de tous les rôles
+func (s *PermissionService) DeletePermission(id string) error {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("permission_id = ?", id).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Permission{}, "id = ?", id).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	InvalidatePermissionCache()
+	return nil
+}
+
+// ListUserRoles liste les rôles attribués à un utilisateur
+func (s *PermissionService) ListUserRoles(userID string) ([]models.UserRole, error) {
+	var userRoles []models.UserRole
+	err := s.DB.Preload("Role").Where("user_id = ?", userID).Order("assigned_at ASC").Find(&userRoles).Error
+	return userRoles, err
+}
+
+// AssignRole attribue un rôle à un utilisateur, globalement ou dans une organisation. Les rôles système
+// ne s'attribuent pas, et actorID doit détenir dans la même portée toutes les permissions du rôle.
+func (s *PermissionService) AssignRole(actorID, userID, roleID string, organizationID *string) (*models.UserRole, error) {
+	if err := s.DB.Select("id").First(&models.User{}, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	role, err := s.GetRole(roleID)
+	if err != nil {
+		return nil, err
+	}
+	if role.IsSystem {
+		return nil, ErrSystemRoleAssign
+	}
+	scope := ""
+	if organizationID != nil {
+		scope = *organizationID
+	}
+	rolePermissions := make([]models.Permission, 0, len(role.RolePermissions))
+	for _, rolePermission := range role.RolePermissions {
+		rolePermissions = append(rolePermissions, rolePermission.Permission)
+	}
+	if err := s.checkPermissionsHeld(actorID, scope, rolePermissions); err != nil {
+		return nil, err
+	}
+
+	query := s.DB.Where("user_id = ? AND role_id = ?", userID, roleID)
+	if organizationID != nil && *organizationID != "" {
+		if err := s.DB.Select("id").First(&models.Organization{}, "id = ?", *organizationID).Error; err != nil {
+			return nil, err
+		}
+		query = query.Where("organization_id = ?", *organizationID)
+	} else {
+		organizationID = nil
+		query = query.Where("organization_id IS NULL")
+	}
+
+	var userRole models.UserRole
+	err = query.First(&userRole).Error
+	if err == nil {
+		return &userRole, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	userRole = models.UserRole{
+		UserID:         userID,
+		RoleID:         roleID,
+		OrganizationID: organizationID,
+		AssignedBy:     &actorID,
+		AssignedAt:     time.Now(),
+	}
+	if err := s.DB.Omit("User", "Role").Create(&userRole).Error; err != nil {
+		return nil, err
+	}
+
+	InvalidatePermissionCache()
+	return &userRole, nil
+}
+
+// RevokeRole retire une attribution de rôle à un utilisateur
+func (s *PermissionService) RevokeRole(userID, userRoleID string) error {
+	result := s.DB.Where("id = ? AND user_id = ?", userRoleID, userID).Delete(&models.UserRole{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	InvalidatePermissionCache()
+	return nil
+}
+
+func (s *PermissionService) checkEditableRole(roleID string) error {
+	var role models.Role
+	if err := s.DB.Select("id", "is_system").First(&role, "id = ?", roleID).Error; err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return ErrSystemRole
+	}
+	return nil
+}
+
+// findPermission recherche une permission par identifiant ou par nom "ressource:action"
+func (s *PermissionService) findPermission(tx *gorm.DB, permission string) (*models.Permission, error) {
+	var found models.Permission
+	query := tx.Where("name = ?", strings.ToLower(strings.TrimSpace(permission)))
+	if !strings.Contains(permission, ":") {
+		query = tx.Where("id = ?", permission)
+	}
+	if err := query.First(&found).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrPermissionUnknown, permission)
+		}
+		return nil, err
+	}
+	return &found, nil
+}
+
+// resolveGrantable résout une liste de permissions (noms ou identifiants) et vérifie que actorID les
+// détient toutes : un rôle ne peut pas accorder plus que son créateur n'a lui-même
+func (s *PermissionService) resolveGrantable(actorID, organizationID string, permissions []string) ([]models.Permission, error) {
+	resolved := make([]models.Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		found, err := s.findPermission(s.DB, permission)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, *found)
+	}
+	if err := s.checkPermissionsHeld(actorID, organizationID, resolved); err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+// checkPermissionsHeld renvoie ErrPermissionNotHeld si l'une des permissions n'est pas couverte par
+// les permissions effectives de actorID
+func (s *PermissionService) checkPermissionsHeld(actorID, organizationID string, permissions []models.Permission) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+	if actorID == "" {
+		return ErrPermissionNotHeld
+	}
+	held, err := s.EffectivePermissions(actorID, organizationID)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		required := permission.Resource + ":" + permission.Action
+		covered := false
+		for _, granted := range held {
+			if PermissionGrants(granted, required) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return fmt.Errorf("%w: %s", ErrPermissionNotHeld, required)
+		}
+	}
+	return nil
+}
+
+// setRolePermissions remplace les permissions d'un rôle
+func setRolePermissions(tx *gorm.DB, roleID string, permissions []models.Permission) error {
+	if err := tx.Where("role_id = ?", roleID).Delete(&models.RolePermission{}).Error; err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		if err := tx.Omit("Role", "Permission").Create(&models.RolePermission{RoleID: roleID, PermissionID: permission.ID, CreatedAt: time.Now()}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
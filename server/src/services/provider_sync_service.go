@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrProviderSyncInvalidEvent = errors.New("provider, entity_type and entity_id are required")
+)
+
+// ProviderSyncEvent représente un événement de synchronisation envoyé par un connecteur (application GitHub...)
+type ProviderSyncEvent struct {
+	EventType  string                 `json:"event_type"`
+	Provider   string                 `json:"provider"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   string                 `json:"entity_id"`
+	Data       map[string]interface{} `json:"data"`
+	Timestamp  time.Time              `json:"timestamp"`
+	RequestID  string                 `json:"request_id"`
+}
+
+// ProviderSyncService conserve l'état des entités synchronisées depuis les fournisseurs externes
+type ProviderSyncService struct {
+	DB *gorm.DB
+}
+
+// NewProviderSyncService crée une nouvelle instance de ProviderSyncService
+func NewProviderSyncService(db *gorm.DB) *ProviderSyncService {
+	return &ProviderSyncService{DB: db}
+}
+
+// Ingest applique un événement de synchronisation : création ou mise à jour de l'entité,
+// ou suppression lorsque l'événement signale une entité supprimée
+func (s *ProviderSyncService) Ingest(event ProviderSyncEvent) (*models.ProviderEntity, error) {
+	if event.Provider == "" || event.EntityType == "" || event.EntityID == "" {
+		return nil, ErrProviderSyncInvalidEvent
+	}
+
+	if deleted, _ := event.Data["deleted"].(bool); deleted || strings.HasSuffix(event.EventType, "_delete") {
+		return nil, s.Delete(event.Provider, event.EntityType, event.EntityID)
+	}
+
+	syncedAt := event.Timestamp
+	if syncedAt.IsZero() {
+		syncedAt = time.Now().UTC()
+	}
+
+	entity := models.ProviderEntity{
+		Provider:      event.Provider,
+		EntityType:    event.EntityType,
+		ExternalID:    event.EntityID,
+		Name:          providerEntityName(event),
+		Data:          event.Data,
+		LastEventType: event.EventType,
+		SyncedAt:      syncedAt,
+	}
+	if installationID, ok := event.Data["installation_id"].(float64); ok && installationID != 0 {
+		id := int64(installationID)
+		entity.InstallationID = &id
+	}
+
+	// Les événements de webhook ne portent pas toujours l'installation : on conserve alors la valeur connue
+	updates := []string{"data", "last_event_type", "synced_at", "updated_at"}
+	if entity.Name != "" {
+		updates = append(updates, "name")
+	}
+	if entity.InstallationID != nil {
+		updates = append(updates, "installation_id")
+	}
+
+	if err := s.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider"}, {Name: "entity_type"}, {Name: "external_id"}},
+		DoUpdates: clause.AssignmentColumns(updates),
+	}).Create(&entity).Error; err != nil {
+		return nil, err
+	}
+
+	return s.Get(event.Provider, event.EntityType, event.EntityID)
+}
+
+// Get récupère une entité par son identifiant chez le fournisseur
+func (s *ProviderSyncService) Get(provider, entityType, externalID string) (*models.ProviderEntity, error) {
+	var entity models.ProviderEntity
+	if err := s.DB.Where("provider = ? AND entity_type = ? AND external_id = ?", provider, entityType, externalID).
+		First(&entity).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// List liste les entités d'un fournisseur, filtrées par type et installation
+func (s *ProviderSyncService) List(provider, entityType string, installationID *int64, page, limit int) ([]models.ProviderEntity, int64, error) {
+	query := s.DB.Model(&models.ProviderEntity{}).Where("provider = ?", provider)
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if installationID != nil {
+		query = query.Where("installation_id = ?", *installationID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entities []models.ProviderEntity
+	if err := query.Order("entity_type, external_id").Offset((page - 1) * limit).Limit(limit).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return entities, total, nil
+}
+
+// Delete supprime une entité ; supprimer une entité absente n'est pas une erreur
+func (s *ProviderSyncService) Delete(provider, entityType, externalID string) error {
+	return s.DB.Where("provider = ? AND entity_type = ? AND external_id = ?", provider, entityType, externalID).
+		Delete(&models.ProviderEntity{}).Error
+}
+
+// providerEntityName déduit un nom lisible des données de l'événement
+func providerEntityName(event ProviderSyncEvent) string {
+	for _, key := range []string{"role", "resource", "user"} {
+		if nested, ok := event.Data[key].(map[string]interface{}); ok {
+			for _, field := range []string{"name", "username", "login"} {
+				if name, ok := nested[field].(string); ok && name != "" {
+					return name
+				}
+			}
+		}
+	}
+
+	if team, ok := event.Data["team"].(map[string]interface{}); ok {
+		org, _ := event.Data["organization"].(string)
+		if slug, ok := team["slug"].(string); ok && slug != "" {
+			return fmt.Sprintf("%s/%s", org, slug)
+		}
+	}
+
+	for _, key := range []string{"full_name", "github_login", "login"} {
+		if name, ok := event.Data[key].(string); ok && name != "" {
+			return name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,958 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"gorm.io/gorm"
+)
+
+// Paramètres des tentatives d'envoi vers les applications cibles
+const (
+	provisioningBaseBackoff = 30 * time.Second
+	provisioningMaxBackoff  = time.Hour
+	provisioningBatchSize   = 50
+	provisioningStaleAfter  = 10 * time.Minute
+)
+
+var (
+	ErrProvisioningNotConfigured = errors.New("provisioning is not configured for this application")
+	ErrProvisioningDisabled      = errors.New("provisioning is disabled for this application")
+)
+
+// DefaultProvisioningMapping associe les attributs SCIM distants aux attributs locaux
+var DefaultProvisioningMapping = map[string]string{
+	"userName":                     "userName",
+	"externalId":                   "id",
+	"name.formatted":               "name",
+	"name.givenName":               "givenName",
+	"name.familyName":              "familyName",
+	"displayName":                  "displayName",
+	`emails[type eq "work"].value`: "email",
+	"locale":                       "locale",
+	"timezone":                     "timezone",
+	"active":                       "active",
+}
+
+// ProvisioningService pousse les identités locales vers les applications cibles via SCIM
+type ProvisioningService struct {
+	DB *gorm.DB
+}
+
+// NewProvisioningService crée une nouvelle instance de ProvisioningService
+func NewProvisioningService(db *gorm.DB) *ProvisioningService {
+	return &ProvisioningService{DB: db}
+}
+
+// GetConfig récupère la configuration de provisioning d'une application
+func (s *ProvisioningService) GetConfig(applicationID string) (*models.ApplicationProvisioning, error) {
+	var config models.ApplicationProvisioning
+	if err := s.DB.Where("application_id = ?", applicationID).First(&config).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProvisioningNotConfigured
+		}
+		return nil, err
+	}
+	return &config, nil
+}
+
+// SaveConfig crée ou met à jour la configuration de provisioning d'une application
+func (s *ProvisioningService) SaveConfig(applicationID string, req *models.ApplicationProvisioningRequest) (*models.ApplicationProvisioning, error) {
+	if err := s.DB.Select("id").First(&models.Application{}, "id = ?", applicationID).Error; err != nil {
+		return nil, err
+	}
+
+	config, err := s.GetConfig(applicationID)
+	if errors.Is(err, ErrProvisioningNotConfigured) {
+		config = &models.ApplicationProvisioning{
+			ApplicationID:   applicationID,
+			AuthType:        models.ProvisioningAuthBearer,
+			DeprovisionMode: models.ProvisioningDeprovisionDeactivate,
+			MaxRetries:      5,
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if req.Enabled != nil {
+		config.Enabled = *req.Enabled
+	}
+	if req.BaseURL != nil {
+		config.BaseURL = strings.TrimRight(strings.TrimSpace(*req.BaseURL), "/")
+	}
+	if req.AuthType != nil {
+		config.AuthType = *req.AuthType
+	}
+	if req.AuthHeaderName != nil {
+		config.AuthHeaderName = req.AuthHeaderName
+	}
+	if req.Username != nil {
+		config.Username = req.Username
+	}
+	if req.Secret != nil {
+		config.Secret = req.Secret
+	}
+	if req.AttributeMapping != nil {
+		config.AttributeMapping = req.AttributeMapping
+	}
+	if req.DeprovisionMode != nil {
+		config.DeprovisionMode = *req.DeprovisionMode
+	}
+	if req.MaxRetries != nil {
+		config.MaxRetries = *req.MaxRetries
+	}
+	if req.ReconcileInterval != nil {
+		config.ReconcileInterval = *req.ReconcileInterval
+	}
+
+	if err := validateProvisioningConfig(config); err != nil {
+		return nil, err
+	}
+
+	if config.ID == "" {
+		err = s.DB.Omit("Application").Create(config).Error
+	} else {
+		err = s.DB.Omit("Application").Save(config).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// DeleteConfig supprime la configuration de provisioning et les données associées
+func (s *ProvisioningService) DeleteConfig(applicationID string) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("application_id = ?", applicationID).Delete(&models.ProvisioningTask{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("application_id = ?", applicationID).Delete(&models.ProvisionedUser{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("application_id = ?", applicationID).Delete(&models.ApplicationProvisioning{}).Error
+	})
+}
+
+// TestConnection vérifie que l'endpoint SCIM de l'application répond
+func (s *ProvisioningService) TestConnection(applicationID string) error {
+	config, err := s.GetConfig(applicationID)
+	if err != nil {
+		return err
+	}
+	return s.newClient(config, nil, nil, "test").TestConnection()
+}
+
+// EnqueueUserEvent planifie l'envoi d'un événement utilisateur vers chaque application cible
+func (s *ProvisioningService) EnqueueUserEvent(userID, event string) error {
+	var configs []models.ApplicationProvisioning
+	if err := s.DB.Where("enabled = ?", true).Find(&configs).Error; err != nil {
+		return err
+	}
+
+	for i := range configs {
+		config := &configs[i]
+
+		var linked int64
+		s.DB.Model(&models.ProvisionedUser{}).
+			Where("application_id = ? AND user_id = ?", config.ApplicationID, userID).
+			Count(&linked)
+		if linked == 0 {
+			inScope, err := s.userInScope(config.ApplicationID, userID)
+			if err != nil {
+				return err
+			}
+			if !inScope || event == models.ProvisioningEventUserDeleted {
+				continue
+			}
+		}
+
+		if err := s.enqueue(config.ApplicationID, userID, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enqueue crée une tâche, ou réutilise la tâche en attente pour le même utilisateur :
+// l'état complet est renvoyé à chaque envoi, seul le dernier événement compte
+func (s *ProvisioningService) enqueue(applicationID, userID, event string) error {
+	now := time.Now()
+	result := s.DB.Model(&models.ProvisioningTask{}).
+		Where("application_id = ? AND user_id = ? AND status = ?", applicationID, userID, models.ProvisioningTaskPending).
+		Updates(map[string]interface{}{
+			"event":           event,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"updated_at":      now,
+		})
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+
+	return s.DB.Create(&models.ProvisioningTask{
+		ApplicationID: applicationID,
+		UserID:        userID,
+		Event:         event,
+		Status:        models.ProvisioningTaskPending,
+		NextAttemptAt: now,
+	}).Error
+}
+
+// RetryTask remet en file une tâche en échec
+func (s *ProvisioningService) RetryTask(applicationID, taskID string) error {
+	result := s.DB.Model(&models.ProvisioningTask{}).
+		Where("id = ? AND application_id = ? AND status = ?", taskID, applicationID, models.ProvisioningTaskFailed).
+		Updates(map[string]interface{}{
+			"status":          models.ProvisioningTaskPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+			"updated_at":      time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListTasks liste les tâches de provisioning d'une application
+func (s *ProvisioningService) ListTasks(applicationID, status string, page, limit int) ([]models.ProvisioningTask, int64, error) {
+	var tasks []models.ProvisioningTask
+	var total int64
+
+	query := s.DB.Model(&models.ProvisioningTask{}).Where("application_id = ?", applicationID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&total)
+
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&tasks).Error; err != nil {
+		return nil, 0, err
+	}
+	return tasks, total, nil
+}
+
+// ListLogs liste les journaux de provisioning d'une application
+func (s *ProvisioningService) ListLogs(applicationID, userID string, page, limit int) ([]models.ProvisioningLog, int64, error) {
+	var logs []models.ProvisioningLog
+	var total int64
+
+	query := s.DB.Model(&models.ProvisioningLog{}).Where("application_id = ?", applicationID)
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	query.Count(&total)
+
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// Start lance le traitement périodique des tâches et des réconciliations planifiées
+func (s *ProvisioningService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.ProcessDueTasks(provisioningBatchSize); err != nil {
+					fmt.Printf("Provisioning: failed to process tasks: %v\n", err)
+				}
+				s.runScheduledReconciliations()
+			}
+		}
+	}()
+}
+
+// ProcessDueTasks traite les tâches arrivées à échéance et renvoie le nombre traité
+func (s *ProvisioningService) ProcessDueTasks(limit int) (int, error) {
+	// Libérer les tâches restées bloquées après un arrêt brutal
+	s.DB.Model(&models.ProvisioningTask{}).
+		Where("status = ? AND updated_at < ?", models.ProvisioningTaskProcessing, time.Now().Add(-provisioningStaleAfter)).
+		Update("status", models.ProvisioningTaskPending)
+
+	var tasks []models.ProvisioningTask
+	if err := s.DB.Where("status = ? AND next_attempt_at <= ?", models.ProvisioningTaskPending, time.Now()).
+		Order("next_attempt_at ASC").Limit(limit).Find(&tasks).Error; err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for i := range tasks {
+		task := &tasks[i]
+
+		// Réserver la tâche pour éviter un double traitement entre instances
+		claim := s.DB.Model(&models.ProvisioningTask{}).
+			Where("id = ? AND status = ?", task.ID, models.ProvisioningTaskPending).
+			Updates(map[string]interface{}{"status": models.ProvisioningTaskProcessing, "updated_at": time.Now()})
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			continue
+		}
+
+		s.completeTask(task, s.processTask(task))
+		processed++
+	}
+	return processed, nil
+}
+
+func (s *ProvisioningService) processTask(task *models.ProvisioningTask) error {
+	config, err := s.GetConfig(task.ApplicationID)
+	if err != nil {
+		return err
+	}
+	if !config.Enabled {
+		return ErrProvisioningDisabled
+	}
+
+	var user models.User
+	err = s.DB.Unscoped().First(&user, "id = ?", task.UserID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	deleted := err != nil || user.DeletedAt.Valid || task.Event == models.ProvisioningEventUserDeleted
+
+	inScope := false
+	if !deleted {
+		if inScope, err = s.userInScope(task.ApplicationID, task.UserID); err != nil {
+			return err
+		}
+	}
+
+	taskID := task.ID
+	if deleted || !inScope {
+		return s.deprovisionUser(config, task.UserID, &taskID)
+	}
+	return s.pushUser(config, &user, &taskID)
+}
+
+func (s *ProvisioningService) completeTask(task *models.ProvisioningTask, err error) {
+	updates := map[string]interface{}{"attempts": task.Attempts + 1, "updated_at": time.Now()}
+
+	if err == nil {
+		updates["status"] = models.ProvisioningTaskSucceeded
+		updates["last_error"] = nil
+		s.DB.Model(&models.ProvisioningTask{}).Where("id = ?", task.ID).Updates(updates)
+		s.DB.Model(&models.ApplicationProvisioning{}).Where("application_id = ?", task.ApplicationID).Update("last_error", nil)
+		return
+	}
+
+	message := err.Error()
+	updates["last_error"] = message
+	updates["status"] = models.ProvisioningTaskFailed
+
+	maxRetries := 5
+	if config, cfgErr := s.GetConfig(task.ApplicationID); cfgErr == nil {
+		maxRetries = config.MaxRetries
+	}
+	if provisioningRetryable(err) && task.Attempts+1 < maxRetries {
+		updates["status"] = models.ProvisioningTaskPending
+		updates["next_attempt_at"] = time.Now().Add(provisioningBackoff(task.Attempts + 1))
+	}
+
+	s.DB.Model(&models.ProvisioningTask{}).Where("id = ?", task.ID).Updates(updates)
+	s.DB.Model(&models.ApplicationProvisioning{}).Where("application_id = ?", task.ApplicationID).Update("last_error", message)
+}
+
+// pushUser crée ou met à jour l'utilisateur dans l'application cible
+func (s *ProvisioningService) pushUser(config *models.ApplicationProvisioning, user *models.User, taskID *string) error {
+	var link models.ProvisionedUser
+	linkErr := s.DB.Where("application_id = ? AND user_id = ?", config.ApplicationID, user.ID).First(&link).Error
+	if linkErr != nil && !errors.Is(linkErr, gorm.ErrRecordNotFound) {
+		return linkErr
+	}
+	linked := linkErr == nil
+
+	// Un utilisateur inactif jamais provisionné n'a pas à être créé
+	if !linked && !user.IsActive {
+		return nil
+	}
+
+	resource, err := s.buildResource(config, user)
+	if err != nil {
+		return err
+	}
+
+	operation := "update"
+	if !user.IsActive {
+		operation = "deactivate"
+	}
+	client := s.newClient(config, &user.ID, taskID, operation)
+
+	remoteID := link.RemoteID
+	if !linked {
+		userName, _ := resource["userName"].(string)
+		if userName != "" {
+			existing, err := client.FindUserByUserName(userName)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				remoteID, _ = existing["id"].(string)
+			}
+		}
+	}
+
+	var saved map[string]interface{}
+	if remoteID != "" {
+		saved, err = client.ReplaceUser(remoteID, resource)
+		var clientErr *ScimClientError
+		if errors.As(err, &clientErr) && clientErr.StatusCode == http.StatusNotFound {
+			remoteID = ""
+		} else if err != nil {
+			return err
+		}
+	}
+	if remoteID == "" {
+		client.operation = "create"
+		if saved, err = client.CreateUser(resource); err != nil {
+			return err
+		}
+	}
+
+	if id, ok := saved["id"].(string); ok && id != "" {
+		remoteID = id
+	}
+	if remoteID == "" {
+		return errors.New("scim endpoint did not return a resource id")
+	}
+
+	now := time.Now()
+	link.ApplicationID = config.ApplicationID
+	link.UserID = user.ID
+	link.RemoteID = remoteID
+	link.Active = user.IsActive
+	link.LastSyncedAt = &now
+	if linked {
+		return s.DB.Save(&link).Error
+	}
+	return s.DB.Create(&link).Error
+}
+
+// deprovisionUser désactive ou supprime l'utilisateur dans l'application cible
+func (s *ProvisioningService) deprovisionUser(config *models.ApplicationProvisioning, userID string, taskID *string) error {
+	var link models.ProvisionedUser
+	if err := s.DB.Where("application_id = ? AND user_id = ?", config.ApplicationID, userID).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	var clientErr *ScimClientError
+	if config.DeprovisionMode == models.ProvisioningDeprovisionDelete {
+		err := s.newClient(config, &userID, taskID, "delete").DeleteUser(link.RemoteID)
+		if err != nil && !(errors.As(err, &clientErr) && clientErr.StatusCode == http.StatusNotFound) {
+			return err
+		}
+		return s.DB.Delete(&link).Error
+	}
+
+	err := s.newClient(config, &userID, taskID, "deactivate").SetUserActive(link.RemoteID, false)
+	if errors.As(err, &clientErr) && clientErr.StatusCode == http.StatusNotFound {
+		return s.DB.Delete(&link).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	link.Active = false
+	link.LastSyncedAt = &now
+	return s.DB.Save(&link).Error
+}
+
+// Reconcile compare les utilisateurs locaux et distants et corrige les écarts hors mode dryRun
+func (s *ProvisioningService) Reconcile(applicationID string, dryRun bool) (*models.ProvisioningReconcileReport, error) {
+	config, err := s.GetConfig(applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ProvisioningReconcileReport{
+		ApplicationID: applicationID,
+		DryRun:        dryRun,
+		StartedAt:     time.Now(),
+		Missing:       []models.ProvisioningDrift{},
+		Drifted:       []models.ProvisioningDrift{},
+		Deprovision:   []models.ProvisioningDrift{},
+		Unmanaged:     []models.ProvisioningDrift{},
+	}
+
+	remoteUsers, err := s.newClient(config, nil, nil, "reconcile").ListAllUsers()
+	if err != nil {
+		return nil, err
+	}
+	report.RemoteUsers = len(remoteUsers)
+
+	remoteByID := make(map[string]map[string]interface{})
+	remoteByUserName := make(map[string]map[string]interface{})
+	for _, remote := range remoteUsers {
+		if id, ok := remote["id"].(string); ok {
+			remoteByID[id] = remote
+		}
+		if userName, ok := remote["userName"].(string); ok {
+			remoteByUserName[strings.ToLower(userName)] = remote
+		}
+	}
+
+	var links []models.ProvisionedUser
+	if err := s.DB.Where("application_id = ?", applicationID).Find(&links).Error; err != nil {
+		return nil, err
+	}
+	linkByUser := make(map[string]*models.ProvisionedUser)
+	for i := range links {
+		linkByUser[links[i].UserID] = &links[i]
+	}
+
+	users, err := s.scopedUsers(applicationID)
+	if err != nil {
+		return nil, err
+	}
+	report.LocalUsers = len(users)
+
+	mapping := provisioningMapping(config)
+	matched := make(map[string]bool)
+	localIDs := make(map[string]bool)
+
+	for i := range users {
+		user := &users[i]
+		localIDs[user.ID] = true
+
+		resource, err := s.buildResource(config, user)
+		if err != nil {
+			return nil, err
+		}
+		userName, _ := resource["userName"].(string)
+		drift := models.ProvisioningDrift{UserID: user.ID, UserName: userName}
+
+		var remote map[string]interface{}
+		if link := linkByUser[user.ID]; link != nil {
+			remote = remoteByID[link.RemoteID]
+		}
+		if remote == nil {
+			remote = remoteByUserName[strings.ToLower(userName)]
+		}
+
+		if remote == nil {
+			if user.IsActive {
+				drift.Action = "create"
+				report.Missing = append(report.Missing, drift)
+			}
+			continue
+		}
+
+		drift.RemoteID, _ = remote["id"].(string)
+		matched[drift.RemoteID] = true
+
+		if diff := diffProvisioningResource(mapping, resource, remote); len(diff) > 0 || linkByUser[user.ID] == nil {
+			drift.Action = "update"
+			drift.Attributes = diff
+			report.Drifted = append(report.Drifted, drift)
+		}
+	}
+
+	for _, link := range links {
+		if localIDs[link.UserID] {
+			continue
+		}
+		remote := remoteByID[link.RemoteID]
+		if remote == nil {
+			continue
+		}
+		matched[link.RemoteID] = true
+		if active, ok := remote["active"].(bool); ok && !active && config.DeprovisionMode != models.ProvisioningDeprovisionDelete {
+			continue
+		}
+		userName, _ := remote["userName"].(string)
+		report.Deprovision = append(report.Deprovision, models.ProvisioningDrift{
+			UserID:   link.UserID,
+			RemoteID: link.RemoteID,
+			UserName: userName,
+			Action:   "deprovision",
+		})
+	}
+
+	for id, remote := range remoteByID {
+		if matched[id] {
+			continue
+		}
+		userName, _ := remote["userName"].(string)
+		report.Unmanaged = append(report.Unmanaged, models.ProvisioningDrift{RemoteID: id, UserName: userName, Action: "none"})
+	}
+
+	if !dryRun {
+		s.applyReconciliation(config, report)
+	}
+
+	report.CompletedAt = time.Now()
+	now := report.CompletedAt
+	s.DB.Model(&models.ApplicationProvisioning{}).Where("id = ?", config.ID).Update("last_reconciled_at", now)
+	s.DB.Create(&models.ProvisioningLog{
+		ApplicationID: applicationID,
+		Operation:     "reconcile",
+		Success:       report.Failed == 0,
+		Message: fmt.Sprintf("dryRun=%t missing=%d drifted=%d deprovision=%d unmanaged=%d applied=%d failed=%d",
+			dryRun, len(report.Missing), len(report.Drifted), len(report.Deprovision), len(report.Unmanaged), report.Applied, report.Failed),
+		DurationMs: now.Sub(report.StartedAt).Milliseconds(),
+	})
+
+	return report, nil
+}
+
+func (s *ProvisioningService) applyReconciliation(config *models.ApplicationProvisioning, report *models.ProvisioningReconcileReport) {
+	apply := func(drifts []models.ProvisioningDrift, fn func(drift *models.ProvisioningDrift) error) {
+		for i := range drifts {
+			if err := fn(&drifts[i]); err != nil {
+				drifts[i].Error = err.Error()
+				report.Failed++
+				continue
+			}
+			report.Applied++
+		}
+	}
+
+	push := func(drift *models.ProvisioningDrift) error {
+		var user models.User
+		if err := s.DB.First(&user, "id = ?", drift.UserID).Error; err != nil {
+			return err
+		}
+		return s.pushUser(config, &user, nil)
+	}
+
+	apply(report.Missing, push)
+	apply(report.Drifted, push)
+	apply(report.Deprovision, func(drift *models.ProvisioningDrift) error {
+		return s.deprovisionUser(config, drift.UserID, nil)
+	})
+}
+
+func (s *ProvisioningService) runScheduledReconciliations() {
+	var configs []models.ApplicationProvisioning
+	if err := s.DB.Where("enabled = ? AND reconcile_interval > 0", true).Find(&configs).Error; err != nil {
+		return
+	}
+
+	for _, config := range configs {
+		interval := time.Duration(config.ReconcileInterval) * time.Minute
+		if config.LastReconciledAt != nil && time.Since(*config.LastReconciledAt) < interval {
+			continue
+		}
+		if _, err := s.Reconcile(config.ApplicationID, false); err != nil {
+			message := err.Error()
+			s.DB.Model(&models.ApplicationProvisioning{}).Where("id = ?", config.ID).Updates(map[string]interface{}{
+				"last_error":         message,
+				"last_reconciled_at": time.Now(),
+			})
+		}
+	}
+}
+
+// userInScope indique si l'utilisateur doit être provisionné dans l'application :
+// une application rattachée à une organisation ne reçoit que ses membres actifs
+func (s *ProvisioningService) userInScope(applicationID, userID string) (bool, error) {
+	var app models.Application
+	if err := s.DB.Select("id", "organization_id").First(&app, "id = ?", applicationID).Error; err != nil {
+		return false, err
+	}
+	if app.OrganizationID == nil {
+		return true, nil
+	}
+
+	var count int64
+	err := s.DB.Model(&models.Membership{}).
+		Where("organization_id = ? AND user_id = ? AND status = ?", *app.OrganizationID, userID, "active").
+		Count(&count).Error
+	return count > 0, err
+}
+
+// scopedUsers renvoie les utilisateurs locaux à provisionner dans l'application
+func (s *ProvisioningService) scopedUsers(applicationID string) ([]models.User, error) {
+	var app models.Application
+	if err := s.DB.Select("id", "organization_id").First(&app, "id = ?", applicationID).Error; err != nil {
+		return nil, err
+	}
+
+	query := s.DB.Model(&models.User{})
+	if app.OrganizationID != nil {
+		query = query.Where("id IN (?)", s.DB.Model(&models.Membership{}).Select("user_id").
+			Where("organization_id = ? AND status = ?", *app.OrganizationID, "active"))
+	}
+
+	var users []models.User
+	err := query.Find(&users).Error
+	return users, err
+}
+
+// buildResource construit la ressource SCIM distante à partir du mapping d'attributs
+func (s *ProvisioningService) buildResource(config *models.ApplicationProvisioning, user *models.User) (map[string]interface{}, error) {
+	var profile models.Profile
+	if err := s.DB.Where("user_id = ?", user.ID).First(&profile).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	source := provisioningSource(user, &profile)
+	resource := map[string]interface{}{"schemas": []interface{}{models.ScimSchemaUser}}
+	for path, attr := range provisioningMapping(config) {
+		value, ok := provisioningSourceValue(source, attr)
+		if !ok {
+			continue
+		}
+		if err := applyScimPatchPath(resource, "add", path, value); err != nil {
+			return nil, fmt.Errorf("invalid mapping for %s: %w", path, err)
+		}
+	}
+	return resource, nil
+}
+
+func (s *ProvisioningService) newClient(config *models.ApplicationProvisioning, userID, taskID *string, operation string) *provisioningClient {
+	client := &provisioningClient{ScimClient: NewScimClient(config), operation: operation}
+	client.OnCall = func(result ScimCallResult, err error) {
+		entry := &models.ProvisioningLog{
+			ApplicationID: config.ApplicationID,
+			UserID:        userID,
+			TaskID:        taskID,
+			Operation:     client.operation,
+			Method:        result.Method,
+			Path:          result.Path,
+			StatusCode:    result.StatusCode,
+			Success:       err == nil,
+			Message:       "ok",
+			DurationMs:    result.Duration.Milliseconds(),
+		}
+		if err != nil {
+			entry.Message = err.Error()
+		}
+		s.DB.Create(entry)
+	}
+	return client
+}
+
+// provisioningClient associe un client SCIM à l'opération journalisée en cours
+type provisioningClient struct {
+	*ScimClient
+	operation string
+}
+
+// NotifyUserProvisioning enfile un événement sans faire échouer l'opération qui l'a déclenché,
+// et le transmet aux webhooks abonnés. À appeler après la validation de la transaction qui a
+// modifié l'utilisateur.
+func NotifyUserProvisioning(db *gorm.DB, userID, event string) {
+	if db == nil || userID == "" {
+		return
+	}
+	if err := NewProvisioningService(db).EnqueueUserEvent(userID, event); err != nil {
+		fmt.Printf("Provisioning: failed to enqueue %s for user %s: %v\n", event, userID, err)
+	}
+	NotifyUserWebhooks(db, event, userID, nil)
+}
+
+// UserLifecycleEvent choisit l'événement correspondant à l'état de l'utilisateur mis à jour
+func UserLifecycleEvent(user *models.User) string {
+	if !user.IsActive {
+		return models.ProvisioningEventUserDeactivated
+	}
+	return models.ProvisioningEventUserUpdated
+}
+
+func validateProvisioningConfig(config *models.ApplicationProvisioning) error {
+	if config.BaseURL == "" || !(strings.HasPrefix(config.BaseURL, "https://") || strings.HasPrefix(config.BaseURL, "http://")) {
+		return errors.New("baseUrl must be an http(s) URL")
+	}
+	switch config.AuthType {
+	case models.ProvisioningAuthBearer, models.ProvisioningAuthBasic:
+	case models.ProvisioningAuthHeader:
+		if config.AuthHeaderName == nil || *config.AuthHeaderName == "" {
+			return errors.New("authHeaderName is required for header authentication")
+		}
+	default:
+		return fmt.Errorf("unsupported authType: %s", config.AuthType)
+	}
+	if config.DeprovisionMode != models.ProvisioningDeprovisionDeactivate && config.DeprovisionMode != models.ProvisioningDeprovisionDelete {
+		return fmt.Errorf("unsupported deprovisionMode: %s", config.DeprovisionMode)
+	}
+	if config.MaxRetries < 1 {
+		return errors.New("maxRetries must be at least 1")
+	}
+	if config.ReconcileInterval < 0 {
+		return errors.New("reconcileInterval cannot be negative")
+	}
+	for path := range provisioningMapping(config) {
+		if _, err := parseScimPatchPath(path); err != nil {
+			return fmt.Errorf("invalid mapping path %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// provisioningMapping renvoie le mapping configuré, ou le mapping par défaut
+func provisioningMapping(config *models.ApplicationProvisioning) map[string]string {
+	mapping := make(map[string]string)
+	switch raw := config.AttributeMapping.(type) {
+	case map[string]string:
+		for k, v := range raw {
+			mapping[k] = v
+		}
+	case map[string]interface{}:
+		for k, v := range raw {
+			if str, ok := v.(string); ok {
+				mapping[k] = str
+			}
+		}
+	}
+	if len(mapping) == 0 {
+		return DefaultProvisioningMapping
+	}
+	return mapping
+}
+
+// provisioningSource expose les attributs locaux utilisables dans un mapping
+func provisioningSource(user *models.User, profile *models.Profile) map[string]interface{} {
+	source := map[string]interface{}{
+		"id":            user.ID,
+		"active":        user.IsActive,
+		"emailVerified": user.EmailVerified,
+	}
+	set := func(key string, value *string) {
+		if value != nil && *value != "" {
+			source[key] = *value
+		}
+	}
+	set("email", user.Email)
+	set("username", user.Username)
+	set("name", user.Name)
+	set("externalId", user.ExternalID)
+	set("displayName", profile.DisplayName)
+	set("locale", profile.Locale)
+	set("timezone", profile.Timezone)
+	set("avatarUrl", profile.AvatarURL)
+
+	if user.Email != nil && *user.Email != "" {
+		source["userName"] = *user.Email
+	} else if user.Username != nil {
+		source["userName"] = *user.Username
+	}
+	if _, ok := source["displayName"]; !ok && user.Name != nil && *user.Name != "" {
+		source["displayName"] = *user.Name
+	}
+	if user.Name != nil {
+		given, family, _ := strings.Cut(strings.TrimSpace(*user.Name), " ")
+		if given != "" {
+			source["givenName"] = given
+		}
+		if family = strings.TrimSpace(family); family != "" {
+			source["familyName"] = family
+		}
+	}
+	return source
+}
+
+// provisioningSourceValue résout un attribut local ou une constante préfixée par "="
+func provisioningSourceValue(source map[string]interface{}, attr string) (interface{}, bool) {
+	if constant, ok := strings.CutPrefix(attr, "="); ok {
+		if b, err := strconv.ParseBool(constant); err == nil {
+			return b, true
+		}
+		return constant, true
+	}
+	key, ok := findScimKey(source, attr)
+	if !ok {
+		return nil, false
+	}
+	return source[key], true
+}
+
+// scimPathValue lit la valeur d'un chemin SCIM (attr, attr.sub, attr[filtre].sub) dans une ressource
+func scimPathValue(resource map[string]interface{}, rawPath string) (interface{}, bool) {
+	path, err := parseScimPatchPath(rawPath)
+	if err != nil {
+		return nil, false
+	}
+	key, ok := findScimKey(resource, path.Attr)
+	if !ok {
+		return nil, false
+	}
+	value := resource[key]
+
+	if path.Filter != nil {
+		items, _ := value.([]interface{})
+		value = nil
+		for _, item := range items {
+			if element, ok := item.(map[string]interface{}); ok && path.Filter.Matches(element) {
+				value = element
+				break
+			}
+		}
+		if value == nil {
+			return nil, false
+		}
+	}
+
+	if path.SubAttr != "" {
+		element, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		subKey, ok := findScimKey(element, path.SubAttr)
+		if !ok {
+			return nil, false
+		}
+		value = element[subKey]
+	}
+	return value, true
+}
+
+// diffProvisioningResource compare les attributs mappés entre la ressource locale et distante
+func diffProvisioningResource(mapping map[string]string, local, remote map[string]interface{}) map[string][2]string {
+	diff := make(map[string][2]string)
+	for path := range mapping {
+		localValue, ok := scimPathValue(local, path)
+		if !ok {
+			continue
+		}
+		remoteValue, _ := scimPathValue(remote, path)
+
+		expected := fmt.Sprint(localValue)
+		actual := ""
+		if remoteValue != nil {
+			actual = fmt.Sprint(remoteValue)
+		}
+		if expected != actual {
+			diff[path] = [2]string{expected, actual}
+		}
+	}
+	return diff
+}
+
+func provisioningRetryable(err error) bool {
+	var clientErr *ScimClientError
+	if errors.As(err, &clientErr) {
+		return clientErr.Retryable()
+	}
+	return !errors.Is(err, ErrProvisioningDisabled) && !errors.Is(err, ErrProvisioningNotConfigured)
+}
+
+// provisioningBackoff calcule le délai exponentiel avant la prochaine tentative
+func provisioningBackoff(attempt int) time.Duration {
+	delay := provisioningBaseBackoff
+	for i := 1; i < attempt && delay < provisioningMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > provisioningMaxBackoff {
+		delay = provisioningMaxBackoff
+	}
+	return delay + rand.N(delay/10+1)
+}
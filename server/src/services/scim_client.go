@@ -0,0 +1,239 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+)
+
+// ScimClientError représente une réponse d'erreur d'un serveur SCIM distant
+type ScimClientError struct {
+	StatusCode int
+	Detail     string
+}
+
+func (e *ScimClientError) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("scim endpoint returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("scim endpoint returned status %d: %s", e.StatusCode, e.Detail)
+}
+
+// Retryable indique si l'appel peut être retenté plus tard
+func (e *ScimClientError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode == http.StatusRequestTimeout ||
+		e.StatusCode >= 500
+}
+
+// ScimCallResult décrit un appel HTTP effectué vers un serveur SCIM
+type ScimCallResult struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Duration   time.Duration
+}
+
+// ScimClient envoie des ressources vers un serveur SCIM 2.0 distant
+type ScimClient struct {
+	BaseURL    string
+	Config     *models.ApplicationProvisioning
+	HTTPClient *http.Client
+
+	// OnCall est appelé après chaque requête, pour la journalisation
+	OnCall func(result ScimCallResult, err error)
+}
+
+// NewScimClient crée un client SCIM à partir de la configuration de provisioning d'une application
+func NewScimClient(config *models.ApplicationProvisioning) *ScimClient {
+	return &ScimClient{
+		BaseURL:    strings.TrimRight(config.BaseURL, "/"),
+		Config:     config,
+		HTTPClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// CreateUser crée un utilisateur distant et renvoie la ressource créée
+func (c *ScimClient) CreateUser(resource map[string]interface{}) (map[string]interface{}, error) {
+	var created map[string]interface{}
+	err := c.do(http.MethodPost, "/Users", resource, &created)
+	return created, err
+}
+
+// GetUser récupère un utilisateur distant
+func (c *ScimClient) GetUser(id string) (map[string]interface{}, error) {
+	var user map[string]interface{}
+	err := c.do(http.MethodGet, "/Users/"+url.PathEscape(id), nil, &user)
+	return user, err
+}
+
+// ReplaceUser remplace un utilisateur distant
+func (c *ScimClient) ReplaceUser(id string, resource map[string]interface{}) (map[string]interface{}, error) {
+	var replaced map[string]interface{}
+	err := c.do(http.MethodPut, "/Users/"+url.PathEscape(id), resource, &replaced)
+	return replaced, err
+}
+
+// SetUserActive active ou désactive un utilisateur distant via PATCH
+func (c *ScimClient) SetUserActive(id string, active bool) error {
+	patch := models.ScimPatchRequest{
+		Schemas: []string{models.ScimSchemaPatchOp},
+		Operations: []models.ScimPatchOperation{
+			{Op: "replace", Path: "active", Value: active},
+		},
+	}
+	return c.do(http.MethodPatch, "/Users/"+url.PathEscape(id), patch, nil)
+}
+
+// DeleteUser supprime un utilisateur distant
+func (c *ScimClient) DeleteUser(id string) error {
+	return c.do(http.MethodDelete, "/Users/"+url.PathEscape(id), nil, nil)
+}
+
+// FindUserByUserName recherche un utilisateur distant par userName
+func (c *ScimClient) FindUserByUserName(userName string) (map[string]interface{}, error) {
+	filter := fmt.Sprintf(`userName eq "%s"`, strings.ReplaceAll(userName, `"`, `\"`))
+	users, _, err := c.ListUsers(filter, 1, 1)
+	if err != nil || len(users) == 0 {
+		return nil, err
+	}
+	return users[0], nil
+}
+
+// ListUsers liste une page d'utilisateurs distants
+func (c *ScimClient) ListUsers(filter string, startIndex, count int) ([]map[string]interface{}, int, error) {
+	query := url.Values{}
+	query.Set("startIndex", strconv.Itoa(startIndex))
+	query.Set("count", strconv.Itoa(count))
+	if filter != "" {
+		query.Set("filter", filter)
+	}
+
+	var list struct {
+		TotalResults int                      `json:"totalResults"`
+		Resources    []map[string]interface{} `json:"Resources"`
+	}
+	if err := c.do(http.MethodGet, "/Users?"+query.Encode(), nil, &list); err != nil {
+		return nil, 0, err
+	}
+	return list.Resources, list.TotalResults, nil
+}
+
+// ListAllUsers parcourt toutes les pages d'utilisateurs distants
+func (c *ScimClient) ListAllUsers() ([]map[string]interface{}, error) {
+	const pageSize = 100
+
+	var users []map[string]interface{}
+	startIndex := 1
+	for {
+		page, total, err := c.ListUsers("", startIndex, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, page...)
+		startIndex += len(page)
+		if len(page) == 0 || startIndex > total {
+			return users, nil
+		}
+	}
+}
+
+// TestConnection vérifie que l'endpoint distant répond
+func (c *ScimClient) TestConnection() error {
+	_, _, err := c.ListUsers("", 1, 1)
+	return err
+}
+
+func (c *ScimClient) do(method, path string, body interface{}, out interface{}) error {
+	started := time.Now()
+	result := ScimCallResult{Method: method, Path: path}
+
+	err := c.send(method, path, body, out, &result)
+	result.Duration = time.Since(started)
+	if c.OnCall != nil {
+		c.OnCall(result, err)
+	}
+	return err
+}
+
+func (c *ScimClient) send(method, path string, body interface{}, out interface{}, result *ScimCallResult) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/scim+json, application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/scim+json")
+	}
+	c.authenticate(req)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	result.StatusCode = resp.StatusCode
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		var scimErr models.ScimError
+		detail := strings.TrimSpace(string(data))
+		if json.Unmarshal(data, &scimErr) == nil && scimErr.Detail != "" {
+			detail = scimErr.Detail
+		}
+		if len(detail) > 500 {
+			detail = detail[:500]
+		}
+		return &ScimClientError{StatusCode: resp.StatusCode, Detail: detail}
+	}
+
+	if out != nil && len(data) > 0 {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
+
+func (c *ScimClient) authenticate(req *http.Request) {
+	secret := ""
+	if c.Config.Secret != nil {
+		secret = *c.Config.Secret
+	}
+
+	switch c.Config.AuthType {
+	case models.ProvisioningAuthBasic:
+		username := ""
+		if c.Config.Username != nil {
+			username = *c.Config.Username
+		}
+		req.SetBasicAuth(username, secret)
+	case models.ProvisioningAuthHeader:
+		if c.Config.AuthHeaderName != nil && *c.Config.AuthHeaderName != "" {
+			req.Header.Set(*c.Config.AuthHeaderName, secret)
+		}
+	default:
+		if secret != "" {
+			req.Header.Set("Authorization", "Bearer "+secret)
+		}
+	}
+}
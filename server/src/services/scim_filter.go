@@ -0,0 +1,454 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ScimFilter représente un nœud de l'arbre d'un filtre SCIM (RFC 7644 §3.4.2.2)
+type ScimFilter struct {
+	Op    string // eq, ne, co, sw, ew, pr, gt, ge, lt, le, and, or, not
+	Attr  string
+	Value interface{}
+	Left  *ScimFilter
+	Right *ScimFilter
+}
+
+// scimColumn décrit comment un attribut SCIM est traduit en expression SQL
+type scimColumn struct {
+	Expr string
+	Type string // string, bool, time, id, exists
+}
+
+// ParseScimFilter analyse une expression de filtre SCIM
+func ParseScimFilter(input string) (*ScimFilter, error) {
+	tokens, err := tokenizeScimFilter(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &scimFilterParser{tokens: tokens}
+	filter, err := p.parseOr("")
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected token %q", p.tokens[p.pos].text)
+	}
+	return filter, nil
+}
+
+// normalizeScimAttr retire le préfixe URN d'un chemin d'attribut et le met en minuscules
+func normalizeScimAttr(attr string) string {
+	if strings.HasPrefix(strings.ToLower(attr), "urn:") {
+		if idx := strings.LastIndex(attr, ":"); idx >= 0 {
+			attr = attr[idx+1:]
+		}
+	}
+	return strings.ToLower(attr)
+}
+
+// ToSQL traduit le filtre en clause WHERE à partir de la table de correspondance des colonnes
+func (f *ScimFilter) ToSQL(columns map[string]scimColumn) (string, []interface{}, error) {
+	switch f.Op {
+	case "and", "or":
+		left, leftArgs, err := f.Left.ToSQL(columns)
+		if err != nil {
+			return "", nil, err
+		}
+		right, rightArgs, err := f.Right.ToSQL(columns)
+		if err != nil {
+			return "", nil, err
+		}
+		return "(" + left + " " + strings.ToUpper(f.Op) + " " + right + ")", append(leftArgs, rightArgs...), nil
+	case "not":
+		inner, args, err := f.Left.ToSQL(columns)
+		if err != nil {
+			return "", nil, err
+		}
+		return "NOT (" + inner + ")", args, nil
+	}
+
+	column, ok := columns[normalizeScimAttr(f.Attr)]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported filter attribute: %s", f.Attr)
+	}
+
+	if column.Type == "exists" {
+		if f.Op != "eq" {
+			return "", nil, fmt.Errorf("operator %s is not supported on %s", f.Op, f.Attr)
+		}
+		return column.Expr, []interface{}{fmt.Sprint(f.Value)}, nil
+	}
+
+	if f.Op == "pr" {
+		return column.Expr + " IS NOT NULL", nil, nil
+	}
+	if f.Value == nil {
+		switch f.Op {
+		case "eq":
+			return column.Expr + " IS NULL", nil, nil
+		case "ne":
+			return column.Expr + " IS NOT NULL", nil, nil
+		}
+		return "", nil, fmt.Errorf("operator %s does not accept null", f.Op)
+	}
+
+	expr := column.Expr
+	var value interface{} = f.Value
+	switch column.Type {
+	case "bool":
+		b, ok := f.Value.(bool)
+		if !ok || (f.Op != "eq" && f.Op != "ne") {
+			return "", nil, fmt.Errorf("invalid boolean comparison on %s", f.Attr)
+		}
+		value = b
+	case "time":
+		str, ok := f.Value.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("invalid date value for %s", f.Attr)
+		}
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid date value for %s", f.Attr)
+		}
+		value = t
+	case "id":
+		expr = "CAST(" + expr + " AS TEXT)"
+		value = fmt.Sprint(f.Value)
+	default:
+		// Les attributs textuels SCIM ne sont pas sensibles à la casse par défaut
+		expr = "LOWER(" + expr + ")"
+		value = strings.ToLower(fmt.Sprint(f.Value))
+	}
+
+	switch f.Op {
+	case "eq":
+		return expr + " = ?", []interface{}{value}, nil
+	case "ne":
+		return expr + " <> ?", []interface{}{value}, nil
+	case "gt":
+		return expr + " > ?", []interface{}{value}, nil
+	case "ge":
+		return expr + " >= ?", []interface{}{value}, nil
+	case "lt":
+		return expr + " < ?", []interface{}{value}, nil
+	case "le":
+		return expr + " <= ?", []interface{}{value}, nil
+	case "co", "sw", "ew":
+		str, ok := value.(string)
+		if !ok || column.Type == "bool" || column.Type == "time" {
+			return "", nil, fmt.Errorf("operator %s requires a string attribute", f.Op)
+		}
+		str = escapeLike(str)
+		switch f.Op {
+		case "co":
+			str = "%" + str + "%"
+		case "sw":
+			str = str + "%"
+		case "ew":
+			str = "%" + str
+		}
+		return expr + " LIKE ?", []interface{}{str}, nil
+	}
+
+	return "", nil, fmt.Errorf("unsupported filter operator: %s", f.Op)
+}
+
+// Matches évalue le filtre sur une ressource représentée sous forme de map (utilisé par PATCH)
+func (f *ScimFilter) Matches(resource map[string]interface{}) bool {
+	switch f.Op {
+	case "and":
+		return f.Left.Matches(resource) && f.Right.Matches(resource)
+	case "or":
+		return f.Left.Matches(resource) || f.Right.Matches(resource)
+	case "not":
+		return !f.Left.Matches(resource)
+	}
+
+	actual, found := lookupScimAttr(resource, f.Attr)
+	if f.Op == "pr" {
+		return found && actual != nil && actual != ""
+	}
+	if !found || actual == nil {
+		return f.Value == nil && f.Op == "eq"
+	}
+
+	switch expected := f.Value.(type) {
+	case bool:
+		b, ok := actual.(bool)
+		if !ok {
+			return false
+		}
+		return (f.Op == "eq" && b == expected) || (f.Op == "ne" && b != expected)
+	case float64:
+		n, ok := actual.(float64)
+		if !ok {
+			return false
+		}
+		return compareOrdered(f.Op, n, expected)
+	default:
+		a := strings.ToLower(fmt.Sprint(actual))
+		e := strings.ToLower(fmt.Sprint(expected))
+		switch f.Op {
+		case "co":
+			return strings.Contains(a, e)
+		case "sw":
+			return strings.HasPrefix(a, e)
+		case "ew":
+			return strings.HasSuffix(a, e)
+		}
+		return compareOrdered(f.Op, a, e)
+	}
+}
+
+func compareOrdered[T float64 | string](op string, a, b T) bool {
+	switch op {
+	case "eq":
+		return a == b
+	case "ne":
+		return a != b
+	case "gt":
+		return a > b
+	case "ge":
+		return a >= b
+	case "lt":
+		return a < b
+	case "le":
+		return a <= b
+	}
+	return false
+}
+
+// lookupScimAttr lit un attribut (éventuellement "parent.enfant") sans tenir compte de la casse
+func lookupScimAttr(resource map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = resource
+	for _, part := range strings.Split(normalizeScimAttr(path), ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		key, ok := findScimKey(obj, part)
+		if !ok {
+			return nil, false
+		}
+		current = obj[key]
+	}
+	return current, true
+}
+
+// findScimKey retrouve la clé d'une map sans tenir compte de la casse
+func findScimKey(obj map[string]interface{}, name string) (string, bool) {
+	if _, ok := obj[name]; ok {
+		return name, true
+	}
+	for key := range obj {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return name, false
+}
+
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
+
+type scimToken struct {
+	kind string // word, string, lparen, rparen, lbracket, rbracket
+	text string
+}
+
+func tokenizeScimFilter(input string) ([]scimToken, error) {
+	var tokens []scimToken
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, scimToken{kind: "lparen", text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, scimToken{kind: "rparen", text: ")"})
+			i++
+		case r == '[':
+			tokens = append(tokens, scimToken{kind: "lbracket", text: "["})
+			i++
+		case r == ']':
+			tokens = append(tokens, scimToken{kind: "rbracket", text: "]"})
+			i++
+		case r == '"':
+			j := i + 1
+			for j < len(runes) && runes[j] != '"' {
+				if runes[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string in filter")
+			}
+			tokens = append(tokens, scimToken{kind: "string", text: string(runes[i : j+1])})
+			i = j + 1
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune("()[]\"", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, scimToken{kind: "word", text: string(runes[i:j])})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type scimFilterParser struct {
+	tokens []scimToken
+	pos    int
+}
+
+func (p *scimFilterParser) peekWord(word string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == "word" && strings.EqualFold(p.tokens[p.pos].text, word)
+}
+
+func (p *scimFilterParser) parseOr(prefix string) (*ScimFilter, error) {
+	left, err := p.parseAnd(prefix)
+	if err != nil {
+		return nil, err
+	}
+	for p.peekWord("or") {
+		p.pos++
+		right, err := p.parseAnd(prefix)
+		if err != nil {
+			return nil, err
+		}
+		left = &ScimFilter{Op: "or", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *scimFilterParser) parseAnd(prefix string) (*ScimFilter, error) {
+	left, err := p.parseUnary(prefix)
+	if err != nil {
+		return nil, err
+	}
+	for p.peekWord("and") {
+		p.pos++
+		right, err := p.parseUnary(prefix)
+		if err != nil {
+			return nil, err
+		}
+		left = &ScimFilter{Op: "and", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *scimFilterParser) parseUnary(prefix string) (*ScimFilter, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of filter")
+	}
+
+	if p.peekWord("not") {
+		p.pos++
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != "lparen" {
+			return nil, fmt.Errorf("expected '(' after not")
+		}
+		inner, err := p.parseUnary(prefix)
+		if err != nil {
+			return nil, err
+		}
+		return &ScimFilter{Op: "not", Left: inner}, nil
+	}
+
+	if p.tokens[p.pos].kind == "lparen" {
+		p.pos++
+		inner, err := p.parseOr(prefix)
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != "rparen" {
+			return nil, fmt.Errorf("expected ')'")
+		}
+		p.pos++
+		return inner, nil
+	}
+
+	token := p.tokens[p.pos]
+	if token.kind != "word" {
+		return nil, fmt.Errorf("expected attribute path, got %q", token.text)
+	}
+	p.pos++
+	attr := token.text
+	if prefix != "" {
+		attr = prefix + "." + attr
+	}
+
+	// valuePath : emails[type eq "work"] est aplati en emails.type eq "work"
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == "lbracket" {
+		p.pos++
+		inner, err := p.parseOr(attr)
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != "rbracket" {
+			return nil, fmt.Errorf("expected ']'")
+		}
+		p.pos++
+		return inner, nil
+	}
+
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != "word" {
+		return nil, fmt.Errorf("expected operator after %s", attr)
+	}
+	op := strings.ToLower(p.tokens[p.pos].text)
+	p.pos++
+
+	switch op {
+	case "pr":
+		return &ScimFilter{Op: op, Attr: attr}, nil
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+	default:
+		return nil, fmt.Errorf("unsupported operator %q", op)
+	}
+
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("expected value after %s", op)
+	}
+	value, err := parseScimValue(p.tokens[p.pos])
+	if err != nil {
+		return nil, err
+	}
+	p.pos++
+	return &ScimFilter{Op: op, Attr: attr, Value: value}, nil
+}
+
+func parseScimValue(token scimToken) (interface{}, error) {
+	if token.kind == "string" {
+		var str string
+		if err := json.Unmarshal([]byte(token.text), &str); err != nil {
+			return nil, fmt.Errorf("invalid string value %s", token.text)
+		}
+		return str, nil
+	}
+	if token.kind != "word" {
+		return nil, fmt.Errorf("invalid value %q", token.text)
+	}
+	switch strings.ToLower(token.text) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if n, err := strconv.ParseFloat(token.text, 64); err == nil {
+		return n, nil
+	}
+	return nil, fmt.Errorf("invalid value %q", token.text)
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+)
+
+// scimExtensionSchemas liste les schémas d'extension acceptés dans les chemins PATCH
+var scimExtensionSchemas = []string{models.ScimSchemaAetherGroup}
+
+// scimPatchPath représente un chemin PATCH décomposé : attr[filtre].sousAttr
+type scimPatchPath struct {
+	Attr    string
+	Filter  *ScimFilter
+	SubAttr string
+}
+
+// parseScimPatchPath analyse un chemin d'opération PATCH SCIM
+func parseScimPatchPath(path string) (*scimPatchPath, error) {
+	path = strings.TrimSpace(path)
+
+	// Les attributs d'extension sont stockés sous la clé URN de leur schéma
+	for _, schema := range scimExtensionSchemas {
+		if len(path) >= len(schema) && strings.EqualFold(path[:len(schema)], schema) {
+			return &scimPatchPath{Attr: schema, SubAttr: strings.TrimPrefix(path[len(schema):], ":")}, nil
+		}
+	}
+
+	// Retirer le préfixe URN de schéma (ex: urn:...:User:name.givenName)
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		head := path
+		if idx := strings.Index(head, "["); idx >= 0 {
+			head = head[:idx]
+		}
+		if idx := strings.LastIndex(head, ":"); idx >= 0 {
+			path = path[idx+1:]
+		}
+	}
+
+	result := &scimPatchPath{}
+	if open := strings.Index(path, "["); open >= 0 {
+		closing := strings.LastIndex(path, "]")
+		if closing < open {
+			return nil, fmt.Errorf("invalid path: %s", path)
+		}
+		filter, err := ParseScimFilter(path[open+1 : closing])
+		if err != nil {
+			return nil, err
+		}
+		result.Attr = path[:open]
+		result.Filter = filter
+		result.SubAttr = strings.TrimPrefix(path[closing+1:], ".")
+		return result, nil
+	}
+
+	if attr, sub, ok := strings.Cut(path, "."); ok {
+		result.Attr = attr
+		result.SubAttr = sub
+		return result, nil
+	}
+	result.Attr = path
+	return result, nil
+}
+
+// applyScimPatch applique les opérations PATCH à une ressource représentée sous forme de map
+func applyScimPatch(resource map[string]interface{}, operations []models.ScimPatchOperation) error {
+	for _, operation := range operations {
+		op := strings.ToLower(operation.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return newScimError(http.StatusBadRequest, "invalidSyntax", "unsupported patch operation: "+operation.Op)
+		}
+
+		if operation.Path == "" {
+			if op == "remove" {
+				return newScimError(http.StatusBadRequest, "noTarget", "remove operations require a path")
+			}
+			values, ok := operation.Value.(map[string]interface{})
+			if !ok {
+				return newScimError(http.StatusBadRequest, "invalidValue", "patch value must be an object when no path is given")
+			}
+			for key, value := range values {
+				if err := applyScimPatchPath(resource, op, key, value); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if err := applyScimPatchPath(resource, op, operation.Path, operation.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyScimPatchPath(resource map[string]interface{}, op, rawPath string, value interface{}) error {
+	path, err := parseScimPatchPath(rawPath)
+	if err != nil {
+		return newScimError(http.StatusBadRequest, "invalidPath", err.Error())
+	}
+
+	key, _ := findScimKey(resource, path.Attr)
+
+	// Attribut multi-valué ciblé par un filtre : emails[type eq "work"].value
+	if path.Filter != nil {
+		items, _ := resource[key].([]interface{})
+		var kept []interface{}
+		matched := false
+		for _, item := range items {
+			element, ok := item.(map[string]interface{})
+			if !ok || !path.Filter.Matches(element) {
+				kept = append(kept, item)
+				continue
+			}
+			matched = true
+			switch {
+			case op == "remove" && path.SubAttr == "":
+				continue
+			case op == "remove":
+				subKey, _ := findScimKey(element, path.SubAttr)
+				delete(element, subKey)
+			case path.SubAttr == "":
+				if replacement, ok := value.(map[string]interface{}); ok {
+					element = replacement
+				}
+			default:
+				subKey, _ := findScimKey(element, path.SubAttr)
+				element[subKey] = value
+			}
+			kept = append(kept, element)
+		}
+
+		// Créer l'élément s'il n'existe pas encore (comportement attendu par Azure AD et Okta)
+		if !matched && op != "remove" {
+			element := scimFilterDefaults(path.Filter)
+			if path.SubAttr != "" {
+				element[path.SubAttr] = value
+			} else if replacement, ok := value.(map[string]interface{}); ok {
+				for k, v := range replacement {
+					element[k] = v
+				}
+			}
+			kept = append(kept, element)
+		}
+
+		resource[key] = kept
+		return nil
+	}
+
+	// Sous-attribut d'un attribut complexe : name.givenName
+	if path.SubAttr != "" {
+		parent, ok := resource[key].(map[string]interface{})
+		if !ok {
+			if op == "remove" {
+				return nil
+			}
+			parent = make(map[string]interface{})
+		}
+		subKey, _ := findScimKey(parent, path.SubAttr)
+		if op == "remove" {
+			delete(parent, subKey)
+		} else {
+			parent[subKey] = value
+		}
+		resource[key] = parent
+		return nil
+	}
+
+	existing, exists := resource[key]
+	switch op {
+	case "remove":
+		// Retirer certaines valeurs d'un attribut multi-valué : {"op":"remove","path":"members","value":[{"value":"..."}]}
+		if items, ok := existing.([]interface{}); ok && value != nil {
+			resource[key] = removeScimValues(items, value)
+			return nil
+		}
+		delete(resource, key)
+	case "add":
+		if items, ok := existing.([]interface{}); ok && exists {
+			if additions, ok := value.([]interface{}); ok {
+				resource[key] = appendScimValues(items, additions)
+				return nil
+			}
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			if parent, ok := existing.(map[string]interface{}); ok {
+				for k, v := range nested {
+					parent[k] = v
+				}
+				return nil
+			}
+		}
+		resource[key] = value
+	case "replace":
+		if nested, ok := value.(map[string]interface{}); ok {
+			if parent, ok := existing.(map[string]interface{}); ok {
+				for k, v := range nested {
+					subKey, _ := findScimKey(parent, k)
+					parent[subKey] = v
+				}
+				return nil
+			}
+		}
+		resource[key] = value
+	}
+	return nil
+}
+
+// scimFilterDefaults extrait les égalités d'un filtre pour initialiser un nouvel élément
+func scimFilterDefaults(filter *ScimFilter) map[string]interface{} {
+	element := make(map[string]interface{})
+	var walk func(f *ScimFilter)
+	walk = func(f *ScimFilter) {
+		switch f.Op {
+		case "and":
+			walk(f.Left)
+			walk(f.Right)
+		case "eq":
+			element[f.Attr] = f.Value
+		}
+	}
+	walk(filter)
+	return element
+}
+
+// appendScimValues ajoute des valeurs à un attribut multi-valué en ignorant les doublons
+func appendScimValues(items, additions []interface{}) []interface{} {
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if element, ok := item.(map[string]interface{}); ok {
+			seen[fmt.Sprint(element["value"])] = true
+		}
+	}
+	for _, addition := range additions {
+		if element, ok := addition.(map[string]interface{}); ok {
+			if seen[fmt.Sprint(element["value"])] {
+				continue
+			}
+			seen[fmt.Sprint(element["value"])] = true
+		}
+		items = append(items, addition)
+	}
+	return items
+}
+
+// removeScimValues retire d'un attribut multi-valué les éléments dont la valeur est listée
+func removeScimValues(items []interface{}, value interface{}) []interface{} {
+	removals, ok := value.([]interface{})
+	if !ok {
+		removals = []interface{}{value}
+	}
+	remove := make(map[string]bool)
+	for _, removal := range removals {
+		if element, ok := removal.(map[string]interface{}); ok {
+			remove[fmt.Sprint(element["value"])] = true
+		}
+	}
+
+	var kept []interface{}
+	for _, item := range items {
+		if element, ok := item.(map[string]interface{}); ok && remove[fmt.Sprint(element["value"])] {
+			continue
+		}
+		kept = append(kept, item)
+	}
+	return kept
+}
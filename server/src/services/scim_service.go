@@ -0,0 +1,969 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"gorm.io/gorm"
+)
+
+// Limites de pagination annoncées dans ServiceProviderConfig
+const (
+	ScimDefaultCount = 100
+	ScimMaxCount     = 200
+)
+
+// ScimServiceError représente une erreur SCIM avec son code HTTP et son scimType
+type ScimServiceError struct {
+	Status   int
+	ScimType string
+	Detail   string
+}
+
+func (e *ScimServiceError) Error() string {
+	return e.Detail
+}
+
+func newScimError(status int, scimType, detail string) *ScimServiceError {
+	return &ScimServiceError{Status: status, ScimType: scimType, Detail: detail}
+}
+
+// Correspondance entre les attributs SCIM filtrables et les colonnes SQL
+var (
+	scimUserColumns = map[string]scimColumn{
+		"id":                {Expr: "users.id", Type: "id"},
+		"externalid":        {Expr: "users.external_id", Type: "string"},
+		"username":          {Expr: "COALESCE(users.username, users.email)", Type: "string"},
+		"displayname":       {Expr: "COALESCE(profiles.display_name, users.name)", Type: "string"},
+		"name.formatted":    {Expr: "users.name", Type: "string"},
+		"emails":            {Expr: "users.email", Type: "string"},
+		"emails.value":      {Expr: "users.email", Type: "string"},
+		"emails.type":       {Expr: "'work'", Type: "string"},
+		"active":            {Expr: "users.is_active", Type: "bool"},
+		"meta.created":      {Expr: "users.created_at", Type: "time"},
+		"meta.lastmodified": {Expr: "users.updated_at", Type: "time"},
+	}
+	scimRoleColumns = map[string]scimColumn{
+		"id":                {Expr: "roles.id", Type: "id"},
+		"externalid":        {Expr: "roles.external_id", Type: "string"},
+		"displayname":       {Expr: "roles.name", Type: "string"},
+		"type":              {Expr: "'role'", Type: "string"},
+		"members":           {Expr: "EXISTS (SELECT 1 FROM user_roles WHERE user_roles.role_id = roles.id AND user_roles.organization_id IS NULL AND CAST(user_roles.user_id AS TEXT) = ?)", Type: "exists"},
+		"members.value":     {Expr: "EXISTS (SELECT 1 FROM user_roles WHERE user_roles.role_id = roles.id AND user_roles.organization_id IS NULL AND CAST(user_roles.user_id AS TEXT) = ?)", Type: "exists"},
+		"meta.created":      {Expr: "roles.created_at", Type: "time"},
+		"meta.lastmodified": {Expr: "roles.updated_at", Type: "time"},
+	}
+	scimOrganizationColumns = map[string]scimColumn{
+		"id":                {Expr: "organizations.id", Type: "id"},
+		"externalid":        {Expr: "organizations.external_id", Type: "string"},
+		"displayname":       {Expr: "organizations.name", Type: "string"},
+		"type":              {Expr: "'organization'", Type: "string"},
+		"members":           {Expr: "EXISTS (SELECT 1 FROM memberships WHERE memberships.organization_id = organizations.id AND memberships.deleted_at IS NULL AND CAST(memberships.user_id AS TEXT) = ?)", Type: "exists"},
+		"members.value":     {Expr: "EXISTS (SELECT 1 FROM memberships WHERE memberships.organization_id = organizations.id AND memberships.deleted_at IS NULL AND CAST(memberships.user_id AS TEXT) = ?)", Type: "exists"},
+		"meta.created":      {Expr: "organizations.created_at", Type: "time"},
+		"meta.lastmodified": {Expr: "organizations.updated_at", Type: "time"},
+	}
+)
+
+var slugInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// ScimService expose les utilisateurs, rôles et organisations au format SCIM 2.0
+type ScimService struct {
+	DB      *gorm.DB
+	BaseURL string
+}
+
+// NewScimService crée une nouvelle instance de ScimService
+func NewScimService(db *gorm.DB, baseURL string) *ScimService {
+	return &ScimService{DB: db, BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// ScimETag calcule la version (ETag faible) d'une ressource à partir de sa date de modification
+func ScimETag(id string, updatedAt time.Time) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", id, updatedAt.UnixNano())))
+	return `W/"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// ---------------------------------------------------------------------------
+// Utilisateurs
+// ---------------------------------------------------------------------------
+
+// ListUsers renvoie une page d'utilisateurs correspondant au filtre SCIM
+func (s *ScimService) ListUsers(filter string, startIndex, count int) (*models.ScimListResponse, error) {
+	startIndex, count = normalizeScimPage(startIndex, count)
+
+	query := s.DB.Model(&models.User{}).Joins("LEFT JOIN profiles ON profiles.user_id = users.id")
+	if filter != "" {
+		parsed, err := ParseScimFilter(filter)
+		if err != nil {
+			return nil, newScimError(http.StatusBadRequest, "invalidFilter", err.Error())
+		}
+		clause, args, err := parsed.ToSQL(scimUserColumns)
+		if err != nil {
+			return nil, newScimError(http.StatusBadRequest, "invalidFilter", err.Error())
+		}
+		query = query.Where(clause, args...)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var users []models.User
+	if err := query.Order("users.created_at ASC").Offset(startIndex - 1).Limit(count).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	resources := make([]*models.ScimUser, 0, len(users))
+	for i := range users {
+		resource, err := s.userToScim(&users[i])
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
+
+	return &models.ScimListResponse{
+		Schemas:      []string{models.ScimSchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}, nil
+}
+
+// GetUser renvoie un utilisateur au format SCIM
+func (s *ScimService) GetUser(id string) (*models.ScimUser, error) {
+	user, err := s.findUser(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.userToScim(user)
+}
+
+// CreateUser crée un utilisateur à partir d'une ressource SCIM
+func (s *ScimService) CreateUser(resource *models.ScimUser) (*models.ScimUser, error) {
+	if strings.TrimSpace(resource.UserName) == "" {
+		return nil, newScimError(http.StatusBadRequest, "invalidValue", "userName is required")
+	}
+
+	email := scimPrimaryValue(resource.Emails)
+	if email == "" && strings.Contains(resource.UserName, "@") {
+		email = resource.UserName
+	}
+	if err := s.checkUserUniqueness("", resource.UserName, email); err != nil {
+		return nil, err
+	}
+
+	user := &models.User{IsActive: true}
+	if resource.Active != nil {
+		user.IsActive = *resource.Active
+	}
+	s.applyScimUser(user, resource, email)
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		userService := NewUserService(tx)
+		if resource.Password != "" {
+			if err := userService.CreateUser(user, resource.Password); err != nil {
+				return err
+			}
+		} else if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return s.saveProfile(tx, user.ID, resource)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	NotifyUserProvisioning(s.DB, user.ID, models.ProvisioningEventUserCreated)
+	return s.GetUser(user.ID)
+}
+
+// ReplaceUser remplace un utilisateur (PUT). Si version est fourni, il doit correspondre à l'ETag courant.
+func (s *ScimService) ReplaceUser(id string, resource *models.ScimUser, version string) (*models.ScimUser, error) {
+	user, err := s.findUser(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkScimVersion(version, user.ID, user.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(resource.UserName) == "" {
+		return nil, newScimError(http.StatusBadRequest, "invalidValue", "userName is required")
+	}
+
+	email := scimPrimaryValue(resource.Emails)
+	if email == "" && strings.Contains(resource.UserName, "@") {
+		email = resource.UserName
+	}
+	if err := s.checkUserUniqueness(user.ID, resource.UserName, email); err != nil {
+		return nil, err
+	}
+
+	s.applyScimUser(user, resource, email)
+	if resource.Active != nil {
+		user.IsActive = *resource.Active
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var newPassword *string
+		if resource.Password != "" {
+			newPassword = &resource.Password
+		}
+		if err := NewUserService(tx).UpdateUser(user, newPassword); err != nil {
+			return err
+		}
+		return s.saveProfile(tx, user.ID, resource)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	NotifyUserProvisioning(s.DB, user.ID, UserLifecycleEvent(user))
+	return s.GetUser(user.ID)
+}
+
+// PatchUser applique des opérations PATCH SCIM à un utilisateur
+func (s *ScimService) PatchUser(id string, patch *models.ScimPatchRequest, version string) (*models.ScimUser, error) {
+	current, err := s.GetUser(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != "" && current.Meta != nil && version != current.Meta.Version {
+		return nil, newScimError(http.StatusPreconditionFailed, "", "resource version mismatch")
+	}
+
+	var patched models.ScimUser
+	if err := patchScimResource(current, patch, &patched); err != nil {
+		return nil, err
+	}
+	return s.ReplaceUser(id, &patched, "")
+}
+
+// DeleteUser supprime un utilisateur
+func (s *ScimService) DeleteUser(id string, version string) error {
+	user, err := s.findUser(id)
+	if err != nil {
+		return err
+	}
+	if err := checkScimVersion(version, user.ID, user.UpdatedAt); err != nil {
+		return err
+	}
+	if err := NewUserService(s.DB).DeleteUser(user.ID); err != nil {
+		return err
+	}
+
+	NotifyUserProvisioning(s.DB, user.ID, models.ProvisioningEventUserDeleted)
+	return nil
+}
+
+func (s *ScimService) findUser(id string) (*models.User, error) {
+	var user models.User
+	if err := s.DB.First(&user, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || isInvalidUUIDError(err) {
+			return nil, newScimError(http.StatusNotFound, "", "User "+id+" not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *ScimService) checkUserUniqueness(excludeID, userName, email string) error {
+	query := s.DB.Model(&models.User{}).Where("(LOWER(username) = LOWER(?) OR (? <> '' AND LOWER(email) = LOWER(?)))", userName, email, email)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return newScimError(http.StatusConflict, "uniqueness", "userName or email already exists")
+	}
+	return nil
+}
+
+// applyScimUser copie les attributs SCIM sur le modèle User
+func (s *ScimService) applyScimUser(user *models.User, resource *models.ScimUser, email string) {
+	userName := resource.UserName
+	user.Username = &userName
+	if email != "" {
+		user.Email = &email
+	}
+	if resource.ExternalID != "" {
+		externalID := resource.ExternalID
+		user.ExternalID = &externalID
+	} else {
+		user.ExternalID = nil
+	}
+
+	name := ""
+	if resource.Name != nil {
+		name = resource.Name.Formatted
+		if name == "" {
+			name = strings.TrimSpace(resource.Name.GivenName + " " + resource.Name.FamilyName)
+		}
+	}
+	if name == "" {
+		name = resource.DisplayName
+	}
+	if name != "" {
+		user.Name = &name
+	} else {
+		user.Name = nil
+	}
+}
+
+// saveProfile enregistre les attributs SCIM portés par le profil
+func (s *ScimService) saveProfile(tx *gorm.DB, userID string, resource *models.ScimUser) error {
+	var profile models.Profile
+	err := tx.Where("user_id = ?", userID).First(&profile).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	profile.UserID = userID
+	profile.DisplayName = optionalString(resource.DisplayName)
+	profile.Locale = optionalString(resource.Locale)
+	profile.Timezone = optionalString(resource.Timezone)
+	profile.AvatarURL = optionalString(scimPrimaryValue(resource.Photos))
+	return tx.Save(&profile).Error
+}
+
+func (s *ScimService) userToScim(user *models.User) (*models.ScimUser, error) {
+	var profile models.Profile
+	if err := s.DB.Where("user_id = ?", user.ID).First(&profile).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	active := user.IsActive
+	resource := &models.ScimUser{
+		Schemas: []string{models.ScimSchemaUser},
+		ID:      user.ID,
+		Active:  &active,
+		Meta: &models.ScimMeta{
+			ResourceType: "User",
+			Created:      &user.CreatedAt,
+			LastModified: &user.UpdatedAt,
+			Location:     s.BaseURL + "/Users/" + user.ID,
+			Version:      ScimETag(user.ID, user.UpdatedAt),
+		},
+	}
+
+	if user.ExternalID != nil {
+		resource.ExternalID = *user.ExternalID
+	}
+	switch {
+	case user.Username != nil:
+		resource.UserName = *user.Username
+	case user.Email != nil:
+		resource.UserName = *user.Email
+	}
+	if user.Name != nil && *user.Name != "" {
+		given, family, _ := strings.Cut(*user.Name, " ")
+		resource.Name = &models.ScimName{Formatted: *user.Name, GivenName: given, FamilyName: family}
+		resource.DisplayName = *user.Name
+	}
+	if profile.DisplayName != nil {
+		resource.DisplayName = *profile.DisplayName
+	}
+	if profile.Locale != nil {
+		resource.Locale = *profile.Locale
+	}
+	if profile.Timezone != nil {
+		resource.Timezone = *profile.Timezone
+	}
+	if profile.AvatarURL != nil {
+		resource.Photos = []models.ScimMultiValue{{Value: *profile.AvatarURL, Type: "photo", Primary: true}}
+	}
+	if user.Email != nil {
+		resource.Emails = []models.ScimMultiValue{{Value: *user.Email, Type: "work", Primary: true}}
+	}
+
+	groups, err := s.userGroups(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	resource.Groups = groups
+
+	return resource, nil
+}
+
+// userGroups liste les rôles et organisations de l'utilisateur (attribut en lecture seule)
+func (s *ScimService) userGroups(userID string) ([]models.ScimMultiValue, error) {
+	var roles []models.Role
+	if err := s.DB.Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ? AND user_roles.organization_id IS NULL", userID).Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	var orgs []models.Organization
+	if err := s.DB.Joins("JOIN memberships ON memberships.organization_id = organizations.id").
+		Where("memberships.user_id = ? AND memberships.deleted_at IS NULL", userID).Find(&orgs).Error; err != nil {
+		return nil, err
+	}
+
+	groups := make([]models.ScimMultiValue, 0, len(roles)+len(orgs))
+	for _, role := range roles {
+		groups = append(groups, models.ScimMultiValue{Value: role.ID, Display: role.Name, Type: "direct", Ref: s.BaseURL + "/Groups/" + role.ID})
+	}
+	for _, org := range orgs {
+		groups = append(groups, models.ScimMultiValue{Value: org.ID, Display: org.Name, Type: "direct", Ref: s.BaseURL + "/Groups/" + org.ID})
+	}
+	return groups, nil
+}
+
+// ---------------------------------------------------------------------------
+// Groupes (rôles et organisations)
+// ---------------------------------------------------------------------------
+
+// ListGroups renvoie une page de groupes : les rôles d'abord, puis les organisations
+func (s *ScimService) ListGroups(filter string, startIndex, count int) (*models.ScimListResponse, error) {
+	startIndex, count = normalizeScimPage(startIndex, count)
+
+	roleQuery := s.DB.Model(&models.Role{})
+	orgQuery := s.DB.Model(&models.Organization{})
+	if filter != "" {
+		parsed, err := ParseScimFilter(filter)
+		if err != nil {
+			return nil, newScimError(http.StatusBadRequest, "invalidFilter", err.Error())
+		}
+		roleClause, roleArgs, err := parsed.ToSQL(scimRoleColumns)
+		if err != nil {
+			return nil, newScimError(http.StatusBadRequest, "invalidFilter", err.Error())
+		}
+		orgClause, orgArgs, err := parsed.ToSQL(scimOrganizationColumns)
+		if err != nil {
+			return nil, newScimError(http.StatusBadRequest, "invalidFilter", err.Error())
+		}
+		roleQuery = roleQuery.Where(roleClause, roleArgs...)
+		orgQuery = orgQuery.Where(orgClause, orgArgs...)
+	}
+
+	var roleTotal, orgTotal int64
+	if err := roleQuery.Count(&roleTotal).Error; err != nil {
+		return nil, err
+	}
+	if err := orgQuery.Count(&orgTotal).Error; err != nil {
+		return nil, err
+	}
+
+	resources := make([]*models.ScimGroup, 0, count)
+	offset := int64(startIndex - 1)
+
+	if offset < roleTotal {
+		var roles []models.Role
+		if err := roleQuery.Order("roles.created_at ASC").Offset(int(offset)).Limit(count).Find(&roles).Error; err != nil {
+			return nil, err
+		}
+		for i := range roles {
+			group, err := s.roleToScim(&roles[i])
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, group)
+		}
+		offset = 0
+	} else {
+		offset -= roleTotal
+	}
+
+	if remaining := count - len(resources); remaining > 0 {
+		var orgs []models.Organization
+		if err := orgQuery.Order("organizations.created_at ASC").Offset(int(offset)).Limit(remaining).Find(&orgs).Error; err != nil {
+			return nil, err
+		}
+		for i := range orgs {
+			group, err := s.organizationToScim(&orgs[i])
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, group)
+		}
+	}
+
+	return &models.ScimListResponse{
+		Schemas:      []string{models.ScimSchemaListResponse},
+		TotalResults: roleTotal + orgTotal,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}, nil
+}
+
+// GetGroup renvoie un rôle ou une organisation au format SCIM
+func (s *ScimService) GetGroup(id string) (*models.ScimGroup, error) {
+	role, org, err := s.findGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	if role != nil {
+		return s.roleToScim(role)
+	}
+	return s.organizationToScim(org)
+}
+
+// CreateGroup crée un rôle (par défaut) ou une organisation à partir d'un groupe SCIM
+func (s *ScimService) CreateGroup(resource *models.ScimGroup) (*models.ScimGroup, error) {
+	if strings.TrimSpace(resource.DisplayName) == "" {
+		return nil, newScimError(http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+
+	memberIDs, err := s.resolveMembers(resource.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	if scimGroupType(resource) == models.ScimGroupTypeOrganization {
+		if len(memberIDs) == 0 {
+			return nil, newScimError(http.StatusBadRequest, "invalidValue", "organization groups require at least one member to own them")
+		}
+		org := &models.Organization{
+			Name:       resource.DisplayName,
+			Slug:       scimSlug(resource.DisplayName),
+			IsActive:   true,
+			OwnerID:    memberIDs[0],
+			ExternalID: optionalString(resource.ExternalID),
+		}
+		var count int64
+		s.DB.Model(&models.Organization{}).Where("slug = ?", org.Slug).Count(&count)
+		if count > 0 {
+			return nil, newScimError(http.StatusConflict, "uniqueness", "a group with this displayName already exists")
+		}
+		err := s.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Omit("Owner", "Domains", "Memberships").Create(org).Error; err != nil {
+				return err
+			}
+			return s.syncOrganizationMembers(tx, org.ID, memberIDs)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return s.GetGroup(org.ID)
+	}
+
+	var count int64
+	s.DB.Model(&models.Role{}).Where("LOWER(name) = LOWER(?)", resource.DisplayName).Count(&count)
+	if count > 0 {
+		return nil, newScimError(http.StatusConflict, "uniqueness", "a group with this displayName already exists")
+	}
+	role := &models.Role{
+		Name:       resource.DisplayName,
+		ExternalID: optionalString(resource.ExternalID),
+	}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(role).Error; err != nil {
+			return err
+		}
+		return s.syncRoleMembers(tx, role, memberIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetGroup(role.ID)
+}
+
+// ReplaceGroup remplace un groupe (PUT), y compris la liste complète de ses membres
+func (s *ScimService) ReplaceGroup(id string, resource *models.ScimGroup, version string) (*models.ScimGroup, error) {
+	role, org, err := s.findGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(resource.DisplayName) == "" {
+		return nil, newScimError(http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+
+	memberIDs, err := s.resolveMembers(resource.Members)
+	if err != nil {
+		return nil, err
+	}
+
+	if role != nil {
+		if err := checkScimVersion(version, role.ID, role.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if role.IsSystem && role.Name != resource.DisplayName {
+			return nil, newScimError(http.StatusBadRequest, "mutability", "system roles cannot be renamed")
+		}
+		role.Name = resource.DisplayName
+		role.ExternalID = optionalString(resource.ExternalID)
+		err = s.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(role).Error; err != nil {
+				return err
+			}
+			return s.syncRoleMembers(tx, role, memberIDs)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return s.GetGroup(role.ID)
+	}
+
+	if err := checkScimVersion(version, org.ID, org.UpdatedAt); err != nil {
+		return nil, err
+	}
+	org.Name = resource.DisplayName
+	org.ExternalID = optionalString(resource.ExternalID)
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Owner", "Domains", "Memberships").Save(org).Error; err != nil {
+			return err
+		}
+		return s.syncOrganizationMembers(tx, org.ID, memberIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetGroup(org.ID)
+}
+
+// PatchGroup applique des opérations PATCH SCIM à un groupe
+func (s *ScimService) PatchGroup(id string, patch *models.ScimPatchRequest, version string) (*models.ScimGroup, error) {
+	current, err := s.GetGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != "" && current.Meta != nil && version != current.Meta.Version {
+		return nil, newScimError(http.StatusPreconditionFailed, "", "resource version mismatch")
+	}
+
+	var patched models.ScimGroup
+	if err := patchScimResource(current, patch, &patched); err != nil {
+		return nil, err
+	}
+	return s.ReplaceGroup(id, &patched, "")
+}
+
+// DeleteGroup supprime un rôle ou une organisation
+func (s *ScimService) DeleteGroup(id string, version string) error {
+	role, org, err := s.findGroup(id)
+	if err != nil {
+		return err
+	}
+	if role != nil {
+		if err := checkScimVersion(version, role.ID, role.UpdatedAt); err != nil {
+			return err
+		}
+		if role.IsSystem {
+			return newScimError(http.StatusBadRequest, "mutability", "system roles cannot be deleted")
+		}
+		return NewPermissionService(s.DB).DeleteRole(role.ID)
+	}
+	if err := checkScimVersion(version, org.ID, org.UpdatedAt); err != nil {
+		return err
+	}
+	return NewOrganizationService(s.DB).DeleteOrganization(org.ID)
+}
+
+func (s *ScimService) findGroup(id string) (*models.Role, *models.Organization, error) {
+	var role models.Role
+	err := s.DB.First(&role, "id = ?", id).Error
+	if err == nil {
+		return &role, nil, nil
+	}
+	if isInvalidUUIDError(err) {
+		return nil, nil, newScimError(http.StatusNotFound, "", "Group "+id+" not found")
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+
+	var org models.Organization
+	if err := s.DB.First(&org, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, newScimError(http.StatusNotFound, "", "Group "+id+" not found")
+		}
+		return nil, nil, err
+	}
+	return nil, &org, nil
+}
+
+// resolveMembers vérifie que les membres référencés sont des utilisateurs existants
+func (s *ScimService) resolveMembers(members []models.ScimMultiValue) ([]string, error) {
+	ids := make([]string, 0, len(members))
+	seen := make(map[string]bool)
+	for _, member := range members {
+		if member.Value == "" || seen[member.Value] {
+			continue
+		}
+		seen[member.Value] = true
+		ids = append(ids, member.Value)
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	var count int64
+	if err := s.DB.Model(&models.User{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+		if isInvalidUUIDError(err) {
+			return nil, newScimError(http.StatusBadRequest, "invalidValue", "members must reference existing users")
+		}
+		return nil, err
+	}
+	if int(count) != len(ids) {
+		return nil, newScimError(http.StatusBadRequest, "invalidValue", "members must reference existing users")
+	}
+	return ids, nil
+}
+
+// syncRoleMembers aligne les UserRole d'un rôle sur la liste de membres fournie.
+// Les membres d'un rôle système (admin, etc.) ne sont pas gérés par SCIM : seul un envoi inchangé est accepté.
+func (s *ScimService) syncRoleMembers(tx *gorm.DB, role *models.Role, memberIDs []string) error {
+	var existing []models.UserRole
+	if err := tx.Where("role_id = ? AND organization_id IS NULL", role.ID).Find(&existing).Error; err != nil {
+		return err
+	}
+
+	wanted := make(map[string]bool, len(memberIDs))
+	for _, id := range memberIDs {
+		wanted[id] = true
+	}
+	var removed []string
+	for _, userRole := range existing {
+		if wanted[userRole.UserID] {
+			delete(wanted, userRole.UserID)
+			continue
+		}
+		removed = append(removed, userRole.ID)
+	}
+	if role.IsSystem && (len(removed) > 0 || len(wanted) > 0) {
+		return newScimError(http.StatusForbidden, "", "membership of system roles cannot be changed through SCIM")
+	}
+
+	changed := false
+	for _, id := range removed {
+		if err := tx.Delete(&models.UserRole{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		changed = true
+	}
+	for _, id := range memberIDs {
+		if !wanted[id] {
+			continue
+		}
+		userRole := &models.UserRole{UserID: id, RoleID: role.ID, AssignedAt: time.Now()}
+		if err := tx.Omit("User", "Role").Create(userRole).Error; err != nil {
+			return err
+		}
+		changed = true
+	}
+
+	// Les changements de membres modifient la version du groupe
+	if changed {
+		InvalidatePermissionCache()
+		return tx.Model(&models.Role{}).Where("id = ?", role.ID).Update("updated_at", time.Now()).Error
+	}
+	return nil
+}
+
+// syncOrganizationMembers aligne les Membership d'une organisation sur la liste de membres fournie
+func (s *ScimService) syncOrganizationMembers(tx *gorm.DB, orgID string, memberIDs []string) error {
+	var existing []models.Membership
+	if err := tx.Where("organization_id = ?", orgID).Find(&existing).Error; err != nil {
+		return err
+	}
+
+	wanted := make(map[string]bool, len(memberIDs))
+	for _, id := range memberIDs {
+		wanted[id] = true
+	}
+	changed := false
+	for _, membership := range existing {
+		if wanted[membership.UserID] {
+			delete(wanted, membership.UserID)
+			continue
+		}
+		if err := tx.Delete(&models.Membership{}, "id = ?", membership.ID).Error; err != nil {
+			return err
+		}
+		changed = true
+	}
+	for _, id := range memberIDs {
+		if !wanted[id] {
+			continue
+		}
+		membership := &models.Membership{UserID: id, OrganizationID: orgID, Status: "active", JoinedAt: time.Now()}
+		if err := tx.Omit("User", "Organization", "Role").Create(membership).Error; err != nil {
+			return err
+		}
+		changed = true
+	}
+
+	if changed {
+		InvalidatePermissionCache()
+		return tx.Model(&models.Organization{}).Where("id = ?", orgID).Update("updated_at", time.Now()).Error
+	}
+	return nil
+}
+
+func (s *ScimService) roleToScim(role *models.Role) (*models.ScimGroup, error) {
+	var users []models.User
+	if err := s.DB.Joins("JOIN user_roles ON user_roles.user_id = users.id").
+		Where("user_roles.role_id = ? AND user_roles.organization_id IS NULL", role.ID).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	group := &models.ScimGroup{
+		Schemas:     []string{models.ScimSchemaGroup, models.ScimSchemaAetherGroup},
+		ID:          role.ID,
+		DisplayName: role.Name,
+		Members:     s.usersToMembers(users),
+		Extension:   &models.ScimGroupExtension{Type: models.ScimGroupTypeRole},
+		Meta:        s.groupMeta(role.ID, role.CreatedAt, role.UpdatedAt),
+	}
+	if role.ExternalID != nil {
+		group.ExternalID = *role.ExternalID
+	}
+	return group, nil
+}
+
+func (s *ScimService) organizationToScim(org *models.Organization) (*models.ScimGroup, error) {
+	var users []models.User
+	if err := s.DB.Joins("JOIN memberships ON memberships.user_id = users.id").
+		Where("memberships.organization_id = ? AND memberships.deleted_at IS NULL", org.ID).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	group := &models.ScimGroup{
+		Schemas:     []string{models.ScimSchemaGroup, models.ScimSchemaAetherGroup},
+		ID:          org.ID,
+		DisplayName: org.Name,
+		Members:     s.usersToMembers(users),
+		Extension:   &models.ScimGroupExtension{Type: models.ScimGroupTypeOrganization},
+		Meta:        s.groupMeta(org.ID, org.CreatedAt, org.UpdatedAt),
+	}
+	if org.ExternalID != nil {
+		group.ExternalID = *org.ExternalID
+	}
+	return group, nil
+}
+
+func (s *ScimService) usersToMembers(users []models.User) []models.ScimMultiValue {
+	members := make([]models.ScimMultiValue, 0, len(users))
+	for _, user := range users {
+		member := models.ScimMultiValue{Value: user.ID, Type: "User", Ref: s.BaseURL + "/Users/" + user.ID}
+		switch {
+		case user.Name != nil:
+			member.Display = *user.Name
+		case user.Email != nil:
+			member.Display = *user.Email
+		}
+		members = append(members, member)
+	}
+	return members
+}
+
+func (s *ScimService) groupMeta(id string, created, updated time.Time) *models.ScimMeta {
+	return &models.ScimMeta{
+		ResourceType: "Group",
+		Created:      &created,
+		LastModified: &updated,
+		Location:     s.BaseURL + "/Groups/" + id,
+		Version:      ScimETag(id, updated),
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Utilitaires
+// ---------------------------------------------------------------------------
+
+// patchScimResource applique un PatchOp à une ressource et décode le résultat dans target
+func patchScimResource(current interface{}, patch *models.ScimPatchRequest, target interface{}) error {
+	if len(patch.Operations) == 0 {
+		return newScimError(http.StatusBadRequest, "invalidSyntax", "Operations are required")
+	}
+
+	raw, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	var resource map[string]interface{}
+	if err := json.Unmarshal(raw, &resource); err != nil {
+		return err
+	}
+
+	if err := applyScimPatch(resource, patch.Operations); err != nil {
+		return err
+	}
+
+	// Certains clients (Azure AD) envoient les booléens sous forme de chaînes
+	if key, ok := findScimKey(resource, "active"); ok {
+		if str, isString := resource[key].(string); isString {
+			resource[key] = strings.EqualFold(str, "true")
+		}
+	}
+
+	raw, err = json.Marshal(resource)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, target); err != nil {
+		return newScimError(http.StatusBadRequest, "invalidValue", err.Error())
+	}
+	return nil
+}
+
+func checkScimVersion(version, id string, updatedAt time.Time) error {
+	if version == "" || version == "*" {
+		return nil
+	}
+	if version != ScimETag(id, updatedAt) {
+		return newScimError(http.StatusPreconditionFailed, "", "resource version mismatch")
+	}
+	return nil
+}
+
+func normalizeScimPage(startIndex, count int) (int, int) {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 {
+		count = 0
+	}
+	if count > ScimMaxCount {
+		count = ScimMaxCount
+	}
+	return startIndex, count
+}
+
+func scimPrimaryValue(values []models.ScimMultiValue) string {
+	for _, value := range values {
+		if value.Primary {
+			return value.Value
+		}
+	}
+	if len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
+
+func scimGroupType(resource *models.ScimGroup) string {
+	if resource.Extension != nil && strings.EqualFold(resource.Extension.Type, models.ScimGroupTypeOrganization) {
+		return models.ScimGroupTypeOrganization
+	}
+	return models.ScimGroupTypeRole
+}
+
+func scimSlug(name string) string {
+	return strings.Trim(slugInvalidChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// isInvalidUUIDError détecte les erreurs Postgres de syntaxe UUID (identifiant mal formé)
+func isInvalidUUIDError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "invalid input syntax for type uuid")
+}
@@ -13,6 +13,28 @@ func NewTenantService(db *gorm.DB) *TenantService {
 	return &TenantService{DB: db}
 }
 
+// ResolveTenantID renvoie le tenant d'une authentification : celui de l'application ou du client OAuth
+// clientID, à défaut celui de l'utilisateur. Une chaîne vide désigne l'instance.
+func ResolveTenantID(db *gorm.DB, clientID, userID string) string {
+	if db == nil {
+		return ""
+	}
+	var tenantIDs []string
+	if clientID != "" {
+		db.Model(&models.Application{}).Where("client_id = ? AND tenant_id IS NOT NULL", clientID).Limit(1).Pluck("tenant_id", &tenantIDs)
+		if len(tenantIDs) == 0 {
+			db.Model(&models.OAuthClient{}).Where("client_id = ? AND tenant_id IS NOT NULL", clientID).Limit(1).Pluck("tenant_id", &tenantIDs)
+		}
+	}
+	if len(tenantIDs) == 0 && userID != "" {
+		db.Model(&models.User{}).Where("id = ? AND tenant_id IS NOT NULL", userID).Limit(1).Pluck("tenant_id", &tenantIDs)
+	}
+	if len(tenantIDs) == 0 {
+		return ""
+	}
+	return tenantIDs[0]
+}
+
 func (s *TenantService) CreateTenant(tenant *models.Tenant) error {
 	return s.DB.Create(tenant).Error
 }
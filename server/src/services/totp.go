@@ -50,6 +50,7 @@ func (s *TOTPService) GenerateQRCode(url string) (barcode.Barcode, error) {
 // VerifyTOTPCode verifies a TOTP code against a secret
 func (s *TOTPService) VerifyTOTPCode(code, secret string) (bool, error) {
 	valid := totp.Validate(code, secret)
+	recordTOTPChallenge(valid)
 	return valid, nil
 }
 
@@ -64,6 +65,7 @@ func (s *TOTPService) EnableTOTP(userID string, secret string) error {
 	if err := s.userService.UpdateUser(user, nil); err != nil {
 		return fmt.Errorf("failed to enable TOTP: %w", err)
 	}
+	NotifyUserWebhooks(s.userService.DB, models.WebhookEventMfaEnrolled, user.ID, map[string]interface{}{"method": "totp"})
 	return nil
 }
 
@@ -103,8 +105,19 @@ func (s *TOTPService) VerifyTOTPLogin(email, password, totpCode string) (*models
 		return nil, fmt.Errorf("TOTP not enabled for this user")
 	}
 	// Validate the TOTP code
-	if user.TotpSecret == nil || !totp.Validate(totpCode, *user.TotpSecret) {
+	valid := user.TotpSecret != nil && totp.Validate(totpCode, *user.TotpSecret)
+	recordTOTPChallenge(valid)
+	if !valid {
 		return nil, fmt.Errorf("invalid TOTP code")
 	}
 	return user, nil
 }
+
+// recordTOTPChallenge counts the outcome of a TOTP code check
+func recordTOTPChallenge(valid bool) {
+	if valid {
+		RecordMfaChallenge(string(models.MfaMethodTypeTotp), MfaSuccess)
+	} else {
+		RecordMfaChallenge(string(models.MfaMethodTypeTotp), MfaFailure)
+	}
+}
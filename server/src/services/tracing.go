@@ -0,0 +1,443 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	mrand "math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TraceparentHeader propage le contexte de trace (W3C Trace Context)
+const TraceparentHeader = "traceparent"
+
+// Type d'un span, selon l'énumération OTLP
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+const (
+	traceQueueSize     = 2048
+	traceBatchSize     = 512
+	traceFlushInterval = 5 * time.Second
+	traceExportTimeout = 10 * time.Second
+	traceScopeName     = "github.com/skygenesisenterprise/aether-identity/server"
+	gormSpanKey        = "tracing:span"
+)
+
+// TracingConfig configure l'export des traces en OTLP/HTTP (encodage JSON)
+type TracingConfig struct {
+	Endpoint    string            // URL complète du collecteur, par exemple http://otel-collector:4318/v1/traces
+	Headers     map[string]string // En-têtes ajoutés à chaque export (authentification du collecteur)
+	ServiceName string
+	SampleRatio float64 // Part des traces démarrées ici qui sont enregistrées ; une trace entrante garde sa décision
+}
+
+// Span est une opération chronométrée d'une trace. Un span nil (traçage désactivé) accepte tous les appels.
+type Span struct {
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	sampled  bool
+	remote   bool // Parent reçu dans traceparent, jamais exporté
+
+	name  string
+	kind  SpanKind
+	start time.Time
+
+	mu         sync.Mutex
+	end        time.Time
+	attributes map[string]interface{}
+	errMessage string
+}
+
+type spanContextKey struct{}
+
+type tracer struct {
+	config TracingConfig
+	queue  chan *Span
+	client *http.Client
+}
+
+var activeTracer atomic.Pointer[tracer]
+
+// StartTracing active le traçage et exporte les spans par lots jusqu'à l'annulation de ctx.
+// Sans Endpoint, le traçage reste désactivé et StartSpan ne coûte presque rien.
+func StartTracing(ctx context.Context, config TracingConfig) bool {
+	if config.Endpoint == "" {
+		return false
+	}
+	if config.ServiceName == "" {
+		config.ServiceName = logSinkSource
+	}
+	t := &tracer{
+		config: config,
+		queue:  make(chan *Span, traceQueueSize),
+		client: &http.Client{Timeout: traceExportTimeout},
+	}
+	activeTracer.Store(t)
+
+	go func() {
+		ticker := time.NewTicker(traceFlushInterval)
+		defer ticker.Stop()
+
+		batch := make([]*Span, 0, traceBatchSize)
+		flush := func() {
+			if len(batch) == 0 {
+				return
+			}
+			if err := t.export(batch); err != nil {
+				fmt.Printf("Tracing: failed to export %d spans: %v\n", len(batch), err)
+			}
+			batch = batch[:0]
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				// Exporter les spans encore en file avant de s'arrêter
+				activeTracer.CompareAndSwap(t, nil)
+				for {
+					select {
+					case span := <-t.queue:
+						batch = append(batch, span)
+						if len(batch) >= traceBatchSize {
+							flush()
+						}
+					default:
+						flush()
+						return
+					}
+				}
+			case span := <-t.queue:
+				batch = append(batch, span)
+				if len(batch) >= traceBatchSize {
+					flush()
+				}
+			case <-ticker.C:
+				flush()
+			}
+		}
+	}()
+	return true
+}
+
+// ParseTracingHeaders lit des en-têtes au format de OTEL_EXPORTER_OTLP_HEADERS : "clé=valeur,clé2=valeur2"
+func ParseTracingHeaders(value string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		name, val, ok := strings.Cut(pair, "=")
+		if ok && strings.TrimSpace(name) != "" {
+			headers[strings.TrimSpace(name)] = strings.TrimSpace(val)
+		}
+	}
+	return headers
+}
+
+// StartSpan démarre un span enfant du span porté par ctx, ou une nouvelle trace.
+// Il renvoie nil lorsque le traçage est désactivé.
+func StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	t := activeTracer.Load()
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{name: name, kind: kind, start: time.Now()}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.traceID, span.parentID, span.sampled = parent.traceID, parent.spanID, parent.sampled
+	} else {
+		_, _ = rand.Read(span.traceID[:])
+		span.sampled = t.config.SampleRatio >= 1 || mrand.Float64() < t.config.SampleRatio
+	}
+	_, _ = rand.Read(span.spanID[:])
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// SpanFromContext renvoie le span courant, éventuellement le parent distant lu dans traceparent
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// ContextWithTraceparent rattache ctx au contexte de trace d'un appelant ; un en-tête invalide est ignoré
+func ContextWithTraceparent(ctx context.Context, header string) context.Context {
+	// version-traceid-parentid-flags, par exemple 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return ctx
+	}
+	remote := &Span{remote: true}
+	var flags [1]byte
+	if _, err := hex.Decode(remote.traceID[:], []byte(parts[1])); err != nil {
+		return ctx
+	}
+	if _, err := hex.Decode(remote.spanID[:], []byte(parts[2])); err != nil {
+		return ctx
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return ctx
+	}
+	if remote.traceID == [16]byte{} || remote.spanID == [8]byte{} {
+		return ctx
+	}
+	remote.sampled = flags[0]&1 == 1
+	return context.WithValue(ctx, spanContextKey{}, remote)
+}
+
+// Traceparent renvoie l'en-tête à transmettre aux services appelés
+func (s *Span) Traceparent() string {
+	if s == nil {
+		return ""
+	}
+	flags := "00"
+	if s.sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(s.traceID[:]) + "-" + hex.EncodeToString(s.spanID[:]) + "-" + flags
+}
+
+// TraceID renvoie l'identifiant de trace en hexadécimal, vide sans span
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+// SetAttribute ajoute un attribut (chaîne, booléen, entier ou flottant) au span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil || !s.sampled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attributes == nil {
+		s.attributes = make(map[string]interface{})
+	}
+	s.attributes[key] = value
+}
+
+// RecordError marque le span en erreur
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil || !s.sampled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errMessage = truncateString(err.Error(), 500)
+}
+
+// End termine le span et le confie à l'exportateur ; si la file est pleine, le span est perdu
+func (s *Span) End() {
+	if s == nil || s.remote || !s.sampled {
+		return
+	}
+	s.mu.Lock()
+	if !s.end.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	if t := activeTracer.Load(); t != nil {
+		select {
+		case t.queue <- s:
+		default:
+		}
+	}
+}
+
+// export envoie un lot au collecteur au format OTLP/JSON (ExportTraceServiceRequest)
+func (t *tracer) export(batch []*Span) error {
+	spans := make([]map[string]interface{}, 0, len(batch))
+	for _, s := range batch {
+		s.mu.Lock()
+		span := map[string]interface{}{
+			"traceId":           hex.EncodeToString(s.traceID[:]),
+			"spanId":            hex.EncodeToString(s.spanID[:]),
+			"name":              s.name,
+			"kind":              int(s.kind),
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+			"attributes":        otlpAttributes(s.attributes),
+		}
+		if s.parentID != [8]byte{} {
+			span["parentSpanId"] = hex.EncodeToString(s.parentID[:])
+		}
+		if s.errMessage != "" {
+			span["status"] = map[string]interface{}{"code": 2, "message": s.errMessage}
+		}
+		s.mu.Unlock()
+		spans = append(spans, span)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": otlpAttributes(map[string]interface{}{"service.name": t.config.ServiceName}),
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]interface{}{"name": traceScopeName},
+				"spans": spans,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range t.config.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		response, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector responded with HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(response)))
+	}
+	return nil
+}
+
+// otlpAttributes convertit des attributs en KeyValue OTLP
+func otlpAttributes(attributes map[string]interface{}) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(attributes))
+	for key, value := range attributes {
+		var typed map[string]interface{}
+		switch v := value.(type) {
+		case bool:
+			typed = map[string]interface{}{"boolValue": v}
+		case int:
+			typed = map[string]interface{}{"intValue": strconv.Itoa(v)}
+		case int64:
+			typed = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			typed = map[string]interface{}{"doubleValue": v}
+		default:
+			typed = map[string]interface{}{"stringValue": fmt.Sprint(v)}
+		}
+		result = append(result, map[string]interface{}{"key": key, "value": typed})
+	}
+	return result
+}
+
+// tracingTransport trace les requêtes sortantes et propage traceparent
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+// NewTracedHTTPClient crée un client HTTP dont chaque requête produit un span client,
+// enfant du span porté par le contexte de la requête
+func NewTracedHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: &tracingTransport{base: http.DefaultTransport}}
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := StartSpan(req.Context(), req.Method+" "+req.URL.Host, SpanKindClient)
+	if span == nil {
+		return t.base.RoundTrip(req)
+	}
+	defer span.End()
+
+	req = req.Clone(ctx)
+	req.Header.Set(TraceparentHeader, span.Traceparent())
+	span.SetAttribute("http.request.method", req.Method)
+	span.SetAttribute("server.address", req.URL.Hostname())
+	span.SetAttribute("url.full", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttribute("http.response.status_code", resp.StatusCode)
+	if resp.StatusCode >= 500 {
+		span.RecordError(fmt.Errorf("HTTP %d", resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// registerTracingCallbacks trace les requêtes GORM exécutées avec un contexte tracé (db.WithContext).
+// Les requêtes sans span parent, comme celles des workers, ne produisent pas de trace.
+func registerTracingCallbacks(db *gorm.DB) error {
+	before := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			if SpanFromContext(tx.Statement.Context) == nil {
+				return
+			}
+			_, span := StartSpan(tx.Statement.Context, "db."+operation, SpanKindClient)
+			tx.InstanceSet(gormSpanKey, span)
+		}
+	}
+	after := func(tx *gorm.DB) {
+		value, ok := tx.InstanceGet(gormSpanKey)
+		if !ok {
+			return
+		}
+		span, _ := value.(*Span)
+		span.SetAttribute("db.system", "postgresql")
+		span.SetAttribute("db.collection.name", tx.Statement.Table)
+		span.SetAttribute("db.query.text", truncateString(tx.Statement.SQL.String(), 2000))
+		span.SetAttribute("db.response.rows_affected", tx.Statement.RowsAffected)
+		if tx.Error != nil && tx.Error != gorm.ErrRecordNotFound {
+			span.RecordError(tx.Error)
+		}
+		span.End()
+	}
+
+	callbacks := db.Callback()
+	for _, register := range []struct {
+		operation string
+		before    func(string, func(*gorm.DB)) error
+		after     func(string, func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		{"query", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		{"update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		{"delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		{"row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		{"raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	} {
+		if err := register.before("tracing:before_"+register.operation, before(register.operation)); err != nil {
+			return err
+		}
+		if err := register.after("tracing:after_"+register.operation, after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RequestDB renvoie la base rattachée au contexte d'une requête, pour que ses requêtes SQL
+// apparaissent dans la trace ; nil en mode sans base de données
+func RequestDB(ctx context.Context) *gorm.DB {
+	if DB == nil {
+		return nil
+	}
+	return DB.WithContext(ctx)
+}
@@ -2,13 +2,20 @@ package services
 
 import (
 	"errors"
-	"strings"
+	"time"
 
 	"github.com/skygenesisenterprise/aether-identity/server/src/models"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrNoPasswordSet est renvoyée quand le compte local n'a pas de mot de passe
+var ErrNoPasswordSet = errors.New("no password set")
+
+// ErrAccountLocked est renvoyée tant que le verrouillage consécutif aux échecs de connexion est actif
+var ErrAccountLocked = errors.New("account temporarily locked")
+
 // UserService gère les opérations liées aux utilisateurs
 type UserService struct {
 	DB *gorm.DB
@@ -70,6 +77,16 @@ func (s *UserService) GetUserByEmail(email string) (*models.User, error) {
 func (s *UserService) UpdateUser(user *models.User, newPassword *string) error {
 	// Si le mot de passe est fourni, le hacher
 	if newPassword != nil && *newPassword != "" {
+		// Les utilisateurs d'une base externe non importée gardent leur mot de passe dans cette base
+		delegated, err := NewCustomDatabaseService(s.DB).ChangePassword(user, *newPassword)
+		if err != nil {
+			return err
+		}
+		if delegated {
+			user.PasswordHash = nil
+			return s.DB.Save(user).Error
+		}
+
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*newPassword), bcrypt.DefaultCost)
 		if err != nil {
 			return err
@@ -93,24 +110,72 @@ func (s *UserService) AuthenticateUser(email, password string) (*models.User, er
 		return nil, err
 	}
 
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		return nil, ErrAccountLocked
+	}
+
 	// Vérifier le mot de passe
 	if user.PasswordHash == nil {
-		return nil, errors.New("no password set")
+		return nil, ErrNoPasswordSet
 	}
 
-	hashToCheck := *user.PasswordHash
-	// Convertir $2b$ en $2a$ pour la compatibilité avec les hashes générés par Node.js
-	if strings.HasPrefix(hashToCheck, "$2b$") {
-		hashToCheck = "$2a$" + hashToCheck[4:]
+	// Les hashes importés depuis une base externe (scrypt, PBKDF2, argon2) sont aussi acceptés
+	ok, err := VerifyPasswordHash(*user.PasswordHash, password)
+	if err != nil || !ok {
+		s.recordFailedLogin(user)
+		return nil, errors.New("invalid password")
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(hashToCheck), []byte(password)); err != nil {
-		return nil, errors.New("invalid password")
+	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		s.DB.Model(user).UpdateColumns(map[string]interface{}{"failed_login_attempts": 0, "locked_until": nil})
+		user.FailedLoginAttempts = 0
+		user.LockedUntil = nil
+	}
+
+	// Convertir un hash hérité en bcrypt après une connexion réussie
+	if !IsBcryptHash(*user.PasswordHash) {
+		if hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost); err == nil {
+			hashStr := string(hashedPassword)
+			if err := s.DB.Model(user).Update("password_hash", hashStr).Error; err == nil {
+				user.PasswordHash = &hashStr
+			}
+		}
 	}
 
 	return user, nil
 }
 
+// recordFailedLogin compte un échec de connexion et verrouille le compte lorsque le seuil de
+// BruteForceConfig est atteint. Sans configuration enregistrée, aucun verrouillage n'est appliqué.
+// Le compteur est incrémenté en base et le verrouillage conditionné à sa valeur, pour que des tentatives
+// concurrentes ne perdent pas d'échec ni ne verrouillent deux fois.
+func (s *UserService) recordFailedLogin(user *models.User) {
+	config, err := NewSecurityService(s.DB).GetBruteForceConfig()
+	if err != nil || config.MaxAttempts <= 0 {
+		return
+	}
+
+	err = s.DB.Model(user).Clauses(clause.Returning{Columns: []clause.Column{{Name: "failed_login_attempts"}}}).
+		UpdateColumn("failed_login_attempts", gorm.Expr("failed_login_attempts + 1")).Error
+	if err != nil || user.FailedLoginAttempts < config.MaxAttempts {
+		return
+	}
+
+	lockedUntil := time.Now().Add(time.Duration(config.LockoutDuration) * time.Second)
+	result := s.DB.Model(&models.User{}).
+		Where("id = ? AND failed_login_attempts >= ?", user.ID, config.MaxAttempts).
+		UpdateColumns(map[string]interface{}{"failed_login_attempts": 0, "locked_until": lockedUntil})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+	user.FailedLoginAttempts = 0
+	user.LockedUntil = &lockedUntil
+	NotifyUserWebhooks(s.DB, models.WebhookEventUserLockedOut, user.ID, map[string]interface{}{
+		"locked_until": lockedUntil.UTC(),
+		"max_attempts": config.MaxAttempts,
+	})
+}
+
 // UserToResponse convertit un modèle User en une réponse appropriée
 func (s *UserService) UserToResponse(user *models.User) map[string]interface{} {
 	return map[string]interface{}{
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"gorm.io/gorm"
+)
+
+// userTenantConstraint est la clé étrangère de users.tenant_id vers tenants.id
+const userTenantConstraint = "fk_users_tenant"
+
+// MigrateUserTenant ajoute la colonne users.tenant_id (tenant de rattachement d'un utilisateur, nul pour
+// un utilisateur de l'instance), son index et sa clé étrangère vers tenants. La suppression d'un tenant
+// rattache ses utilisateurs à l'instance. La migration est idempotente et ne renseigne aucun tenant :
+// les utilisateurs existants restent des utilisateurs de l'instance.
+func MigrateUserTenant(db *gorm.DB) error {
+	migrator := db.Migrator()
+
+	if !migrator.HasColumn(&models.User{}, "TenantID") {
+		if err := migrator.AddColumn(&models.User{}, "TenantID"); err != nil {
+			return fmt.Errorf("failed to add users.tenant_id: %w", err)
+		}
+	}
+	if !migrator.HasIndex(&models.User{}, "TenantID") {
+		if err := migrator.CreateIndex(&models.User{}, "TenantID"); err != nil {
+			return fmt.Errorf("failed to index users.tenant_id: %w", err)
+		}
+	}
+
+	// Sans table tenants, aucun utilisateur ne peut encore y être rattaché
+	if !migrator.HasTable(&models.Tenant{}) || migrator.HasConstraint(&models.User{}, userTenantConstraint) {
+		return nil
+	}
+	if err := db.Exec(fmt.Sprintf(
+		"ALTER TABLE users ADD CONSTRAINT %s FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE SET NULL",
+		userTenantConstraint,
+	)).Error; err != nil {
+		return fmt.Errorf("failed to add %s: %w", userTenantConstraint, err)
+	}
+	return nil
+}
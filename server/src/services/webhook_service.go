@@ -0,0 +1,610 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/skygenesisenterprise/aether-identity/server/src/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookTargetForbidden  = errors.New("webhook target must be a public address")
+)
+
+// En-têtes des livraisons, en plus de TimestampHeader et SignatureHeader
+const (
+	WebhookEventHeader    = "X-Aether-Event"
+	WebhookDeliveryHeader = "X-Aether-Delivery"
+)
+
+const (
+	webhookBatchSize      = 50
+	webhookBaseBackoff    = 30 * time.Second
+	webhookMaxBackoff     = 6 * time.Hour
+	webhookStaleAfter     = 5 * time.Minute
+	webhookTimeout        = 10 * time.Second
+	webhookResponseLimit  = 1024
+	webhookSecretPrefix   = "whsec_"
+	WebhookSecretGrace    = 24 * time.Hour // Validité par défaut de l'ancien secret après une rotation
+	webhookEventSource    = "identity"
+	webhookDefaultRetries = 8
+)
+
+// webhookWake réveille le worker dès qu'une livraison est planifiée, sans attendre le prochain tick
+var webhookWake = make(chan struct{}, 1)
+
+// webhookClient ne suit pas les redirections : l'endpoint configuré doit répondre lui-même.
+// Il n'utilise pas de proxy et refuse, après résolution DNS, toute connexion vers une adresse non publique.
+var webhookClient = &http.Client{
+	Timeout: webhookTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: webhookTimeout,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if addr, err := netip.ParseAddr(host); err != nil || !webhookAddressAllowed(addr) {
+					return fmt.Errorf("%w: %s", ErrWebhookTargetForbidden, host)
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: webhookTimeout,
+		MaxIdleConnsPerHost: 2,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// webhookReservedPrefixes complète les plages non publiques reconnues par netip (partage d'adresses
+// des opérateurs, réseau "this host", tests de performance)
+var webhookReservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+// webhookAddressAllowed indique si une adresse peut recevoir des livraisons : ni boucle locale, ni réseau
+// privé, ni lien local (métadonnées des clouds), ni adresse non spécifiée ou multicast
+func webhookAddressAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+		return false
+	}
+	return !slices.ContainsFunc(webhookReservedPrefixes, func(prefix netip.Prefix) bool {
+		return prefix.Contains(addr)
+	})
+}
+
+// WebhookService gère les abonnements webhook et la livraison des événements d'identité
+type WebhookService struct {
+	DB *gorm.DB
+}
+
+// NewWebhookService crée une nouvelle instance de WebhookService
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	return &WebhookService{DB: db}
+}
+
+// ListWebhooks liste les abonnements, éventuellement restreints à un tenant
+func (s *WebhookService) ListWebhooks(tenantID string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	query := s.DB.Order("created_at ASC")
+	if tenantID != "" {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+	if err := query.Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// GetWebhook récupère un abonnement
+func (s *WebhookService) GetWebhook(id string) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := s.DB.First(&webhook, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// CreateWebhook crée un abonnement et renvoie son secret de signature, qui ne sera plus affiché
+func (s *WebhookService) CreateWebhook(req *models.WebhookRequest) (*models.Webhook, string, error) {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	webhook := &models.Webhook{Enabled: true, MaxAttempts: webhookDefaultRetries, Secret: secret}
+	if err := s.applyWebhookRequest(webhook, req); err != nil {
+		return nil, "", err
+	}
+	if err := s.DB.Create(webhook).Error; err != nil {
+		return nil, "", err
+	}
+	return webhook, secret, nil
+}
+
+// UpdateWebhook modifie un abonnement
+func (s *WebhookService) UpdateWebhook(id string, req *models.WebhookRequest) (*models.Webhook, error) {
+	webhook, err := s.GetWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyWebhookRequest(webhook, req); err != nil {
+		return nil, err
+	}
+	if err := s.DB.Save(webhook).Error; err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (s *WebhookService) applyWebhookRequest(webhook *models.Webhook, req *models.WebhookRequest) error {
+	if req.TenantID != nil {
+		if *req.TenantID == "" {
+			webhook.TenantID = nil
+		} else {
+			if err := s.DB.Select("id").First(&models.Tenant{}, "id = ?", *req.TenantID).Error; err != nil {
+				return errors.New("tenant not found")
+			}
+			webhook.TenantID = req.TenantID
+		}
+	}
+	if req.Name != nil {
+		webhook.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.URL != nil {
+		webhook.URL = strings.TrimSpace(*req.URL)
+	}
+	if req.Events != nil {
+		webhook.Events = req.Events
+	}
+	if req.Enabled != nil {
+		webhook.Enabled = *req.Enabled
+	}
+	if req.MaxAttempts != nil {
+		webhook.MaxAttempts = *req.MaxAttempts
+	}
+	return validateWebhook(webhook)
+}
+
+// DeleteWebhook supprime un abonnement et son historique de livraisons
+func (s *WebhookService) DeleteWebhook(id string) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Webhook{}, "id = ?", id)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return ErrWebhookNotFound
+		}
+		return result.Error
+	})
+}
+
+// RotateSecret génère un nouveau secret. Pendant grace, les livraisons sont signées avec les deux secrets
+// pour laisser au destinataire le temps de basculer ; grace nul révoque immédiatement l'ancien.
+func (s *WebhookService) RotateSecret(id string, grace time.Duration) (*models.Webhook, string, error) {
+	webhook, err := s.GetWebhook(id)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	webhook.PreviousSecret, webhook.PreviousSecretExpiresAt = nil, nil
+	if grace > 0 {
+		previous := webhook.Secret
+		expiresAt := now.Add(grace)
+		webhook.PreviousSecret, webhook.PreviousSecretExpiresAt = &previous, &expiresAt
+	}
+	webhook.Secret = secret
+	webhook.SecretRotatedAt = &now
+	if err := s.DB.Save(webhook).Error; err != nil {
+		return nil, "", err
+	}
+	return webhook, secret, nil
+}
+
+// SendTest planifie l'envoi d'un événement webhook.test au seul abonnement donné
+func (s *WebhookService) SendTest(id string) (*models.WebhookDelivery, error) {
+	webhook, err := s.GetWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+	payload := models.WebhookPayload{
+		ID:        "test_" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		Type:      models.WebhookEventTest,
+		TenantID:  webhook.TenantID,
+		CreatedAt: time.Now().UTC(),
+		Data:      map[string]interface{}{"webhook_id": webhook.ID, "message": "Test event from Aether Identity"},
+	}
+	deliveries, err := s.enqueue([]models.Webhook{*webhook}, &payload, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &deliveries[0], nil
+}
+
+// ListDeliveries liste l'historique des livraisons d'un abonnement, des plus récentes aux plus anciennes
+func (s *WebhookService) ListDeliveries(webhookID, status string, page, limit int) ([]models.WebhookDelivery, int64, error) {
+	var deliveries []models.WebhookDelivery
+	var total int64
+
+	query := s.DB.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&total)
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// GetDelivery récupère une livraison d'un abonnement
+func (s *WebhookService) GetDelivery(webhookID, deliveryID string) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := s.DB.First(&delivery, "id = ? AND webhook_id = ?", deliveryID, webhookID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// Redeliver planifie une nouvelle livraison du même événement (même identifiant, nouvel horodatage de signature)
+func (s *WebhookService) Redeliver(webhookID, deliveryID string) (*models.WebhookDelivery, error) {
+	original, err := s.GetDelivery(webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	delivery := &models.WebhookDelivery{
+		WebhookID:     original.WebhookID,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: time.Now(),
+		RedeliveryOf:  &original.ID,
+	}
+	if err := s.DB.Create(delivery).Error; err != nil {
+		return nil, err
+	}
+	wakeWebhookWorker()
+	return delivery, nil
+}
+
+// Emit enregistre un événement d'identité et planifie sa livraison aux abonnements concernés
+func (s *WebhookService) Emit(eventType string, tenantID, userID *string, data interface{}) error {
+	source := webhookEventSource
+	event := &models.Event{Type: eventType, Source: &source, Subject: userID, Data: data, UserID: userID, TenantID: tenantID}
+	if err := NewEventService(s.DB).CreateEvent(event); err != nil {
+		return err
+	}
+
+	var webhooks []models.Webhook
+	query := s.DB.Where("enabled = ?", true)
+	if tenantID != nil {
+		query = query.Where("tenant_id IS NULL OR tenant_id = ?", *tenantID)
+	} else {
+		query = query.Where("tenant_id IS NULL")
+	}
+	if err := query.Find(&webhooks).Error; err != nil {
+		return err
+	}
+	webhooks = slices.DeleteFunc(webhooks, func(webhook models.Webhook) bool {
+		return !webhookSubscribed(&webhook, eventType)
+	})
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	payload := models.WebhookPayload{
+		ID:        event.ID,
+		Type:      eventType,
+		TenantID:  tenantID,
+		CreatedAt: event.CreatedAt.UTC(),
+		Data:      data,
+	}
+	_, err := s.enqueue(webhooks, &payload, nil)
+	return err
+}
+
+// enqueue crée une livraison en attente par abonnement et réveille le worker
+func (s *WebhookService) enqueue(webhooks []models.Webhook, payload *models.WebhookPayload, redeliveryOf *string) ([]models.WebhookDelivery, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	deliveries := make([]models.WebhookDelivery, len(webhooks))
+	for i := range webhooks {
+		deliveries[i] = models.WebhookDelivery{
+			WebhookID:     webhooks[i].ID,
+			EventID:       payload.ID,
+			EventType:     payload.Type,
+			Payload:       body,
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: now,
+			RedeliveryOf:  redeliveryOf,
+		}
+	}
+	if err := s.DB.Create(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	wakeWebhookWorker()
+	return deliveries, nil
+}
+
+// NotifyWebhooks émet un événement sans faire échouer l'opération qui l'a déclenché
+func NotifyWebhooks(db *gorm.DB, eventType string, tenantID, userID *string, data interface{}) {
+	if db == nil {
+		return
+	}
+	if err := NewWebhookService(db).Emit(eventType, tenantID, userID, data); err != nil {
+		fmt.Printf("Webhooks: failed to emit %s: %v\n", eventType, err)
+	}
+}
+
+// NotifyUserWebhooks émet un événement portant sur un utilisateur ; extra complète les données envoyées.
+// L'événement est rattaché au tenant de l'utilisateur et atteint donc les webhooks de ce tenant
+// comme les webhooks globaux. À appeler après la validation de la transaction qui a modifié l'utilisateur.
+func NotifyUserWebhooks(db *gorm.DB, eventType, userID string, extra map[string]interface{}) {
+	if db == nil || userID == "" {
+		return
+	}
+	data := map[string]interface{}{"user_id": userID}
+	var tenantID *string
+	var user models.User
+	if err := db.Unscoped().First(&user, "id = ?", userID).Error; err == nil {
+		data["user"] = NewUserService(db).UserToResponse(&user)
+		tenantID = user.TenantID
+	}
+	for key, value := range extra {
+		data[key] = value
+	}
+	NotifyWebhooks(db, eventType, tenantID, &userID, data)
+}
+
+// Start traite périodiquement les livraisons en attente, et immédiatement lorsqu'une livraison est planifiée
+func (s *WebhookService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-webhookWake:
+			}
+			if _, err := s.ProcessDueDeliveries(webhookBatchSize); err != nil {
+				fmt.Printf("Webhooks: failed to process deliveries: %v\n", err)
+			}
+		}
+	}()
+}
+
+func wakeWebhookWorker() {
+	select {
+	case webhookWake <- struct{}{}:
+	default:
+	}
+}
+
+// ProcessDueDeliveries envoie les livraisons arrivées à échéance et renvoie le nombre traité
+func (s *WebhookService) ProcessDueDeliveries(limit int) (int, error) {
+	// Libérer les livraisons restées bloquées après un arrêt brutal
+	s.DB.Model(&models.WebhookDelivery{}).
+		Where("status = ? AND updated_at < ?", models.WebhookDeliveryProcessing, time.Now().Add(-webhookStaleAfter)).
+		Update("status", models.WebhookDeliveryPending)
+
+	var deliveries []models.WebhookDelivery
+	if err := s.DB.Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, time.Now()).
+		Order("next_attempt_at ASC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		// Réserver la livraison pour éviter un double envoi entre instances
+		claim := s.DB.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ?", delivery.ID, models.WebhookDeliveryPending).
+			Updates(map[string]interface{}{"status": models.WebhookDeliveryProcessing, "updated_at": time.Now()})
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			continue
+		}
+
+		s.deliver(delivery)
+		processed++
+	}
+	return processed, nil
+}
+
+// deliver envoie une livraison et enregistre le résultat ; un échec est replanifié avec un délai
+// exponentiel jusqu'à MaxAttempts, puis la livraison passe en échec (relivrable manuellement)
+func (s *WebhookService) deliver(delivery *models.WebhookDelivery) {
+	webhook, err := s.GetWebhook(delivery.WebhookID)
+	if err != nil {
+		s.DB.Model(delivery).Updates(map[string]interface{}{"status": models.WebhookDeliveryFailed, "last_error": err.Error()})
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{"attempts": attempts, "updated_at": time.Now()}
+	var statusCode int
+	var sendErr error
+	if !webhook.Enabled {
+		sendErr = errors.New("webhook disabled")
+	} else {
+		start := time.Now()
+		var body string
+		statusCode, body, sendErr = s.post(webhook, delivery)
+		updates["duration_ms"] = time.Since(start).Milliseconds()
+		if statusCode != 0 {
+			updates["response_code"] = statusCode
+			updates["response_body"] = body
+		}
+	}
+
+	webhookUpdates := map[string]interface{}{"last_delivery_at": time.Now()}
+	switch {
+	case sendErr == nil:
+		now := time.Now()
+		updates["status"] = models.WebhookDeliverySucceeded
+		updates["delivered_at"] = now
+		updates["last_error"] = nil
+		webhookUpdates["last_delivery_status"] = models.WebhookDeliverySucceeded
+		webhookUpdates["consecutive_failures"] = 0
+	case webhook.Enabled && attempts < webhook.MaxAttempts:
+		updates["status"] = models.WebhookDeliveryPending
+		updates["next_attempt_at"] = time.Now().Add(webhookBackoff(attempts))
+		updates["last_error"] = sendErr.Error()
+		webhookUpdates["last_delivery_status"] = models.WebhookDeliveryFailed
+		webhookUpdates["consecutive_failures"] = gorm.Expr("consecutive_failures + 1")
+	default:
+		updates["status"] = models.WebhookDeliveryFailed
+		updates["last_error"] = sendErr.Error()
+		webhookUpdates["last_delivery_status"] = models.WebhookDeliveryFailed
+		webhookUpdates["consecutive_failures"] = gorm.Expr("consecutive_failures + 1")
+	}
+
+	s.DB.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(updates)
+	if webhook.Enabled {
+		s.DB.Model(&models.Webhook{}).Where("id = ?", webhook.ID).UpdateColumns(webhookUpdates)
+	}
+}
+
+// post envoie le corps signé ; toute réponse hors 2xx est un échec
+func (s *WebhookService) post(webhook *models.Webhook, delivery *models.WebhookDelivery) (int, string, error) {
+	timestamp := time.Now().Unix()
+	signatures := []string{SignPayload(webhook.Secret, timestamp, delivery.Payload)}
+	if webhook.PreviousSecret != nil && webhook.PreviousSecretExpiresAt != nil && time.Now().Before(*webhook.PreviousSecretExpiresAt) {
+		signatures = append(signatures, SignPayload(*webhook.PreviousSecret, timestamp, delivery.Payload))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Aether-Identity-Webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, strings.Join(signatures, ","))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(body), fmt.Errorf("endpoint responded with HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// webhookSubscribed indique si l'abonnement couvre le type d'événement
+func webhookSubscribed(webhook *models.Webhook, eventType string) bool {
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(webhook.Events, func(event string) bool {
+		if strings.HasSuffix(event, ".") {
+			return strings.HasPrefix(eventType, event)
+		}
+		return event == eventType
+	})
+}
+
+func validateWebhook(webhook *models.Webhook) error {
+	if webhook.Name == "" {
+		return errors.New("name is required")
+	}
+	if !strings.HasPrefix(webhook.URL, "https://") && !strings.HasPrefix(webhook.URL, "http://") {
+		return errors.New("url must be an http(s) URL")
+	}
+	if err := validateWebhookTarget(webhook.URL); err != nil {
+		return err
+	}
+	for _, event := range webhook.Events {
+		if !strings.HasSuffix(event, ".") && !slices.Contains(models.WebhookEventTypes, event) {
+			return fmt.Errorf("unsupported event type: %s", event)
+		}
+	}
+	if webhook.MaxAttempts < 1 || webhook.MaxAttempts > 20 {
+		return errors.New("maxAttempts must be between 1 and 20")
+	}
+	return nil
+}
+
+// validateWebhookTarget refuse une URL dont l'hôte est une adresse IP non publique ou un nom local.
+// Un nom DNS n'est pas résolu ici : webhookClient vérifie l'adresse effectivement contactée à chaque livraison.
+func validateWebhookTarget(rawURL string) error {
+	target, err := url.Parse(rawURL)
+	if err != nil || target.Hostname() == "" {
+		return errors.New("url must be an http(s) URL")
+	}
+	host := strings.TrimSuffix(strings.ToLower(target.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrWebhookTargetForbidden
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !webhookAddressAllowed(addr) {
+		return ErrWebhookTargetForbidden
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	secret, err := GenerateRandomString(24)
+	if err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + secret, nil
+}
+
+// webhookBackoff calcule le délai exponentiel avant la prochaine tentative
+func webhookBackoff(attempt int) time.Duration {
+	delay := webhookBaseBackoff
+	for i := 1; i < attempt && delay < webhookMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxBackoff {
+		delay = webhookMaxBackoff
+	}
+	return delay + rand.N(delay/10+1)
+}
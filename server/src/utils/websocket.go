@@ -0,0 +1,225 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GUID défini par la RFC 6455 pour calculer Sec-WebSocket-Accept
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Opcodes des trames WebSocket
+const (
+	webSocketOpText  = 0x1
+	webSocketOpClose = 0x8
+	webSocketOpPing  = 0x9
+	webSocketOpPong  = 0xA
+)
+
+const (
+	webSocketWriteTimeout   = 10 * time.Second
+	webSocketMaxReadPayload = 64 << 10 // Les clients n'envoient que des trames de contrôle ou de courts messages
+)
+
+var ErrNotWebSocket = errors.New("not a websocket upgrade request")
+
+// IsWebSocketUpgrade indique si la requête demande un passage en WebSocket
+func IsWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") &&
+		headerContainsToken(r.Header.Get("Connection"), "upgrade")
+}
+
+// WebSocketConn est une connexion WebSocket côté serveur (RFC 6455) limitée à l'envoi de messages texte.
+// Les messages reçus du client sont ignorés ; les pings reçoivent un pong et la fermeture est acquittée.
+type WebSocketConn struct {
+	conn   net.Conn
+	rw     *bufio.ReadWriter
+	mu     sync.Mutex // Sérialise les écritures de trames
+	done   chan struct{}
+	closed sync.Once
+}
+
+// UpgradeWebSocket valide la poignée de main, répond 101 et prend le contrôle de la connexion HTTP
+func UpgradeWebSocket(w http.ResponseWriter, r *http.Request) (*WebSocketConn, error) {
+	if r.Method != http.MethodGet || !IsWebSocketUpgrade(r) {
+		return nil, ErrNotWebSocket
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, errors.New("unsupported websocket version, expected 13")
+	}
+	key := strings.TrimSpace(r.Header.Get("Sec-WebSocket-Key"))
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, errors.New("invalid Sec-WebSocket-Key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("connection does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	accept := sha1.Sum([]byte(key + webSocketGUID))
+	_ = conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		base64.StdEncoding.EncodeToString(accept[:]))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	ws := &WebSocketConn{conn: conn, rw: rw, done: make(chan struct{})}
+	go ws.readLoop()
+	return ws, nil
+}
+
+// Done est fermé lorsque la connexion est terminée (fermeture par le client, erreur ou Close)
+func (ws *WebSocketConn) Done() <-chan struct{} {
+	return ws.done
+}
+
+// WriteText envoie un message texte
+func (ws *WebSocketConn) WriteText(data []byte) error {
+	return ws.writeFrame(webSocketOpText, data)
+}
+
+// WritePing envoie un ping pour maintenir la connexion ouverte
+func (ws *WebSocketConn) WritePing() error {
+	return ws.writeFrame(webSocketOpPing, nil)
+}
+
+// Close envoie une trame de fermeture avec le code et la raison donnés puis ferme la connexion
+func (ws *WebSocketConn) Close(code uint16, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, code)
+	payload = append(payload, truncateUTF8(reason, 123)...)
+	err := ws.writeFrame(webSocketOpClose, payload)
+	ws.shutdown()
+	return err
+}
+
+func (ws *WebSocketConn) shutdown() {
+	ws.closed.Do(func() {
+		close(ws.done)
+		ws.conn.Close()
+	})
+}
+
+// writeFrame écrit une trame finale non masquée, comme l'exige la RFC pour le serveur
+func (ws *WebSocketConn) writeFrame(opcode byte, payload []byte) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	select {
+	case <-ws.done:
+		return net.ErrClosed
+	default:
+	}
+
+	header := []byte{0x80 | opcode}
+	switch length := len(payload); {
+	case length < 126:
+		header = append(header, byte(length))
+	case length <= 0xFFFF:
+		header = binary.BigEndian.AppendUint16(append(header, 126), uint16(length))
+	default:
+		header = binary.BigEndian.AppendUint64(append(header, 127), uint64(length))
+	}
+
+	_ = ws.conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+	if _, err := ws.rw.Write(header); err != nil {
+		return err
+	}
+	if _, err := ws.rw.Write(payload); err != nil {
+		return err
+	}
+	return ws.rw.Flush()
+}
+
+// readLoop lit les trames du client jusqu'à la fermeture
+func (ws *WebSocketConn) readLoop() {
+	defer ws.shutdown()
+
+	header := make([]byte, 2)
+	for {
+		if _, err := io.ReadFull(ws.rw, header); err != nil {
+			return
+		}
+		opcode := header[0] & 0x0F
+		masked := header[1]&0x80 != 0
+		length := uint64(header[1] & 0x7F)
+		switch length {
+		case 126:
+			extended := make([]byte, 2)
+			if _, err := io.ReadFull(ws.rw, extended); err != nil {
+				return
+			}
+			length = uint64(binary.BigEndian.Uint16(extended))
+		case 127:
+			extended := make([]byte, 8)
+			if _, err := io.ReadFull(ws.rw, extended); err != nil {
+				return
+			}
+			length = binary.BigEndian.Uint64(extended)
+		}
+		// Les trames du client doivent être masquées
+		if !masked || length > webSocketMaxReadPayload {
+			_ = ws.Close(1002, "protocol error")
+			return
+		}
+
+		mask := make([]byte, 4)
+		if _, err := io.ReadFull(ws.rw, mask); err != nil {
+			return
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(ws.rw, payload); err != nil {
+			return
+		}
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+
+		switch opcode {
+		case webSocketOpPing:
+			if err := ws.writeFrame(webSocketOpPong, payload); err != nil {
+				return
+			}
+		case webSocketOpClose:
+			_ = ws.writeFrame(webSocketOpClose, payload[:min(len(payload), 2)])
+			return
+		}
+	}
+}
+
+// headerContainsToken indique si une liste d'en-tête séparée par des virgules contient le jeton
+func headerContainsToken(header, token string) bool {
+	for _, part := range strings.Split(header, ",") {
+		if strings.EqualFold(strings.TrimSpace(part), token) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateUTF8 tronque une chaîne à au plus limit octets sans couper de caractère
+func truncateUTF8(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	for limit > 0 && value[limit]&0xC0 == 0x80 {
+		limit--
+	}
+	return value[:limit]
+}